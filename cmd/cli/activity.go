@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	activityRecentDays   int
+	activityBaselineDays int
+	activityStatuses     []string
+)
+
+var showActivityCmd = &cobra.Command{
+	Use:   "activity [org]",
+	Short: "Classify repositories as active, slowing, dormant or dead",
+	Long: `Classify the repositories of an organization or user by their recent activity compared
+with their own past, to find repositories to archive or clean up. Commits, pull requests and
+deploys in the recent window, ending at --end (now by default), are compared with those in
+the baseline window before it:
+
+  active   as active as in the baseline window, or more; also new repositories
+  slowing  active at less than half the daily rate of the baseline window
+  dormant  no activity in the recent window, but some in the baseline window
+  dead     no activity in either window
+
+Repositories stored by past collections are listed even without events. Repositories
+needing attention come first.`,
+	Example: `  github-metrics show activity my-org
+  github-metrics show activity my-org --recent 14 --baseline 90 --status dead,dormant`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowActivity),
+}
+
+func init() {
+	showActivityCmd.Flags().IntVar(&activityRecentDays, "recent", int(domain.DefaultRecentWindow/(24*time.Hour)), "length of the recent window in days")
+	showActivityCmd.Flags().IntVar(&activityBaselineDays, "baseline", int(domain.DefaultBaselineWindow/(24*time.Hour)), "length of the baseline window before it in days")
+	showActivityCmd.Flags().StringSliceVar(&activityStatuses, "status", nil, "only repositories with these statuses (active, slowing, dormant, dead)")
+
+	showCmd.AddCommand(showActivityCmd)
+}
+
+func runShowActivity(cmd *cobra.Command, args []string) error {
+	org := args[0]
+	if activityRecentDays <= 0 || activityBaselineDays <= 0 {
+		return fmt.Errorf("--recent and --baseline must be positive numbers of days")
+	}
+	only := make(map[domain.RepoActivityStatus]bool)
+	for _, s := range activityStatuses {
+		status := domain.RepoActivityStatus(s)
+		valid := false
+		for _, known := range domain.RepoActivityStatuses {
+			valid = valid || status == known
+		}
+		if !valid {
+			return fmt.Errorf("unknown status %q: must be one of active, slowing, dormant, dead", s)
+		}
+		only[status] = true
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	report, err := agg.GetRepoActivity(context.Background(), org, domain.RepoActivityQuery{
+		End:      getTimeRange().End,
+		Recent:   time.Duration(activityRecentDays) * 24 * time.Hour,
+		Baseline: time.Duration(activityBaselineDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get repository activity: %w", err)
+	}
+
+	if len(only) > 0 {
+		kept := report.Repos[:0]
+		for _, r := range report.Repos {
+			if only[r.Status] {
+				kept = append(kept, r)
+			}
+		}
+		report.Repos = kept
+	}
+
+	table := viewTable{Header: []string{"Repository", "Status", "Recent", "Baseline", "Rate vs Baseline", "Last Activity"}}
+	for _, r := range report.Repos {
+		ratio, last := "-", "-"
+		if r.Ratio > 0 {
+			ratio = fmt.Sprintf("%.0f%%", r.Ratio*100)
+		}
+		if r.LastActivity != nil {
+			last = r.LastActivity.Format("2006-01-02")
+		}
+		table.Rows = append(table.Rows, []string{
+			r.Repo,
+			string(r.Status),
+			fmt.Sprintf("%d", r.RecentEvents),
+			fmt.Sprintf("%d", r.BaselineEvents),
+			ratio,
+			last,
+		})
+	}
+	return render(&view{
+		Title:     "Repository Activity: " + org,
+		TimeRange: report.Recent,
+		Tables:    []viewTable{table},
+		Value:     report,
+		After: func(w io.Writer) {
+			fmt.Fprintf(w, "\nBaseline: %s to %s. ", report.Baseline.Start.Format("2006-01-02"), report.Baseline.End.Format("2006-01-02"))
+			for i, status := range domain.RepoActivityStatuses {
+				if i > 0 {
+					fmt.Fprint(w, ", ")
+				}
+				fmt.Fprintf(w, "%d %s", report.Counts[status], status)
+			}
+			fmt.Fprintln(w)
+		},
+	})
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	apiKeyScope     string
+	apiKeyOwners    []string
+	apiKeyRole      string
+	apiKeyWorkspace string
+	viewerTokenTTL  time.Duration
+)
+
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage API keys",
+	Long:  `Create, list and revoke the API keys accepted by the API server when API_AUTH_ENABLED is set.`,
+}
+
+var apiKeyCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create an API key",
+	Long:  `Generate a new API key. The key is printed once; only its hash is stored.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAPIKeyCreate,
+}
+
+var apiKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API keys",
+	Args:  cobra.NoArgs,
+	RunE:  runAPIKeyList,
+}
+
+var apiKeyGrantCmd = &cobra.Command{
+	Use:   "grant [name] [owner]",
+	Short: "Grant an API key access to an organization or user",
+	Long:  `Give an API key a role (viewer or admin) for an organization or user. Use "*" as the owner to grant access to every owner.`,
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAPIKeyGrant,
+}
+
+var apiKeyUngrantCmd = &cobra.Command{
+	Use:   "ungrant [name] [owner]",
+	Short: "Remove an API key's access to an organization or user",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAPIKeyUngrant,
+}
+
+var apiKeyViewerTokenCmd = &cobra.Command{
+	Use:   "viewer-token [owner]",
+	Short: "Issue a read-only viewer token for an organization or user",
+	Long: `Issue a signed, expiring token giving read-only access to a single organization or user,
+for sharing dashboard links. The token is signed with VIEWER_TOKEN_SECRET, which must match the
+API server's; tokens cannot be revoked individually, change the secret to revoke all of them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAPIKeyViewerToken,
+}
+
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke [name]",
+	Short: "Revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAPIKeyRevoke,
+}
+
+func init() {
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyScope, "scope", string(domain.APIKeyScopeRead), "key scope (read, admin)")
+	apiKeyCreateCmd.Flags().StringSliceVar(&apiKeyOwners, "owner", []string{domain.AllOwners}, "organizations/users the key may view (repeatable, * for all)")
+	apiKeyCreateCmd.Flags().StringVar(&apiKeyWorkspace, "workspace", "", "confine the key to a workspace (only its owners, via /api/v1/workspaces/<id>)")
+	apiKeyViewerTokenCmd.Flags().DurationVar(&viewerTokenTTL, "ttl", 7*24*time.Hour, "how long the token is valid")
+	apiKeyGrantCmd.Flags().StringVar(&apiKeyRole, "role", string(domain.RoleViewer), "role for the owner (viewer, admin)")
+
+	rootCmd.AddCommand(apiKeyCmd)
+	apiKeyCmd.AddCommand(apiKeyCreateCmd)
+	apiKeyCmd.AddCommand(apiKeyListCmd)
+	apiKeyCmd.AddCommand(apiKeyGrantCmd)
+	apiKeyCmd.AddCommand(apiKeyUngrantCmd)
+	apiKeyCmd.AddCommand(apiKeyRevokeCmd)
+	apiKeyCmd.AddCommand(apiKeyViewerTokenCmd)
+}
+
+func runAPIKeyCreate(cmd *cobra.Command, args []string) error {
+	scope := domain.APIKeyScope(apiKeyScope)
+	if !scope.IsValid() {
+		return fmt.Errorf("invalid scope %q: must be 'read' or 'admin'", apiKeyScope)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := "gam_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	ctx := context.Background()
+	if apiKeyWorkspace != "" {
+		ws, err := store.GetWorkspace(ctx, apiKeyWorkspace)
+		if err != nil {
+			return fmt.Errorf("failed to get workspace: %w", err)
+		}
+		if ws == nil {
+			return fmt.Errorf("workspace %q does not exist", apiKeyWorkspace)
+		}
+	}
+
+	if err := store.SaveAPIKey(ctx, &domain.APIKey{
+		Name:      args[0],
+		KeyHash:   domain.HashAPIKey(key),
+		Scope:     scope,
+		Workspace: apiKeyWorkspace,
+	}); err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	for _, owner := range apiKeyOwners {
+		grant := &domain.OwnerGrant{KeyName: args[0], Owner: owner, Role: domain.RoleViewer}
+		if err := store.SaveAPIKeyGrant(ctx, grant); err != nil {
+			return fmt.Errorf("failed to grant access to %s: %w", owner, err)
+		}
+	}
+
+	fmt.Printf("Created %s key %q. Store it now, it will not be shown again:\n\n%s\n", scope, args[0], key)
+	return nil
+}
+
+func runAPIKeyList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	keys, err := store.ListAPIKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Scope", "Workspace", "Access", "Created"})
+	for _, k := range keys {
+		grants, err := store.GetAPIKeyGrants(ctx, k.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get grants for %s: %w", k.Name, err)
+		}
+		access := make([]string, 0, len(grants))
+		for _, g := range grants {
+			access = append(access, fmt.Sprintf("%s (%s)", g.Owner, g.Role))
+		}
+		table.Append([]string{k.Name, string(k.Scope), k.Workspace, strings.Join(access, ", "), k.CreatedAt.Format("2006-01-02 15:04")})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runAPIKeyGrant(cmd *cobra.Command, args []string) error {
+	role := domain.Role(apiKeyRole)
+	if !role.IsValid() {
+		return fmt.Errorf("invalid role %q: must be 'viewer' or 'admin'", apiKeyRole)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	keys, err := store.ListAPIKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+	if !slices.ContainsFunc(keys, func(k *domain.APIKey) bool { return k.Name == args[0] }) {
+		return fmt.Errorf("API key %q does not exist", args[0])
+	}
+
+	grant := &domain.OwnerGrant{KeyName: args[0], Owner: args[1], Role: role}
+	if err := store.SaveAPIKeyGrant(ctx, grant); err != nil {
+		return fmt.Errorf("failed to grant access: %w", err)
+	}
+
+	fmt.Printf("Granted %s access to %s for API key %q\n", role, args[1], args[0])
+	return nil
+}
+
+func runAPIKeyUngrant(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.DeleteAPIKeyGrant(context.Background(), args[0], args[1]); err != nil {
+		return fmt.Errorf("failed to remove access: %w", err)
+	}
+
+	fmt.Printf("Removed access to %s for API key %q\n", args[1], args[0])
+	return nil
+}
+
+func runAPIKeyRevoke(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.DeleteAPIKey(context.Background(), args[0]); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	fmt.Printf("Revoked API key %q\n", args[0])
+	return nil
+}
+
+func runAPIKeyViewerToken(cmd *cobra.Command, args []string) error {
+	owner := args[0]
+	if owner == domain.AllOwners {
+		return fmt.Errorf("viewer tokens are scoped to a single organization or user")
+	}
+	if viewerTokenTTL <= 0 {
+		return fmt.Errorf("--ttl must be positive")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.ViewerTokenSecret == "" {
+		return fmt.Errorf("VIEWER_TOKEN_SECRET is not set")
+	}
+
+	expiresAt := time.Now().Add(viewerTokenTTL).UTC().Truncate(time.Second)
+	token, err := domain.ViewerToken{Owner: owner, ExpiresAt: expiresAt}.Sign([]byte(cfg.ViewerTokenSecret))
+	if err != nil {
+		return fmt.Errorf("failed to sign viewer token: %w", err)
+	}
+
+	fmt.Printf("Viewer token for %s, valid until %s:\n\n%s\n", owner, expiresAt.Format(time.RFC3339), token)
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var (
+	backupOut  string
+	restoreIn  string
+	restoreYes bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a backup of the database to a file",
+	Long: `Write every event, repository, member, team, collection batch, API key, workspace and
+audit log entry to a file, e.g. to snapshot the data before an upgrade or to move it to
+another machine. The rows are read as of one point in time, so collections may keep running.
+
+The backup is JSON lines, gzip-compressed when the file name ends in .gz, and can be restored
+into either SQLite or PostgreSQL with "restore". Use - to write it to standard output.`,
+	Example: `  github-metrics backup --out metrics-backup.jsonl.gz
+  github-metrics backup --out - | ssh other-host github-metrics restore --in - --yes`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runBackup,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Replace the database contents with a backup",
+	Long: `Replace all data in the database with a backup written by "backup". The backup is
+loaded in one transaction, so the data is left as it was if it fails. Compressed backups are
+detected automatically; use - to read the backup from standard input.
+
+The database schema must be at least as new as the backup's. The command asks for
+confirmation unless --yes is given.`,
+	Example:      `  github-metrics restore --in metrics-backup.jsonl.gz`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runRestore,
+}
+
+func init() {
+	backupCmd.Flags().StringVarP(&backupOut, "out", "o", "", "backup file; .gz compresses it, - writes to standard output (required)")
+	backupCmd.MarkFlagRequired("out")
+	restoreCmd.Flags().StringVarP(&restoreIn, "in", "i", "", "backup file, or - for standard input (required)")
+	restoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "do not ask for confirmation")
+	restoreCmd.MarkFlagRequired("in")
+
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
+
+// backupResult is what a backup holds, or what a restore loaded, in JSON output
+type backupResult struct {
+	File          string           `json:"file"`
+	Storage       string           `json:"storage"` // storage the backup was taken from
+	SchemaVersion int              `json:"schema_version"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Rows          int64            `json:"rows"`
+	Tables        map[string]int64 `json:"tables"`
+}
+
+func newBackupResult(file string, summary *storage.BackupSummary) *backupResult {
+	return &backupResult{
+		File:          file,
+		Storage:       summary.Storage,
+		SchemaVersion: summary.SchemaVersion,
+		CreatedAt:     summary.CreatedAt,
+		Rows:          summary.TotalRows(),
+		Tables:        summary.Rows,
+	}
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	// A file is written under a temporary name and renamed once complete, so an interrupted
+	// backup never replaces a good one
+	var out io.Writer = os.Stdout
+	var tmp *os.File
+	if backupOut != "-" {
+		tmp, err = os.CreateTemp(filepath.Dir(backupOut), "."+filepath.Base(backupOut)+".*")
+		if err != nil {
+			return fmt.Errorf("failed to create backup file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		out = tmp
+	}
+	buf := bufio.NewWriter(out)
+	var w io.Writer = buf
+	var zw *gzip.Writer
+	if strings.HasSuffix(backupOut, ".gz") {
+		zw = gzip.NewWriter(buf)
+		w = zw
+	}
+
+	summary, err := store.Backup(context.Background(), w)
+	if err != nil {
+		return err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	result := newBackupResult(backupOut, summary)
+	if tmp == nil {
+		// Standard output holds the backup itself
+		slog.Info("backup written", "rows", result.Rows, "schema_version", result.SchemaVersion)
+		return nil
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), backupOut); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if outputJSON {
+		return printJSON(result)
+	}
+	fmt.Printf("Backed up %d rows of %d tables to %s (schema version %d)\n", result.Rows, len(result.Tables), backupOut, result.SchemaVersion)
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var in io.Reader = os.Stdin
+	if restoreIn != "-" {
+		f, err := os.Open(restoreIn)
+		if err != nil {
+			return fmt.Errorf("failed to open backup: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	r := bufio.NewReader(in)
+	var backup io.Reader = r
+	// gzip streams start with 1f 8b
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		defer zr.Close()
+		backup = zr
+	}
+
+	if !restoreYes {
+		location := cfg.SQLitePath
+		if cfg.StorageType == "postgres" {
+			location = "the PostgreSQL database"
+		}
+		if err := confirmDestructive(fmt.Sprintf("Replace all data in %s with the backup %s?", location, restoreIn), "restore"); err != nil {
+			return err
+		}
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	summary, err := store.Restore(context.Background(), backup)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", restoreIn, err)
+	}
+
+	result := newBackupResult(restoreIn, summary)
+	if outputJSON {
+		return printJSON(result)
+	}
+	fmt.Printf("Restored %d rows of %d tables from %s (%s backup of %s, schema version %d)\n",
+		result.Rows, len(result.Tables), restoreIn, result.Storage, result.CreatedAt.Local().Format("2006-01-02 15:04"), result.SchemaVersion)
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/badge"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+)
+
+var (
+	badgeMetric string
+	badgeOut    string
+	badgeLabel  string
+	badgeColor  string
+)
+
+var badgeCmd = &cobra.Command{
+	Use:   "badge [org]",
+	Short: "Generate a shields.io-style SVG badge of a metric",
+	Long: `Draw a badge such as "commits | 1.2k" of an organization's or user's metric over --start/--end
+(the last month by default), for embedding in READMEs. Without --out the SVG is written to
+standard output. The API serves the same badges at /api/v1/orgs/{org}/badge/{metric}.`,
+	Example: `  github-metrics badge my-org --metric commits --out badge.svg
+  github-metrics badge my-org --metric deploys --label "deploys this month" --color brightgreen -o deploys.svg`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runBadge,
+}
+
+func init() {
+	badgeCmd.Flags().StringVar(&badgeMetric, "metric", "commits", "metric shown (commits, prs, additions, deletions, deploys, members, repos)")
+	badgeCmd.Flags().StringVarP(&badgeOut, "out", "o", "", "SVG file to write (default standard output)")
+	badgeCmd.Flags().StringVar(&badgeLabel, "label", "", "text of the left half (default the metric name)")
+	badgeCmd.Flags().StringVar(&badgeColor, "color", badge.DefaultColor, "color of the value: a name such as green, or hex such as #4c1")
+
+	rootCmd.AddCommand(badgeCmd)
+}
+
+func runBadge(cmd *cobra.Command, args []string) error {
+	owner := args[0]
+	path, err := outputPath("out", badgeOut)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	metrics, err := newAggregator(cfg, store).AggregateOrgMetrics(context.Background(), owner, getTimeRange())
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+	b, err := badge.ForMetric(metrics, badgeMetric, badgeLabel, badgeColor)
+	if err != nil {
+		return err
+	}
+
+	err = writeOutput(path, func(w io.Writer) error {
+		_, err := w.Write(b.SVG())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !toStdout(path) {
+		fmt.Fprintf(os.Stderr, "Wrote %s badge (%s) to %s\n", b.Label, b.Message, path)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	batchesStatus string
+	batchesLimit  int
+	batchesFailed bool
+)
+
+var batchesCmd = &cobra.Command{
+	Use:   "batches [org]",
+	Short: "List collection batches and their progress",
+	Long: `List the collection batches of one owner or, without an argument, of every owner, newest
+first, with their status, date range, how many repositories completed and how many failed.
+Use "batches show <id>" for the status of each repository of a batch.`,
+	Example: `  github-metrics batches my-org
+  github-metrics batches --status failed
+  github-metrics batches show organization-my-org-1717200000-1719792000`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runBatches,
+}
+
+var batchesShowCmd = &cobra.Command{
+	Use:          "show <id>",
+	Short:        "Show the status of each repository of a collection batch",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runBatchesShow,
+}
+
+func init() {
+	batchesCmd.Flags().StringVar(&batchesStatus, "status", "", "only batches with this status (in_progress, completed, failed)")
+	batchesCmd.Flags().IntVar(&batchesLimit, "limit", 20, "maximum number of batches; 0 for all")
+	batchesShowCmd.Flags().BoolVar(&batchesFailed, "failed", false, "only list the repositories that failed")
+
+	batchesCmd.AddCommand(batchesShowCmd)
+	rootCmd.AddCommand(batchesCmd)
+}
+
+// batchJSON is a collection batch in JSON output
+type batchJSON struct {
+	ID        string          `json:"id"`
+	Mode      string          `json:"mode"`
+	Owner     string          `json:"owner"`
+	StartDate string          `json:"start_date"`
+	EndDate   string          `json:"end_date"`
+	Status    string          `json:"status"`
+	Total     int             `json:"total_repos"`
+	Completed int             `json:"completed_repos"`
+	Failed    int             `json:"failed_repos"`
+	Pending   int             `json:"pending_repos"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Repos     []batchRepoJSON `json:"repos,omitempty"`
+}
+
+// batchRepoJSON is the status of a repository of a batch in JSON output
+type batchRepoJSON struct {
+	Repo      string    `json:"repo"`
+	Status    string    `json:"status"`
+	Events    int       `json:"events"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newBatchJSON(batch *domain.CollectionBatch, progress domain.BatchProgress) batchJSON {
+	return batchJSON{
+		ID:        batch.ID,
+		Mode:      batch.Mode,
+		Owner:     batch.Owner,
+		StartDate: batch.StartDate.Format("2006-01-02"),
+		EndDate:   batch.EndDate.Format("2006-01-02"),
+		Status:    batch.Status,
+		Total:     progress.Total,
+		Completed: progress.Completed,
+		Failed:    progress.Failed,
+		Pending:   progress.Pending + progress.Processing,
+		CreatedAt: batch.CreatedAt,
+		UpdatedAt: batch.UpdatedAt,
+	}
+}
+
+func runBatches(cmd *cobra.Command, args []string) error {
+	switch batchesStatus {
+	case "", "in_progress", "completed", "failed":
+	default:
+		return fmt.Errorf("invalid --status %q: must be in_progress, completed or failed", batchesStatus)
+	}
+	if batchesLimit < 0 {
+		return fmt.Errorf("invalid --limit %d: must not be negative", batchesLimit)
+	}
+	filter := domain.BatchFilter{Status: batchesStatus, Limit: batchesLimit}
+	if len(args) == 1 {
+		filter.Owner = args[0]
+	}
+
+	store, err := openStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	batches, err := store.ListBatches(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to list batches: %w", err)
+	}
+
+	list := make([]batchJSON, 0, len(batches))
+	for _, batch := range batches {
+		statuses, err := store.GetBatchRepoStatuses(ctx, batch.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read batch %s: %w", batch.ID, err)
+		}
+		list = append(list, newBatchJSON(batch, domain.SummarizeBatchRepos(statuses)))
+	}
+
+	if outputJSON {
+		return printJSON(list)
+	}
+	if len(list) == 0 {
+		fmt.Println("No collection batches")
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Owner", "Range", "Status", "Repositories", "Failed", "Updated"})
+	for _, b := range list {
+		table.Append([]string{
+			b.ID, b.Owner, b.StartDate + " - " + b.EndDate, b.Status,
+			fmt.Sprintf("%d/%d", b.Completed, b.Total), fmt.Sprintf("%d", b.Failed),
+			b.UpdatedAt.Local().Format("2006-01-02 15:04"),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func runBatchesShow(cmd *cobra.Command, args []string) error {
+	store, err := openStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	batch, err := store.GetBatch(ctx, args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("batch %q not found: list the batches with \"github-metrics batches\"", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read batch: %w", err)
+	}
+	statuses, err := store.GetBatchRepoStatuses(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read batch %s: %w", batch.ID, err)
+	}
+
+	detail := newBatchJSON(batch, domain.SummarizeBatchRepos(statuses))
+	for _, st := range statuses {
+		if batchesFailed && st.Status != domain.BatchRepoStatusFailed {
+			continue
+		}
+		detail.Repos = append(detail.Repos, batchRepoJSON{Repo: st.Repo, Status: st.Status, Events: st.Events, Error: st.Error, UpdatedAt: st.UpdatedAt})
+	}
+
+	if outputJSON {
+		return printJSON(detail)
+	}
+	fmt.Printf("Batch:        %s\n", detail.ID)
+	fmt.Printf("Owner:        %s (%s)\n", detail.Owner, detail.Mode)
+	fmt.Printf("Range:        %s - %s\n", detail.StartDate, detail.EndDate)
+	fmt.Printf("Status:       %s\n", detail.Status)
+	fmt.Printf("Repositories: %d completed, %d failed, %d pending of %d\n", detail.Completed, detail.Failed, detail.Pending, detail.Total)
+	fmt.Printf("Updated:      %s\n\n", detail.UpdatedAt.Local().Format("2006-01-02 15:04"))
+
+	if len(detail.Repos) == 0 {
+		return nil
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Repository", "Status", "Events", "Error"})
+	for _, r := range detail.Repos {
+		table.Append([]string{r.Repo, r.Status, fmt.Sprintf("%d", r.Events), r.Error})
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// Chart size in pixels, a 2:1 picture that fits a 16:9 slide with a title
+const (
+	chartWidth  = 1200
+	chartHeight = 600
+)
+
+// chartColors are the series colors, so a metric has the same color in every chart
+var chartColors = map[string]drawing.Color{
+	"Commits":       drawing.ColorFromHex("1f77b4"),
+	"Pull requests": drawing.ColorFromHex("ff7f0e"),
+	"Deployments":   drawing.ColorFromHex("2ca02c"),
+}
+
+// renderable is a go-chart chart
+type renderable interface {
+	Render(rp chart.RendererProvider, w io.Writer) error
+}
+
+// writeChart renders c to path as SVG when the path ends in .svg, or else as PNG
+func writeChart(path string, c renderable) error {
+	renderer := chart.PNG
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		renderer = chart.SVG
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create chart file: %w", err)
+	}
+	if err := c.Render(renderer, f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to render chart: %w", err)
+	}
+	return f.Close()
+}
+
+// timeSeriesChart draws commits, pull requests and deployments per period as lines
+func timeSeriesChart(title string, series *domain.DetailedTimeSeriesData) (*chart.Chart, error) {
+	if len(series.DataPoints) < 2 {
+		return nil, fmt.Errorf("a chart needs at least two periods, got %d", len(series.DataPoints))
+	}
+
+	times := make([]time.Time, len(series.DataPoints))
+	lines := []struct {
+		name   string
+		values []float64
+	}{
+		{"Commits", make([]float64, len(series.DataPoints))},
+		{"Pull requests", make([]float64, len(series.DataPoints))},
+		{"Deployments", make([]float64, len(series.DataPoints))},
+	}
+	peak := 0.0
+	for i, p := range series.DataPoints {
+		times[i] = p.Timestamp
+		lines[0].values[i] = float64(p.Commits)
+		lines[1].values[i] = float64(p.PRs)
+		lines[2].values[i] = float64(p.Deploys)
+		peak = max(peak, float64(p.Commits), float64(p.PRs), float64(p.Deploys))
+	}
+
+	dateFormat := "2006-01-02"
+	if series.Granularity == "month" {
+		dateFormat = "2006-01"
+	}
+	c := &chart.Chart{
+		Title:  title,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 60, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: chart.XAxis{ValueFormatter: chart.TimeValueFormatterWithFormat(dateFormat)},
+		YAxis: countAxis(peak),
+	}
+	for _, line := range lines {
+		c.Series = append(c.Series, chart.TimeSeries{
+			Name:    line.name,
+			XValues: times,
+			YValues: line.values,
+			Style:   chart.Style{StrokeColor: chartColors[line.name], StrokeWidth: 2},
+		})
+	}
+	// The legend goes in a row of its own between the title and the plot
+	c.Background.Padding.Top = 100
+	c.Elements = []chart.Renderable{chart.LegendThin(c)}
+	return c, nil
+}
+
+// contributorsChart draws the commits of each member as bars
+func contributorsChart(title string, members []*domain.MemberMetrics) (*chart.BarChart, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("a chart needs at least one contributor")
+	}
+
+	peak := 0.0
+	bars := make([]chart.Value, 0, len(members))
+	for _, m := range members {
+		peak = max(peak, float64(m.Commits))
+		bars = append(bars, chart.Value{
+			Label: m.Member,
+			Value: float64(m.Commits),
+			Style: chart.Style{FillColor: chartColors["Commits"], StrokeColor: chartColors["Commits"]},
+		})
+	}
+
+	// Bars share the plot width, with a third of each slot as the gap between them
+	slot := (chartWidth - 120) / len(bars)
+	return &chart.BarChart{
+		Title:  title,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 60, Left: 20, Right: 20, Bottom: 20},
+		},
+		BarWidth:   slot * 2 / 3,
+		BarSpacing: slot / 3,
+		XAxis:      chart.Shown(),
+		YAxis:      countAxis(peak),
+		Bars:       bars,
+	}, nil
+}
+
+// countAxis returns a Y axis for counts up to peak, with about ten whole-number ticks from
+// zero. An all-zero series still gets a range to draw in.
+func countAxis(peak float64) chart.YAxis {
+	step := max(1, math.Ceil(peak/10))
+	var ticks []chart.Tick
+	for v := 0.0; ; v += step {
+		ticks = append(ticks, chart.Tick{Value: v, Label: strconv.FormatFloat(v, 'f', 0, 64)})
+		if v >= peak {
+			break
+		}
+	}
+	return chart.YAxis{
+		Style: chart.Shown(),
+		Range: &chart.ContinuousRange{Min: 0, Max: ticks[len(ticks)-1].Value},
+		Ticks: ticks,
+	}
+}
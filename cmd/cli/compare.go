@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	comparePeriod1 string
+	comparePeriod2 string
+	compareRepos   []string
+	compareMembers []string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare [org]",
+	Short: "Compare periods, repositories or members side by side",
+	Long: `Print metrics side by side with the difference between them.
+
+With --period1 and --period2 (START..END, both dates included) the two periods are compared:
+the organization totals, or each repository and member given with --repos/--members.
+
+Without periods, the repositories and members given with --repos/--members are compared with
+each other over --start/--end, each against the first one listed.`,
+	Example: `  github-metrics compare my-org --period1 2024-01-01..2024-03-31 --period2 2024-04-01..2024-06-30
+  github-metrics compare my-org --repos api,web --start 2024-01-01
+  github-metrics compare my-org --period1 2024-01-01..2024-01-31 --period2 2024-02-01..2024-02-29 --members alice`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCompare,
+}
+
+func init() {
+	compareCmd.Flags().StringVar(&comparePeriod1, "period1", "", "first period (YYYY-MM-DD..YYYY-MM-DD)")
+	compareCmd.Flags().StringVar(&comparePeriod2, "period2", "", "second period (YYYY-MM-DD..YYYY-MM-DD)")
+	compareCmd.Flags().StringSliceVar(&compareRepos, "repos", nil, "repositories to compare (comma-separated)")
+	compareCmd.Flags().StringSliceVar(&compareMembers, "members", nil, "members to compare (comma-separated)")
+
+	rootCmd.AddCommand(compareCmd)
+}
+
+// comparisonTable is a set of metrics compared side by side
+type comparisonTable struct {
+	Title   string             `json:"title"`
+	Columns []comparisonColumn `json:"columns"`
+}
+
+// comparisonColumn is one side of a comparison
+type comparisonColumn struct {
+	Label     string `json:"label"`
+	Commits   int64  `json:"commits"`
+	PRs       int64  `json:"prs"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	Deploys   int64  `json:"deploys"`
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	if (comparePeriod1 == "") != (comparePeriod2 == "") {
+		return fmt.Errorf("--period1 and --period2 must be given together")
+	}
+	byPeriod := comparePeriod1 != ""
+	if !byPeriod && len(compareRepos)+len(compareMembers) < 2 {
+		return fmt.Errorf("nothing to compare: give --period1 and --period2, or at least two of --repos/--members")
+	}
+
+	var period1, period2 domain.TimeRange
+	if byPeriod {
+		var err error
+		if period1, err = parsePeriod(comparePeriod1); err != nil {
+			return fmt.Errorf("invalid --period1: %w", err)
+		}
+		if period2, err = parsePeriod(comparePeriod2); err != nil {
+			return fmt.Errorf("invalid --period2: %w", err)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	ctx := context.Background()
+
+	var tables []comparisonTable
+	if byPeriod {
+		label1, label2 := formatPeriod(period1), formatPeriod(period2)
+		if len(compareRepos)+len(compareMembers) == 0 {
+			table := comparisonTable{Title: "Organization: " + org}
+			for _, period := range []domain.TimeRange{period1, period2} {
+				m, err := agg.AggregateOrgMetrics(ctx, org, period)
+				if err != nil {
+					return fmt.Errorf("failed to get metrics: %w", err)
+				}
+				table.Columns = append(table.Columns, comparisonColumn{
+					Label: formatPeriod(period), Commits: m.Commits, PRs: m.PRs,
+					Additions: m.Additions, Deletions: m.Deletions, Deploys: m.Deploys,
+				})
+			}
+			tables = append(tables, table)
+		} else {
+			first, err := agg.Compare(ctx, org, compareRepos, compareMembers, period1)
+			if err != nil {
+				return fmt.Errorf("failed to compare: %w", err)
+			}
+			second, err := agg.Compare(ctx, org, compareRepos, compareMembers, period2)
+			if err != nil {
+				return fmt.Errorf("failed to compare: %w", err)
+			}
+			for i, r := range first.Repos {
+				tables = append(tables, comparisonTable{
+					Title:   "Repository: " + r.Repo,
+					Columns: []comparisonColumn{repoColumn(label1, r.Metrics), repoColumn(label2, second.Repos[i].Metrics)},
+				})
+			}
+			for i, m := range first.Members {
+				tables = append(tables, comparisonTable{
+					Title:   "Member: " + m.Member,
+					Columns: []comparisonColumn{memberColumn(label1, m.Metrics), memberColumn(label2, second.Members[i].Metrics)},
+				})
+			}
+		}
+	} else {
+		timeRange := getTimeRange()
+		comparison, err := agg.Compare(ctx, org, compareRepos, compareMembers, timeRange)
+		if err != nil {
+			return fmt.Errorf("failed to compare: %w", err)
+		}
+		table := comparisonTable{Title: fmt.Sprintf("%s, %s", org, formatPeriod(timeRange))}
+		for _, r := range comparison.Repos {
+			table.Columns = append(table.Columns, repoColumn(r.Repo, r.Metrics))
+		}
+		for _, m := range comparison.Members {
+			table.Columns = append(table.Columns, memberColumn(m.Member, m.Metrics))
+		}
+		tables = append(tables, table)
+	}
+
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tables)
+	}
+
+	for _, t := range tables {
+		printComparisonTable(t)
+	}
+	return nil
+}
+
+// parsePeriod parses a START..END period; both dates are included
+func parsePeriod(s string) (domain.TimeRange, error) {
+	startStr, endStr, ok := strings.Cut(s, "..")
+	if !ok {
+		return domain.TimeRange{}, fmt.Errorf("%q: must be YYYY-MM-DD..YYYY-MM-DD", s)
+	}
+	start, err := time.Parse("2006-01-02", startStr)
+	if err != nil {
+		return domain.TimeRange{}, fmt.Errorf("%q: invalid start date", s)
+	}
+	end, err := time.Parse("2006-01-02", endStr)
+	if err != nil {
+		return domain.TimeRange{}, fmt.Errorf("%q: invalid end date", s)
+	}
+	if end.Before(start) {
+		return domain.TimeRange{}, fmt.Errorf("%q: end is before start", s)
+	}
+	return domain.TimeRange{
+		Start:       start,
+		End:         end.AddDate(0, 0, 1).Add(-time.Nanosecond),
+		Granularity: granularity,
+	}, nil
+}
+
+// formatPeriod formats a time range as the dates it covers
+func formatPeriod(timeRange domain.TimeRange) string {
+	return timeRange.Start.Format("2006-01-02") + " to " + timeRange.End.Format("2006-01-02")
+}
+
+// repoColumn returns a repository's metrics as a comparison column
+func repoColumn(label string, m *domain.RepoMetrics) comparisonColumn {
+	return comparisonColumn{Label: label, Commits: m.Commits, PRs: m.PRs, Additions: m.Additions, Deletions: m.Deletions, Deploys: m.Deploys}
+}
+
+// memberColumn returns a member's metrics as a comparison column
+func memberColumn(label string, m *domain.MemberMetrics) comparisonColumn {
+	return comparisonColumn{Label: label, Commits: m.Commits, PRs: m.PRs, Additions: m.Additions, Deletions: m.Deletions, Deploys: m.Deploys}
+}
+
+// printComparisonTable prints the columns side by side, followed by the difference of each
+// column from the first one
+func printComparisonTable(t comparisonTable) {
+	fmt.Printf("\n%s\n\n", t.Title)
+
+	header := []string{"Metric"}
+	for _, c := range t.Columns {
+		header = append(header, c.Label)
+	}
+	for _, c := range t.Columns[1:] {
+		if len(t.Columns) == 2 {
+			header = append(header, "Change")
+		} else {
+			header = append(header, "Δ "+c.Label)
+		}
+	}
+
+	rows := []struct {
+		name  string
+		value func(comparisonColumn) int64
+	}{
+		{"Commits", func(c comparisonColumn) int64 { return c.Commits }},
+		{"Pull Requests", func(c comparisonColumn) int64 { return c.PRs }},
+		{"Lines Added", func(c comparisonColumn) int64 { return c.Additions }},
+		{"Lines Deleted", func(c comparisonColumn) int64 { return c.Deletions }},
+		{"Deployments", func(c comparisonColumn) int64 { return c.Deploys }},
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	for _, row := range rows {
+		line := []string{row.name}
+		for _, c := range t.Columns {
+			line = append(line, fmt.Sprintf("%d", row.value(c)))
+		}
+		base := row.value(t.Columns[0])
+		for _, c := range t.Columns[1:] {
+			line = append(line, formatDelta(base, row.value(c)))
+		}
+		table.Append(line)
+	}
+	table.Render()
+}
+
+// formatDelta formats the difference between two values with its relative change
+func formatDelta(base, value int64) string {
+	switch {
+	case base == value:
+		return "0"
+	case base == 0:
+		return fmt.Sprintf("%+d (new)", value)
+	default:
+		return fmt.Sprintf("%+d (%+.1f%%)", value-base, float64(value-base)/float64(base)*100)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/badge"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// registerCompletions sets up dynamic shell completion once every command has its flags.
+// The scripts come from cobra's completion command (completion bash|zsh|fish|powershell);
+// owner, repository and member names are completed from the local database.
+func registerCompletions() {
+	collectCmd.ValidArgsFunction = completeOwners
+	for _, cmd := range []*cobra.Command{showCmd, showMembersCmd, showReposCmd, showRankingsCmd, showTimeSeriesCmd, reportCmd, exportCmd, compareCmd, apiKeyViewerTokenCmd, purgeCmd, batchesCmd, showTeamsCmd, badgeCmd, showDoraCmd, showHeatmapCmd, showTrendsCmd, showActivityCmd} {
+		cmd.ValidArgsFunction = completeOwnerArgs(nil)
+	}
+	showRepoCmd.ValidArgsFunction = completeOwnerArgs(completeRepos)
+	showComponentsCmd.ValidArgsFunction = completeOwnerArgs(completeRepos)
+	showMemberCmd.ValidArgsFunction = completeOwnerArgs(completeMembers)
+	showTeamCmd.ValidArgsFunction = completeOwnerArgs(completeTeams)
+	doctorCmd.ValidArgsFunction = completeOwners
+	identityAddCmd.ValidArgsFunction = completeOwnerThenNames(completeMembers)
+	identityListCmd.ValidArgsFunction = completeOwnerArgs(nil)
+	identityShowCmd.ValidArgsFunction = completeOwnerArgs(completeMembers)
+	identityRemoveCmd.ValidArgsFunction = completeOwnerThenNames(completeAliases)
+	deploysListCmd.ValidArgsFunction = completeOwnerArgs(nil)
+	for _, cmd := range []*cobra.Command{deploysSetCmd, deploysShowCmd, deploysRemoveCmd} {
+		cmd.ValidArgsFunction = completeOwnerArgs(completeRepos)
+	}
+	for _, cmd := range []*cobra.Command{batchesShowCmd, collectRetryCmd} {
+		cmd.ValidArgsFunction = completeBatchIDs
+	}
+
+	collectCmd.ValidArgsFunction = completeOwners
+	for _, cmd := range []*cobra.Command{showCmd, showMembersCmd, showMemberCmd, showReposCmd} {
+		registerFlagCompletion(cmd, "repos", completeListFlag(completeRepos))
+		registerFlagCompletion(cmd, "exclude-repos", completeListFlag(completeRepos))
+	}
+	registerFlagCompletion(showCmd, "member", completeFirstArgFlag(completeMembers))
+	registerFlagCompletion(showRepoCmd, "member", completeFirstArgFlag(completeMembers))
+	registerFlagCompletion(compareCmd, "repos", completeListFlag(completeRepos))
+	registerFlagCompletion(compareCmd, "members", completeListFlag(completeMembers))
+	registerFlagCompletion(showRankingsCmd, "type", fixedCompletion("commits", "prs", "code-changes", "deploys"))
+	registerFlagCompletion(showRankingsCmd, "target", fixedCompletion("members", "repos"))
+	registerFlagCompletion(showTimeSeriesCmd, "repo", completeFirstArgFlag(completeRepos))
+	registerFlagCompletion(showTimeSeriesCmd, "member", completeFirstArgFlag(completeMembers))
+	registerFlagCompletion(showDoraCmd, "repo", completeFirstArgFlag(completeRepos))
+	registerFlagCompletion(showHeatmapCmd, "repo", completeFirstArgFlag(completeRepos))
+	registerFlagCompletion(showHeatmapCmd, "member", completeFirstArgFlag(completeMembers))
+	registerFlagCompletion(showHeatmapCmd, "type", fixedCompletion("commit", "pull_request", "deploy"))
+	registerFlagCompletion(showActivityCmd, "status", fixedCompletion("active", "slowing", "dormant", "dead"))
+	registerFlagCompletion(reportCmd, "period", fixedCompletion("weekly", "monthly", "quarterly"))
+	registerFlagCompletion(showTrendsCmd, "period", fixedCompletion("weekly", "monthly", "quarterly"))
+	registerFlagCompletion(reportCmd, "format", fixedCompletion("markdown", "html"))
+	registerFlagCompletion(exportCmd, "data", fixedCompletion("events", "members", "repos", "org"))
+	registerFlagCompletion(exportCmd, "format", fixedCompletion("csv", "json", "ndjson"))
+	registerFlagCompletion(exportCmd, "type", fixedCompletion("commit", "pull_request", "deploy"))
+	registerFlagCompletion(batchesCmd, "status", fixedCompletion("in_progress", "completed", "failed"))
+	registerFlagCompletion(showCmd, "format", fixedCompletion(showFormats...))
+	registerFlagCompletion(badgeCmd, "metric", fixedCompletion(badge.Metrics()...))
+	registerFlagCompletion(deploysSetCmd, "source", fixedCompletion("deployments", "workflow", "tag", "release"))
+	registerFlagCompletion(configInitCmd, "mode", fixedCompletion("organization", "user"))
+	registerFlagCompletion(configInitCmd, "storage", fixedCompletion("sqlite", "postgres"))
+	registerFlagCompletion(rootCmd, "log-format", fixedCompletion("text", "json"))
+	registerFlagCompletion(rootCmd, "profile", completeProfiles)
+	registerFlagCompletion(rootCmd, "granularity", fixedCompletion("day", "week", "month"))
+}
+
+// completionFunc completes an argument or flag value
+type completionFunc = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeFunc completes a name belonging to owner
+type completeFunc func(ctx context.Context, store storage.Storage, owner string) ([]string, error)
+
+// completeOwnerArgs completes an owner as the first argument and, with next, a name of that
+// owner as the second one
+func completeOwnerArgs(next completeFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch {
+		case len(args) == 0:
+			return withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+				return store.ListOwners(ctx)
+			})
+		case len(args) == 1 && next != nil:
+			return withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+				return next(ctx, store, args[0])
+			})
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+}
+
+// completeOwnerThenNames completes an owner as the first argument and names of that owner as
+// the following ones
+func completeOwnerThenNames(next completeFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeOwners(cmd, args, toComplete)
+		}
+		return withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+			return next(ctx, store, args[0])
+		})
+	}
+}
+
+// completeOwners completes owners as any argument
+func completeOwners(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+		return store.ListOwners(ctx)
+	})
+}
+
+// completeListFlag completes the last element of a comma-separated flag with names of the
+// owner given as the first argument
+func completeListFlag(complete completeFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		prefix := ""
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix = toComplete[:i+1]
+		}
+		names, directive := withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+			return complete(ctx, store, args[0])
+		})
+		for i, name := range names {
+			names[i] = prefix + name
+		}
+		return names, directive
+	}
+}
+
+// completeFirstArgFlag completes a flag with names of the owner given as the first argument
+func completeFirstArgFlag(complete completeFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+			return complete(ctx, store, args[0])
+		})
+	}
+}
+
+// completeProfiles completes the profiles of the configuration file given with --config or
+// found by default
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if path, err := cmd.Flags().GetString("config"); err == nil {
+		config.SetFile(path)
+	}
+	names, _, err := config.Profiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBatchIDs completes the ID of a collection batch, newest first
+func completeBatchIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return withCompletionStorage(func(ctx context.Context, store storage.Storage) ([]string, error) {
+		batches, err := store.ListBatches(ctx, domain.BatchFilter{})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(batches))
+		for _, b := range batches {
+			ids = append(ids, b.ID)
+		}
+		return ids, nil
+	})
+}
+
+// completeRepos lists the owner's repositories
+func completeRepos(ctx context.Context, store storage.Storage, owner string) ([]string, error) {
+	repos, err := store.GetRepositories(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repos))
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	return names, nil
+}
+
+// completeMembers lists the owner's members
+func completeMembers(ctx context.Context, store storage.Storage, owner string) ([]string, error) {
+	members, err := store.GetMembers(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, m.Username)
+	}
+	return names, nil
+}
+
+// completeAliases lists the owner's member aliases
+func completeAliases(ctx context.Context, store storage.Storage, owner string) ([]string, error) {
+	aliases, err := store.ListMemberAliases(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		names = append(names, a.Alias)
+	}
+	return names, nil
+}
+
+// completeTeams lists the organization's teams
+func completeTeams(ctx context.Context, store storage.Storage, org string) ([]string, error) {
+	teams, err := store.GetTeams(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	slugs := make([]string, 0, len(teams))
+	for _, t := range teams {
+		slugs = append(slugs, t.Slug)
+	}
+	return slugs, nil
+}
+
+// withCompletionStorage runs list against the configured database. Completion stays silent
+// when the database cannot be opened, and never creates a SQLite file that doesn't exist yet.
+func withCompletionStorage(list func(ctx context.Context, store storage.Storage) ([]string, error)) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if cfg.StorageType != "postgres" {
+		if _, err := os.Stat(cfg.SQLitePath); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	store, err := getStorage(cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	names, err := list(ctx, store)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// fixedCompletion completes a flag with a fixed set of values
+func fixedCompletion(values ...string) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerFlagCompletion registers the completion of a flag of cmd
+func registerFlagCompletion(cmd *cobra.Command, flag string, fn completionFunc) {
+	if err := cmd.RegisterFlagCompletionFunc(flag, fn); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var showComponentsCmd = &cobra.Command{
+	Use:   "components [org] [repo]",
+	Short: "Show metrics for each component of a monorepo",
+	Long: `Display the metrics of each component of a repository, as configured with REPO_COMPONENTS:
+the commits changing the files under the component's paths, the lines and files they changed,
+the members committing them, and the merged pull requests whose merge commit changed them.
+
+A commit changing several components counts for each of them. Changes to files under no
+component's paths are shown as "(other)". Commits collected before changed files were
+recorded can't be attributed to a component; collect again to include them.`,
+	Example: `  github-metrics show components my-org monorepo
+  github-metrics show components my-org monorepo --start 2024-Q1 --format json`,
+	Args: cobra.ExactArgs(2),
+	RunE: watchable(runShowComponents),
+}
+
+func init() {
+	showCmd.AddCommand(showComponentsCmd)
+}
+
+func runShowComponents(cmd *cobra.Command, args []string) error {
+	org := args[0]
+	repo := args[1]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	timeRange := getTimeRange()
+
+	metrics, err := agg.GetComponentMetrics(context.Background(), org, repo, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get component metrics: %w", err)
+	}
+
+	table := viewTable{Header: []string{"Component", "Paths", "Commits", "PRs", "Additions", "Deletions", "Files", "Contributors"}}
+	for _, m := range metrics.Components {
+		table.Rows = append(table.Rows, []string{
+			m.Component,
+			strings.Join(m.Paths, ", "),
+			fmt.Sprintf("%d", m.Commits),
+			fmt.Sprintf("%d", m.PRs),
+			fmt.Sprintf("%d", m.Additions),
+			fmt.Sprintf("%d", m.Deletions),
+			fmt.Sprintf("%d", m.FilesChanged),
+			fmt.Sprintf("%d", m.Contributors),
+		})
+	}
+	return render(&view{
+		Title:     fmt.Sprintf("Component Metrics: %s/%s", org, repo),
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value:     metrics,
+		After: func(w io.Writer) {
+			if len(metrics.Components) == 0 || metrics.Components[0].Component == domain.OtherComponent {
+				fmt.Fprintf(w, "\nNo components are configured for %s/%s (REPO_COMPONENTS).\n", org, repo)
+			}
+			if metrics.UnattributedCommits > 0 {
+				fmt.Fprintf(w, "\n%s collected without changed files; collect again to attribute them.\n", countNoun(metrics.UnattributedCommits, "commit"))
+			}
+		},
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFlagForms describes the values --start and --end accept
+const dateFlagForms = "a date (2024-01-31), a quarter (2024-Q1) or a time ago (7d, 2w, 1m, 1y)"
+
+// parseDateFlag parses the value of --start or --end: a date, a quarter, which is its first
+// day, or the end of its last day with end set, or a number of days, weeks, months or years
+// before now
+func parseDateFlag(name, value string, now time.Time, end bool) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+
+	if year, quarter, ok := strings.Cut(s, "-q"); ok {
+		y, yerr := strconv.Atoi(year)
+		q, qerr := strconv.Atoi(quarter)
+		if yerr != nil || qerr != nil || len(year) != 4 || q < 1 || q > 4 {
+			return time.Time{}, fmt.Errorf("invalid --%s %q: a quarter must be like 2024-Q1", name, value)
+		}
+		start := time.Date(y, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, time.UTC)
+		if end {
+			// The last instant of the quarter, so that ranges include events on its last day
+			return start.AddDate(0, 3, 0).Add(-time.Nanosecond), nil
+		}
+		return start, nil
+	}
+
+	if n, unit := strings.TrimRight(s, "dwmy"), strings.TrimLeft(s, "0123456789"); n != "" && len(unit) == 1 {
+		count, err := strconv.Atoi(n)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --%s %q: must be %s", name, value, dateFlagForms)
+		}
+		switch unit {
+		case "d":
+			return now.AddDate(0, 0, -count), nil
+		case "w":
+			return now.AddDate(0, 0, -7*count), nil
+		case "m":
+			return now.AddDate(0, -count, 0), nil
+		case "y":
+			return now.AddDate(-count, 0, 0), nil
+		}
+	}
+
+	if len(s) == len("2006-01-02") && strings.Count(s, "-") == 2 {
+		// Shaped like a date but not one, such as 2024-02-30
+		return time.Time{}, fmt.Errorf("invalid --%s %q: not a valid date", name, value)
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q: must be %s", name, value, dateFlagForms)
+}
+
+// dateFlags returns the times given with --start and --end, zero when a flag isn't set
+func dateFlags(now time.Time) (start, end time.Time, err error) {
+	if startDate != "" {
+		if start, err = parseDateFlag("start", startDate, now, false); err != nil {
+			return start, end, err
+		}
+	}
+	if endDate != "" {
+		if end, err = parseDateFlag("end", endDate, now, true); err != nil {
+			return start, end, err
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return start, end, fmt.Errorf("--start %s is after --end %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	return start, end, nil
+}
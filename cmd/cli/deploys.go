@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	deploysSource  string
+	deploysPattern string
+)
+
+var deploysCmd = &cobra.Command{
+	Use:   "deploys",
+	Short: "Manage what counts as a deploy of each repository",
+	Long: `Manage the deploy definitions of repositories. A deploy definition chooses what
+counts as a deploy of a repository, for deploy counts, rankings and DORA metrics alike:
+
+  deployments  GitHub deployments (the default), to environments matching --pattern if given
+  workflow     completed runs of the GitHub Actions workflows whose name or file name
+               matches --pattern; failed or timed out runs are failed deploys
+  tag          tags matching --pattern, at the time of their commit
+  release      published releases, whose tag matches --pattern if given
+
+Patterns are globs (* and ?) matched case-insensitively. Changing what counts as a deploy
+of a repository deletes its collected deploys; collect the repository again to record the
+deploys of the new definition.`,
+}
+
+var deploysSetCmd = &cobra.Command{
+	Use:   "set [owner] [repo]",
+	Short: "Set what counts as a deploy of a repository",
+	Example: `  github-metrics deploys set my-org api --source workflow --pattern "deploy*.yml"
+  github-metrics deploys set my-org web --source tag --pattern "v*"
+  github-metrics deploys set my-org app --source deployments --pattern production`,
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         runDeploysSet,
+}
+
+var deploysListCmd = &cobra.Command{
+	Use:          "list [owner]",
+	Short:        "List the deploy definitions of an owner's repositories",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runDeploysList,
+}
+
+var deploysShowCmd = &cobra.Command{
+	Use:          "show [owner] [repo]",
+	Short:        "Show what counts as a deploy of a repository",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         runDeploysShow,
+}
+
+var deploysRemoveCmd = &cobra.Command{
+	Use:          "remove [owner] [repo]",
+	Short:        "Count all GitHub deployments of a repository again",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         runDeploysRemove,
+}
+
+func init() {
+	deploysSetCmd.Flags().StringVar(&deploysSource, "source", string(domain.DeploySourceDeployments), "what counts as a deploy: deployments, workflow, tag or release")
+	deploysSetCmd.Flags().StringVar(&deploysPattern, "pattern", "", "glob of the environments, workflows or tags counted (required for workflow and tag)")
+
+	rootCmd.AddCommand(deploysCmd)
+	deploysCmd.AddCommand(deploysSetCmd)
+	deploysCmd.AddCommand(deploysListCmd)
+	deploysCmd.AddCommand(deploysShowCmd)
+	deploysCmd.AddCommand(deploysRemoveCmd)
+}
+
+func runDeploysSet(cmd *cobra.Command, args []string) error {
+	def := &domain.DeployDefinition{
+		Owner:     args[0],
+		Repo:      args[1],
+		Source:    domain.DeploySource(deploysSource),
+		Pattern:   deploysPattern,
+		UpdatedAt: time.Now(),
+	}
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	deleted, err := store.SaveDeployDefinition(context.Background(), def)
+	if err != nil {
+		return fmt.Errorf("failed to save deploy definition: %w", err)
+	}
+	fmt.Printf("Deploys of %s/%s: %s\n", def.Owner, def.Repo, describeDeployDefinition(def))
+	printDeletedDeploys(def.Owner, def.Repo, deleted)
+	return nil
+}
+
+func runDeploysList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	defs, err := store.ListDeployDefinitions(context.Background(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to list deploy definitions: %w", err)
+	}
+
+	if outputJSON {
+		if defs == nil {
+			defs = []*domain.DeployDefinition{}
+		}
+		return printJSON(defs)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Repository", "Source", "Pattern", "Updated"})
+	for _, def := range defs {
+		table.Append([]string{def.Repo, string(def.Source), def.Pattern, def.UpdatedAt.Format("2006-01-02 15:04")})
+	}
+	table.Render()
+	fmt.Println("Repositories not listed count their GitHub deployments.")
+
+	return nil
+}
+
+func runDeploysShow(cmd *cobra.Command, args []string) error {
+	owner, repo := args[0], args[1]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	def, err := store.GetDeployDefinition(context.Background(), owner, repo)
+	if err != nil {
+		return fmt.Errorf("failed to get deploy definition: %w", err)
+	}
+	if def == nil {
+		def = domain.DefaultDeployDefinition(owner, repo)
+	}
+
+	if outputJSON {
+		return printJSON(def)
+	}
+	fmt.Printf("Deploys of %s/%s: %s\n", owner, repo, describeDeployDefinition(def))
+	return nil
+}
+
+func runDeploysRemove(cmd *cobra.Command, args []string) error {
+	owner, repo := args[0], args[1]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	deleted, err := store.DeleteDeployDefinition(context.Background(), owner, repo)
+	if err != nil {
+		return fmt.Errorf("failed to delete deploy definition: %w", err)
+	}
+	fmt.Printf("Deploys of %s/%s: %s\n", owner, repo, describeDeployDefinition(domain.DefaultDeployDefinition(owner, repo)))
+	printDeletedDeploys(owner, repo, deleted)
+	return nil
+}
+
+// describeDeployDefinition describes what a deploy definition counts as a deploy
+func describeDeployDefinition(def *domain.DeployDefinition) string {
+	switch def.Source {
+	case domain.DeploySourceWorkflow:
+		return fmt.Sprintf("runs of workflows matching %q", def.Pattern)
+	case domain.DeploySourceTag:
+		return fmt.Sprintf("tags matching %q", def.Pattern)
+	case domain.DeploySourceRelease:
+		if def.Pattern != "" {
+			return fmt.Sprintf("releases with tags matching %q", def.Pattern)
+		}
+		return "releases"
+	default:
+		if def.Pattern != "" {
+			return fmt.Sprintf("GitHub deployments to environments matching %q", def.Pattern)
+		}
+		return "GitHub deployments"
+	}
+}
+
+// printDeletedDeploys reports the deploys deleted by changing a definition
+func printDeletedDeploys(owner, repo string, deleted int64) {
+	if deleted == 0 {
+		return
+	}
+	fmt.Printf("Deleted %d collected deploys; run 'github-metrics collect %s --repos %s' to collect the deploys again\n", deleted, owner, repo)
+}
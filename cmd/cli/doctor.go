@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/collector"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [owner...]",
+	Short: "Diagnose the configuration, GitHub token and database",
+	Long: `Check that everything needed to collect and serve metrics works, and print how to fix
+what doesn't:
+
+  - the configuration is valid
+  - the GitHub token is accepted and has the scopes collection needs
+  - enough of the GitHub rate limit is left
+  - the database can be reached and has every table
+  - data has been collected for each owner
+
+Owners are the ones given as arguments, or else every owner that has collection batches or
+a schedule in COLLECT_SCHEDULES. The command fails when any check fails.`,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorStatus is the outcome of a check
+type doctorStatus string
+
+const (
+	doctorOK   doctorStatus = "ok"
+	doctorWarn doctorStatus = "warn"
+	doctorFail doctorStatus = "fail"
+)
+
+// doctorCheck is the result of a single diagnosis
+type doctorCheck struct {
+	Name    string       `json:"name"`
+	Status  doctorStatus `json:"status"`
+	Message string       `json:"message"`
+	Fix     string       `json:"fix,omitempty"`
+}
+
+// doctorReport collects check results
+type doctorReport struct {
+	Checks []doctorCheck `json:"checks"`
+}
+
+// add records the result of a check
+func (r *doctorReport) add(name string, status doctorStatus, message, fix string) {
+	r.Checks = append(r.Checks, doctorCheck{Name: name, Status: status, Message: message, Fix: fix})
+}
+
+// failed counts the failed checks
+func (r *doctorReport) failed() int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == doctorFail {
+			n++
+		}
+	}
+	return n
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report := &doctorReport{}
+
+	cfg, err := config.Load()
+	if err != nil {
+		report.add("config", doctorFail, err.Error(), "fix the configuration file, .env or the environment")
+		return finishDoctor(report)
+	}
+	if err := cfg.Validate(); err != nil {
+		fix := "set the value in .env or the environment (see .env.example)"
+		var cfgErr *config.ConfigError
+		if errors.As(err, &cfgErr) {
+			fix = fmt.Sprintf("set %s in .env or the environment (see .env.example)", cfgErr.Field)
+		}
+		report.add("config", doctorFail, err.Error(), fix)
+	} else {
+		message := fmt.Sprintf("mode %s, storage %s", cfg.Mode, cfg.StorageType)
+		if path, _ := config.FilePath(); path != "" {
+			message += ", config file " + path
+		}
+		if _, profile, _ := config.Profiles(); profile != "" {
+			message += ", profile " + profile
+		}
+		report.add("config", doctorOK, message, "")
+	}
+
+	if cfg.GitHubToken != "" || cfg.UsesGitHubApp() {
+		checkGitHubToken(ctx, cfg, report)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		fix := "check SQLITE_PATH points to a writable location"
+		if cfg.StorageType == "postgres" {
+			fix = "check POSTGRES_URL and that the PostgreSQL server is running"
+		}
+		report.add("database", doctorFail, fmt.Sprintf("cannot open %s storage: %v", cfg.StorageType, err), fix)
+		return finishDoctor(report)
+	}
+	defer store.Close()
+
+	checkDatabase(ctx, store, cfg, report)
+	checkOwnerData(ctx, store, cfg, args, report)
+
+	return finishDoctor(report)
+}
+
+// checkGitHubToken checks that the token is accepted, has the needed scopes and rate limit left
+func checkGitHubToken(ctx context.Context, cfg *config.Config, report *doctorReport) {
+	info, err := collector.InspectToken(ctx, cfg.GitHubToken, collectorOptions(cfg)...)
+	if errors.Is(err, collector.ErrInvalidAppCredentials) {
+		report.add("github token", doctorFail, err.Error(),
+			"check GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and the private key in the app's settings")
+		return
+	}
+	if errors.Is(err, collector.ErrInvalidToken) {
+		report.add("github token", doctorFail, err.Error(),
+			"create a new token at https://github.com/settings/tokens and set GITHUB_TOKEN")
+		return
+	}
+	if err != nil {
+		report.add("github token", doctorFail, fmt.Sprintf("cannot reach the GitHub API: %v", err),
+			"check the network connection and any proxy settings")
+		return
+	}
+
+	switch missing := info.MissingScopes(cfg.Mode); {
+	case cfg.UsesGitHubApp():
+		report.add("github token", doctorOK, fmt.Sprintf("authenticated as %s (grant the app read access to contents, pull requests, deployments and members)", info.Login), "")
+	case !info.ScopesKnown:
+		report.add("github token", doctorOK, fmt.Sprintf("authenticated as %s (fine-grained token; grant read access to contents, pull requests, deployments and members)", info.Login), "")
+	case len(missing) > 0:
+		report.add("github token", doctorFail,
+			fmt.Sprintf("authenticated as %s, but the token lacks scopes: %s", info.Login, strings.Join(missing, ", ")),
+			fmt.Sprintf("add the %s scopes to the token at https://github.com/settings/tokens", strings.Join(missing, ", ")))
+	default:
+		report.add("github token", doctorOK, fmt.Sprintf("authenticated as %s with scopes %s", info.Login, strings.Join(info.Scopes, ", ")), "")
+	}
+
+	message := fmt.Sprintf("%d of %d requests left, resets at %s", info.RateRemaining, info.RateLimit, info.RateReset.Local().Format("15:04"))
+	switch {
+	case info.RateRemaining == 0:
+		report.add("rate limit", doctorFail, message, "wait for the reset before collecting")
+	case info.RateLimit > 0 && info.RateRemaining < info.RateLimit/10:
+		report.add("rate limit", doctorWarn, message, "large collections may pause until the reset")
+	default:
+		report.add("rate limit", doctorOK, message, "")
+	}
+}
+
+// checkDatabase checks that the database can be reached and has the current schema
+func checkDatabase(ctx context.Context, store storage.Storage, cfg *config.Config, report *doctorReport) {
+	location := cfg.SQLitePath
+	if cfg.StorageType == "postgres" {
+		location = "PostgreSQL"
+	}
+	if err := store.Ping(ctx); err != nil {
+		report.add("database", doctorFail, fmt.Sprintf("cannot reach %s: %v", location, err), "check the database is running and reachable")
+		return
+	}
+	report.add("database", doctorOK, fmt.Sprintf("connected to %s", location), "")
+
+	missing, err := store.MissingTables(ctx)
+	switch {
+	case err != nil:
+		report.add("schema", doctorFail, fmt.Sprintf("cannot inspect the schema: %v", err), "check the database user can read the catalog")
+	case len(missing) > 0:
+		report.add("schema", doctorFail, fmt.Sprintf("missing tables: %s", strings.Join(missing, ", ")), "check the database user can create tables, then run \"github-metrics migrate up\"")
+	default:
+		report.add("schema", doctorOK, "all tables present", "")
+	}
+
+	status, err := store.MigrationStatus(ctx)
+	if err != nil {
+		report.add("migrations", doctorFail, fmt.Sprintf("cannot read the applied migrations: %v", err), "")
+		return
+	}
+	if pending := len(status) - countApplied(status); pending > 0 {
+		report.add("migrations", doctorWarn, fmt.Sprintf("%d of %d migrations pending", pending, len(status)), "run \"github-metrics migrate up\"")
+	} else {
+		report.add("migrations", doctorOK, fmt.Sprintf("schema at version %d", storage.SchemaVersion(status)), "")
+	}
+}
+
+// checkOwnerData checks that data has been collected for each owner
+func checkOwnerData(ctx context.Context, store storage.Storage, cfg *config.Config, owners []string, report *doctorReport) {
+	if len(owners) == 0 {
+		seen := make(map[string]bool)
+		batches, err := store.ListBatches(ctx, domain.BatchFilter{})
+		if err != nil {
+			report.add("data", doctorFail, fmt.Sprintf("cannot list collection batches: %v", err), "")
+			return
+		}
+		for _, b := range batches {
+			if !seen[b.Owner] {
+				seen[b.Owner] = true
+				owners = append(owners, b.Owner)
+			}
+		}
+		for _, s := range cfg.Schedules {
+			if !seen[s.Owner] {
+				seen[s.Owner] = true
+				owners = append(owners, s.Owner)
+			}
+		}
+	}
+	if len(owners) == 0 {
+		report.add("data", doctorWarn, "nothing has been collected yet", "run github-metrics collect <org|user>")
+		return
+	}
+
+	for _, owner := range owners {
+		name := "data " + owner
+		latest, err := store.GetLatestEventTime(ctx, owner)
+		if err != nil {
+			report.add(name, doctorFail, fmt.Sprintf("cannot read events: %v", err), "")
+			continue
+		}
+		repos, err := store.GetRepositories(ctx, owner)
+		if err != nil {
+			report.add(name, doctorFail, fmt.Sprintf("cannot read repositories: %v", err), "")
+			continue
+		}
+		if latest.IsZero() {
+			report.add(name, doctorWarn, fmt.Sprintf("no events (%d repositories)", len(repos)), "run github-metrics collect "+owner)
+			continue
+		}
+
+		var lastSync time.Time
+		for _, r := range repos {
+			if r.LastSyncedAt != nil && r.LastSyncedAt.After(lastSync) {
+				lastSync = *r.LastSyncedAt
+			}
+		}
+		message := fmt.Sprintf("%d repositories, last event stored %s", len(repos), latest.Local().Format("2006-01-02 15:04"))
+		if !lastSync.IsZero() {
+			message += fmt.Sprintf(", last synced %s", lastSync.Local().Format("2006-01-02 15:04"))
+		}
+		if time.Since(latest) > 7*24*time.Hour {
+			report.add(name, doctorWarn, message+" (over a week ago)", "run github-metrics collect "+owner+" --incremental, or schedule it with COLLECT_SCHEDULES")
+			continue
+		}
+		report.add(name, doctorOK, message, "")
+	}
+}
+
+// finishDoctor prints the report and fails when any check failed
+func finishDoctor(report *doctorReport) error {
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		labels := map[doctorStatus]string{doctorOK: "[ OK ]", doctorWarn: "[WARN]", doctorFail: "[FAIL]"}
+		for _, c := range report.Checks {
+			fmt.Printf("%s %-14s %s\n", labels[c.Status], c.Name, c.Message)
+			if c.Fix != "" {
+				fmt.Printf("       %-14s fix: %s\n", "", c.Fix)
+			}
+		}
+	}
+
+	if n := report.failed(); n > 0 {
+		return fmt.Errorf("%d check(s) failed", n)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+)
+
+var doraRepo string
+
+var showDoraCmd = &cobra.Command{
+	Use:   "dora [org]",
+	Short: "Show the DORA metrics",
+	Long: `Display the four DORA software delivery metrics of an organization or user, or with --repo of
+one repository, each with its performance level (elite, high, medium or low):
+
+  - deployment frequency: successful deployments per day
+  - lead time for changes: median time from opening a pull request to merging it
+  - change failure rate: share of deployments whose status is failure or error
+  - time to restore: median time from a failed deployment to the next successful one
+
+A metric without data in the range is shown as "-" and not classified.`,
+	Example: `  github-metrics show dora my-org
+  github-metrics show dora my-org --repo api --start 2024-01-01 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowDora),
+}
+
+func init() {
+	showDoraCmd.Flags().StringVar(&doraRepo, "repo", "", "only this repository")
+
+	showCmd.AddCommand(showDoraCmd)
+}
+
+// doraMetricsJSON is the JSON form of the DORA metrics; a level is empty when a metric has
+// no data to classify
+type doraMetricsJSON struct {
+	Owner               string    `json:"owner"`
+	Repo                string    `json:"repo,omitempty"`
+	Start               time.Time `json:"start"`
+	End                 time.Time `json:"end"`
+	Deployments         int64     `json:"deployments"`
+	FailedDeployments   int64     `json:"failed_deployments"`
+	DeploymentFrequency float64   `json:"deployment_frequency_per_day"`
+	DeploymentLevel     string    `json:"deployment_frequency_level"`
+	MergedPRs           int64     `json:"merged_prs"`
+	LeadTimeHours       float64   `json:"lead_time_hours"`
+	LeadTimeLevel       string    `json:"lead_time_level"`
+	ChangeFailureRate   float64   `json:"change_failure_rate"`
+	ChangeFailureLevel  string    `json:"change_failure_level"`
+	Restores            int64     `json:"restores"`
+	TimeToRestoreHours  float64   `json:"time_to_restore_hours"`
+	TimeToRestoreLevel  string    `json:"time_to_restore_level"`
+}
+
+func runShowDora(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	timeRange := getTimeRange()
+
+	dora, err := agg.GetDORAMetrics(context.Background(), org, doraRepo, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get DORA metrics: %w", err)
+	}
+
+	subject := org
+	if doraRepo != "" {
+		subject = org + "/" + doraRepo
+	}
+	table := viewTable{
+		Header: []string{"Metric", "Value", "Level", "Based On"},
+		Rows: [][]string{
+			{"Deployment frequency", formatPerDay(dora.DeploymentFrequency, dora.Deployments), doraLevel(dora.DeploymentLevel), countNoun(dora.Deployments, "deployment")},
+			{"Lead time for changes", formatHours(dora.LeadTimeHours, dora.MergedPRs), doraLevel(dora.LeadTimeLevel), countNoun(dora.MergedPRs, "merged PR")},
+			{"Change failure rate", formatRate(dora.ChangeFailureRate, dora.Deployments), doraLevel(dora.ChangeFailureLevel), countNoun(dora.FailedDeployments, "failed deployment")},
+			{"Time to restore", formatHours(dora.TimeToRestoreHours, dora.Restores), doraLevel(dora.TimeToRestoreLevel), countNoun(dora.Restores, "restore")},
+		},
+	}
+	return render(&view{
+		Title:     "DORA Metrics: " + subject,
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value: doraMetricsJSON{
+			Owner: org, Repo: doraRepo, Start: timeRange.Start, End: timeRange.End,
+			Deployments: dora.Deployments, FailedDeployments: dora.FailedDeployments,
+			DeploymentFrequency: dora.DeploymentFrequency, DeploymentLevel: string(dora.DeploymentLevel),
+			MergedPRs: dora.MergedPRs, LeadTimeHours: dora.LeadTimeHours, LeadTimeLevel: string(dora.LeadTimeLevel),
+			ChangeFailureRate: dora.ChangeFailureRate, ChangeFailureLevel: string(dora.ChangeFailureLevel),
+			Restores: dora.Restores, TimeToRestoreHours: dora.TimeToRestoreHours, TimeToRestoreLevel: string(dora.TimeToRestoreLevel),
+		},
+	})
+}
+
+// countNoun formats a count of a noun, pluralized with "s" unless it is one
+func countNoun(n int64, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
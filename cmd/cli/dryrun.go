@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/collector"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// dryRunPlan is what a collection would do
+type dryRunPlan struct {
+	Owner      string          `json:"owner"`
+	Mode       string          `json:"mode"`
+	Start      time.Time       `json:"start"`
+	End        time.Time       `json:"end"`
+	Found      int             `json:"repos_found"`
+	Repos      []dryRunRepo    `json:"repos"`
+	Skipped    []string        `json:"skipped,omitempty"` // already collected by the resumed batch
+	APICalls   int64           `json:"estimated_api_calls"`
+	RateLimit  *dryRunRateInfo `json:"rate_limit,omitempty"`
+	StoredData bool            `json:"stored_data"` // estimates use events already stored
+}
+
+// dryRunRepo is a repository a collection would fetch
+type dryRunRepo struct {
+	Name     string    `json:"name"`
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+	APICalls int64     `json:"estimated_api_calls"`
+}
+
+// dryRunRateInfo is the token's remaining rate limit
+type dryRunRateInfo struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Budget    int       `json:"budget_percent"` // GITHUB_RATE_LIMIT_BUDGET
+	Usable    int       `json:"usable"`         // remaining requests within the budget
+}
+
+// dryRunCollect prints the repositories a collection would fetch, each one's time range and
+// an estimate of the GitHub API requests it would make, without fetching events or writing
+// to storage. Only the repository list and the token's rate limit are requested from GitHub.
+func dryRunCollect(ctx context.Context, cfg *config.Config, target string, opts collectOptions) error {
+	plan := &dryRunPlan{Owner: target, Mode: cfg.Mode, Start: opts.TimeRange.Start, End: opts.TimeRange.End}
+
+	// Storage is only read, and a SQLite database that doesn't exist yet is not created
+	var store storage.Storage
+	if _, err := os.Stat(cfg.SQLitePath); cfg.StorageType == "postgres" || err == nil {
+		var err error
+		if store, err = getStorage(cfg); err != nil {
+			return fmt.Errorf("failed to initialize storage: %w", err)
+		}
+		defer store.Close()
+	}
+
+	completed := make(map[string]bool)
+	lastSynced := make(map[string]time.Time)
+	if store != nil {
+		if opts.Resume {
+			var batch *domain.CollectionBatch
+			var err error
+			if opts.ExplicitRange {
+				batch, err = findBatch(ctx, store, cfg.Mode, target, opts.TimeRange)
+			} else {
+				batch, err = findUnfinishedBatch(ctx, store, cfg.Mode, target)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to find batch to resume: %w", err)
+			}
+			if batch != nil {
+				plan.Start, plan.End = batch.StartDate, batch.EndDate
+				if completed, err = completedBatchRepos(ctx, store, batch.ID); err != nil {
+					return fmt.Errorf("failed to read batch progress: %w", err)
+				}
+			}
+		}
+		if opts.SinceLastSync {
+			last, err := findLastCompletedBatch(ctx, store, cfg.Mode, target)
+			if err != nil {
+				return fmt.Errorf("failed to find the last completed batch: %w", err)
+			}
+			if last != nil {
+				plan.Start = last.EndDate
+			}
+		}
+		if opts.Incremental {
+			var err error
+			if lastSynced, err = repoSyncTimes(ctx, store, target); err != nil {
+				return fmt.Errorf("failed to read repository sync times: %w", err)
+			}
+		}
+	}
+
+	token := cfg.TokenFor(target)
+	if token == "" && !cfg.UsesGitHubApp() {
+		return fmt.Errorf("no GitHub token for %s: set GITHUB_TOKEN or add %s to GITHUB_TOKENS", target, target)
+	}
+	coll := collector.NewGitHubCollector(token, collectorOptions(cfg)...)
+	var repos []*domain.Repository
+	var err error
+	if cfg.Mode == "user" {
+		repos, err = coll.GetUserRepositories(ctx, target)
+	} else {
+		repos, err = coll.GetRepositories(ctx, target)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get repositories: %w", err)
+	}
+	plan.Found = len(repos)
+
+	// Listing the repositories, plus members and teams for an organization
+	plan.APICalls = max(1, int64(len(repos)+99)/100)
+	if cfg.Mode != "user" {
+		plan.APICalls += 2
+	}
+	for _, repo := range opts.reposOf(target).filterRepositories(repos) {
+		if completed[repo.Name] {
+			plan.Skipped = append(plan.Skipped, repo.Name)
+			continue
+		}
+		r := dryRunRepo{Name: repo.Name, Since: plan.Start, Until: plan.End}
+		if since := lastSynced[repo.Name]; !since.IsZero() {
+			r.Since = since
+		}
+
+		var commits, prs, deploys int64
+		if store != nil {
+			m, err := store.GetMetricsByRepo(ctx, target, repo.Name, domain.TimeRange{Start: r.Since, End: r.Until})
+			if err != nil {
+				return fmt.Errorf("failed to read stored metrics for %s: %w", repo.Name, err)
+			}
+			commits, prs, deploys = m.Commits, m.PRs, m.Deploys
+			plan.StoredData = plan.StoredData || commits+prs+deploys > 0
+		}
+		r.APICalls = estimateRepoCalls(commits, prs, deploys)
+		plan.APICalls += r.APICalls
+		plan.Repos = append(plan.Repos, r)
+	}
+
+	if info, err := collector.InspectToken(ctx, token, collectorOptions(cfg)...); err == nil {
+		plan.RateLimit = &dryRunRateInfo{
+			Limit:     info.RateLimit,
+			Remaining: info.RateRemaining,
+			Reset:     info.RateReset,
+			Budget:    cfg.GitHubRateLimitBudget,
+			Usable:    collector.UsableRequests(info.RateLimit, info.RateRemaining, float64(cfg.GitHubRateLimitBudget)/100),
+		}
+	}
+
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	printDryRunPlan(plan)
+	return nil
+}
+
+// findBatch returns the owner's batch for exactly timeRange, or nil
+func findBatch(ctx context.Context, store storage.Storage, mode, owner string, timeRange domain.TimeRange) (*domain.CollectionBatch, error) {
+	batches, err := store.ListBatches(ctx, domain.BatchFilter{Owner: owner})
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		if batch.Mode == mode && batch.StartDate.Equal(timeRange.Start) && batch.EndDate.Equal(timeRange.End) {
+			return batch, nil
+		}
+	}
+	return nil, nil
+}
+
+// estimateRepoCalls estimates the GitHub API requests collecting a repository makes: a page
+// of commits, pull requests and deployments at the least, plus one request per commit (for
+// its line changes) and per deployment (for its status). Events already stored for the
+// range stand in for the numbers of commits, pull requests and deployments, which are not
+// known before collecting.
+func estimateRepoCalls(commits, prs, deploys int64) int64 {
+	pages := func(n int64) int64 { return max(1, (n+99)/100) }
+	return pages(commits) + commits + pages(prs) + pages(deploys) + deploys
+}
+
+// printDryRunPlan prints a dry-run plan as a table
+func printDryRunPlan(plan *dryRunPlan) {
+	fmt.Printf("\nDry run: collecting %s (%s mode)\n", plan.Owner, plan.Mode)
+	fmt.Printf("Time range: %s to %s\n", plan.Start.Format("2006-01-02"), plan.End.Format("2006-01-02"))
+	fmt.Printf("Repositories: %d to collect of %d found", len(plan.Repos), plan.Found)
+	if len(plan.Skipped) > 0 {
+		fmt.Printf(", %d already collected by the resumed batch", len(plan.Skipped))
+	}
+	fmt.Print("\n\n")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Repository", "From", "To", "Est. API Calls"})
+	for _, r := range plan.Repos {
+		table.Append([]string{r.Name, r.Since.Format("2006-01-02"), r.Until.Format("2006-01-02"), fmt.Sprintf("%d", r.APICalls)})
+	}
+	table.Render()
+
+	basis := "the minimum per repository; commits and deployments add one request each"
+	if plan.StoredData {
+		basis = "events already stored for the range"
+	}
+	fmt.Printf("\nEstimated GitHub API requests: %d (based on %s)\n", plan.APICalls, basis)
+	if plan.RateLimit != nil {
+		fmt.Printf("Rate limit: %d of %d requests left, resets at %s\n",
+			plan.RateLimit.Remaining, plan.RateLimit.Limit, plan.RateLimit.Reset.Local().Format("15:04"))
+		if plan.RateLimit.Budget < 100 {
+			fmt.Printf("Rate limit budget: %d%% of the quota, %d requests usable\n", plan.RateLimit.Budget, plan.RateLimit.Usable)
+		}
+		if int64(plan.RateLimit.Usable) < plan.APICalls {
+			fmt.Println("Warning: the collection is likely to wait for the rate limit to reset")
+		}
+	}
+	fmt.Println("Nothing was collected or stored.")
+}
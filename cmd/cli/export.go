@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	exportData      string
+	exportFormat    string
+	exportOut       string
+	exportEventType string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [org]",
+	Short: "Export events or metrics to a file",
+	Long: `Export an organization's or user's raw events or aggregated metrics for the time range
+given by --start/--end, as CSV, a JSON array or newline-delimited JSON.
+
+--data selects what is exported:
+  events   one record per commit, pull request and deployment (--type to select one kind)
+  members  metrics per member
+  repos    metrics per repository
+  org      organization totals
+
+The format defaults to the --out file extension (.csv, .json, .ndjson/.jsonl), or CSV.
+Without --out the export is written to standard output.
+
+With PSEUDONYMIZE_MEMBERS, events are exported with hashed IDs and only the fields of their
+data that can't be looked up on GitHub (line counts, states, environments); SHAs, pull
+request numbers, messages and titles would reveal who a pseudonym is.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportData, "data", "events", "data to export (events, members, repos, org)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "output format (csv, json, ndjson)")
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "output file (default standard output)")
+	exportCmd.Flags().StringVar(&exportEventType, "type", "", "event type to export with --data events (commit, pull_request, deploy)")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+// recordWriter writes exported records; value is the JSON form of a record, row its CSV form
+type recordWriter interface {
+	Write(value interface{}, row []string) error
+	Close() error
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	path, err := outputPath("out", exportOut)
+	if err != nil {
+		return err
+	}
+	format := exportFormat
+	if format == "" {
+		format = exportFormatFromPath(path)
+	}
+	if format != "csv" && format != "json" && format != "ndjson" {
+		return fmt.Errorf("invalid format %q: must be 'csv', 'json' or 'ndjson'", format)
+	}
+
+	var eventTypes []domain.EventType
+	switch exportEventType {
+	case "":
+		eventTypes = []domain.EventType{domain.EventTypeCommit, domain.EventTypePullRequest, domain.EventTypeDeploy}
+	case string(domain.EventTypeCommit), string(domain.EventTypePullRequest), string(domain.EventTypeDeploy):
+		eventTypes = []domain.EventType{domain.EventType(exportEventType)}
+	default:
+		return fmt.Errorf("invalid event type %q: must be 'commit', 'pull_request' or 'deploy'", exportEventType)
+	}
+
+	var header []string
+	switch exportData {
+	case "events":
+		header = []string{"id", "type", "owner", "repo", "member", "timestamp", "data"}
+	case "members":
+		header = []string{"member", "commits", "prs", "additions", "deletions", "deploys"}
+	case "repos":
+		header = []string{"repo", "commits", "prs", "additions", "deletions", "deploys"}
+	case "org":
+		header = []string{"org", "total_repos", "total_members", "commits", "prs", "additions", "deletions", "deploys"}
+	default:
+		return fmt.Errorf("invalid data %q: must be 'events', 'members', 'repos' or 'org'", exportData)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	timeRange := getTimeRange()
+	agg := newAggregator(cfg, store)
+	pseudonyms := memberPseudonyms(cfg)
+	count := 0
+
+	err = writeOutput(path, func(out io.Writer) error {
+		buf := bufio.NewWriter(out)
+		w, err := newRecordWriter(buf, format, header)
+		if err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+
+		switch exportData {
+		case "events":
+			for _, eventType := range eventTypes {
+				err = store.StreamEvents(ctx, org, eventType, timeRange, func(e *domain.Event) error {
+					if pseudonyms != nil {
+						e.ID = pseudonyms.EventID(org, e.ID)
+						e.Member = pseudonyms.Name(org, e.Member)
+						e.Data = anonymousEventData(e.Data)
+					}
+					data, err := json.Marshal(e.Data)
+					if err != nil {
+						return err
+					}
+					count++
+					return w.Write(e, []string{e.ID, string(e.Type), e.Org, e.Repo, e.Member, e.Timestamp.Format(time.RFC3339), string(data)})
+				})
+				if err != nil {
+					return fmt.Errorf("failed to export %s events: %w", eventType, err)
+				}
+			}
+		case "members":
+			metrics, err := agg.GetMembersMetrics(ctx, org, timeRange)
+			if err != nil {
+				return fmt.Errorf("failed to get member metrics: %w", err)
+			}
+			for _, m := range metrics {
+				count++
+				if err := w.Write(m, append([]string{m.Member}, formatCounts(m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys)...)); err != nil {
+					return fmt.Errorf("failed to write export: %w", err)
+				}
+			}
+		case "repos":
+			metrics, err := agg.GetReposMetrics(ctx, org, timeRange)
+			if err != nil {
+				return fmt.Errorf("failed to get repository metrics: %w", err)
+			}
+			for _, m := range metrics {
+				count++
+				if err := w.Write(m, append([]string{m.Repo}, formatCounts(m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys)...)); err != nil {
+					return fmt.Errorf("failed to write export: %w", err)
+				}
+			}
+		case "org":
+			m, err := agg.AggregateOrgMetrics(ctx, org, timeRange)
+			if err != nil {
+				return fmt.Errorf("failed to get organization metrics: %w", err)
+			}
+			count++
+			row := append([]string{m.Org, strconv.Itoa(m.TotalRepos), strconv.Itoa(m.TotalMembers)},
+				formatCounts(m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys)...)
+			if err := w.Write(m, row); err != nil {
+				return fmt.Errorf("failed to write export: %w", err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		if err := buf.Flush(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !toStdout(path) {
+		fmt.Fprintf(os.Stderr, "Exported %d %s records to %s\n", count, exportData, path)
+	}
+	return nil
+}
+
+// anonymousEventFields are the fields of event data exported when member usernames are
+// pseudonymized. Everything else is left out: SHAs, pull request numbers, messages, titles
+// and file paths can be looked up on GitHub, revealing who a pseudonym is.
+var anonymousEventFields = map[string]bool{
+	"additions": true, "deletions": true, "files_changed": true, "parent_count": true, "merge_kind": true,
+	"state": true, "environment": true, "status": true, "source": true,
+}
+
+// anonymousEventData returns the anonymousEventFields of event data
+func anonymousEventData(data map[string]interface{}) map[string]interface{} {
+	kept := make(map[string]interface{}, len(anonymousEventFields))
+	for k, v := range data {
+		if anonymousEventFields[k] {
+			kept[k] = v
+		}
+	}
+	return kept
+}
+
+// exportFormatFromPath infers the export format from an output file extension
+func exportFormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	default:
+		return "csv"
+	}
+}
+
+// formatCounts formats metric counts as CSV fields
+func formatCounts(counts ...int64) []string {
+	fields := make([]string, len(counts))
+	for i, n := range counts {
+		fields[i] = strconv.FormatInt(n, 10)
+	}
+	return fields
+}
+
+// newRecordWriter returns a writer for format; CSV output starts with header
+func newRecordWriter(w io.Writer, format string, header []string) (recordWriter, error) {
+	switch format {
+	case "json":
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+		return &jsonRecordWriter{w: w}, nil
+	case "ndjson":
+		return &ndjsonRecordWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvRecordWriter{w: cw}, nil
+	}
+}
+
+// csvRecordWriter writes records as CSV rows
+type csvRecordWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRecordWriter) Write(value interface{}, row []string) error {
+	return c.w.Write(row)
+}
+
+func (c *csvRecordWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonRecordWriter writes records as the elements of a JSON array
+type jsonRecordWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonRecordWriter) Write(value interface{}, row []string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if j.count == 0 {
+		sep = "\n  "
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonRecordWriter) Close() error {
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// ndjsonRecordWriter writes one JSON record per line
+type ndjsonRecordWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonRecordWriter) Write(value interface{}, row []string) error {
+	return n.enc.Encode(value)
+}
+
+func (n *ndjsonRecordWriter) Close() error {
+	return nil
+}
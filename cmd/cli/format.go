@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// showFormat is the --format of the show commands
+var showFormat string
+
+// showFormats are the output formats of the show commands
+var showFormats = []string{"table", "json", "yaml", "csv"}
+
+// outputFormat returns the output format of the show commands: --format, or json with --json,
+// or else the format of the --output file's extension
+func outputFormat() (string, error) {
+	format := strings.ToLower(showFormat)
+	switch {
+	case format == "" && outputJSON:
+		return "json", nil
+	case format == "":
+		return formatFromPath(outputFile), nil
+	case outputJSON && format != "json":
+		return "", fmt.Errorf("--json conflicts with --format %s", showFormat)
+	}
+	for _, f := range showFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --format %q: must be one of %s", showFormat, strings.Join(showFormats, ", "))
+}
+
+// formatFromPath infers the output format of the show commands from a file extension
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".csv":
+		return "csv"
+	default:
+		return "table"
+	}
+}
+
+// view is the output of a show command. The table format prints its title, time range and
+// tables, or what Draw draws instead; csv writes the tables' rows; json and yaml marshal its
+// value with encoding/json.
+type view struct {
+	Title     string
+	TimeRange domain.TimeRange
+	Tables    []viewTable
+	Value     any
+	Draw      func(w io.Writer) // draws the table format's body instead of the tables
+	After     func(w io.Writer) // more of the table format, printed after the tables
+}
+
+// viewTable is a table of a view
+type viewTable struct {
+	Header []string
+	Rows   [][]string
+}
+
+// render prints v in the output format to standard output or the --output file
+func render(v *view) error {
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	return writeOutput(outputFile, func(w io.Writer) error {
+		switch format {
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(v.Value)
+		case "yaml":
+			return writeYAML(w, v.Value)
+		case "csv":
+			return writeCSV(w, v.Tables)
+		}
+
+		fmt.Fprintf(w, "\n%s\n", v.Title)
+		fmt.Fprintf(w, "Time Range: %s to %s\n", v.TimeRange.Start.Format("2006-01-02"), v.TimeRange.End.Format("2006-01-02"))
+		if v.Draw != nil {
+			fmt.Fprintln(w)
+			v.Draw(w)
+		} else {
+			for _, t := range v.Tables {
+				fmt.Fprintln(w)
+				table := tablewriter.NewWriter(w)
+				table.SetHeader(t.Header)
+				table.AppendBulk(t.Rows)
+				table.Render()
+			}
+		}
+		if v.After != nil {
+			v.After(w)
+		}
+		return nil
+	})
+}
+
+// writeYAML writes v as YAML, with the field names of its JSON form
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	out, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// writeCSV writes tables as CSV, separated by an empty line
+func writeCSV(out io.Writer, tables []viewTable) error {
+	w := csv.NewWriter(out)
+	for i, t := range tables {
+		if i > 0 {
+			w.Write(nil)
+		}
+		if err := w.Write(t.Header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.Rows); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// metricsRows are the rows of a metric/value table of totals
+func metricsRows(commits, prs, additions, deletions, deploys int64) [][]string {
+	return [][]string{
+		{"Commits", fmt.Sprintf("%d", commits)},
+		{"Pull Requests", fmt.Sprintf("%d", prs)},
+		{"Lines Added", fmt.Sprintf("%d", additions)},
+		{"Lines Deleted", fmt.Sprintf("%d", deletions)},
+		{"Deployments", fmt.Sprintf("%d", deploys)},
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	heatmapRepo     string
+	heatmapMember   string
+	heatmapType     string
+	heatmapTimeZone string
+)
+
+var showHeatmapCmd = &cobra.Command{
+	Use:   "heatmap [org]",
+	Short: "Show activity per weekday and hour of day",
+	Long: `Display when an organization or user is active: the number of commits (or, with --type, pull
+requests or deployments) per weekday and hour of day, as a grid that is darker the busier
+the hour. Hours are taken in the local time zone, or in the one given with --tz.
+
+The grid is colored when printed to a terminal; set NO_COLOR to draw it with shades
+instead. The csv format has one row per weekday and one column per hour.`,
+	Example: `  github-metrics show heatmap my-org
+  github-metrics show heatmap my-org --member alice --tz Asia/Tokyo --start 2024-01-01
+  github-metrics show heatmap my-org --repo api --type pull_request --format csv`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowHeatmap),
+}
+
+func init() {
+	showHeatmapCmd.Flags().StringVar(&heatmapRepo, "repo", "", "only this repository")
+	showHeatmapCmd.Flags().StringVar(&heatmapMember, "member", "", "only this member")
+	showHeatmapCmd.Flags().StringVar(&heatmapType, "type", string(domain.MetricTypeCommit), "events to count (commit, pull_request, deploy)")
+	showHeatmapCmd.Flags().StringVar(&heatmapTimeZone, "tz", "", "IANA time zone of the hours, such as Asia/Tokyo (default local)")
+	showHeatmapCmd.MarkFlagsMutuallyExclusive("repo", "member")
+
+	showCmd.AddCommand(showHeatmapCmd)
+}
+
+// heatmapJSON is the JSON form of an activity heatmap; values are indexed [weekday][hour]
+type heatmapJSON struct {
+	Owner    string    `json:"owner"`
+	Repo     string    `json:"repo,omitempty"`
+	Member   string    `json:"member,omitempty"`
+	Type     string    `json:"type"`
+	TimeZone string    `json:"time_zone"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Weekdays []string  `json:"weekdays"`
+	Hours    []int     `json:"hours"`
+	Values   [][]int64 `json:"values"`
+	Max      int64     `json:"max"`
+	Total    int64     `json:"total"`
+}
+
+func runShowHeatmap(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	query := domain.HeatmapQuery{
+		Repo:       heatmapRepo,
+		Member:     heatmapMember,
+		MetricType: domain.MetricType(heatmapType),
+		Location:   time.Local,
+	}
+	switch query.MetricType {
+	case domain.MetricTypeCommit, domain.MetricTypePullRequest, domain.MetricTypeDeploy:
+	default:
+		return fmt.Errorf("invalid --type %q: must be one of commit, pull_request, deploy", heatmapType)
+	}
+	if heatmapTimeZone != "" {
+		loc, err := time.LoadLocation(heatmapTimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid --tz %q: must be an IANA time zone such as Asia/Tokyo", heatmapTimeZone)
+		}
+		query.Location = loc
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	timeRange := getTimeRange()
+
+	heatmap, err := agg.GetHeatmap(context.Background(), org, query, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get heatmap: %w", err)
+	}
+
+	timeZone := heatmap.TimeZone
+	if timeZone == time.Local.String() {
+		// "Local" tells nothing about the hours; name the zone they are in
+		timeZone, _ = time.Now().Zone()
+	}
+
+	subject := org
+	switch {
+	case heatmapRepo != "":
+		subject = org + "/" + heatmapRepo
+	case heatmapMember != "":
+		subject = org + " (" + heatmapMember + ")"
+	}
+
+	table := viewTable{Header: []string{"Weekday"}}
+	for _, h := range heatmap.Hours {
+		table.Header = append(table.Header, fmt.Sprintf("%d", h))
+	}
+	for d, weekday := range heatmap.Weekdays {
+		row := []string{weekday}
+		for _, v := range heatmap.Values[d] {
+			row = append(row, fmt.Sprintf("%d", v))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return render(&view{
+		Title:     fmt.Sprintf("Activity Heatmap: %s (%s, %s)", subject, heatmapNoun(heatmap.Type), timeZone),
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value: heatmapJSON{
+			Owner: org, Repo: heatmap.Repo, Member: heatmap.Member, Type: string(heatmap.Type),
+			TimeZone: timeZone, Start: timeRange.Start, End: timeRange.End,
+			Weekdays: heatmap.Weekdays, Hours: heatmap.Hours, Values: heatmap.Values,
+			Max: heatmap.Max, Total: heatmap.Total,
+		},
+		Draw: func(w io.Writer) { drawHeatmap(w, heatmap, useColor()) },
+	})
+}
+
+// heatmapNoun names the events a heatmap counts
+func heatmapNoun(t domain.MetricType) string {
+	switch t {
+	case domain.MetricTypePullRequest:
+		return "pull request"
+	case domain.MetricTypeDeploy:
+		return "deployment"
+	default:
+		return "commit"
+	}
+}
+
+// heatmapColors are the 256-color palette backgrounds of the heatmap levels, empty first
+var heatmapColors = []int{236, 22, 28, 34, 40}
+
+// heatmapShades draw the heatmap levels without color, empty first
+var heatmapShades = []string{"··", "░░", "▒▒", "▓▓", "██"}
+
+// drawHeatmap draws a heatmap as a weekday × hour grid of cells shaded by their count
+func drawHeatmap(w io.Writer, heatmap *domain.Heatmap, color bool) {
+	cell := func(level int) string {
+		if color {
+			return fmt.Sprintf("\x1b[48;5;%dm  \x1b[0m", heatmapColors[level])
+		}
+		return heatmapShades[level]
+	}
+
+	fmt.Fprint(w, "   ")
+	for _, h := range heatmap.Hours {
+		fmt.Fprintf(w, " %02d", h)
+	}
+	fmt.Fprintln(w)
+
+	busiestDay, busiestHour := -1, -1
+	for d, weekday := range heatmap.Weekdays {
+		fmt.Fprintf(w, "%-3.3s", weekday)
+		for h, v := range heatmap.Values[d] {
+			fmt.Fprint(w, " ", cell(heatmapLevel(v, heatmap.Max)))
+			if v > 0 && v == heatmap.Max && busiestDay < 0 {
+				busiestDay, busiestHour = d, h
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	legend := make([]string, len(heatmapShades))
+	for level := range legend {
+		legend[level] = cell(level)
+	}
+	fmt.Fprintf(w, "\n    Less %s More\n\n", strings.Join(legend, " "))
+
+	noun := heatmapNoun(heatmap.Type)
+	fmt.Fprintf(w, "Total: %s\n", countNoun(heatmap.Total, noun))
+	if busiestDay >= 0 {
+		fmt.Fprintf(w, "Busiest: %s %02d:00-%02d:00 (%s)\n", heatmap.Weekdays[busiestDay],
+			heatmap.Hours[busiestHour], heatmap.Hours[busiestHour]+1, countNoun(heatmap.Max, noun))
+	}
+}
+
+// heatmapLevel scales a count to a heatmap level: 0 when empty, up to 4 for the largest count
+func heatmapLevel(v, peak int64) int {
+	if v <= 0 || peak <= 0 {
+		return 0
+	}
+	levels := int64(len(heatmapShades) - 1)
+	return int((v*levels + peak - 1) / peak)
+}
+
+// useColor reports whether output goes to a terminal that may be colored: standard output is
+// a terminal and NO_COLOR is not set
+func useColor() bool {
+	return toStdout(outputFile) && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var identityPreview bool
+
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "Manage member aliases",
+	Long: `Manage the member aliases of an organization or user. Events are recorded under the
+author's GitHub login, or the commit author's name when the commit isn't linked to an
+account, so one person may show up as several members: a second account, an unlinked
+work email, a name spelled differently. An alias maps such a name to a canonical member;
+member metrics, rankings and time series then count the alias's activity as the member's.
+
+Aliases are matched case-insensitively. Collected events are not changed, so removing an
+alias splits the activity up again. A running API server picks up changes once its cached
+results expire.`,
+}
+
+var identityAddCmd = &cobra.Command{
+	Use:   "add [owner] [member] [alias...]",
+	Short: "Count the activity of aliases as a member's",
+	Long: `Map one or more aliases to a member. An alias already mapped to another member is moved.
+
+With --preview nothing is saved; instead the metrics of the affected members are shown
+before and after the merge, over the range given with --start and --end.`,
+	Example: `  github-metrics identity add my-org alice alice-work "Alice Smith"
+  github-metrics identity add my-org alice alice@example.com --preview --start 2024-01-01`,
+	SilenceUsage: true,
+	Args:         cobra.MinimumNArgs(3),
+	RunE:         runIdentityAdd,
+}
+
+var identityListCmd = &cobra.Command{
+	Use:          "list [owner]",
+	Short:        "List member aliases",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runIdentityList,
+}
+
+var identityShowCmd = &cobra.Command{
+	Use:   "show [owner] [name]",
+	Short: "Show the member a name belongs to and its aliases",
+	Long: `Resolve a name events may be recorded under, a member or one of its aliases, to the
+member its activity counts towards, and list every name of that member.`,
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE:         runIdentityShow,
+}
+
+var identityRemoveCmd = &cobra.Command{
+	Use:          "remove [owner] [alias...]",
+	Short:        "Remove member aliases",
+	SilenceUsage: true,
+	Args:         cobra.MinimumNArgs(2),
+	RunE:         runIdentityRemove,
+}
+
+func init() {
+	identityAddCmd.Flags().BoolVar(&identityPreview, "preview", false, "show how the members' metrics would change without saving the aliases")
+
+	rootCmd.AddCommand(identityCmd)
+	identityCmd.AddCommand(identityAddCmd)
+	identityCmd.AddCommand(identityListCmd)
+	identityCmd.AddCommand(identityShowCmd)
+	identityCmd.AddCommand(identityRemoveCmd)
+}
+
+func runIdentityAdd(cmd *cobra.Command, args []string) error {
+	owner, member := args[0], strings.TrimSpace(args[1])
+	if member == "" {
+		return fmt.Errorf("member must not be empty")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	current, err := store.ListMemberAliases(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("failed to list member aliases: %w", err)
+	}
+	ids := domain.NewIdentities(owner, current)
+	if a := ids.Alias(member); a != nil {
+		return fmt.Errorf("%s is an alias of %s; add aliases to %s instead", member, a.Member, a.Member)
+	}
+
+	// Storage looks members up by exact name, so an alias is saved as spelled in the events,
+	// or as it was first saved, and only once
+	recorded, err := store.GetMembersWithMetrics(ctx, owner, domain.TimeRange{End: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to get members: %w", err)
+	}
+	var added []*domain.MemberAlias
+	for _, name := range args[2:] {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			return fmt.Errorf("alias must not be empty")
+		case strings.EqualFold(name, member):
+			return fmt.Errorf("%s can't be an alias of itself", name)
+		}
+		for _, m := range recorded {
+			if strings.EqualFold(m.Member, name) {
+				name = m.Member
+			}
+		}
+		if len(ids.Identity(name).Aliases) > 0 {
+			return fmt.Errorf("%s has aliases of its own; remove them before making it an alias of %s", name, member)
+		}
+		if a := ids.Alias(name); a != nil {
+			name = a.Alias
+		}
+		added = append(added, &domain.MemberAlias{Owner: owner, Alias: name, Member: member})
+	}
+
+	if identityPreview {
+		return previewIdentityAdd(ctx, cfg, store, owner, member, current, added)
+	}
+
+	for _, alias := range added {
+		previous := ""
+		if a := ids.Alias(alias.Alias); a != nil {
+			previous = a.Member
+		}
+		if err := store.SaveMemberAlias(ctx, alias); err != nil {
+			return fmt.Errorf("failed to save member alias: %w", err)
+		}
+		switch previous {
+		case "":
+			fmt.Printf("Added alias %s of %s\n", alias.Alias, member)
+		case member:
+			fmt.Printf("%s is already an alias of %s\n", alias.Alias, member)
+		default:
+			fmt.Printf("Moved alias %s from %s to %s\n", alias.Alias, previous, member)
+		}
+	}
+	return nil
+}
+
+// identityPreviewJSON is the JSON form of a member's metrics before and after adding aliases
+type identityPreviewJSON struct {
+	Member string            `json:"member"`
+	Before memberMetricsJSON `json:"before"`
+	After  memberMetricsJSON `json:"after"`
+}
+
+// previewIdentityAdd shows the metrics of the members affected by adding aliases, as they are
+// and as they would be with the aliases
+func previewIdentityAdd(ctx context.Context, cfg *config.Config, store storage.Storage, owner, member string, current, added []*domain.MemberAlias) error {
+	// The members whose metrics change: the member, the aliases and the members they move from
+	affected := map[string]bool{member: true}
+	proposed := make([]*domain.MemberAlias, 0, len(current)+len(added))
+	replaced := make(map[string]bool)
+	for _, alias := range added {
+		affected[alias.Alias] = true
+		replaced[alias.Alias] = true
+	}
+	for _, a := range current {
+		if replaced[a.Alias] {
+			affected[a.Member] = true
+			continue
+		}
+		proposed = append(proposed, a)
+	}
+	proposed = append(proposed, added...)
+
+	timeRange := getTimeRange()
+	before, err := newAggregator(cfg, store).GetMembersMetrics(ctx, owner, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get member metrics: %w", err)
+	}
+	previewStore := &aliasPreviewStorage{Storage: store, owner: owner, aliases: proposed}
+	after, err := newAggregator(cfg, previewStore).GetMembersMetrics(ctx, owner, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get member metrics: %w", err)
+	}
+
+	names := make([]string, 0, len(affected))
+	for name := range affected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	changes := make([]identityPreviewJSON, 0, len(names))
+	table := viewTable{Header: []string{"Member", "Commits", "PRs", "Additions", "Deletions", "Deploys"}}
+	for _, name := range names {
+		b, a := memberMetricsOf(before, name), memberMetricsOf(after, name)
+		if name != member && b == a {
+			// An alias without activity of its own in the range
+			continue
+		}
+		changes = append(changes, identityPreviewJSON{Member: b.Member, Before: b, After: a})
+		table.Rows = append(table.Rows, []string{
+			b.Member,
+			formatChange(b.Commits, a.Commits),
+			formatChange(b.PRs, a.PRs),
+			formatChange(b.Additions, a.Additions),
+			formatChange(b.Deletions, a.Deletions),
+			formatChange(b.Deploys, a.Deploys),
+		})
+	}
+
+	return render(&view{
+		Title:     fmt.Sprintf("Preview: aliases of %s in %s (not saved)", member, owner),
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value:     changes,
+	})
+}
+
+// memberMetricsOf returns the metrics of member in metrics, matched case-insensitively like
+// aliases, or zero metrics if it has none
+func memberMetricsOf(metrics []*domain.MemberMetrics, member string) memberMetricsJSON {
+	for _, m := range metrics {
+		if strings.EqualFold(m.Member, member) {
+			return newMemberMetricsJSON(m)
+		}
+	}
+	return memberMetricsJSON{Member: member}
+}
+
+// formatChange formats a value before and after a change, or the value if it doesn't change
+func formatChange(before, after int64) string {
+	if before == after {
+		return fmt.Sprintf("%d", before)
+	}
+	return fmt.Sprintf("%d → %d", before, after)
+}
+
+// aliasPreviewStorage is a storage whose member aliases of one owner are replaced, to
+// aggregate metrics as they would be with other aliases
+type aliasPreviewStorage struct {
+	storage.Storage
+	owner   string
+	aliases []*domain.MemberAlias
+}
+
+// ListMemberAliases returns the replaced aliases for the previewed owner
+func (s *aliasPreviewStorage) ListMemberAliases(ctx context.Context, owner string) ([]*domain.MemberAlias, error) {
+	if owner == s.owner {
+		return s.aliases, nil
+	}
+	return s.Storage.ListMemberAliases(ctx, owner)
+}
+
+// memberAliasJSON is the JSON form of a member alias
+type memberAliasJSON struct {
+	Alias     string    `json:"alias"`
+	Member    string    `json:"member"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func runIdentityList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	aliases, err := store.ListMemberAliases(context.Background(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to list member aliases: %w", err)
+	}
+
+	if outputJSON {
+		out := make([]memberAliasJSON, 0, len(aliases))
+		for _, a := range aliases {
+			out = append(out, memberAliasJSON{Alias: a.Alias, Member: a.Member, CreatedAt: a.CreatedAt})
+		}
+		return printJSON(out)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Member", "Alias", "Created"})
+	for _, a := range aliases {
+		table.Append([]string{a.Member, a.Alias, a.CreatedAt.Format("2006-01-02 15:04")})
+	}
+	table.Render()
+
+	return nil
+}
+
+// identityJSON is the JSON form of a member's identity
+type identityJSON struct {
+	Member  string   `json:"member"`
+	Aliases []string `json:"aliases"`
+}
+
+func runIdentityShow(cmd *cobra.Command, args []string) error {
+	owner, name := args[0], strings.TrimSpace(args[1])
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	aliases, err := store.ListMemberAliases(context.Background(), owner)
+	if err != nil {
+		return fmt.Errorf("failed to list member aliases: %w", err)
+	}
+	ids := domain.NewIdentities(owner, aliases)
+	identity := ids.Identity(ids.Resolve(name))
+
+	if outputJSON {
+		out := identityJSON{Member: identity.Member, Aliases: identity.Aliases}
+		if out.Aliases == nil {
+			out.Aliases = []string{}
+		}
+		return printJSON(out)
+	}
+
+	fmt.Printf("Member: %s\n", identity.Member)
+	if len(identity.Aliases) == 0 {
+		fmt.Println("Aliases: none")
+		return nil
+	}
+	fmt.Printf("Aliases: %s\n", strings.Join(identity.Aliases, ", "))
+	return nil
+}
+
+func runIdentityRemove(cmd *cobra.Command, args []string) error {
+	owner := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	current, err := store.ListMemberAliases(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("failed to list member aliases: %w", err)
+	}
+
+	ids := domain.NewIdentities(owner, current)
+	for _, name := range args[1:] {
+		alias := ids.Alias(strings.TrimSpace(name))
+		if alias == nil {
+			return fmt.Errorf("%s is not an alias in %s", name, owner)
+		}
+		if err := store.DeleteMemberAlias(ctx, owner, alias.Alias); err != nil {
+			return fmt.Errorf("failed to delete member alias: %w", err)
+		}
+		fmt.Printf("Removed alias %s of %s\n", alias.Alias, alias.Member)
+	}
+	return nil
+}
@@ -2,17 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
 	"github.com/kurihiro0119/github-activity-metrics/internal/collector"
 	"github.com/kurihiro0119/github-activity-metrics/internal/config"
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/logging"
+	"github.com/kurihiro0119/github-activity-metrics/internal/monitoring"
 	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
 	"github.com/kurihiro0119/github-activity-metrics/internal/storage/postgres"
 	"github.com/kurihiro0119/github-activity-metrics/internal/storage/sqlite"
@@ -20,10 +25,33 @@ import (
 
 var (
 	cfgFile     string
+	profileName string
 	outputJSON  bool
 	startDate   string
 	endDate     string
 	granularity string
+	excludeBots bool
+	logVerbose  bool
+	logQuiet    bool
+	logFormat   string
+
+	defaultRange string // DEFAULT_RANGE, the start of the range without --start
+
+	// logLevel is the level of the default logger, which schedule sets from LOG_LEVEL unless
+	// --verbose or --quiet is given
+	logLevel = new(slog.LevelVar)
+
+	collectResume      bool
+	collectIncremental bool
+	collectSinceSync   bool
+	collectDryRun      bool
+	collectConcurrency int
+	collectTargetsFile string
+	collectReposFile   string
+	collectParallel    int
+	collectRefresh     bool
+
+	showMemberFilter string
 )
 
 var rootCmd = &cobra.Command{
@@ -32,63 +60,160 @@ var rootCmd = &cobra.Command{
 	Long: `A CLI tool for collecting and visualizing GitHub organization activity metrics.
 
 This tool collects commit, pull request, and deployment data from GitHub
-and provides aggregated metrics for organizations, repositories, and members.`,
+and provides aggregated metrics for organizations, repositories, and members.
+
+Progress, warnings and errors are logged to standard error, leaving standard output to the
+results; --log-format json makes them parseable for CI.
+
+Settings come from the environment, then .env, then a YAML configuration file: --config,
+$GITHUB_METRICS_CONFIG, ./github-metrics.yaml or $XDG_CONFIG_HOME/github-metrics/config.yaml.
+--profile applies one of the file's named profiles (such as work and personal accounts).`,
+	// main reports errors, as a log record with --log-format json
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		config.SetFile(cfgFile)
+		config.SetProfile(profileName)
+		if _, _, err := dateFlags(time.Now()); err != nil {
+			return err
+		}
+		switch granularity {
+		case "day", "week", "month":
+		default:
+			return fmt.Errorf("invalid --granularity %q: must be one of day, week, month", granularity)
+		}
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		applyConfigDefaults(cmd)
+		return nil
+	},
 }
 
 var collectCmd = &cobra.Command{
-	Use:   "collect [org|user]",
+	Use:   "collect [org|user...]",
 	Short: "Collect data from GitHub",
-	Long:  `Collect activity data from a GitHub organization or user account and store it locally.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runCollect,
+	Long: `Collect activity data from a GitHub organization or user account and store it locally.
+
+With --resume, the unfinished batch for the owner and --start/--end range is continued and
+repositories it already collected are skipped. Without --start/--end, the owner's most recent
+unfinished batch is resumed with its own range.
+
+With --incremental, each repository is collected from the time it was last synced up to
+--end (now by default); repositories that were never synced start at --start.
+
+With --since-last-sync, each owner is collected from the end of its latest completed batch
+up to --end, so a cron job picks up exactly where the previous run finished, without gaps
+or overlaps. An owner without a completed batch starts one month ago.
+
+--repos and --exclude-repos restrict the collection to repositories whose names match glob
+patterns (e.g. "service-*"); other repositories are neither stored nor collected. Without
+them, COLLECT_REPOS and COLLECT_EXCLUDE_REPOS apply.
+
+With --dry-run, the repositories that would be collected are listed with their time range
+and an estimate of the GitHub API requests, without fetching events or writing to storage.
+
+Several owners can be given as arguments and/or listed in --targets-file (one per line, #
+starts a comment). They are collected one after another, or --parallel at a time, sharing
+the GitHub rate limit; a summary of every owner is printed at the end, and the command fails
+when any owner could not be collected.
+
+Instead of owners, --repos-file lists the repositories to collect as owner/repo lines ("-"
+reads them from standard input), so a curated list drives the collection rather than every
+repository of the owners. --exclude-repos still applies.
+
+--notify-slack posts the events collected, the failures and the members whose activity
+changed the most to the Slack incoming webhook SLACK_WEBHOOK_URL when the run finishes.`,
+	Example: `  github-metrics collect my-org
+  github-metrics collect org1 org2 --incremental
+  github-metrics collect my-org --since-last-sync
+  github-metrics collect --targets-file owners.txt --parallel 2
+  github-metrics collect --repos-file repos.txt`,
+	Args:         collectTargetArgs,
+	SilenceUsage: true,
+	RunE:         runCollect,
 }
 
 var showCmd = &cobra.Command{
 	Use:   "show [org]",
 	Short: "Show organization metrics",
-	Long:  `Display aggregated metrics for a GitHub organization.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runShowOrg,
+	Long: `Display aggregated metrics for a GitHub organization, or with --repos/--exclude-repos for
+the repositories whose names match glob patterns. With --member only that member's activity
+is counted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowOrg),
 }
 
 var showMembersCmd = &cobra.Command{
 	Use:   "members [org]",
 	Short: "Show member metrics",
-	Long:  `Display metrics for all members in a GitHub organization.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runShowMembers,
+	Long: `Display metrics for all members in a GitHub organization. With --repos/--exclude-repos only
+activity in the repositories whose names match glob patterns is counted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowMembers),
 }
 
 var showMemberCmd = &cobra.Command{
 	Use:   "member [org] [member]",
 	Short: "Show metrics for a specific member",
-	Long:  `Display metrics for a specific member in a GitHub organization.`,
-	Args:  cobra.ExactArgs(2),
-	RunE:  runShowMember,
+	Long: `Display metrics for a specific member in a GitHub organization. With --repos/--exclude-repos
+only activity in the repositories whose names match glob patterns is counted.`,
+	Args: cobra.ExactArgs(2),
+	RunE: watchable(runShowMember),
 }
 
 var showReposCmd = &cobra.Command{
 	Use:   "repos [org]",
 	Short: "Show repository metrics",
-	Long:  `Display metrics for all repositories in a GitHub organization.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runShowRepos,
+	Long: `Display metrics for all repositories in a GitHub organization, or with --repos/--exclude-repos
+for the repositories whose names match glob patterns.`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowRepos),
 }
 
 var showRepoCmd = &cobra.Command{
 	Use:   "repo [org] [repo]",
 	Short: "Show metrics for a specific repository",
-	Long:  `Display metrics for a specific repository in a GitHub organization.`,
-	Args:  cobra.ExactArgs(2),
-	RunE:  runShowRepo,
+	Long: `Display metrics for a specific repository in a GitHub organization. With --member only
+that member's activity in the repository is counted.`,
+	Args: cobra.ExactArgs(2),
+	RunE: watchable(runShowRepo),
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .env)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "configuration file profile to use (default $GITHUB_METRICS_PROFILE or the file's profile setting)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "YAML or TOML configuration file (default ./github-metrics.yaml or $XDG_CONFIG_HOME/github-metrics/config.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&outputJSON, "json", false, "output in JSON format")
-	rootCmd.PersistentFlags().StringVar(&startDate, "start", "", "start date (YYYY-MM-DD)")
-	rootCmd.PersistentFlags().StringVar(&endDate, "end", "", "end date (YYYY-MM-DD)")
-	rootCmd.PersistentFlags().StringVar(&granularity, "granularity", "day", "time granularity (day, week, month)")
+	rootCmd.PersistentFlags().StringVar(&outputFile, "output", "", "write the results of show, export, report and badge to this file instead of standard output")
+	rootCmd.PersistentFlags().StringVar(&startDate, "start", "", "start date: YYYY-MM-DD, a quarter such as 2024-Q1, or a time ago such as 7d, 2w, 1m, 1y (default DEFAULT_RANGE or 1m)")
+	rootCmd.PersistentFlags().StringVar(&endDate, "end", "", "end date: YYYY-MM-DD, a quarter such as 2024-Q4 (its last day), or a time ago such as 1w")
+	rootCmd.PersistentFlags().StringVar(&granularity, "granularity", "day", "time granularity (day, week, month); DEFAULT_GRANULARITY sets the default")
+	rootCmd.PersistentFlags().BoolVarP(&logVerbose, "verbose", "v", false, "also log debug messages, such as per-repository progress")
+	rootCmd.PersistentFlags().BoolVarP(&logQuiet, "quiet", "q", false, "only log warnings and errors")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "log format (text, json)")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.PersistentFlags().BoolVar(&excludeBots, "exclude-bots", false, "leave bot accounts out of member tables and rankings (default EXCLUDE_BOTS)")
+
+	collectCmd.Flags().BoolVar(&collectResume, "resume", false, "resume an unfinished batch, skipping repositories already collected")
+	collectCmd.Flags().BoolVar(&collectIncremental, "incremental", false, "collect each repository from its last sync time")
+	collectCmd.Flags().BoolVar(&collectSinceSync, "since-last-sync", false, "start at the end of the owner's latest completed batch")
+	collectCmd.Flags().BoolVar(&collectDryRun, "dry-run", false, "list what would be collected and estimate the API requests, without collecting")
+	collectCmd.Flags().IntVar(&collectConcurrency, "concurrency", 0, "repositories collected at once (default COLLECT_CONCURRENCY, or 5)")
+	collectCmd.Flags().StringVar(&collectTargetsFile, "targets-file", "", "file listing owners to collect, one per line")
+	collectCmd.Flags().StringVar(&collectReposFile, "repos-file", "", `file listing repositories to collect as owner/repo, one per line ("-" reads standard input)`)
+	collectCmd.Flags().IntVar(&collectParallel, "parallel", 1, "owners collected at once")
+	collectCmd.Flags().BoolVar(&collectRefresh, "refresh-repos", false, "list repositories from GitHub instead of the cached list (COLLECT_REPO_CACHE_TTL)")
+	collectCmd.MarkFlagsMutuallyExclusive("resume", "incremental", "since-last-sync")
+	collectCmd.MarkFlagsMutuallyExclusive("repos-file", "targets-file")
+	addNotifyFlags(collectCmd)
+	for _, cmd := range []*cobra.Command{collectCmd, showCmd, showMembersCmd, showMemberCmd, showReposCmd} {
+		addRepoFilterFlags(cmd)
+	}
+	collectCmd.MarkFlagsMutuallyExclusive("repos-file", "repos")
+	showCmd.Flags().StringVar(&showMemberFilter, "member", "", "only this member's activity")
+	showRepoCmd.Flags().StringVar(&showMemberFilter, "member", "", "only this member's activity in the repository")
+
+	showCmd.PersistentFlags().StringVar(&showFormat, "format", "", "output format (table, json, yaml, csv; default table, or json with --json)")
+	showCmd.PersistentFlags().IntVar(&showWatch, "watch", 0, "refresh the output every N seconds until interrupted")
 
 	rootCmd.AddCommand(collectCmd)
 	rootCmd.AddCommand(showCmd)
@@ -99,35 +224,196 @@ func init() {
 }
 
 func main() {
+	registerCompletions()
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if logFormat == "json" {
+			slog.Error("command failed", "error", err.Error())
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		code := 1
+		var exit *exitError
+		if errors.As(err, &exit) {
+			code = exit.code
+		}
+		os.Exit(code)
 	}
 }
 
+// exitError makes the command exit with code instead of 1
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
 func getStorage(cfg *config.Config) (storage.Storage, error) {
+	var opts []storage.OpenOption
+	if !cfg.AutoMigrate {
+		opts = append(opts, storage.WithoutMigrate())
+	}
 	switch cfg.StorageType {
 	case "postgres":
-		return postgres.NewPostgresStorage(cfg.PostgresURL)
+		return postgres.NewPostgresStorage(cfg.PostgresURL, opts...)
 	default:
-		return sqlite.NewSQLiteStorage(cfg.SQLitePath)
+		return sqlite.NewSQLiteStorage(cfg.SQLitePath, opts...)
+	}
+}
+
+// openStorage loads the configuration and opens the configured database
+func openStorage() (storage.Storage, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := getStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+// printJSON writes v to standard output as indented JSON
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// setupLogging makes the default logger write to standard error at the level and in the
+// format chosen with --verbose/--quiet/--log-format. Lines go through logOutput so they
+// don't tear the collection progress display.
+func setupLogging() error {
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("invalid --log-format %q: must be 'text' or 'json'", logFormat)
+	}
+	logLevel.Set(slog.LevelInfo)
+	switch {
+	case logVerbose:
+		logLevel.Set(slog.LevelDebug)
+	case logQuiet:
+		logLevel.Set(slog.LevelWarn)
+	}
+	slog.SetDefault(logging.NewLeveled(logOutput, logFormat, logLevel))
+	return nil
+}
+
+// collectorOptions returns the options of GitHub collectors for cfg
+func collectorOptions(cfg *config.Config) []collector.Option {
+	opts := []collector.Option{
+		collector.WithAPIURL(cfg.GitHubAPIURL),
+		collector.WithRateLimitBudget(float64(cfg.GitHubRateLimitBudget) / 100),
+	}
+	// An invalid CA_CERT_PATH or App key fails config.Validate before collecting
+	if transport, err := cfg.HTTPTransport(); err != nil {
+		slog.Warn("ignoring the proxy and CA settings", "error", err)
+	} else if transport != nil {
+		opts = append(opts, collector.WithTransport(transport))
+	}
+	if cfg.UsesGitHubApp() {
+		if key, err := cfg.GitHubAppKey(); err != nil {
+			slog.Warn("ignoring the GitHub App settings", "error", err)
+		} else {
+			opts = append(opts, collector.WithAppAuth(&collector.AppCredentials{
+				AppID:          cfg.GitHubAppID,
+				InstallationID: cfg.GitHubAppInstallationID,
+				PrivateKey:     key,
+			}))
+		}
+	}
+	return opts
+}
+
+// storeCollectorOptions returns the options of the collectors of collections, which collect
+// the deploys of the repositories' deploy definitions in store and cache repository lists in
+// it for COLLECT_REPO_CACHE_TTL; with --refresh-repos they list repositories from GitHub and
+// refresh the cache
+func storeCollectorOptions(cfg *config.Config, store storage.Storage) []collector.Option {
+	opts := append(collectorOptions(cfg), collector.WithDeployDefinitions(store))
+	if cfg.CollectRepoCacheTTL > 0 {
+		ttl := cfg.CollectRepoCacheTTL
+		if collectRefresh {
+			ttl = 0
+		}
+		opts = append(opts, collector.WithRepoListCache(store, ttl))
+	}
+	return opts
+}
+
+func newAggregator(cfg *config.Config, store storage.Storage) aggregator.Aggregator {
+	opts := []aggregator.Option{aggregator.WithStreamChunk(cfg.StreamChunk)}
+	if cfg.DedupMergeCommits {
+		opts = append(opts, aggregator.WithMergeCommitDedup())
+	}
+	exclude := cfg.ExcludeBots
+	if rootCmd.PersistentFlags().Changed("exclude-bots") {
+		exclude = excludeBots
+	}
+	if exclude {
+		opts = append(opts, aggregator.WithBotExclusion(cfg.BotPatterns...))
+	}
+	if p := memberPseudonyms(cfg); p != nil {
+		opts = append(opts, aggregator.WithPseudonyms(p))
+	}
+	if components := repoComponents(cfg); len(components) > 0 {
+		opts = append(opts, aggregator.WithComponents(components))
+	}
+	return aggregator.NewAggregator(store, opts...)
+}
+
+// repoComponents returns the components of monorepos configured with REPO_COMPONENTS
+func repoComponents(cfg *config.Config) []domain.Component {
+	components := make([]domain.Component, 0, len(cfg.RepoComponents))
+	for _, c := range cfg.RepoComponents {
+		components = append(components, domain.Component{Owner: c.Owner, Repo: c.Repo, Name: c.Name, Paths: c.Paths})
+	}
+	return components
+}
+
+// memberPseudonyms returns the pseudonyms member usernames are shown as, or nil unless
+// PSEUDONYMIZE_MEMBERS is enabled
+func memberPseudonyms(cfg *config.Config) *aggregator.Pseudonyms {
+	if !cfg.PseudonymizeMembers {
+		return nil
+	}
+	return aggregator.NewPseudonyms(cfg.PseudonymSecret)
+}
+
+// applyConfigDefaults applies DEFAULT_RANGE and DEFAULT_GRANULARITY to the flags that weren't
+// given. Invalid settings are only logged, so that commands such as config validate still run.
+func applyConfigDefaults(cmd *cobra.Command) {
+	defaults, err := config.LoadDefaults()
+	if err != nil {
+		slog.Warn("Ignoring default settings", "error", err)
+		return
+	}
+	defaultRange = defaults.Range
+	if defaults.Granularity != "" && !cmd.Flags().Changed("granularity") {
+		granularity = defaults.Granularity
 	}
 }
 
 func getTimeRange() domain.TimeRange {
 	now := time.Now()
 	start := now.AddDate(0, -1, 0)
-	end := now
-
-	if startDate != "" {
-		if t, err := time.Parse("2006-01-02", startDate); err == nil {
-			start = t
+	if defaultRange != "" {
+		// Validated by config.LoadDefaults
+		if s, err := parseDateFlag("start", defaultRange, now, false); err == nil {
+			start = s
 		}
 	}
+	end := now
 
-	if endDate != "" {
-		if t, err := time.Parse("2006-01-02", endDate); err == nil {
-			end = t
+	// The flags were validated before the command ran; relative ones follow now, as in --watch
+	if s, e, err := dateFlags(now); err == nil {
+		if !s.IsZero() {
+			start = s
+		}
+		if !e.IsZero() {
+			end = e
 		}
 	}
 
@@ -139,65 +425,310 @@ func getTimeRange() domain.TimeRange {
 }
 
 func runCollect(cmd *cobra.Command, args []string) error {
-	target := args[0] // org or user
+	var targets []string // orgs or users
+	var err error
+	if collectReposFile == "" {
+		if targets, err = collectTargets(args); err != nil {
+			return err
+		}
+	}
+	if collectParallel < 1 {
+		return fmt.Errorf("invalid --parallel %d: must be at least 1", collectParallel)
+	}
+	if collectSinceSync && startDate != "" {
+		return fmt.Errorf("--since-last-sync and --start can't be used together; the start is where the last sync ended")
+	}
 
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	filter, err := collectRepoFilter(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("concurrency") {
+		if collectConcurrency < 1 {
+			return fmt.Errorf("invalid --concurrency %d: must be at least 1", collectConcurrency)
+		}
+		cfg.CollectConcurrency = collectConcurrency
+	}
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
+	opts := collectOptions{
+		TimeRange:     getTimeRange(),
+		ExplicitRange: startDate != "" || endDate != "",
+		Resume:        collectResume,
+		Incremental:   collectIncremental,
+		SinceLastSync: collectSinceSync,
+		Repos:         filter,
+	}
+	if collectReposFile != "" {
+		// The listed repositories replace --repos and COLLECT_REPOS; exclusions still apply
+		if targets, opts.OwnerRepos, err = readReposFile(collectReposFile, filter.exclude); err != nil {
+			return err
+		}
+	}
+	ctx := context.Background()
+	if collectDryRun {
+		for _, target := range targets {
+			if err := dryRunCollect(ctx, cfg, target, opts); err != nil {
+				if len(targets) > 1 {
+					return fmt.Errorf("%s: %w", target, err)
+				}
+				return err
+			}
+		}
+		return nil
+	}
+
+	notifier, err := slackNotifier(cfg)
+	if err != nil {
+		return err
+	}
+	hook, err := metricsWebhook(cfg)
+	if err != nil {
+		return err
+	}
+
 	store, err := getStorage(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer store.Close()
 
-	coll := collector.NewGitHubCollector(cfg.GitHubToken)
-	ctx := context.Background()
-	timeRange := getTimeRange()
+	stopMetrics, err := serveMetrics(cfg.CollectMetricsAddr)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	if len(targets) == 1 {
+		start := time.Now()
+		result, err := collectOwner(ctx, cfg, store, targets[0], opts)
+		if err != nil {
+			result = &collectResult{Owner: targets[0]}
+		}
+		result.finish(err, time.Since(start))
+		results := []*collectResult{result}
+		sendMetricsWebhooks(ctx, hook, cfg, store, result, opts.TimeRange.End)
+		if notifier != nil {
+			sendNotification(ctx, notifier, collectSummary(ctx, cfg, store, results, opts.TimeRange))
+		}
+		if err != nil {
+			return err
+		}
+		if outputJSON {
+			if err := printCollectSummary(results); err != nil {
+				return err
+			}
+		} else {
+			printRepoFailures(results)
+		}
+		return collectError(results)
+	}
+
+	// One collector for every owner, so they wait on the same rate limit; owners with a token
+	// of their own get their own collector
+	opts.Collector = collector.NewGitHubCollector(cfg.GitHubToken, storeCollectorOptions(cfg, store)...)
+	opts.Plain = collectParallel > 1
+	results := collectOwners(ctx, cfg, store, targets, opts, collectParallel)
+	if err := printCollectSummary(results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		sendMetricsWebhooks(ctx, hook, cfg, store, result, opts.TimeRange.End)
+	}
+	sendNotification(ctx, notifier, collectSummary(ctx, cfg, store, results, opts.TimeRange))
+	return collectError(results)
+}
+
+// collectOptions controls a collection run
+type collectOptions struct {
+	TimeRange     domain.TimeRange
+	ExplicitRange bool        // TimeRange was given with --start/--end rather than defaulted
+	Resume        bool        // continue an unfinished batch, skipping completed repositories
+	Incremental   bool        // collect each repository from its last sync time
+	SinceLastSync bool        // start at the end of the owner's latest completed batch
+	Repos         *repoFilter // repositories to collect; nil collects all
+
+	// OwnerRepos are the repositories of each owner, keyed by lower-case name, listed in
+	// --repos-file; an owner's filter replaces Repos
+	OwnerRepos map[string]*repoFilter
+
+	// Batch is the batch to collect into, with its own range, instead of the one for
+	// TimeRange; with Resume its completed repositories are skipped
+	Batch *domain.CollectionBatch
+
+	// Collector is shared by the collections of several owners so they share the rate
+	// limit; nil creates one
+	Collector collector.Collector
+	// Plain logs the progress line by line instead of drawing the live display, which
+	// collections running side by side would tear
+	Plain bool
+}
+
+// reposOf returns the filter selecting the repositories of owner to collect
+func (o collectOptions) reposOf(owner string) *repoFilter {
+	if f, ok := o.OwnerRepos[strings.ToLower(owner)]; ok {
+		return f
+	}
+	return o.Repos
+}
+
+// collectOwner collects an organization's or user's repositories, members, teams and events
+// into store, recording its progress as a collection batch
+func collectOwner(ctx context.Context, cfg *config.Config, store storage.Storage, target string, opts collectOptions) (*collectResult, error) {
+	opts.Repos = opts.reposOf(target)
+	coll := opts.Collector
+	if coll == nil || cfg.HasOwnToken(target) {
+		token := cfg.TokenFor(target)
+		if token == "" && !cfg.UsesGitHubApp() {
+			return nil, fmt.Errorf("no GitHub token for %s: set GITHUB_TOKEN or add %s to GITHUB_TOKENS", target, target)
+		}
+		coll = collector.NewGitHubCollector(token, storeCollectorOptions(cfg, store)...)
+	}
+	timeRange := opts.TimeRange
+	result := &collectResult{Owner: target}
+	var err error
+
+	if opts.SinceLastSync && opts.Batch == nil {
+		last, err := findLastCompletedBatch(ctx, store, cfg.Mode, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the last completed batch: %w", err)
+		}
+		if last == nil {
+			slog.Info("no completed batch; collecting the default range", "owner", target)
+		} else {
+			timeRange.Start = last.EndDate
+			if !timeRange.Start.Before(timeRange.End) {
+				slog.Info("already synced up to the end of the range; nothing to collect",
+					"owner", target, "synced_until", last.EndDate.Format(time.RFC3339))
+				return result, nil
+			}
+			slog.Info("collecting since the last completed batch", "owner", target, "batch_id", last.ID,
+				"since", timeRange.Start.Format(time.RFC3339))
+		}
+	}
 
 	// Create or get batch
-	batch := &domain.CollectionBatch{
-		Mode:      cfg.Mode,
-		Owner:     target,
-		StartDate: timeRange.Start,
-		EndDate:   timeRange.End,
-		Status:    "in_progress",
+	var batch *domain.CollectionBatch
+	if opts.Batch != nil {
+		batch = opts.Batch
+		timeRange.Start, timeRange.End = batch.StartDate, batch.EndDate
+	} else if opts.Resume && !opts.ExplicitRange {
+		batch, err = findUnfinishedBatch(ctx, store, cfg.Mode, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find batch to resume: %w", err)
+		}
+		if batch == nil {
+			return nil, fmt.Errorf("no unfinished batch to resume for %s; pass --start/--end to start one", target)
+		}
+		timeRange.Start, timeRange.End = batch.StartDate, batch.EndDate
+	} else {
+		batch, err = store.CreateOrGetBatch(ctx, &domain.CollectionBatch{
+			Mode:      cfg.Mode,
+			Owner:     target,
+			StartDate: timeRange.Start,
+			EndDate:   timeRange.End,
+			Status:    "in_progress",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create/get batch: %w", err)
+		}
 	}
-	batch, err = store.CreateOrGetBatch(ctx, batch)
-	if err != nil {
-		return fmt.Errorf("failed to create/get batch: %w", err)
+	slog.Info("collection batch", "owner", target, "batch_id", batch.ID)
+	result.BatchID = batch.ID
+	if batch.Status == "completed" && opts.Batch == nil {
+		if opts.Resume {
+			slog.Info("batch is already completed; nothing to resume", "batch_id", batch.ID)
+			return result, nil
+		}
+		slog.Info("batch was previously completed; re-running to check for new data", "batch_id", batch.ID)
 	}
-	fmt.Printf("Batch ID: %s\n", batch.ID)
-	if batch.Status == "completed" {
-		fmt.Printf("Note: This batch was previously completed. Re-running to check for new data.\n")
+
+	// Repositories already collected by an earlier run of the batch are skipped when resuming
+	completed := make(map[string]bool)
+	if opts.Resume {
+		completed, err = completedBatchRepos(ctx, store, batch.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch progress: %w", err)
+		}
+		if batch.Status != "in_progress" {
+			if err := store.UpdateBatchStatus(ctx, batch.ID, "in_progress"); err != nil {
+				slog.Warn("failed to update batch status", "batch_id", batch.ID, "error", err)
+			}
+		}
+		slog.Info("resuming batch", "batch_id", batch.ID, "skipped_repos", len(completed))
 	}
 
 	var repos []*domain.Repository
+	var collected *domain.CollectionResult
 	var totalEvents int
+	progress := newCollectProgress(target, coll.RateLimit, !opts.Plain)
+	defer progress.finish()
+
+	// Record per-repository progress so the API can report it while collection runs
+	collectCtx := collector.WithRepoStartHook(ctx, func(repo string) {
+		saveBatchRepoStatus(ctx, store, batch.ID, repo, domain.BatchRepoStatusProcessing, 0)
+		progress.repoStarted(repo)
+	})
+	collectCtx = collector.WithRepoErrorHook(collectCtx, func(repo string, err error) {
+		saveBatchRepoError(ctx, store, batch.ID, repo, err)
+		monitoring.ObserveRepoFailed(target, repo)
+		progress.repoFailed(repo, err)
+	})
+	collectCtx = collector.WithConcurrency(collectCtx, cfg.CollectConcurrency)
+	collectCtx = collector.WithRepoFilter(collectCtx, func(repo string) bool {
+		return !completed[repo] && opts.Repos.match(repo)
+	})
+
+	// Sync times recorded by earlier collections; repositories only move forward
+	lastSynced, err := repoSyncTimes(ctx, store, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository sync times: %w", err)
+	}
+	if opts.Incremental {
+		slog.Info("incremental collection", "synced_repos", len(lastSynced))
+		collectCtx = collector.WithRepoSince(collectCtx, func(repo string) time.Time {
+			return lastSynced[repo]
+		})
+	}
+	syncedUntil := timeRange.End
+	if now := time.Now(); syncedUntil.After(now) {
+		syncedUntil = now
+	}
+	repoByName := make(map[string]*domain.Repository)
 
 	if cfg.Mode == "user" {
-		fmt.Printf("Collecting data for user: %s\n", target)
-		fmt.Printf("Time range: %s to %s\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
+		slog.Info("collecting user data", "user", target,
+			"start", timeRange.Start.Format("2006-01-02"), "end", timeRange.End.Format("2006-01-02"))
 
 		// Collect repositories
-		fmt.Println("Fetching repositories...")
+		slog.Info("fetching repositories")
 		repos, err = coll.GetUserRepositories(ctx, target)
 		if err != nil {
-			return fmt.Errorf("failed to get repositories: %w", err)
+			return nil, fmt.Errorf("failed to get repositories: %w", err)
 		}
-		fmt.Printf("Found %d repositories\n", len(repos))
+		repos = selectRepos(repos, opts.Repos)
 
 		// Save repositories
+		pending := 0
 		for _, repo := range repos {
+			repoByName[repo.Name] = repo
 			if err := store.SaveRepository(ctx, repo); err != nil {
-				fmt.Printf("Warning: failed to save repository %s: %v\n", repo.Name, err)
+				slog.Warn("failed to save repository", "repo", repo.Name, "error", err)
+			}
+			if !completed[repo.Name] {
+				saveBatchRepoStatus(ctx, store, batch.ID, repo.Name, domain.BatchRepoStatusPending, 0)
+				pending++
 			}
 		}
+		progress.setTotal(pending)
+		result.Repos = pending
 
 		// Save user as member (for consistency)
 		now := time.Now()
@@ -210,14 +741,14 @@ func runCollect(cmd *cobra.Command, args []string) error {
 			UpdatedAt:   now,
 		}
 		if err := store.SaveMember(ctx, member); err != nil {
-			fmt.Printf("Warning: failed to save member %s: %v\n", member.Username, err)
+			slog.Warn("failed to save member", "member", member.Username, "error", err)
 		}
 
 		// Collect events and save incrementally per repository
-		fmt.Println("Collecting activity data...")
-		err = coll.CollectUserDataWithCallback(ctx, target, timeRange.Start, timeRange.End,
+		slog.Info("collecting activity data")
+		collected, err = coll.CollectUserDataWithCallback(collectCtx, target, timeRange.Start, timeRange.End,
 			func(repo string, progress float64) {
-				fmt.Printf("\rProgress: %.1f%% (%s)", progress*100, repo)
+				slog.Debug("collecting repository", "repo", repo, "progress", fmt.Sprintf("%.1f%%", progress*100))
 			},
 			func(repo string, events []*domain.Event) error {
 				// Save events for this repository
@@ -226,53 +757,79 @@ func runCollect(cmd *cobra.Command, args []string) error {
 						return fmt.Errorf("failed to save events for %s: %w", repo, err)
 					}
 					totalEvents += len(events)
-					fmt.Printf("\n  Saved %d events for %s\n", len(events), repo)
 				}
+				saveBatchRepoStatus(ctx, store, batch.ID, repo, domain.BatchRepoStatusCompleted, len(events))
+				markRepoSynced(ctx, store, repoByName[repo], lastSynced[repo], syncedUntil)
+				monitoring.ObserveRepoCollected(target, events)
+				progress.repoDone(repo, len(events))
 
 				return nil
 			})
 		if err != nil {
+			failUnfinishedBatchRepos(ctx, store, batch.ID)
 			store.UpdateBatchStatus(ctx, batch.ID, "failed")
-			return fmt.Errorf("failed to collect data: %w", err)
+			return nil, fmt.Errorf("failed to collect data: %w", err)
 		}
 	} else {
-		fmt.Printf("Collecting data for organization: %s\n", target)
-		fmt.Printf("Time range: %s to %s\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
+		slog.Info("collecting organization data", "org", target,
+			"start", timeRange.Start.Format("2006-01-02"), "end", timeRange.End.Format("2006-01-02"))
 
 		// Collect repositories
-		fmt.Println("Fetching repositories...")
+		slog.Info("fetching repositories")
 		repos, err = coll.GetRepositories(ctx, target)
 		if err != nil {
-			return fmt.Errorf("failed to get repositories: %w\nHint: Check if the organization name is correct and your token has 'read:org' permission", err)
+			return nil, fmt.Errorf("failed to get repositories: %w\nHint: Check if the organization name is correct and your token has 'read:org' permission", err)
 		}
-		fmt.Printf("Found %d repositories\n", len(repos))
+		repos = selectRepos(repos, opts.Repos)
 
 		// Save repositories
+		pending := 0
 		for _, repo := range repos {
+			repoByName[repo.Name] = repo
 			if err := store.SaveRepository(ctx, repo); err != nil {
-				fmt.Printf("Warning: failed to save repository %s: %v\n", repo.Name, err)
+				slog.Warn("failed to save repository", "repo", repo.Name, "error", err)
+			}
+			if !completed[repo.Name] {
+				saveBatchRepoStatus(ctx, store, batch.ID, repo.Name, domain.BatchRepoStatusPending, 0)
+				pending++
 			}
 		}
+		progress.setTotal(pending)
+		result.Repos = pending
 
 		// Collect members
-		fmt.Println("Fetching members...")
+		slog.Info("fetching members")
 		members, err := coll.GetMembers(ctx, target)
 		if err != nil {
-			fmt.Printf("Warning: failed to get members: %v\n", err)
+			slog.Warn("failed to get members", "error", err)
 		} else {
-			fmt.Printf("Found %d members\n", len(members))
+			slog.Info("found members", "count", len(members))
 			for _, member := range members {
 				if err := store.SaveMember(ctx, member); err != nil {
-					fmt.Printf("Warning: failed to save member %s: %v\n", member.Username, err)
+					slog.Warn("failed to save member", "member", member.Username, "error", err)
+				}
+			}
+		}
+
+		// Collect teams
+		slog.Info("fetching teams")
+		teams, err := coll.GetTeams(ctx, target)
+		if err != nil {
+			slog.Warn("failed to get teams", "error", err)
+		} else {
+			slog.Info("found teams", "count", len(teams))
+			for _, team := range teams {
+				if err := store.SaveTeam(ctx, team); err != nil {
+					slog.Warn("failed to save team", "team", team.Slug, "error", err)
 				}
 			}
 		}
 
 		// Collect events and save incrementally per repository
-		fmt.Println("Collecting activity data...")
-		err = coll.CollectOrganizationDataWithCallback(ctx, target, timeRange.Start, timeRange.End,
+		slog.Info("collecting activity data")
+		collected, err = coll.CollectOrganizationDataWithCallback(collectCtx, target, timeRange.Start, timeRange.End,
 			func(repo string, progress float64) {
-				fmt.Printf("\rProgress: %.1f%% (%s)", progress*100, repo)
+				slog.Debug("collecting repository", "repo", repo, "progress", fmt.Sprintf("%.1f%%", progress*100))
 			},
 			func(repo string, events []*domain.Event) error {
 				// Save events for this repository
@@ -281,26 +838,218 @@ func runCollect(cmd *cobra.Command, args []string) error {
 						return fmt.Errorf("failed to save events for %s: %w", repo, err)
 					}
 					totalEvents += len(events)
-					fmt.Printf("\n  Saved %d events for %s\n", len(events), repo)
 				}
+				saveBatchRepoStatus(ctx, store, batch.ID, repo, domain.BatchRepoStatusCompleted, len(events))
+				markRepoSynced(ctx, store, repoByName[repo], lastSynced[repo], syncedUntil)
+				monitoring.ObserveRepoCollected(target, events)
+				progress.repoDone(repo, len(events))
 
 				return nil
 			})
 		if err != nil {
+			failUnfinishedBatchRepos(ctx, store, batch.ID)
 			store.UpdateBatchStatus(ctx, batch.ID, "failed")
-			return fmt.Errorf("failed to collect data: %w", err)
+			return nil, fmt.Errorf("failed to collect data: %w", err)
 		}
 	}
 
+	// Repositories the collector skipped after an error never reached "completed"
+	result.RepoResults = collected.Repos
+	result.Failed = failUnfinishedBatchRepos(ctx, store, batch.ID)
+	if result.Failed > 0 {
+		slog.Warn("some repositories could not be collected", "failed_repos", result.Failed)
+	}
+
 	// Update batch status to completed
 	if err := store.UpdateBatchStatus(ctx, batch.ID, "completed"); err != nil {
-		fmt.Printf("Warning: failed to update batch status: %v\n", err)
+		slog.Warn("failed to update batch status", "batch_id", batch.ID, "error", err)
 	}
 
-	fmt.Printf("\nCollected %d events total\n", totalEvents)
+	progress.finish()
+	result.Events = totalEvents
+	slog.Info("data collection complete", "owner", target, "events", totalEvents)
+	return result, nil
+}
+
+// selectRepos reports the repositories found and returns the ones the filter selects
+func selectRepos(repos []*domain.Repository, filter *repoFilter) []*domain.Repository {
+	if !filter.active() {
+		slog.Info("found repositories", "count", len(repos))
+		return repos
+	}
+	selected := filter.filterRepositories(repos)
+	slog.Info("found repositories", "count", len(repos), "selected", len(selected))
+	return selected
+}
 
-	fmt.Println("Data collection complete!")
-	return nil
+// findUnfinishedBatch returns the owner's most recent batch that did not complete, or nil
+func findUnfinishedBatch(ctx context.Context, store storage.Storage, mode, owner string) (*domain.CollectionBatch, error) {
+	batches, err := store.ListBatches(ctx, domain.BatchFilter{Owner: owner})
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		if batch.Mode == mode && batch.Status != "completed" {
+			return batch, nil
+		}
+	}
+	return nil, nil
+}
+
+// findLastCompletedBatch returns the owner's completed batch whose range ends last, or nil
+func findLastCompletedBatch(ctx context.Context, store storage.Storage, mode, owner string) (*domain.CollectionBatch, error) {
+	batches, err := store.ListBatches(ctx, domain.BatchFilter{Owner: owner, Status: "completed"})
+	if err != nil {
+		return nil, err
+	}
+	var last *domain.CollectionBatch
+	for _, batch := range batches {
+		if batch.Mode == mode && (last == nil || batch.EndDate.After(last.EndDate)) {
+			last = batch
+		}
+	}
+	return last, nil
+}
+
+// completedBatchRepos returns the repositories a batch has already collected
+func completedBatchRepos(ctx context.Context, store storage.Storage, batchID string) (map[string]bool, error) {
+	statuses, err := store.GetBatchRepoStatuses(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	completed := make(map[string]bool, len(statuses))
+	for _, st := range statuses {
+		if st.Status == domain.BatchRepoStatusCompleted {
+			completed[st.Repo] = true
+		}
+	}
+	return completed, nil
+}
+
+// repoSyncTimes returns the last sync time of the owner's repositories that were synced
+func repoSyncTimes(ctx context.Context, store storage.Storage, owner string) (map[string]time.Time, error) {
+	repos, err := store.GetRepositories(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	synced := make(map[string]time.Time, len(repos))
+	for _, repo := range repos {
+		if repo.LastSyncedAt != nil {
+			synced[repo.Name] = *repo.LastSyncedAt
+		}
+	}
+	return synced, nil
+}
+
+// markRepoSynced records that repo was collected up to until, unless it had already been
+// synced further; failures only warn
+func markRepoSynced(ctx context.Context, store storage.Storage, repo *domain.Repository, previous, until time.Time) {
+	if repo == nil || !until.After(previous) {
+		return
+	}
+	synced := *repo
+	synced.LastSyncedAt = &until
+	if err := store.SaveRepository(ctx, &synced); err != nil {
+		slog.Warn("failed to record sync time", "repo", repo.Name, "error", err)
+	}
+}
+
+// saveBatchRepoStatus records a repository's progress within a batch; failures only warn
+func saveBatchRepoStatus(ctx context.Context, store storage.Storage, batchID, repo, status string, events int) {
+	err := store.SaveBatchRepoStatus(ctx, &domain.BatchRepoStatus{
+		BatchID: batchID,
+		Repo:    repo,
+		Status:  status,
+		Events:  events,
+	})
+	if err != nil {
+		slog.Warn("failed to record progress", "repo", repo, "error", err)
+	}
+}
+
+// saveBatchRepoError records that collecting a repository of a batch failed with err;
+// failures to record it only warn
+func saveBatchRepoError(ctx context.Context, store storage.Storage, batchID, repo string, err error) {
+	saveErr := store.SaveBatchRepoStatus(ctx, &domain.BatchRepoStatus{
+		BatchID: batchID,
+		Repo:    repo,
+		Status:  domain.BatchRepoStatusFailed,
+		Error:   err.Error(),
+	})
+	if saveErr != nil {
+		slog.Warn("failed to record progress", "repo", repo, "error", saveErr)
+	}
+}
+
+// failUnfinishedBatchRepos marks the batch's repositories that did not complete as failed
+// and returns how many there were
+func failUnfinishedBatchRepos(ctx context.Context, store storage.Storage, batchID string) int {
+	statuses, err := store.GetBatchRepoStatuses(ctx, batchID)
+	if err != nil {
+		slog.Warn("failed to read batch progress", "batch_id", batchID, "error", err)
+		return 0
+	}
+
+	failed := 0
+	for _, st := range statuses {
+		if st.Status == domain.BatchRepoStatusCompleted {
+			continue
+		}
+		failed++
+		if st.Status == domain.BatchRepoStatusFailed && st.Error != "" {
+			continue // the error was recorded when the repository failed
+		}
+		st.Status = domain.BatchRepoStatusFailed
+		st.Error = "collection did not complete"
+		st.UpdatedAt = time.Time{}
+		if err := store.SaveBatchRepoStatus(ctx, st); err != nil {
+			slog.Warn("failed to record progress", "repo", st.Repo, "error", err)
+		}
+	}
+	return failed
+}
+
+// repoMetricsJSON is the JSON form of a repository's metrics
+type repoMetricsJSON struct {
+	Org       string `json:"org,omitempty"`
+	Repo      string `json:"repo"`
+	Commits   int64  `json:"commits"`
+	PRs       int64  `json:"prs"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	Deploys   int64  `json:"deploys"`
+}
+
+// memberMetricsJSON is the JSON form of a member's metrics, within a repository with Repo
+type memberMetricsJSON struct {
+	Org       string `json:"org,omitempty"`
+	Repo      string `json:"repo,omitempty"`
+	Member    string `json:"member"`
+	Commits   int64  `json:"commits"`
+	PRs       int64  `json:"prs"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	Deploys   int64  `json:"deploys"`
+}
+
+func newMemberMetricsJSON(m *domain.MemberMetrics) memberMetricsJSON {
+	return memberMetricsJSON{Member: m.Member, Commits: m.Commits, PRs: m.PRs, Additions: m.Additions, Deletions: m.Deletions, Deploys: m.Deploys}
+}
+
+// memberMetricsTable is the table of members' metrics, one member per row
+func memberMetricsTable(members []memberMetricsJSON) viewTable {
+	table := viewTable{Header: []string{"Member", "Commits", "PRs", "Additions", "Deletions", "Deploys"}}
+	for _, m := range members {
+		table.Rows = append(table.Rows, []string{
+			m.Member,
+			fmt.Sprintf("%d", m.Commits),
+			fmt.Sprintf("%d", m.PRs),
+			fmt.Sprintf("%d", m.Additions),
+			fmt.Sprintf("%d", m.Deletions),
+			fmt.Sprintf("%d", m.Deploys),
+		})
+	}
+	return table
 }
 
 func runShowOrg(cmd *cobra.Command, args []string) error {
@@ -317,37 +1066,56 @@ func runShowOrg(cmd *cobra.Command, args []string) error {
 	}
 	defer store.Close()
 
-	agg := aggregator.NewAggregator(store)
+	agg := newAggregator(cfg, store)
 	ctx := context.Background()
 	timeRange := getTimeRange()
 
-	metrics, err := agg.AggregateOrgMetrics(ctx, org, timeRange)
+	filter, err := repoFilterFromFlags()
 	if err != nil {
-		return fmt.Errorf("failed to get metrics: %w", err)
+		return err
 	}
-
-	if outputJSON {
-		fmt.Printf(`{"org":"%s","total_repos":%d,"total_members":%d,"commits":%d,"prs":%d,"additions":%d,"deletions":%d,"deploys":%d}`,
-			metrics.Org, metrics.TotalRepos, metrics.TotalMembers, metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)
-		fmt.Println()
-		return nil
+	if showMemberFilter != "" {
+		return showOrgMember(ctx, agg, org, showMemberFilter, filter, timeRange)
+	}
+	var metrics *domain.OrgMetrics
+	if filter.active() {
+		metrics, err = filteredOrgMetrics(ctx, agg, store, org, filter, timeRange)
+	} else {
+		metrics, err = agg.AggregateOrgMetrics(ctx, org, timeRange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
 	}
 
-	fmt.Printf("\nOrganization Metrics: %s\n", org)
-	fmt.Printf("Time Range: %s to %s\n\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
+	rows := append([][]string{
+		{"Total Repositories", fmt.Sprintf("%d", metrics.TotalRepos)},
+		{"Total Members", fmt.Sprintf("%d", metrics.TotalMembers)},
+	}, metricsRows(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)...)
+	return render(&view{
+		Title:     "Organization Metrics: " + org,
+		TimeRange: timeRange,
+		Tables:    []viewTable{{Header: []string{"Metric", "Value"}, Rows: rows}},
+		Value:     metrics,
+	})
+}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Metric", "Value"})
-	table.Append([]string{"Total Repositories", fmt.Sprintf("%d", metrics.TotalRepos)})
-	table.Append([]string{"Total Members", fmt.Sprintf("%d", metrics.TotalMembers)})
-	table.Append([]string{"Commits", fmt.Sprintf("%d", metrics.Commits)})
-	table.Append([]string{"Pull Requests", fmt.Sprintf("%d", metrics.PRs)})
-	table.Append([]string{"Lines Added", fmt.Sprintf("%d", metrics.Additions)})
-	table.Append([]string{"Lines Deleted", fmt.Sprintf("%d", metrics.Deletions)})
-	table.Append([]string{"Deployments", fmt.Sprintf("%d", metrics.Deploys)})
-	table.Render()
+// showOrgMember shows a member's totals over the organization's repositories, or the ones
+// the filter selects
+func showOrgMember(ctx context.Context, agg aggregator.Aggregator, org, member string, filter *repoFilter, timeRange domain.TimeRange) error {
+	var metrics *domain.MemberMetrics
+	var err error
+	if filter.active() {
+		metrics, err = filteredMemberMetrics(ctx, agg, org, member, filter, timeRange)
+	} else {
+		metrics, err = agg.AggregateMemberMetrics(ctx, org, member, timeRange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
 
-	return nil
+	value := newMemberMetricsJSON(metrics)
+	value.Member, value.Org = member, org
+	return render(memberMetricsView(fmt.Sprintf("Organization Metrics: %s (member: %s)", org, member), timeRange, metrics, value))
 }
 
 func runShowMembers(cmd *cobra.Command, args []string) error {
@@ -364,46 +1132,34 @@ func runShowMembers(cmd *cobra.Command, args []string) error {
 	}
 	defer store.Close()
 
-	agg := aggregator.NewAggregator(store)
+	agg := newAggregator(cfg, store)
 	ctx := context.Background()
 	timeRange := getTimeRange()
 
-	metrics, err := agg.GetMembersMetrics(ctx, org, timeRange)
+	filter, err := repoFilterFromFlags()
 	if err != nil {
-		return fmt.Errorf("failed to get metrics: %w", err)
+		return err
 	}
-
-	if outputJSON {
-		fmt.Print("[")
-		for i, m := range metrics {
-			if i > 0 {
-				fmt.Print(",")
-			}
-			fmt.Printf(`{"member":"%s","commits":%d,"prs":%d,"additions":%d,"deletions":%d,"deploys":%d}`,
-				m.Member, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys)
-		}
-		fmt.Println("]")
-		return nil
+	var metrics []*domain.MemberMetrics
+	if filter.active() {
+		metrics, err = filteredMembersMetrics(ctx, agg, org, filter, timeRange)
+	} else {
+		metrics, err = agg.GetMembersMetrics(ctx, org, timeRange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
 	}
 
-	fmt.Printf("\nMember Metrics: %s\n", org)
-	fmt.Printf("Time Range: %s to %s\n\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Member", "Commits", "PRs", "Additions", "Deletions", "Deploys"})
+	rows := make([]memberMetricsJSON, 0, len(metrics))
 	for _, m := range metrics {
-		table.Append([]string{
-			m.Member,
-			fmt.Sprintf("%d", m.Commits),
-			fmt.Sprintf("%d", m.PRs),
-			fmt.Sprintf("%d", m.Additions),
-			fmt.Sprintf("%d", m.Deletions),
-			fmt.Sprintf("%d", m.Deploys),
-		})
+		rows = append(rows, newMemberMetricsJSON(m))
 	}
-	table.Render()
-
-	return nil
+	return render(&view{
+		Title:     "Member Metrics: " + org,
+		TimeRange: timeRange,
+		Tables:    []viewTable{memberMetricsTable(rows)},
+		Value:     rows,
+	})
 }
 
 func runShowMember(cmd *cobra.Command, args []string) error {
@@ -421,35 +1177,40 @@ func runShowMember(cmd *cobra.Command, args []string) error {
 	}
 	defer store.Close()
 
-	agg := aggregator.NewAggregator(store)
+	agg := newAggregator(cfg, store)
 	ctx := context.Background()
 	timeRange := getTimeRange()
 
-	metrics, err := agg.AggregateMemberMetrics(ctx, org, member, timeRange)
+	filter, err := repoFilterFromFlags()
 	if err != nil {
-		return fmt.Errorf("failed to get metrics: %w", err)
+		return err
 	}
-
-	if outputJSON {
-		fmt.Printf(`{"member":"%s","commits":%d,"prs":%d,"additions":%d,"deletions":%d,"deploys":%d}`,
-			metrics.Member, metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)
-		fmt.Println()
-		return nil
+	var metrics *domain.MemberMetrics
+	if filter.active() {
+		metrics, err = filteredMemberMetrics(ctx, agg, org, member, filter, timeRange)
+	} else {
+		metrics, err = agg.AggregateMemberMetrics(ctx, org, member, timeRange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
 	}
 
-	fmt.Printf("\nMember Metrics: %s/%s\n", org, member)
-	fmt.Printf("Time Range: %s to %s\n\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Metric", "Value"})
-	table.Append([]string{"Commits", fmt.Sprintf("%d", metrics.Commits)})
-	table.Append([]string{"Pull Requests", fmt.Sprintf("%d", metrics.PRs)})
-	table.Append([]string{"Lines Added", fmt.Sprintf("%d", metrics.Additions)})
-	table.Append([]string{"Lines Deleted", fmt.Sprintf("%d", metrics.Deletions)})
-	table.Append([]string{"Deployments", fmt.Sprintf("%d", metrics.Deploys)})
-	table.Render()
+	value := newMemberMetricsJSON(metrics)
+	value.Org = org
+	return render(memberMetricsView(fmt.Sprintf("Member Metrics: %s/%s", org, member), timeRange, metrics, value))
+}
 
-	return nil
+// memberMetricsView shows a member's metrics as a metric/value table
+func memberMetricsView(title string, timeRange domain.TimeRange, metrics *domain.MemberMetrics, value memberMetricsJSON) *view {
+	return &view{
+		Title:     title,
+		TimeRange: timeRange,
+		Tables: []viewTable{{
+			Header: []string{"Metric", "Value"},
+			Rows:   metricsRows(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys),
+		}},
+		Value: value,
+	}
 }
 
 func runShowRepos(cmd *cobra.Command, args []string) error {
@@ -466,35 +1227,25 @@ func runShowRepos(cmd *cobra.Command, args []string) error {
 	}
 	defer store.Close()
 
-	agg := aggregator.NewAggregator(store)
+	agg := newAggregator(cfg, store)
 	ctx := context.Background()
 	timeRange := getTimeRange()
 
+	filter, err := repoFilterFromFlags()
+	if err != nil {
+		return err
+	}
 	metrics, err := agg.GetReposMetrics(ctx, org, timeRange)
 	if err != nil {
 		return fmt.Errorf("failed to get metrics: %w", err)
 	}
+	metrics = filter.filterRepoMetrics(metrics)
 
-	if outputJSON {
-		fmt.Print("[")
-		for i, m := range metrics {
-			if i > 0 {
-				fmt.Print(",")
-			}
-			fmt.Printf(`{"repo":"%s","commits":%d,"prs":%d,"additions":%d,"deletions":%d,"deploys":%d}`,
-				m.Repo, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys)
-		}
-		fmt.Println("]")
-		return nil
-	}
-
-	fmt.Printf("\nRepository Metrics: %s\n", org)
-	fmt.Printf("Time Range: %s to %s\n\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Repository", "Commits", "PRs", "Additions", "Deletions", "Deploys"})
+	rows := make([]repoMetricsJSON, 0, len(metrics))
+	table := viewTable{Header: []string{"Repository", "Commits", "PRs", "Additions", "Deletions", "Deploys"}}
 	for _, m := range metrics {
-		table.Append([]string{
+		rows = append(rows, repoMetricsJSON{Repo: m.Repo, Commits: m.Commits, PRs: m.PRs, Additions: m.Additions, Deletions: m.Deletions, Deploys: m.Deploys})
+		table.Rows = append(table.Rows, []string{
 			m.Repo,
 			fmt.Sprintf("%d", m.Commits),
 			fmt.Sprintf("%d", m.PRs),
@@ -503,9 +1254,31 @@ func runShowRepos(cmd *cobra.Command, args []string) error {
 			fmt.Sprintf("%d", m.Deploys),
 		})
 	}
-	table.Render()
+	return render(&view{
+		Title:     "Repository Metrics: " + org,
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value:     rows,
+	})
+}
 
-	return nil
+// showRepoMember shows a member's metrics within a repository
+func showRepoMember(ctx context.Context, agg aggregator.Aggregator, org, repo, member string, timeRange domain.TimeRange) error {
+	members, err := agg.GetRepoMembersMetrics(ctx, org, repo, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+	metrics := &domain.MemberMetrics{Member: member, TimeRange: timeRange}
+	for _, m := range members {
+		if strings.EqualFold(m.Member, member) {
+			metrics = m
+			break
+		}
+	}
+
+	value := newMemberMetricsJSON(metrics)
+	value.Org, value.Repo = org, repo
+	return render(memberMetricsView(fmt.Sprintf("Repository Metrics: %s/%s (member: %s)", org, repo, metrics.Member), timeRange, metrics, value))
 }
 
 func runShowRepo(cmd *cobra.Command, args []string) error {
@@ -523,33 +1296,29 @@ func runShowRepo(cmd *cobra.Command, args []string) error {
 	}
 	defer store.Close()
 
-	agg := aggregator.NewAggregator(store)
+	agg := newAggregator(cfg, store)
 	ctx := context.Background()
 	timeRange := getTimeRange()
 
+	if showMemberFilter != "" {
+		return showRepoMember(ctx, agg, org, repo, showMemberFilter, timeRange)
+	}
+
 	metrics, err := agg.AggregateRepoMetrics(ctx, org, repo, timeRange)
 	if err != nil {
 		return fmt.Errorf("failed to get metrics: %w", err)
 	}
 
-	if outputJSON {
-		fmt.Printf(`{"repo":"%s","commits":%d,"prs":%d,"additions":%d,"deletions":%d,"deploys":%d}`,
-			metrics.Repo, metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)
-		fmt.Println()
-		return nil
-	}
-
-	fmt.Printf("\nRepository Metrics: %s/%s\n", org, repo)
-	fmt.Printf("Time Range: %s to %s\n\n", timeRange.Start.Format("2006-01-02"), timeRange.End.Format("2006-01-02"))
-
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Metric", "Value"})
-	table.Append([]string{"Commits", fmt.Sprintf("%d", metrics.Commits)})
-	table.Append([]string{"Pull Requests", fmt.Sprintf("%d", metrics.PRs)})
-	table.Append([]string{"Lines Added", fmt.Sprintf("%d", metrics.Additions)})
-	table.Append([]string{"Lines Deleted", fmt.Sprintf("%d", metrics.Deletions)})
-	table.Append([]string{"Deployments", fmt.Sprintf("%d", metrics.Deploys)})
-	table.Render()
-
-	return nil
+	return render(&view{
+		Title:     fmt.Sprintf("Repository Metrics: %s/%s", org, repo),
+		TimeRange: timeRange,
+		Tables: []viewTable{{
+			Header: []string{"Metric", "Value"},
+			Rows:   metricsRows(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys),
+		}},
+		Value: repoMetricsJSON{
+			Org: org, Repo: repo, Commits: metrics.Commits, PRs: metrics.PRs,
+			Additions: metrics.Additions, Deletions: metrics.Deletions, Deploys: metrics.Deploys,
+		},
+	})
 }
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var (
+	migrateDownSteps int
+	migrateDownYes   bool
+	migrateCheck     bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply, revert or inspect database schema migrations",
+	Long: `Manage the versioned migrations of the database schema.
+
+Every command applies pending migrations when it opens the database unless AUTO_MIGRATE is
+false. Deployments that set it to false run "migrate up" as a release step instead, so the
+schema changes once, before the new version starts. The migrate commands themselves never
+migrate implicitly.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply the pending migrations",
+	Long: `Apply the migrations that haven't been applied to the database yet, oldest first.
+
+A database created before migrations were versioned already has their tables; "up" records
+those migrations as applied without changing its data.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMigrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert the last applied migrations",
+	Long: `Revert the last --steps applied migrations, newest first. Reverting a migration drops
+the tables and columns it created with their data, so the command asks for confirmation
+unless --yes is given.`,
+	Example: `  github-metrics migrate down
+  github-metrics migrate down --steps 2 --yes`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMigrateDown,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the migrations and whether each is applied",
+	Long: `List every schema migration with the time it was applied, or "pending".
+With --check the command fails when a migration is pending, e.g. to stop a deployment whose
+database was not migrated.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMigrateStatus,
+}
+
+func init() {
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "number of migrations to revert")
+	migrateDownCmd.Flags().BoolVarP(&migrateDownYes, "yes", "y", false, "do not ask for confirmation")
+	migrateStatusCmd.Flags().BoolVar(&migrateCheck, "check", false, "fail when a migration is pending")
+
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+// migrationJSON is a migration in JSON output
+type migrationJSON struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// migrateResult is the outcome of migrate up or down in JSON output
+type migrateResult struct {
+	Applied  []migrationJSON `json:"applied,omitempty"`
+	Reverted []migrationJSON `json:"reverted,omitempty"`
+	Version  int             `json:"version"` // schema version afterwards; 0 for none
+}
+
+// openMigrationStorage opens the configured database without applying migrations
+func openMigrationStorage() (storage.Storage, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.AutoMigrate = false
+	store, err := getStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+func runMigrateUp(cmd *cobra.Command, args []string) error {
+	store, err := openMigrationStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	before, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if err := store.Migrate(ctx); err != nil {
+		return err
+	}
+	after, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := &migrateResult{Version: storage.SchemaVersion(after)}
+	for _, m := range before {
+		if !m.Applied() {
+			result.Applied = append(result.Applied, toMigrationJSON(m))
+		}
+	}
+	if outputJSON {
+		return printJSON(result)
+	}
+	for _, m := range result.Applied {
+		fmt.Printf("Applied migration %d: %s\n", m.Version, m.Name)
+	}
+	fmt.Printf("Database schema is at version %d\n", result.Version)
+	return nil
+}
+
+func runMigrateDown(cmd *cobra.Command, args []string) error {
+	if migrateDownSteps < 1 {
+		return fmt.Errorf("invalid --steps %d: must be at least 1", migrateDownSteps)
+	}
+	store, err := openMigrationStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	status, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	var revert []*storage.Migration
+	for i := len(status) - 1; i >= 0 && len(revert) < migrateDownSteps; i-- {
+		if status[i].Applied() {
+			revert = append(revert, status[i])
+		}
+	}
+	if len(revert) == 0 {
+		return fmt.Errorf("no migrations to revert: the database schema is empty")
+	}
+
+	if !migrateDownYes {
+		names := make([]string, 0, len(revert))
+		for _, m := range revert {
+			names = append(names, fmt.Sprintf("%d (%s)", m.Version, m.Name))
+		}
+		question := fmt.Sprintf("Revert migration %s, dropping the data it holds?", strings.Join(names, ", "))
+		if err := confirmDestructive(question, "migrate down"); err != nil {
+			return err
+		}
+	}
+
+	if err := store.MigrateDown(ctx, len(revert)); err != nil {
+		return err
+	}
+	after, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := &migrateResult{Version: storage.SchemaVersion(after)}
+	for _, m := range revert {
+		result.Reverted = append(result.Reverted, toMigrationJSON(m))
+	}
+	if outputJSON {
+		return printJSON(result)
+	}
+	for _, m := range result.Reverted {
+		fmt.Printf("Reverted migration %d: %s\n", m.Version, m.Name)
+	}
+	fmt.Printf("Database schema is at version %d\n", result.Version)
+	return nil
+}
+
+func runMigrateStatus(cmd *cobra.Command, args []string) error {
+	store, err := openMigrationStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	status, err := store.MigrationStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	pending := len(status) - countApplied(status)
+
+	if outputJSON {
+		migrations := make([]migrationJSON, 0, len(status))
+		for _, m := range status {
+			migrations = append(migrations, toMigrationJSON(m))
+		}
+		if err := printJSON(migrations); err != nil {
+			return err
+		}
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Version", "Name", "Applied At"})
+		for _, m := range status {
+			applied := "pending"
+			if m.Applied() {
+				applied = m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			table.Append([]string{fmt.Sprintf("%d", m.Version), m.Name, applied})
+		}
+		table.Render()
+		fmt.Printf("\nSchema version %d, %d pending\n", storage.SchemaVersion(status), pending)
+	}
+
+	if migrateCheck && pending > 0 {
+		return fmt.Errorf("%d migrations pending: run \"github-metrics migrate up\"", pending)
+	}
+	return nil
+}
+
+// countApplied returns the number of applied migrations in status
+func countApplied(status []*storage.Migration) int {
+	n := 0
+	for _, m := range status {
+		if m.Applied() {
+			n++
+		}
+	}
+	return n
+}
+
+// toMigrationJSON converts a migration for JSON output
+func toMigrationJSON(m *storage.Migration) migrationJSON {
+	j := migrationJSON{Version: m.Version, Name: m.Name, Applied: m.Applied()}
+	if m.Applied() {
+		at := m.AppliedAt
+		j.AppliedAt = &at
+	}
+	return j
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/notify"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// notifyTop is the number of members listed as top movers and contributors in notifications
+const notifyTop = 5
+
+var notifySlack bool
+
+// addNotifyFlags adds the notification flags to cmd
+func addNotifyFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&notifySlack, "notify-slack", false, "post a summary to the Slack webhook SLACK_WEBHOOK_URL when the run finishes")
+}
+
+// slackNotifier returns the Slack notifier when --notify-slack is given, so a missing
+// webhook is reported before the run rather than after it
+func slackNotifier(cfg *config.Config) (*notify.Slack, error) {
+	if !notifySlack {
+		return nil, nil
+	}
+	if cfg.SlackWebhookURL == "" {
+		return nil, fmt.Errorf("--notify-slack needs a webhook: set SLACK_WEBHOOK_URL (slack.webhook_url in the configuration file)")
+	}
+	return notify.NewSlack(cfg.SlackWebhookURL)
+}
+
+// sendNotification posts summary with n, if any. A notification that can't be delivered
+// is logged without failing the run it reports on.
+func sendNotification(ctx context.Context, n *notify.Slack, summary *notify.Summary) {
+	if n == nil {
+		return
+	}
+	if err := n.Send(ctx, summary); err != nil {
+		slog.Warn("failed to send notification", "error", err)
+		return
+	}
+	slog.Info("posted summary to Slack")
+}
+
+// reportEmailer returns the sender of emailed reports, configured with the SMTP_* variables
+func reportEmailer(cfg *config.Config) (*notify.Email, error) {
+	return notify.NewEmail(notify.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	})
+}
+
+// collectSummary summarizes the collection of owners over timeRange, with the members whose
+// activity changed the most since the period before it
+func collectSummary(ctx context.Context, cfg *config.Config, store storage.Storage, results []*collectResult, timeRange domain.TimeRange) *notify.Summary {
+	owners := make([]string, 0, len(results))
+	var repos, failedRepos, events int
+	var duration time.Duration
+	var failures []string
+	for _, r := range results {
+		owners = append(owners, r.Owner)
+		repos += r.Repos
+		failedRepos += r.Failed
+		events += r.Events
+		duration = max(duration, r.Duration)
+		switch {
+		case r.Status == collectStatusFailed:
+			failures = append(failures, fmt.Sprintf("%s: %s", r.Owner, r.Error))
+		case r.Failed > 0:
+			failures = append(failures, fmt.Sprintf("%s: %d repositories failed", r.Owner, r.Failed))
+		}
+	}
+
+	title := "Collection finished: " + strings.Join(owners, ", ")
+	if len(owners) > 3 {
+		title = fmt.Sprintf("Collection finished: %d owners", len(owners))
+	}
+	summary := &notify.Summary{
+		Title:  title,
+		Failed: len(failures) > 0,
+		Fields: []notify.Field{
+			{Name: "Events collected", Value: fmt.Sprintf("%d", events)},
+			{Name: "Repositories", Value: fmt.Sprintf("%d", repos)},
+			{Name: "Failed repositories", Value: fmt.Sprintf("%d", failedRepos)},
+			{Name: "Duration", Value: formatElapsed(duration)},
+		},
+		Footer: "Collected " + formatPeriod(timeRange),
+	}
+	if len(results) > 1 {
+		summary.Fields = append([]notify.Field{{Name: "Owners", Value: fmt.Sprintf("%d", len(results))}}, summary.Fields...)
+	}
+	summary.Sections = append(summary.Sections, notify.Section{Title: "Failures", Lines: failures})
+
+	// Movers compare the collected period with the one of the same length before it
+	agg := newAggregator(cfg, store)
+	previous := previousRange(timeRange)
+	for _, r := range results {
+		if r.Status == collectStatusFailed {
+			continue
+		}
+		current, err := agg.GetMembersMetrics(ctx, r.Owner, timeRange)
+		if err != nil {
+			slog.Warn("failed to get member metrics for the notification", "owner", r.Owner, "error", err)
+			continue
+		}
+		before, err := agg.GetMembersMetrics(ctx, r.Owner, previous)
+		if err != nil {
+			slog.Warn("failed to get member metrics for the notification", "owner", r.Owner, "error", err)
+			continue
+		}
+		title := "Top movers"
+		if len(results) > 1 {
+			title += ": " + r.Owner
+		}
+		summary.Sections = append(summary.Sections, notify.Section{Title: title, Lines: memberMovers(current, before, notifyTop)})
+	}
+	return summary
+}
+
+// reportSummary summarizes a report: its totals, top contributors and top movers
+func reportSummary(data *reportData) *notify.Summary {
+	summary := &notify.Summary{
+		Title:  fmt.Sprintf("%s report: %s", data.Period, data.Owner),
+		Footer: fmt.Sprintf("%s to %s, compared with %s to %s", data.Start, data.End, data.PrevStart, data.PrevEnd),
+	}
+	for _, row := range data.Summary {
+		summary.Fields = append(summary.Fields, notify.Field{Name: row.Name, Value: fmt.Sprintf("%d (%s)", row.Current, row.Change)})
+	}
+
+	var contributors []string
+	for _, m := range data.Contributors[:min(notifyTop, len(data.Contributors))] {
+		if m.Commits+m.PRs == 0 {
+			break
+		}
+		contributors = append(contributors, fmt.Sprintf("%s: %d commits, %d PRs", m.Member, m.Commits, m.PRs))
+	}
+	summary.Sections = []notify.Section{
+		{Title: "Top contributors", Lines: contributors},
+		{Title: "Top movers", Lines: memberMovers(data.members, data.prevMembers, notifyTop)},
+	}
+	return summary
+}
+
+// memberMovers describes the n members whose commits and pull requests changed the most
+// between previous and current, largest change first
+func memberMovers(current, previous []*domain.MemberMetrics, n int) []string {
+	activity := func(m *domain.MemberMetrics) int64 { return m.Commits + m.PRs }
+	before := make(map[string]int64, len(previous))
+	for _, m := range previous {
+		before[m.Member] = activity(m)
+	}
+
+	type mover struct {
+		member     string
+		now, delta int64
+	}
+	var movers []mover
+	seen := make(map[string]bool, len(current))
+	for _, m := range current {
+		seen[m.Member] = true
+		if delta := activity(m) - before[m.Member]; delta != 0 {
+			movers = append(movers, mover{m.Member, activity(m), delta})
+		}
+	}
+	// Members active only in the previous period dropped to nothing
+	for member, was := range before {
+		if !seen[member] && was > 0 {
+			movers = append(movers, mover{member, 0, -was})
+		}
+	}
+
+	sort.Slice(movers, func(i, j int) bool {
+		a, b := movers[i].delta, movers[j].delta
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		if a != b {
+			return a > b
+		}
+		return movers[i].member < movers[j].member
+	})
+	lines := make([]string, 0, min(n, len(movers)))
+	for _, m := range movers[:min(n, len(movers))] {
+		lines = append(lines, fmt.Sprintf("%s: %d commits and PRs (%+d)", m.member, m.now, m.delta))
+	}
+	return lines
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// outputFile is the --output file the show, export, report and badge commands write to instead of
+// standard output
+var outputFile string
+
+// outputPath returns the file a command writes to: the value of its own flag, such as --out,
+// or --output. "" and "-" are standard output.
+func outputPath(flag, value string) (string, error) {
+	switch {
+	case value == "":
+		return outputFile, nil
+	case outputFile != "" && outputFile != value:
+		return "", fmt.Errorf("--%s %s conflicts with --output %s", flag, value, outputFile)
+	}
+	return value, nil
+}
+
+// toStdout reports whether path is standard output
+func toStdout(path string) bool {
+	return path == "" || path == "-"
+}
+
+// writeOutput calls write with standard output, or with a file at path that replaces any
+// previous one only once write succeeds, so readers never see a partial file and a failed
+// command leaves the previous one intact
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if toStdout(path) {
+		return write(os.Stdout)
+	}
+
+	// The temporary file is in the same directory so that renaming it is atomic
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	buf := bufio.NewWriter(f)
+	if err := write(buf); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressRefresh is how often the live progress display is redrawn
+const progressRefresh = 250 * time.Millisecond
+
+// progressActiveRows is the number of repositories in progress listed by the live display
+const progressActiveRows = 5
+
+// logOutput is where the default logger writes. A live progress display attaches to it so
+// log lines are printed above the display instead of tearing it.
+var logOutput = &progressLogWriter{w: os.Stderr}
+
+// progressLogWriter writes log lines to w, clearing and redrawing the attached display
+// around each of them
+type progressLogWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	display *collectProgress
+}
+
+// Write writes a log line above the live display, if any
+func (l *progressLogWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.display == nil {
+		return l.w.Write(p)
+	}
+	l.display.mu.Lock()
+	defer l.display.mu.Unlock()
+	l.display.clear()
+	n, err := l.w.Write(p)
+	l.display.draw()
+	return n, err
+}
+
+// attach makes log lines go around display; nil detaches it
+func (l *progressLogWriter) attach(display *collectProgress) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.display = display
+}
+
+// collectProgress reports the progress of a collection: repositories done and in progress,
+// events collected, failures, rate limit left and the estimated time left. On a terminal it
+// is a display redrawn in place; otherwise every finished repository is logged as a line.
+type collectProgress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	live    bool
+	owner   string
+	rate    func() (remaining int, reset time.Time)
+	started time.Time
+
+	total  int
+	done   int
+	failed int
+	events int
+	active map[string]time.Time
+
+	// lines is the number of lines of the display currently on screen
+	lines int
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// newCollectProgress creates the progress report of collecting owner. rate returns the
+// GitHub rate limit left. With display, the live display is used when both standard output
+// and standard error are terminals, unless JSON output, JSON logs or --quiet were asked for.
+func newCollectProgress(owner string, rate func() (int, time.Time), display bool) *collectProgress {
+	p := &collectProgress{
+		out:     os.Stderr,
+		owner:   owner,
+		rate:    rate,
+		started: time.Now(),
+		active:  make(map[string]time.Time),
+	}
+	p.live = display && !outputJSON && !logQuiet && logFormat == "text" &&
+		term.IsTerminal(int(os.Stdout.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+	if p.live {
+		p.stop = make(chan struct{})
+		logOutput.attach(p)
+		p.wg.Add(1)
+		go p.refresh()
+	}
+	return p
+}
+
+// refresh redraws the display until finish is called, so elapsed times and the ETA move
+// while repositories are being collected
+func (p *collectProgress) refresh() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(progressRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.clear()
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// setTotal sets the number of repositories to collect
+func (p *collectProgress) setTotal(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+}
+
+// repoStarted records that collecting repo began
+func (p *collectProgress) repoStarted(repo string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active[repo] = time.Now()
+}
+
+// repoDone records that repo was collected with events events
+func (p *collectProgress) repoDone(repo string, events int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elapsed := p.finishRepo(repo)
+	p.done++
+	p.events += events
+	if !p.live {
+		remaining, _ := p.rate()
+		slog.Info("collected repository", "repo", repo, "events", events,
+			"done", p.done, "total", p.total, "elapsed", elapsed.Round(time.Second),
+			"rate_remaining", remaining, "eta", p.eta().Round(time.Second))
+		return
+	}
+	p.clear()
+	fmt.Fprintf(p.out, "✓ %s  %d events  %s\n", repo, events, formatElapsed(elapsed))
+	p.draw()
+}
+
+// repoFailed records that collecting repo failed with err
+func (p *collectProgress) repoFailed(repo string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finishRepo(repo)
+	p.done++
+	p.failed++
+	if !p.live {
+		slog.Warn("repository collection failed", "repo", repo, "done", p.done, "total", p.total, "error", err)
+		return
+	}
+	p.clear()
+	fmt.Fprintf(p.out, "✗ %s  %v\n", repo, err)
+	p.draw()
+}
+
+// finishRepo removes repo from the repositories in progress and returns how long it took
+func (p *collectProgress) finishRepo(repo string) time.Duration {
+	start, ok := p.active[repo]
+	delete(p.active, repo)
+	if !ok {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// finish stops the live display, leaving its last state on screen
+func (p *collectProgress) finish() {
+	if !p.live {
+		return
+	}
+	close(p.stop)
+	p.wg.Wait()
+	logOutput.attach(nil)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	p.active = map[string]time.Time{}
+	p.draw()
+	p.lines = 0
+	p.live = false
+}
+
+// eta estimates the time left from the average time per repository so far
+func (p *collectProgress) eta() time.Duration {
+	if p.done == 0 || p.done >= p.total {
+		return 0
+	}
+	perRepo := time.Since(p.started) / time.Duration(p.done)
+	return perRepo * time.Duration(p.total-p.done)
+}
+
+// clear erases the display drawn last. The caller holds p.mu.
+func (p *collectProgress) clear() {
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.lines)
+		p.lines = 0
+	}
+}
+
+// draw prints the display below the cursor. The caller holds p.mu.
+func (p *collectProgress) draw() {
+	if !p.live {
+		return
+	}
+	width := 80
+	if w, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil && w > 0 {
+		width = w
+	}
+
+	lines := []string{p.header(), p.status()}
+	lines = append(lines, p.activeLines()...)
+	for _, line := range lines {
+		fmt.Fprintln(p.out, truncateLine(line, width))
+	}
+	p.lines = len(lines)
+}
+
+// header returns the progress bar line
+func (p *collectProgress) header() string {
+	const barWidth = 30
+	ratio := 0.0
+	if p.total > 0 {
+		ratio = float64(p.done) / float64(p.total)
+	}
+	filled := int(ratio * barWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	return fmt.Sprintf("%s [%s] %d/%d repositories (%.0f%%)", p.owner, bar, p.done, p.total, ratio*100)
+}
+
+// status returns the line with the events, failures, rate limit, elapsed time and ETA
+func (p *collectProgress) status() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "events %d · failed %d", p.events, p.failed)
+	if remaining, reset := p.rate(); !reset.IsZero() {
+		fmt.Fprintf(&b, " · rate limit %d", remaining)
+	}
+	fmt.Fprintf(&b, " · elapsed %s", formatElapsed(time.Since(p.started)))
+	if p.done > 0 && p.done < p.total {
+		fmt.Fprintf(&b, " · ETA %s", formatElapsed(p.eta()))
+	}
+	return b.String()
+}
+
+// activeLines lists the repositories in progress, the longest running first
+func (p *collectProgress) activeLines() []string {
+	repos := make([]string, 0, len(p.active))
+	for repo := range p.active {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return p.active[repos[i]].Before(p.active[repos[j]])
+	})
+
+	var lines []string
+	for i, repo := range repos {
+		if i == progressActiveRows {
+			lines = append(lines, fmt.Sprintf("  … %d more", len(repos)-progressActiveRows))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  ⠿ %s  %s", repo, formatElapsed(time.Since(p.active[repo]))))
+	}
+	return lines
+}
+
+// formatElapsed formats a duration as 1h02m03s, 2m03s or 3s
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
+	case m > 0:
+		return fmt.Sprintf("%dm%02ds", m, s)
+	default:
+		return fmt.Sprintf("%ds", s)
+	}
+}
+
+// truncateLine shortens line to width columns so the display never wraps, which would
+// break clearing it
+func truncateLine(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) < width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
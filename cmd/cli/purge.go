@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+)
+
+var (
+	purgeOlderThan string
+	purgeDryRun    bool
+	purgeYes       bool
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge [org]",
+	Short: "Delete old events and collection batches",
+	Long: `Delete the events older than --older-than, and the finished collection batches whose
+time range ended before then, for one owner or, without an argument, for every owner.
+
+The age is a number of days (365d), weeks (52w) or a Go duration (720h). With --dry-run the
+events and batches that would be removed are counted without deleting anything. Otherwise
+the command asks for confirmation unless --yes is given.`,
+	Example: `  github-metrics purge my-org --older-than 365d --dry-run
+  github-metrics purge --older-than 104w --yes`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runPurge,
+}
+
+func init() {
+	purgeCmd.Flags().StringVar(&purgeOlderThan, "older-than", "", "delete data older than this age, e.g. 365d (required)")
+	purgeCmd.Flags().BoolVar(&purgeDryRun, "dry-run", false, "count what would be removed without deleting it")
+	purgeCmd.Flags().BoolVarP(&purgeYes, "yes", "y", false, "do not ask for confirmation")
+	purgeCmd.MarkFlagRequired("older-than")
+
+	rootCmd.AddCommand(purgeCmd)
+}
+
+// purgeResult is what purge removed, or would remove with --dry-run
+type purgeResult struct {
+	Owner   string    `json:"owner,omitempty"`
+	Before  time.Time `json:"before"`
+	Events  int64     `json:"events"`
+	Batches int64     `json:"batches"`
+	DryRun  bool      `json:"dry_run"`
+}
+
+func runPurge(cmd *cobra.Command, args []string) error {
+	age, err := parseAge(purgeOlderThan)
+	if err != nil {
+		return fmt.Errorf("invalid --older-than: %w", err)
+	}
+	result := &purgeResult{Before: time.Now().Add(-age).Truncate(time.Second), DryRun: purgeDryRun}
+	if len(args) == 1 {
+		result.Owner = args[0]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	events, batches, err := store.CountPurgeable(ctx, result.Owner, result.Before)
+	if err != nil {
+		return err
+	}
+	if purgeDryRun || events+batches == 0 {
+		result.Events, result.Batches = events, batches
+		return printPurgeResult(result)
+	}
+
+	if !purgeYes {
+		if err := confirmDestructive(fmt.Sprintf("Delete %d events and %d batches %s?", events, batches, purgeScope(result)), "purge"); err != nil {
+			return err
+		}
+	}
+
+	if result.Events, err = store.PurgeEvents(ctx, result.Owner, result.Before); err != nil {
+		return err
+	}
+	if result.Batches, err = store.PurgeBatches(ctx, result.Owner, result.Before); err != nil {
+		return err
+	}
+	return printPurgeResult(result)
+}
+
+// printPurgeResult prints how many events and batches were, or would be, removed
+func printPurgeResult(r *purgeResult) error {
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	verb := "Removed"
+	if r.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("%s %d events and %d batches %s\n", verb, r.Events, r.Batches, purgeScope(r))
+	return nil
+}
+
+// purgeScope describes the owner and cutoff of a purge
+func purgeScope(r *purgeResult) string {
+	owner := "all owners"
+	if r.Owner != "" {
+		owner = r.Owner
+	}
+	return fmt.Sprintf("older than %s (%s)", r.Before.Local().Format("2006-01-02 15:04"), owner)
+}
+
+// confirmDestructive asks question on the terminal before action deletes data. Without a
+// terminal to ask on it refuses, since --yes was not given.
+func confirmDestructive(question, action string) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("refusing to %s without confirmation: pass --yes", action)
+	}
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+	ok, err := p.confirm(question, false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s canceled", action)
+	}
+	return nil
+}
+
+// parseAge parses an age given in days (365d), weeks (52w) or as a Go duration (720h)
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var age time.Duration
+	if n, unit := strings.TrimRight(s, "dw"), strings.TrimLeft(s, "0123456789"); (unit == "d" || unit == "w") && n != "" {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("%q: not a number of days or weeks", s)
+		}
+		if unit == "w" {
+			days *= 7
+		}
+		age = time.Duration(days) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("%q: must be like 365d, 52w or 720h", s)
+		}
+		age = d
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("%q: must be positive", s)
+	}
+	return age, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	rankingType   string
+	rankingTarget string
+	rankingLimit  int
+)
+
+var showRankingsCmd = &cobra.Command{
+	Use:   "rankings [org]",
+	Short: "Show member or repository rankings",
+	Long: `Rank the members or repositories of an organization or user by commits, pull requests,
+code changes (lines added plus deleted) or deployments.`,
+	Example: `  github-metrics show rankings my-org
+  github-metrics show rankings my-org --type code-changes --target repos --limit 20`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowRankings),
+}
+
+func init() {
+	showRankingsCmd.Flags().StringVar(&rankingType, "type", "commits", "ranking type (commits, prs, code-changes, deploys)")
+	showRankingsCmd.Flags().StringVar(&rankingTarget, "target", "members", "what to rank (members, repos)")
+	showRankingsCmd.Flags().IntVar(&rankingLimit, "limit", 10, "number of entries")
+
+	showCmd.AddCommand(showRankingsCmd)
+}
+
+func runShowRankings(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	rt := domain.RankingType(rankingType)
+	switch rt {
+	case domain.RankingTypeCommits, domain.RankingTypePRs, domain.RankingTypeCodeChanges, domain.RankingTypeDeploys:
+	default:
+		return fmt.Errorf("invalid ranking type %q: must be 'commits', 'prs', 'code-changes' or 'deploys'", rankingType)
+	}
+	if rankingTarget != "members" && rankingTarget != "repos" {
+		return fmt.Errorf("invalid target %q: must be 'members' or 'repos'", rankingTarget)
+	}
+	if rankingLimit < 1 {
+		return fmt.Errorf("invalid --limit %d: must be at least 1", rankingLimit)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	ctx := context.Background()
+	timeRange := getTimeRange()
+
+	if rankingTarget == "repos" {
+		rankings, err := agg.GetRepoRanking(ctx, org, rt, timeRange, rankingLimit)
+		if err != nil {
+			return fmt.Errorf("failed to get rankings: %w", err)
+		}
+		if rankings == nil {
+			rankings = []*domain.RepoRanking{}
+		}
+		table := viewTable{Header: []string{"Rank", "Repository", "Value", "Commits", "PRs", "Deploys"}}
+		for _, r := range rankings {
+			table.Rows = append(table.Rows, []string{
+				fmt.Sprintf("%d", r.Rank),
+				r.Repo,
+				fmt.Sprintf("%d", r.Value),
+				fmt.Sprintf("%d", r.Commits),
+				fmt.Sprintf("%d", r.PRs),
+				fmt.Sprintf("%d", r.Deploys),
+			})
+		}
+		return render(&view{
+			Title:     rankingTitle("Repository", org, rt),
+			TimeRange: timeRange,
+			Tables:    []viewTable{table},
+			Value:     rankings,
+		})
+	}
+
+	rankings, err := agg.GetMemberRanking(ctx, org, rt, timeRange, rankingLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get rankings: %w", err)
+	}
+	if rankings == nil {
+		rankings = []*domain.MemberRanking{}
+	}
+	table := viewTable{Header: []string{"Rank", "Member", "Value", "Commits", "PRs", "Additions", "Deletions", "Deploys"}}
+	for _, r := range rankings {
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprintf("%d", r.Rank),
+			r.Member,
+			fmt.Sprintf("%d", r.Value),
+			fmt.Sprintf("%d", r.Commits),
+			fmt.Sprintf("%d", r.PRs),
+			fmt.Sprintf("%d", r.Additions),
+			fmt.Sprintf("%d", r.Deletions),
+			fmt.Sprintf("%d", r.Deploys),
+		})
+	}
+	return render(&view{
+		Title:     rankingTitle("Member", org, rt),
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value:     rankings,
+	})
+}
+
+// rankingTitle is the title of a ranking table
+func rankingTitle(target, org string, rt domain.RankingType) string {
+	return fmt.Sprintf("%s Ranking by %s: %s", target, rankingValueLabel(rt), org)
+}
+
+// rankingValueLabel names the value a ranking is ordered by
+func rankingValueLabel(rt domain.RankingType) string {
+	switch rt {
+	case domain.RankingTypePRs:
+		return "Pull Requests"
+	case domain.RankingTypeCodeChanges:
+		return "Code Changes"
+	case domain.RankingTypeDeploys:
+		return "Deployments"
+	default:
+		return "Commits"
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/collector"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var reaggregateDryRun bool
+
+var reaggregateCmd = &cobra.Command{
+	Use:   "reaggregate [owner...]",
+	Short: "Replay stored events to rebuild the data derived from them",
+	Long: `Replay the stored raw events of the given owners, or of every owner without arguments,
+and rebuild what is derived from them with the current metric definitions, e.g. the merge and
+squash commit classification of events collected by an older version. Afterwards the owner's
+metrics are recomputed from the replayed events.
+
+Events are processed in chunks of AGGREGATOR_STREAM_CHUNK (30 days by default) from the
+owner's first event, or over --start/--end, logging the progress of each chunk. Replayed events
+keep their created_at, so the latest event time doesn't move; instead the owner's data version
+is bumped after each chunk that changes events, so running API servers drop their cached
+results and ETags for the owner. With --dry-run the events that would change are counted without saving them.`,
+	Example: `  github-metrics reaggregate my-org
+  github-metrics reaggregate --start 2024-01-01 --dry-run`,
+	SilenceUsage: true,
+	RunE:         runReaggregate,
+}
+
+func init() {
+	reaggregateCmd.Flags().BoolVar(&reaggregateDryRun, "dry-run", false, "count the events that would change without saving them")
+	reaggregateCmd.ValidArgsFunction = completeOwners
+
+	rootCmd.AddCommand(reaggregateCmd)
+}
+
+// replayedEventTypes are the event types replayed, in order
+var replayedEventTypes = []domain.EventType{
+	domain.EventTypeCommit,
+	domain.EventTypePullRequest,
+	domain.EventTypeDeploy,
+	domain.EventTypeReview,
+	domain.EventTypeRelease,
+}
+
+// reaggregateResult is what reaggregate did for an owner, or would do with --dry-run
+type reaggregateResult struct {
+	Owner   string             `json:"owner"`
+	Range   domain.TimeRange   `json:"time_range"`
+	Chunks  int                `json:"chunks"`
+	Events  int64              `json:"events"`  // events replayed
+	Updated int64              `json:"updated"` // events whose derived data changed
+	DryRun  bool               `json:"dry_run"`
+	Metrics *domain.OrgMetrics `json:"metrics,omitempty"` // recomputed from the replayed events
+}
+
+func runReaggregate(cmd *cobra.Command, args []string) error {
+	start, end, err := dateFlags(time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	owners := args
+	if len(owners) == 0 {
+		if owners, err = store.ListOwners(ctx); err != nil {
+			return fmt.Errorf("failed to list owners: %w", err)
+		}
+		if len(owners) == 0 {
+			return fmt.Errorf("no stored events to replay; run collect first")
+		}
+	}
+
+	agg := newAggregator(cfg, store)
+	var results []*reaggregateResult
+	for _, owner := range owners {
+		timeRange := domain.TimeRange{Start: start, End: end, Granularity: granularity}
+		if timeRange.End.IsZero() {
+			timeRange.End = time.Now()
+		}
+		if timeRange.Start.IsZero() {
+			first, err := firstEventTime(ctx, store, owner, timeRange.End)
+			if err != nil {
+				return fmt.Errorf("%s: failed to find the first event: %w", owner, err)
+			}
+			if first.IsZero() {
+				slog.Info("no events to replay", "owner", owner)
+				results = append(results, &reaggregateResult{Owner: owner, Range: timeRange, DryRun: reaggregateDryRun})
+				continue
+			}
+			timeRange.Start = first
+		}
+
+		result, err := replayOwner(ctx, store, owner, timeRange, cfg.StreamChunk)
+		if err != nil {
+			return fmt.Errorf("%s: %w", owner, err)
+		}
+		// Recompute the owner's metrics from the replayed events, as the API will
+		if !reaggregateDryRun {
+			if result.Metrics, err = agg.AggregateOrgMetrics(ctx, owner, timeRange); err != nil {
+				return fmt.Errorf("%s: failed to aggregate: %w", owner, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return printReaggregateResults(results)
+}
+
+// replayOwner replays the owner's events in timeRange chunk by chunk, saving the events whose
+// derived data changes at the end of each chunk
+func replayOwner(ctx context.Context, store storage.Storage, owner string, timeRange domain.TimeRange, chunk time.Duration) (*reaggregateResult, error) {
+	result := &reaggregateResult{Owner: owner, Range: timeRange, DryRun: reaggregateDryRun}
+	chunks := splitRange(timeRange, chunk)
+	result.Chunks = len(chunks)
+	started := time.Now()
+
+	for i, c := range chunks {
+		var replayed int64
+		var changed []*domain.Event
+		for _, eventType := range replayedEventTypes {
+			err := store.StreamEvents(ctx, owner, eventType, c, func(event *domain.Event) error {
+				replayed++
+				if collector.ReclassifyCommit(event) {
+					changed = append(changed, event)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s events: %w", eventType, err)
+			}
+		}
+		if len(changed) > 0 && !reaggregateDryRun {
+			if err := store.SaveRawEvents(ctx, changed); err != nil {
+				return nil, fmt.Errorf("failed to save replayed events: %w", err)
+			}
+			// The events keep their created_at, so move the data version for caches and ETags
+			if err := store.BumpDataVersion(ctx, owner); err != nil {
+				return nil, fmt.Errorf("failed to update the data version: %w", err)
+			}
+		}
+		result.Events += replayed
+		result.Updated += int64(len(changed))
+
+		slog.Info("replayed chunk",
+			"owner", owner,
+			"chunk", fmt.Sprintf("%d/%d", i+1, len(chunks)),
+			"from", c.Start.Format("2006-01-02"),
+			"to", c.End.Format("2006-01-02"),
+			"events", replayed,
+			"updated", len(changed),
+			"elapsed", time.Since(started).Round(time.Second).String(),
+		)
+	}
+	return result, nil
+}
+
+// errFirstEvent stops the stream in firstEventTime at the first event
+var errFirstEvent = errors.New("first event found")
+
+// firstEventTime returns the time of the owner's earliest event before end (zero if none)
+func firstEventTime(ctx context.Context, store storage.Storage, owner string, end time.Time) (time.Time, error) {
+	var first time.Time
+	for _, eventType := range replayedEventTypes {
+		// Events are streamed in time order, so the first one is the earliest of its type
+		err := store.StreamEvents(ctx, owner, eventType, domain.TimeRange{Start: time.Unix(0, 0), End: end}, func(event *domain.Event) error {
+			if first.IsZero() || event.Timestamp.Before(first) {
+				first = event.Timestamp
+			}
+			return errFirstEvent
+		})
+		if err != nil && !errors.Is(err, errFirstEvent) {
+			return time.Time{}, err
+		}
+	}
+	return first, nil
+}
+
+// splitRange splits a time range into consecutive chunks of at most chunk length, each ending
+// just before the next starts since storage range queries include both ends
+func splitRange(timeRange domain.TimeRange, chunk time.Duration) []domain.TimeRange {
+	if chunk <= 0 || !timeRange.End.After(timeRange.Start) {
+		return []domain.TimeRange{timeRange}
+	}
+	var chunks []domain.TimeRange
+	for start := timeRange.Start; !start.After(timeRange.End); start = start.Add(chunk) {
+		end := start.Add(chunk - time.Nanosecond)
+		if end.After(timeRange.End) {
+			end = timeRange.End
+		}
+		chunks = append(chunks, domain.TimeRange{Start: start, End: end, Granularity: timeRange.Granularity})
+	}
+	return chunks
+}
+
+// printReaggregateResults prints what was, or would be, replayed and updated for each owner
+func printReaggregateResults(results []*reaggregateResult) error {
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	verb := "updated"
+	if reaggregateDryRun {
+		verb = "would update"
+	}
+	for _, r := range results {
+		if r.Events == 0 {
+			fmt.Printf("%s: no events\n", r.Owner)
+			continue
+		}
+		fmt.Printf("%s: replayed %d events from %s to %s in %d chunks, %s %d\n",
+			r.Owner, r.Events, r.Range.Start.Format("2006-01-02"), r.Range.End.Format("2006-01-02"), r.Chunks, verb, r.Updated)
+		if m := r.Metrics; m != nil {
+			fmt.Printf("  commits %d, pull requests %d, deployments %d, lines +%d/-%d\n", m.Commits, m.PRs, m.Deploys, m.Additions, m.Deletions)
+		}
+	}
+	return nil
+}
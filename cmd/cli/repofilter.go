@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var (
+	filterRepos        []string
+	filterExcludeRepos []string
+)
+
+// addRepoFilterFlags adds the --repos/--exclude-repos glob filters to cmd
+func addRepoFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&filterRepos, "repos", nil, `only repositories matching these glob patterns (e.g. "service-*")`)
+	cmd.Flags().StringSliceVar(&filterExcludeRepos, "exclude-repos", nil, "skip repositories matching these glob patterns")
+}
+
+// repoFilter selects repositories by name with glob patterns (path.Match syntax, case
+// insensitive). A repository matches when it matches any include pattern, or there are
+// none, and no exclude pattern.
+type repoFilter struct {
+	include []string
+	exclude []string
+}
+
+// newRepoFilter validates the patterns and returns a filter for them
+func newRepoFilter(include, exclude []string) (*repoFilter, error) {
+	f := &repoFilter{}
+	for _, list := range []struct {
+		flag     string
+		patterns []string
+		dst      *[]string
+	}{
+		{"--repos", include, &f.include},
+		{"--exclude-repos", exclude, &f.exclude},
+	} {
+		for _, p := range list.patterns {
+			p = strings.ToLower(strings.TrimSpace(p))
+			if p == "" {
+				continue
+			}
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("invalid %s pattern %q: %w", list.flag, p, err)
+			}
+			*list.dst = append(*list.dst, p)
+		}
+	}
+	return f, nil
+}
+
+// repoFilterFromFlags returns the filter given with --repos/--exclude-repos
+func repoFilterFromFlags() (*repoFilter, error) {
+	return newRepoFilter(filterRepos, filterExcludeRepos)
+}
+
+// collectRepoFilter returns the filter of a collection: --repos and --exclude-repos, each
+// defaulting to COLLECT_REPOS and COLLECT_EXCLUDE_REPOS
+func collectRepoFilter(cmd *cobra.Command, cfg *config.Config) (*repoFilter, error) {
+	include, exclude := filterRepos, filterExcludeRepos
+	if !cmd.Flags().Changed("repos") {
+		include = cfg.CollectRepos
+	}
+	if !cmd.Flags().Changed("exclude-repos") {
+		exclude = cfg.CollectExcludeRepos
+	}
+	return newRepoFilter(include, exclude)
+}
+
+// active reports whether the filter excludes anything
+func (f *repoFilter) active() bool {
+	return f != nil && (len(f.include) > 0 || len(f.exclude) > 0)
+}
+
+// match reports whether the filter selects the repository; a nil filter selects all
+func (f *repoFilter) match(repo string) bool {
+	if f == nil {
+		return true
+	}
+	repo = strings.ToLower(repo)
+	for _, p := range f.exclude {
+		if ok, _ := path.Match(p, repo); ok {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if ok, _ := path.Match(p, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRepositories returns the repositories the filter selects
+func (f *repoFilter) filterRepositories(repos []*domain.Repository) []*domain.Repository {
+	filtered := make([]*domain.Repository, 0, len(repos))
+	for _, r := range repos {
+		if f.match(r.Name) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// filterRepoMetrics returns the metrics of the repositories the filter selects
+func (f *repoFilter) filterRepoMetrics(metrics []*domain.RepoMetrics) []*domain.RepoMetrics {
+	filtered := make([]*domain.RepoMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		if f.match(m.Repo) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// filteredMembersMetrics sums each member's metrics over the repositories the filter
+// selects. Members without activity in those repositories are left out.
+func filteredMembersMetrics(ctx context.Context, agg aggregator.Aggregator, org string, f *repoFilter, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
+	repos, err := agg.GetReposMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	byMember := make(map[string]*domain.MemberMetrics)
+	for _, r := range f.filterRepoMetrics(repos) {
+		if r.Commits+r.PRs+r.Deploys == 0 {
+			continue
+		}
+		members, err := agg.GetRepoMembersMetrics(ctx, org, r.Repo, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			total, ok := byMember[m.Member]
+			if !ok {
+				total = &domain.MemberMetrics{Member: m.Member, TimeRange: timeRange}
+				byMember[m.Member] = total
+			}
+			total.Commits += m.Commits
+			total.PRs += m.PRs
+			total.Additions += m.Additions
+			total.Deletions += m.Deletions
+			total.Deploys += m.Deploys
+		}
+	}
+
+	metrics := make([]*domain.MemberMetrics, 0, len(byMember))
+	for _, m := range byMember {
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Member < metrics[j].Member })
+	return metrics, nil
+}
+
+// filteredOrgMetrics sums the metrics of the repositories the filter selects. TotalRepos
+// counts the selected repositories and TotalMembers the members active in them.
+func filteredOrgMetrics(ctx context.Context, agg aggregator.Aggregator, store storage.Storage, org string, f *repoFilter, timeRange domain.TimeRange) (*domain.OrgMetrics, error) {
+	repos, err := store.GetRepositories(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	repoMetrics, err := agg.GetReposMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	members, err := filteredMembersMetrics(ctx, agg, org, f, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &domain.OrgMetrics{
+		Org:          org,
+		TotalRepos:   len(f.filterRepositories(repos)),
+		TotalMembers: len(members),
+		TimeRange:    timeRange,
+	}
+	for _, r := range f.filterRepoMetrics(repoMetrics) {
+		metrics.Commits += r.Commits
+		metrics.PRs += r.PRs
+		metrics.Additions += r.Additions
+		metrics.Deletions += r.Deletions
+		metrics.Deploys += r.Deploys
+	}
+	return metrics, nil
+}
+
+// filteredMemberMetrics sums a member's metrics over the repositories the filter selects
+func filteredMemberMetrics(ctx context.Context, agg aggregator.Aggregator, org, member string, f *repoFilter, timeRange domain.TimeRange) (*domain.MemberMetrics, error) {
+	repos, err := agg.GetMemberReposMetrics(ctx, org, member, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	metrics := &domain.MemberMetrics{Member: member, TimeRange: timeRange}
+	for _, r := range f.filterRepoMetrics(repos) {
+		metrics.Commits += r.Commits
+		metrics.PRs += r.PRs
+		metrics.Additions += r.Additions
+		metrics.Deletions += r.Deletions
+		metrics.Deploys += r.Deploys
+	}
+	return metrics, nil
+}
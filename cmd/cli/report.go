@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/notify"
+)
+
+var (
+	reportPeriod  string
+	reportFormat  string
+	reportOut     string
+	reportTop     int
+	reportChart   string
+	reportEmailTo []string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [org]",
+	Short: "Generate a Markdown or HTML activity report",
+	Long: `Generate a summary report of an organization or user for sharing: totals compared with the
+previous period, top contributors, busiest repositories and the DORA metrics.
+
+--period selects the last complete week (Monday to Sunday), month or quarter and compares it
+with the one before. --start/--end report on an explicit range instead, compared with the
+range of the same length just before it.
+
+The format defaults to the --out file extension (.html/.htm for a self-contained HTML page),
+or Markdown. Without --out the report is written to standard output. --chart also draws the
+commits of the top contributors as a bar chart to a PNG (or, for a .svg file, SVG) image for
+slides and wikis.
+
+--notify-slack also posts the totals, top contributors and top movers to the Slack incoming
+webhook SLACK_WEBHOOK_URL, e.g. from a weekly cron job.
+
+--email-to emails the report as HTML, with the Markdown as plain text, through the SMTP server
+configured with SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. It is not
+printed unless --out is also given. "schedule" emails reports on a schedule with
+REPORT_SCHEDULES.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReport,
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportPeriod, "period", "monthly", "report period (weekly, monthly, quarterly)")
+	reportCmd.Flags().StringVar(&reportFormat, "format", "", "output format (markdown, html)")
+	reportCmd.Flags().StringVarP(&reportOut, "out", "o", "", "output file (default standard output)")
+	reportCmd.Flags().IntVar(&reportTop, "top", 10, "number of contributors and repositories listed")
+	reportCmd.Flags().StringVar(&reportChart, "chart", "", "also draw a bar chart of the top contributors to this PNG or SVG file")
+	reportCmd.Flags().StringSliceVar(&reportEmailTo, "email-to", nil, "email the HTML report to these addresses through the SMTP_* server")
+	addNotifyFlags(reportCmd)
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+// reportData is the content of a report
+type reportData struct {
+	Owner        string
+	Period       string
+	Start        string
+	End          string
+	PrevStart    string
+	PrevEnd      string
+	GeneratedAt  string
+	Summary      []reportRow
+	Contributors []*domain.MemberMetrics
+	Repos        []*domain.RepoMetrics
+	DORA         []reportDORARow
+
+	// Every member of the period and the previous one, for the top movers of notifications
+	members, prevMembers []*domain.MemberMetrics
+}
+
+// reportRow is a total of the report period and the previous period
+type reportRow struct {
+	Name     string
+	Current  int64
+	Previous int64
+	Change   string
+}
+
+// reportDORARow is a DORA metric of the report period and the previous period
+type reportDORARow struct {
+	Name     string
+	Current  string
+	Previous string
+	Level    string
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	path, err := outputPath("out", reportOut)
+	if err != nil {
+		return err
+	}
+	format := reportFormat
+	if format == "" {
+		format = "markdown"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+			format = "html"
+		}
+	}
+	if format == "md" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "html" {
+		return fmt.Errorf("invalid format %q: must be 'markdown' or 'html'", format)
+	}
+	if reportTop < 1 {
+		return fmt.Errorf("invalid --top %d: must be at least 1", reportTop)
+	}
+
+	current, previous, err := reportRanges(reportPeriod, time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	notifier, err := slackNotifier(cfg)
+	if err != nil {
+		return err
+	}
+	var emailer *notify.Email
+	var recipients []string
+	if len(reportEmailTo) > 0 {
+		if recipients, err = notify.ParseRecipients(reportEmailTo); err != nil {
+			return err
+		}
+		if emailer, err = reportEmailer(cfg); err != nil {
+			return err
+		}
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	data, err := buildReport(ctx, newAggregator(cfg, store), org, reportPeriodLabel(), current, previous, reportTop)
+	if err != nil {
+		return err
+	}
+
+	if reportChart != "" {
+		c, err := contributorsChart(fmt.Sprintf("Top contributors by commits: %s, %s", org, formatPeriod(current)), data.Contributors)
+		if err != nil {
+			return err
+		}
+		if err := writeChart(reportChart, c); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote chart to %s\n", reportChart)
+	}
+
+	// Emailing the report replaces printing it, unless --out names a file as well
+	if emailer == nil || path != "" {
+		err := writeOutput(path, func(w io.Writer) error { return renderReport(w, format, data) })
+		if err != nil {
+			return err
+		}
+		if !toStdout(path) {
+			fmt.Fprintf(os.Stderr, "Wrote %s report to %s\n", format, path)
+		}
+	}
+
+	if emailer != nil {
+		mail, err := reportMail(data, recipients)
+		if err != nil {
+			return err
+		}
+		if err := emailer.Send(ctx, mail); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Emailed the report to %s\n", strings.Join(recipients, ", "))
+	}
+	sendNotification(ctx, notifier, reportSummary(data))
+	return nil
+}
+
+// buildReport aggregates the report of org over current, compared with previous, listing the
+// top contributors and repositories. label names the period in the title, e.g. "Weekly".
+func buildReport(ctx context.Context, agg aggregator.Aggregator, org, label string, current, previous domain.TimeRange, top int) (*reportData, error) {
+	orgMetrics, err := agg.AggregateOrgMetrics(ctx, org, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization metrics: %w", err)
+	}
+	prevMetrics, err := agg.AggregateOrgMetrics(ctx, org, previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization metrics: %w", err)
+	}
+	members, err := agg.GetMembersMetrics(ctx, org, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member metrics: %w", err)
+	}
+	prevMembers, err := agg.GetMembersMetrics(ctx, org, previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member metrics: %w", err)
+	}
+	repos, err := agg.GetReposMetrics(ctx, org, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository metrics: %w", err)
+	}
+	prevRepos, err := agg.GetReposMetrics(ctx, org, previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository metrics: %w", err)
+	}
+	dora, err := agg.GetDORAMetrics(ctx, org, "", current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DORA metrics: %w", err)
+	}
+	prevDORA, err := agg.GetDORAMetrics(ctx, org, "", previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DORA metrics: %w", err)
+	}
+
+	summary := []reportRow{
+		newReportRow("Commits", orgMetrics.Commits, prevMetrics.Commits),
+		newReportRow("Pull requests", orgMetrics.PRs, prevMetrics.PRs),
+		newReportRow("Additions", orgMetrics.Additions, prevMetrics.Additions),
+		newReportRow("Deletions", orgMetrics.Deletions, prevMetrics.Deletions),
+		newReportRow("Deployments", orgMetrics.Deploys, prevMetrics.Deploys),
+		newReportRow("Active members", countActiveMembers(members), countActiveMembers(prevMembers)),
+		newReportRow("Active repositories", countActiveRepos(repos), countActiveRepos(prevRepos)),
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Commits != members[j].Commits {
+			return members[i].Commits > members[j].Commits
+		}
+		return members[i].PRs > members[j].PRs
+	})
+	sort.SliceStable(repos, func(i, j int) bool {
+		if repos[i].Commits+repos[i].PRs != repos[j].Commits+repos[j].PRs {
+			return repos[i].Commits+repos[i].PRs > repos[j].Commits+repos[j].PRs
+		}
+		return repos[i].Repo < repos[j].Repo
+	})
+
+	return &reportData{
+		Owner:        org,
+		Period:       label,
+		Start:        current.Start.Format("2006-01-02"),
+		End:          current.End.Format("2006-01-02"),
+		PrevStart:    previous.Start.Format("2006-01-02"),
+		PrevEnd:      previous.End.Format("2006-01-02"),
+		GeneratedAt:  time.Now().Format("2006-01-02 15:04 MST"),
+		Contributors: members[:min(top, len(members))],
+		Repos:        repos[:min(top, len(repos))],
+		Summary:      summary,
+		DORA: []reportDORARow{
+			{
+				Name:     "Deployment frequency",
+				Current:  formatPerDay(dora.DeploymentFrequency, dora.Deployments),
+				Previous: formatPerDay(prevDORA.DeploymentFrequency, prevDORA.Deployments),
+				Level:    doraLevel(dora.DeploymentLevel),
+			},
+			{
+				Name:     "Lead time for changes",
+				Current:  formatHours(dora.LeadTimeHours, dora.MergedPRs),
+				Previous: formatHours(prevDORA.LeadTimeHours, prevDORA.MergedPRs),
+				Level:    doraLevel(dora.LeadTimeLevel),
+			},
+			{
+				Name:     "Change failure rate",
+				Current:  formatRate(dora.ChangeFailureRate, dora.Deployments),
+				Previous: formatRate(prevDORA.ChangeFailureRate, prevDORA.Deployments),
+				Level:    doraLevel(dora.ChangeFailureLevel),
+			},
+			{
+				Name:     "Time to restore",
+				Current:  formatHours(dora.TimeToRestoreHours, dora.Restores),
+				Previous: formatHours(prevDORA.TimeToRestoreHours, prevDORA.Restores),
+				Level:    doraLevel(dora.TimeToRestoreLevel),
+			},
+		},
+		members:     members,
+		prevMembers: prevMembers,
+	}, nil
+}
+
+// renderReport writes the report in format, "markdown" or "html"
+func renderReport(w io.Writer, format string, data *reportData) error {
+	var err error
+	if format == "html" {
+		err = reportHTMLTemplate.Execute(w, data)
+	} else {
+		err = reportMarkdownTemplate.Execute(w, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// reportMail is the report as an email to recipients: HTML, with the Markdown as the plain
+// text alternative
+func reportMail(data *reportData, recipients []string) (*notify.Mail, error) {
+	var text, html strings.Builder
+	if err := renderReport(&text, "markdown", data); err != nil {
+		return nil, err
+	}
+	if err := renderReport(&html, "html", data); err != nil {
+		return nil, err
+	}
+	return &notify.Mail{
+		To:      recipients,
+		Subject: fmt.Sprintf("%s report: %s (%s to %s)", data.Period, data.Owner, data.Start, data.End),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// reportRanges returns the time range of the report and the previous period it is compared
+// with. Storage range queries are inclusive, so ranges end just before the next one starts.
+func reportRanges(period string, now time.Time) (current, previous domain.TimeRange, err error) {
+	if startDate != "" || endDate != "" {
+		current = getTimeRange()
+		return current, previousRange(current), nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var end time.Time
+	var back func(time.Time) time.Time
+	switch period {
+	case "weekly":
+		// Weeks start on Monday
+		end = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		back = func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }
+	case "monthly":
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		back = func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }
+	case "quarterly":
+		end = time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, now.Location())
+		back = func(t time.Time) time.Time { return t.AddDate(0, -3, 0) }
+	default:
+		return current, previous, fmt.Errorf("invalid period %q: must be 'weekly', 'monthly' or 'quarterly'", period)
+	}
+
+	start := back(end)
+	current = domain.TimeRange{Start: start, End: end.Add(-time.Nanosecond), Granularity: granularity}
+	previous = domain.TimeRange{Start: back(start), End: start.Add(-time.Nanosecond), Granularity: granularity}
+	return current, previous, nil
+}
+
+// previousRange returns the range of the same length just before current
+func previousRange(current domain.TimeRange) domain.TimeRange {
+	length := current.End.Sub(current.Start)
+	return domain.TimeRange{
+		Start:       current.Start.Add(-length),
+		End:         current.Start.Add(-time.Nanosecond),
+		Granularity: current.Granularity,
+	}
+}
+
+// reportPeriodLabel describes the report period in the report title
+func reportPeriodLabel() string {
+	if startDate != "" || endDate != "" {
+		return "Activity"
+	}
+	return periodLabel(reportPeriod)
+}
+
+// periodLabel names a report period: weekly, monthly or quarterly
+func periodLabel(period string) string {
+	switch period {
+	case "weekly":
+		return "Weekly"
+	case "quarterly":
+		return "Quarterly"
+	default:
+		return "Monthly"
+	}
+}
+
+// newReportRow compares a total with the previous period
+func newReportRow(name string, current, previous int64) reportRow {
+	row := reportRow{Name: name, Current: current, Previous: previous}
+	switch {
+	case previous == 0 && current == 0:
+		row.Change = "-"
+	case previous == 0:
+		row.Change = "new"
+	default:
+		row.Change = fmt.Sprintf("%+.1f%%", float64(current-previous)/float64(previous)*100)
+	}
+	return row
+}
+
+// countActiveMembers counts the members with any activity
+func countActiveMembers(metrics []*domain.MemberMetrics) int64 {
+	var n int64
+	for _, m := range metrics {
+		if m.Commits+m.PRs+m.Deploys > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// countActiveRepos counts the repositories with any activity
+func countActiveRepos(metrics []*domain.RepoMetrics) int64 {
+	var n int64
+	for _, m := range metrics {
+		if m.Commits+m.PRs+m.Deploys > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// formatPerDay formats a daily rate, or "-" without samples
+func formatPerDay(perDay float64, samples int64) string {
+	if samples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f / day", perDay)
+}
+
+// formatHours formats a duration in hours, or "-" without samples
+func formatHours(hours float64, samples int64) string {
+	if samples == 0 {
+		return "-"
+	}
+	if hours >= 48 {
+		return fmt.Sprintf("%.1f days", hours/24)
+	}
+	return fmt.Sprintf("%.1f hours", hours)
+}
+
+// formatRate formats a ratio as a percentage, or "-" without samples
+func formatRate(rate float64, samples int64) string {
+	if samples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
+
+// doraLevel formats a DORA performance level, or "-" when it could not be classified
+func doraLevel(level domain.DORAPerformance) string {
+	if level == "" {
+		return "-"
+	}
+	return string(level)
+}
+
+var reportMarkdownTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"inc": inc}).Parse(`# {{.Period}} report: {{.Owner}}
+
+Period: {{.Start}} to {{.End}} (compared with {{.PrevStart}} to {{.PrevEnd}})
+
+## Summary
+
+| Metric | Current | Previous | Change |
+|---|---:|---:|---:|
+{{range .Summary}}| {{.Name}} | {{.Current}} | {{.Previous}} | {{.Change}} |
+{{end}}
+## Top contributors
+
+{{if .Contributors}}| # | Member | Commits | PRs | Additions | Deletions |
+|---:|---|---:|---:|---:|---:|
+{{range $i, $m := .Contributors}}| {{inc $i}} | {{$m.Member}} | {{$m.Commits}} | {{$m.PRs}} | {{$m.Additions}} | {{$m.Deletions}} |
+{{end}}{{else}}No activity in this period.
+{{end}}
+## Busiest repositories
+
+{{if .Repos}}| # | Repository | Commits | PRs | Deployments |
+|---:|---|---:|---:|---:|
+{{range $i, $r := .Repos}}| {{inc $i}} | {{$r.Repo}} | {{$r.Commits}} | {{$r.PRs}} | {{$r.Deploys}} |
+{{end}}{{else}}No activity in this period.
+{{end}}
+## DORA metrics
+
+| Metric | Current | Previous | Level |
+|---|---:|---:|---|
+{{range .DORA}}| {{.Name}} | {{.Current}} | {{.Previous}} | {{.Level}} |
+{{end}}
+_Generated {{.GeneratedAt}}_
+`))
+
+var reportHTMLTemplate = htmltemplate.Must(htmltemplate.New("report").Funcs(htmltemplate.FuncMap{"inc": inc}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Period}} report: {{.Owner}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+h2 { margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; }
+th { background: #f6f8fa; text-align: left; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.muted { color: #656d76; }
+.level-elite { color: #1a7f37; font-weight: 600; }
+.level-high { color: #0969da; font-weight: 600; }
+.level-medium { color: #9a6700; font-weight: 600; }
+.level-low { color: #cf222e; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Period}} report: {{.Owner}}</h1>
+<p class="muted">Period: {{.Start}} to {{.End}} (compared with {{.PrevStart}} to {{.PrevEnd}})</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Metric</th><th>Current</th><th>Previous</th><th>Change</th></tr>
+{{range .Summary}}<tr><td>{{.Name}}</td><td class="num">{{.Current}}</td><td class="num">{{.Previous}}</td><td class="num">{{.Change}}</td></tr>
+{{end}}</table>
+
+<h2>Top contributors</h2>
+{{if .Contributors}}<table>
+<tr><th>#</th><th>Member</th><th>Commits</th><th>PRs</th><th>Additions</th><th>Deletions</th></tr>
+{{range $i, $m := .Contributors}}<tr><td class="num">{{inc $i}}</td><td>{{$m.Member}}</td><td class="num">{{$m.Commits}}</td><td class="num">{{$m.PRs}}</td><td class="num">{{$m.Additions}}</td><td class="num">{{$m.Deletions}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">No activity in this period.</p>
+{{end}}
+<h2>Busiest repositories</h2>
+{{if .Repos}}<table>
+<tr><th>#</th><th>Repository</th><th>Commits</th><th>PRs</th><th>Deployments</th></tr>
+{{range $i, $r := .Repos}}<tr><td class="num">{{inc $i}}</td><td>{{$r.Repo}}</td><td class="num">{{$r.Commits}}</td><td class="num">{{$r.PRs}}</td><td class="num">{{$r.Deploys}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">No activity in this period.</p>
+{{end}}
+<h2>DORA metrics</h2>
+<table>
+<tr><th>Metric</th><th>Current</th><th>Previous</th><th>Level</th></tr>
+{{range .DORA}}<tr><td>{{.Name}}</td><td class="num">{{.Current}}</td><td class="num">{{.Previous}}</td><td class="level-{{.Level}}">{{.Level}}</td></tr>
+{{end}}</table>
+
+<p class="muted">Generated {{.GeneratedAt}}</p>
+</body>
+</html>
+`))
+
+// inc returns i+1, numbering template rows from one
+func inc(i int) int {
+	return i + 1
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var retryConcurrency int
+
+var collectRetryCmd = &cobra.Command{
+	Use:   "retry <batch-id>",
+	Short: "Collect the repositories that failed in a collection batch again",
+	Long: `Collect again only the repositories marked failed in a collection batch, over the batch's
+own date range, and record the outcome in the same batch. Repositories the batch already
+collected are left as they are, so a few failures don't require rerunning the whole window.
+
+List the batches with "github-metrics batches" and their failed repositories with
+"github-metrics batches show <id> --failed".`,
+	Example:      `  github-metrics collect retry organization-my-org-1717200000-1719792000`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runCollectRetry,
+}
+
+func init() {
+	collectRetryCmd.Flags().IntVar(&retryConcurrency, "concurrency", 0, "repositories collected at once (default COLLECT_CONCURRENCY, or 5)")
+	collectCmd.AddCommand(collectRetryCmd)
+}
+
+// retryResult is the outcome of collect retry in JSON output
+type retryResult struct {
+	BatchID   string `json:"batch_id"`
+	Owner     string `json:"owner"`
+	Retried   int    `json:"retried_repos"`
+	Collected int    `json:"collected_repos"`
+	Failed    int    `json:"failed_repos"` // repositories of the batch still failed
+	Events    int    `json:"events"`
+}
+
+func runCollectRetry(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cmd.Flags().Changed("concurrency") {
+		if retryConcurrency < 1 {
+			return fmt.Errorf("invalid --concurrency %d: must be at least 1", retryConcurrency)
+		}
+		cfg.CollectConcurrency = retryConcurrency
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	batch, err := store.GetBatch(ctx, args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("batch %q not found: list the batches with \"github-metrics batches\"", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read batch: %w", err)
+	}
+	statuses, err := store.GetBatchRepoStatuses(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read batch %s: %w", batch.ID, err)
+	}
+	var failed []string
+	for _, st := range statuses {
+		if st.Status == domain.BatchRepoStatusFailed {
+			failed = append(failed, st.Repo)
+		}
+	}
+	if len(failed) == 0 {
+		return fmt.Errorf("batch %s has no failed repositories to retry", batch.ID)
+	}
+
+	// The batch is collected the way it was created, whatever GITHUB_MODE is now
+	cfg.Mode = batch.Mode
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	filter, err := newRepoFilter(failed, nil)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("retrying failed repositories", "batch_id", batch.ID, "repos", len(failed))
+	result, err := collectOwner(ctx, cfg, store, batch.Owner, collectOptions{
+		Batch:  batch,
+		Resume: true,
+		Repos:  filter,
+	})
+	if err != nil {
+		return err
+	}
+
+	retried := &retryResult{
+		BatchID:   batch.ID,
+		Owner:     batch.Owner,
+		Retried:   len(failed),
+		Collected: len(failed) - result.Failed,
+		Failed:    result.Failed,
+		Events:    result.Events,
+	}
+	if outputJSON {
+		if err := printJSON(retried); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Retried %d repositories of batch %s: %d collected, %d still failed, %d events\n",
+			retried.Retried, retried.BatchID, retried.Collected, retried.Failed, retried.Events)
+	}
+	if retried.Failed > 0 {
+		return &exitError{code: exitPartialFailure, err: fmt.Errorf("%d repositories still failed: see \"github-metrics batches show %s --failed\"", retried.Failed, batch.ID)}
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/logging"
+	"github.com/kurihiro0119/github-activity-metrics/internal/notify"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var scheduleRunNow bool
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run collections and emailed reports on a schedule",
+	Long: `Run as a long-lived process that collects each owner in COLLECT_SCHEDULES on its cron
+schedule, e.g. COLLECT_SCHEDULES="my-org=0 2 * * *;my-user=@every 6h". Expressions use the
+standard five fields or descriptors such as @daily and @every <duration>, in local time unless
+prefixed with CRON_TZ=<zone>.
+
+Each run is incremental: repositories are collected from their last sync time. A run is
+skipped when the previous run for the same owner is still in progress.
+
+REPORT_SCHEDULES lists owners whose report of the last complete REPORT_PERIOD (weekly by
+default) is emailed to REPORT_EMAIL_TO through the SMTP_* server in the same way, e.g.
+REPORT_SCHEDULES="my-org=0 8 * * 1" for Monday mornings.
+
+SIGHUP reloads the configuration file, .env and the environment without a restart: the
+schedules, repository filters, report and SMTP settings, GitHub tokens and LOG_LEVEL (which
+--verbose and --quiet override) apply to the following runs. Storage settings need a restart.`,
+	Args: cobra.NoArgs,
+	RunE: runSchedule,
+}
+
+func init() {
+	scheduleCmd.Flags().BoolVar(&scheduleRunNow, "run-now", false, "also collect every owner once at startup")
+
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+// scheduledCollection collects one owner, skipping runs that would overlap
+type scheduledCollection struct {
+	cfg     *config.Config
+	store   storage.Storage
+	owner   string
+	repos   *repoFilter     // COLLECT_REPOS/COLLECT_EXCLUDE_REPOS
+	hook    *notify.Webhook // METRICS_WEBHOOK_URLS, nil if none
+	running *sync.Mutex     // shared by every schedule of the owner
+}
+
+// run collects the owner unless a collection of it is already running
+func (s *scheduledCollection) run(ctx context.Context) {
+	if !s.running.TryLock() {
+		slog.Warn("skipping scheduled collection: the previous run is still in progress", "owner", s.owner)
+		return
+	}
+	defer s.running.Unlock()
+
+	slog.Info("starting scheduled collection", "owner", s.owner)
+	start := time.Now()
+	result, err := collectOwner(ctx, s.cfg, s.store, s.owner, collectOptions{
+		TimeRange:   domain.TimeRange{Start: start.AddDate(0, -1, 0), End: start},
+		Incremental: true,
+		Repos:       s.repos,
+		Plain:       true,
+	})
+	if err != nil {
+		result = &collectResult{Owner: s.owner}
+	}
+	result.finish(err, time.Since(start))
+	sendMetricsWebhooks(ctx, s.hook, s.cfg, s.store, result, start)
+	if err != nil {
+		slog.Error("scheduled collection failed", "owner", s.owner, "duration", time.Since(start).Round(time.Second).String(), "error", err)
+		return
+	}
+	if result.Failed > 0 {
+		slog.Warn("scheduled collection finished with failed repositories", "owner", s.owner, "batch_id", result.BatchID,
+			"failed_repos", result.Failed, "repos", result.Repos, "duration", time.Since(start).Round(time.Second).String())
+		return
+	}
+	slog.Info("finished scheduled collection", "owner", s.owner, "duration", time.Since(start).Round(time.Second).String())
+}
+
+// scheduledReport emails the report of one owner
+type scheduledReport struct {
+	agg     aggregator.Aggregator
+	owner   string
+	period  string // weekly, monthly or quarterly
+	to      []string
+	emailer *notify.Email
+}
+
+// run emails the report of the last complete period
+func (s *scheduledReport) run(ctx context.Context) {
+	period, err := s.send(ctx)
+	if err != nil {
+		slog.Error("scheduled report failed", "owner", s.owner, "error", err)
+		return
+	}
+	slog.Info("emailed scheduled report", "owner", s.owner, "period", formatPeriod(period), "recipients", len(s.to))
+}
+
+// send builds and emails the report, returning the period it covers
+func (s *scheduledReport) send(ctx context.Context) (domain.TimeRange, error) {
+	current, previous, err := reportRanges(s.period, time.Now())
+	if err != nil {
+		return current, err
+	}
+	data, err := buildReport(ctx, s.agg, s.owner, periodLabel(s.period), current, previous, 10)
+	if err != nil {
+		return current, err
+	}
+	mail, err := reportMail(data, s.to)
+	if err != nil {
+		return current, err
+	}
+	return current, s.emailer.Send(ctx, mail)
+}
+
+func runSchedule(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if !logVerbose && !logQuiet {
+		logLevel.Set(logging.ParseLevel(cfg.LogLevel))
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	stopMetrics, err := serveMetrics(cfg.CollectMetricsAddr)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := &scheduleDaemon{ctx: ctx, store: store, scheduler: cron.New(), locks: make(map[string]*sync.Mutex)}
+	if err := d.apply(cfg); err != nil {
+		return err
+	}
+
+	d.scheduler.Start()
+	if scheduleRunNow {
+		for _, job := range d.jobs {
+			go job.run(ctx)
+		}
+	}
+
+	// Reload the configuration on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			if err := d.reload(); err != nil {
+				slog.Error("failed to reload configuration; keeping the current schedules", "error", err)
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("shutting down scheduler; interrupted collections can be continued with collect --resume")
+	// Running collections see the canceled context and stop at the next request
+	<-d.scheduler.Stop().Done()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, lock := range d.locks {
+		lock.Lock()
+	}
+	return nil
+}
+
+// scheduleDaemon runs the scheduled collections and reports of the configuration, replacing
+// them when SIGHUP reloads it
+type scheduleDaemon struct {
+	ctx       context.Context
+	store     storage.Storage // storage settings take effect on restart
+	scheduler *cron.Cron
+
+	mu      sync.Mutex
+	cfg     *config.Config
+	entries []cron.EntryID
+	jobs    []*scheduledCollection
+	locks   map[string]*sync.Mutex // per owner, kept across reloads so that runs never overlap
+}
+
+// apply replaces the scheduled jobs with those of cfg. Every schedule is checked first, so
+// the current jobs stay in place if one is invalid.
+func (d *scheduleDaemon) apply(cfg *config.Config) error {
+	if len(cfg.Schedules) == 0 && len(cfg.ReportSchedules) == 0 {
+		return fmt.Errorf("no schedules configured: set COLLECT_SCHEDULES (e.g. \"my-org=0 2 * * *\") or REPORT_SCHEDULES")
+	}
+	repos, err := newRepoFilter(cfg.CollectRepos, cfg.CollectExcludeRepos)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	type entry struct {
+		schedule cron.Schedule
+		run      func()
+	}
+	var entries []entry
+	var jobs []*scheduledCollection
+	hook, err := metricsWebhook(cfg)
+	if err != nil {
+		return err
+	}
+	for _, sc := range cfg.Schedules {
+		if sc.Owner == "" || sc.Spec == "" {
+			return fmt.Errorf("invalid schedule %q: must be owner=cron expression", sc.Owner+"="+sc.Spec)
+		}
+		schedule, err := cron.ParseStandard(sc.Spec)
+		if err != nil {
+			return fmt.Errorf("invalid schedule for %s (%q): %w", sc.Owner, sc.Spec, err)
+		}
+		if d.locks[sc.Owner] == nil {
+			d.locks[sc.Owner] = &sync.Mutex{}
+		}
+		job := &scheduledCollection{cfg: cfg, store: d.store, owner: sc.Owner, repos: repos, hook: hook, running: d.locks[sc.Owner]}
+		entries = append(entries, entry{schedule, func() { job.run(d.ctx) }})
+		jobs = append(jobs, job)
+	}
+
+	if len(cfg.ReportSchedules) > 0 {
+		if _, _, err := reportRanges(cfg.ReportPeriod, time.Now()); err != nil {
+			return fmt.Errorf("invalid REPORT_PERIOD: %w", err)
+		}
+		to, err := notify.ParseRecipients(cfg.ReportEmailTo)
+		if err != nil {
+			return fmt.Errorf("invalid REPORT_EMAIL_TO: %w", err)
+		}
+		emailer, err := reportEmailer(cfg)
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		agg := newAggregator(cfg, d.store)
+		for _, sc := range cfg.ReportSchedules {
+			if sc.Owner == "" || sc.Spec == "" {
+				return fmt.Errorf("invalid report schedule %q: must be owner=cron expression", sc.Owner+"="+sc.Spec)
+			}
+			schedule, err := cron.ParseStandard(sc.Spec)
+			if err != nil {
+				return fmt.Errorf("invalid report schedule for %s (%q): %w", sc.Owner, sc.Spec, err)
+			}
+			job := &scheduledReport{agg: agg, owner: sc.Owner, period: cfg.ReportPeriod, to: to, emailer: emailer}
+			entries = append(entries, entry{schedule, func() { job.run(d.ctx) }})
+		}
+	}
+
+	for _, id := range d.entries {
+		d.scheduler.Remove(id)
+	}
+	d.entries = nil
+	for _, e := range entries {
+		d.entries = append(d.entries, d.scheduler.Schedule(e.schedule, cron.FuncJob(e.run)))
+	}
+
+	var previous config.Config
+	if d.cfg != nil {
+		previous = *d.cfg
+	}
+	added, removed := scheduleChanges(previous.Schedules, cfg.Schedules)
+	for _, sc := range removed {
+		slog.Info("unscheduled collection", "owner", sc.Owner, "schedule", sc.Spec)
+	}
+	for _, sc := range added {
+		slog.Info("scheduled collection", "owner", sc.Owner, "schedule", sc.Spec)
+	}
+	added, removed = scheduleChanges(previous.ReportSchedules, cfg.ReportSchedules)
+	for _, sc := range removed {
+		slog.Info("unscheduled report", "owner", sc.Owner, "schedule", sc.Spec)
+	}
+	for _, sc := range added {
+		slog.Info("scheduled report", "owner", sc.Owner, "schedule", sc.Spec, "period", cfg.ReportPeriod)
+	}
+
+	d.cfg, d.jobs = cfg, jobs
+	return nil
+}
+
+// reload re-reads the configuration and applies it: schedules, repository filters, report
+// settings, the GitHub token and, unless --verbose or --quiet is given, LOG_LEVEL. Running
+// collections finish with the settings they started with.
+func (d *scheduleDaemon) reload() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous := d.cfg
+	if err := d.apply(cfg); err != nil {
+		return err
+	}
+
+	var changed []string
+	check := func(name string, differs bool) {
+		if differs {
+			changed = append(changed, name)
+		}
+	}
+	check("COLLECT_SCHEDULES", !slices.Equal(cfg.Schedules, previous.Schedules))
+	check("REPORT_SCHEDULES", !slices.Equal(cfg.ReportSchedules, previous.ReportSchedules))
+	check("COLLECT_REPOS/COLLECT_EXCLUDE_REPOS", !slices.Equal(cfg.CollectRepos, previous.CollectRepos) || !slices.Equal(cfg.CollectExcludeRepos, previous.CollectExcludeRepos))
+	check("COLLECT_CONCURRENCY", cfg.CollectConcurrency != previous.CollectConcurrency)
+	check("GITHUB_RATE_LIMIT_BUDGET", cfg.GitHubRateLimitBudget != previous.GitHubRateLimitBudget)
+	check("COLLECT_REPO_CACHE_TTL", cfg.CollectRepoCacheTTL != previous.CollectRepoCacheTTL)
+	check("REPORT_PERIOD", cfg.ReportPeriod != previous.ReportPeriod)
+	check("REPORT_EMAIL_TO", !slices.Equal(cfg.ReportEmailTo, previous.ReportEmailTo))
+	check("GITHUB_TOKEN", cfg.GitHubToken != previous.GitHubToken || !maps.Equal(cfg.GitHubTokens, previous.GitHubTokens))
+	if cfg.LogLevel != previous.LogLevel {
+		if !logVerbose && !logQuiet {
+			logLevel.Set(logging.ParseLevel(cfg.LogLevel))
+		}
+		changed = append(changed, "LOG_LEVEL")
+	}
+	if cfg.StorageType != previous.StorageType || cfg.SQLitePath != previous.SQLitePath || cfg.PostgresURL != previous.PostgresURL {
+		slog.Warn("storage settings changed; they take effect when the scheduler restarts")
+	}
+
+	slog.Info("configuration reloaded", "changed", changed)
+	return nil
+}
+
+// scheduleChanges returns the schedules of next that aren't in previous, and those of
+// previous that aren't in next
+func scheduleChanges(previous, next []config.ScheduleConfig) (added, removed []config.ScheduleConfig) {
+	for _, sc := range next {
+		if !slices.Contains(previous, sc) {
+			added = append(added, sc)
+		}
+	}
+	for _, sc := range previous {
+		if !slices.Contains(next, sc) {
+			removed = append(removed, sc)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/server"
+)
+
+var (
+	serveHost     string
+	servePort     string
+	serveGRPCPort string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start the API server",
+	Long: `Start the REST API server (and the gRPC server when GRPC_PORT is set) with the same
+configuration and storage as the other commands. It runs until interrupted and reloads the
+reloadable settings on SIGHUP, like the standalone API server binary.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveHost, "host", "", "address to listen on (default API_HOST)")
+	serveCmd.Flags().StringVar(&servePort, "port", "", "port to listen on (default API_PORT)")
+	serveCmd.Flags().StringVar(&serveGRPCPort, "grpc-port", "", "gRPC port (default GRPC_PORT)")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if serveHost != "" {
+		cfg.APIHost = serveHost
+	}
+	if servePort != "" {
+		cfg.APIPort = servePort
+	}
+	if serveGRPCPort != "" {
+		cfg.GRPCPort = serveGRPCPort
+	}
+
+	return server.Run(cfg)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how much data the database holds and how much space it takes",
+	Long: `Show the size of the database, the events stored for each owner by type with the oldest
+and newest of them, and the rows and sizes of the tables and indexes, for capacity planning.
+
+On PostgreSQL the row counts of tables are the planner's estimates, and each index shows how
+often it was used since the statistics were last reset. SQLite only reports the sizes of
+tables and indexes when go-sqlite3 is built with CGO_CFLAGS=-DSQLITE_ENABLE_DBSTAT_VTAB.`,
+	Example: `  github-metrics stats
+  github-metrics stats --json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runStats,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	stats, err := store.Stats(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get storage statistics: %w", err)
+	}
+	if outputJSON {
+		return printJSON(stats)
+	}
+	printStats(stats)
+	return nil
+}
+
+// printStats prints the statistics of a database as tables
+func printStats(stats *storage.Stats) {
+	fmt.Printf("Storage: %s, %s\n", stats.Storage, formatBytes(stats.SizeBytes))
+	if stats.OldestEvent != nil {
+		fmt.Printf("Events: %d, %s to %s\n", stats.Events,
+			stats.OldestEvent.Local().Format("2006-01-02 15:04:05"), stats.NewestEvent.Local().Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Println("Events: 0")
+	}
+
+	// Event types in a stable order, as columns
+	seen := make(map[domain.EventType]bool)
+	var types []domain.EventType
+	for _, o := range stats.Owners {
+		for t := range o.ByType {
+			if !seen[t] {
+				seen[t] = true
+				types = append(types, t)
+			}
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	if len(stats.Owners) > 0 {
+		fmt.Println()
+		header := []string{"Owner", "Events"}
+		for _, t := range types {
+			header = append(header, string(t))
+		}
+		header = append(header, "Oldest", "Newest")
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(header)
+		for _, o := range stats.Owners {
+			row := []string{o.Owner, fmt.Sprintf("%d", o.Events)}
+			for _, t := range types {
+				row = append(row, fmt.Sprintf("%d", o.ByType[t]))
+			}
+			row = append(row, o.OldestEvent.Local().Format("2006-01-02"), o.NewestEvent.Local().Format("2006-01-02"))
+			table.Append(row)
+		}
+		table.Render()
+	}
+
+	// Sizes are unknown on SQLite without dbstat, and only PostgreSQL counts index scans
+	sized := false
+	for _, t := range stats.Tables {
+		sized = sized || t.SizeBytes > 0
+	}
+	fmt.Println()
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(statsColumns([]string{"Table", "Rows", "Size"}, sized, false))
+	for _, t := range stats.Tables {
+		table.Append(statsColumns([]string{t.Name, fmt.Sprintf("%d", t.Rows), formatBytes(t.SizeBytes)}, sized, false))
+	}
+	table.Render()
+
+	scanned := len(stats.Indexes) > 0 && stats.Indexes[0].Scans != nil
+	fmt.Println()
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(statsColumns([]string{"Index", "Table", "Size", "Scans"}, sized, scanned))
+	for _, i := range stats.Indexes {
+		scans := ""
+		if i.Scans != nil {
+			scans = fmt.Sprintf("%d", *i.Scans)
+		}
+		table.Append(statsColumns([]string{i.Name, i.Table, formatBytes(i.SizeBytes), scans}, sized, scanned))
+	}
+	table.Render()
+}
+
+// statsColumns drops the size column (the third) of a row unless sized, and the scans
+// column (the fourth, if any) unless scanned
+func statsColumns(row []string, sized, scanned bool) []string {
+	if len(row) > 3 && !scanned {
+		row = row[:3]
+	}
+	if !sized {
+		row = append(row[:2:2], row[3:]...)
+	}
+	return row
+}
+
+// formatBytes formats a number of bytes with a binary unit, e.g. "12.5 MiB"
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
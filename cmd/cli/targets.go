@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// Statuses of collecting an owner
+const (
+	collectStatusCompleted = "completed"
+	collectStatusPartial   = "partial" // completed, but some repositories could not be collected
+	collectStatusFailed    = "failed"
+)
+
+// exitPartialFailure is the exit code of collections that completed with some repositories
+// failed, telling them apart from collections that failed altogether (exit code 1)
+const exitPartialFailure = 2
+
+// collectResult is the outcome of collecting one owner
+type collectResult struct {
+	Owner       string                         `json:"owner"`
+	BatchID     string                         `json:"batch_id,omitempty"`
+	Status      string                         `json:"status"` // collectStatusCompleted, collectStatusPartial or collectStatusFailed
+	Repos       int                            `json:"repos"`
+	Failed      int                            `json:"failed_repos"`
+	Events      int                            `json:"events"`
+	Duration    time.Duration                  `json:"-"`
+	Seconds     float64                        `json:"duration_seconds"`
+	Error       string                         `json:"error,omitempty"`
+	RepoResults []*domain.RepoCollectionResult `json:"repo_results,omitempty"` // status, events and error of each repository collected
+}
+
+// finish sets the status and duration of a collection that returned err
+func (r *collectResult) finish(err error, duration time.Duration) {
+	switch {
+	case err != nil:
+		r.Status, r.Error = collectStatusFailed, err.Error()
+	case r.Failed > 0:
+		r.Status = collectStatusPartial
+	default:
+		r.Status = collectStatusCompleted
+	}
+	r.Duration = duration
+	r.Seconds = duration.Round(time.Second).Seconds()
+}
+
+// failedRepoResults returns the repositories of the collection that failed
+func (r *collectResult) failedRepoResults() []*domain.RepoCollectionResult {
+	var failed []*domain.RepoCollectionResult
+	for _, repo := range r.RepoResults {
+		if repo.Failed() {
+			failed = append(failed, repo)
+		}
+	}
+	return failed
+}
+
+// collectTargetArgs requires an owner argument unless --targets-file or --repos-file is
+// given; --repos-file lists the owners itself
+func collectTargetArgs(cmd *cobra.Command, args []string) error {
+	if collectReposFile != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--repos-file lists the owners to collect: remove the arguments")
+		}
+		return nil
+	}
+	if len(args) == 0 && collectTargetsFile == "" {
+		return fmt.Errorf("requires an org or user, --targets-file or --repos-file")
+	}
+	return nil
+}
+
+// collectTargets returns the owners given as arguments followed by the ones in
+// --targets-file, each once
+func collectTargets(args []string) ([]string, error) {
+	targets := args
+	if collectTargetsFile != "" {
+		fromFile, err := readTargetsFile(collectTargetsFile)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, fromFile...)
+	}
+
+	seen := make(map[string]bool, len(targets))
+	unique := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if !seen[strings.ToLower(target)] {
+			seen[strings.ToLower(target)] = true
+			unique = append(unique, target)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("no owners to collect in %s", collectTargetsFile)
+	}
+	return unique, nil
+}
+
+// readTargetsFile reads owners from a file, one per line
+func readTargetsFile(path string) ([]string, error) {
+	targets, err := readListFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets file: %w", err)
+	}
+	return targets, nil
+}
+
+// readReposFile reads the repositories to collect from a file, or standard input for "-",
+// one owner/repo per line. It returns the owners in the order they first appear and, for
+// each owner, a filter selecting its listed repositories but none matching exclude.
+func readReposFile(path string, exclude []string) ([]string, map[string]*repoFilter, error) {
+	lines, err := readListFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read repos file: %w", err)
+	}
+
+	var owners []string
+	repos := make(map[string][]string)
+	for _, line := range lines {
+		owner, repo, ok := strings.Cut(line, "/")
+		if !ok || !validName(owner) || !validName(repo) {
+			return nil, nil, fmt.Errorf("invalid line %q in %s: must be owner/repo", line, path)
+		}
+		key := strings.ToLower(owner)
+		if _, ok := repos[key]; !ok {
+			owners = append(owners, owner)
+		}
+		repos[key] = append(repos[key], repo)
+	}
+	if len(owners) == 0 {
+		return nil, nil, fmt.Errorf("no repositories to collect in %s", path)
+	}
+
+	filters := make(map[string]*repoFilter, len(repos))
+	for key, names := range repos {
+		if filters[key], err = newRepoFilter(names, exclude); err != nil {
+			return nil, nil, err
+		}
+	}
+	return owners, filters, nil
+}
+
+// validName reports whether s can be a GitHub owner or repository name; such names can't
+// hold glob metacharacters, so they match only themselves as repoFilter patterns
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// readListFile reads a file, or standard input for "-", of one entry per line; blank lines
+// and lines starting with # are skipped
+func readListFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var entries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// collectOwners collects each target, up to parallel at once, and returns their results in
+// the order of targets. A target that fails does not stop the others.
+func collectOwners(ctx context.Context, cfg *config.Config, store storage.Storage, targets []string, opts collectOptions, parallel int) []*collectResult {
+	results := make([]*collectResult, len(targets))
+	semaphore := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			slog.Info("collecting owner", "owner", target, "position", fmt.Sprintf("%d/%d", i+1, len(targets)))
+			start := time.Now()
+			result, err := collectOwner(ctx, cfg, store, target, opts)
+			if err != nil {
+				slog.Error("collection failed", "owner", target, "error", err)
+				result = &collectResult{Owner: target}
+			}
+			result.finish(err, time.Since(start))
+			results[i] = result
+		}(i, target)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// printCollectSummary prints the results of collecting several owners. Errors of failed
+// owners were logged as they happened.
+func printCollectSummary(results []*collectResult) error {
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	var repos, failed, events int
+	fmt.Println()
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Owner", "Status", "Repositories", "Failed", "Events", "Duration"})
+	for _, r := range results {
+		table.Append([]string{
+			r.Owner, r.Status, fmt.Sprintf("%d", r.Repos), fmt.Sprintf("%d", r.Failed),
+			fmt.Sprintf("%d", r.Events), formatElapsed(r.Duration),
+		})
+		repos += r.Repos
+		failed += r.Failed
+		events += r.Events
+	}
+	table.Append([]string{"Total", "-", fmt.Sprintf("%d", repos), fmt.Sprintf("%d", failed), fmt.Sprintf("%d", events), "-"})
+	table.Render()
+	printRepoFailures(results)
+	return nil
+}
+
+// printRepoFailures lists the repositories that could not be collected with their errors
+func printRepoFailures(results []*collectResult) {
+	header := false
+	for _, r := range results {
+		for _, repo := range r.failedRepoResults() {
+			if !header {
+				fmt.Println("\nFailed repositories:")
+				header = true
+			}
+			fmt.Printf("  %s/%s: %s\n", r.Owner, repo.Repo, repo.Error)
+		}
+	}
+}
+
+// collectError is the error a collection of owners exits with: exit code 1 if any owner
+// could not be collected, exitPartialFailure if only some repositories failed, or nil
+func collectError(results []*collectResult) error {
+	var failedOwners, partial int
+	for _, r := range results {
+		switch r.Status {
+		case collectStatusFailed:
+			failedOwners++
+		case collectStatusPartial:
+			partial++
+		}
+	}
+	switch {
+	case failedOwners > 0:
+		return fmt.Errorf("%d of %d owners could not be collected", failedOwners, len(results))
+	case partial == 1 && len(results) == 1:
+		r := results[0]
+		return &exitError{code: exitPartialFailure, err: fmt.Errorf("%d of %d repositories of %s could not be collected: retry them with \"github-metrics collect retry %s\"",
+			r.Failed, r.Repos, r.Owner, r.BatchID)}
+	case partial > 0:
+		return &exitError{code: exitPartialFailure, err: fmt.Errorf("some repositories of %d of %d owners could not be collected", partial, len(results))}
+	}
+	return nil
+}
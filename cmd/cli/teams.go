@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var showTeamsCmd = &cobra.Command{
+	Use:   "teams [org]",
+	Short: "Show team metrics",
+	Long: `Display metrics for all teams of a GitHub organization. A team's metrics are the sum of
+its members' activity; teams are fetched when the organization is collected.`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowTeams),
+}
+
+var showTeamCmd = &cobra.Command{
+	Use:   "team [org] [team]",
+	Short: "Show metrics for a specific team",
+	Long: `Display metrics for a team of a GitHub organization, given by its slug, and the activity of
+each of its members.`,
+	Example: `  github-metrics show team my-org platform`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    watchable(runShowTeam),
+}
+
+func init() {
+	showCmd.AddCommand(showTeamsCmd)
+	showCmd.AddCommand(showTeamCmd)
+}
+
+// teamMetricsJSON is the JSON form of a team's metrics
+type teamMetricsJSON struct {
+	Team      string              `json:"team"`
+	Name      string              `json:"name"`
+	Members   int                 `json:"members"`
+	Commits   int64               `json:"commits"`
+	PRs       int64               `json:"prs"`
+	Additions int64               `json:"additions"`
+	Deletions int64               `json:"deletions"`
+	Deploys   int64               `json:"deploys"`
+	Activity  []memberMetricsJSON `json:"member_metrics,omitempty"`
+}
+
+func newTeamMetricsJSON(m *domain.TeamMetrics) teamMetricsJSON {
+	return teamMetricsJSON{
+		Team: m.Team, Name: m.Name, Members: m.Members, Commits: m.Commits, PRs: m.PRs,
+		Additions: m.Additions, Deletions: m.Deletions, Deploys: m.Deploys,
+	}
+}
+
+func runShowTeams(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	timeRange := getTimeRange()
+
+	metrics, err := agg.GetTeamsMetrics(context.Background(), org, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if len(metrics) == 0 && format == "table" {
+		fmt.Printf("No teams collected for %s: teams are fetched by \"github-metrics collect %s\"\n", org, org)
+		return nil
+	}
+
+	rows := make([]teamMetricsJSON, 0, len(metrics))
+	table := viewTable{Header: []string{"Team", "Name", "Members", "Commits", "PRs", "Additions", "Deletions", "Deploys"}}
+	for _, m := range metrics {
+		rows = append(rows, newTeamMetricsJSON(m))
+		table.Rows = append(table.Rows, []string{
+			m.Team,
+			m.Name,
+			fmt.Sprintf("%d", m.Members),
+			fmt.Sprintf("%d", m.Commits),
+			fmt.Sprintf("%d", m.PRs),
+			fmt.Sprintf("%d", m.Additions),
+			fmt.Sprintf("%d", m.Deletions),
+			fmt.Sprintf("%d", m.Deploys),
+		})
+	}
+	return render(&view{
+		Title:     "Team Metrics: " + org,
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value:     rows,
+	})
+}
+
+func runShowTeam(cmd *cobra.Command, args []string) error {
+	org := args[0]
+	slug := args[1]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	ctx := context.Background()
+	timeRange := getTimeRange()
+
+	team, err := store.GetTeam(ctx, org, slug)
+	if err != nil {
+		return fmt.Errorf("failed to get team: %w", err)
+	}
+	if team == nil {
+		return fmt.Errorf("team %q not found in %s: list the teams with \"github-metrics show teams %s\"", slug, org, org)
+	}
+	metrics, err := agg.GetTeamMetrics(ctx, org, slug, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+	members, err := agg.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	// Every member of the team is listed, including those without activity in the range
+	byMember := make(map[string]*domain.MemberMetrics, len(members))
+	for _, m := range members {
+		byMember[m.Member] = m
+	}
+	detail := newTeamMetricsJSON(metrics)
+	pseudonyms := memberPseudonyms(cfg)
+	for _, username := range team.Members {
+		username = pseudonyms.Name(org, username)
+		row := memberMetricsJSON{Member: username}
+		if m, ok := byMember[username]; ok {
+			row = newMemberMetricsJSON(m)
+		}
+		detail.Activity = append(detail.Activity, row)
+	}
+
+	title := fmt.Sprintf("Team Metrics: %s/%s", org, slug)
+	if team.Name != "" && team.Name != slug {
+		title += fmt.Sprintf(" (%s)", team.Name)
+	}
+	tables := []viewTable{{
+		Header: []string{"Metric", "Value"},
+		Rows: append([][]string{{"Members", fmt.Sprintf("%d", metrics.Members)}},
+			metricsRows(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)...),
+	}}
+	if len(detail.Activity) > 0 {
+		tables = append(tables, memberMetricsTable(detail.Activity))
+	}
+	return render(&view{
+		Title:     title,
+		TimeRange: timeRange,
+		Tables:    tables,
+		Value:     detail,
+	})
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	timeSeriesRepo      string
+	timeSeriesMember    string
+	timeSeriesChartPath string
+)
+
+var showTimeSeriesCmd = &cobra.Command{
+	Use:   "timeseries [org]",
+	Short: "Show metrics per period with sparklines",
+	Long: `Display commits, pull requests, code changes and deployments per --granularity period of an
+organization or user, or of one repository or member, as a table followed by sparklines of
+the trend. With --chart the same numbers are also drawn as a line chart to a PNG (or, for
+a .svg file, SVG) image.`,
+	Example: `  github-metrics show timeseries my-org --granularity week
+  github-metrics show timeseries my-org --repo api --start 2024-01-01 --granularity month
+  github-metrics show timeseries my-org --granularity week --chart activity.png`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowTimeSeries),
+}
+
+func init() {
+	showTimeSeriesCmd.Flags().StringVar(&timeSeriesRepo, "repo", "", "only this repository")
+	showTimeSeriesCmd.Flags().StringVar(&timeSeriesMember, "member", "", "only this member")
+	showTimeSeriesCmd.Flags().StringVar(&timeSeriesChartPath, "chart", "", "also draw a line chart to this PNG or SVG file")
+	showTimeSeriesCmd.MarkFlagsMutuallyExclusive("repo", "member")
+
+	showCmd.AddCommand(showTimeSeriesCmd)
+}
+
+// timeSeriesPointJSON is the JSON form of a time series data point
+type timeSeriesPointJSON struct {
+	Period    string `json:"period"`
+	Commits   int64  `json:"commits"`
+	PRs       int64  `json:"prs"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	Deploys   int64  `json:"deploys"`
+}
+
+func runShowTimeSeries(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	ctx := context.Background()
+	timeRange := getTimeRange()
+
+	var series *domain.DetailedTimeSeriesData
+	subject := org
+	switch {
+	case timeSeriesRepo != "":
+		series, err = agg.GetRepoTimeSeries(ctx, org, timeSeriesRepo, timeRange)
+		subject = org + "/" + timeSeriesRepo
+	case timeSeriesMember != "":
+		series, err = agg.GetMemberTimeSeries(ctx, org, timeSeriesMember, timeRange)
+		subject = org + " (" + timeSeriesMember + ")"
+	default:
+		series, err = agg.GetOrgTimeSeries(ctx, org, timeRange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get time series: %w", err)
+	}
+
+	if timeSeriesChartPath != "" {
+		c, err := timeSeriesChart(fmt.Sprintf("%s, %s", subject, formatPeriod(timeRange)), series)
+		if err != nil {
+			return err
+		}
+		if err := writeChart(timeSeriesChartPath, c); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote chart to %s\n", timeSeriesChartPath)
+	}
+
+	points := make([]timeSeriesPointJSON, 0, len(series.DataPoints))
+	table := viewTable{Header: []string{"Period", "Commits", "PRs", "Additions", "Deletions", "Deploys"}}
+	for _, p := range series.DataPoints {
+		period := formatTimeSeriesPeriod(p, series.Granularity)
+		points = append(points, timeSeriesPointJSON{
+			Period: period, Commits: p.Commits, PRs: p.PRs,
+			Additions: p.Additions, Deletions: p.Deletions, Deploys: p.Deploys,
+		})
+		table.Rows = append(table.Rows, []string{
+			period,
+			fmt.Sprintf("%d", p.Commits),
+			fmt.Sprintf("%d", p.PRs),
+			fmt.Sprintf("%d", p.Additions),
+			fmt.Sprintf("%d", p.Deletions),
+			fmt.Sprintf("%d", p.Deploys),
+		})
+	}
+	return render(&view{
+		Title:     fmt.Sprintf("Time Series: %s (per %s)", subject, series.Granularity),
+		TimeRange: timeRange,
+		Tables:    []viewTable{table},
+		Value:     points,
+		After:     func(w io.Writer) { printSparklines(w, series.DataPoints) },
+	})
+}
+
+// printSparklines prints the trend of each metric of a time series as a sparkline
+func printSparklines(w io.Writer, points []domain.DetailedTimeSeriesMetric) {
+	if len(points) == 0 {
+		return
+	}
+	commits := make([]int64, len(points))
+	prs := make([]int64, len(points))
+	changes := make([]int64, len(points))
+	deploys := make([]int64, len(points))
+	for i, p := range points {
+		commits[i], prs[i], changes[i], deploys[i] = p.Commits, p.PRs, p.Additions+p.Deletions, p.Deploys
+	}
+	fmt.Fprintln(w)
+	for _, line := range []struct {
+		name   string
+		values []int64
+	}{
+		{"Commits", commits},
+		{"PRs", prs},
+		{"Changes", changes},
+		{"Deploys", deploys},
+	} {
+		total, peak := int64(0), int64(0)
+		for _, v := range line.values {
+			total += v
+			peak = max(peak, v)
+		}
+		fmt.Fprintf(w, "%-8s %s  total %d, peak %d\n", line.name, sparkline(line.values), total, peak)
+	}
+}
+
+// formatTimeSeriesPeriod formats a data point's period: the day, the ISO week with the date of
+// its Monday, or the month
+func formatTimeSeriesPeriod(p domain.DetailedTimeSeriesMetric, granularity string) string {
+	switch granularity {
+	case "week":
+		year, week := p.Timestamp.ISOWeek()
+		return fmt.Sprintf("%d-W%02d (%s)", year, week, p.Timestamp.Format("01-02"))
+	case "month":
+		return p.Timestamp.Format("2006-01")
+	}
+	return p.Timestamp.Format("2006-01-02")
+}
+
+// sparkTicks are the bars of a sparkline, lowest first
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as unicode bars scaled to the largest value
+func sparkline(values []int64) string {
+	var peak int64
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		tick := 0
+		if peak > 0 && v > 0 {
+			tick = int(v * int64(len(sparkTicks)-1) / peak)
+		}
+		b.WriteRune(sparkTicks[tick])
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	trendsPeriod  string
+	trendsPeriods int
+)
+
+// trendThreshold is the smallest change per period, relative to the average, that counts as
+// a trend rather than noise
+const trendThreshold = 0.05
+
+var showTrendsCmd = &cobra.Command{
+	Use:   "trends [org]",
+	Short: "Show how the metrics changed since the previous period",
+	Long: `Display the totals of an organization or user for the last complete week (Monday to Sunday),
+month or quarter next to the period before, with the change as an up or down arrow, for a
+quick look in a weekly stand-up.
+
+Each metric also gets a trend direction, rising, falling or steady, fitted over the last
+--periods periods so that one unusual week doesn't flip it; the History column draws those
+periods as a sparkline, oldest first. A metric is steady when it changes by less than 5% of
+its average per period.
+
+--start/--end select an explicit range instead, compared with ranges of the same length
+just before it.`,
+	Example: `  github-metrics show trends my-org
+  github-metrics show trends my-org --period monthly --periods 12 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: watchable(runShowTrends),
+}
+
+func init() {
+	showTrendsCmd.Flags().StringVar(&trendsPeriod, "period", "weekly", "period compared (weekly, monthly, quarterly)")
+	showTrendsCmd.Flags().IntVar(&trendsPeriods, "periods", 6, "number of periods the trend is detected over, including the current one")
+
+	showCmd.AddCommand(showTrendsCmd)
+}
+
+// trendMetricJSON is the JSON form of a metric's change and trend
+type trendMetricJSON struct {
+	Metric        string   `json:"metric"`
+	Current       int64    `json:"current"`
+	Previous      int64    `json:"previous"`
+	Change        int64    `json:"change"`
+	ChangePercent *float64 `json:"change_percent"` // null when the previous value is 0
+	Trend         string   `json:"trend"`          // rising, falling or steady
+	History       []int64  `json:"history"`        // the metric in each period, oldest first
+}
+
+// trendsJSON is the JSON form of the trends command's output
+type trendsJSON struct {
+	Owner         string            `json:"owner"`
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	PreviousStart time.Time         `json:"previous_start"`
+	PreviousEnd   time.Time         `json:"previous_end"`
+	Metrics       []trendMetricJSON `json:"metrics"`
+}
+
+func runShowTrends(cmd *cobra.Command, args []string) error {
+	org := args[0]
+
+	if trendsPeriods < 2 {
+		return fmt.Errorf("invalid --periods %d: must be at least 2", trendsPeriods)
+	}
+	ranges, err := trendRanges(trendsPeriod, time.Now(), trendsPeriods)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	agg := newAggregator(cfg, store)
+	ctx := context.Background()
+
+	// history[m][i] is metric m in period i
+	names := []string{"Commits", "Pull Requests", "Lines Added", "Lines Deleted", "Deployments", "Active Members", "Active Repositories"}
+	history := make([][]int64, len(names))
+	for _, timeRange := range ranges {
+		totals, err := trendTotals(ctx, agg, org, timeRange)
+		if err != nil {
+			return err
+		}
+		for m := range names {
+			history[m] = append(history[m], totals[m])
+		}
+	}
+
+	current, previous := ranges[len(ranges)-1], ranges[len(ranges)-2]
+	out := trendsJSON{
+		Owner: org, Start: current.Start, End: current.End,
+		PreviousStart: previous.Start, PreviousEnd: previous.End,
+	}
+	table := viewTable{Header: []string{"Metric", "Previous", "Current", "Change", "Trend", "History"}}
+	for m, name := range names {
+		values := history[m]
+		cur, prev := values[len(values)-1], values[len(values)-2]
+		metric := trendMetricJSON{
+			Metric: name, Current: cur, Previous: prev, Change: cur - prev,
+			Trend: detectTrend(values), History: values,
+		}
+		if prev != 0 {
+			percent := float64(cur-prev) / float64(prev) * 100
+			metric.ChangePercent = &percent
+		}
+		out.Metrics = append(out.Metrics, metric)
+		table.Rows = append(table.Rows, []string{
+			name,
+			fmt.Sprintf("%d", prev),
+			fmt.Sprintf("%d", cur),
+			changeArrow(prev, cur) + " " + formatDelta(prev, cur),
+			trendLabel(metric.Trend),
+			sparkline(values),
+		})
+	}
+
+	return render(&view{
+		Title:     fmt.Sprintf("Trends: %s (previous period %s)", org, formatPeriod(previous)),
+		TimeRange: current,
+		Tables:    []viewTable{table},
+		Value:     out,
+		After: func(w io.Writer) {
+			fmt.Fprintf(w, "\nTrend and history over the last %d periods, %s to %s\n",
+				len(ranges), ranges[0].Start.Format("2006-01-02"), current.End.Format("2006-01-02"))
+		},
+	})
+}
+
+// trendRanges returns the last n periods up to the report period of period (see reportRanges),
+// oldest first
+func trendRanges(period string, now time.Time, n int) ([]domain.TimeRange, error) {
+	current, _, err := reportRanges(period, now)
+	if err != nil {
+		return nil, err
+	}
+	ranges := []domain.TimeRange{current}
+	for len(ranges) < n {
+		// The report period before a period's start is the period before it
+		previous, _, err := reportRanges(period, ranges[0].Start)
+		if err != nil {
+			return nil, err
+		}
+		if startDate != "" || endDate != "" {
+			previous = previousRange(ranges[0])
+		}
+		ranges = append([]domain.TimeRange{previous}, ranges...)
+	}
+	return ranges, nil
+}
+
+// trendTotals aggregates the metrics compared by the trends command over a period, in the
+// order of its rows
+func trendTotals(ctx context.Context, agg aggregator.Aggregator, org string, timeRange domain.TimeRange) ([]int64, error) {
+	metrics, err := agg.AggregateOrgMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization metrics: %w", err)
+	}
+	members, err := agg.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member metrics: %w", err)
+	}
+	repos, err := agg.GetReposMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository metrics: %w", err)
+	}
+	return []int64{
+		metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys,
+		countActiveMembers(members), countActiveRepos(repos),
+	}, nil
+}
+
+// detectTrend fits a least-squares line through values, oldest first, and returns "rising" or
+// "falling" when its slope is at least trendThreshold of their average, or else "steady"
+func detectTrend(values []int64) string {
+	n := float64(len(values))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		x, y := float64(i), float64(v)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	mean := sumY / n
+	denominator := n*sumXX - sumX*sumX
+	if mean == 0 || denominator == 0 {
+		return "steady"
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	switch {
+	case slope >= trendThreshold*mean:
+		return "rising"
+	case slope <= -trendThreshold*mean:
+		return "falling"
+	default:
+		return "steady"
+	}
+}
+
+// trendLabel prefixes a trend direction with an arrow
+func trendLabel(trend string) string {
+	switch trend {
+	case "rising":
+		return "↗ rising"
+	case "falling":
+		return "↘ falling"
+	default:
+		return "→ steady"
+	}
+}
+
+// changeArrow is an arrow pointing the way a value changed
+func changeArrow(previous, current int64) string {
+	switch {
+	case current > previous:
+		return "↑"
+	case current < previous:
+		return "↓"
+	default:
+		return "="
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/version"
+)
+
+var versionCheck bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of this build",
+	Long: `Print the version, commit and build date of this build, with the Go version and platform.
+Include this line when reporting an issue.
+
+With --check the latest release is looked up on GitHub and compared with this build.
+Development builds, which have no release version, only print the latest one.`,
+	Example: `  github-metrics version
+  github-metrics version --check --json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "check GitHub for a newer release")
+
+	rootCmd.AddCommand(versionCmd)
+}
+
+// versionJSON is the JSON form of the version command's output
+type versionJSON struct {
+	version.Info
+	Latest          *version.Release `json:"latest,omitempty"`
+	UpdateAvailable *bool            `json:"update_available,omitempty"` // unset when unknown
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	info := version.Get()
+	out := versionJSON{Info: info}
+
+	var checkErr error
+	if versionCheck {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		out.Latest, checkErr = version.LatestRelease(ctx, nil)
+		if checkErr == nil {
+			if newer, ok := version.Newer(out.Latest.Version, info.Version); ok {
+				out.UpdateAvailable = &newer
+			}
+		}
+	}
+
+	if outputJSON {
+		if err := printJSON(out); err != nil {
+			return err
+		}
+		return checkErr
+	}
+
+	fmt.Printf("github-metrics %s\n", info)
+	switch {
+	case checkErr != nil:
+		return checkErr
+	case out.Latest == nil:
+	case out.UpdateAvailable == nil:
+		fmt.Printf("Latest release: %s (%s)\n", out.Latest.Version, out.Latest.URL)
+	case *out.UpdateAvailable:
+		fmt.Printf("A newer version is available: %s, released %s\n%s\n", out.Latest.Version, out.Latest.PublishedAt.Format("2006-01-02"), out.Latest.URL)
+	default:
+		fmt.Println("This is the latest release")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// showWatch is the --watch refresh interval of the show commands in seconds (0 disables)
+var showWatch int
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// watchable wraps a show command so that with --watch it is rerun every showWatch seconds until
+// interrupted. The screen is cleared before each table; the other formats are printed once per
+// refresh so they can be piped, and an --output file is rewritten each time. Errors are shown
+// without stopping, e.g. while a collection holds a lock.
+func watchable(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+		if showWatch < 0 {
+			return fmt.Errorf("invalid --watch %d: must be a number of seconds", showWatch)
+		}
+		if showWatch == 0 {
+			return run(cmd, args)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		interval := time.Duration(showWatch) * time.Second
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if format == "table" && toStdout(outputFile) {
+				fmt.Print(clearScreen)
+				fmt.Printf("Every %s: %s (updated %s, Ctrl+C to exit)\n", interval, cmd.CommandPath(), time.Now().Format("15:04:05"))
+			}
+			if err := run(cmd, args); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/notify"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// collectionDelivery is the data of a collection.completed webhook delivery
+type collectionDelivery struct {
+	Collection *collectResult      `json:"collection"`
+	Metrics    *domain.OrgMetrics  `json:"metrics,omitempty"` // METRICS_WEBHOOK_WINDOW up to the end of the collection
+	Previous   *domain.OrgMetrics  `json:"previous,omitempty"`
+	Thresholds []thresholdDelivery `json:"thresholds,omitempty"` // the thresholds breached now
+	Anomalies  []anomalyDelivery   `json:"anomalies,omitempty"`  // the anomalies now
+}
+
+// Statuses of metric.threshold and metric.anomaly deliveries. An alert is delivered when it
+// is triggered and when it is resolved, not after every collection while it lasts.
+const (
+	alertTriggered = "triggered"
+	alertResolved  = "resolved"
+)
+
+// thresholdDelivery is the data of a metric.threshold webhook delivery
+type thresholdDelivery struct {
+	Status    string           `json:"status"` // alertTriggered when breached, otherwise alertResolved
+	Metric    string           `json:"metric"`
+	Threshold string           `json:"threshold"` // as configured, e.g. "deploys<1"
+	Value     float64          `json:"value"`
+	TimeRange domain.TimeRange `json:"time_range"`
+}
+
+// anomalyDelivery is the data of a metric.anomaly webhook delivery
+type anomalyDelivery struct {
+	Status        string           `json:"status"` // alertTriggered when anomalous, otherwise alertResolved
+	Metric        string           `json:"metric"`
+	Value         float64          `json:"value"`
+	Previous      float64          `json:"previous"`
+	ChangePercent float64          `json:"change_percent"`
+	TimeRange     domain.TimeRange `json:"time_range"`
+}
+
+// anomalyMetrics are the metrics compared with the previous window for anomalies
+var anomalyMetrics = []string{"commits", "prs", "deploys"}
+
+// metricsWebhook returns the notifier of METRICS_WEBHOOK_URLS, or nil if none are configured
+func metricsWebhook(cfg *config.Config) (*notify.Webhook, error) {
+	if len(cfg.MetricsHookURLs) == 0 {
+		return nil, nil
+	}
+	hook, err := notify.NewWebhook(cfg.MetricsHookURLs, cfg.MetricsHookSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_WEBHOOK_URLS: %w", err)
+	}
+	return hook, nil
+}
+
+// sendMetricsWebhooks delivers the collection of an owner that ended at end to hook, if any,
+// with the owner's metrics over METRICS_WEBHOOK_WINDOW, then each threshold and anomaly that
+// was triggered or resolved since the previous collection. Deliveries that fail are logged
+// without failing the collection.
+func sendMetricsWebhooks(ctx context.Context, hook *notify.Webhook, cfg *config.Config, store storage.Storage, result *collectResult, end time.Time) {
+	if hook == nil {
+		return
+	}
+	send := func(event string, data any) bool {
+		if err := hook.Send(ctx, event, result.Owner, data); err != nil {
+			slog.Warn("failed to deliver metrics webhook", "owner", result.Owner, "event", event, "error", err)
+			return false
+		}
+		slog.Debug("delivered metrics webhook", "owner", result.Owner, "event", event)
+		return true
+	}
+
+	delivery := &collectionDelivery{Collection: result}
+	var thresholds []thresholdDelivery
+	var anomalies []anomalyDelivery
+	// A failed collection has no metrics worth checking
+	if result.Status != collectStatusFailed {
+		window := domain.TimeRange{Start: end.Add(-cfg.MetricsHookWindow), End: end}
+		agg := newAggregator(cfg, store)
+		current, err := agg.AggregateOrgMetrics(ctx, result.Owner, window)
+		if err == nil {
+			delivery.Metrics = current
+			delivery.Previous, err = agg.AggregateOrgMetrics(ctx, result.Owner, previousRange(window))
+		}
+		if err != nil {
+			slog.Warn("failed to get metrics for the webhook", "owner", result.Owner, "error", err)
+		} else {
+			thresholds = checkThresholds(cfg.MetricsHookThresholds, current)
+			anomalies = checkAnomalies(cfg.MetricsHookAnomaly, current, delivery.Previous)
+		}
+	}
+	for _, t := range thresholds {
+		if t.Status == alertTriggered {
+			delivery.Thresholds = append(delivery.Thresholds, t)
+		}
+	}
+	for _, a := range anomalies {
+		if a.Status == alertTriggered {
+			delivery.Anomalies = append(delivery.Anomalies, a)
+		}
+	}
+
+	send(notify.WebhookEventCollectionCompleted, delivery)
+	if delivery.Metrics == nil {
+		return
+	}
+
+	alerts, err := store.ListMetricAlerts(ctx, result.Owner)
+	if err != nil {
+		slog.Warn("failed to get the metric alerts in effect", "owner", result.Owner, "error", err)
+		return
+	}
+	inEffect := make(map[string]bool, len(alerts))
+	for _, a := range alerts {
+		inEffect[a.Key] = true
+	}
+	// update delivers an alert whose status changed, recording the new status once delivered
+	// so that an undelivered change is delivered again after the next collection
+	update := func(key, event string, triggered bool, data any) {
+		wasTriggered := inEffect[key]
+		delete(inEffect, key)
+		if triggered == wasTriggered || !send(event, data) {
+			return
+		}
+		var err error
+		if triggered {
+			err = store.SaveMetricAlert(ctx, &domain.MetricAlert{Owner: result.Owner, Key: key})
+		} else {
+			err = store.DeleteMetricAlert(ctx, result.Owner, key)
+		}
+		if err != nil {
+			slog.Warn("failed to record the metric alert", "owner", result.Owner, "alert", key, "error", err)
+		}
+	}
+	for _, t := range thresholds {
+		update("threshold:"+t.Threshold, notify.WebhookEventThreshold, t.Status == alertTriggered, t)
+	}
+	for _, a := range anomalies {
+		update("anomaly:"+a.Metric, notify.WebhookEventAnomaly, a.Status == alertTriggered, a)
+	}
+	// The alerts left are of thresholds and anomalies no longer configured
+	for key := range inEffect {
+		if err := store.DeleteMetricAlert(ctx, result.Owner, key); err != nil {
+			slog.Warn("failed to record the metric alert", "owner", result.Owner, "alert", key, "error", err)
+		}
+	}
+}
+
+// thresholdValue returns the value of one of config.ThresholdMetrics
+func thresholdValue(m *domain.OrgMetrics, metric string) float64 {
+	switch metric {
+	case "commits":
+		return float64(m.Commits)
+	case "prs":
+		return float64(m.PRs)
+	case "additions":
+		return float64(m.Additions)
+	case "deletions":
+		return float64(m.Deletions)
+	case "deploys":
+		return float64(m.Deploys)
+	case "members":
+		return float64(m.TotalMembers)
+	case "repos":
+		return float64(m.TotalRepos)
+	}
+	return 0
+}
+
+// checkThresholds returns every threshold with the value of its metric, triggered if the
+// value is outside of it
+func checkThresholds(thresholds []config.ThresholdConfig, metrics *domain.OrgMetrics) []thresholdDelivery {
+	checked := make([]thresholdDelivery, 0, len(thresholds))
+	for _, t := range thresholds {
+		value := thresholdValue(metrics, t.Metric)
+		status := alertResolved
+		if t.Breached(value) {
+			status = alertTriggered
+		}
+		checked = append(checked, thresholdDelivery{Status: status, Metric: t.Metric, Threshold: t.Entry, Value: value, TimeRange: metrics.TimeRange})
+	}
+	return checked
+}
+
+// checkAnomalies returns every anomaly metric with its change since the previous window,
+// triggered if it changed by percent or more. Metrics without activity in the previous window
+// have no baseline to change from and aren't anomalous; a percent of 0 disables anomalies.
+func checkAnomalies(percent float64, current, previous *domain.OrgMetrics) []anomalyDelivery {
+	if percent <= 0 {
+		return nil
+	}
+	checked := make([]anomalyDelivery, 0, len(anomalyMetrics))
+	for _, metric := range anomalyMetrics {
+		now, before := thresholdValue(current, metric), thresholdValue(previous, metric)
+		var change float64
+		if before != 0 {
+			change = (now - before) / before * 100
+		}
+		status := alertResolved
+		if before != 0 && math.Abs(change) >= percent {
+			status = alertTriggered
+		}
+		checked = append(checked, anomalyDelivery{
+			Status:        status,
+			Metric:        metric,
+			Value:         now,
+			Previous:      before,
+			ChangePercent: math.Round(change*10) / 10,
+			TimeRange:     current.TimeRange,
+		})
+	}
+	return checked
+}
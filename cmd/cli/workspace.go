@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var workspaceName string
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage workspaces",
+	Long: `Manage the workspaces of a multi-tenant deployment. A workspace owns a set of
+organizations/users, served under /api/v1/workspaces/<id>; API keys created with
+--workspace can only access that workspace.`,
+}
+
+var workspaceCreateCmd = &cobra.Command{
+	Use:   "create [id]",
+	Short: "Create a workspace (or rename an existing one)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkspaceCreate,
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List workspaces",
+	Args:  cobra.NoArgs,
+	RunE:  runWorkspaceList,
+}
+
+var workspaceDeleteCmd = &cobra.Command{
+	Use:   "delete [id]",
+	Short: "Delete a workspace",
+	Long:  `Delete a workspace and its owner assignments. Collected data is kept; API keys confined to the workspace stop working.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkspaceDelete,
+}
+
+var workspaceAddOwnerCmd = &cobra.Command{
+	Use:   "add-owner [id] [owner]",
+	Short: "Add an organization or user to a workspace",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runWorkspaceAddOwner,
+}
+
+var workspaceRemoveOwnerCmd = &cobra.Command{
+	Use:   "remove-owner [id] [owner]",
+	Short: "Remove an organization or user from a workspace",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runWorkspaceRemoveOwner,
+}
+
+func init() {
+	workspaceCreateCmd.Flags().StringVar(&workspaceName, "name", "", "display name of the workspace")
+
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceCreateCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceDeleteCmd)
+	workspaceCmd.AddCommand(workspaceAddOwnerCmd)
+	workspaceCmd.AddCommand(workspaceRemoveOwnerCmd)
+}
+
+func runWorkspaceCreate(cmd *cobra.Command, args []string) error {
+	if !domain.IsValidWorkspaceID(args[0]) {
+		return fmt.Errorf("invalid workspace id %q: use lowercase letters, digits and dashes", args[0])
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	name := workspaceName
+	if name == "" {
+		name = args[0]
+	}
+	if err := store.SaveWorkspace(context.Background(), &domain.Workspace{ID: args[0], Name: name}); err != nil {
+		return fmt.Errorf("failed to save workspace: %w", err)
+	}
+
+	fmt.Printf("Saved workspace %q (%s)\n", args[0], name)
+	return nil
+}
+
+func runWorkspaceList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	workspaces, err := store.ListWorkspaces(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Owners", "Created"})
+	for _, ws := range workspaces {
+		table.Append([]string{ws.ID, ws.Name, strings.Join(ws.Owners, ", "), ws.CreatedAt.Format("2006-01-02 15:04")})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runWorkspaceDelete(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.DeleteWorkspace(context.Background(), args[0]); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+
+	fmt.Printf("Deleted workspace %q\n", args[0])
+	return nil
+}
+
+func runWorkspaceAddOwner(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	ws, err := store.GetWorkspace(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if ws == nil {
+		return fmt.Errorf("workspace %q does not exist", args[0])
+	}
+
+	if err := store.AddWorkspaceOwner(ctx, args[0], args[1]); err != nil {
+		return fmt.Errorf("failed to add owner: %w", err)
+	}
+
+	fmt.Printf("Added %s to workspace %q\n", args[1], args[0])
+	return nil
+}
+
+func runWorkspaceRemoveOwner(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := getStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.RemoveWorkspaceOwner(context.Background(), args[0], args[1]); err != nil {
+		return fmt.Errorf("failed to remove owner: %w", err)
+	}
+
+	fmt.Printf("Removed %s from workspace %q\n", args[1], args[0])
+	return nil
+}
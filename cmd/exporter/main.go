@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/exporter"
+)
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if err := exporter.Run(cfg); err != nil {
+		log.Fatalf("Exporter failed: %v", err)
+	}
+}
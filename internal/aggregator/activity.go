@@ -0,0 +1,127 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// repoActivityTally is the activity of a repository while it's being classified
+type repoActivityTally struct {
+	recent, baseline int64
+	last             time.Time
+}
+
+// GetRepoActivity classifies the repositories of an owner as active, slowing, dormant or dead
+// by comparing their events in the recent window with their own baseline. Repositories
+// stored by past collections count even without events, as dead ones.
+func (a *aggregator) GetRepoActivity(ctx context.Context, org string, query domain.RepoActivityQuery) (*domain.RepoActivityReport, error) {
+	recent, baseline := query.Windows()
+	span := domain.TimeRange{Start: baseline.Start, End: recent.End}
+	return cached(ctx, a, org, cacheKey("repo-activity", span, org, recent.Start), func() (*domain.RepoActivityReport, error) {
+		return a.buildRepoActivity(ctx, org, recent, baseline)
+	})
+}
+
+func (a *aggregator) buildRepoActivity(ctx context.Context, org string, recent, baseline domain.TimeRange) (*domain.RepoActivityReport, error) {
+	tallies := make(map[string]*repoActivityTally)
+	repos, err := a.storage.GetRepositories(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		tallies[repo.Name] = &repoActivityTally{}
+	}
+
+	span := domain.TimeRange{Start: baseline.Start, End: recent.End}
+	merges, err := a.loadMergeCommits(ctx, org, span)
+	if err != nil {
+		return nil, err
+	}
+	for _, eventType := range []domain.EventType{domain.EventTypeCommit, domain.EventTypePullRequest, domain.EventTypeDeploy} {
+		counts, err := aggregateEvents(ctx, a, org, eventType, span,
+			func() map[string]*repoActivityTally { return make(map[string]*repoActivityTally) },
+			func(counts map[string]*repoActivityTally, event *domain.Event) {
+				if a.isBot(event.Member) || merges.excludes(event) {
+					return
+				}
+				t, ok := counts[event.Repo]
+				if !ok {
+					t = &repoActivityTally{}
+					counts[event.Repo] = t
+				}
+				if event.Timestamp.Before(recent.Start) {
+					t.baseline++
+				} else {
+					t.recent++
+				}
+				if event.Timestamp.After(t.last) {
+					t.last = event.Timestamp
+				}
+			},
+			mergeRepoActivity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		mergeRepoActivity(tallies, counts)
+	}
+
+	report := &domain.RepoActivityReport{
+		Owner:    org,
+		Repos:    make([]*domain.RepoActivity, 0, len(tallies)),
+		Counts:   make(map[domain.RepoActivityStatus]int, len(domain.RepoActivityStatuses)),
+		Recent:   recent,
+		Baseline: baseline,
+	}
+	for _, status := range domain.RepoActivityStatuses {
+		report.Counts[status] = 0
+	}
+	for name, t := range tallies {
+		status, ratio := domain.ClassifyRepoActivity(t.recent, t.baseline, recent, baseline)
+		activity := &domain.RepoActivity{
+			Repo:           name,
+			Status:         status,
+			RecentEvents:   t.recent,
+			BaselineEvents: t.baseline,
+			Ratio:          ratio,
+		}
+		if !t.last.IsZero() {
+			last := t.last
+			activity.LastActivity = &last
+		}
+		report.Repos = append(report.Repos, activity)
+		report.Counts[status]++
+	}
+
+	rank := make(map[domain.RepoActivityStatus]int, len(domain.RepoActivityStatuses))
+	for i, status := range domain.RepoActivityStatuses {
+		rank[status] = i
+	}
+	sort.Slice(report.Repos, func(i, j int) bool {
+		ri, rj := report.Repos[i], report.Repos[j]
+		if ri.Status != rj.Status {
+			return rank[ri.Status] < rank[rj.Status]
+		}
+		return ri.Repo < rj.Repo
+	})
+	return report, nil
+}
+
+// mergeRepoActivity adds the repository tallies of src to dst
+func mergeRepoActivity(dst, src map[string]*repoActivityTally) {
+	for repo, s := range src {
+		d, ok := dst[repo]
+		if !ok {
+			d = &repoActivityTally{}
+			dst[repo] = d
+		}
+		d.recent += s.recent
+		d.baseline += s.baseline
+		if s.last.After(d.last) {
+			d.last = s.last
+		}
+	}
+}
@@ -2,6 +2,7 @@ package aggregator
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
@@ -28,9 +29,15 @@ type Aggregator interface {
 	// GetReposMetrics retrieves metrics for all repositories
 	GetReposMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error)
 
+	// GetMemberReposMetrics retrieves a member's metrics in each repository they were active in
+	GetMemberReposMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error)
+
 	// GetTimeSeriesMetrics retrieves time series metrics
 	GetTimeSeriesMetrics(ctx context.Context, org string, metricType domain.MetricType, timeRange domain.TimeRange) (*domain.TimeSeriesData, error)
 
+	// GetHeatmap counts events per weekday and hour of day
+	GetHeatmap(ctx context.Context, org string, query domain.HeatmapQuery, timeRange domain.TimeRange) (*domain.Heatmap, error)
+
 	// GetMemberRanking retrieves member rankings
 	GetMemberRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.MemberRanking, error)
 
@@ -45,52 +52,285 @@ type Aggregator interface {
 
 	// GetMemberTimeSeries retrieves time series data for a member
 	GetMemberTimeSeries(ctx context.Context, org, member string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error)
+
+	// GetTeamsMetrics retrieves metrics for all teams of an organization
+	GetTeamsMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.TeamMetrics, error)
+
+	// GetTeamMetrics retrieves metrics for a single team
+	GetTeamMetrics(ctx context.Context, org, team string, timeRange domain.TimeRange) (*domain.TeamMetrics, error)
+
+	// GetTeamTimeSeries retrieves time series data for a team
+	GetTeamTimeSeries(ctx context.Context, org, team string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error)
+
+	// GetTeamRanking retrieves team rankings
+	GetTeamRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.TeamRanking, error)
+
+	// Compare retrieves side-by-side metrics and time series for repositories and members
+	Compare(ctx context.Context, org string, repos, members []string, timeRange domain.TimeRange) (*domain.Comparison, error)
+
+	// GetRepoCommitClassification retrieves conventional-commit type counts per repository
+	GetRepoCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.CommitClassification, error)
+
+	// GetMemberCommitClassification retrieves conventional-commit type counts per member
+	GetMemberCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.CommitClassification, error)
+
+	// GetDORAMetrics computes the DORA metrics of an owner, or of one repository if repo is not empty
+	GetDORAMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.DORAMetrics, error)
+
+	// GetComponentMetrics retrieves the metrics of each component of a repository
+	GetComponentMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.RepoComponentMetrics, error)
+
+	// GetRepoActivity classifies repositories by their recent activity against their own baseline
+	GetRepoActivity(ctx context.Context, org string, query domain.RepoActivityQuery) (*domain.RepoActivityReport, error)
+
+	// InvalidateCache drops cached results for an owner (all owners if empty)
+	InvalidateCache(owner string)
+
+	// GetWatermark returns the state of the owner's data, which changes whenever the data does
+	GetWatermark(ctx context.Context, owner string) (Watermark, error)
+}
+
+// Watermark identifies the state of an owner's data: the latest event created_at moves when
+// events are saved, the data version when data is deleted or rewritten or when the metadata
+// metrics depend on changes
+type Watermark struct {
+	LatestEvent time.Time
+	Version     int64
+}
+
+// Equal reports whether w and other identify the same data
+func (w Watermark) Equal(other Watermark) bool {
+	return w.LatestEvent.Equal(other.LatestEvent) && w.Version == other.Version
 }
 
 // aggregator implements the Aggregator interface
 type aggregator struct {
-	storage storage.Storage
+	storage           storage.Storage
+	cache             *resultCache // nil when caching is disabled
+	dedupMergeCommits bool
+	excludeBots       bool
+	botPatterns       []string      // lowercase globs of bot logins, besides the "[bot]" suffix
+	streamChunk       time.Duration // length of the chunks event-based aggregations are split into
+	pseudonyms        *Pseudonyms   // nil unless member usernames are pseudonymized
+	components        []domain.Component
+}
+
+// Option configures optional aggregator behavior
+type Option func(*aggregator)
+
+// WithCache enables an in-process LRU cache of up to size results, each kept for at most ttl.
+// Entries are also invalidated whenever new events are saved for the owner.
+func WithCache(size int, ttl time.Duration) Option {
+	return func(a *aggregator) {
+		if size > 0 && ttl > 0 {
+			a.cache = newResultCache(size, ttl)
+		}
+	}
+}
+
+// WithMergeCommitDedup excludes PR merge and squash commits from everything that counts
+// commits (totals, lists, rankings, team metrics, time series, heatmaps, activity,
+// components and commit classification), so teams that squash or use merge queues don't get
+// every PR counted both as a PR and as a commit. Rankings are then computed from the members'
+// and repositories' metrics rather than by storage.
+func WithMergeCommitDedup() Option {
+	return func(a *aggregator) {
+		a.dedupMergeCommits = true
+	}
+}
+
+// WithStreamChunk sets the length of the time chunks events are streamed in when aggregating
+// over raw events (time series, commit classification). Non-positive values are ignored.
+func WithStreamChunk(chunk time.Duration) Option {
+	return func(a *aggregator) {
+		if chunk > 0 {
+			a.streamChunk = chunk
+		}
+	}
 }
 
 // NewAggregator creates a new aggregator
-func NewAggregator(storage storage.Storage) Aggregator {
-	return &aggregator{
-		storage: storage,
+func NewAggregator(storage storage.Storage, opts ...Option) Aggregator {
+	a := &aggregator{
+		storage:     storage,
+		streamChunk: DefaultStreamChunk,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.pseudonyms != nil {
+		return newPseudonymizedAggregator(a)
+	}
+	return a
+}
+
+// InvalidateCache drops cached results for an owner (all owners if empty)
+func (a *aggregator) InvalidateCache(owner string) {
+	if a.cache != nil {
+		a.cache.invalidateOwner(owner)
 	}
 }
 
+// GetWatermark returns the state of the owner's data: its latest event created_at and data version
+func (a *aggregator) GetWatermark(ctx context.Context, owner string) (Watermark, error) {
+	latest, err := a.storage.GetLatestEventTime(ctx, owner)
+	if err != nil {
+		return Watermark{}, err
+	}
+	version, err := a.storage.GetDataVersion(ctx, owner)
+	if err != nil {
+		return Watermark{}, err
+	}
+	return Watermark{LatestEvent: latest, Version: version}, nil
+}
+
 // AggregateOrgMetrics aggregates organization-level metrics
 func (a *aggregator) AggregateOrgMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (*domain.OrgMetrics, error) {
-	return a.storage.GetMetricsByOrg(ctx, org, timeRange)
+	return cached(ctx, a, org, cacheKey("org", timeRange, org), func() (*domain.OrgMetrics, error) {
+		metrics, err := a.storage.GetMetricsByOrg(ctx, org, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupOrgMetrics(ctx, metrics, timeRange); err != nil {
+			return nil, err
+		}
+		return metrics, nil
+	})
 }
 
-// AggregateMemberMetrics aggregates member-level metrics
+// AggregateMemberMetrics aggregates member-level metrics, including the activity of the
+// member's aliases. An alias is resolved to its member.
 func (a *aggregator) AggregateMemberMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) (*domain.MemberMetrics, error) {
-	return a.storage.GetMetricsByMember(ctx, org, member, timeRange)
+	member, identities, err := a.memberIdentities(ctx, org, member)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.MemberMetrics{Member: member, TimeRange: timeRange}
+	for _, identity := range identities {
+		metrics, err := a.storage.GetMetricsByMember(ctx, org, identity, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupMemberMetrics(ctx, org, "", []*domain.MemberMetrics{metrics}, timeRange); err != nil {
+			return nil, err
+		}
+		result.Commits += metrics.Commits
+		result.PRs += metrics.PRs
+		result.Additions += metrics.Additions
+		result.Deletions += metrics.Deletions
+		result.Deploys += metrics.Deploys
+	}
+	return result, nil
 }
 
 // AggregateRepoMetrics aggregates repository-level metrics
 func (a *aggregator) AggregateRepoMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.RepoMetrics, error) {
-	return a.storage.GetMetricsByRepo(ctx, org, repo, timeRange)
+	metrics, err := a.storage.GetMetricsByRepo(ctx, org, repo, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.dedupRepoMetrics(ctx, org, "", []*domain.RepoMetrics{metrics}, timeRange); err != nil {
+		return nil, err
+	}
+	return metrics, nil
 }
 
 // GetMembersMetrics retrieves metrics for all members
 func (a *aggregator) GetMembersMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
-	return a.storage.GetMembersWithMetrics(ctx, org, timeRange)
+	return cached(ctx, a, org, cacheKey("members", timeRange, org), func() ([]*domain.MemberMetrics, error) {
+		metrics, err := a.storage.GetMembersWithMetrics(ctx, org, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		metrics = withoutBots(a, metrics, func(m *domain.MemberMetrics) string { return m.Member })
+		if err := a.dedupMemberMetrics(ctx, org, "", metrics, timeRange); err != nil {
+			return nil, err
+		}
+		ids, err := a.loadIdentities(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		return mergeIdentities(ids, metrics), nil
+	})
 }
 
 // GetRepoMembersMetrics retrieves metrics for all members in a specific repository
 func (a *aggregator) GetRepoMembersMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
-	return a.storage.GetRepoMembersWithMetrics(ctx, org, repo, timeRange)
+	metrics, err := a.storage.GetRepoMembersWithMetrics(ctx, org, repo, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	metrics = withoutBots(a, metrics, func(m *domain.MemberMetrics) string { return m.Member })
+	if err := a.dedupMemberMetrics(ctx, org, repo, metrics, timeRange); err != nil {
+		return nil, err
+	}
+	ids, err := a.loadIdentities(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	return mergeIdentities(ids, metrics), nil
 }
 
 // GetReposMetrics retrieves metrics for all repositories
 func (a *aggregator) GetReposMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error) {
-	return a.storage.GetReposWithMetrics(ctx, org, timeRange)
+	return cached(ctx, a, org, cacheKey("repos", timeRange, org), func() ([]*domain.RepoMetrics, error) {
+		metrics, err := a.storage.GetReposWithMetrics(ctx, org, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupRepoMetrics(ctx, org, "", metrics, timeRange); err != nil {
+			return nil, err
+		}
+		return metrics, nil
+	})
+}
+
+// GetMemberReposMetrics retrieves a member's metrics in each repository they were active in,
+// including the activity of the member's aliases
+func (a *aggregator) GetMemberReposMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error) {
+	_, identities, err := a.memberIdentities(ctx, org, member)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*domain.RepoMetrics
+	byRepo := make(map[string]*domain.RepoMetrics)
+	for _, identity := range identities {
+		metrics, err := a.storage.GetMemberReposWithMetrics(ctx, org, identity, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupRepoMetrics(ctx, org, identity, metrics, timeRange); err != nil {
+			return nil, err
+		}
+		for _, m := range metrics {
+			dst, ok := byRepo[m.Repo]
+			if !ok {
+				byRepo[m.Repo] = m
+				result = append(result, m)
+				continue
+			}
+			dst.Commits += m.Commits
+			dst.PRs += m.PRs
+			dst.Additions += m.Additions
+			dst.Deletions += m.Deletions
+			dst.Deploys += m.Deploys
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Repo < result[j].Repo })
+	return result, nil
 }
 
 // GetTimeSeriesMetrics retrieves time series metrics
 func (a *aggregator) GetTimeSeriesMetrics(ctx context.Context, org string, metricType domain.MetricType, timeRange domain.TimeRange) (*domain.TimeSeriesData, error) {
+	return cached(ctx, a, org, cacheKey("timeseries", timeRange, org, metricType), func() (*domain.TimeSeriesData, error) {
+		return a.buildTimeSeriesMetrics(ctx, org, metricType, timeRange)
+	})
+}
+
+// buildTimeSeriesMetrics computes time series metrics from raw events
+func (a *aggregator) buildTimeSeriesMetrics(ctx context.Context, org string, metricType domain.MetricType, timeRange domain.TimeRange) (*domain.TimeSeriesData, error) {
 	// Get events for the time range
 	var eventType domain.EventType
 	switch metricType {
@@ -104,21 +344,33 @@ func (a *aggregator) GetTimeSeriesMetrics(ctx context.Context, org string, metri
 		eventType = domain.EventTypeCommit
 	}
 
-	events, err := a.storage.GetEvents(ctx, org, eventType, timeRange)
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
 	if err != nil {
 		return nil, err
 	}
 
-	// Group events by time period
-	periodCounts := make(map[time.Time]int64)
-	for _, event := range events {
-		period := truncateTime(event.Timestamp, timeRange.Granularity)
-		periodCounts[period]++
+	// Group events by time period, one chunk of the range at a time
+	periodCounts, err := aggregateEvents(ctx, a, org, eventType, timeRange,
+		func() map[time.Time]int64 { return make(map[time.Time]int64) },
+		func(counts map[time.Time]int64, event *domain.Event) {
+			if merges.excludes(event) {
+				return
+			}
+			counts[truncateTime(event.Timestamp.UTC(), timeRange.Granularity)]++
+		},
+		func(dst, src map[time.Time]int64) {
+			for period, count := range src {
+				dst[period] += count
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate all periods in the range
+	// Generate all periods in the range, in UTC like the events were grouped
 	var dataPoints []domain.TimeSeriesMetric
-	current := truncateTime(timeRange.Start, timeRange.Granularity)
+	current := truncateTime(timeRange.Start.UTC(), timeRange.Granularity)
 	for !current.After(timeRange.End) {
 		count := periodCounts[current]
 		dataPoints = append(dataPoints, domain.TimeSeriesMetric{
@@ -137,27 +389,66 @@ func (a *aggregator) GetTimeSeriesMetrics(ctx context.Context, org string, metri
 
 // GetMemberRanking retrieves member rankings
 func (a *aggregator) GetMemberRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.MemberRanking, error) {
-	return a.storage.GetMemberRanking(ctx, org, rankingType, timeRange, limit)
+	return cached(ctx, a, org, cacheKey("member-ranking", timeRange, org, rankingType, limit), func() ([]*domain.MemberRanking, error) {
+		ids, err := a.loadIdentities(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		if !ids.Empty() || a.dedupMergeCommits {
+			return a.memberRankingFromMetrics(ctx, org, rankingType, timeRange, limit)
+		}
+		return a.memberRankingWithoutBots(ctx, org, rankingType, timeRange, limit)
+	})
 }
 
 // GetRepoRanking retrieves repository rankings
 func (a *aggregator) GetRepoRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.RepoRanking, error) {
-	return a.storage.GetRepoRanking(ctx, org, rankingType, timeRange, limit)
+	return cached(ctx, a, org, cacheKey("repo-ranking", timeRange, org, rankingType, limit), func() ([]*domain.RepoRanking, error) {
+		if a.dedupMergeCommits {
+			return a.repoRankingFromMetrics(ctx, org, rankingType, timeRange, limit)
+		}
+		return a.storage.GetRepoRanking(ctx, org, rankingType, timeRange, limit)
+	})
 }
 
 // GetOrgTimeSeries retrieves time series data for an organization
 func (a *aggregator) GetOrgTimeSeries(ctx context.Context, org string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
-	return a.storage.GetOrgTimeSeries(ctx, org, timeRange)
+	return cached(ctx, a, org, cacheKey("org-timeseries", timeRange, org), func() (*domain.DetailedTimeSeriesData, error) {
+		data, err := a.storage.GetOrgTimeSeries(ctx, org, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupTimeSeries(ctx, org, data, timeRange, func(*domain.MergeCommit) bool { return true }); err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
 }
 
 // GetRepoTimeSeries retrieves time series data for a repository
 func (a *aggregator) GetRepoTimeSeries(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
-	return a.storage.GetRepoTimeSeries(ctx, org, repo, timeRange)
+	return cached(ctx, a, org, cacheKey("repo-timeseries", timeRange, org, repo), func() (*domain.DetailedTimeSeriesData, error) {
+		data, err := a.storage.GetRepoTimeSeries(ctx, org, repo, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupTimeSeries(ctx, org, data, timeRange, func(c *domain.MergeCommit) bool { return c.Repo == repo }); err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
 }
 
-// GetMemberTimeSeries retrieves time series data for a member
+// GetMemberTimeSeries retrieves time series data for a member, including the activity of the
+// member's aliases
 func (a *aggregator) GetMemberTimeSeries(ctx context.Context, org, member string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
-	return a.storage.GetMemberTimeSeries(ctx, org, member, timeRange)
+	return cached(ctx, a, org, cacheKey("member-timeseries", timeRange, org, member), func() (*domain.DetailedTimeSeriesData, error) {
+		_, identities, err := a.memberIdentities(ctx, org, member)
+		if err != nil {
+			return nil, err
+		}
+		return a.sumMemberTimeSeries(ctx, org, identities, timeRange)
+	})
 }
 
 // truncateTime truncates a time to the start of the period based on granularity
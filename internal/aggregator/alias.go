@@ -0,0 +1,155 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// loadIdentities loads the identities of an owner's member aliases
+func (a *aggregator) loadIdentities(ctx context.Context, org string) (*domain.Identities, error) {
+	aliases, err := a.storage.ListMemberAliases(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewIdentities(org, aliases), nil
+}
+
+// mergeIdentities sums the metrics of aliases into their canonical member, keeping the list
+// ordered by member
+func mergeIdentities(ids *domain.Identities, metrics []*domain.MemberMetrics) []*domain.MemberMetrics {
+	if ids.Empty() {
+		return metrics
+	}
+	byMember := make(map[string]*domain.MemberMetrics, len(metrics))
+	merged := make([]*domain.MemberMetrics, 0, len(metrics))
+	for _, metric := range metrics {
+		member := ids.Resolve(metric.Member)
+		dst, ok := byMember[member]
+		if !ok {
+			dst = &domain.MemberMetrics{Member: member, TimeRange: metric.TimeRange}
+			byMember[member] = dst
+			merged = append(merged, dst)
+		}
+		dst.Commits += metric.Commits
+		dst.PRs += metric.PRs
+		dst.Additions += metric.Additions
+		dst.Deletions += metric.Deletions
+		dst.Deploys += metric.Deploys
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Member < merged[j].Member })
+	return merged
+}
+
+// memberIdentities resolves member, which may be an alias, to its canonical member and the
+// names its activity is recorded under
+func (a *aggregator) memberIdentities(ctx context.Context, org, member string) (string, []string, error) {
+	ids, err := a.loadIdentities(ctx, org)
+	if err != nil {
+		return "", nil, err
+	}
+	member = ids.Resolve(member)
+	return member, ids.Identity(member).Names(), nil
+}
+
+// memberRankingFromMetrics ranks the members of an owner by their metrics, with their
+// aliases' activity merged in and PR merge commits left out. Storage ranks by the names
+// events are recorded under and counts every commit, so it is used only without either.
+func (a *aggregator) memberRankingFromMetrics(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.MemberRanking, error) {
+	metrics, err := a.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := make([]*domain.MemberRanking, 0, len(metrics))
+	for _, m := range metrics {
+		rankings = append(rankings, &domain.MemberRanking{
+			Member:    m.Member,
+			Value:     memberRankingValue(m, rankingType),
+			Commits:   m.Commits,
+			PRs:       m.PRs,
+			Additions: m.Additions,
+			Deletions: m.Deletions,
+			Deploys:   m.Deploys,
+		})
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].Value > rankings[j].Value
+	})
+	if limit <= 0 {
+		// Storage's default ranking length
+		limit = 10
+	}
+	if len(rankings) > limit {
+		rankings = rankings[:limit]
+	}
+	for i, r := range rankings {
+		r.Rank = i + 1
+	}
+	return rankings, nil
+}
+
+// memberRankingValue returns the value a member is ranked by
+func memberRankingValue(m *domain.MemberMetrics, rankingType domain.RankingType) int64 {
+	switch rankingType {
+	case domain.RankingTypePRs:
+		return m.PRs
+	case domain.RankingTypeCodeChanges:
+		return m.Additions + m.Deletions
+	case domain.RankingTypeDeploys:
+		return m.Deploys
+	default:
+		return m.Commits
+	}
+}
+
+// sumMemberTimeSeries sums the time series of several names events are recorded under,
+// leaving out their PR merge commits
+func (a *aggregator) sumMemberTimeSeries(ctx context.Context, org string, members []string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	if len(members) == 1 {
+		data, err := a.storage.GetMemberTimeSeries(ctx, org, members[0], timeRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.dedupTimeSeries(ctx, org, data, timeRange, func(c *domain.MergeCommit) bool { return c.Member == members[0] }); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	result := &domain.DetailedTimeSeriesData{Granularity: timeRange.Granularity}
+	index := make(map[int64]int)
+	for _, member := range members {
+		data, err := a.storage.GetMemberTimeSeries(ctx, org, member, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range data.DataPoints {
+			i, ok := index[p.Timestamp.Unix()]
+			if !ok {
+				i = len(result.DataPoints)
+				index[p.Timestamp.Unix()] = i
+				result.DataPoints = append(result.DataPoints, domain.DetailedTimeSeriesMetric{Timestamp: p.Timestamp})
+			}
+			dst := &result.DataPoints[i]
+			dst.Commits += p.Commits
+			dst.PRs += p.PRs
+			dst.Additions += p.Additions
+			dst.Deletions += p.Deletions
+			dst.Deploys += p.Deploys
+		}
+	}
+	sort.Slice(result.DataPoints, func(i, j int) bool {
+		return result.DataPoints[i].Timestamp.Before(result.DataPoints[j].Timestamp)
+	})
+	names := make(map[string]bool, len(members))
+	for _, member := range members {
+		names[member] = true
+	}
+	if err := a.dedupTimeSeries(ctx, org, result, timeRange, func(c *domain.MergeCommit) bool { return names[c.Member] }); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package aggregator
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// WithBotExclusion removes bot accounts from member lists, member rankings and the
+// per-member commit classification. Logins ending in "[bot]" (GitHub Apps such as
+// dependabot[bot]) are bots, as are logins matching any of patterns (path.Match globs, case
+// insensitive, e.g. "*-bot" or "renovate"). Organization, repository and team totals still
+// include the bots' activity.
+func WithBotExclusion(patterns ...string) Option {
+	return func(a *aggregator) {
+		a.excludeBots = true
+		for _, p := range patterns {
+			if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+				a.botPatterns = append(a.botPatterns, p)
+			}
+		}
+	}
+}
+
+// isBot reports whether bot exclusion is enabled and login is a bot account
+func (a *aggregator) isBot(login string) bool {
+	if !a.excludeBots {
+		return false
+	}
+	login = strings.ToLower(login)
+	if strings.HasSuffix(login, "[bot]") {
+		return true
+	}
+	for _, p := range a.botPatterns {
+		if ok, _ := path.Match(p, login); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutBots drops the items whose member is a bot, keeping the order
+func withoutBots[T any](a *aggregator, items []T, member func(T) string) []T {
+	if !a.excludeBots {
+		return items
+	}
+	kept := items[:0:0]
+	for _, item := range items {
+		if !a.isBot(member(item)) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// memberRankingWithoutBots fetches a member ranking from storage, asking for more entries
+// until limit of them are not bots or the ranking runs out, and ranks what is left
+func (a *aggregator) memberRankingWithoutBots(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.MemberRanking, error) {
+	if !a.excludeBots {
+		return a.storage.GetMemberRanking(ctx, org, rankingType, timeRange, limit)
+	}
+	if limit <= 0 {
+		// Storage's default ranking length
+		limit = 10
+	}
+
+	for fetch := limit * 2; ; fetch *= 2 {
+		rankings, err := a.storage.GetMemberRanking(ctx, org, rankingType, timeRange, fetch)
+		if err != nil {
+			return nil, err
+		}
+		kept := withoutBots(a, rankings, func(r *domain.MemberRanking) string { return r.Member })
+		if len(kept) >= limit || len(rankings) < fetch {
+			if len(kept) > limit {
+				kept = kept[:limit]
+			}
+			for i, r := range kept {
+				r.Rank = i + 1
+			}
+			return kept, nil
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package aggregator
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// resultCache is a size-bounded LRU cache with per-entry TTL for aggregation results
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+// cacheEntry is a single cached aggregation result
+type cacheEntry struct {
+	key       string
+	owner     string
+	watermark Watermark // the owner's watermark when the value was computed
+	expiresAt time.Time
+	value     interface{}
+}
+
+// newResultCache creates a new result cache holding at most size entries for ttl each
+func newResultCache(size int, ttl time.Duration) *resultCache {
+	return &resultCache{
+		size:    size,
+		ttl:     ttl,
+		entries: list.New(),
+		index:   make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached value for key if it has not expired and was computed
+// against the same watermark
+func (c *resultCache) get(key string, watermark Watermark) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) || !entry.watermark.Equal(watermark) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.entries.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set stores a value, evicting the least recently used entry when the cache is full
+func (c *resultCache) set(key, owner string, watermark Watermark, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.index[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.owner = owner
+		entry.watermark = watermark
+		entry.expiresAt = time.Now().Add(c.ttl)
+		entry.value = value
+		c.entries.MoveToFront(elem)
+		return
+	}
+
+	elem := c.entries.PushFront(&cacheEntry{
+		key:       key,
+		owner:     owner,
+		watermark: watermark,
+		expiresAt: time.Now().Add(c.ttl),
+		value:     value,
+	})
+	c.index[key] = elem
+
+	for c.entries.Len() > c.size {
+		c.removeElement(c.entries.Back())
+	}
+}
+
+// invalidateOwner drops every entry computed for owner (all entries if owner is empty)
+func (c *resultCache) invalidateOwner(owner string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.entries.Front(); elem != nil; {
+		next := elem.Next()
+		if owner == "" || elem.Value.(*cacheEntry).owner == owner {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+// removeElement removes an element from both the list and the index; caller must hold mu
+func (c *resultCache) removeElement(elem *list.Element) {
+	c.entries.Remove(elem)
+	delete(c.index, elem.Value.(*cacheEntry).key)
+}
+
+// cached returns the cached result for key or computes and stores it with load.
+// The owner's watermark is checked on every call so that entries are invalidated as
+// soon as events are saved, deleted or rewritten, or the metadata metrics depend on
+// changes, even by another process.
+func cached[T any](ctx context.Context, a *aggregator, owner, key string, load func() (T, error)) (T, error) {
+	if a.cache == nil {
+		return load()
+	}
+
+	watermark, err := a.GetWatermark(ctx, owner)
+	if err != nil {
+		// Fall back to an uncached query rather than failing the request
+		return load()
+	}
+
+	if value, ok := a.cache.get(key, watermark); ok {
+		return value.(T), nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	a.cache.set(key, owner, watermark, value)
+	return value, nil
+}
+
+// cacheKey builds a cache key from the method name, its arguments and the time range.
+// Range bounds are truncated to the minute so that requests relying on the default
+// "until now" range can still share entries.
+func cacheKey(method string, timeRange domain.TimeRange, args ...interface{}) string {
+	key := method
+	for _, arg := range args {
+		key += fmt.Sprintf("|%v", arg)
+	}
+	return fmt.Sprintf("%s|%d|%d|%s",
+		key,
+		timeRange.Start.Truncate(time.Minute).Unix(),
+		timeRange.End.Truncate(time.Minute).Unix(),
+		timeRange.Granularity,
+	)
+}
@@ -0,0 +1,51 @@
+package aggregator
+
+import (
+	"context"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// Compare retrieves metrics and time series for each of the given repositories and members
+// of an owner, in the order they are listed
+func (a *aggregator) Compare(ctx context.Context, org string, repos, members []string, timeRange domain.TimeRange) (*domain.Comparison, error) {
+	comparison := &domain.Comparison{
+		Repos:     make([]*domain.RepoComparison, 0, len(repos)),
+		Members:   make([]*domain.MemberComparison, 0, len(members)),
+		TimeRange: timeRange,
+	}
+
+	for _, repo := range repos {
+		metrics, err := a.AggregateRepoMetrics(ctx, org, repo, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		series, err := a.GetRepoTimeSeries(ctx, org, repo, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Repos = append(comparison.Repos, &domain.RepoComparison{
+			Repo:       repo,
+			Metrics:    metrics,
+			TimeSeries: series,
+		})
+	}
+
+	for _, member := range members {
+		metrics, err := a.AggregateMemberMetrics(ctx, org, member, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		series, err := a.GetMemberTimeSeries(ctx, org, member, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Members = append(comparison.Members, &domain.MemberComparison{
+			Member:     member,
+			Metrics:    metrics,
+			TimeSeries: series,
+		})
+	}
+
+	return comparison, nil
+}
@@ -0,0 +1,178 @@
+package aggregator
+
+import (
+	"context"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// WithComponents splits the metrics of the repositories of components by the path prefixes
+// each component owns (see GetComponentMetrics)
+func WithComponents(components []domain.Component) Option {
+	return func(a *aggregator) {
+		a.components = components
+	}
+}
+
+// componentTally is the activity of a component while it's being aggregated
+type componentTally struct {
+	metrics domain.ComponentMetrics
+	members map[string]bool
+}
+
+// componentPartial is the activity of the components of a repository in a chunk of time
+type componentPartial struct {
+	byName       map[string]*componentTally
+	unattributed int64
+	shas         map[string][]string // components whose files each commit changed
+}
+
+func newComponentPartial() *componentPartial {
+	return &componentPartial{
+		byName: make(map[string]*componentTally),
+		shas:   make(map[string][]string),
+	}
+}
+
+// tally returns the tally of a component, adding it if needed
+func (p *componentPartial) tally(name string) *componentTally {
+	t, ok := p.byName[name]
+	if !ok {
+		t = &componentTally{metrics: domain.ComponentMetrics{Component: name}, members: make(map[string]bool)}
+		p.byName[name] = t
+	}
+	return t
+}
+
+// GetComponentMetrics splits the activity of a repository by its configured components.
+// A commit counts once for each component whose files it changed, and a merged pull request
+// for each component its merge commit changed. Changes to files under no component's paths
+// go to domain.OtherComponent.
+func (a *aggregator) GetComponentMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.RepoComponentMetrics, error) {
+	return cached(ctx, a, org, cacheKey("components", timeRange, org, repo), func() (*domain.RepoComponentMetrics, error) {
+		return a.buildComponentMetrics(ctx, org, repo, timeRange)
+	})
+}
+
+func (a *aggregator) buildComponentMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.RepoComponentMetrics, error) {
+	components := domain.RepoComponents(a.components, org, repo)
+	ids, err := a.loadIdentities(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := aggregateEvents(ctx, a, org, domain.EventTypeCommit, timeRange,
+		newComponentPartial,
+		func(p *componentPartial, event *domain.Event) {
+			if event.Repo != repo {
+				return
+			}
+			kind, _ := event.Data["merge_kind"].(string)
+			files := domain.CommitFiles(event.Data)
+			if len(files) == 0 {
+				if !merges.excludes(event) {
+					p.unattributed++
+				}
+				return
+			}
+
+			touched := make(map[string]bool)
+			var names []string
+			for _, f := range files {
+				name := domain.ComponentOf(components, f.Path)
+				if !touched[name] {
+					touched[name] = true
+					names = append(names, name)
+				}
+				// Merge commits repeat the line changes of the merged branch
+				if kind == domain.MergeKindMerge && merges.excludes(event) {
+					continue
+				}
+				t := p.tally(name)
+				t.metrics.Additions += int64(f.Additions)
+				t.metrics.Deletions += int64(f.Deletions)
+				t.metrics.FilesChanged++
+			}
+			if sha, _ := event.Data["sha"].(string); sha != "" {
+				p.shas[sha] = names
+			}
+			if merges.excludes(event) {
+				return
+			}
+			member := ids.Resolve(event.Member)
+			for _, name := range names {
+				t := p.tally(name)
+				t.metrics.Commits++
+				if !a.isBot(member) {
+					t.members[member] = true
+				}
+			}
+		},
+		func(dst, src *componentPartial) {
+			for name, partial := range src.byName {
+				t := dst.tally(name)
+				t.metrics.Commits += partial.metrics.Commits
+				t.metrics.Additions += partial.metrics.Additions
+				t.metrics.Deletions += partial.metrics.Deletions
+				t.metrics.FilesChanged += partial.metrics.FilesChanged
+				for member := range partial.members {
+					t.members[member] = true
+				}
+			}
+			dst.unattributed += src.unattributed
+			for sha, names := range src.shas {
+				dst.shas[sha] = names
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := aggregateEvents(ctx, a, org, domain.EventTypePullRequest, timeRange,
+		func() map[string]int64 { return make(map[string]int64) },
+		func(prs map[string]int64, event *domain.Event) {
+			if event.Repo != repo {
+				return
+			}
+			sha, _ := event.Data["merge_commit_sha"].(string)
+			for _, name := range commits.shas[sha] {
+				prs[name]++
+			}
+		},
+		func(dst, src map[string]int64) {
+			for name, count := range src {
+				dst[name] += count
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.RepoComponentMetrics{
+		Owner:               org,
+		Repo:                repo,
+		Components:          make([]*domain.ComponentMetrics, 0, len(components)+1),
+		UnattributedCommits: commits.unattributed,
+		TimeRange:           timeRange,
+	}
+	metrics := func(name string, paths []string) *domain.ComponentMetrics {
+		m := commits.tally(name).metrics
+		m.Paths = paths
+		m.PRs = prs[name]
+		m.Contributors = int64(len(commits.tally(name).members))
+		return &m
+	}
+	for _, c := range components {
+		result.Components = append(result.Components, metrics(c.Name, c.Paths))
+	}
+	if _, ok := commits.byName[domain.OtherComponent]; ok || prs[domain.OtherComponent] > 0 {
+		result.Components = append(result.Components, metrics(domain.OtherComponent, nil))
+	}
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package aggregator
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// conventionalCommitPattern matches "type(scope)!: subject" headers
+var conventionalCommitPattern = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?!?:\s*\S`)
+
+// commitCategoryAliases maps the header types seen in the wild to their canonical category
+var commitCategoryAliases = map[string]domain.CommitCategory{
+	"feat":     domain.CommitCategoryFeat,
+	"feature":  domain.CommitCategoryFeat,
+	"fix":      domain.CommitCategoryFix,
+	"bugfix":   domain.CommitCategoryFix,
+	"hotfix":   domain.CommitCategoryFix,
+	"chore":    domain.CommitCategoryChore,
+	"docs":     domain.CommitCategoryDocs,
+	"doc":      domain.CommitCategoryDocs,
+	"refactor": domain.CommitCategoryRefactor,
+	"test":     domain.CommitCategoryTest,
+	"tests":    domain.CommitCategoryTest,
+	"perf":     domain.CommitCategoryPerf,
+	"ci":       domain.CommitCategoryCI,
+	"build":    domain.CommitCategoryBuild,
+	"style":    domain.CommitCategoryStyle,
+	"revert":   domain.CommitCategoryRevert,
+}
+
+// ClassifyCommitMessage returns the conventional-commit category of a commit message,
+// based on its first line. Messages that don't follow the convention are "other".
+func ClassifyCommitMessage(message string) domain.CommitCategory {
+	header := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	if strings.HasPrefix(header, "Revert \"") {
+		return domain.CommitCategoryRevert
+	}
+
+	match := conventionalCommitPattern.FindStringSubmatch(header)
+	if match == nil {
+		return domain.CommitCategoryOther
+	}
+	if category, ok := commitCategoryAliases[strings.ToLower(match[1])]; ok {
+		return category
+	}
+	return domain.CommitCategoryOther
+}
+
+// GetRepoCommitClassification retrieves conventional-commit counts per repository
+func (a *aggregator) GetRepoCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.CommitClassification, error) {
+	return cached(ctx, a, org, cacheKey("repo-commit-types", timeRange, org), func() ([]*domain.CommitClassification, error) {
+		return a.classifyCommits(ctx, org, timeRange, func(e *domain.Event) string { return e.Repo })
+	})
+}
+
+// GetMemberCommitClassification retrieves conventional-commit counts per member, counting the
+// commits of aliases for their canonical member
+func (a *aggregator) GetMemberCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.CommitClassification, error) {
+	return cached(ctx, a, org, cacheKey("member-commit-types", timeRange, org), func() ([]*domain.CommitClassification, error) {
+		ids, err := a.loadIdentities(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		classifications, err := a.classifyCommits(ctx, org, timeRange, func(e *domain.Event) string { return ids.Resolve(e.Member) })
+		if err != nil {
+			return nil, err
+		}
+		return withoutBots(a, classifications, func(c *domain.CommitClassification) string { return c.Name }), nil
+	})
+}
+
+// classifyCommits groups commit events with groupKey and counts their categories
+func (a *aggregator) classifyCommits(ctx context.Context, org string, timeRange domain.TimeRange, groupKey func(*domain.Event) string) ([]*domain.CommitClassification, error) {
+	newClassification := func(name string) *domain.CommitClassification {
+		return &domain.CommitClassification{
+			Name:      name,
+			Counts:    make(map[domain.CommitCategory]int64),
+			TimeRange: timeRange,
+		}
+	}
+
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	byName, err := aggregateEvents(ctx, a, org, domain.EventTypeCommit, timeRange,
+		func() map[string]*domain.CommitClassification { return make(map[string]*domain.CommitClassification) },
+		func(byName map[string]*domain.CommitClassification, event *domain.Event) {
+			if merges.excludes(event) {
+				return
+			}
+			name := groupKey(event)
+			c, ok := byName[name]
+			if !ok {
+				c = newClassification(name)
+				byName[name] = c
+			}
+
+			message, _ := event.Data["message"].(string)
+			c.Counts[ClassifyCommitMessage(message)]++
+			c.Total++
+		},
+		func(dst, src map[string]*domain.CommitClassification) {
+			for name, partial := range src {
+				c, ok := dst[name]
+				if !ok {
+					c = newClassification(name)
+					dst[name] = c
+				}
+				for category, count := range partial.Counts {
+					c.Counts[category] += count
+				}
+				c.Total += partial.Total
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.CommitClassification, 0, len(byName))
+	for _, c := range byName {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
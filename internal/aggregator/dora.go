@@ -0,0 +1,130 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// doraDeploy is a deployment as seen by the DORA metrics
+type doraDeploy struct {
+	key    string // repository and environment
+	at     time.Time
+	failed bool
+	ok     bool
+}
+
+// GetDORAMetrics computes the DORA metrics of an owner, or of one repository if repo is not empty
+func (a *aggregator) GetDORAMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.DORAMetrics, error) {
+	return cached(ctx, a, org, cacheKey("dora", timeRange, org, repo), func() (*domain.DORAMetrics, error) {
+		return a.buildDORAMetrics(ctx, org, repo, timeRange)
+	})
+}
+
+func (a *aggregator) buildDORAMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.DORAMetrics, error) {
+	appendSlice := func(dst, src *[]float64) { *dst = append(*dst, *src...) }
+
+	leadTimes, err := aggregateEvents(ctx, a, org, domain.EventTypePullRequest, timeRange,
+		func() *[]float64 { return &[]float64{} },
+		func(hours *[]float64, event *domain.Event) {
+			if repo != "" && event.Repo != repo {
+				return
+			}
+			mergedAt, _ := event.Data["merged_at"].(string)
+			if mergedAt == "" {
+				return
+			}
+			merged, err := time.Parse(time.RFC3339, mergedAt)
+			if err != nil || merged.Before(event.Timestamp) {
+				return
+			}
+			*hours = append(*hours, merged.Sub(event.Timestamp).Hours())
+		},
+		appendSlice,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deploys, err := aggregateEvents(ctx, a, org, domain.EventTypeDeploy, timeRange,
+		func() *[]doraDeploy { return &[]doraDeploy{} },
+		func(deploys *[]doraDeploy, event *domain.Event) {
+			if repo != "" && event.Repo != repo {
+				return
+			}
+			status, _ := event.Data["status"].(string)
+			environment, _ := event.Data["environment"].(string)
+			*deploys = append(*deploys, doraDeploy{
+				key:    event.Repo + "\x00" + environment,
+				at:     event.Timestamp,
+				failed: domain.IsFailedDeployStatus(status),
+				ok:     status == "success",
+			})
+		},
+		func(dst, src *[]doraDeploy) { *dst = append(*dst, *src...) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.DORAMetrics{
+		Owner:     org,
+		Repo:      repo,
+		MergedPRs: int64(len(*leadTimes)),
+		TimeRange: timeRange,
+	}
+	result.LeadTimeHours = median(*leadTimes)
+
+	// Deployments are restored by the next successful deployment of the same repository and environment
+	sort.SliceStable(*deploys, func(i, j int) bool { return (*deploys)[i].at.Before((*deploys)[j].at) })
+	failedSince := make(map[string]time.Time)
+	var successful int64
+	var restoreHours []float64
+	for _, d := range *deploys {
+		result.Deployments++
+		switch {
+		case d.failed:
+			result.FailedDeployments++
+			if _, ok := failedSince[d.key]; !ok {
+				failedSince[d.key] = d.at
+			}
+		case d.ok:
+			successful++
+			if since, ok := failedSince[d.key]; ok {
+				restoreHours = append(restoreHours, d.at.Sub(since).Hours())
+				delete(failedSince, d.key)
+			}
+		}
+	}
+	result.Restores = int64(len(restoreHours))
+	result.TimeToRestoreHours = median(restoreHours)
+
+	if days := timeRange.End.Sub(timeRange.Start).Hours() / 24; days > 0 {
+		result.DeploymentFrequency = float64(successful) / days
+	}
+	if result.Deployments > 0 {
+		result.ChangeFailureRate = float64(result.FailedDeployments) / float64(result.Deployments)
+	}
+
+	result.DeploymentLevel = domain.ClassifyDeploymentFrequency(result.DeploymentFrequency, successful)
+	result.LeadTimeLevel = domain.ClassifyLeadTime(result.LeadTimeHours, result.MergedPRs)
+	result.ChangeFailureLevel = domain.ClassifyChangeFailureRate(result.ChangeFailureRate, result.Deployments)
+	result.TimeToRestoreLevel = domain.ClassifyTimeToRestore(result.TimeToRestoreHours, result.Restores)
+	return result, nil
+}
+
+// median returns the median of values, or 0 if there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -0,0 +1,115 @@
+package aggregator
+
+import (
+	"context"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// heatmapWeekdays are the heatmap rows, Monday first
+var heatmapWeekdays = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// heatmapCounts is a weekday × hour count matrix indexed like domain.Heatmap.Values
+type heatmapCounts [7][24]int64
+
+// GetHeatmap counts the owner's events per weekday and hour of day, optionally limited
+// to a repository or member. A member's events include those of its aliases.
+func (a *aggregator) GetHeatmap(ctx context.Context, org string, query domain.HeatmapQuery, timeRange domain.TimeRange) (*domain.Heatmap, error) {
+	if query.Location == nil {
+		query.Location = time.UTC
+	}
+	if query.MetricType == "" {
+		query.MetricType = domain.MetricTypeCommit
+	}
+
+	key := cacheKey("heatmap", timeRange, org, query.Repo, query.Member, query.MetricType, query.Location.String())
+	return cached(ctx, a, org, key, func() (*domain.Heatmap, error) {
+		return a.buildHeatmap(ctx, org, query, timeRange)
+	})
+}
+
+// buildHeatmap computes a heatmap from raw events
+func (a *aggregator) buildHeatmap(ctx context.Context, org string, query domain.HeatmapQuery, timeRange domain.TimeRange) (*domain.Heatmap, error) {
+	var eventType domain.EventType
+	switch query.MetricType {
+	case domain.MetricTypePullRequest:
+		eventType = domain.EventTypePullRequest
+	case domain.MetricTypeDeploy:
+		eventType = domain.EventTypeDeploy
+	default:
+		eventType = domain.EventTypeCommit
+	}
+
+	var identities map[string]bool
+	if query.Member != "" {
+		_, names, err := a.memberIdentities(ctx, org, query.Member)
+		if err != nil {
+			return nil, err
+		}
+		identities = make(map[string]bool, len(names))
+		for _, name := range names {
+			identities[name] = true
+		}
+	}
+
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := aggregateEvents(ctx, a, org, eventType, timeRange,
+		func() *heatmapCounts { return &heatmapCounts{} },
+		func(counts *heatmapCounts, event *domain.Event) {
+			if query.Repo != "" && event.Repo != query.Repo {
+				return
+			}
+			if identities != nil && !identities[event.Member] {
+				return
+			}
+			if merges.excludes(event) {
+				return
+			}
+			t := event.Timestamp.In(query.Location)
+			// Monday is row 0, Sunday row 6
+			counts[(int(t.Weekday())+6)%7][t.Hour()]++
+		},
+		func(dst, src *heatmapCounts) {
+			for d := range src {
+				for h := range src[d] {
+					dst[d][h] += src[d][h]
+				}
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &domain.Heatmap{
+		Owner:     org,
+		Repo:      query.Repo,
+		Member:    query.Member,
+		Type:      query.MetricType,
+		TimeZone:  query.Location.String(),
+		Weekdays:  make([]string, len(heatmapWeekdays)),
+		Hours:     make([]int, 24),
+		Values:    make([][]int64, len(heatmapWeekdays)),
+		TimeRange: timeRange,
+	}
+	for h := range heatmap.Hours {
+		heatmap.Hours[h] = h
+	}
+	for d, weekday := range heatmapWeekdays {
+		heatmap.Weekdays[d] = weekday.String()
+		heatmap.Values[d] = counts[d][:]
+		for _, count := range counts[d] {
+			heatmap.Total += count
+			if count > heatmap.Max {
+				heatmap.Max = count
+			}
+		}
+	}
+	return heatmap, nil
+}
@@ -0,0 +1,194 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// mergeAdjustment is the amount to subtract from commit-based metrics when deduplicating merges
+type mergeAdjustment struct {
+	commits   int64
+	additions int64
+	deletions int64
+}
+
+// mergeCommits are the PR merge commits of an owner in a time range
+type mergeCommits struct {
+	commits []*domain.MergeCommit
+	shas    map[string]bool
+}
+
+// loadMergeCommits loads the owner's PR merge commits, or returns nil when dedup is disabled
+func (a *aggregator) loadMergeCommits(ctx context.Context, org string, timeRange domain.TimeRange) (*mergeCommits, error) {
+	if !a.dedupMergeCommits {
+		return nil, nil
+	}
+	commits, err := a.storage.GetMergeCommits(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	m := &mergeCommits{commits: commits, shas: make(map[string]bool, len(commits))}
+	for _, c := range commits {
+		m.shas[c.SHA] = true
+	}
+	return m, nil
+}
+
+// excludes reports whether a commit event is a PR merge commit left out of commit counts; it
+// is false for every event when m is nil
+func (m *mergeCommits) excludes(event *domain.Event) bool {
+	if m == nil || event.Type != domain.EventTypeCommit {
+		return false
+	}
+	sha, _ := event.Data["sha"].(string)
+	return m.shas[sha]
+}
+
+// adjustment sums the merge commits accepted by match.
+// Squash commits are the only record of a squashed PR's code, so only their commit count is
+// removed; merge commits also duplicate the line changes of the merged branch.
+func (m *mergeCommits) adjustment(match func(*domain.MergeCommit) bool) mergeAdjustment {
+	var adj mergeAdjustment
+	for _, c := range m.commits {
+		if !match(c) {
+			continue
+		}
+		adj.commits++
+		if c.Kind == domain.MergeKindMerge {
+			adj.additions += c.Additions
+			adj.deletions += c.Deletions
+		}
+	}
+	return adj
+}
+
+// dedupOrgMetrics removes PR merge commits from organization metrics
+func (a *aggregator) dedupOrgMetrics(ctx context.Context, m *domain.OrgMetrics, timeRange domain.TimeRange) error {
+	merges, err := a.loadMergeCommits(ctx, m.Org, timeRange)
+	if err != nil || merges == nil {
+		return err
+	}
+	adj := merges.adjustment(func(*domain.MergeCommit) bool { return true })
+	m.Commits -= adj.commits
+	m.Additions -= adj.additions
+	m.Deletions -= adj.deletions
+	return nil
+}
+
+// dedupMemberMetrics removes PR merge commits from member metrics; repo limits the
+// adjustment to a single repository when non-empty
+func (a *aggregator) dedupMemberMetrics(ctx context.Context, org, repo string, metrics []*domain.MemberMetrics, timeRange domain.TimeRange) error {
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
+	if err != nil || merges == nil {
+		return err
+	}
+	for _, m := range metrics {
+		member := m.Member
+		adj := merges.adjustment(func(c *domain.MergeCommit) bool {
+			return c.Member == member && (repo == "" || c.Repo == repo)
+		})
+		m.Commits -= adj.commits
+		m.Additions -= adj.additions
+		m.Deletions -= adj.deletions
+	}
+	return nil
+}
+
+// dedupRepoMetrics removes PR merge commits from repository metrics; member limits the
+// adjustment to a single member when non-empty
+func (a *aggregator) dedupRepoMetrics(ctx context.Context, org, member string, metrics []*domain.RepoMetrics, timeRange domain.TimeRange) error {
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
+	if err != nil || merges == nil {
+		return err
+	}
+	for _, m := range metrics {
+		repo := m.Repo
+		adj := merges.adjustment(func(c *domain.MergeCommit) bool {
+			return c.Repo == repo && (member == "" || c.Member == member)
+		})
+		m.Commits -= adj.commits
+		m.Additions -= adj.additions
+		m.Deletions -= adj.deletions
+	}
+	return nil
+}
+
+// dedupTimeSeries removes the PR merge commits accepted by match from the periods of a
+// detailed time series, which storage groups by the UTC date of the events
+func (a *aggregator) dedupTimeSeries(ctx context.Context, org string, data *domain.DetailedTimeSeriesData, timeRange domain.TimeRange, match func(*domain.MergeCommit) bool) error {
+	merges, err := a.loadMergeCommits(ctx, org, timeRange)
+	if err != nil || merges == nil {
+		return err
+	}
+	index := make(map[int64]int, len(data.DataPoints))
+	for i, p := range data.DataPoints {
+		index[p.Timestamp.Unix()] = i
+	}
+	for _, c := range merges.commits {
+		if !match(c) {
+			continue
+		}
+		i, ok := index[truncateTime(c.Timestamp.UTC(), data.Granularity).Unix()]
+		if !ok {
+			continue
+		}
+		p := &data.DataPoints[i]
+		p.Commits--
+		if c.Kind == domain.MergeKindMerge {
+			p.Additions -= c.Additions
+			p.Deletions -= c.Deletions
+		}
+	}
+	return nil
+}
+
+// repoRankingFromMetrics ranks the repositories of an owner by their metrics with PR merge
+// commits left out, which storage's ranking counts
+func (a *aggregator) repoRankingFromMetrics(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.RepoRanking, error) {
+	metrics, err := a.GetReposMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := make([]*domain.RepoRanking, 0, len(metrics))
+	for _, m := range metrics {
+		rankings = append(rankings, &domain.RepoRanking{
+			Repo:    m.Repo,
+			Value:   repoRankingValue(m, rankingType),
+			Commits: m.Commits,
+			PRs:     m.PRs,
+			Deploys: m.Deploys,
+		})
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].Value > rankings[j].Value
+	})
+	if limit <= 0 {
+		// Storage's default ranking length
+		limit = 10
+	}
+	if len(rankings) > limit {
+		rankings = rankings[:limit]
+	}
+	for i, r := range rankings {
+		r.Rank = i + 1
+	}
+	return rankings, nil
+}
+
+// repoRankingValue returns the value a repository is ranked by
+func repoRankingValue(m *domain.RepoMetrics, rankingType domain.RankingType) int64 {
+	switch rankingType {
+	case domain.RankingTypePRs:
+		return m.PRs
+	case domain.RankingTypeCodeChanges:
+		return m.Additions + m.Deletions
+	case domain.RankingTypeDeploys:
+		return m.Deploys
+	default:
+		return m.Commits
+	}
+}
@@ -0,0 +1,281 @@
+package aggregator
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+const (
+	// pseudonymPrefix starts every pseudonym, e.g. "member-3f9a0c21d4"
+	pseudonymPrefix = "member-"
+	// pseudonymHexLen is the number of hex digits of the keyed hash kept in a pseudonym
+	pseudonymHexLen = 10
+	// unknownMember is queried in place of a name that isn't the pseudonym of any member, so
+	// that nothing matches and real usernames can't be probed
+	unknownMember = ":unknown:"
+)
+
+// Pseudonyms maps member usernames to stable pseudonyms: "member-" followed by the start of
+// the HMAC-SHA256, keyed with a secret, of the owner and the username. The same member of an
+// owner always gets the same pseudonym, while nobody without the secret can tell who it is.
+// A nil *Pseudonyms leaves usernames as they are.
+type Pseudonyms struct {
+	key []byte
+}
+
+// NewPseudonyms returns the pseudonyms keyed with secret
+func NewPseudonyms(secret string) *Pseudonyms {
+	return &Pseudonyms{key: []byte(secret)}
+}
+
+// Name returns the pseudonym of an owner's member, or member itself if p is nil. GitHub
+// logins are case insensitive, and so are pseudonyms.
+func (p *Pseudonyms) Name(owner, member string) string {
+	if p == nil || member == "" {
+		return member
+	}
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(strings.ToLower(owner) + "/" + strings.ToLower(member)))
+	return pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:pseudonymHexLen]
+}
+
+// EventID returns a stable identifier of an owner's event in place of its ID, which holds the
+// commit SHA or pull request number the event, and so its author, could be looked up by
+func (p *Pseudonyms) EventID(owner, id string) string {
+	if p == nil {
+		return id
+	}
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte("event:" + strings.ToLower(owner) + "/" + id))
+	return "event-" + hex.EncodeToString(mac.Sum(nil))[:2*pseudonymHexLen]
+}
+
+// IsPseudonym reports whether name has the form of a pseudonym
+func IsPseudonym(name string) bool {
+	hash, ok := strings.CutPrefix(name, pseudonymPrefix)
+	if !ok || len(hash) != pseudonymHexLen {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// WithPseudonyms replaces member usernames with their pseudonyms in every result, and takes
+// pseudonyms instead of usernames wherever a member is given. A member that isn't given as
+// the pseudonym of a known member has no activity, so results can't be traced back to real
+// usernames by trying them.
+func WithPseudonyms(p *Pseudonyms) Option {
+	return func(a *aggregator) {
+		a.pseudonyms = p
+	}
+}
+
+// pseudonymizedAggregator applies WithPseudonyms to the results of an aggregator. Results
+// are copied before members are renamed, as the aggregator may keep them in its cache.
+type pseudonymizedAggregator struct {
+	Aggregator
+	aggregator *aggregator
+
+	mu       sync.Mutex
+	resolved map[string]string // real member by owner and pseudonym, see resolve
+}
+
+func newPseudonymizedAggregator(a *aggregator) Aggregator {
+	return &pseudonymizedAggregator{
+		Aggregator: a,
+		aggregator: a,
+		resolved:   make(map[string]string),
+	}
+}
+
+// name returns the pseudonym of an owner's member
+func (a *pseudonymizedAggregator) name(org, member string) string {
+	return a.aggregator.pseudonyms.Name(org, member)
+}
+
+// resolve returns the member whose pseudonym is name, looking among the owner's collected
+// members and the members active in timeRange, or unknownMember if there is none
+func (a *pseudonymizedAggregator) resolve(ctx context.Context, org, name string, timeRange domain.TimeRange) (string, error) {
+	if !IsPseudonym(name) {
+		return unknownMember, nil
+	}
+	name = strings.ToLower(name)
+	key := strings.ToLower(org) + "/" + name
+	a.mu.Lock()
+	member, ok := a.resolved[key]
+	a.mu.Unlock()
+	if ok {
+		return member, nil
+	}
+
+	members, err := a.aggregator.storage.GetMembers(ctx, org)
+	if err != nil {
+		return "", err
+	}
+	candidates := make([]string, 0, len(members))
+	for _, m := range members {
+		candidates = append(candidates, m.Username)
+	}
+	active, err := a.Aggregator.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range active {
+		candidates = append(candidates, m.Member)
+	}
+
+	for _, candidate := range candidates {
+		if a.name(org, candidate) == name {
+			a.mu.Lock()
+			a.resolved[key] = candidate
+			a.mu.Unlock()
+			return candidate, nil
+		}
+	}
+	return unknownMember, nil
+}
+
+// members returns copies of metrics with pseudonymized members, ordered by pseudonym so that
+// the order doesn't reveal the usernames
+func (a *pseudonymizedAggregator) members(org string, metrics []*domain.MemberMetrics) []*domain.MemberMetrics {
+	renamed := make([]*domain.MemberMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		renamed = append(renamed, a.member(m, a.name(org, m.Member)))
+	}
+	sort.SliceStable(renamed, func(i, j int) bool { return renamed[i].Member < renamed[j].Member })
+	return renamed
+}
+
+// member returns a copy of m named name
+func (a *pseudonymizedAggregator) member(m *domain.MemberMetrics, name string) *domain.MemberMetrics {
+	if m == nil {
+		return nil
+	}
+	renamed := *m
+	renamed.Member = name
+	return &renamed
+}
+
+func (a *pseudonymizedAggregator) AggregateMemberMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) (*domain.MemberMetrics, error) {
+	real, err := a.resolve(ctx, org, member, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := a.Aggregator.AggregateMemberMetrics(ctx, org, real, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return a.member(metrics, member), nil
+}
+
+func (a *pseudonymizedAggregator) GetMembersMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
+	metrics, err := a.Aggregator.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return a.members(org, metrics), nil
+}
+
+func (a *pseudonymizedAggregator) GetRepoMembersMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
+	metrics, err := a.Aggregator.GetRepoMembersMetrics(ctx, org, repo, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return a.members(org, metrics), nil
+}
+
+func (a *pseudonymizedAggregator) GetMemberReposMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error) {
+	real, err := a.resolve(ctx, org, member, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return a.Aggregator.GetMemberReposMetrics(ctx, org, real, timeRange)
+}
+
+func (a *pseudonymizedAggregator) GetHeatmap(ctx context.Context, org string, query domain.HeatmapQuery, timeRange domain.TimeRange) (*domain.Heatmap, error) {
+	name := query.Member
+	if name != "" {
+		real, err := a.resolve(ctx, org, name, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		query.Member = real
+	}
+	heatmap, err := a.Aggregator.GetHeatmap(ctx, org, query, timeRange)
+	if err != nil || heatmap == nil {
+		return heatmap, err
+	}
+	renamed := *heatmap
+	renamed.Member = name
+	return &renamed, nil
+}
+
+func (a *pseudonymizedAggregator) GetMemberRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.MemberRanking, error) {
+	rankings, err := a.Aggregator.GetMemberRanking(ctx, org, rankingType, timeRange, limit)
+	if err != nil {
+		return nil, err
+	}
+	renamed := make([]*domain.MemberRanking, 0, len(rankings))
+	for _, r := range rankings {
+		entry := *r
+		entry.Member = a.name(org, r.Member)
+		renamed = append(renamed, &entry)
+	}
+	return renamed, nil
+}
+
+func (a *pseudonymizedAggregator) GetMemberTimeSeries(ctx context.Context, org, member string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	real, err := a.resolve(ctx, org, member, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return a.Aggregator.GetMemberTimeSeries(ctx, org, real, timeRange)
+}
+
+func (a *pseudonymizedAggregator) Compare(ctx context.Context, org string, repos, members []string, timeRange domain.TimeRange) (*domain.Comparison, error) {
+	reals := make([]string, len(members))
+	for i, member := range members {
+		real, err := a.resolve(ctx, org, member, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		reals[i] = real
+	}
+	comparison, err := a.Aggregator.Compare(ctx, org, repos, reals, timeRange)
+	if err != nil || comparison == nil {
+		return comparison, err
+	}
+
+	// Entries follow the order of the members asked for
+	renamed := *comparison
+	renamed.Members = make([]*domain.MemberComparison, 0, len(comparison.Members))
+	for i, m := range comparison.Members {
+		entry := *m
+		entry.Member = members[i]
+		entry.Metrics = a.member(m.Metrics, entry.Member)
+		renamed.Members = append(renamed.Members, &entry)
+	}
+	return &renamed, nil
+}
+
+func (a *pseudonymizedAggregator) GetMemberCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.CommitClassification, error) {
+	classifications, err := a.Aggregator.GetMemberCommitClassification(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	renamed := make([]*domain.CommitClassification, 0, len(classifications))
+	for _, c := range classifications {
+		entry := *c
+		entry.Name = a.name(org, c.Name)
+		renamed = append(renamed, &entry)
+	}
+	sort.Slice(renamed, func(i, j int) bool { return renamed[i].Name < renamed[j].Name })
+	return renamed, nil
+}
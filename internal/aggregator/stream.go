@@ -0,0 +1,73 @@
+package aggregator
+
+import (
+	"context"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// DefaultStreamChunk is the default length of the time chunks event-based aggregations are split into
+const DefaultStreamChunk = 30 * 24 * time.Hour
+
+// streamWorkers is the number of chunks aggregated concurrently
+const streamWorkers = 4
+
+// splitTimeRange splits a time range into consecutive, non-overlapping chunks of at most chunk length.
+// Storage range queries are inclusive on both ends, so each chunk ends just before the next starts.
+func splitTimeRange(timeRange domain.TimeRange, chunk time.Duration) []domain.TimeRange {
+	if chunk <= 0 || !timeRange.End.After(timeRange.Start) {
+		return []domain.TimeRange{timeRange}
+	}
+
+	var chunks []domain.TimeRange
+	for start := timeRange.Start; !start.After(timeRange.End); start = start.Add(chunk) {
+		end := start.Add(chunk - time.Nanosecond)
+		if end.After(timeRange.End) {
+			end = timeRange.End
+		}
+		chunks = append(chunks, domain.TimeRange{Start: start, End: end, Granularity: timeRange.Granularity})
+	}
+	return chunks
+}
+
+// aggregateEvents streams the owner's events of eventType chunk by chunk, folding each chunk into
+// its own partial result and merging the partials in chronological order. Memory use is bounded by
+// the size of the partial results rather than the number of events in the range.
+func aggregateEvents[P any](
+	ctx context.Context,
+	a *aggregator,
+	org string,
+	eventType domain.EventType,
+	timeRange domain.TimeRange,
+	newPartial func() P,
+	fold func(partial P, event *domain.Event),
+	merge func(dst, src P),
+) (P, error) {
+	chunks := splitTimeRange(timeRange, a.streamChunk)
+	partials := make([]P, len(chunks))
+
+	err := storage.ForEachParallel(ctx, len(chunks), streamWorkers, func(ctx context.Context, i int) error {
+		partial := newPartial()
+		err := a.storage.StreamEvents(ctx, org, eventType, chunks[i], func(event *domain.Event) error {
+			fold(partial, event)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		partials[i] = partial
+		return nil
+	})
+	if err != nil {
+		var zero P
+		return zero, err
+	}
+
+	result := newPartial()
+	for _, partial := range partials {
+		merge(result, partial)
+	}
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// GetTeamsMetrics retrieves metrics for all teams of an organization, summed over each
+// team's members. A member of several teams counts towards each of them.
+func (a *aggregator) GetTeamsMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.TeamMetrics, error) {
+	teams, err := a.storage.GetTeams(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := a.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	byMember := make(map[string]*domain.MemberMetrics, len(members))
+	for _, m := range members {
+		byMember[m.Member] = m
+	}
+
+	metrics := make([]*domain.TeamMetrics, 0, len(teams))
+	for _, team := range teams {
+		metrics = append(metrics, sumTeamMetrics(team, byMember, timeRange))
+	}
+	return metrics, nil
+}
+
+// GetTeamMetrics retrieves metrics for a single team
+func (a *aggregator) GetTeamMetrics(ctx context.Context, org, slug string, timeRange domain.TimeRange) (*domain.TeamMetrics, error) {
+	team, err := a.getTeam(ctx, org, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := a.GetMembersMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	byMember := make(map[string]*domain.MemberMetrics, len(members))
+	for _, m := range members {
+		byMember[m.Member] = m
+	}
+
+	return sumTeamMetrics(team, byMember, timeRange), nil
+}
+
+// GetTeamTimeSeries retrieves time series data for a team, summed over its members
+func (a *aggregator) GetTeamTimeSeries(ctx context.Context, org, slug string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	team, err := a.getTeam(ctx, org, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return cached(ctx, a, org, cacheKey("team-timeseries", timeRange, org, slug, team.Members), func() (*domain.DetailedTimeSeriesData, error) {
+		ids, err := a.loadIdentities(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		var identities []string
+		for _, member := range team.Members {
+			identities = append(identities, ids.Identity(member).Names()...)
+		}
+		return a.sumMemberTimeSeries(ctx, org, identities, timeRange)
+	})
+}
+
+// GetTeamRanking retrieves team rankings
+func (a *aggregator) GetTeamRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.TeamRanking, error) {
+	metrics, err := a.GetTeamsMetrics(ctx, org, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := make([]*domain.TeamRanking, 0, len(metrics))
+	for _, m := range metrics {
+		rankings = append(rankings, &domain.TeamRanking{
+			Team:      m.Team,
+			Name:      m.Name,
+			Value:     teamRankingValue(m, rankingType),
+			Commits:   m.Commits,
+			PRs:       m.PRs,
+			Additions: m.Additions,
+			Deletions: m.Deletions,
+			Deploys:   m.Deploys,
+		})
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		if rankings[i].Value != rankings[j].Value {
+			return rankings[i].Value > rankings[j].Value
+		}
+		return rankings[i].Team < rankings[j].Team
+	})
+	if limit > 0 && len(rankings) > limit {
+		rankings = rankings[:limit]
+	}
+	for i, r := range rankings {
+		r.Rank = i + 1
+	}
+	return rankings, nil
+}
+
+// getTeam retrieves a team, returning a not found error if it has not been collected
+func (a *aggregator) getTeam(ctx context.Context, org, slug string) (*domain.Team, error) {
+	team, err := a.storage.GetTeam(ctx, org, slug)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, apperrors.NewNotFoundError("team " + org + "/" + slug)
+	}
+	return team, nil
+}
+
+// sumTeamMetrics sums the metrics of a team's members
+func sumTeamMetrics(team *domain.Team, byMember map[string]*domain.MemberMetrics, timeRange domain.TimeRange) *domain.TeamMetrics {
+	metrics := &domain.TeamMetrics{
+		Team:      team.Slug,
+		Name:      team.Name,
+		Members:   len(team.Members),
+		TimeRange: timeRange,
+	}
+	for _, member := range team.Members {
+		m, ok := byMember[member]
+		if !ok {
+			continue
+		}
+		metrics.Commits += m.Commits
+		metrics.PRs += m.PRs
+		metrics.Additions += m.Additions
+		metrics.Deletions += m.Deletions
+		metrics.Deploys += m.Deploys
+	}
+	return metrics
+}
+
+// teamRankingValue returns the value a team is ranked by
+func teamRankingValue(m *domain.TeamMetrics, rankingType domain.RankingType) int64 {
+	switch rankingType {
+	case domain.RankingTypePRs:
+		return m.PRs
+	case domain.RankingTypeCodeChanges:
+		return m.Additions + m.Deletions
+	case domain.RankingTypeDeploys:
+		return m.Deploys
+	default:
+		return m.Commits
+	}
+}
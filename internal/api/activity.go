@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// GetRepoActivity classifies the owner's repositories as active, slowing, dormant or dead by
+// their events in the recent window, ending at end, against the baseline window before it.
+// recent and baseline are the window lengths in days (30 and 180 by default).
+// GET /api/v1/orgs/:org/repos/activity
+// GET /api/v1/users/:user/repos/activity
+func (h *Handler) GetRepoActivity(c *gin.Context) {
+	owner := c.Param("org")
+	if owner == "" {
+		owner = c.Param("user")
+	}
+	day := 24 * time.Hour
+	query := domain.RepoActivityQuery{
+		End:      parseTimeRange(c).End,
+		Recent:   time.Duration(parseIntQuery(c, "recent", int(domain.DefaultRecentWindow/day))) * day,
+		Baseline: time.Duration(parseIntQuery(c, "baseline", int(domain.DefaultBaselineWindow/day))) * day,
+	}
+
+	report, err := h.aggregator.GetRepoActivity(c.Request.Context(), owner, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, report)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// RequireDeploymentKey returns a middleware that rejects API keys confined to a workspace,
+// for endpoints that act on the whole deployment. Requests are let through when
+// authentication is disabled.
+func RequireDeploymentKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := APIKeyFromContext(c)
+		if ok && key.Workspace != "" {
+			respondError(c, apperrors.NewForbiddenError("workspace API keys cannot use this endpoint"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// PurgeEvents deletes events older than the before date (YYYY-MM-DD), for the owner given by
+// the owner query parameter or for all owners
+// POST /api/v1/admin/events/purge
+func (h *Handler) PurgeEvents(c *gin.Context) {
+	beforeStr := c.Query("before")
+	if beforeStr == "" {
+		respondError(c, apperrors.NewBadRequestError("before is required (YYYY-MM-DD)"))
+		return
+	}
+	before, err := time.Parse("2006-01-02", beforeStr)
+	if err != nil {
+		respondError(c, apperrors.NewBadRequestError("before must be a date in YYYY-MM-DD format"))
+		return
+	}
+	owner := c.Query("owner")
+
+	deleted, err := h.storage.PurgeEvents(c.Request.Context(), owner, before)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to purge events", err))
+		return
+	}
+	h.aggregator.InvalidateCache(owner)
+
+	respond(c, http.StatusOK, gin.H{
+		"owner":   owner,
+		"before":  before,
+		"deleted": deleted,
+	})
+}
+
+// DeleteRepoData deletes all events and metadata of a repository
+// DELETE /api/v1/admin/owners/:owner/repos/:repo
+func (h *Handler) DeleteRepoData(c *gin.Context) {
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+
+	deleted, err := h.storage.DeleteRepoData(c.Request.Context(), owner, repo)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to delete repository data", err))
+		return
+	}
+	h.aggregator.InvalidateCache(owner)
+
+	respond(c, http.StatusOK, gin.H{
+		"owner":   owner,
+		"repo":    repo,
+		"deleted": deleted,
+	})
+}
+
+// DeleteMemberData deletes all events and metadata of a member within an owner
+// DELETE /api/v1/admin/owners/:owner/members/:member
+func (h *Handler) DeleteMemberData(c *gin.Context) {
+	owner := c.Param("owner")
+	member := c.Param("member")
+
+	deleted, err := h.storage.DeleteMemberData(c.Request.Context(), owner, member)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to delete member data", err))
+		return
+	}
+	h.aggregator.InvalidateCache(owner)
+
+	respond(c, http.StatusOK, gin.H{
+		"owner":   owner,
+		"member":  member,
+		"deleted": deleted,
+	})
+}
+
+// RunMigrations re-runs the storage schema migrations
+// POST /api/v1/admin/migrate
+func (h *Handler) RunMigrations(c *gin.Context) {
+	if err := h.storage.Migrate(c.Request.Context()); err != nil {
+		respondError(c, apperrors.NewInternalError("failed to run migrations", err))
+		return
+	}
+	h.aggregator.InvalidateCache("")
+
+	respond(c, http.StatusOK, gin.H{
+		"migrated": true,
+	})
+}
+
+// GetStorageStats returns the events stored for each owner and type, the size of the
+// database and the sizes of its tables and indexes, for capacity planning
+// GET /api/v1/admin/stats
+func (h *Handler) GetStorageStats(c *gin.Context) {
+	stats, err := h.storage.Stats(c.Request.Context())
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to get storage statistics", err))
+		return
+	}
+
+	respond(c, http.StatusOK, stats)
+}
+
+// InvalidateCache drops cached aggregation results for the owner query parameter, or for
+// all owners if it is omitted
+// POST /api/v1/admin/cache/invalidate
+func (h *Handler) InvalidateCache(c *gin.Context) {
+	owner := c.Query("owner")
+	h.aggregator.InvalidateCache(owner)
+
+	respond(c, http.StatusOK, gin.H{
+		"owner":       owner,
+		"invalidated": true,
+	})
+}
+
+// ReloadFunc re-reads the configuration and applies the settings that can change at
+// runtime, returning the names of the settings that changed
+type ReloadFunc func(ctx context.Context) ([]string, error)
+
+// ReloadConfig returns a handler that reloads the configuration with reload.
+// Requests in flight are not interrupted.
+// POST /api/v1/admin/reload
+func (h *Handler) ReloadConfig(reload ReloadFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changed, err := reload(c.Request.Context())
+		if err != nil {
+			respondError(c, apperrors.NewInternalError("failed to reload configuration: "+err.Error(), err))
+			return
+		}
+		if changed == nil {
+			changed = []string{}
+		}
+
+		respond(c, http.StatusOK, gin.H{
+			"reloaded": true,
+			"changed":  changed,
+		})
+	}
+}
+
+const (
+	// defaultViewerTokenTTL and maxViewerTokenTTL bound the lifetime of issued viewer tokens
+	defaultViewerTokenTTL = 7 * 24 * time.Hour
+	maxViewerTokenTTL     = 90 * 24 * time.Hour
+)
+
+// IssueViewerToken returns a handler that issues a read-only token for a single organization
+// or user, to be shared as a dashboard link. ttl is a duration such as "24h" (default 7
+// days, at most 90 days). The caller must have the admin role for the owner.
+// POST /api/v1/admin/viewer-tokens?owner=&ttl=
+func (h *Handler) IssueViewerToken(auth *Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		owner := c.Query("owner")
+		if owner == "" || owner == domain.AllOwners {
+			respondError(c, apperrors.NewBadRequestError("owner is required and must name a single organization or user"))
+			return
+		}
+		ttl := defaultViewerTokenTTL
+		if ttlStr := c.Query("ttl"); ttlStr != "" {
+			parsed, err := time.ParseDuration(ttlStr)
+			if err != nil || parsed <= 0 || parsed > maxViewerTokenTTL {
+				respondError(c, apperrors.NewBadRequestError("ttl must be a positive duration of at most 2160h (e.g. 24h)"))
+				return
+			}
+			ttl = parsed
+		}
+		if !authorizeOwner(c, owner) {
+			return
+		}
+
+		expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+		token, err := auth.IssueViewerToken(owner, expiresAt)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		respond(c, http.StatusCreated, gin.H{
+			"owner":      owner,
+			"token":      token,
+			"expires_at": expiresAt,
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+const (
+	// defaultAuditLimit and maxAuditLimit bound the number of audit entries listed
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+
+	// anonymousPrincipal is recorded for requests without an authenticated API key
+	anonymousPrincipal = "anonymous"
+)
+
+// AuditStore records and lists API access audit entries
+type AuditStore interface {
+	SaveAuditEntry(ctx context.Context, entry *domain.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+}
+
+// Audit returns a middleware that records every request (principal, owner, route, query
+// parameters and status) in the audit log. It must run before Auth so that rejected
+// requests are recorded as well. Failures to record are logged, not returned to the client.
+func Audit(store AuditStore, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		entry := &domain.AuditEntry{
+			Time:      start.UTC(),
+			Principal: anonymousPrincipal,
+			Owner:     auditOwner(c),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Params:    redactQuery(c.Request.URL.RawQuery),
+			Status:    c.Writer.Status(),
+			ClientIP:  c.ClientIP(),
+			RequestID: c.GetString(requestIDContextKey),
+		}
+		if key, ok := APIKeyFromContext(c); ok {
+			entry.Principal = key.Name
+		}
+
+		// The request context may already be canceled once the response is written
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := store.SaveAuditEntry(ctx, entry); err != nil {
+			logger.Error("failed to record audit entry", "request_id", entry.RequestID, "error", err)
+		}
+	}
+}
+
+// auditOwner returns the organization/user a request targeted, if any
+func auditOwner(c *gin.Context) string {
+	for _, param := range []string{"org", "user", "owner"} {
+		if owner := c.Param(param); owner != "" {
+			return owner
+		}
+	}
+	return c.Query("owner")
+}
+
+// ListAuditLog returns API access audit entries, newest first. since and until are
+// YYYY-MM-DD dates; until is inclusive.
+// GET /api/v1/admin/audit?owner=&principal=&since=&until=&limit=
+func (h *Handler) ListAuditLog(c *gin.Context) {
+	filter := domain.AuditFilter{
+		Owner:     c.Query("owner"),
+		Principal: c.Query("principal"),
+		Limit:     defaultAuditLimit,
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			respondError(c, apperrors.NewBadRequestError("since must be a date in YYYY-MM-DD format"))
+			return
+		}
+		filter.Since = since
+	}
+	if untilStr := c.Query("until"); untilStr != "" {
+		until, err := time.Parse("2006-01-02", untilStr)
+		if err != nil {
+			respondError(c, apperrors.NewBadRequestError("until must be a date in YYYY-MM-DD format"))
+			return
+		}
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			respondError(c, apperrors.NewBadRequestError(fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit)))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.storage.ListAuditEntries(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to list audit entries", err))
+		return
+	}
+	if entries == nil {
+		entries = []*domain.AuditEntry{}
+	}
+
+	setPagination(c, filter.Limit, len(entries))
+	respondData(c, "audit-log", entries)
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// apiKeyContextKey is the gin context key holding the authenticated *domain.APIKey
+const apiKeyContextKey = "apiKey"
+
+// APIKeyStore looks up stored API keys and their owner grants
+type APIKeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	GetAPIKeyGrants(ctx context.Context, keyName string) ([]*domain.OwnerGrant, error)
+}
+
+// Authenticator validates API keys against statically configured keys and stored keys
+type Authenticator struct {
+	mu           sync.RWMutex
+	static       []*domain.APIKey
+	viewerSecret []byte      // secret signing viewer tokens; viewer tokens are rejected when empty
+	store        APIKeyStore // may be nil
+}
+
+// NewAuthenticator creates a new authenticator. Static keys must have KeyHash and Grants set.
+func NewAuthenticator(static []*domain.APIKey, store APIKeyStore) *Authenticator {
+	return &Authenticator{
+		static: static,
+		store:  store,
+	}
+}
+
+// SetStaticKeys replaces the statically configured keys. Requests already authenticated
+// with a removed key are not affected.
+func (a *Authenticator) SetStaticKeys(static []*domain.APIKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.static = static
+}
+
+// SetViewerTokenSecret sets the secret viewer tokens are signed with. Changing it invalidates
+// every token issued with the previous secret; an empty secret disables viewer tokens.
+func (a *Authenticator) SetViewerTokenSecret(secret string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.viewerSecret = []byte(secret)
+}
+
+// IssueViewerToken returns a viewer token for owner valid until expiresAt
+func (a *Authenticator) IssueViewerToken(owner string, expiresAt time.Time) (string, error) {
+	a.mu.RLock()
+	secret := a.viewerSecret
+	a.mu.RUnlock()
+	if len(secret) == 0 {
+		return "", apperrors.NewBadRequestError("viewer tokens are not enabled (VIEWER_TOKEN_SECRET is not set)")
+	}
+	return domain.ViewerToken{Owner: owner, ExpiresAt: expiresAt}.Sign(secret)
+}
+
+// Authenticate returns the API key matching rawKey, or an unauthorized error. Viewer tokens
+// authenticate as a read-only key with the viewer role for their owner.
+func (a *Authenticator) Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	if rawKey == "" {
+		return nil, apperrors.NewUnauthorizedError("missing API key")
+	}
+
+	a.mu.RLock()
+	static, viewerSecret := a.static, a.viewerSecret
+	a.mu.RUnlock()
+
+	if len(viewerSecret) > 0 && strings.HasPrefix(rawKey, domain.ViewerTokenPrefix) {
+		token, err := domain.ParseViewerToken(viewerSecret, rawKey, time.Now())
+		if err != nil {
+			return nil, apperrors.NewUnauthorizedError(err.Error())
+		}
+		return token.APIKey(), nil
+	}
+
+	hash := domain.HashAPIKey(rawKey)
+	for _, key := range static {
+		if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hash)) == 1 {
+			return key, nil
+		}
+	}
+
+	if a.store != nil {
+		key, err := a.store.GetAPIKeyByHash(ctx, hash)
+		if err != nil {
+			return nil, apperrors.NewInternalError("failed to look up API key", err)
+		}
+		if key != nil {
+			grants, err := a.store.GetAPIKeyGrants(ctx, key.Name)
+			if err != nil {
+				return nil, apperrors.NewInternalError("failed to look up API key grants", err)
+			}
+			for _, g := range grants {
+				key.Grants = append(key.Grants, *g)
+			}
+			return key, nil
+		}
+	}
+
+	return nil, apperrors.NewUnauthorizedError("invalid API key")
+}
+
+// Auth returns a middleware that requires a valid API key in the Authorization header
+// ("Authorization: Bearer <key>"), or in the access_token query parameter for WebSocket handshakes
+// and for viewer tokens, so that shared dashboard links can carry them. Read-only keys may only
+// use safe methods (GET, HEAD); other methods require the admin scope.
+func Auth(auth *Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		token := bearerToken(c.GetHeader("Authorization"))
+		if token == "" {
+			// Browsers cannot set headers on WebSocket handshakes, and viewer tokens are
+			// shared as links
+			query := c.Query("access_token")
+			if websocket.IsWebSocketUpgrade(c.Request) || strings.HasPrefix(query, domain.ViewerTokenPrefix) {
+				token = query
+			}
+		}
+
+		key, err := auth.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			if appErr, ok := err.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrCodeUnauthorized {
+				c.Header("WWW-Authenticate", `Bearer realm="github-activity-metrics"`)
+			}
+			respondError(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(apiKeyContextKey, key)
+
+		required := domain.APIKeyScopeRead
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			required = domain.APIKeyScopeAdmin
+		}
+		if !key.Scope.Allows(required) {
+			respondError(c, apperrors.NewForbiddenError("API key scope '"+string(key.Scope)+"' does not allow this request"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope returns a middleware that rejects requests whose API key lacks scope.
+// Requests are let through when authentication is disabled (no key in the context).
+func RequireScope(scope domain.APIKeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := APIKeyFromContext(c)
+		if ok && !key.Scope.Allows(scope) {
+			respondError(c, apperrors.NewForbiddenError("this endpoint requires the '"+string(scope)+"' scope"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthorizeOwner returns a middleware that checks the API key's role for the owner named by
+// the param path parameter. Safe methods require the viewer role, others the admin role.
+// Requests are let through when authentication is disabled.
+func AuthorizeOwner(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorizeOwner(c, c.Param(param)) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeOwner checks that the request's API key may access owner with the role required by
+// the request method, responding with an error and returning false otherwise. Handlers that
+// read several owners (e.g. from query parameters) must call it for each of them.
+func authorizeOwner(c *gin.Context, owner string) bool {
+	key, ok := APIKeyFromContext(c)
+	if !ok {
+		return true
+	}
+
+	required := domain.RoleViewer
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		required = domain.RoleAdmin
+	}
+
+	if !keyAllows(c, key, owner, required) {
+		respondError(c, apperrors.NewForbiddenError("API key is not allowed to access '"+owner+"'"))
+		return false
+	}
+	return true
+}
+
+// keyAllows reports whether key has the required role for owner. Keys confined to a workspace
+// only reach the owners of that workspace, and only through its /workspaces/:ws routes.
+func keyAllows(c *gin.Context, key *domain.APIKey, owner string, required domain.Role) bool {
+	if key.Workspace != "" {
+		ws, ok := WorkspaceFromContext(c)
+		if !ok || ws.ID != key.Workspace || !ws.HasOwner(owner) {
+			return false
+		}
+	}
+
+	role, ok := key.RoleFor(owner)
+	return ok && role.Allows(required)
+}
+
+// APIKeyFromContext returns the API key the request was authenticated with
+func APIKeyFromContext(c *gin.Context) (*domain.APIKey, bool) {
+	value, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	key, ok := value.(*domain.APIKey)
+	return key, ok
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header value
+func bearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
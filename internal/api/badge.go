@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/badge"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// GetBadge returns a shields.io-style SVG badge of an owner's metric over the time range, for
+// embedding in READMEs. label replaces the metric name and color the value's background (a
+// name such as green, or hex such as 4c1).
+// GET /api/v1/orgs/:org/badge/:metric
+// GET /api/v1/users/:user/badge/:metric
+func (h *Handler) GetBadge(c *gin.Context) {
+	owner := c.Param("org")
+	if owner == "" {
+		owner = c.Param("user")
+	}
+	timeRange := parseTimeRange(c)
+
+	metrics, err := h.aggregator.AggregateOrgMetrics(c.Request.Context(), owner, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	b, err := badge.ForMetric(metrics, c.Param("metric"), c.Query("label"), c.Query("color"))
+	if err != nil {
+		respondError(c, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", b.SVG())
+}
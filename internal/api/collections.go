@@ -0,0 +1,268 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+const (
+	// defaultCollectionsLimit and maxCollectionsLimit bound the number of batches listed
+	defaultCollectionsLimit = 20
+	maxCollectionsLimit     = 200
+
+	// collectionStreamInterval is how often batch progress is polled while streaming
+	collectionStreamInterval = time.Second
+	// collectionStreamKeepAlive is how often a comment is sent to keep idle streams open
+	collectionStreamKeepAlive = 15 * time.Second
+)
+
+// collectionProgressEvent is the payload of "progress" and "done" server-sent events
+type collectionProgressEvent struct {
+	BatchID    string               `json:"batch_id"`
+	Status     string               `json:"status"`
+	Total      int                  `json:"total"`
+	Pending    int                  `json:"pending"`
+	Processing int                  `json:"processing"`
+	Completed  int                  `json:"completed"`
+	Failed     int                  `json:"failed"`
+	Repos      []collectionRepoItem `json:"repos"` // repositories whose status changed since the previous event
+}
+
+// collectionRepoItem is the status of a single repository in a progress event
+type collectionRepoItem struct {
+	Repo   string `json:"repo"`
+	Status string `json:"status"`
+	Events int    `json:"events"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ListCollections returns recent collection batches with their progress, newest first
+// GET /api/v1/collections?owner=&status=&limit=
+func (h *Handler) ListCollections(c *gin.Context) {
+	filter := domain.BatchFilter{
+		Owner:  c.Query("owner"),
+		Status: c.Query("status"),
+		Limit:  defaultCollectionsLimit,
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxCollectionsLimit {
+			respondError(c, apperrors.NewBadRequestError(fmt.Sprintf("limit must be between 1 and %d", maxCollectionsLimit)))
+			return
+		}
+		filter.Limit = limit
+	}
+	if filter.Owner != "" && !authorizeOwner(c, filter.Owner) {
+		return
+	}
+	filter.Owners = visibleBatchOwners(c)
+
+	batches, err := h.storage.ListBatches(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	details := make([]*domain.CollectionBatchDetail, 0, len(batches))
+	for _, batch := range batches {
+		statuses, err := h.storage.GetBatchRepoStatuses(c.Request.Context(), batch.ID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		details = append(details, &domain.CollectionBatchDetail{
+			Batch:    batch,
+			Progress: domain.SummarizeBatchRepos(statuses),
+		})
+	}
+
+	setPagination(c, filter.Limit, len(details))
+	respond(c, http.StatusOK, details)
+}
+
+// visibleBatchOwners returns the owners whose batches the request may list: the owners of its
+// workspace and, with an API key, those the key may view. It returns nil when every owner's
+// batches are visible.
+func visibleBatchOwners(c *gin.Context) []string {
+	var owners []string
+	ws, scoped := WorkspaceFromContext(c)
+	if scoped {
+		owners = ws.Owners
+	}
+	key, restricted := APIKeyFromContext(c)
+	if !restricted {
+		if scoped {
+			return append([]string{}, owners...)
+		}
+		return nil
+	}
+	if !scoped {
+		if _, all := key.RoleFor(domain.AllOwners); all && key.Workspace == "" {
+			return nil
+		}
+		for _, grant := range key.Grants {
+			owners = append(owners, grant.Owner)
+		}
+	}
+
+	visible := []string{}
+	for _, owner := range owners {
+		if keyAllows(c, key, owner, domain.RoleViewer) {
+			visible = append(visible, owner)
+		}
+	}
+	return visible
+}
+
+// GetCollection returns a collection batch with its per-repository statuses
+// GET /api/v1/collections/:id
+func (h *Handler) GetCollection(c *gin.Context) {
+	batch, err := h.getBatch(c, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !authorizeOwner(c, batch.Owner) {
+		return
+	}
+
+	statuses, err := h.storage.GetBatchRepoStatuses(c.Request.Context(), batch.ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, &domain.CollectionBatchDetail{
+		Batch:    batch,
+		Progress: domain.SummarizeBatchRepos(statuses),
+		Repos:    statuses,
+	})
+}
+
+// StreamCollection streams per-repository progress of a collection batch as server-sent events.
+// A "progress" event is sent whenever a repository changes status, and a final "done" event
+// once the batch is no longer in progress.
+// GET /api/v1/collections/:id/stream
+func (h *Handler) StreamCollection(c *gin.Context) {
+	ctx := c.Request.Context()
+	batchID := c.Param("id")
+
+	batch, err := h.getBatch(c, batchID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !authorizeOwner(c, batch.Owner) {
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	poll := time.NewTicker(collectionStreamInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(collectionStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	seen := make(map[string]domain.BatchRepoStatus)
+	first := true
+
+	c.Stream(func(w io.Writer) bool {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-keepAlive.C:
+				_, _ = io.WriteString(w, ": keep-alive\n\n")
+				return true
+			case <-poll.C:
+			}
+
+			batch, err = h.storage.GetBatch(ctx, batchID)
+			if err != nil {
+				c.SSEvent("error", gin.H{"message": err.Error()})
+				return false
+			}
+		}
+
+		statuses, err := h.storage.GetBatchRepoStatuses(ctx, batchID)
+		if err != nil {
+			c.SSEvent("error", gin.H{"message": err.Error()})
+			return false
+		}
+
+		event := newCollectionProgressEvent(batch, statuses, seen)
+		done := batch.Status != "in_progress"
+		switch {
+		case done:
+			c.SSEvent("done", event)
+			return false
+		case first || len(event.Repos) > 0:
+			c.SSEvent("progress", event)
+		}
+		first = false
+		return true
+	})
+}
+
+// getBatch loads a batch, converting a missing batch (or, on workspace routes, a batch of an
+// owner outside the workspace) into a not found error
+func (h *Handler) getBatch(c *gin.Context, batchID string) (*domain.CollectionBatch, error) {
+	batch, err := h.storage.GetBatch(c.Request.Context(), batchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.NewNotFoundError("collection " + batchID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ws, ok := WorkspaceFromContext(c); ok && !ws.HasOwner(batch.Owner) {
+		return nil, apperrors.NewNotFoundError("collection " + batchID)
+	}
+	return batch, nil
+}
+
+// newCollectionProgressEvent builds a progress event, including only the repositories whose
+// status changed since they were last recorded in seen
+func newCollectionProgressEvent(batch *domain.CollectionBatch, statuses []*domain.BatchRepoStatus, seen map[string]domain.BatchRepoStatus) *collectionProgressEvent {
+	progress := domain.SummarizeBatchRepos(statuses)
+	event := &collectionProgressEvent{
+		BatchID:    batch.ID,
+		Status:     batch.Status,
+		Total:      progress.Total,
+		Pending:    progress.Pending,
+		Processing: progress.Processing,
+		Completed:  progress.Completed,
+		Failed:     progress.Failed,
+		Repos:      []collectionRepoItem{},
+	}
+
+	for _, st := range statuses {
+		previous, ok := seen[st.Repo]
+		if ok && previous.Status == st.Status && previous.Events == st.Events {
+			continue
+		}
+		seen[st.Repo] = *st
+		event.Repos = append(event.Repos, collectionRepoItem{
+			Repo:   st.Repo,
+			Status: st.Status,
+			Events: st.Events,
+			Error:  st.Error,
+		})
+	}
+	return event
+}
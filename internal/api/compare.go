@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// maxCompareEntities is the largest number of repositories plus members a comparison may list
+const maxCompareEntities = 10
+
+// Compare returns side-by-side metrics and time series for the repositories and members listed
+// in the repos and members query parameters (comma-separated or repeated)
+// GET /api/v1/orgs/:org/compare?repos=a,b&members=x,y
+func (h *Handler) Compare(c *gin.Context) {
+	org := c.Param("org")
+	timeRange := parseTimeRange(c)
+	repos := parseListQuery(c, "repos")
+	members := parseListQuery(c, "members")
+
+	total := len(repos) + len(members)
+	if total == 0 {
+		respondError(c, apperrors.NewBadRequestError("repos or members is required"))
+		return
+	}
+	if total > maxCompareEntities {
+		respondError(c, apperrors.NewBadRequestError(fmt.Sprintf("at most %d repos and members can be compared", maxCompareEntities)))
+		return
+	}
+
+	comparison, err := h.aggregator.Compare(c.Request.Context(), org, repos, members, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondData(c, "compare", comparison)
+}
+
+// parseListQuery returns the distinct values of a query parameter given as a comma-separated
+// list and/or repeated, in the order they first appear
+func parseListQuery(c *gin.Context, key string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, param := range c.QueryArray(key) {
+		for _, v := range strings.Split(param, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
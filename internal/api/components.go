@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRepoComponentMetrics returns the metrics of each component of a monorepo, as configured
+// with REPO_COMPONENTS
+// GET /api/v1/orgs/:org/repos/:repo/components/metrics
+// GET /api/v1/users/:user/repos/:repo/components/metrics
+func (h *Handler) GetRepoComponentMetrics(c *gin.Context) {
+	owner := c.Param("org")
+	if owner == "" {
+		owner = c.Param("user")
+	}
+	repo := c.Param("repo")
+	timeRange := parseTimeRange(c)
+
+	metrics, err := h.aggregator.GetComponentMetrics(c.Request.Context(), owner, repo, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, metrics)
+}
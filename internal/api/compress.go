@@ -0,0 +1,125 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipPool reuses gzip writers across responses
+var gzipPool = sync.Pool{
+	New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return gz
+	},
+}
+
+// compressibleTypes are the response content types worth compressing
+var compressibleTypes = []string{"application/json", "text/plain", "text/csv", "text/html"}
+
+// Gzip returns a middleware that gzip-compresses JSON and text responses for clients
+// sending "Accept-Encoding: gzip". Streaming responses (e.g. text/event-stream) are left as is.
+func Gzip() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		w := &gzipResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer w.close()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether the request accepts gzip content encoding
+func acceptsGzip(r *http.Request) bool {
+	if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter compresses the body once the content type is known (at the first write)
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	gz       *gzip.Writer
+	decided  bool
+	compress bool
+}
+
+// decide chooses whether to compress based on the headers set by the handler
+func (w *gzipResponseWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return
+	}
+	contentType := h.Get("Content-Type")
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			w.compress = true
+			break
+		}
+	}
+	if !w.compress {
+		return
+	}
+
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	w.gz = gzipPool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.compress {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.compress {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flushes the compressed stream and returns the writer to the pool
+func (w *gzipResponseWriter) close() {
+	if !w.compress {
+		return
+	}
+	_ = w.gz.Close()
+	w.gz.Reset(io.Discard)
+	gzipPool.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// ListDeployDefinitions returns the deploy definitions of an owner's repositories; the other
+// repositories count their GitHub deployments
+// GET /api/v1/admin/owners/:owner/deploy-definitions
+func (h *Handler) ListDeployDefinitions(c *gin.Context) {
+	owner := c.Param("owner")
+
+	defs, err := h.storage.ListDeployDefinitions(c.Request.Context(), owner)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to list deploy definitions", err))
+		return
+	}
+	if defs == nil {
+		defs = []*domain.DeployDefinition{}
+	}
+
+	respond(c, http.StatusOK, defs)
+}
+
+// GetDeployDefinition returns what counts as a deploy of a repository, the default GitHub
+// deployments if it has no definition
+// GET /api/v1/admin/owners/:owner/repos/:repo/deploy-definition
+func (h *Handler) GetDeployDefinition(c *gin.Context) {
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+
+	def, err := h.storage.GetDeployDefinition(c.Request.Context(), owner, repo)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to get the deploy definition", err))
+		return
+	}
+	if def == nil {
+		def = domain.DefaultDeployDefinition(owner, repo)
+	}
+
+	respond(c, http.StatusOK, def)
+}
+
+// SetDeployDefinition sets what counts as a deploy of a repository: source is deployments,
+// workflow, tag or release, and pattern a glob of the environments, workflows or tags
+// counted. If that changes what counts as a deploy, the repository's deploys are deleted;
+// collect the repository again to record the deploys of the new definition.
+// PUT /api/v1/admin/owners/:owner/repos/:repo/deploy-definition?source=&pattern=
+func (h *Handler) SetDeployDefinition(c *gin.Context) {
+	def := &domain.DeployDefinition{
+		Owner:     c.Param("owner"),
+		Repo:      c.Param("repo"),
+		Source:    domain.DeploySource(c.Query("source")),
+		Pattern:   c.Query("pattern"),
+		UpdatedAt: time.Now(),
+	}
+	if err := def.Validate(); err != nil {
+		respondError(c, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	deleted, err := h.storage.SaveDeployDefinition(c.Request.Context(), def)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to save the deploy definition", err))
+		return
+	}
+	h.aggregator.InvalidateCache(def.Owner)
+
+	respond(c, http.StatusOK, gin.H{
+		"definition":      def,
+		"deleted_deploys": deleted,
+	})
+}
+
+// DeleteDeployDefinition makes a repository count all its GitHub deployments again, deleting
+// its deploys if it counted others
+// DELETE /api/v1/admin/owners/:owner/repos/:repo/deploy-definition
+func (h *Handler) DeleteDeployDefinition(c *gin.Context) {
+	owner := c.Param("owner")
+	repo := c.Param("repo")
+
+	deleted, err := h.storage.DeleteDeployDefinition(c.Request.Context(), owner, repo)
+	if err != nil {
+		respondError(c, apperrors.NewInternalError("failed to delete the deploy definition", err))
+		return
+	}
+	h.aggregator.InvalidateCache(owner)
+
+	respond(c, http.StatusOK, gin.H{
+		"definition":      domain.DefaultDeployDefinition(owner, repo),
+		"deleted_deploys": deleted,
+	})
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+const (
+	apiVersionContextKey = "api_version"
+	timeRangeContextKey  = "time_range"
+	paginationContextKey = "pagination"
+)
+
+// Envelope is the body of every successful /api/v2 response
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta Meta        `json:"meta"`
+}
+
+// ErrorResponse is the body of every failed /api/v2 response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+	Meta  Meta      `json:"meta"`
+}
+
+// ErrorBody describes an error. Type is a stable category clients can switch on; Code is the
+// specific application error code.
+type ErrorBody struct {
+	Type      ErrorType `json:"type"`
+	Code      string    `json:"code"`
+	Status    int       `json:"status"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// ErrorType is the category of a v2 error
+type ErrorType string
+
+const (
+	ErrorTypeInvalidRequest ErrorType = "invalid_request"
+	ErrorTypeAuthentication ErrorType = "authentication"
+	ErrorTypePermission     ErrorType = "permission"
+	ErrorTypeNotFound       ErrorType = "not_found"
+	ErrorTypeConflict       ErrorType = "conflict"
+	ErrorTypeRateLimit      ErrorType = "rate_limit"
+	ErrorTypeInternal       ErrorType = "internal"
+)
+
+// Meta describes how a v2 response was produced
+type Meta struct {
+	TimeRange   *MetaTimeRange `json:"time_range,omitempty"`  // range actually queried, after defaults
+	Granularity string         `json:"granularity,omitempty"` // set together with TimeRange
+	Pagination  *Pagination    `json:"pagination,omitempty"`  // set by list endpoints with a limit
+	GeneratedAt time.Time      `json:"generated_at"`
+	RequestID   string         `json:"request_id,omitempty"`
+}
+
+// MetaTimeRange is the time range a response covers
+type MetaTimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Pagination describes the page returned by a list endpoint
+type Pagination struct {
+	Limit  int  `json:"limit"`
+	Offset int  `json:"offset,omitempty"` // number of items skipped, for endpoints with offset
+	Count  int  `json:"count"`            // number of items returned
+	Total  *int `json:"total,omitempty"`  // number of items on every page, when known
+}
+
+// APIVersion returns a middleware that records the API version of a route group. Version 2
+// responses use Envelope and ErrorResponse bodies; earlier versions use {"data": ...} and
+// {"error": ...}.
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionContextKey, version)
+		c.Next()
+	}
+}
+
+// isV2 reports whether the request is served by the /api/v2 routes
+func isV2(c *gin.Context) bool {
+	return c.GetInt(apiVersionContextKey) >= 2
+}
+
+// respond writes data with status in the response body format of the request's API version.
+// Successful responses are reduced to the fields selected by ?fields=, if any.
+func respond(c *gin.Context, status int, data interface{}) {
+	if fields := parseFields(c); fields != nil && status < http.StatusBadRequest {
+		filtered, err := selectFields(data, fields)
+		if err != nil {
+			respondError(c, apperrors.NewInternalError("failed to select response fields", err))
+			return
+		}
+		data = filtered
+	}
+
+	if !isV2(c) {
+		c.JSON(status, gin.H{
+			"data": data,
+		})
+		return
+	}
+
+	c.JSON(status, Envelope{
+		Data: data,
+		Meta: newMeta(c),
+	})
+}
+
+// setPagination records the pagination of a list response for the v2 meta
+func setPagination(c *gin.Context, limit, count int) {
+	c.Set(paginationContextKey, &Pagination{Limit: limit, Count: count})
+}
+
+// TotalCountHeader is the header of paginated list responses with the number of items on every
+// page, for /api/v1 responses, which have no meta
+const TotalCountHeader = "X-Total-Count"
+
+// paginate returns the page of items selected by the limit and offset query parameters and
+// records it for the meta. Without limit every item from offset on is returned.
+func paginate[T any](c *gin.Context, items []T) []T {
+	limit := parseIntQuery(c, "limit", 0)
+	offset := parseIntQuery(c, "offset", 0)
+	if limit == 0 && offset == 0 {
+		return items
+	}
+
+	total := len(items)
+	page := items[min(offset, total):]
+	if limit > 0 && len(page) > limit {
+		page = page[:limit]
+	}
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.Set(paginationContextKey, &Pagination{Limit: limit, Offset: offset, Count: len(page), Total: &total})
+	return page
+}
+
+// newMeta builds the meta of a v2 response from what the handler recorded in the context
+func newMeta(c *gin.Context) Meta {
+	meta := Meta{
+		GeneratedAt: time.Now().UTC(),
+		RequestID:   RequestIDFromContext(c),
+	}
+	if timeRange, ok := timeRangeFromContext(c); ok {
+		meta.TimeRange = &MetaTimeRange{Start: timeRange.Start, End: timeRange.End}
+		meta.Granularity = timeRange.Granularity
+	}
+	if value, ok := c.Get(paginationContextKey); ok {
+		meta.Pagination, _ = value.(*Pagination)
+	}
+	return meta
+}
+
+// errorStatus returns the HTTP status for an application error code
+func errorStatus(code apperrors.ErrCode) int {
+	switch code {
+	case apperrors.ErrCodeNotFound:
+		return http.StatusNotFound
+	case apperrors.ErrCodeUnauthorized:
+		return http.StatusUnauthorized
+	case apperrors.ErrCodeForbidden:
+		return http.StatusForbidden
+	case apperrors.ErrCodeBadRequest, apperrors.ErrCodeInvalidRankingType:
+		return http.StatusBadRequest
+	case apperrors.ErrCodeConflict:
+		return http.StatusConflict
+	case apperrors.ErrCodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorType returns the v2 error category for an HTTP status
+func errorType(status int) ErrorType {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrorTypeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrorTypeAuthentication
+	case http.StatusForbidden:
+		return ErrorTypePermission
+	case http.StatusNotFound:
+		return ErrorTypeNotFound
+	case http.StatusConflict:
+		return ErrorTypeConflict
+	case http.StatusTooManyRequests:
+		return ErrorTypeRateLimit
+	default:
+		return ErrorTypeInternal
+	}
+}
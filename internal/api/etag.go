@@ -0,0 +1,113 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+)
+
+// ETagState returns the state of what a response depends on besides the owner's events, for
+// responses that the data watermark alone doesn't identify
+type ETagState func(c *gin.Context) (string, error)
+
+// ETag returns a middleware that tags GET responses for the owner named by the param path
+// parameter with an ETag derived from the request and the owner's data watermark (latest event
+// created_at and data version), answering matching If-None-Match requests with 304 Not Modified without running
+// the handler.
+func ETag(agg aggregator.Aggregator, param string) gin.HandlerFunc {
+	return ETagWithState(agg, param, nil)
+}
+
+// ETagWithState is ETag for responses that also depend on the state returned by state
+func ETagWithState(agg aggregator.Aggregator, param string, state ETagState) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		watermark, err := agg.GetWatermark(c.Request.Context(), c.Param(param))
+		if err != nil {
+			// Serve the request untagged rather than failing it
+			c.Next()
+			return
+		}
+
+		var extra string
+		if state != nil {
+			if extra, err = state(c); err != nil {
+				c.Next()
+				return
+			}
+		}
+
+		etag := computeETag(c, watermark, extra)
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Header("ETag", etag)
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		c.Writer = &etagResponseWriter{ResponseWriter: c.Writer, etag: etag}
+		c.Next()
+	}
+}
+
+// computeETag builds a weak ETag from the request path, query, time range, owner watermark and
+// extra state. The resolved time range is included (to the minute) because default ranges end
+// at "now", so the response can change without new events.
+func computeETag(c *gin.Context, watermark aggregator.Watermark, extra string) string {
+	timeRange := parseTimeRange(c)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s?%s|%d|%d|%d|%d|%s",
+		c.Request.URL.Path,
+		c.Request.URL.RawQuery,
+		timeRange.Start.Truncate(time.Minute).Unix(),
+		timeRange.End.Truncate(time.Minute).Unix(),
+		watermark.LatestEvent.UnixNano(),
+		watermark.Version,
+		extra,
+	)
+	// Weak, because the representation differs with content encoding (gzip)
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag (weak comparison)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagResponseWriter adds the ETag header to successful responses only
+type etagResponseWriter struct {
+	gin.ResponseWriter
+	etag string
+}
+
+func (w *etagResponseWriter) WriteHeader(code int) {
+	if code == http.StatusOK {
+		w.Header().Set("ETag", w.etag)
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *etagResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
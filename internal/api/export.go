@@ -0,0 +1,338 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+const (
+	// exportFormatCSV and exportFormatXLSX are the values accepted by ?format= besides "json"
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+
+	// csvFlushRows is how many CSV rows are buffered before they are flushed to the client
+	csvFlushRows = 500
+
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	xlsxSheet       = "Sheet1"
+)
+
+// exportTable is tabular data written by CSV and Excel exports
+type exportTable struct {
+	header []string
+	rows   func(yield func(row []interface{}) error) error
+}
+
+// respondData writes data as JSON (see respond) or, when ?format=csv|xlsx is given and
+// data is tabular, as a spreadsheet download named after the owner and name
+func respondData(c *gin.Context, name string, data interface{}) {
+	format := c.DefaultQuery("format", "json")
+	if format == "json" {
+		respond(c, http.StatusOK, data)
+		return
+	}
+	if format != exportFormatCSV && format != exportFormatXLSX {
+		respondError(c, apperrors.NewBadRequestError("format must be one of: json, csv, xlsx"))
+		return
+	}
+
+	table, ok := newExportTable(data)
+	if !ok {
+		respondError(c, apperrors.NewBadRequestError(fmt.Sprintf("format %s is not supported by this endpoint", format)))
+		return
+	}
+
+	filename := exportFilename(c, name, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var err error
+	if format == exportFormatCSV {
+		err = writeCSV(c, table)
+	} else {
+		err = writeXLSX(c, table)
+	}
+	if err != nil {
+		// Headers may already be sent; record the error for the request log
+		_ = c.Error(err)
+	}
+}
+
+// exportFilename builds a download file name such as "my-org-members-20240101-20240131.csv"
+func exportFilename(c *gin.Context, name, format string) string {
+	owner := c.Param("org")
+	if owner == "" {
+		owner = c.Param("user")
+	}
+	if owner == "" {
+		owner = c.Query("owner")
+	}
+	if owner != "" {
+		name = owner + "-" + name
+	}
+	timeRange := parseTimeRange(c)
+	return fmt.Sprintf("%s-%s-%s.%s", name,
+		timeRange.Start.Format("20060102"), timeRange.End.Format("20060102"), format)
+}
+
+// writeCSV streams the table as CSV, flushing every csvFlushRows rows
+func writeCSV(c *gin.Context, table *exportTable) error {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(table.header); err != nil {
+		return err
+	}
+
+	n := 0
+	record := make([]string, len(table.header))
+	err := table.rows(func(row []interface{}) error {
+		for i, v := range row {
+			record[i] = formatExportValue(v)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		n++
+		if n%csvFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// writeXLSX streams the table into a single-sheet workbook using excelize's stream writer
+func writeXLSX(c *gin.Context, table *exportTable) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sw, err := f.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(table.header))
+	for i, h := range table.header {
+		header[i] = h
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	rowNum := 1
+	err = table.rows(func(row []interface{}) error {
+		rowNum++
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			if t, ok := v.(time.Time); ok {
+				// Excel has no time zones; keep the timestamp readable as text
+				v = t.UTC().Format(time.RFC3339)
+			}
+			values[i] = v
+		}
+		return sw.SetRow(cell, values)
+	})
+	if err != nil {
+		return err
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", xlsxContentType)
+	_, err = f.WriteTo(c.Writer)
+	return err
+}
+
+// formatExportValue formats a cell value for CSV output
+func formatExportValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// newExportTable returns the tabular representation of a handler result; ok is false
+// for results that have no natural table form
+func newExportTable(data interface{}) (table *exportTable, ok bool) {
+	switch d := data.(type) {
+	case []*domain.MemberMetrics:
+		return &exportTable{
+			header: []string{"member", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, m := range d {
+					if err := yield([]interface{}{m.Member, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case []*domain.RepoMetrics:
+		return &exportTable{
+			header: []string{"repo", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, m := range d {
+					if err := yield([]interface{}{m.Repo, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case []*domain.MemberRanking:
+		return &exportTable{
+			header: []string{"rank", "member", "value", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, r := range d {
+					if err := yield([]interface{}{r.Rank, r.Member, r.Value, r.Commits, r.PRs, r.Additions, r.Deletions, r.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case []*domain.RepoRanking:
+		return &exportTable{
+			header: []string{"rank", "repo", "value", "commits", "prs", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, r := range d {
+					if err := yield([]interface{}{r.Rank, r.Repo, r.Value, r.Commits, r.PRs, r.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case []*domain.TeamMetrics:
+		return &exportTable{
+			header: []string{"team", "name", "members", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, m := range d {
+					if err := yield([]interface{}{m.Team, m.Name, m.Members, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case []*domain.TeamRanking:
+		return &exportTable{
+			header: []string{"rank", "team", "name", "value", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, r := range d {
+					if err := yield([]interface{}{r.Rank, r.Team, r.Name, r.Value, r.Commits, r.PRs, r.Additions, r.Deletions, r.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case []*domain.AuditEntry:
+		return &exportTable{
+			header: []string{"time", "principal", "owner", "method", "route", "path", "params", "status", "client_ip", "request_id"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, e := range d {
+					if err := yield([]interface{}{e.Time, e.Principal, e.Owner, e.Method, e.Route, e.Path, e.Params, e.Status, e.ClientIP, e.RequestID}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case *domain.Heatmap:
+		header := []string{"weekday"}
+		for _, h := range d.Hours {
+			header = append(header, strconv.Itoa(h))
+		}
+		return &exportTable{
+			header: header,
+			rows: func(yield func([]interface{}) error) error {
+				for i, weekday := range d.Weekdays {
+					row := []interface{}{weekday}
+					for _, count := range d.Values[i] {
+						row = append(row, count)
+					}
+					if err := yield(row); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case *domain.Comparison:
+		return &exportTable{
+			header: []string{"type", "name", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, r := range d.Repos {
+					m := r.Metrics
+					if err := yield([]interface{}{"repo", r.Repo, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys}); err != nil {
+						return err
+					}
+				}
+				for _, r := range d.Members {
+					m := r.Metrics
+					if err := yield([]interface{}{"member", r.Member, m.Commits, m.PRs, m.Additions, m.Deletions, m.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case *domain.TimeSeriesData:
+		return &exportTable{
+			header: []string{"timestamp", "type", "value"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, p := range d.DataPoints {
+					if err := yield([]interface{}{p.Timestamp, string(d.Type), p.Value}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	case *domain.DetailedTimeSeriesData:
+		return &exportTable{
+			header: []string{"timestamp", "commits", "prs", "additions", "deletions", "deploys"},
+			rows: func(yield func([]interface{}) error) error {
+				for _, p := range d.DataPoints {
+					if err := yield([]interface{}{p.Timestamp, p.Commits, p.PRs, p.Additions, p.Deletions, p.Deploys}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldSet is a parsed ?fields= selection. Each key is a normalized field name; a non-empty
+// value selects fields of the nested object (or of each element of a nested list).
+type fieldSet map[string]fieldSet
+
+// parseFields parses a comma-separated ?fields= value such as "member,commits,prs" or
+// "org,time_range.start". It returns nil when no fields are requested.
+func parseFields(c *gin.Context) fieldSet {
+	value := c.Query("fields")
+	if value == "" {
+		return nil
+	}
+
+	fields := fieldSet{}
+	for _, path := range strings.Split(value, ",") {
+		set := fields
+		for _, name := range strings.Split(strings.TrimSpace(path), ".") {
+			name = normalizeFieldName(name)
+			if name == "" {
+				break
+			}
+			if set[name] == nil {
+				set[name] = fieldSet{}
+			}
+			set = set[name]
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// normalizeFieldName makes field names match regardless of case and underscores, so
+// "totalRepos" selects total_repos
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// selectFields returns data reduced to the selected fields. Lists are filtered element by
+// element; names that match no field are ignored.
+func selectFields(data interface{}, fields fieldSet) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return filterFields(value, fields), nil
+}
+
+// filterFields applies fields to a decoded JSON value
+func filterFields(value interface{}, fields fieldSet) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(fields))
+		for key, field := range v {
+			nested, ok := fields[normalizeFieldName(key)]
+			if !ok {
+				continue
+			}
+			if len(nested) > 0 {
+				field = filterFields(field, nested)
+			}
+			filtered[key] = field
+		}
+		return filtered
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = filterFields(elem, fields)
+		}
+		return v
+	default:
+		return value
+	}
+}
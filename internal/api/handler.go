@@ -10,20 +10,32 @@ import (
 	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
 	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+	"github.com/kurihiro0119/github-activity-metrics/internal/version"
 )
 
 // Handler handles API requests
 type Handler struct {
 	aggregator aggregator.Aggregator
+	storage    storage.Storage
+	pseudonyms *aggregator.Pseudonyms // nil unless member usernames are pseudonymized
 }
 
 // NewHandler creates a new API handler
-func NewHandler(agg aggregator.Aggregator) *Handler {
+func NewHandler(agg aggregator.Aggregator, store storage.Storage) *Handler {
 	return &Handler{
 		aggregator: agg,
+		storage:    store,
 	}
 }
 
+// SetPseudonyms makes the handler show member usernames read from storage, such as team
+// members, as their pseudonyms; the aggregator is expected to pseudonymize with the same p
+// (see aggregator.WithPseudonyms). nil shows usernames as they are.
+func (h *Handler) SetPseudonyms(p *aggregator.Pseudonyms) {
+	h.pseudonyms = p
+}
+
 // GetOrgMetrics returns organization-level metrics
 // GET /api/v1/orgs/:org/metrics
 func (h *Handler) GetOrgMetrics(c *gin.Context) {
@@ -36,9 +48,7 @@ func (h *Handler) GetOrgMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respond(c, http.StatusOK, metrics)
 }
 
 // GetMemberMetrics returns member-level metrics
@@ -54,9 +64,7 @@ func (h *Handler) GetMemberMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respond(c, http.StatusOK, metrics)
 }
 
 // GetRepoMetrics returns repository-level metrics
@@ -72,9 +80,7 @@ func (h *Handler) GetRepoMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respond(c, http.StatusOK, metrics)
 }
 
 // GetRepoMembersMetrics returns metrics for all members in a specific repository
@@ -90,9 +96,26 @@ func (h *Handler) GetRepoMembersMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, repo+"-members", metrics)
+}
+
+// GetMemberReposMetrics returns a member's metrics in each repository they were active in
+// GET /api/v1/orgs/:org/members/:member/repos/metrics
+func (h *Handler) GetMemberReposMetrics(c *gin.Context) {
+	org := c.Param("org")
+	member := c.Param("member")
+	timeRange := parseTimeRange(c)
+
+	metrics, err := h.aggregator.GetMemberReposMetrics(c.Request.Context(), org, member, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if metrics == nil {
+		metrics = []*domain.RepoMetrics{}
+	}
+
+	respondData(c, member+"-repos", metrics)
 }
 
 // GetMembersMetrics returns metrics for all members
@@ -107,9 +130,7 @@ func (h *Handler) GetMembersMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, "members", paginate(c, metrics))
 }
 
 // GetReposMetrics returns metrics for all repositories
@@ -124,9 +145,53 @@ func (h *Handler) GetReposMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, "repos", paginate(c, metrics))
+}
+
+// GetReposCommitTypes returns conventional-commit type counts per repository
+// GET /api/v1/orgs/:org/repos/metrics/commit-types
+func (h *Handler) GetReposCommitTypes(c *gin.Context) {
+	org := c.Param("org")
+	timeRange := parseTimeRange(c)
+
+	classification, err := h.aggregator.GetRepoCommitClassification(c.Request.Context(), org, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, classification)
+}
+
+// GetMembersCommitTypes returns conventional-commit type counts per member
+// GET /api/v1/orgs/:org/members/metrics/commit-types
+func (h *Handler) GetMembersCommitTypes(c *gin.Context) {
+	org := c.Param("org")
+	timeRange := parseTimeRange(c)
+
+	classification, err := h.aggregator.GetMemberCommitClassification(c.Request.Context(), org, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, classification)
+}
+
+// GetUserReposCommitTypes returns conventional-commit type counts per repository of a user
+// GET /api/v1/users/:user/repos/metrics/commit-types
+func (h *Handler) GetUserReposCommitTypes(c *gin.Context) {
+	user := c.Param("user")
+	timeRange := parseTimeRange(c)
+
+	// Use org classification aggregator (user is stored as org in the database)
+	classification, err := h.aggregator.GetRepoCommitClassification(c.Request.Context(), user, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, classification)
 }
 
 // GetTimeSeriesMetrics returns time series metrics
@@ -154,9 +219,7 @@ func (h *Handler) GetTimeSeriesMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, "timeseries-"+metricTypeStr, metrics)
 }
 
 // GetUserMetrics returns user-level metrics (same as org metrics)
@@ -172,9 +235,7 @@ func (h *Handler) GetUserMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respond(c, http.StatusOK, metrics)
 }
 
 // GetUserTimeSeriesMetrics returns time series metrics for a user
@@ -203,9 +264,7 @@ func (h *Handler) GetUserTimeSeriesMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, "timeseries-"+metricTypeStr, metrics)
 }
 
 // GetUserReposMetrics returns metrics for all repositories of a user
@@ -221,9 +280,7 @@ func (h *Handler) GetUserReposMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, "repos", paginate(c, metrics))
 }
 
 // GetUserRepoMetrics returns repository-level metrics for a user
@@ -240,9 +297,7 @@ func (h *Handler) GetUserRepoMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respond(c, http.StatusOK, metrics)
 }
 
 // GetUserRepoMembersMetrics returns metrics for all members in a user's specific repository
@@ -259,9 +314,7 @@ func (h *Handler) GetUserRepoMembersMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": metrics,
-	})
+	respondData(c, repo+"-members", metrics)
 }
 
 // GetOrgTimeSeriesDetailed returns detailed time series data for an organization
@@ -276,9 +329,7 @@ func (h *Handler) GetOrgTimeSeriesDetailed(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": data,
-	})
+	respondData(c, "timeseries", data)
 }
 
 // GetRepoTimeSeriesDetailed returns detailed time series data for a repository
@@ -294,9 +345,7 @@ func (h *Handler) GetRepoTimeSeriesDetailed(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": data,
-	})
+	respondData(c, repo+"-timeseries", data)
 }
 
 // GetMemberTimeSeriesDetailed returns detailed time series data for a member
@@ -312,9 +361,7 @@ func (h *Handler) GetMemberTimeSeriesDetailed(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": data,
-	})
+	respondData(c, member+"-timeseries", data)
 }
 
 // GetUserTimeSeriesDetailed returns detailed time series data for a user
@@ -330,9 +377,7 @@ func (h *Handler) GetUserTimeSeriesDetailed(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": data,
-	})
+	respondData(c, "timeseries", data)
 }
 
 // GetUserRepoTimeSeriesDetailed returns detailed time series data for a user repository
@@ -349,9 +394,7 @@ func (h *Handler) GetUserRepoTimeSeriesDetailed(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": data,
-	})
+	respondData(c, repo+"-timeseries", data)
 }
 
 // GetMemberRanking returns member rankings
@@ -373,12 +416,7 @@ func (h *Handler) GetMemberRanking(c *gin.Context) {
 	case "deploys":
 		rankingType = domain.RankingTypeDeploys
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_RANKING_TYPE",
-				"message": "ranking type must be one of: commits, prs, code-changes, deploys",
-			},
-		})
+		respondError(c, apperrors.NewInvalidRankingTypeError("ranking type must be one of: commits, prs, code-changes, deploys"))
 		return
 	}
 
@@ -387,10 +425,8 @@ func (h *Handler) GetMemberRanking(c *gin.Context) {
 		respondError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": rankings,
-	})
+	setPagination(c, limit, len(rankings))
+	respondData(c, "ranking-members-"+rankingTypeStr, rankings)
 }
 
 // GetRepoRanking returns repository rankings
@@ -412,12 +448,7 @@ func (h *Handler) GetRepoRanking(c *gin.Context) {
 	case "deploys":
 		rankingType = domain.RankingTypeDeploys
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_RANKING_TYPE",
-				"message": "ranking type must be one of: commits, prs, code-changes, deploys",
-			},
-		})
+		respondError(c, apperrors.NewInvalidRankingTypeError("ranking type must be one of: commits, prs, code-changes, deploys"))
 		return
 	}
 
@@ -426,10 +457,8 @@ func (h *Handler) GetRepoRanking(c *gin.Context) {
 		respondError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": rankings,
-	})
+	setPagination(c, limit, len(rankings))
+	respondData(c, "ranking-repos-"+rankingTypeStr, rankings)
 }
 
 // GetUserMemberRanking returns member rankings for a user account
@@ -451,12 +480,7 @@ func (h *Handler) GetUserMemberRanking(c *gin.Context) {
 	case "deploys":
 		rankingType = domain.RankingTypeDeploys
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_RANKING_TYPE",
-				"message": "ranking type must be one of: commits, prs, code-changes, deploys",
-			},
-		})
+		respondError(c, apperrors.NewInvalidRankingTypeError("ranking type must be one of: commits, prs, code-changes, deploys"))
 		return
 	}
 
@@ -465,10 +489,8 @@ func (h *Handler) GetUserMemberRanking(c *gin.Context) {
 		respondError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": rankings,
-	})
+	setPagination(c, limit, len(rankings))
+	respondData(c, "ranking-members-"+rankingTypeStr, rankings)
 }
 
 // GetUserRepoRanking returns repository rankings for a user account
@@ -490,12 +512,7 @@ func (h *Handler) GetUserRepoRanking(c *gin.Context) {
 	case "deploys":
 		rankingType = domain.RankingTypeDeploys
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_RANKING_TYPE",
-				"message": "ranking type must be one of: commits, prs, code-changes, deploys",
-			},
-		})
+		respondError(c, apperrors.NewInvalidRankingTypeError("ranking type must be one of: commits, prs, code-changes, deploys"))
 		return
 	}
 
@@ -504,10 +521,8 @@ func (h *Handler) GetUserRepoRanking(c *gin.Context) {
 		respondError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": rankings,
-	})
+	setPagination(c, limit, len(rankings))
+	respondData(c, "ranking-repos-"+rankingTypeStr, rankings)
 }
 
 // parseIntQuery parses an integer query parameter with a default value
@@ -523,16 +538,22 @@ func parseIntQuery(c *gin.Context, key string, defaultValue int) int {
 	return value
 }
 
-// HealthCheck returns the health status of the API
+// HealthCheck returns the health status and version of the API
 // GET /health
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
+		"status":  "ok",
+		"version": version.Get().Version,
 	})
 }
 
-// parseTimeRange parses time range from query parameters
+// parseTimeRange parses time range from query parameters. The result is kept in the context
+// so that middleware, handlers and the v2 meta all see the same range.
 func parseTimeRange(c *gin.Context) domain.TimeRange {
+	if timeRange, ok := timeRangeFromContext(c); ok {
+		return timeRange
+	}
+
 	// Default to last 30 days
 	now := time.Now()
 	defaultStart := now.AddDate(0, -1, 0)
@@ -568,42 +589,57 @@ func parseTimeRange(c *gin.Context) domain.TimeRange {
 		granularity = "day"
 	}
 
-	return domain.TimeRange{
+	timeRange := domain.TimeRange{
 		Start:       start,
 		End:         end,
 		Granularity: granularity,
 	}
+	c.Set(timeRangeContextKey, timeRange)
+	return timeRange
+}
+
+// timeRangeFromContext returns the time range parsed for the request, if any
+func timeRangeFromContext(c *gin.Context) (domain.TimeRange, bool) {
+	value, ok := c.Get(timeRangeContextKey)
+	if !ok {
+		return domain.TimeRange{}, false
+	}
+	timeRange, ok := value.(domain.TimeRange)
+	return timeRange, ok
 }
 
 // respondError sends an error response
 func respondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+
+	status := http.StatusInternalServerError
+	code := string(apperrors.ErrCodeInternal)
+	message := err.Error()
 	if appErr, ok := err.(*apperrors.AppError); ok {
-		status := http.StatusInternalServerError
-		switch appErr.Code {
-		case apperrors.ErrCodeNotFound:
-			status = http.StatusNotFound
-		case apperrors.ErrCodeUnauthorized:
-			status = http.StatusUnauthorized
-		case apperrors.ErrCodeForbidden:
-			status = http.StatusForbidden
-		case apperrors.ErrCodeBadRequest:
-			status = http.StatusBadRequest
-		case apperrors.ErrCodeRateLimited:
-			status = http.StatusTooManyRequests
-		}
-		c.JSON(status, gin.H{
-			"error": gin.H{
-				"code":    appErr.Code,
-				"message": appErr.Message,
+		status = errorStatus(appErr.Code)
+		code = string(appErr.Code)
+		message = appErr.Message
+	}
+
+	if isV2(c) {
+		c.JSON(status, ErrorResponse{
+			Error: ErrorBody{
+				Type:      errorType(status),
+				Code:      code,
+				Status:    status,
+				Message:   message,
+				RequestID: RequestIDFromContext(c),
 			},
+			Meta: newMeta(c),
 		})
 		return
 	}
 
-	c.JSON(http.StatusInternalServerError, gin.H{
+	c.JSON(status, gin.H{
 		"error": gin.H{
-			"code":    "INTERNAL_ERROR",
-			"message": err.Error(),
+			"code":       code,
+			"message":    message,
+			"request_id": RequestIDFromContext(c),
 		},
 	})
 }
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// stubAggregator answers the watermark, time series and repository member queries with fixed
+// results, recording the arguments of the last call. Other methods of the embedded nil
+// Aggregator panic if called.
+type stubAggregator struct {
+	aggregator.Aggregator
+
+	timeSeries *domain.DetailedTimeSeriesData
+	members    []*domain.MemberMetrics
+	err        error
+
+	called    string // the method called last
+	owner     string
+	repo      string
+	member    string
+	timeRange domain.TimeRange
+}
+
+func (s *stubAggregator) GetWatermark(ctx context.Context, owner string) (aggregator.Watermark, error) {
+	return aggregator.Watermark{}, nil
+}
+
+func (s *stubAggregator) GetOrgTimeSeries(ctx context.Context, org string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	s.called, s.owner, s.timeRange = "GetOrgTimeSeries", org, timeRange
+	return s.timeSeries, s.err
+}
+
+func (s *stubAggregator) GetRepoTimeSeries(ctx context.Context, org, repo string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	s.called, s.owner, s.repo, s.timeRange = "GetRepoTimeSeries", org, repo, timeRange
+	return s.timeSeries, s.err
+}
+
+func (s *stubAggregator) GetMemberTimeSeries(ctx context.Context, org, member string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	s.called, s.owner, s.member, s.timeRange = "GetMemberTimeSeries", org, member, timeRange
+	return s.timeSeries, s.err
+}
+
+func (s *stubAggregator) GetRepoMembersMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
+	s.called, s.owner, s.repo, s.timeRange = "GetRepoMembersMetrics", org, repo, timeRange
+	return s.members, s.err
+}
+
+// newTestRouter serves the organization and user routes of handler under /api/v1
+func newTestRouter(agg aggregator.Aggregator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewHandler(agg, nil)
+	registerOrgRoutes(router.Group("/api/v1/orgs/:org"), handler)
+	registerUserRoutes(router.Group("/api/v1/users/:user"), handler)
+	return router
+}
+
+// get serves a GET request for path and returns the response
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestDetailedTimeSeriesRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		method string
+		owner  string
+		repo   string
+		member string
+	}{
+		{"org", "/api/v1/orgs/acme/metrics/timeseries/detailed", "GetOrgTimeSeries", "acme", "", ""},
+		{"org repo", "/api/v1/orgs/acme/repos/api/metrics/timeseries", "GetRepoTimeSeries", "acme", "api", ""},
+		{"org member", "/api/v1/orgs/acme/members/alice/metrics/timeseries", "GetMemberTimeSeries", "acme", "", "alice"},
+		{"user", "/api/v1/users/bob/metrics/timeseries/detailed", "GetOrgTimeSeries", "bob", "", ""},
+		{"user repo", "/api/v1/users/bob/repos/dotfiles/metrics/timeseries", "GetRepoTimeSeries", "bob", "dotfiles", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			agg := &stubAggregator{timeSeries: &domain.DetailedTimeSeriesData{
+				Granularity: "week",
+				DataPoints:  []domain.DetailedTimeSeriesMetric{{Timestamp: point, Commits: 3, PRs: 1, Additions: 10, Deletions: 2}},
+			}}
+
+			w := get(newTestRouter(agg), tt.path+"?start=2024-01-01&end=2024-01-31&granularity=week")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+			}
+			if agg.called != tt.method || agg.owner != tt.owner || agg.repo != tt.repo || agg.member != tt.member {
+				t.Errorf("called %s(%q, repo %q, member %q), want %s(%q, repo %q, member %q)",
+					agg.called, agg.owner, agg.repo, agg.member, tt.method, tt.owner, tt.repo, tt.member)
+			}
+			wantRange := domain.TimeRange{
+				Start:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				End:         time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+				Granularity: "week",
+			}
+			if !agg.timeRange.Start.Equal(wantRange.Start) || !agg.timeRange.End.Equal(wantRange.End) || agg.timeRange.Granularity != wantRange.Granularity {
+				t.Errorf("time range = %+v, want %+v", agg.timeRange, wantRange)
+			}
+
+			var body struct {
+				Data domain.DetailedTimeSeriesData `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode the response: %v", err)
+			}
+			if body.Data.Granularity != "week" || len(body.Data.DataPoints) != 1 {
+				t.Fatalf("data = %+v, want the week granularity with 1 data point", body.Data)
+			}
+			if p := body.Data.DataPoints[0]; !p.Timestamp.Equal(point) || p.Commits != 3 || p.PRs != 1 || p.Additions != 10 || p.Deletions != 2 {
+				t.Errorf("data point = %+v", p)
+			}
+		})
+	}
+}
+
+func TestDetailedTimeSeriesDefaults(t *testing.T) {
+	agg := &stubAggregator{timeSeries: &domain.DetailedTimeSeriesData{Granularity: "day"}}
+
+	before := time.Now()
+	w := get(newTestRouter(agg), "/api/v1/orgs/acme/metrics/timeseries/detailed?granularity=hour")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	if agg.timeRange.Granularity != "day" {
+		t.Errorf("granularity = %q, want an unknown granularity to fall back to day", agg.timeRange.Granularity)
+	}
+	if agg.timeRange.End.Before(before) || !agg.timeRange.Start.Equal(agg.timeRange.End.AddDate(0, -1, 0)) {
+		t.Errorf("time range = %v - %v, want the last month", agg.timeRange.Start, agg.timeRange.End)
+	}
+}
+
+func TestRepoMembersRoutes(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		owner string
+		repo  string
+	}{
+		{"org", "/api/v1/orgs/acme/repos/api/members/metrics", "acme", "api"},
+		{"user", "/api/v1/users/bob/repos/dotfiles/members/metrics", "bob", "dotfiles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := &stubAggregator{members: []*domain.MemberMetrics{
+				{Member: "alice", Commits: 5, PRs: 2, Additions: 40, Deletions: 4},
+				{Member: "carol", Commits: 1, Deploys: 1},
+			}}
+
+			w := get(newTestRouter(agg), tt.path+"?start=2024-02-01&end=2024-02-29")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+			}
+			if agg.called != "GetRepoMembersMetrics" || agg.owner != tt.owner || agg.repo != tt.repo {
+				t.Errorf("called %s(%q, %q), want GetRepoMembersMetrics(%q, %q)", agg.called, agg.owner, agg.repo, tt.owner, tt.repo)
+			}
+			if want := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC); !agg.timeRange.Start.Equal(want) {
+				t.Errorf("start = %v, want %v", agg.timeRange.Start, want)
+			}
+
+			var body struct {
+				Data []*domain.MemberMetrics `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode the response: %v", err)
+			}
+			if len(body.Data) != 2 || body.Data[0].Member != "alice" || body.Data[0].Commits != 5 || body.Data[1].Member != "carol" || body.Data[1].Deploys != 1 {
+				t.Errorf("data = %+v", body.Data)
+			}
+		})
+	}
+}
+
+func TestRepoMembersCSV(t *testing.T) {
+	agg := &stubAggregator{members: []*domain.MemberMetrics{{Member: "alice", Commits: 5, PRs: 2, Additions: 40, Deletions: 4}}}
+
+	w := get(newTestRouter(agg), "/api/v1/orgs/acme/repos/api/members/metrics?format=csv")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, "api-members") {
+		t.Errorf("Content-Disposition = %q, want a file named after the repository", disposition)
+	}
+	want := "member,commits,prs,additions,deletions,deploys\nalice,5,2,40,4,0\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestRouteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		err    error
+		status int
+	}{
+		{"time series not found", "/api/v1/orgs/acme/repos/missing/metrics/timeseries", apperrors.NewNotFoundError("repository"), http.StatusNotFound},
+		{"repo members failure", "/api/v1/orgs/acme/repos/api/members/metrics", apperrors.NewInternalError("failed to query", context.DeadlineExceeded), http.StatusInternalServerError},
+		{"unknown format", "/api/v1/orgs/acme/metrics/timeseries/detailed?format=pdf", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := &stubAggregator{timeSeries: &domain.DetailedTimeSeriesData{}, err: tt.err}
+
+			w := get(newTestRouter(agg), tt.path)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code == "" {
+				t.Errorf("body = %s, want an error response", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// GetHeatmap returns event counts per weekday and hour of day for an owner, or for one of
+// its repositories or members. type selects the events (commit, pull_request or deploy) and
+// tz the IANA time zone hours are taken in (UTC by default).
+// GET /api/v1/orgs/:org/metrics/heatmap
+// GET /api/v1/orgs/:org/repos/:repo/metrics/heatmap
+// GET /api/v1/orgs/:org/members/:member/metrics/heatmap
+// GET /api/v1/users/:user/metrics/heatmap
+// GET /api/v1/users/:user/repos/:repo/metrics/heatmap
+func (h *Handler) GetHeatmap(c *gin.Context) {
+	owner := c.Param("org")
+	if owner == "" {
+		owner = c.Param("user")
+	}
+	timeRange := parseTimeRange(c)
+
+	query := domain.HeatmapQuery{
+		Repo:       c.Param("repo"),
+		Member:     c.Param("member"),
+		MetricType: domain.MetricType(c.DefaultQuery("type", string(domain.MetricTypeCommit))),
+	}
+	switch query.MetricType {
+	case domain.MetricTypeCommit, domain.MetricTypePullRequest, domain.MetricTypeDeploy:
+	default:
+		respondError(c, apperrors.NewBadRequestError("type must be one of: commit, pull_request, deploy"))
+		return
+	}
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		respondError(c, apperrors.NewBadRequestError("tz must be an IANA time zone such as Asia/Tokyo"))
+		return
+	}
+	query.Location = loc
+
+	heatmap, err := h.aggregator.GetHeatmap(c.Request.Context(), owner, query, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	name := "heatmap"
+	if query.Repo != "" {
+		name = query.Repo + "-heatmap"
+	} else if query.Member != "" {
+		name = query.Member + "-heatmap"
+	}
+	respondData(c, name, heatmap)
+}
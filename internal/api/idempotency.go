@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+const (
+	// IdempotencyKeyHeader is the header carrying a client-chosen key for a mutating request
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength is the longest idempotency key accepted
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotentBody is the longest request body covered by the request fingerprint
+	maxIdempotentBody = maxWebhookPayload
+)
+
+// IdempotencyStore remembers the responses of mutating requests sent with an idempotency key
+// so that retries are answered without running the request again. Entries are kept in memory
+// for a fixed time; each API server instance has its own store.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotentEntry
+	lastGC  time.Time
+}
+
+// idempotentEntry is the state of a single idempotency key
+type idempotentEntry struct {
+	fingerprint [sha256.Size]byte // method, path, query and body of the first request
+	done        bool              // false while the first request is in progress
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// NewIdempotencyStore creates a store keeping responses for ttl
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotentEntry),
+		lastGC:  time.Now(),
+	}
+}
+
+// begin claims key for a request with fingerprint. It returns the entry of an earlier
+// request with the same key, or nil if the caller should run the request.
+func (s *IdempotencyStore) begin(key string, fingerprint [sha256.Size]byte) *idempotentEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastGC) > s.ttl {
+		for k, e := range s.entries {
+			if e.done && now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastGC = now
+	}
+
+	if e, ok := s.entries[key]; ok && !(e.done && now.After(e.expires)) {
+		copied := *e
+		return &copied
+	}
+	s.entries[key] = &idempotentEntry{fingerprint: fingerprint}
+	return nil
+}
+
+// finish records the response of the request holding key
+func (s *IdempotencyStore) finish(key string, status int, contentType string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.done = true
+		e.status = status
+		e.contentType = contentType
+		e.body = body
+		e.expires = time.Now().Add(s.ttl)
+	}
+}
+
+// release forgets key so that the request can be retried
+func (s *IdempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// Idempotency returns a middleware that deduplicates POST, PUT, PATCH and DELETE requests
+// sent with an Idempotency-Key header. The first request with a key runs normally and its
+// response is stored; retries with the same key and the same request are answered with the
+// stored response, while a retry sent while the first request is still running gets a 409.
+// Reusing a key for a different request is rejected. Keys are scoped to the API key of the
+// caller. Server errors are not stored, so such requests can be retried with the same key.
+// fallbackHeaders name headers used as the key when Idempotency-Key is not set, such as
+// X-GitHub-Delivery for webhook redeliveries.
+func Idempotency(store *IdempotencyStore, fallbackHeaders ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(IdempotencyKeyHeader)
+		for _, header := range fallbackHeaders {
+			if key != "" {
+				break
+			}
+			key = c.GetHeader(header)
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondError(c, apperrors.NewBadRequestError("Idempotency-Key must be at most 255 characters"))
+			c.Abort()
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			respondError(c, apperrors.NewBadRequestError("failed to read request body"))
+			c.Abort()
+			return
+		}
+
+		principal := ""
+		if apiKey, ok := APIKeyFromContext(c); ok {
+			principal = apiKey.Name
+		}
+		storeKey := principal + "\x00" + c.Request.Method + " " + c.Request.URL.Path + "\x00" + key
+
+		if prev := store.begin(storeKey, fingerprint); prev != nil {
+			switch {
+			case prev.fingerprint != fingerprint:
+				respondError(c, apperrors.NewBadRequestError("Idempotency-Key was already used for a different request"))
+			case !prev.done:
+				respondError(c, apperrors.NewConflictError("a request with this Idempotency-Key is still in progress"))
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(prev.status, prev.contentType, prev.body)
+			}
+			c.Abort()
+			return
+		}
+
+		w := &recordingResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		completed := false
+		defer func() {
+			// Release the key when the handler panics or fails so that the request can be retried
+			if !completed {
+				store.release(storeKey)
+			}
+		}()
+
+		c.Next()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		store.finish(storeKey, status, w.Header().Get("Content-Type"), w.body.Bytes())
+		completed = true
+	}
+}
+
+// requestFingerprint hashes the parts of a request that must match for a retry to be
+// answered with a stored response. The body is read and restored for the handler.
+func requestFingerprint(c *gin.Context) ([sha256.Size]byte, error) {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "?" + c.Request.URL.RawQuery + "\n"))
+
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody))
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		h.Write(body)
+		// Anything past the limit is passed on to the handler, which rejects oversized bodies
+		c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// readCloser pairs a reader with the closer of the original request body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recordingResponseWriter keeps a copy of the response body
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,326 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+const (
+	// liveWriteWait is the time allowed to write a message to a client
+	liveWriteWait = 10 * time.Second
+	// livePongWait is the time allowed to read the next pong from a client
+	livePongWait = 60 * time.Second
+	// livePingPeriod is how often clients are pinged; must be less than livePongWait
+	livePingPeriod = livePongWait * 9 / 10
+	// liveSendBuffer is the number of updates queued per client before it is dropped as too slow
+	liveSendBuffer = 8
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// CORS is open for the REST API as well; access is controlled by API keys
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// LiveUpdate is the message pushed to WebSocket clients when an owner's metrics change
+type LiveUpdate struct {
+	Type      string                `json:"type"` // "metrics"
+	Owner     string                `json:"owner"`
+	Watermark time.Time             `json:"watermark"` // the latest event created_at
+	Org       *domain.OrgMetrics    `json:"org"`
+	Repos     []*domain.RepoMetrics `json:"repos"`
+}
+
+// LiveHub pushes updated metrics to WebSocket clients. Changes are detected by polling the
+// owners' data watermarks, so collections run by other processes are picked up as well;
+// in-process ingestion can call Notify to push immediately.
+type LiveHub struct {
+	agg      aggregator.Aggregator
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu         sync.Mutex
+	subs       map[string]map[*liveClient]struct{} // owner -> subscribed clients
+	watermarks map[string]aggregator.Watermark
+	notify     chan string
+	done       chan struct{}
+}
+
+// liveClient is a single WebSocket connection
+type liveClient struct {
+	conn      *websocket.Conn
+	owners    []string
+	timeRange domain.TimeRange
+	send      chan *LiveUpdate
+}
+
+// NewLiveHub creates a hub polling subscribed owners for changes every interval
+func NewLiveHub(agg aggregator.Aggregator, interval time.Duration, logger *slog.Logger) *LiveHub {
+	return &LiveHub{
+		agg:        agg,
+		interval:   interval,
+		logger:     logger,
+		subs:       make(map[string]map[*liveClient]struct{}),
+		watermarks: make(map[string]aggregator.Watermark),
+		notify:     make(chan string, 64),
+		done:       make(chan struct{}),
+	}
+}
+
+// Notify tells the hub that new events were saved for owner
+func (h *LiveHub) Notify(owner string) {
+	select {
+	case h.notify <- owner:
+	default:
+		// The next poll will pick the change up
+	}
+}
+
+// Run polls for changes until ctx is done, then disconnects all clients
+func (h *LiveHub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	defer h.closeAll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case owner := <-h.notify:
+			h.checkOwner(ctx, owner)
+		case <-ticker.C:
+			for _, owner := range h.subscribedOwners() {
+				h.checkOwner(ctx, owner)
+			}
+		}
+	}
+}
+
+// ServeWS upgrades the request to a WebSocket subscribed to the owners given by
+// the repeatable "owner" query parameter
+// GET /ws?owner=:owner[&owner=...][&start=...&end=...]
+func (h *LiveHub) ServeWS(c *gin.Context) {
+	owners := c.QueryArray("owner")
+	if len(owners) == 0 {
+		respondError(c, apperrors.NewBadRequestError("at least one owner query parameter is required"))
+		return
+	}
+	for _, owner := range owners {
+		if !authorizeOwner(c, owner) {
+			return
+		}
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		return
+	}
+
+	client := &liveClient{
+		conn:      conn,
+		owners:    owners,
+		timeRange: parseTimeRange(c),
+		send:      make(chan *LiveUpdate, liveSendBuffer),
+	}
+	if !h.register(client) {
+		conn.Close()
+		return
+	}
+
+	go h.writePump(client)
+	go h.readPump(client)
+
+	// Initial snapshot so clients don't have to wait for the first change
+	for _, owner := range owners {
+		h.push(c.Request.Context(), client, owner, aggregator.Watermark{})
+	}
+}
+
+// register adds a client to the subscriptions of its owners; it fails once the hub has stopped
+func (h *LiveHub) register(client *liveClient) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	select {
+	case <-h.done:
+		return false
+	default:
+	}
+
+	for _, owner := range client.owners {
+		if h.subs[owner] == nil {
+			h.subs[owner] = make(map[*liveClient]struct{})
+		}
+		h.subs[owner][client] = struct{}{}
+	}
+	return true
+}
+
+// unregister removes a client and closes its send channel (once)
+func (h *LiveHub) unregister(client *liveClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	removed := false
+	for _, owner := range client.owners {
+		if _, ok := h.subs[owner][client]; ok {
+			delete(h.subs[owner], client)
+			removed = true
+		}
+		if len(h.subs[owner]) == 0 {
+			delete(h.subs, owner)
+			delete(h.watermarks, owner)
+		}
+	}
+	if removed {
+		close(client.send)
+	}
+}
+
+// closeAll disconnects every client when the hub stops
+func (h *LiveHub) closeAll() {
+	h.mu.Lock()
+	close(h.done)
+	clients := make(map[*liveClient]struct{})
+	for _, subs := range h.subs {
+		for client := range subs {
+			clients[client] = struct{}{}
+		}
+	}
+	h.mu.Unlock()
+
+	for client := range clients {
+		h.unregister(client)
+	}
+}
+
+// subscribedOwners returns the owners with at least one client
+func (h *LiveHub) subscribedOwners() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	owners := make([]string, 0, len(h.subs))
+	for owner := range h.subs {
+		owners = append(owners, owner)
+	}
+	return owners
+}
+
+// checkOwner pushes fresh metrics to the owner's clients if its watermark moved
+func (h *LiveHub) checkOwner(ctx context.Context, owner string) {
+	watermark, err := h.agg.GetWatermark(ctx, owner)
+	if err != nil {
+		h.logger.Warn("live updates: failed to get watermark", "owner", owner, "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	previous, seen := h.watermarks[owner]
+	subs := h.subs[owner]
+	clients := make([]*liveClient, 0, len(subs))
+	for client := range subs {
+		clients = append(clients, client)
+	}
+	if len(clients) > 0 {
+		h.watermarks[owner] = watermark
+	}
+	h.mu.Unlock()
+
+	if !seen || watermark.Equal(previous) {
+		// First check only records the baseline; clients got a snapshot on connect
+		return
+	}
+
+	// Changed data invalidates cached aggregates for this owner
+	h.agg.InvalidateCache(owner)
+	for _, client := range clients {
+		h.push(ctx, client, owner, watermark)
+	}
+}
+
+// push computes the client's view of owner's metrics and queues it
+func (h *LiveHub) push(ctx context.Context, client *liveClient, owner string, watermark aggregator.Watermark) {
+	org, err := h.agg.AggregateOrgMetrics(ctx, owner, client.timeRange)
+	if err != nil {
+		h.logger.Warn("live updates: failed to aggregate metrics", "owner", owner, "error", err)
+		return
+	}
+	repos, err := h.agg.GetReposMetrics(ctx, owner, client.timeRange)
+	if err != nil {
+		h.logger.Warn("live updates: failed to aggregate repository metrics", "owner", owner, "error", err)
+		return
+	}
+
+	update := &LiveUpdate{Type: "metrics", Owner: owner, Watermark: watermark.LatestEvent, Org: org, Repos: repos}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[owner][client]; !ok {
+		return
+	}
+	select {
+	case client.send <- update:
+	default:
+		// Too slow to keep up; drop the connection rather than block the hub
+		client.conn.Close()
+	}
+}
+
+// writePump sends queued updates and pings to the client
+func (h *LiveHub) writePump(client *liveClient) {
+	ticker := time.NewTicker(livePingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+
+	for {
+		select {
+		case update, ok := <-client.send:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if !ok {
+				_ = client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := client.conn.WriteJSON(update); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump discards client messages and detects disconnects
+func (h *LiveHub) readPump(client *liveClient) {
+	defer func() {
+		h.unregister(client)
+		client.conn.Close()
+	}()
+
+	client.conn.SetReadLimit(512)
+	_ = client.conn.SetReadDeadline(time.Now().Add(livePongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+	for {
+		if _, _, err := client.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
@@ -1,13 +1,65 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger returns a middleware that logs requests
-func Logger() gin.HandlerFunc {
+// RequestIDHeader is the header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDContextKey is the gin context key holding the request ID
+const requestIDContextKey = "requestID"
+
+// RequestID returns a middleware that propagates the client's X-Request-ID or generates one,
+// echoes it in the response and makes it available to handlers and the logger
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDContextKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID of the current request, or "" if RequestID is not in use
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random 128-bit request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Logger returns a middleware that logs each request as a structured record
+func Logger(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -15,32 +67,127 @@ func Logger() gin.HandlerFunc {
 
 		c.Next()
 
-		latency := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
-		statusCode := c.Writer.Status()
-
 		if raw != "" {
-			path = path + "?" + raw
+			path = path + "?" + redactQuery(raw)
 		}
 
-		_, _ = gin.DefaultWriter.Write([]byte(
-			time.Now().Format("2006/01/02 - 15:04:05") +
-				" | " + method +
-				" | " + path +
-				" | " + clientIP +
-				" | " + latency.String() +
-				" | " + string(rune(statusCode)) + "\n",
-		))
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("request_id", RequestIDFromContext(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if key, ok := APIKeyFromContext(c); ok {
+			attrs = append(attrs, slog.String("api_key", key.Name))
+		}
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// redactQuery hides credentials passed as query parameters
+func redactQuery(raw string) string {
+	if !strings.Contains(raw, "access_token=") {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return "[unparsable query]"
 	}
+	values.Set("access_token", "REDACTED")
+	return values.Encode()
+}
+
+// CORSOrigins is the set of origins allowed to make cross-origin requests. It may be
+// replaced while the server is running.
+type CORSOrigins struct {
+	mu      sync.RWMutex
+	any     bool
+	origins map[string]bool
+}
+
+// NewCORSOrigins creates an origin set; "*" allows every origin
+func NewCORSOrigins(origins []string) *CORSOrigins {
+	o := &CORSOrigins{}
+	o.Set(origins)
+	return o
 }
 
-// CORS returns a middleware that handles CORS
-func CORS() gin.HandlerFunc {
+// Set replaces the allowed origins
+func (o *CORSOrigins) Set(origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	anyOrigin := false
+	for _, origin := range origins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.any = anyOrigin
+	o.origins = allowed
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request from origin,
+// or "" when the origin is not allowed
+func (o *CORSOrigins) allowOrigin(origin string) string {
+	if o == nil {
+		return "*"
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.any {
+		return "*"
+	}
+	if origin != "" && o.origins[origin] {
+		return origin
+	}
+	return ""
+}
+
+// CORS returns a middleware that handles CORS for the given origins (every origin if nil)
+func CORS(origins *CORSOrigins) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		allowOrigin := origins.allowOrigin(c.GetHeader("Origin"))
+		if allowOrigin != "*" {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if allowOrigin == "" {
+			// Not an allowed origin: let the request through without CORS headers so the
+			// browser blocks the response
+			if c.Request.Method == "OPTIONS" {
+				c.AbortWithStatus(204)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+const (
+	// overviewTopN is the number of members and repositories ranked in an overview
+	overviewTopN = 5
+	// overviewSparklineDays is the number of days of an overview's sparkline
+	overviewSparklineDays = 30
+)
+
+// GetOrgOverview returns an owner's metrics, its top members and repositories by commits,
+// its latest collection batch and a daily sparkline of the last 30 days of the range, the
+// data a dashboard home page would otherwise fetch with several requests
+// GET /api/v1/orgs/:org/overview
+// GET /api/v1/users/:user/overview
+func (h *Handler) GetOrgOverview(c *gin.Context) {
+	owner := c.Param("org")
+	if owner == "" {
+		owner = c.Param("user")
+	}
+	timeRange := parseTimeRange(c)
+	sparklineRange := domain.TimeRange{
+		Start:       timeRange.End.AddDate(0, 0, -overviewSparklineDays),
+		End:         timeRange.End,
+		Granularity: "day",
+	}
+
+	overview := &domain.OrgOverview{TimeRange: timeRange}
+	parts := []func(ctx context.Context) error{
+		func(ctx context.Context) (err error) {
+			overview.Metrics, err = h.aggregator.AggregateOrgMetrics(ctx, owner, timeRange)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			overview.TopMembers, err = h.aggregator.GetMemberRanking(ctx, owner, domain.RankingTypeCommits, timeRange, overviewTopN)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			overview.TopRepos, err = h.aggregator.GetRepoRanking(ctx, owner, domain.RankingTypeCommits, timeRange, overviewTopN)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			overview.LatestCollection, err = h.latestCollection(ctx, owner)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			overview.Sparkline, err = h.aggregator.GetOrgTimeSeries(ctx, owner, sparklineRange)
+			return err
+		},
+	}
+	err := storage.ForEachParallel(c.Request.Context(), len(parts), len(parts), func(ctx context.Context, i int) error {
+		return parts[i](ctx)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	// Dashboards iterate over the rankings, which are empty rather than missing
+	if overview.TopMembers == nil {
+		overview.TopMembers = []*domain.MemberRanking{}
+	}
+	if overview.TopRepos == nil {
+		overview.TopRepos = []*domain.RepoRanking{}
+	}
+
+	respond(c, http.StatusOK, overview)
+}
+
+// collectionState is the ETag state of overviews: their latest collection batch changes as
+// a collection progresses, without moving the owner's data watermark
+func (h *Handler) collectionState(param string) ETagState {
+	return func(c *gin.Context) (string, error) {
+		latest, err := h.latestCollection(c.Request.Context(), c.Param(param))
+		if err != nil || latest == nil {
+			return "", err
+		}
+		p := latest.Progress
+		return fmt.Sprintf("%s|%s|%d|%d|%d|%d|%d|%d",
+			latest.Batch.ID, latest.Batch.Status, latest.Batch.UpdatedAt.UnixNano(),
+			p.Total, p.Pending, p.Processing, p.Completed, p.Failed), nil
+	}
+}
+
+// latestCollection returns the owner's latest collection batch with its progress, or nil if
+// the owner was never collected
+func (h *Handler) latestCollection(ctx context.Context, owner string) (*domain.CollectionBatchDetail, error) {
+	batches, err := h.storage.ListBatches(ctx, domain.BatchFilter{Owner: owner, Limit: 1})
+	if err != nil || len(batches) == 0 {
+		return nil, err
+	}
+	statuses, err := h.storage.GetBatchRepoStatuses(ctx, batches[0].ID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CollectionBatchDetail{
+		Batch:    batches[0],
+		Progress: domain.SummarizeBatchRepos(statuses),
+	}, nil
+}
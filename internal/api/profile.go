@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// profileRankingTypes are the rankings a member profile places the member in
+var profileRankingTypes = []domain.RankingType{
+	domain.RankingTypeCommits,
+	domain.RankingTypePRs,
+	domain.RankingTypeCodeChanges,
+	domain.RankingTypeDeploys,
+}
+
+// GetMemberProfile returns a member's lifetime and range metrics, their activity in each
+// repository, a daily sparkline of the last 30 days of the range, their position in each
+// ranking and their activity streaks, the data an individual contributor page needs
+// GET /api/v1/orgs/:org/members/:member/profile
+func (h *Handler) GetMemberProfile(c *gin.Context) {
+	org := c.Param("org")
+	member := c.Param("member")
+	timeRange := parseTimeRange(c)
+	lifetimeRange := domain.TimeRange{End: timeRange.End, Granularity: "day"}
+	sparklineRange := domain.TimeRange{
+		Start:       timeRange.End.AddDate(0, 0, -overviewSparklineDays),
+		End:         timeRange.End,
+		Granularity: "day",
+	}
+
+	profile := &domain.MemberProfile{Member: member, TimeRange: timeRange}
+	var members []*domain.MemberMetrics
+	var history *domain.DetailedTimeSeriesData
+	parts := []func(ctx context.Context) error{
+		func(ctx context.Context) (err error) {
+			profile.Lifetime, err = h.aggregator.AggregateMemberMetrics(ctx, org, member, lifetimeRange)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			profile.Metrics, err = h.aggregator.AggregateMemberMetrics(ctx, org, member, timeRange)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			profile.Repos, err = h.aggregator.GetMemberReposMetrics(ctx, org, member, timeRange)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			profile.TimeSeries, err = h.aggregator.GetMemberTimeSeries(ctx, org, member, sparklineRange)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			history, err = h.aggregator.GetMemberTimeSeries(ctx, org, member, lifetimeRange)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			members, err = h.aggregator.GetMembersMetrics(ctx, org, timeRange)
+			return err
+		},
+	}
+	err := storage.ForEachParallel(c.Request.Context(), len(parts), len(parts), func(ctx context.Context, i int) error {
+		return parts[i](ctx)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	// Aliases resolve to the member they belong to
+	profile.Member = profile.Metrics.Member
+
+	profile.Rankings, err = h.memberRankPositions(c.Request.Context(), org, profile.Member, len(members), timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	profile.Streak = domain.NewMemberStreak(history, timeRange.End)
+	if profile.Repos == nil {
+		profile.Repos = []*domain.RepoMetrics{}
+	}
+
+	respond(c, http.StatusOK, profile)
+}
+
+// memberRankPositions returns where member ranks in each profile ranking among the members
+// of an owner; members is how many members were active in the range
+func (h *Handler) memberRankPositions(ctx context.Context, org, member string, members int, timeRange domain.TimeRange) ([]domain.MemberRankPosition, error) {
+	positions := make([]domain.MemberRankPosition, len(profileRankingTypes))
+	err := storage.ForEachParallel(ctx, len(profileRankingTypes), len(profileRankingTypes), func(ctx context.Context, i int) error {
+		positions[i] = domain.MemberRankPosition{Type: profileRankingTypes[i]}
+		if members == 0 {
+			return nil
+		}
+		rankings, err := h.aggregator.GetMemberRanking(ctx, org, profileRankingTypes[i], timeRange, members)
+		if err != nil {
+			return err
+		}
+		positions[i].Of = len(rankings)
+		for _, r := range rankings {
+			if strings.EqualFold(r.Member, member) {
+				positions[i].Rank, positions[i].Value = r.Rank, r.Value
+				break
+			}
+		}
+		return nil
+	})
+	return positions, err
+}
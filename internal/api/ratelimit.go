@@ -0,0 +1,178 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// RateLimiter is a per-client token bucket rate limiter
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+	buckets map[string]*tokenBucket
+	lastGC  time.Time
+}
+
+// tokenBucket is the state of a single client's bucket
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// bucketIdleTTL is how long an unused bucket is kept; a full bucket carries no state worth keeping
+const bucketIdleTTL = 10 * time.Minute
+
+// NewRateLimiter creates a rate limiter allowing rps requests per second per client,
+// with bursts of up to burst requests. An rps of 0 lets every request through.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	l := &RateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		lastGC:  time.Now(),
+	}
+	l.SetLimits(rps, burst)
+	return l
+}
+
+// SetLimits changes the rate and burst size; existing buckets are capped at the new burst
+// size on their next request. An rps of 0 disables rate limiting.
+func (l *RateLimiter) SetLimits(rps float64, burst int) {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rps)))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rps
+	l.burst = float64(burst)
+}
+
+// Limit returns the burst size, and false when rate limiting is disabled
+func (l *RateLimiter) Limit() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.burst), l.rate > 0
+}
+
+// Allow takes a token from the client's bucket. It returns whether the request is allowed,
+// the tokens remaining and, when denied, how long until a token is available.
+func (l *RateLimiter) Allow(client string) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true, int(l.burst), 0
+	}
+
+	now := time.Now()
+	l.gc(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// Wait returns how long until the client's bucket has a token, without taking one. It is
+// 0 when a request would be allowed.
+func (l *RateLimiter) Wait(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[client]
+	if l.rate <= 0 || !ok {
+		return 0
+	}
+	tokens := math.Min(l.burst, b.tokens+time.Since(b.last).Seconds()*l.rate)
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / l.rate * float64(time.Second))
+}
+
+// gc drops buckets that have been idle long enough to be full again; caller must hold mu
+func (l *RateLimiter) gc(now time.Time) {
+	if now.Sub(l.lastGC) < bucketIdleTTL {
+		return
+	}
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTTL {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastGC = now
+}
+
+// RateLimit returns a middleware that limits requests per API key, or per client IP
+// when the request is not authenticated
+func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		if key, ok := APIKeyFromContext(c); ok {
+			client = "key:" + key.Name
+		}
+
+		limit, enabled := limiter.Limit()
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		allowed, remaining, retryAfter := limiter.Allow(client)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			rejectRateLimited(c, retryAfter)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitRejected returns a middleware, installed in front of Auth, that limits the
+// requests Auth rejects as unauthenticated per client IP. Each 401 response takes a token
+// from the IP's bucket, and once it is empty every request from the IP is refused until it
+// refills, so keys can't be guessed at an unlimited rate. Other requests take nothing,
+// leaving authenticated clients behind a shared IP to the per-key limit of RateLimit.
+func RateLimitRejected(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "rejected:" + c.ClientIP()
+		if retryAfter := limiter.Wait(client); retryAfter > 0 {
+			rejectRateLimited(c, retryAfter)
+			return
+		}
+
+		c.Next()
+
+		// A valid key refused an owner it isn't granted (403) is not guessing keys
+		if c.Writer.Status() == http.StatusUnauthorized {
+			limiter.Allow(client)
+		}
+	}
+}
+
+// rejectRateLimited aborts the request with a rate limited error
+func rejectRateLimited(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	respondError(c, apperrors.NewRateLimitedError("too many requests"))
+	c.Abort()
+}
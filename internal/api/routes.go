@@ -1,82 +1,364 @@
 package api
 
 import (
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v55/github"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/monitoring"
 )
 
+// RouteOption configures optional router behavior
+type RouteOption func(*routeConfig)
+
+// routeConfig holds the optional router settings
+type routeConfig struct {
+	auth    *Authenticator // nil when authentication is disabled
+	limiter *RateLimiter   // nil when rate limiting is disabled
+	logger  *slog.Logger
+	gzip    bool
+	live    *LiveHub     // nil when live updates are disabled
+	cors    *CORSOrigins // nil allows every origin
+	proxies []string     // trusted reverse proxies; nil uses the remote address as the client IP
+	reload  ReloadFunc   // nil disables /admin/reload
+	audit   AuditStore   // nil disables the audit log
+	tracing string       // service name of request spans; empty disables tracing
+
+	idempotency *IdempotencyStore // nil disables Idempotency-Key handling
+
+	webhookSecret []byte             // GitHub webhook ingestion is disabled when empty
+	slack         SlackCommandConfig // the Slack slash command is disabled without a secret
+}
+
+// WithAuth requires a valid API key on all /api routes
+func WithAuth(auth *Authenticator) RouteOption {
+	return func(rc *routeConfig) {
+		rc.auth = auth
+	}
+}
+
+// WithRateLimit limits the request rate of each client on all /api routes
+func WithRateLimit(limiter *RateLimiter) RouteOption {
+	return func(rc *routeConfig) {
+		rc.limiter = limiter
+	}
+}
+
+// WithLogger sets the logger used for request logs (slog.Default() if not set)
+func WithLogger(logger *slog.Logger) RouteOption {
+	return func(rc *routeConfig) {
+		rc.logger = logger
+	}
+}
+
+// WithGzip enables gzip compression of JSON and text responses
+func WithGzip() RouteOption {
+	return func(rc *routeConfig) {
+		rc.gzip = true
+	}
+}
+
+// WithLiveUpdates serves WebSocket live metric updates from hub on /ws
+func WithLiveUpdates(hub *LiveHub) RouteOption {
+	return func(rc *routeConfig) {
+		rc.live = hub
+	}
+}
+
+// WithGitHubWebhook accepts GitHub webhook deliveries signed with secret on
+// /api/v1/webhooks/github. The endpoint is authenticated by the signature, not by API keys.
+func WithGitHubWebhook(secret string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.webhookSecret = []byte(secret)
+	}
+}
+
+// WithSlackCommands answers Slack slash commands signed with the Slack app's signing secret
+// on /api/v1/slack/commands. The endpoint is authenticated by the signature, not by API keys.
+func WithSlackCommands(slack SlackCommandConfig) RouteOption {
+	return func(rc *routeConfig) {
+		rc.slack = slack
+	}
+}
+
+// WithCORSOrigins restricts cross-origin requests to origins
+func WithCORSOrigins(origins *CORSOrigins) RouteOption {
+	return func(rc *routeConfig) {
+		rc.cors = origins
+	}
+}
+
+// WithTrustedProxies takes the client IP of requests from proxies, IP addresses or CIDR
+// ranges, from their X-Forwarded-For or X-Real-IP header. Without it the headers are
+// ignored, so clients can't choose the IP rate limits and the audit log see.
+func WithTrustedProxies(proxies []string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.proxies = proxies
+	}
+}
+
+// WithReload serves POST /api/v1/admin/reload, which calls reload to re-read the
+// configuration. The endpoint is only registered when authentication is enabled.
+func WithReload(reload ReloadFunc) RouteOption {
+	return func(rc *routeConfig) {
+		rc.reload = reload
+	}
+}
+
+// WithAuditLog records every /api request in store
+func WithAuditLog(store AuditStore) RouteOption {
+	return func(rc *routeConfig) {
+		rc.audit = store
+	}
+}
+
+// WithTracing starts an OpenTelemetry span for every request, continuing the trace of
+// the incoming traceparent header if any
+func WithTracing(serviceName string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.tracing = serviceName
+	}
+}
+
+// WithIdempotency deduplicates mutating requests sent with an Idempotency-Key header, and
+// GitHub webhook redeliveries by their delivery ID, using store
+func WithIdempotency(store *IdempotencyStore) RouteOption {
+	return func(rc *routeConfig) {
+		rc.idempotency = store
+	}
+}
+
 // SetupRoutes sets up the API routes
-func SetupRoutes(handler *Handler) *gin.Engine {
+func SetupRoutes(handler *Handler, opts ...RouteOption) *gin.Engine {
+	rc := &routeConfig{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(rc)
+	}
+
 	router := gin.New()
+	// gin trusts the forwarding headers of every peer by default
+	if err := router.SetTrustedProxies(rc.proxies); err != nil {
+		rc.logger.Error("invalid trusted proxies; using the remote address as the client IP", "error", err)
+		_ = router.SetTrustedProxies(nil)
+	}
 
 	// Middleware
+	if rc.tracing != "" {
+		router.Use(otelgin.Middleware(rc.tracing))
+	}
+	router.Use(RequestID())
+	router.Use(Logger(rc.logger))
 	router.Use(Recovery())
-	router.Use(CORS())
-	router.Use(gin.Logger())
+	router.Use(CORS(rc.cors))
+	router.Use(Metrics())
+	if rc.gzip {
+		router.Use(Gzip())
+	}
 
 	// Health check
 	router.GET("/health", handler.HealthCheck)
 
-	// API v1
-	v1 := router.Group("/api/v1")
-	{
-		// Organization endpoints
-		orgs := v1.Group("/orgs/:org")
-		{
-			// Organization metrics
-			orgs.GET("/metrics", handler.GetOrgMetrics)
-			orgs.GET("/metrics/timeseries", handler.GetTimeSeriesMetrics)
-			orgs.GET("/metrics/timeseries/detailed", handler.GetOrgTimeSeriesDetailed)
-
-			// Members metrics
-			members := orgs.Group("/members")
-			{
-				members.GET("/metrics", handler.GetMembersMetrics)
-				members.GET("/:member/metrics", handler.GetMemberMetrics)
-				members.GET("/:member/metrics/timeseries", handler.GetMemberTimeSeriesDetailed)
-			}
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(monitoring.Handler()))
 
-			// Repositories metrics
-			repos := orgs.Group("/repos")
-			{
-				repos.GET("/metrics", handler.GetReposMetrics)
-				repos.GET("/:repo/metrics", handler.GetRepoMetrics)
-				repos.GET("/:repo/metrics/timeseries", handler.GetRepoTimeSeriesDetailed)
-				repos.GET("/:repo/members/metrics", handler.GetRepoMembersMetrics)
+	// Live metric updates (WebSocket)
+	if rc.live != nil {
+		ws := router.Group("/ws")
+		if rc.auth != nil {
+			if rc.limiter != nil {
+				ws.Use(RateLimitRejected(rc.limiter))
 			}
+			ws.Use(Auth(rc.auth))
+		}
+		ws.GET("", rc.live.ServeWS)
+	}
 
-			// Rankings
-			rankings := orgs.Group("/rankings")
-			{
-				rankings.GET("/members/:type", handler.GetMemberRanking)
-				rankings.GET("/repos/:type", handler.GetRepoRanking)
-			}
+	// GitHub webhook ingestion; registered outside the v1 group so API key auth doesn't apply
+	if len(rc.webhookSecret) > 0 {
+		webhook := []gin.HandlerFunc{handler.GitHubWebhook(rc.webhookSecret, rc.live)}
+		if rc.idempotency != nil {
+			// Redeliveries keep the delivery ID of the original delivery
+			webhook = append([]gin.HandlerFunc{Idempotency(rc.idempotency, github.DeliveryIDHeader)}, webhook...)
 		}
+		router.POST("/api/v1/webhooks/github", webhook...)
+	}
 
-		// User endpoints
-		users := v1.Group("/users/:user")
-		{
-			// User metrics (same as org metrics, but for user account)
-			users.GET("/metrics", handler.GetUserMetrics)
-			users.GET("/metrics/timeseries", handler.GetUserTimeSeriesMetrics)
-			users.GET("/metrics/timeseries/detailed", handler.GetUserTimeSeriesDetailed)
-
-			// Repositories metrics
-			repos := users.Group("/repos")
-			{
-				repos.GET("/metrics", handler.GetUserReposMetrics)
-				repos.GET("/:repo/metrics", handler.GetUserRepoMetrics)
-				repos.GET("/:repo/metrics/timeseries", handler.GetUserRepoTimeSeriesDetailed)
-				repos.GET("/:repo/members/metrics", handler.GetUserRepoMembersMetrics)
-			}
+	// Slack slash commands; authenticated by the request signature like the GitHub webhook
+	if rc.slack.Secret != "" {
+		router.POST("/api/v1/slack/commands", handler.SlackCommand(rc.slack))
+	}
 
-			// Rankings
-			rankings := users.Group("/rankings")
-			{
-				rankings.GET("/members/:type", handler.GetUserMemberRanking)
-				rankings.GET("/repos/:type", handler.GetUserRepoRanking)
-			}
+	// API v1
+	registerAPIRoutes(router.Group("/api/v1"), handler, rc)
+
+	// API v2: the same endpoints, with Envelope / ErrorResponse bodies
+	registerAPIRoutes(router.Group("/api/v2", APIVersion(2)), handler, rc)
+
+	return router
+}
+
+// registerAPIRoutes registers the versioned API endpoints on an /api/vN group
+func registerAPIRoutes(api *gin.RouterGroup, handler *Handler, rc *routeConfig) {
+	if rc.audit != nil {
+		// Before Auth so that rejected requests are recorded as well
+		api.Use(Audit(rc.audit, rc.logger))
+	}
+	if rc.auth != nil {
+		if rc.limiter != nil {
+			// RateLimit runs after Auth, so the requests Auth rejects are limited here
+			api.Use(RateLimitRejected(rc.limiter))
 		}
+		api.Use(Auth(rc.auth))
+	}
+	if rc.limiter != nil {
+		// After Auth so that authenticated clients are limited per key rather than per IP
+		api.Use(RateLimit(rc.limiter))
+	}
+	if rc.idempotency != nil {
+		// After Auth so that keys are scoped to the caller
+		api.Use(Idempotency(rc.idempotency))
 	}
 
-	return router
+	// Organization endpoints
+	registerOrgRoutes(api.Group("/orgs/:org", AuthorizeOwner("org")), handler)
+
+	// Collection batches
+	registerCollectionRoutes(api.Group("/collections"), handler)
+
+	// User endpoints
+	registerUserRoutes(api.Group("/users/:user", AuthorizeOwner("user")), handler)
+
+	// Data management; admin scope keys only, not confined to a workspace. Without
+	// authentication there are no admin keys, so the endpoints aren't served at all.
+	if rc.auth != nil {
+		admin := api.Group("/admin", RequireScope(domain.APIKeyScopeAdmin), RequireDeploymentKey())
+		admin.POST("/events/purge", handler.PurgeEvents)
+		admin.DELETE("/owners/:owner/repos/:repo", handler.DeleteRepoData)
+		admin.DELETE("/owners/:owner/members/:member", handler.DeleteMemberData)
+		admin.GET("/owners/:owner/deploy-definitions", handler.ListDeployDefinitions)
+		admin.GET("/owners/:owner/repos/:repo/deploy-definition", handler.GetDeployDefinition)
+		admin.PUT("/owners/:owner/repos/:repo/deploy-definition", handler.SetDeployDefinition)
+		admin.DELETE("/owners/:owner/repos/:repo/deploy-definition", handler.DeleteDeployDefinition)
+		admin.POST("/migrate", handler.RunMigrations)
+		admin.GET("/stats", handler.GetStorageStats)
+		admin.POST("/cache/invalidate", handler.InvalidateCache)
+		admin.GET("/audit", handler.ListAuditLog)
+		admin.POST("/viewer-tokens", handler.IssueViewerToken(rc.auth))
+		if rc.reload != nil {
+			admin.POST("/reload", handler.ReloadConfig(rc.reload))
+		}
+	}
+
+	// Workspace-scoped endpoints: the same routes, restricted to the workspace's owners
+	workspaces := api.Group("/workspaces/:ws", Workspace(handler.storage))
+	{
+		workspaces.GET("", handler.GetWorkspace)
+		registerOrgRoutes(workspaces.Group("/orgs/:org", WorkspaceOwner("org"), AuthorizeOwner("org")), handler)
+		registerCollectionRoutes(workspaces.Group("/collections"), handler)
+		registerUserRoutes(workspaces.Group("/users/:user", WorkspaceOwner("user"), AuthorizeOwner("user")), handler)
+	}
+}
+
+// registerOrgRoutes registers the organization endpoints on a /orgs/:org group
+func registerOrgRoutes(orgs *gin.RouterGroup, handler *Handler) {
+	// The overview includes the latest collection batch, which its ETag covers as well
+	orgs.GET("/overview", ETagWithState(handler.aggregator, "org", handler.collectionState("org")), handler.GetOrgOverview)
+	orgs = orgs.Group("", ETag(handler.aggregator, "org"))
+
+	// Organization metrics
+	orgs.GET("/metrics", handler.GetOrgMetrics)
+	orgs.GET("/metrics/timeseries", handler.GetTimeSeriesMetrics)
+	orgs.GET("/metrics/timeseries/detailed", handler.GetOrgTimeSeriesDetailed)
+	orgs.GET("/metrics/heatmap", handler.GetHeatmap)
+	orgs.GET("/badge/:metric", handler.GetBadge)
+
+	// Members metrics
+	members := orgs.Group("/members")
+	{
+		members.GET("/metrics", handler.GetMembersMetrics)
+		members.GET("/metrics/commit-types", handler.GetMembersCommitTypes)
+		members.GET("/:member/metrics", handler.GetMemberMetrics)
+		members.GET("/:member/metrics/timeseries", handler.GetMemberTimeSeriesDetailed)
+		members.GET("/:member/repos/metrics", handler.GetMemberReposMetrics)
+		members.GET("/:member/metrics/heatmap", handler.GetHeatmap)
+		members.GET("/:member/teams", handler.GetMemberTeams)
+		members.GET("/:member/profile", handler.GetMemberProfile)
+	}
+
+	// Repositories metrics
+	repos := orgs.Group("/repos")
+	{
+		repos.GET("/metrics", handler.GetReposMetrics)
+		repos.GET("/metrics/commit-types", handler.GetReposCommitTypes)
+		repos.GET("/activity", handler.GetRepoActivity)
+		repos.GET("/:repo/metrics", handler.GetRepoMetrics)
+		repos.GET("/:repo/metrics/timeseries", handler.GetRepoTimeSeriesDetailed)
+		repos.GET("/:repo/members/metrics", handler.GetRepoMembersMetrics)
+		repos.GET("/:repo/components/metrics", handler.GetRepoComponentMetrics)
+		repos.GET("/:repo/metrics/heatmap", handler.GetHeatmap)
+	}
+
+	// Side-by-side comparison of repositories and members
+	orgs.GET("/compare", handler.Compare)
+
+	// Teams metrics
+	teams := orgs.Group("/teams")
+	{
+		teams.GET("", handler.ListTeams)
+		teams.GET("/metrics", handler.GetTeamsMetrics)
+		teams.GET("/:team/metrics", handler.GetTeamMetrics)
+		teams.GET("/:team/metrics/timeseries", handler.GetTeamTimeSeriesDetailed)
+	}
+
+	// Rankings
+	rankings := orgs.Group("/rankings")
+	{
+		rankings.GET("/members/:type", handler.GetMemberRanking)
+		rankings.GET("/repos/:type", handler.GetRepoRanking)
+		rankings.GET("/teams/:type", handler.GetTeamRanking)
+	}
+}
+
+// registerUserRoutes registers the user account endpoints on a /users/:user group
+func registerUserRoutes(users *gin.RouterGroup, handler *Handler) {
+	// The overview includes the latest collection batch, which its ETag covers as well
+	users.GET("/overview", ETagWithState(handler.aggregator, "user", handler.collectionState("user")), handler.GetOrgOverview)
+	users = users.Group("", ETag(handler.aggregator, "user"))
+
+	// User metrics (same as org metrics, but for user account)
+	users.GET("/metrics", handler.GetUserMetrics)
+	users.GET("/metrics/timeseries", handler.GetUserTimeSeriesMetrics)
+	users.GET("/metrics/timeseries/detailed", handler.GetUserTimeSeriesDetailed)
+	users.GET("/metrics/heatmap", handler.GetHeatmap)
+	users.GET("/badge/:metric", handler.GetBadge)
+
+	// Repositories metrics
+	repos := users.Group("/repos")
+	{
+		repos.GET("/metrics", handler.GetUserReposMetrics)
+		repos.GET("/metrics/commit-types", handler.GetUserReposCommitTypes)
+		repos.GET("/activity", handler.GetRepoActivity)
+		repos.GET("/:repo/metrics", handler.GetUserRepoMetrics)
+		repos.GET("/:repo/metrics/timeseries", handler.GetUserRepoTimeSeriesDetailed)
+		repos.GET("/:repo/members/metrics", handler.GetUserRepoMembersMetrics)
+		repos.GET("/:repo/components/metrics", handler.GetRepoComponentMetrics)
+		repos.GET("/:repo/metrics/heatmap", handler.GetHeatmap)
+	}
+
+	// Rankings
+	rankings := users.Group("/rankings")
+	{
+		rankings.GET("/members/:type", handler.GetUserMemberRanking)
+		rankings.GET("/repos/:type", handler.GetUserRepoRanking)
+	}
+}
+
+// registerCollectionRoutes registers the collection batch endpoints on a /collections group
+func registerCollectionRoutes(collections *gin.RouterGroup, handler *Handler) {
+	collections.GET("", handler.ListCollections)
+	collections.GET("/:id", handler.GetCollection)
+	collections.GET("/:id/stream", handler.StreamCollection)
 }
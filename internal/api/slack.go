@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+	"github.com/kurihiro0119/github-activity-metrics/internal/notify"
+)
+
+const (
+	// maxSlackPayload is the largest slash command body accepted
+	maxSlackPayload = 64 << 10
+	// maxSlackRequestAge is how far a request's timestamp may be from now; older requests are
+	// rejected as possible replays
+	maxSlackRequestAge = 5 * time.Minute
+	// slackTopEntries is the number of top members or repositories listed in a response
+	slackTopEntries = 5
+	// defaultSlackRange is the time range of queries that don't give one
+	defaultSlackRange = "30d"
+)
+
+// SlackCommandConfig configures the Slack slash command endpoint
+type SlackCommandConfig struct {
+	Secret string // signing secret of the Slack app; the endpoint is disabled when empty
+	// AllowedOwners are the organizations/users whose metrics the command shows; "*" allows
+	// every owner and an empty list none. Slack users aren't API keys, so the command can't
+	// check grants or workspaces and anyone in the Slack workspace can query these owners.
+	AllowedOwners []string
+	InChannel     bool // post results to the channel instead of only to the user who ran the command
+}
+
+// allowsOwner reports whether the command may show the metrics of owner
+func (sc SlackCommandConfig) allowsOwner(owner string) bool {
+	return slices.ContainsFunc(sc.AllowedOwners, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, owner)
+	})
+}
+
+// SlackCommand returns a handler answering Slack slash commands signed with the configured
+// secret, such as "/metrics org acme last 7d", with a summary of the metrics of an allowed
+// owner. Results are shown only to the user who ran the command unless InChannel is set;
+// usage and errors always are.
+// POST /api/v1/slack/commands
+func (h *Handler) SlackCommand(sc SlackCommandConfig) gin.HandlerFunc {
+	secret := []byte(sc.Secret)
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSlackPayload))
+		if err != nil {
+			respondError(c, apperrors.NewBadRequestError("failed to read request body"))
+			return
+		}
+		if err := verifySlackSignature(secret, c.Request.Header, body, time.Now()); err != nil {
+			respondError(c, apperrors.NewUnauthorizedError("invalid Slack signature: "+err.Error()))
+			return
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			respondError(c, apperrors.NewBadRequestError("invalid form body"))
+			return
+		}
+
+		command := form.Get("command")
+		if command == "" {
+			command = "/metrics"
+		}
+		query, err := parseSlackQuery(form.Get("text"), time.Now())
+		if err != nil {
+			c.JSON(http.StatusOK, slackEphemeral(fmt.Sprintf("%s\n\n%s", err, slackUsage(command))))
+			return
+		}
+		if query == nil {
+			c.JSON(http.StatusOK, slackEphemeral(slackUsage(command)))
+			return
+		}
+		if !sc.allowsOwner(query.owner) {
+			c.JSON(http.StatusOK, slackEphemeral(fmt.Sprintf("The metrics of %s are not available in Slack.", query.owner)))
+			return
+		}
+
+		summary, err := h.slackSummary(c, query)
+		if err != nil {
+			_ = c.Error(err) // logged by the request logger
+			c.JSON(http.StatusOK, slackEphemeral("Failed to get the metrics; please try again later."))
+			return
+		}
+		msg := notify.SlackPayload(summary)
+		msg.ResponseType = "ephemeral"
+		if sc.InChannel {
+			msg.ResponseType = "in_channel"
+		}
+		c.JSON(http.StatusOK, msg)
+	}
+}
+
+// verifySlackSignature checks the X-Slack-Signature of a request body: the HMAC-SHA256, keyed
+// with the app's signing secret, of "v0:<X-Slack-Request-Timestamp>:<body>"
+func verifySlackSignature(secret []byte, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid X-Slack-Request-Timestamp header")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxSlackRequestAge || age < -maxSlackRequestAge {
+		return errors.New("request timestamp is too far from the current time")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// slackQuery is a parsed slash command
+type slackQuery struct {
+	target    string // "org", "repo" or "member"
+	owner     string
+	name      string // repository or member; empty for "org"
+	period    string // how the time range was given, e.g. "last 7d"
+	timeRange domain.TimeRange
+}
+
+// slackRangePattern matches a time ago such as 7d, 2w, 3m or 1y, optionally spelled out as
+// in "7 days" once the tokens are joined
+var slackRangePattern = regexp.MustCompile(`^([0-9]+)\s*(d|day|days|w|week|weeks|m|month|months|y|year|years)$`)
+
+// parseSlackQuery parses the text of a slash command: "org <owner>", "repo <owner>/<repo>"
+// or "member <owner> <member>", optionally followed by "last <N><d|w|m|y>". It returns nil
+// for an empty text or "help".
+func parseSlackQuery(text string, now time.Time) (*slackQuery, error) {
+	tokens := strings.Fields(text)
+	if len(tokens) == 0 || strings.EqualFold(tokens[0], "help") {
+		return nil, nil
+	}
+
+	// The time range, if any, comes last: "last 7d", "7d" or "last 7 days"
+	period := defaultSlackRange
+	explicit := false
+	for i, token := range tokens {
+		if !strings.EqualFold(token, "last") && !slackRangePattern.MatchString(token) {
+			continue
+		}
+		rest := strings.Join(tokens[i:], " ")
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.ToLower(rest), "last"))
+		if !slackRangePattern.MatchString(rest) {
+			return nil, fmt.Errorf("Unknown time range %q; use e.g. last 7d, last 2w or last 3m.", strings.Join(tokens[i:], " "))
+		}
+		period, explicit, tokens = rest, true, tokens[:i]
+		break
+	}
+	m := slackRangePattern.FindStringSubmatch(period)
+	n, _ := strconv.Atoi(m[1])
+	if n == 0 {
+		return nil, fmt.Errorf("The time range must be at least one %s.", strings.TrimSuffix(m[2], "s"))
+	}
+	var start time.Time
+	switch m[2][0] {
+	case 'd':
+		start = now.AddDate(0, 0, -n)
+	case 'w':
+		start = now.AddDate(0, 0, -7*n)
+	case 'm':
+		start = now.AddDate(0, -n, 0)
+	case 'y':
+		start = now.AddDate(-n, 0, 0)
+	}
+	query := &slackQuery{
+		period:    "last " + m[1] + m[2][:1],
+		timeRange: domain.TimeRange{Start: start, End: now, Granularity: "day"},
+	}
+	if !explicit {
+		query.period += " (default)"
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("Nothing to show; give a target such as org acme.")
+	}
+	target, args := strings.ToLower(tokens[0]), tokens[1:]
+	switch target {
+	case "org", "user":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Usage: %s <owner>", target)
+		}
+		query.target, query.owner = "org", args[0]
+	case "repo":
+		// "repo acme/api" or "repo acme api"
+		if len(args) == 1 {
+			args = strings.SplitN(args[0], "/", 2)
+		}
+		if len(args) != 2 || args[0] == "" || args[1] == "" {
+			return nil, errors.New("Usage: repo <owner>/<repo>")
+		}
+		query.target, query.owner, query.name = "repo", args[0], args[1]
+	case "member":
+		if len(args) != 2 {
+			return nil, errors.New("Usage: member <owner> <member>")
+		}
+		query.target, query.owner, query.name = "member", args[0], args[1]
+	default:
+		return nil, fmt.Errorf("Unknown target %q; use org, repo or member.", tokens[0])
+	}
+	return query, nil
+}
+
+// slackSummary queries the metrics a slash command asks for
+func (h *Handler) slackSummary(c *gin.Context, q *slackQuery) (*notify.Summary, error) {
+	ctx := c.Request.Context()
+	tr := q.timeRange
+	summary := &notify.Summary{
+		Footer: fmt.Sprintf("%s: %s to %s", q.period, tr.Start.Format("2006-01-02"), tr.End.Format("2006-01-02")),
+	}
+
+	switch q.target {
+	case "org":
+		metrics, err := h.aggregator.AggregateOrgMetrics(ctx, q.owner, tr)
+		if err != nil {
+			return nil, err
+		}
+		ranking, err := h.aggregator.GetMemberRanking(ctx, q.owner, domain.RankingTypeCommits, tr, slackTopEntries)
+		if err != nil {
+			return nil, err
+		}
+		summary.Title = "Metrics: " + q.owner
+		summary.Fields = append(slackFields(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys),
+			notify.Field{Name: "Repositories", Value: strconv.Itoa(metrics.TotalRepos)},
+			notify.Field{Name: "Members", Value: strconv.Itoa(metrics.TotalMembers)},
+		)
+		top := notify.Section{Title: "Top members by commits"}
+		for _, r := range ranking {
+			if r.Value > 0 {
+				top.Lines = append(top.Lines, fmt.Sprintf("%s: %d", r.Member, r.Value))
+			}
+		}
+		summary.Sections = []notify.Section{top}
+
+	case "repo":
+		metrics, err := h.aggregator.AggregateRepoMetrics(ctx, q.owner, q.name, tr)
+		if err != nil {
+			return nil, err
+		}
+		members, err := h.aggregator.GetRepoMembersMetrics(ctx, q.owner, q.name, tr)
+		if err != nil {
+			return nil, err
+		}
+		summary.Title = fmt.Sprintf("Metrics: %s/%s", q.owner, q.name)
+		summary.Fields = slackFields(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)
+		sort.SliceStable(members, func(i, j int) bool { return members[i].Commits > members[j].Commits })
+		top := notify.Section{Title: "Top contributors by commits"}
+		for _, m := range members[:min(slackTopEntries, len(members))] {
+			if m.Commits > 0 {
+				top.Lines = append(top.Lines, fmt.Sprintf("%s: %d", m.Member, m.Commits))
+			}
+		}
+		summary.Sections = []notify.Section{top}
+
+	case "member":
+		metrics, err := h.aggregator.AggregateMemberMetrics(ctx, q.owner, q.name, tr)
+		if err != nil {
+			return nil, err
+		}
+		repos, err := h.aggregator.GetMemberReposMetrics(ctx, q.owner, q.name, tr)
+		if err != nil {
+			return nil, err
+		}
+		summary.Title = fmt.Sprintf("Metrics: %s in %s", q.name, q.owner)
+		summary.Fields = slackFields(metrics.Commits, metrics.PRs, metrics.Additions, metrics.Deletions, metrics.Deploys)
+		sort.SliceStable(repos, func(i, j int) bool { return repos[i].Commits > repos[j].Commits })
+		top := notify.Section{Title: "Top repositories by commits"}
+		for _, r := range repos[:min(slackTopEntries, len(repos))] {
+			if r.Commits > 0 {
+				top.Lines = append(top.Lines, fmt.Sprintf("%s: %d", r.Repo, r.Commits))
+			}
+		}
+		summary.Sections = []notify.Section{top}
+	}
+	return summary, nil
+}
+
+// slackFields are the summary fields of a set of totals
+func slackFields(commits, prs, additions, deletions, deploys int64) []notify.Field {
+	return []notify.Field{
+		{Name: "Commits", Value: strconv.FormatInt(commits, 10)},
+		{Name: "Pull requests", Value: strconv.FormatInt(prs, 10)},
+		{Name: "Lines changed", Value: fmt.Sprintf("+%d / -%d", additions, deletions)},
+		{Name: "Deployments", Value: strconv.FormatInt(deploys, 10)},
+	}
+}
+
+// slackEphemeral is a response only the user who ran the command sees
+func slackEphemeral(text string) *notify.SlackMessage {
+	return &notify.SlackMessage{ResponseType: "ephemeral", Text: text}
+}
+
+// slackUsage describes the slash command
+func slackUsage(command string) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "*Usage*\n")
+	fmt.Fprintf(&b, "`%s org <owner> [last 7d]` totals and top members of an organization or user\n", command)
+	fmt.Fprintf(&b, "`%s repo <owner>/<repo> [last 2w]` totals and top contributors of a repository\n", command)
+	fmt.Fprintf(&b, "`%s member <owner> <member> [last 3m]` totals and top repositories of a member\n", command)
+	fmt.Fprintf(&b, "The time range defaults to the last %s.", defaultSlackRange)
+	return b.String()
+}
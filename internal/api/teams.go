@@ -0,0 +1,179 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// ListTeams returns the collected teams of an organization with their members
+// GET /api/v1/orgs/:org/teams
+func (h *Handler) ListTeams(c *gin.Context) {
+	org := c.Param("org")
+
+	teams, err := h.storage.GetTeams(c.Request.Context(), org)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if teams == nil {
+		teams = []*domain.Team{}
+	}
+	h.pseudonymizeTeams(org, teams)
+
+	respond(c, http.StatusOK, teams)
+}
+
+// GetMemberTeams returns the teams of an organization a member belongs to, with the member's
+// role in each
+// GET /api/v1/orgs/:org/members/:member/teams
+func (h *Handler) GetMemberTeams(c *gin.Context) {
+	org := c.Param("org")
+	member := c.Param("member")
+
+	var memberships []*domain.TeamMembership
+	var err error
+	if h.pseudonyms != nil {
+		memberships, err = h.pseudonymousMemberTeams(c, org, member)
+	} else {
+		memberships, err = h.storage.GetMemberTeams(c.Request.Context(), org, member)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if memberships == nil {
+		memberships = []*domain.TeamMembership{}
+	}
+
+	respond(c, http.StatusOK, memberships)
+}
+
+// pseudonymizeTeams replaces the usernames of the teams' members with their pseudonyms when
+// member usernames are pseudonymized
+func (h *Handler) pseudonymizeTeams(org string, teams []*domain.Team) {
+	if h.pseudonyms == nil {
+		return
+	}
+	for _, team := range teams {
+		for i, m := range team.Members {
+			team.Members[i] = h.pseudonyms.Name(org, m)
+		}
+		for i, m := range team.Maintainers {
+			team.Maintainers[i] = h.pseudonyms.Name(org, m)
+		}
+	}
+}
+
+// pseudonymousMemberTeams returns the teams of the member whose pseudonym is member. Storage
+// only knows the usernames, so the memberships are found among the pseudonymized teams.
+func (h *Handler) pseudonymousMemberTeams(c *gin.Context, org, member string) ([]*domain.TeamMembership, error) {
+	teams, err := h.storage.GetTeams(c.Request.Context(), org)
+	if err != nil {
+		return nil, err
+	}
+	h.pseudonymizeTeams(org, teams)
+
+	var memberships []*domain.TeamMembership
+	for _, team := range teams {
+		if team.HasMember(member) {
+			memberships = append(memberships, &domain.TeamMembership{
+				Org:      team.Org,
+				Team:     team.Slug,
+				TeamName: team.Name,
+				Username: member,
+				Role:     team.Role(member),
+			})
+		}
+	}
+	return memberships, nil
+}
+
+// GetTeamsMetrics returns metrics for all teams
+// GET /api/v1/orgs/:org/teams/metrics
+func (h *Handler) GetTeamsMetrics(c *gin.Context) {
+	org := c.Param("org")
+	timeRange := parseTimeRange(c)
+
+	metrics, err := h.aggregator.GetTeamsMetrics(c.Request.Context(), org, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondData(c, "teams", metrics)
+}
+
+// GetTeamMetrics returns metrics for a team
+// GET /api/v1/orgs/:org/teams/:team/metrics
+func (h *Handler) GetTeamMetrics(c *gin.Context) {
+	org := c.Param("org")
+	team := c.Param("team")
+	timeRange := parseTimeRange(c)
+
+	metrics, err := h.aggregator.GetTeamMetrics(c.Request.Context(), org, team, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, metrics)
+}
+
+// GetTeamTimeSeriesDetailed returns detailed time series data for a team
+// GET /api/v1/orgs/:org/teams/:team/metrics/timeseries
+func (h *Handler) GetTeamTimeSeriesDetailed(c *gin.Context) {
+	org := c.Param("org")
+	team := c.Param("team")
+	timeRange := parseTimeRange(c)
+
+	data, err := h.aggregator.GetTeamTimeSeries(c.Request.Context(), org, team, timeRange)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondData(c, team+"-timeseries", data)
+}
+
+// GetTeamRanking returns team rankings
+// GET /api/v1/orgs/:org/rankings/teams/:type
+func (h *Handler) GetTeamRanking(c *gin.Context) {
+	org := c.Param("org")
+	rankingTypeStr := c.Param("type")
+	timeRange := parseTimeRange(c)
+	limit := parseIntQuery(c, "limit", 10)
+
+	rankingType, ok := parseRankingType(rankingTypeStr)
+	if !ok {
+		respondError(c, apperrors.NewInvalidRankingTypeError("ranking type must be one of: commits, prs, code-changes, deploys"))
+		return
+	}
+
+	rankings, err := h.aggregator.GetTeamRanking(c.Request.Context(), org, rankingType, timeRange, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setPagination(c, limit, len(rankings))
+	respondData(c, "ranking-teams-"+rankingTypeStr, rankings)
+}
+
+// parseRankingType parses the :type path parameter of ranking endpoints
+func parseRankingType(s string) (domain.RankingType, bool) {
+	switch s {
+	case "commits":
+		return domain.RankingTypeCommits, true
+	case "prs":
+		return domain.RankingTypePRs, true
+	case "code-changes":
+		return domain.RankingTypeCodeChanges, true
+	case "deploys":
+		return domain.RankingTypeDeploys, true
+	default:
+		return "", false
+	}
+}
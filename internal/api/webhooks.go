@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v55/github"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/collector"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// maxWebhookPayload is the largest webhook body accepted (GitHub caps payloads at 25 MB)
+const maxWebhookPayload = 25 << 20
+
+// GitHubWebhook returns a handler that ingests GitHub webhook deliveries signed with secret.
+// Push, pull_request, deployment and deployment_status payloads are converted to events and
+// saved; other event types are acknowledged and ignored. Live update clients of the affected
+// owners are notified when live is not nil.
+// POST /api/v1/webhooks/github
+func (h *Handler) GitHubWebhook(secret []byte, live *LiveHub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookPayload)
+
+		payload, err := github.ValidatePayload(c.Request, secret)
+		if err != nil {
+			respondError(c, apperrors.NewUnauthorizedError("invalid webhook signature or payload: "+err.Error()))
+			return
+		}
+
+		eventType := github.WebHookType(c.Request)
+		if eventType == "" {
+			respondError(c, apperrors.NewBadRequestError("missing "+github.EventTypeHeader+" header"))
+			return
+		}
+
+		events, err := collector.WebhookEvents(eventType, payload)
+		if err != nil {
+			respondError(c, apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+		// Deployments only count for repositories whose deploys are GitHub deployments
+		events, err = collector.FilterDeploys(c.Request.Context(), h.storage, events)
+		if err != nil {
+			respondError(c, apperrors.NewInternalError("failed to filter webhook deploys", err))
+			return
+		}
+
+		if len(events) > 0 {
+			if err := h.storage.SaveRawEvents(c.Request.Context(), events); err != nil {
+				respondError(c, apperrors.NewInternalError("failed to save webhook events", err))
+				return
+			}
+
+			owners := make(map[string]struct{})
+			for _, event := range events {
+				owners[event.Org] = struct{}{}
+			}
+			for owner := range owners {
+				h.aggregator.InvalidateCache(owner)
+				if live != nil {
+					live.Notify(owner)
+				}
+			}
+		}
+
+		respond(c, http.StatusAccepted, gin.H{
+			"event":    eventType,
+			"delivery": github.DeliveryID(c.Request),
+			"saved":    len(events),
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+)
+
+// workspaceContextKey is the gin context key holding the request's *domain.Workspace
+const workspaceContextKey = "workspace"
+
+// WorkspaceStore looks up workspaces
+type WorkspaceStore interface {
+	GetWorkspace(ctx context.Context, id string) (*domain.Workspace, error)
+}
+
+// Workspace returns a middleware that loads the workspace named by the :ws path parameter.
+// API keys confined to a different workspace are rejected.
+func Workspace(store WorkspaceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("ws")
+		ws, err := store.GetWorkspace(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, apperrors.NewInternalError("failed to look up workspace", err))
+			c.Abort()
+			return
+		}
+		if ws == nil {
+			respondError(c, apperrors.NewNotFoundError("workspace "+id))
+			c.Abort()
+			return
+		}
+
+		if key, ok := APIKeyFromContext(c); ok && key.Workspace != "" && key.Workspace != ws.ID {
+			respondError(c, apperrors.NewForbiddenError("API key is not allowed to access workspace '"+ws.ID+"'"))
+			c.Abort()
+			return
+		}
+
+		c.Set(workspaceContextKey, ws)
+		c.Next()
+	}
+}
+
+// WorkspaceOwner returns a middleware that only lets requests through whose owner (the param
+// path parameter) belongs to the request's workspace. Other owners are reported as not found
+// so that workspaces can't probe each other's data.
+func WorkspaceOwner(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		owner := c.Param(param)
+		if ws, ok := WorkspaceFromContext(c); ok && !ws.HasOwner(owner) {
+			respondError(c, apperrors.NewNotFoundError(owner))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// WorkspaceFromContext returns the workspace the request is scoped to
+func WorkspaceFromContext(c *gin.Context) (*domain.Workspace, bool) {
+	value, ok := c.Get(workspaceContextKey)
+	if !ok {
+		return nil, false
+	}
+	ws, ok := value.(*domain.Workspace)
+	return ws, ok
+}
+
+// GetWorkspace returns the workspace and its owners
+// GET /api/v1/workspaces/:ws
+func (h *Handler) GetWorkspace(c *gin.Context) {
+	ws, _ := WorkspaceFromContext(c)
+
+	// Deployment-wide keys need access to every owner to see a workspace's members
+	if key, ok := APIKeyFromContext(c); ok && key.Workspace == "" {
+		if _, all := key.RoleFor(domain.AllOwners); !all {
+			respondError(c, apperrors.NewForbiddenError("API key is not allowed to access workspace '"+ws.ID+"'"))
+			return
+		}
+	}
+
+	respond(c, http.StatusOK, ws)
+}
@@ -0,0 +1,170 @@
+// Package badge draws shields.io-style badges of an owner's metrics, for embedding in READMEs
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// DefaultColor is the color of the message half of a badge
+const DefaultColor = "blue"
+
+// metric is a value a badge can show
+type metric struct {
+	label string
+	value func(m *domain.OrgMetrics) int64
+}
+
+// metrics are the values badges can show, keyed by the name used on the command line and in
+// the API
+var metrics = map[string]metric{
+	"commits":   {"commits", func(m *domain.OrgMetrics) int64 { return m.Commits }},
+	"prs":       {"pull requests", func(m *domain.OrgMetrics) int64 { return m.PRs }},
+	"additions": {"lines added", func(m *domain.OrgMetrics) int64 { return m.Additions }},
+	"deletions": {"lines deleted", func(m *domain.OrgMetrics) int64 { return m.Deletions }},
+	"deploys":   {"deployments", func(m *domain.OrgMetrics) int64 { return m.Deploys }},
+	"members":   {"members", func(m *domain.OrgMetrics) int64 { return int64(m.TotalMembers) }},
+	"repos":     {"repositories", func(m *domain.OrgMetrics) int64 { return int64(m.TotalRepos) }},
+}
+
+// Metrics returns the names of the metrics badges can show, sorted
+func Metrics() []string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Badge is a badge's label and message
+type Badge struct {
+	Label   string
+	Message string
+	Color   string // name such as "green" or hex such as "#4c1"
+}
+
+// ForMetric returns the badge of a metric of m: its label, unless label is not empty, and its
+// value abbreviated like 1.2k
+func ForMetric(m *domain.OrgMetrics, name, label, color string) (*Badge, error) {
+	mt, ok := metrics[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid metric %q: must be one of %s", name, strings.Join(Metrics(), ", "))
+	}
+	if label == "" {
+		label = mt.label
+	}
+	if color == "" {
+		color = DefaultColor
+	}
+	if _, err := resolveColor(color); err != nil {
+		return nil, err
+	}
+	return &Badge{Label: label, Message: FormatCount(mt.value(m)), Color: color}, nil
+}
+
+// FormatCount abbreviates n like shields.io: 999, 1.2k, 12k, 3.4M
+func FormatCount(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	for _, unit := range []struct {
+		size   int64
+		suffix string
+	}{{1_000_000_000, "G"}, {1_000_000, "M"}, {1_000, "k"}} {
+		if n < unit.size {
+			continue
+		}
+		if n < 10*unit.size {
+			s := fmt.Sprintf("%.1f", float64(n)/float64(unit.size))
+			return sign + strings.TrimSuffix(s, ".0") + unit.suffix
+		}
+		return fmt.Sprintf("%s%d%s", sign, n/unit.size, unit.suffix)
+	}
+	return fmt.Sprintf("%s%d", sign, n)
+}
+
+// colors are the named colors of shields.io
+var colors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellow":      "#dfb317",
+	"yellowgreen": "#a4a61d",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"grey":        "#555",
+	"gray":        "#555",
+	"lightgrey":   "#9f9f9f",
+	"lightgray":   "#9f9f9f",
+	"blueviolet":  "#8a2be2",
+}
+
+var hexColor = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// resolveColor returns the hex value of a named or hex color
+func resolveColor(color string) (string, error) {
+	if hex, ok := colors[strings.ToLower(color)]; ok {
+		return hex, nil
+	}
+	if hexColor.MatchString(color) {
+		return "#" + strings.TrimPrefix(color, "#"), nil
+	}
+	return "", fmt.Errorf("invalid color %q: must be a hex color such as #4c1 or one of brightgreen, green, yellowgreen, yellow, orange, red, blue, grey, lightgrey, blueviolet", color)
+}
+
+// SVG draws the badge in the flat style of shields.io
+func (b *Badge) SVG() []byte {
+	color, err := resolveColor(b.Color)
+	if err != nil {
+		color = colors[DefaultColor]
+	}
+	labelWidth := textWidth(b.Label) + 10
+	messageWidth := textWidth(b.Message) + 10
+	width := labelWidth + messageWidth
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message)
+	fmt.Fprintf(&buf, `<title>%s: %s</title>`, label, message)
+	buf.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&buf, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&buf, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, messageWidth, color, width)
+	buf.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="11">`)
+	for _, t := range []struct {
+		x    int
+		text string
+	}{{labelWidth / 2, label}, {labelWidth + messageWidth/2, message}} {
+		fmt.Fprintf(&buf, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, t.x, t.text, t.x, t.text)
+	}
+	buf.WriteString(`</g></svg>`)
+	return buf.Bytes()
+}
+
+// textWidth estimates the width in pixels of s in 11px Verdana
+func textWidth(s string) int {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("ijlI!|.,:;'", r):
+			width += 3.5
+		case strings.ContainsRune("frt() -", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW%", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.8
+		}
+	}
+	return int(width + 0.5)
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrInvalidAppCredentials is returned when GitHub rejects the credentials of a GitHub App
+var ErrInvalidAppCredentials = errors.New("GitHub rejected the GitHub App credentials (check the app ID, installation ID and private key)")
+
+// AppCredentials authenticate the collector as an installation of a GitHub App instead of with
+// a personal access token
+type AppCredentials struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+}
+
+// WithAppAuth makes the collector authenticate as the installation of a GitHub App, with
+// installation tokens it creates and renews before they expire (after an hour); the token
+// given to the collector is ignored. A nil creds keeps token authentication.
+func WithAppAuth(creds *AppCredentials) Option {
+	return func(o *clientOptions) {
+		o.app = creds
+	}
+}
+
+// appTokenSource creates installation tokens of a GitHub App, signing a JWT with the app's
+// private key to request each one
+type appTokenSource struct {
+	creds      *AppCredentials
+	apiURL     string // ending with "/"
+	httpClient *http.Client
+}
+
+// newAppTokenSource returns a token source of installation tokens, reused until shortly
+// before they expire
+func newAppTokenSource(creds *AppCredentials, apiURL string, httpClient *http.Client) oauth2.TokenSource {
+	if apiURL == "" {
+		apiURL = "https://api.github.com/"
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{creds: creds, apiURL: apiURL, httpClient: httpClient})
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%sapp/installations/%d/access_tokens", s.apiURL, s.creds.InstallationID)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a GitHub App installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound:
+		return nil, ErrInvalidAppCredentials
+	case resp.StatusCode != http.StatusCreated:
+		return nil, fmt.Errorf("failed to create a GitHub App installation token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to read the GitHub App installation token: %w", err)
+	}
+	// Renew a minute early so requests in flight don't fail
+	return &oauth2.Token{AccessToken: token.Token, TokenType: "token", Expiry: token.ExpiresAt.Add(-time.Minute)}, nil
+}
+
+// jwt returns the JSON Web Token identifying the app, valid for 9 minutes (GitHub allows 10)
+// and backdated a minute for clock drift
+func (s *appTokenSource) jwt(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprint(s.creds.AppID),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.creds.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appTokenSources shares the token source of each installation between collectors, so that
+// collecting several owners doesn't create a token per collector
+var appTokenSources struct {
+	mu      sync.Mutex
+	sources map[string]oauth2.TokenSource
+}
+
+// sharedAppTokenSource returns the token source of an installation, creating it once
+func sharedAppTokenSource(creds *AppCredentials, apiURL string, httpClient *http.Client) oauth2.TokenSource {
+	key := fmt.Sprintf("%s|%d|%d", apiURL, creds.AppID, creds.InstallationID)
+	appTokenSources.mu.Lock()
+	defer appTokenSources.mu.Unlock()
+	if src, ok := appTokenSources.sources[key]; ok {
+		return src
+	}
+	if appTokenSources.sources == nil {
+		appTokenSources.sources = make(map[string]oauth2.TokenSource)
+	}
+	src := newAppTokenSource(creds, apiURL, httpClient)
+	appTokenSources.sources[key] = src
+	return src
+}
+
+// inspectApp reports the rate limit of a GitHub App installation; its permissions aren't
+// reported as scopes
+func inspectApp(ctx context.Context, token string, opts []Option) (*TokenInfo, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	client := newClient(token, opts)
+	limits, _, err := client.RateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info := &TokenInfo{Login: fmt.Sprintf("GitHub App %d (installation %d)", o.app.AppID, o.app.InstallationID)}
+	if core := limits.GetCore(); core != nil {
+		info.RateLimit, info.RateRemaining, info.RateReset = core.Limit, core.Remaining, core.Reset.Time
+	}
+	return info, nil
+}
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
@@ -24,11 +25,16 @@ type Collector interface {
 	// GetMembers retrieves all members of an organization
 	GetMembers(ctx context.Context, org string) ([]*domain.Member, error)
 
+	// GetTeams retrieves all teams of an organization with their members
+	GetTeams(ctx context.Context, org string) ([]*domain.Team, error)
+
 	// CollectOrganizationData collects all data for an organization
 	CollectOrganizationData(ctx context.Context, org string, since, until time.Time, onProgress func(repo string, progress float64)) ([]*domain.Event, error)
 
-	// CollectOrganizationDataWithCallback collects data and calls callback for each repository's events
-	CollectOrganizationDataWithCallback(ctx context.Context, org string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) error
+	// CollectOrganizationDataWithCallback collects data and calls callback for each repository's
+	// events. Repositories that fail are reported in the result; an error means the collection
+	// could not run at all.
+	CollectOrganizationDataWithCallback(ctx context.Context, org string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) (*domain.CollectionResult, error)
 
 	// GetUserRepositories retrieves all repositories for a user
 	GetUserRepositories(ctx context.Context, user string) ([]*domain.Repository, error)
@@ -36,9 +42,39 @@ type Collector interface {
 	// CollectUserData collects all data for a user account
 	CollectUserData(ctx context.Context, user string, since, until time.Time, onProgress func(repo string, progress float64)) ([]*domain.Event, error)
 
-	// CollectUserDataWithCallback collects data and calls callback for each repository's events
-	CollectUserDataWithCallback(ctx context.Context, user string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) error
+	// CollectUserDataWithCallback collects data and calls callback for each repository's events,
+	// reporting failed repositories like CollectOrganizationDataWithCallback
+	CollectUserDataWithCallback(ctx context.Context, user string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) (*domain.CollectionResult, error)
+
+	// RateLimit returns the GitHub API requests left and when the limit resets, as of the
+	// last response
+	RateLimit() (remaining int, reset time.Time)
 }
 
 // ProgressCallback is a callback function for reporting progress
 type ProgressCallback func(repo string, progress float64)
+
+// failedRepo reports that collecting repo failed with err to the repository error hook of
+// ctx, if any, and returns the repository's result
+func failedRepo(ctx context.Context, repo string, err error) *domain.RepoCollectionResult {
+	notifyRepoError(ctx, repo, err)
+	return &domain.RepoCollectionResult{Repo: repo, Status: domain.BatchRepoStatusFailed, Error: err.Error()}
+}
+
+// newCollectionResult returns the result of collecting an owner from the results of its
+// repositories, indexed like the repository list with nil for the ones skipped, and logs
+// the failures
+func newCollectionResult(owner string, repos []*domain.RepoCollectionResult) *domain.CollectionResult {
+	result := &domain.CollectionResult{Owner: owner, Repos: make([]*domain.RepoCollectionResult, 0, len(repos))}
+	for _, repo := range repos {
+		if repo == nil {
+			continue
+		}
+		if repo.Failed() {
+			// Log the error but continue with the other repositories
+			slog.Warn("repository collection failed", "owner", owner, "repo", repo.Repo, "error", repo.Error)
+		}
+		result.Repos = append(result.Repos, repo)
+	}
+	return result
+}
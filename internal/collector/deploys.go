@@ -0,0 +1,273 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// DeployDefinitionStore keeps the deploy definitions used with WithDeployDefinitions;
+// storage.Storage is one
+type DeployDefinitionStore interface {
+	// GetDeployDefinition returns a repository's deploy definition, or nil if it has none
+	GetDeployDefinition(ctx context.Context, owner, repo string) (*domain.DeployDefinition, error)
+}
+
+// WithDeployDefinitions makes GetDeploys, also called by the collection methods, collect what
+// each repository's deploy definition in store counts as a deploy: GitHub deployments,
+// workflow runs, tags or releases. Without it, or for repositories without a definition,
+// deploys are GitHub deployments.
+func WithDeployDefinitions(store DeployDefinitionStore) Option {
+	return func(o *clientOptions) {
+		o.deployDefinitions = store
+	}
+}
+
+// deployDefinition returns the deploy definition of a repository
+func (c *githubCollector) deployDefinition(ctx context.Context, org, repo string) (*domain.DeployDefinition, error) {
+	if c.deployDefinitions == nil {
+		return domain.DefaultDeployDefinition(org, repo), nil
+	}
+	def, err := c.deployDefinitions.GetDeployDefinition(ctx, org, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the deploy definition of %s/%s: %w", org, repo, err)
+	}
+	if def == nil {
+		return domain.DefaultDeployDefinition(org, repo), nil
+	}
+	return def, nil
+}
+
+// GetDeploys retrieves the deploys of a repository, as its deploy definition counts them
+func (c *githubCollector) GetDeploys(ctx context.Context, org, repo string, since, until time.Time) ([]*domain.DeployEvent, error) {
+	def, err := c.deployDefinition(ctx, org, repo)
+	if err != nil {
+		return nil, err
+	}
+	switch def.Source {
+	case domain.DeploySourceWorkflow:
+		return c.listWorkflowDeploys(ctx, org, repo, def, since, until)
+	case domain.DeploySourceTag:
+		return c.listTagDeploys(ctx, org, repo, def, since, until)
+	case domain.DeploySourceRelease:
+		return c.listReleaseDeploys(ctx, org, repo, def, since, until)
+	default:
+		return c.listDeployments(ctx, org, repo, def, since, until)
+	}
+}
+
+// listWorkflowDeploys lists the completed runs of the repository's workflows whose name or
+// file name matches the definition's pattern. Successful runs are successful deploys and
+// failed or timed out runs failed ones; cancelled and skipped runs aren't deploys.
+func (c *githubCollector) listWorkflowDeploys(ctx context.Context, org, repo string, def *domain.DeployDefinition, since, until time.Time) ([]*domain.DeployEvent, error) {
+	var workflows []*github.Workflow
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		page, resp, err := c.client.Actions.ListWorkflows(ctx, org, repo, opts)
+		if err != nil {
+			// Skip if Actions are not available
+			if resp != nil && resp.StatusCode == 404 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list workflows for %s/%s: %w", org, repo, err)
+		}
+		c.updateRateLimitFromResponse(resp)
+		for _, w := range page.Workflows {
+			if def.Matches(w.GetName()) || def.Matches(path.Base(w.GetPath())) {
+				workflows = append(workflows, w)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	var deploys []*domain.DeployEvent
+	for _, w := range workflows {
+		runOpts := &github.ListWorkflowRunsOptions{
+			Status:      "completed",
+			Created:     since.UTC().Format(time.RFC3339) + ".." + until.UTC().Format(time.RFC3339),
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			if err := c.rateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+			runs, resp, err := c.client.Actions.ListWorkflowRunsByID(ctx, org, repo, w.GetID(), runOpts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list runs of workflow %s for %s/%s: %w", w.GetName(), org, repo, err)
+			}
+			c.updateRateLimitFromResponse(resp)
+
+			for _, run := range runs.WorkflowRuns {
+				var status string
+				switch run.GetConclusion() {
+				case "success":
+					status = "success"
+				case "failure", "timed_out", "startup_failure":
+					status = "failure"
+				default:
+					continue
+				}
+				deploys = append(deploys, &domain.DeployEvent{
+					ID:            fmt.Sprintf("%s-%s-deploy-run-%d", org, repo, run.GetID()),
+					Org:           org,
+					Repo:          repo,
+					Member:        run.GetActor().GetLogin(),
+					OwnerType:     "organization",
+					Timestamp:     run.GetCreatedAt().Time,
+					Environment:   w.GetName(),
+					Status:        status,
+					WorkflowRunID: fmt.Sprintf("%d", run.GetID()),
+					Source:        domain.DeploySourceWorkflow,
+					CreatedAt:     time.Now(),
+				})
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			runOpts.Page = resp.NextPage
+		}
+	}
+	return deploys, nil
+}
+
+// listTagDeploys lists the tags matching the definition's pattern whose commit was made in
+// the range. Tags carry no date, so the commit of every matching tag is fetched.
+func (c *githubCollector) listTagDeploys(ctx context.Context, org, repo string, def *domain.DeployDefinition, since, until time.Time) ([]*domain.DeployEvent, error) {
+	var deploys []*domain.DeployEvent
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		tags, resp, err := c.client.Repositories.ListTags(ctx, org, repo, opts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list tags for %s/%s: %w", org, repo, err)
+		}
+		c.updateRateLimitFromResponse(resp)
+
+		for _, tag := range tags {
+			if !def.Matches(tag.GetName()) {
+				continue
+			}
+			if err := c.rateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+			commit, commitResp, err := c.client.Repositories.GetCommit(ctx, org, repo, tag.GetCommit().GetSHA(), nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the commit of tag %s for %s/%s: %w", tag.GetName(), org, repo, err)
+			}
+			c.updateRateLimitFromResponse(commitResp)
+
+			at := commit.GetCommit().GetCommitter().GetDate().Time
+			if at.Before(since) || at.After(until) {
+				continue
+			}
+			deploys = append(deploys, &domain.DeployEvent{
+				ID:        fmt.Sprintf("%s-%s-deploy-tag-%s", org, repo, tag.GetName()),
+				Org:       org,
+				Repo:      repo,
+				Member:    commit.GetAuthor().GetLogin(),
+				OwnerType: "organization",
+				Timestamp: at,
+				Status:    "success",
+				Source:    domain.DeploySourceTag,
+				CreatedAt: time.Now(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return deploys, nil
+}
+
+// listReleaseDeploys lists the releases published in the range whose tag matches the
+// definition's pattern. Drafts aren't deploys.
+func (c *githubCollector) listReleaseDeploys(ctx context.Context, org, repo string, def *domain.DeployDefinition, since, until time.Time) ([]*domain.DeployEvent, error) {
+	var deploys []*domain.DeployEvent
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		releases, resp, err := c.client.Repositories.ListReleases(ctx, org, repo, opts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list releases for %s/%s: %w", org, repo, err)
+		}
+		c.updateRateLimitFromResponse(resp)
+
+		// Releases are listed newest first; stop at a page of releases all created before the range
+		older := len(releases) > 0
+		for _, release := range releases {
+			if !release.GetCreatedAt().Time.Before(since) {
+				older = false
+			}
+			if release.GetDraft() || release.PublishedAt == nil || !def.Matches(release.GetTagName()) {
+				continue
+			}
+			at := release.GetPublishedAt().Time
+			if at.Before(since) || at.After(until) {
+				continue
+			}
+			deploys = append(deploys, &domain.DeployEvent{
+				ID:        fmt.Sprintf("%s-%s-deploy-release-%d", org, repo, release.GetID()),
+				Org:       org,
+				Repo:      repo,
+				Member:    release.GetAuthor().GetLogin(),
+				OwnerType: "organization",
+				Timestamp: at,
+				Status:    "success",
+				Source:    domain.DeploySourceRelease,
+				CreatedAt: time.Now(),
+			})
+		}
+		if older || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return deploys, nil
+}
+
+// FilterDeploys drops the deploy events among events, such as those of webhook deliveries,
+// that the deploy definitions in store don't count: deployments of repositories whose deploys
+// come from another source, or to environments not matching their pattern
+func FilterDeploys(ctx context.Context, store DeployDefinitionStore, events []*domain.Event) ([]*domain.Event, error) {
+	kept := events[:0:0]
+	for _, event := range events {
+		if event.Type != domain.EventTypeDeploy {
+			kept = append(kept, event)
+			continue
+		}
+		def, err := store.GetDeployDefinition(ctx, event.Org, event.Repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the deploy definition of %s/%s: %w", event.Org, event.Repo, err)
+		}
+		if def == nil {
+			def = domain.DefaultDeployDefinition(event.Org, event.Repo)
+		}
+		environment, _ := event.Data["environment"].(string)
+		if def.Source == domain.DeployEventSource(event.Data) && def.Matches(environment) {
+			kept = append(kept, event)
+		}
+	}
+	return kept, nil
+}
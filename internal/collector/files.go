@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"github.com/google/go-github/v55/github"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// fileChanges returns the changed files of a commit fetched from the API
+func fileChanges(files []*github.CommitFile) []domain.FileChange {
+	if len(files) == 0 {
+		return nil
+	}
+	changes := make([]domain.FileChange, 0, len(files))
+	for _, f := range files {
+		changes = append(changes, domain.FileChange{
+			Path:      f.GetFilename(),
+			Additions: f.GetAdditions(),
+			Deletions: f.GetDeletions(),
+		})
+	}
+	return changes
+}
+
+// pushedFiles returns the changed files of a pushed commit. Push payloads list the paths
+// but not the lines changed in each file.
+func pushedFiles(commit *github.HeadCommit) []domain.FileChange {
+	var changes []domain.FileChange
+	for _, paths := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+		for _, path := range paths {
+			changes = append(changes, domain.FileChange{Path: path})
+		}
+	}
+	return changes
+}
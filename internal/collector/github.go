@@ -3,42 +3,50 @@ package collector
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/google/go-github/v55/github"
-	"golang.org/x/oauth2"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/monitoring"
 )
 
 // githubCollector implements Collector using GitHub API
 type githubCollector struct {
 	client      *github.Client
 	rateLimiter RateLimiter
+	repoCache   *repoListCache // nil lists repositories from GitHub every time
+
+	deployDefinitions DeployDefinitionStore // nil collects GitHub deployments of every repository
 }
 
 // NewGitHubCollector creates a new GitHub collector
-func NewGitHubCollector(token string) Collector {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	
-	// Create HTTP client with timeout
-	tc := oauth2.NewClient(ctx, ts)
-	tc.Timeout = 30 * time.Second // Set 30 second timeout
-	
-	client := github.NewClient(tc)
-
+func NewGitHubCollector(token string, opts ...Option) Collector {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	client := newClient(token, opts)
+	limiter := o.rateLimiter
+	if limiter == nil {
+		limiter = sharedRateLimiter(token, o, o.rateLimitBudget)
+	}
+	if l, ok := limiter.(*githubRateLimiter); ok {
+		l.bind(client)
+	}
 	return &githubCollector{
 		client:      client,
-		rateLimiter: NewRateLimiter(),
+		rateLimiter: limiter,
+		repoCache:   o.repoCache,
+
+		deployDefinitions: o.deployDefinitions,
 	}
 }
 
-// GetRepositories retrieves all repositories for an organization
-func (c *githubCollector) GetRepositories(ctx context.Context, org string) ([]*domain.Repository, error) {
+// listRepositories retrieves all repositories for an organization from GitHub
+func (c *githubCollector) listRepositories(ctx context.Context, org string) ([]*domain.Repository, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
@@ -57,17 +65,17 @@ func (c *githubCollector) GetRepositories(ctx context.Context, org string) ([]*d
 			return nil, fmt.Errorf("rate limiter error: %w", err)
 		}
 		
-		fmt.Printf("  Fetching page %d...\n", pageCount)
+		slog.Debug("fetching repositories page", "org", org, "page", pageCount)
 		repos, resp, err := c.client.Repositories.ListByOrg(ctx, org, opts)
 		if err != nil {
 			// Handle rate limit error (403)
 			if resp != nil && resp.StatusCode == 403 {
 				// Update rate limiter from response if available
 				if resp.Rate.Remaining == 0 && !resp.Rate.Reset.Time.IsZero() {
-					c.rateLimiter.UpdateLimit(0, resp.Rate.Reset.Time)
+					c.rateLimiter.UpdateLimit(resp.Rate.Limit, 0, resp.Rate.Reset.Time)
 					waitDuration := time.Until(resp.Rate.Reset.Time)
 					if waitDuration > 0 {
-						fmt.Printf("  Rate limit exceeded. Waiting %v until reset...\n", waitDuration.Round(time.Second))
+						slog.Warn("rate limit exceeded, waiting until reset", "wait", waitDuration.Round(time.Second).String())
 						select {
 						case <-ctx.Done():
 							return nil, ctx.Err()
@@ -87,7 +95,7 @@ func (c *githubCollector) GetRepositories(ctx context.Context, org string) ([]*d
 		}
 
 		c.updateRateLimitFromResponse(resp)
-		fmt.Printf("  Processed page %d, found %d repositories (rate limit: %d remaining)\n", pageCount, len(repos), resp.Rate.Remaining)
+		slog.Debug("fetched repositories page", "org", org, "page", pageCount, "repos", len(repos), "rate_remaining", resp.Rate.Remaining)
 
 		for _, repo := range repos {
 			now := time.Now()
@@ -148,6 +156,7 @@ func (c *githubCollector) GetCommits(ctx context.Context, org, repo string, sinc
 			additions := 0
 			deletions := 0
 			filesChanged := 0
+			var files []domain.FileChange
 
 			if err := c.rateLimiter.Wait(ctx); err != nil {
 				return nil, err
@@ -161,8 +170,12 @@ func (c *githubCollector) GetCommits(ctx context.Context, org, repo string, sinc
 					deletions = commitDetail.Stats.GetDeletions()
 				}
 				filesChanged = len(commitDetail.Files)
+				files = fileChanges(commitDetail.Files)
 			}
 
+			// Detect PR merge and squash commits so aggregation can avoid double counting
+			mergeKind, prNumber := detectMergeCommit(commit.Commit.GetMessage(), len(commit.Parents))
+
 			// Generate unique ID based on org, repo, type, and SHA to prevent duplicates
 			commitID := fmt.Sprintf("%s-%s-commit-%s", org, repo, commit.GetSHA())
 
@@ -178,6 +191,10 @@ func (c *githubCollector) GetCommits(ctx context.Context, org, repo string, sinc
 				Additions:    additions,
 				Deletions:    deletions,
 				FilesChanged: filesChanged,
+				Files:        files,
+				ParentCount:  len(commit.Parents),
+				MergeKind:    mergeKind,
+				PRNumber:     prNumber,
 				CreatedAt:    time.Now(),
 			}
 			allCommits = append(allCommits, commitEvent)
@@ -243,17 +260,18 @@ func (c *githubCollector) GetPullRequests(ctx context.Context, org, repo string,
 			prID := fmt.Sprintf("%s-%s-pr-%d", org, repo, pr.GetNumber())
 
 			prEvent := &domain.PullRequestEvent{
-				ID:        prID,
-				Org:       org,
-				Repo:      repo,
-				Member:    pr.User.GetLogin(),
-				OwnerType: "organization",
-				Timestamp: createdAt,
-				Number:    pr.GetNumber(),
-				State:     state,
-				Title:     pr.GetTitle(),
-				MergedAt:  mergedAt,
-				CreatedAt: time.Now(),
+				ID:             prID,
+				Org:            org,
+				Repo:           repo,
+				Member:         pr.User.GetLogin(),
+				OwnerType:      "organization",
+				Timestamp:      createdAt,
+				Number:         pr.GetNumber(),
+				State:          state,
+				Title:          pr.GetTitle(),
+				MergedAt:       mergedAt,
+				MergeCommitSHA: pr.GetMergeCommitSHA(),
+				CreatedAt:      time.Now(),
 			}
 			allPRs = append(allPRs, prEvent)
 		}
@@ -271,8 +289,9 @@ func (c *githubCollector) GetPullRequests(ctx context.Context, org, repo string,
 	return allPRs, nil
 }
 
-// GetDeploys retrieves deployment events for a repository (from GitHub Actions)
-func (c *githubCollector) GetDeploys(ctx context.Context, org, repo string, since, until time.Time) ([]*domain.DeployEvent, error) {
+// listDeployments lists the GitHub deployments of a repository to the environments matching
+// the deploy definition's pattern
+func (c *githubCollector) listDeployments(ctx context.Context, org, repo string, def *domain.DeployDefinition, since, until time.Time) ([]*domain.DeployEvent, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
@@ -296,7 +315,7 @@ func (c *githubCollector) GetDeploys(ctx context.Context, org, repo string, sinc
 
 		for _, deployment := range deployments {
 			createdAt := deployment.GetCreatedAt().Time
-			if createdAt.Before(since) || createdAt.After(until) {
+			if createdAt.Before(since) || createdAt.After(until) || !def.Matches(deployment.GetEnvironment()) {
 				continue
 			}
 
@@ -334,6 +353,7 @@ func (c *githubCollector) GetDeploys(ctx context.Context, org, repo string, sinc
 				Environment:   deployment.GetEnvironment(),
 				Status:        status,
 				WorkflowRunID: fmt.Sprintf("%d", deployment.GetID()),
+				Source:        domain.DeploySourceDeployments,
 				CreatedAt:     time.Now(),
 			}
 			allDeploys = append(allDeploys, deployEvent)
@@ -396,6 +416,95 @@ func (c *githubCollector) GetMembers(ctx context.Context, org string) ([]*domain
 	return allMembers, nil
 }
 
+// GetTeams retrieves all teams of an organization with their members (including members
+// of child teams)
+func (c *githubCollector) GetTeams(ctx context.Context, org string) ([]*domain.Team, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	var allTeams []*domain.Team
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		teams, resp, err := c.client.Teams.ListTeams(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list teams for %s: %w", org, err)
+		}
+
+		c.updateRateLimitFromResponse(resp)
+
+		for _, team := range teams {
+			members, err := c.getTeamMembers(ctx, org, team.GetSlug(), "all")
+			if err != nil {
+				return nil, err
+			}
+			maintainers, err := c.getTeamMembers(ctx, org, team.GetSlug(), domain.TeamRoleMaintainer)
+			if err != nil {
+				return nil, err
+			}
+
+			now := time.Now()
+			allTeams = append(allTeams, &domain.Team{
+				Org:          org,
+				Slug:         team.GetSlug(),
+				Name:         team.GetName(),
+				Description:  team.GetDescription(),
+				Members:      members,
+				Maintainers:  maintainers,
+				LastSyncedAt: &now,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return allTeams, nil
+}
+
+// getTeamMembers retrieves the usernames of a team's members with role: "all", "member" or
+// "maintainer"
+func (c *githubCollector) getTeamMembers(ctx context.Context, org, slug, role string) ([]string, error) {
+	var usernames []string
+	opts := &github.TeamListTeamMembersOptions{
+		Role:        role,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		members, resp, err := c.client.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members of team %s/%s: %w", org, slug, err)
+		}
+
+		c.updateRateLimitFromResponse(resp)
+
+		for _, member := range members {
+			usernames = append(usernames, member.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return usernames, nil
+}
+
 // CollectOrganizationData collects all data for an organization
 func (c *githubCollector) CollectOrganizationData(ctx context.Context, org string, since, until time.Time, onProgress func(repo string, progress float64)) ([]*domain.Event, error) {
 	// Get all repositories
@@ -410,20 +519,25 @@ func (c *githubCollector) CollectOrganizationData(ctx context.Context, org strin
 	errCh := make(chan error, len(repos))
 
 	// Limit concurrent goroutines
-	semaphore := make(chan struct{}, 5)
+	semaphore := make(chan struct{}, concurrency(ctx))
 
 	for i, repo := range repos {
+		if !includeRepo(ctx, repo.Name) {
+			continue
+		}
 		wg.Add(1)
 		go func(r *domain.Repository, index int) {
 			defer wg.Done()
 
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			notifyRepoStart(ctx, r.Name)
+			repoSince := sinceForRepo(ctx, r.Name, since)
 
 			// Collect commits
-			commits, err := c.GetCommits(ctx, org, r.Name, since, until)
+			commits, err := c.GetCommits(ctx, org, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get commits for %s: %w", r.Name, err)
+				errCh <- notifyRepoError(ctx, r.Name, fmt.Errorf("failed to get commits for %s: %w", r.Name, err))
 				return
 			}
 
@@ -434,9 +548,9 @@ func (c *githubCollector) CollectOrganizationData(ctx context.Context, org strin
 			mu.Unlock()
 
 			// Collect pull requests
-			prs, err := c.GetPullRequests(ctx, org, r.Name, since, until)
+			prs, err := c.GetPullRequests(ctx, org, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err)
+				errCh <- notifyRepoError(ctx, r.Name, fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err))
 				return
 			}
 
@@ -447,9 +561,9 @@ func (c *githubCollector) CollectOrganizationData(ctx context.Context, org strin
 			mu.Unlock()
 
 			// Collect deployments
-			deploys, err := c.GetDeploys(ctx, org, r.Name, since, until)
+			deploys, err := c.GetDeploys(ctx, org, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get deployments for %s: %w", r.Name, err)
+				errCh <- notifyRepoError(ctx, r.Name, fmt.Errorf("failed to get deployments for %s: %w", r.Name, err))
 				return
 			}
 
@@ -473,7 +587,7 @@ func (c *githubCollector) CollectOrganizationData(ctx context.Context, org strin
 	for err := range errCh {
 		if err != nil {
 			// Log error but continue with other repos (EDGE-001)
-			fmt.Printf("Warning: %v\n", err)
+			slog.Warn("repository collection failed", "error", err)
 		}
 	}
 
@@ -481,33 +595,38 @@ func (c *githubCollector) CollectOrganizationData(ctx context.Context, org strin
 }
 
 // CollectOrganizationDataWithCallback collects data and calls callback for each repository's events
-func (c *githubCollector) CollectOrganizationDataWithCallback(ctx context.Context, org string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) error {
+func (c *githubCollector) CollectOrganizationDataWithCallback(ctx context.Context, org string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) (*domain.CollectionResult, error) {
 	// Get all repositories
 	repos, err := c.GetRepositories(ctx, org)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var wg sync.WaitGroup
-	errCh := make(chan error, len(repos))
+	results := make([]*domain.RepoCollectionResult, len(repos))
 
 	// Limit concurrent goroutines
-	semaphore := make(chan struct{}, 5)
+	semaphore := make(chan struct{}, concurrency(ctx))
 
 	for i, repo := range repos {
+		if !includeRepo(ctx, repo.Name) {
+			continue
+		}
 		wg.Add(1)
 		go func(r *domain.Repository, index int) {
 			defer wg.Done()
 
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			notifyRepoStart(ctx, r.Name)
+			repoSince := sinceForRepo(ctx, r.Name, since)
 
 			var repoEvents []*domain.Event
 
 			// Collect commits
-			commits, err := c.GetCommits(ctx, org, r.Name, since, until)
+			commits, err := c.GetCommits(ctx, org, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get commits for %s: %w", r.Name, err)
+				results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to get commits for %s: %w", r.Name, err))
 				return
 			}
 			for _, commit := range commits {
@@ -515,9 +634,9 @@ func (c *githubCollector) CollectOrganizationDataWithCallback(ctx context.Contex
 			}
 
 			// Collect pull requests
-			prs, err := c.GetPullRequests(ctx, org, r.Name, since, until)
+			prs, err := c.GetPullRequests(ctx, org, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err)
+				results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err))
 				return
 			}
 			for _, pr := range prs {
@@ -525,9 +644,9 @@ func (c *githubCollector) CollectOrganizationDataWithCallback(ctx context.Contex
 			}
 
 			// Collect deployments
-			deploys, err := c.GetDeploys(ctx, org, r.Name, since, until)
+			deploys, err := c.GetDeploys(ctx, org, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get deployments for %s: %w", r.Name, err)
+				results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to get deployments for %s: %w", r.Name, err))
 				return
 			}
 			for _, deploy := range deploys {
@@ -537,11 +656,13 @@ func (c *githubCollector) CollectOrganizationDataWithCallback(ctx context.Contex
 			// Call callback to save events for this repository
 			if onRepoComplete != nil {
 				if err := onRepoComplete(r.Name, repoEvents); err != nil {
-					errCh <- fmt.Errorf("failed to save events for %s: %w", r.Name, err)
+					results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to save events for %s: %w", r.Name, err))
 					return
 				}
 			}
 
+			results[index] = &domain.RepoCollectionResult{Repo: r.Name, Status: domain.BatchRepoStatusCompleted, Events: len(repoEvents)}
+
 			// Report progress
 			if onProgress != nil {
 				onProgress(r.Name, float64(index+1)/float64(len(repos)))
@@ -550,21 +671,11 @@ func (c *githubCollector) CollectOrganizationDataWithCallback(ctx context.Contex
 	}
 
 	wg.Wait()
-	close(errCh)
-
-	// Check for errors
-	for err := range errCh {
-		if err != nil {
-			// Log error but continue with other repos
-			fmt.Printf("Warning: %v\n", err)
-		}
-	}
-
-	return nil
+	return newCollectionResult(org, results), nil
 }
 
-// GetUserRepositories retrieves all repositories for a user
-func (c *githubCollector) GetUserRepositories(ctx context.Context, user string) ([]*domain.Repository, error) {
+// listUserRepositories retrieves all repositories for a user from GitHub
+func (c *githubCollector) listUserRepositories(ctx context.Context, user string) ([]*domain.Repository, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
@@ -623,20 +734,25 @@ func (c *githubCollector) CollectUserData(ctx context.Context, user string, sinc
 	errCh := make(chan error, len(repos))
 
 	// Limit concurrent goroutines
-	semaphore := make(chan struct{}, 5)
+	semaphore := make(chan struct{}, concurrency(ctx))
 
 	for i, repo := range repos {
+		if !includeRepo(ctx, repo.Name) {
+			continue
+		}
 		wg.Add(1)
 		go func(r *domain.Repository, index int) {
 			defer wg.Done()
 
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			notifyRepoStart(ctx, r.Name)
+			repoSince := sinceForRepo(ctx, r.Name, since)
 
 			// Collect commits
-			commits, err := c.GetCommits(ctx, user, r.Name, since, until)
+			commits, err := c.GetCommits(ctx, user, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get commits for %s: %w", r.Name, err)
+				errCh <- notifyRepoError(ctx, r.Name, fmt.Errorf("failed to get commits for %s: %w", r.Name, err))
 				return
 			}
 
@@ -649,9 +765,9 @@ func (c *githubCollector) CollectUserData(ctx context.Context, user string, sinc
 			mu.Unlock()
 
 			// Collect pull requests
-			prs, err := c.GetPullRequests(ctx, user, r.Name, since, until)
+			prs, err := c.GetPullRequests(ctx, user, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err)
+				errCh <- notifyRepoError(ctx, r.Name, fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err))
 				return
 			}
 
@@ -664,9 +780,9 @@ func (c *githubCollector) CollectUserData(ctx context.Context, user string, sinc
 			mu.Unlock()
 
 			// Collect deployments
-			deploys, err := c.GetDeploys(ctx, user, r.Name, since, until)
+			deploys, err := c.GetDeploys(ctx, user, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get deployments for %s: %w", r.Name, err)
+				errCh <- notifyRepoError(ctx, r.Name, fmt.Errorf("failed to get deployments for %s: %w", r.Name, err))
 				return
 			}
 
@@ -692,7 +808,7 @@ func (c *githubCollector) CollectUserData(ctx context.Context, user string, sinc
 	for err := range errCh {
 		if err != nil {
 			// Log error but continue with other repos
-			fmt.Printf("Warning: %v\n", err)
+			slog.Warn("repository collection failed", "error", err)
 		}
 	}
 
@@ -700,33 +816,38 @@ func (c *githubCollector) CollectUserData(ctx context.Context, user string, sinc
 }
 
 // CollectUserDataWithCallback collects data and calls callback for each repository's events
-func (c *githubCollector) CollectUserDataWithCallback(ctx context.Context, user string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) error {
+func (c *githubCollector) CollectUserDataWithCallback(ctx context.Context, user string, since, until time.Time, onProgress func(repo string, progress float64), onRepoComplete func(repo string, events []*domain.Event) error) (*domain.CollectionResult, error) {
 	// Get all repositories
 	repos, err := c.GetUserRepositories(ctx, user)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var wg sync.WaitGroup
-	errCh := make(chan error, len(repos))
+	results := make([]*domain.RepoCollectionResult, len(repos))
 
 	// Limit concurrent goroutines
-	semaphore := make(chan struct{}, 5)
+	semaphore := make(chan struct{}, concurrency(ctx))
 
 	for i, repo := range repos {
+		if !includeRepo(ctx, repo.Name) {
+			continue
+		}
 		wg.Add(1)
 		go func(r *domain.Repository, index int) {
 			defer wg.Done()
 
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			notifyRepoStart(ctx, r.Name)
+			repoSince := sinceForRepo(ctx, r.Name, since)
 
 			var repoEvents []*domain.Event
 
 			// Collect commits
-			commits, err := c.GetCommits(ctx, user, r.Name, since, until)
+			commits, err := c.GetCommits(ctx, user, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get commits for %s: %w", r.Name, err)
+				results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to get commits for %s: %w", r.Name, err))
 				return
 			}
 			for _, commit := range commits {
@@ -736,9 +857,9 @@ func (c *githubCollector) CollectUserDataWithCallback(ctx context.Context, user
 			}
 
 			// Collect pull requests
-			prs, err := c.GetPullRequests(ctx, user, r.Name, since, until)
+			prs, err := c.GetPullRequests(ctx, user, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err)
+				results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to get pull requests for %s: %w", r.Name, err))
 				return
 			}
 			for _, pr := range prs {
@@ -748,9 +869,9 @@ func (c *githubCollector) CollectUserDataWithCallback(ctx context.Context, user
 			}
 
 			// Collect deployments
-			deploys, err := c.GetDeploys(ctx, user, r.Name, since, until)
+			deploys, err := c.GetDeploys(ctx, user, r.Name, repoSince, until)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get deployments for %s: %w", r.Name, err)
+				results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to get deployments for %s: %w", r.Name, err))
 				return
 			}
 			for _, deploy := range deploys {
@@ -762,11 +883,13 @@ func (c *githubCollector) CollectUserDataWithCallback(ctx context.Context, user
 			// Call callback to save events for this repository
 			if onRepoComplete != nil {
 				if err := onRepoComplete(r.Name, repoEvents); err != nil {
-					errCh <- fmt.Errorf("failed to save events for %s: %w", r.Name, err)
+					results[index] = failedRepo(ctx, r.Name, fmt.Errorf("failed to save events for %s: %w", r.Name, err))
 					return
 				}
 			}
 
+			results[index] = &domain.RepoCollectionResult{Repo: r.Name, Status: domain.BatchRepoStatusCompleted, Events: len(repoEvents)}
+
 			// Report progress
 			if onProgress != nil {
 				onProgress(r.Name, float64(index+1)/float64(len(repos)))
@@ -775,22 +898,21 @@ func (c *githubCollector) CollectUserDataWithCallback(ctx context.Context, user
 	}
 
 	wg.Wait()
-	close(errCh)
-
-	// Check for errors
-	for err := range errCh {
-		if err != nil {
-			// Log error but continue with other repos
-			fmt.Printf("Warning: %v\n", err)
-		}
-	}
-
-	return nil
+	return newCollectionResult(user, results), nil
 }
 
 // updateRateLimitFromResponse updates the rate limiter from API response
+// RateLimit returns the GitHub API requests left and when the limit resets, as of the
+// last response
+func (c *githubCollector) RateLimit() (remaining int, reset time.Time) {
+	return c.rateLimiter.Limit()
+}
+
 func (c *githubCollector) updateRateLimitFromResponse(resp *github.Response) {
-	if resp != nil && resp.Rate.Remaining >= 0 {
-		c.rateLimiter.UpdateLimit(resp.Rate.Remaining, resp.Rate.Reset.Time)
+	// GitHub Enterprise Server without rate limiting sends no rate limit headers
+	if resp != nil && resp.Rate.Limit > 0 {
+		c.rateLimiter.UpdateLimit(resp.Rate.Limit, resp.Rate.Remaining, resp.Rate.Reset.Time)
+		monitoring.GitHubRateLimitRemaining.Set(float64(resp.Rate.Remaining))
+		monitoring.GitHubRateLimitReset.Set(float64(resp.Rate.Reset.Time.Unix()))
 	}
 }
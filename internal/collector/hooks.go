@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"time"
+)
+
+// repoStartHookKey is the context key for the repository start hook
+type repoStartHookKey struct{}
+
+// WithRepoStartHook returns a copy of ctx that makes the collection methods call fn
+// when they start collecting a repository (after waiting for a worker slot)
+func WithRepoStartHook(ctx context.Context, fn func(repo string)) context.Context {
+	return context.WithValue(ctx, repoStartHookKey{}, fn)
+}
+
+// notifyRepoStart calls the repository start hook of ctx, if any
+func notifyRepoStart(ctx context.Context, repo string) {
+	if fn, ok := ctx.Value(repoStartHookKey{}).(func(repo string)); ok && fn != nil {
+		fn(repo)
+	}
+}
+
+// DefaultConcurrency is the number of repositories collected at once unless
+// WithConcurrency says otherwise
+const DefaultConcurrency = 5
+
+// concurrencyKey is the context key for the number of repositories collected at once
+type concurrencyKey struct{}
+
+// WithConcurrency returns a copy of ctx that makes the collection methods collect up to n
+// repositories at once. Values below 1 keep DefaultConcurrency.
+func WithConcurrency(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, concurrencyKey{}, n)
+}
+
+// concurrency returns the number of repositories to collect at once with ctx
+func concurrency(ctx context.Context) int {
+	if n, ok := ctx.Value(concurrencyKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return DefaultConcurrency
+}
+
+// repoFilterKey is the context key for the repository filter
+type repoFilterKey struct{}
+
+// WithRepoFilter returns a copy of ctx that makes the collection methods skip the
+// repositories for which fn returns false
+func WithRepoFilter(ctx context.Context, fn func(repo string) bool) context.Context {
+	return context.WithValue(ctx, repoFilterKey{}, fn)
+}
+
+// includeRepo reports whether the repository filter of ctx, if any, accepts repo
+func includeRepo(ctx context.Context, repo string) bool {
+	if fn, ok := ctx.Value(repoFilterKey{}).(func(repo string) bool); ok && fn != nil {
+		return fn(repo)
+	}
+	return true
+}
+
+// repoSinceKey is the context key for the per-repository start time
+type repoSinceKey struct{}
+
+// WithRepoSince returns a copy of ctx that makes the collection methods collect each
+// repository from the time fn returns for it instead of their since argument. A zero time
+// keeps the since argument.
+func WithRepoSince(ctx context.Context, fn func(repo string) time.Time) context.Context {
+	return context.WithValue(ctx, repoSinceKey{}, fn)
+}
+
+// sinceForRepo returns the time to collect repo from: the per-repository start time of
+// ctx, if any, or since
+func sinceForRepo(ctx context.Context, repo string, since time.Time) time.Time {
+	if fn, ok := ctx.Value(repoSinceKey{}).(func(repo string) time.Time); ok && fn != nil {
+		if t := fn(repo); !t.IsZero() {
+			return t
+		}
+	}
+	return since
+}
+
+// repoErrorHookKey is the context key for the repository error hook
+type repoErrorHookKey struct{}
+
+// WithRepoErrorHook returns a copy of ctx that makes the collection methods call fn when
+// collecting a repository fails. The collection continues with the other repositories.
+func WithRepoErrorHook(ctx context.Context, fn func(repo string, err error)) context.Context {
+	return context.WithValue(ctx, repoErrorHookKey{}, fn)
+}
+
+// notifyRepoError calls the repository error hook of ctx, if any, and returns err
+func notifyRepoError(ctx context.Context, repo string, err error) error {
+	if fn, ok := ctx.Value(repoErrorHookKey{}).(func(repo string, err error)); ok && fn != nil {
+		fn(repo, err)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+var (
+	// mergeCommitPRPattern matches GitHub merge commit headers ("Merge pull request #123 from ...")
+	mergeCommitPRPattern = regexp.MustCompile(`^Merge pull request #(\d+)`)
+	// squashCommitPRPattern matches the "(#123)" suffix GitHub appends to squash and merge-queue commits
+	squashCommitPRPattern = regexp.MustCompile(`\(#(\d+)\)\s*$`)
+)
+
+// detectMergeCommit classifies a commit as a PR merge commit, a squash/merge-queue commit,
+// or a regular commit, returning the referenced PR number when it can be determined.
+// A squash commit is only a candidate: storage matches it against the merge commit SHA of
+// the PR it refers to, so that cherry-picks, reverts and commits of PRs that weren't
+// collected still count.
+// A commit with several parents is a PR merge commit only with GitHub's merge header; other
+// merges, such as a branch merged into another locally, are regular commits, so that
+// DEDUP_MERGE_COMMITS only leaves out commits whose changes a pull request already counts.
+func detectMergeCommit(message string, parentCount int) (kind string, prNumber int) {
+	header := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+
+	if parentCount > 1 {
+		if match := mergeCommitPRPattern.FindStringSubmatch(header); match != nil {
+			prNumber, _ = strconv.Atoi(match[1])
+			return domain.MergeKindMerge, prNumber
+		}
+		return "", 0
+	}
+
+	if match := squashCommitPRPattern.FindStringSubmatch(header); match != nil {
+		prNumber, _ = strconv.Atoi(match[1])
+		return domain.MergeKindSquash, prNumber
+	}
+
+	return "", 0
+}
+
+// ReclassifyCommit recomputes the fields of a stored commit event that are derived when it is
+// collected (merge_kind and pr_number) with the current rules, so that events collected by an
+// older version count the same as new ones. It reports whether the event changed.
+func ReclassifyCommit(event *domain.Event) bool {
+	if event.Type != domain.EventTypeCommit || event.Data == nil {
+		return false
+	}
+	message, _ := event.Data["message"].(string)
+	// Numbers decoded from the stored JSON are float64
+	parents, _ := event.Data["parent_count"].(float64)
+	kind, prNumber := detectMergeCommit(message, int(parents))
+
+	oldKind, _ := event.Data["merge_kind"].(string)
+	oldNumber, _ := event.Data["pr_number"].(float64)
+	if kind == oldKind && prNumber == int(oldNumber) {
+		return false
+	}
+	delete(event.Data, "merge_kind")
+	delete(event.Data, "pr_number")
+	if kind != "" {
+		event.Data["merge_kind"] = kind
+	}
+	if prNumber != 0 {
+		event.Data["pr_number"] = prNumber
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/oauth2"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/monitoring"
+)
+
+// Option configures how a collector connects to GitHub
+type Option func(*clientOptions)
+
+// clientOptions are the settings of the GitHub API client
+type clientOptions struct {
+	apiURL    string
+	transport http.RoundTripper
+	app       *AppCredentials
+
+	rateLimiter     RateLimiter // shared by the caller; nil shares one per credentials
+	rateLimitBudget float64     // fraction of the hourly quota to use; 0 uses all of it
+
+	repoCache *repoListCache // see WithRepoListCache
+
+	deployDefinitions DeployDefinitionStore // see WithDeployDefinitions
+}
+
+// WithAPIURL makes the collector use the GitHub Enterprise Server REST API at url, such as
+// https://github.example.com/api/v3, instead of github.com. An empty url keeps github.com.
+// The URL is expected to be valid (config.Validate checks GITHUB_API_URL).
+func WithAPIURL(url string) Option {
+	return func(o *clientOptions) {
+		o.apiURL = url
+	}
+}
+
+// WithTransport makes the collector connect to GitHub through transport, e.g. one with a
+// proxy or a private CA (config.HTTPTransport). A nil transport keeps the default.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithRateLimitBudget makes the collector use at most budget, a fraction in (0, 1], of the
+// hourly rate limit quota, e.g. 0.6 to leave 40% for other tools using the same token. The
+// budget is shared by every collector with the same credentials.
+func WithRateLimitBudget(budget float64) Option {
+	return func(o *clientOptions) {
+		o.rateLimitBudget = budget
+	}
+}
+
+// WithRateLimiter makes the collector wait on limiter, e.g. to share one between collectors
+// whose credentials draw on the same quota. By default collectors with the same token or
+// GitHub App installation share a rate limiter.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(o *clientOptions) {
+		o.rateLimiter = limiter
+	}
+}
+
+// newClient creates a GitHub API client authenticated with token
+func newClient(token string, opts []Option) *github.Client {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Requests are counted for monitoring; oauth2 wraps the transport of the context's client
+	// with the token
+	base := &http.Client{Transport: monitoring.InstrumentTransport(o.transport), Timeout: 30 * time.Second}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	var tokens oauth2.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	if o.app != nil {
+		tokens = sharedAppTokenSource(o.app, o.apiURL, base)
+	}
+	tc := oauth2.NewClient(ctx, tokens)
+	tc.Timeout = 30 * time.Second
+	client := github.NewClient(tc)
+	if o.apiURL != "" {
+		if enterprise, err := client.WithEnterpriseURLs(o.apiURL, o.apiURL); err == nil {
+			client = enterprise
+		}
+	}
+	return client
+}
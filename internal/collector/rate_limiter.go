@@ -2,46 +2,116 @@ package collector
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"sync"
 	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+const (
+	// defaultRateLimit is the hourly quota assumed until GitHub reports the actual one
+	defaultRateLimit = 5000
+	// minRateRemaining are the requests always left unused, so that other tools using the
+	// token aren't locked out
+	minRateRemaining = 10
 )
 
-// RateLimiter manages GitHub API rate limiting
+// errNoRateLimitClient is returned by CheckLimit of a rate limiter no collector uses yet
+var errNoRateLimitClient = errors.New("the rate limiter is not used by a collector yet")
+
+// RateLimiter manages GitHub API rate limiting. Collectors authenticated with the same
+// credentials share a rate limiter, as they use the same quota.
 type RateLimiter interface {
+	// Wait waits until it's safe to make another API call
 	Wait(ctx context.Context) error
-	CheckLimit() (remaining int, resetTime time.Time, err error)
-	UpdateLimit(remaining int, resetTime time.Time)
+	// CheckLimit queries the GitHub rate limit endpoint, which doesn't count against the
+	// limit, and updates the limiter with the result
+	CheckLimit(ctx context.Context) (remaining int, resetTime time.Time, err error)
+	// Limit returns the requests left and when the limit resets, as of the last response
+	Limit() (remaining int, resetTime time.Time)
+	// UpdateLimit updates the rate limit from API response headers; a limit of 0 keeps the
+	// known one
+	UpdateLimit(limit, remaining int, resetTime time.Time)
 }
 
 // githubRateLimiter implements RateLimiter for GitHub API
 type githubRateLimiter struct {
 	mu        sync.Mutex
+	client    *github.Client // queried by CheckLimit: the first collector's using the limiter
+	budget    float64        // fraction of the hourly quota that may be used, in (0, 1]
+	limit     int
 	remaining int
 	resetTime time.Time
+	checked   bool // the limit was queried from GitHub since the last reset
 	minDelay  time.Duration
 	lastCall  time.Time
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter() RateLimiter {
+// NewRateLimiter creates a new rate limiter that uses at most budget, a fraction in (0, 1],
+// of the hourly quota, e.g. 0.6 for 60%; other values use all of it. The limiter can be shared
+// by collectors with WithRateLimiter.
+func NewRateLimiter(budget float64) RateLimiter {
 	return &githubRateLimiter{
-		remaining: 5000, // GitHub API default limit
+		budget:    normalizeBudget(budget),
+		limit:     defaultRateLimit, // GitHub API default limit
+		remaining: defaultRateLimit,
 		resetTime: time.Now().Add(time.Hour),
 		minDelay:  100 * time.Millisecond, // Minimum delay between requests
 	}
 }
 
+// normalizeBudget returns budget if it's a fraction in (0, 1], or 1
+func normalizeBudget(budget float64) float64 {
+	if budget <= 0 || budget > 1 {
+		return 1
+	}
+	return budget
+}
+
+// reserved returns the requests of the quota left unused; r.mu must be held
+func (r *githubRateLimiter) reserved() int {
+	return reservedRequests(r.limit, r.budget)
+}
+
+// reservedRequests returns the requests of a quota of limit that a budget leaves unused
+func reservedRequests(limit int, budget float64) int {
+	reserved := int(math.Ceil(float64(limit) * (1 - normalizeBudget(budget))))
+	if reserved < minRateRemaining {
+		return minRateRemaining
+	}
+	return reserved
+}
+
+// UsableRequests returns how many of the remaining requests of a quota of limit collectors
+// may make within budget (see WithRateLimitBudget)
+func UsableRequests(limit, remaining int, budget float64) int {
+	return max(remaining-reservedRequests(limit, budget), 0)
+}
+
 // Wait waits until it's safe to make another API call
 func (r *githubRateLimiter) Wait(ctx context.Context) error {
+	// Plan with the actual quota before the first call, as it depends on the token and may
+	// have been partly used by other tools
+	r.checkOnce(ctx)
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// Check if we need to wait for rate limit reset
-	if r.remaining <= 10 {
+	if reserved := r.reserved(); r.remaining <= reserved {
 		waitDuration := time.Until(r.resetTime)
 		if waitDuration > 0 {
-			fmt.Printf("  Rate limit low (%d remaining), waiting %v until reset...\n", r.remaining, waitDuration.Round(time.Second))
+			if r.budget < 1 {
+				slog.Warn("rate limit budget used up, waiting until reset", "remaining", r.remaining, "reserved", reserved, "wait", waitDuration.Round(time.Second).String())
+			} else {
+				slog.Warn("rate limit low, waiting until reset", "remaining", r.remaining, "wait", waitDuration.Round(time.Second).String())
+			}
 			r.mu.Unlock()
 			select {
 			case <-ctx.Done():
@@ -50,11 +120,12 @@ func (r *githubRateLimiter) Wait(ctx context.Context) error {
 			case <-time.After(waitDuration):
 				r.mu.Lock()
 			}
-			fmt.Printf("  Rate limit reset, continuing...\n")
+			slog.Info("rate limit reset, continuing")
 		}
-		// Reset after waiting
-		r.remaining = 5000
+		// Reset after waiting; the next call checks the new quota
+		r.remaining = r.limit
 		r.resetTime = time.Now().Add(time.Hour)
+		r.checked = false
 	}
 
 	// Ensure minimum delay between requests
@@ -74,17 +145,113 @@ func (r *githubRateLimiter) Wait(ctx context.Context) error {
 	return nil
 }
 
-// CheckLimit returns the current rate limit status
-func (r *githubRateLimiter) CheckLimit() (remaining int, resetTime time.Time, err error) {
+// checkOnce queries the rate limit if it wasn't since the last reset. GitHub Enterprise
+// Server without rate limiting has no rate limit endpoint, and the limiter keeps its
+// estimate.
+func (r *githubRateLimiter) checkOnce(ctx context.Context) {
+	r.mu.Lock()
+	if r.checked || r.client == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.checked = true
+	r.mu.Unlock()
+
+	remaining, reset, err := r.CheckLimit(ctx)
+	if err != nil {
+		slog.Debug("failed to check the rate limit", "error", err)
+		return
+	}
+	r.mu.Lock()
+	limit, budget := r.limit, r.budget
+	r.mu.Unlock()
+	slog.Info("GitHub rate limit", "remaining", remaining, "limit", limit,
+		"budget", fmt.Sprintf("%.0f%%", budget*100), "usable", UsableRequests(limit, remaining, budget),
+		"reset", reset.Local().Format(time.RFC3339))
+}
+
+// CheckLimit queries the GitHub rate limit endpoint and updates the limiter with the result
+func (r *githubRateLimiter) CheckLimit(ctx context.Context) (remaining int, resetTime time.Time, err error) {
+	r.mu.Lock()
+	client := r.client
+	r.mu.Unlock()
+	if client == nil {
+		return 0, time.Time{}, errNoRateLimitClient
+	}
+
+	limits, _, err := client.RateLimits(ctx)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get the rate limit: %w", err)
+	}
+	core := limits.GetCore()
+	if core == nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get the rate limit: no core limit in the response")
+	}
+	r.UpdateLimit(core.Limit, core.Remaining, core.Reset.Time)
+	return core.Remaining, core.Reset.Time, nil
+}
+
+// Limit returns the current rate limit status
+func (r *githubRateLimiter) Limit() (remaining int, resetTime time.Time) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.remaining, r.resetTime, nil
+	return r.remaining, r.resetTime
 }
 
 // UpdateLimit updates the rate limit from API response headers
-func (r *githubRateLimiter) UpdateLimit(remaining int, resetTime time.Time) {
+func (r *githubRateLimiter) UpdateLimit(limit, remaining int, resetTime time.Time) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if limit > 0 {
+		r.limit = limit
+	}
 	r.remaining = remaining
 	r.resetTime = resetTime
 }
+
+// setBudget changes the fraction of the quota the limiter may use
+func (r *githubRateLimiter) setBudget(budget float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budget = normalizeBudget(budget)
+}
+
+// bind makes CheckLimit query GitHub with client, unless the limiter has a client already
+func (r *githubRateLimiter) bind(client *github.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		r.client = client
+	}
+}
+
+// rateLimiters are the rate limiters shared by collectors, keyed by their credentials
+var rateLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*githubRateLimiter
+}
+
+// sharedRateLimiter returns the rate limiter of the credentials of token and o, creating it
+// once; its budget becomes budget, so that reloaded settings apply to the next collection
+func sharedRateLimiter(token string, o clientOptions, budget float64) RateLimiter {
+	key := o.apiURL + "|"
+	if o.app != nil {
+		key += fmt.Sprintf("app|%d|%d", o.app.AppID, o.app.InstallationID)
+	} else {
+		sum := sha256.Sum256([]byte(token))
+		key += "token|" + hex.EncodeToString(sum[:])
+	}
+
+	rateLimiters.mu.Lock()
+	defer rateLimiters.mu.Unlock()
+	if limiter, ok := rateLimiters.limiters[key]; ok {
+		limiter.setBudget(budget)
+		return limiter
+	}
+	if rateLimiters.limiters == nil {
+		rateLimiters.limiters = make(map[string]*githubRateLimiter)
+	}
+	limiter := NewRateLimiter(budget).(*githubRateLimiter)
+	rateLimiters.limiters[key] = limiter
+	return limiter
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// RepoListStore keeps the repository lists cached by WithRepoListCache; storage.Storage is one
+type RepoListStore interface {
+	// GetRepositoryList returns the stored repository list of an owner, or nil if there is none
+	GetRepositoryList(ctx context.Context, owner, ownerType string) (*domain.RepositoryList, error)
+	// SaveRepositoryList stores a repository list, replacing the owner's previous one
+	SaveRepositoryList(ctx context.Context, list *domain.RepositoryList) error
+}
+
+// repoListCache caches the repository lists of owners in a RepoListStore
+type repoListCache struct {
+	store RepoListStore
+	ttl   time.Duration
+}
+
+// WithRepoListCache makes GetRepositories and GetUserRepositories, also called by the
+// collection methods, return the owner's repository list stored within ttl instead of
+// listing it from GitHub, and store the lists they fetch. Collections shortly after another,
+// such as retries of failed repositories, then don't page through every repository again.
+// A ttl of 0 always lists repositories from GitHub, refreshing the stored list.
+func WithRepoListCache(store RepoListStore, ttl time.Duration) Option {
+	return func(o *clientOptions) {
+		o.repoCache = &repoListCache{store: store, ttl: ttl}
+	}
+}
+
+// GetRepositories retrieves all repositories for an organization
+func (c *githubCollector) GetRepositories(ctx context.Context, org string) ([]*domain.Repository, error) {
+	return c.repositories(ctx, org, "organization", c.listRepositories)
+}
+
+// GetUserRepositories retrieves all repositories for a user
+func (c *githubCollector) GetUserRepositories(ctx context.Context, user string) ([]*domain.Repository, error) {
+	return c.repositories(ctx, user, "user", c.listUserRepositories)
+}
+
+// repositories returns the owner's cached repository list if it's recent enough, or lists
+// the repositories with list and caches them. Failing to read or write the cache only costs
+// listing the repositories again.
+func (c *githubCollector) repositories(ctx context.Context, owner, ownerType string, list func(context.Context, string) ([]*domain.Repository, error)) ([]*domain.Repository, error) {
+	cache := c.repoCache
+	if cache == nil {
+		return list(ctx, owner)
+	}
+	// GitHub owner names are case insensitive
+	key := strings.ToLower(owner)
+
+	if cache.ttl > 0 {
+		cached, err := cache.store.GetRepositoryList(ctx, key, ownerType)
+		switch {
+		case err != nil:
+			slog.Warn("failed to read the cached repository list", "owner", owner, "error", err)
+		case cached != nil && time.Since(cached.FetchedAt) < cache.ttl:
+			slog.Debug("using the cached repository list", "owner", owner, "repos", len(cached.Repos),
+				"fetched_at", cached.FetchedAt.Format(time.RFC3339))
+			return ownedRepos(cached.Repos, owner), nil
+		}
+	}
+
+	repos, err := list(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	err = cache.store.SaveRepositoryList(ctx, &domain.RepositoryList{
+		Owner:     key,
+		OwnerType: ownerType,
+		Repos:     repos,
+		FetchedAt: time.Now(),
+	})
+	if err != nil {
+		slog.Warn("failed to cache the repository list", "owner", owner, "error", err)
+	}
+	return repos, nil
+}
+
+// ownedRepos returns cached repositories as owned by owner, spelled as asked for, like
+// listed ones
+func ownedRepos(repos []*domain.Repository, owner string) []*domain.Repository {
+	for _, repo := range repos {
+		repo.Org = owner
+	}
+	return repos
+}
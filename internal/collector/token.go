@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when GitHub rejects a token
+var ErrInvalidToken = errors.New("GitHub rejected the token (bad credentials)")
+
+// TokenInfo describes the account, scopes and rate limit of a GitHub token
+type TokenInfo struct {
+	Login string
+
+	// Scopes are the OAuth scopes of a classic token; ScopesKnown is false for fine-grained
+	// and GitHub App tokens, whose permissions GitHub does not report
+	Scopes      []string
+	ScopesKnown bool
+
+	RateLimit     int
+	RateRemaining int
+	RateReset     time.Time
+}
+
+// InspectToken looks up the user a token belongs to, which reports its scopes and rate limit
+// without using up the core rate limit beyond a single request. With WithAppAuth, it reports
+// the rate limit of the app's installation instead.
+func InspectToken(ctx context.Context, token string, opts ...Option) (*TokenInfo, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.app != nil {
+		return inspectApp(ctx, token, opts)
+	}
+	client := newClient(token, opts)
+
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	info := &TokenInfo{
+		Login:         user.GetLogin(),
+		RateLimit:     resp.Rate.Limit,
+		RateRemaining: resp.Rate.Remaining,
+		RateReset:     resp.Rate.Reset.Time,
+	}
+	if header := resp.Header.Values("X-OAuth-Scopes"); len(header) > 0 {
+		info.ScopesKnown = true
+		for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+	return info, nil
+}
+
+// scopeImpliedBy lists the broader scopes that include a scope
+var scopeImpliedBy = map[string][]string{
+	"read:org": {"write:org", "admin:org"},
+}
+
+// MissingScopes returns the scopes collection needs in mode ("organization" or "user") that
+// the token lacks. Nothing is reported when the scopes are not known.
+func (t *TokenInfo) MissingScopes(mode string) []string {
+	if !t.ScopesKnown {
+		return nil
+	}
+	required := []string{"repo"}
+	if mode == "organization" {
+		required = append(required, "read:org")
+	}
+
+	has := make(map[string]bool, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		has[scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		ok := has[scope]
+		for _, broader := range scopeImpliedBy[scope] {
+			ok = ok || has[broader]
+		}
+		if !ok {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// WebhookEvents converts a GitHub webhook payload into raw events. It supports push,
+// pull_request, deployment and deployment_status deliveries; other event types yield
+// no events. IDs match the ones generated by the collector so that webhook deliveries
+// and later collections of the same data don't produce duplicates.
+func WebhookEvents(eventType string, payload []byte) ([]*domain.Event, error) {
+	switch eventType {
+	case "push", "pull_request", "deployment", "deployment_status":
+	default:
+		return nil, nil
+	}
+
+	parsed, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s webhook payload: %w", eventType, err)
+	}
+
+	now := time.Now()
+	switch e := parsed.(type) {
+	case *github.PushEvent:
+		return pushEvents(e, now), nil
+	case *github.PullRequestEvent:
+		return pullRequestEvents(e, now), nil
+	case *github.DeploymentEvent:
+		return deployEvents(e.GetRepo(), e.GetDeployment(), "pending", now), nil
+	case *github.DeploymentStatusEvent:
+		return deployEvents(e.GetRepo(), e.GetDeployment(), e.GetDeploymentStatus().GetState(), now), nil
+	default:
+		return nil, nil
+	}
+}
+
+// webhookOwnerType maps a GitHub account type to the owner type stored with events
+func webhookOwnerType(owner *github.User) string {
+	if strings.EqualFold(owner.GetType(), "User") {
+		return "user"
+	}
+	return "organization"
+}
+
+// pushEvents converts the commits of a push delivery into commit events. Push payloads
+// don't include line statistics or parent counts, so additions/deletions are left at
+// zero and merge commits are detected from the message alone.
+func pushEvents(e *github.PushEvent, now time.Time) []*domain.Event {
+	repo := e.GetRepo()
+	org := repo.GetOwner().GetLogin()
+	name := repo.GetName()
+	ownerType := webhookOwnerType(repo.GetOwner())
+
+	var events []*domain.Event
+	for _, commit := range e.Commits {
+		if !commit.GetDistinct() {
+			// Already delivered with an earlier push
+			continue
+		}
+
+		author := commit.GetAuthor().GetLogin()
+		if author == "" {
+			author = commit.GetAuthor().GetName()
+		}
+
+		parentCount := 1
+		header := strings.TrimSpace(strings.SplitN(commit.GetMessage(), "\n", 2)[0])
+		if mergeCommitPRPattern.MatchString(header) {
+			parentCount = 2
+		}
+		mergeKind, prNumber := detectMergeCommit(commit.GetMessage(), parentCount)
+
+		commitEvent := &domain.CommitEvent{
+			ID:           fmt.Sprintf("%s-%s-commit-%s", org, name, commit.GetID()),
+			Org:          org,
+			Repo:         name,
+			Member:       author,
+			OwnerType:    ownerType,
+			Timestamp:    commit.GetTimestamp().Time,
+			Sha:          commit.GetID(),
+			Message:      commit.GetMessage(),
+			FilesChanged: len(commit.Added) + len(commit.Removed) + len(commit.Modified),
+			Files:        pushedFiles(commit),
+			ParentCount:  parentCount,
+			MergeKind:    mergeKind,
+			PRNumber:     prNumber,
+			CreatedAt:    now,
+		}
+		events = append(events, commitEvent.ToEvent())
+	}
+	return events
+}
+
+// pullRequestEvents converts a pull_request delivery into a pull request event
+func pullRequestEvents(e *github.PullRequestEvent, now time.Time) []*domain.Event {
+	pr := e.GetPullRequest()
+	if pr == nil {
+		return nil
+	}
+	repo := e.GetRepo()
+	org := repo.GetOwner().GetLogin()
+
+	state := pr.GetState()
+	if pr.GetMerged() {
+		state = "merged"
+	}
+
+	var mergedAt *time.Time
+	if pr.MergedAt != nil {
+		t := pr.MergedAt.Time
+		mergedAt = &t
+	}
+
+	prEvent := &domain.PullRequestEvent{
+		ID:             fmt.Sprintf("%s-%s-pr-%d", org, repo.GetName(), pr.GetNumber()),
+		Org:            org,
+		Repo:           repo.GetName(),
+		Member:         pr.GetUser().GetLogin(),
+		OwnerType:      webhookOwnerType(repo.GetOwner()),
+		Timestamp:      pr.GetCreatedAt().Time,
+		Number:         pr.GetNumber(),
+		State:          state,
+		Title:          pr.GetTitle(),
+		MergedAt:       mergedAt,
+		MergeCommitSHA: pr.GetMergeCommitSHA(),
+		CreatedAt:      now,
+	}
+	return []*domain.Event{prEvent.ToEvent()}
+}
+
+// deployEvents converts a deployment or deployment_status delivery into a deploy event
+func deployEvents(repo *github.Repository, deployment *github.Deployment, status string, now time.Time) []*domain.Event {
+	if deployment == nil {
+		return nil
+	}
+	org := repo.GetOwner().GetLogin()
+
+	deployEvent := &domain.DeployEvent{
+		ID:            fmt.Sprintf("%s-%s-deploy-%d", org, repo.GetName(), deployment.GetID()),
+		Org:           org,
+		Repo:          repo.GetName(),
+		Member:        deployment.GetCreator().GetLogin(),
+		OwnerType:     webhookOwnerType(repo.GetOwner()),
+		Timestamp:     deployment.GetCreatedAt().Time,
+		Environment:   deployment.GetEnvironment(),
+		Status:        status,
+		WorkflowRunID: fmt.Sprintf("%d", deployment.GetID()),
+		Source:        domain.DeploySourceDeployments,
+		CreatedAt:     now,
+	}
+	return []*domain.Event{deployEvent.ToEvent()}
+}
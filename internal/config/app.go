@@ -0,0 +1,84 @@
+package config
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// UsesGitHubApp reports whether the collector authenticates as a GitHub App rather than with
+// GITHUB_TOKEN
+func (c *Config) UsesGitHubApp() bool {
+	return c.GitHubAppID != 0
+}
+
+// GitHubAppKey returns the private key of the GitHub App, from GITHUB_APP_PRIVATE_KEY or the
+// file of GITHUB_APP_PRIVATE_KEY_PATH
+func (c *Config) GitHubAppKey() (*rsa.PrivateKey, error) {
+	data := []byte(c.GitHubAppPrivateKey)
+	if c.GitHubAppPrivateKey == "" {
+		if c.GitHubAppPrivateKeyPath == "" {
+			return nil, errors.New("GITHUB_APP_PRIVATE_KEY_PATH or GITHUB_APP_PRIVATE_KEY is required")
+		}
+		var err error
+		if data, err = os.ReadFile(c.GitHubAppPrivateKeyPath); err != nil {
+			return nil, err
+		}
+	} else if !strings.Contains(c.GitHubAppPrivateKey, "\n") {
+		// A PEM in a single-line variable has its line breaks escaped
+		data = []byte(strings.ReplaceAll(c.GitHubAppPrivateKey, `\n`, "\n"))
+	}
+	return parseRSAPrivateKey(data)
+}
+
+// parseRSAPrivateKey parses the PEM of an RSA private key, as downloaded from the GitHub App
+// settings (PKCS #1) or converted to PKCS #8
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM private key")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA private key: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("not an RSA private key; GitHub Apps use RSA keys")
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q; expected an RSA private key", block.Type)
+	}
+}
+
+// appProblems returns the problems of the GitHub App settings
+func (c *Config) appProblems() []*ConfigError {
+	var problems []*ConfigError
+	if c.GitHubAppID < 0 {
+		problems = append(problems, &ConfigError{Field: "GITHUB_APP_ID", Message: "must be the app's numeric ID"})
+	}
+	if c.GitHubAppInstallationID <= 0 {
+		problems = append(problems, &ConfigError{Field: "GITHUB_APP_INSTALLATION_ID", Message: "is required with GITHUB_APP_ID: the ID of the app's installation on the organization or user"})
+	}
+	if _, err := c.GitHubAppKey(); err != nil {
+		field := "GITHUB_APP_PRIVATE_KEY_PATH"
+		if c.GitHubAppPrivateKey != "" {
+			field = "GITHUB_APP_PRIVATE_KEY"
+		}
+		problems = append(problems, &ConfigError{Field: field, Message: err.Error()})
+	}
+	return problems
+}
@@ -1,7 +1,19 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -9,37 +21,482 @@ import (
 // Config holds the application configuration
 type Config struct {
 	// GitHub
-	GitHubToken string
-	Mode        string // "organization" or "user"
+	GitHubToken  string
+	GitHubTokens map[string]string // tokens of owners that need their own, keyed by lower-case owner
+	GitHubAPIURL string            // GitHub Enterprise Server REST API URL; empty for github.com
+	Mode         string            // "organization" or "user"
+
+	// GitHubRateLimitBudget is the percentage of the hourly rate limit quota collections may
+	// use, leaving the rest to other tools using the same token
+	GitHubRateLimitBudget int
+
+	// GitHub App authentication, instead of a token: the collector authenticates as the
+	// app's installation
+	GitHubAppID             int64
+	GitHubAppInstallationID int64
+	GitHubAppPrivateKeyPath string // PEM file of the app's private key
+	GitHubAppPrivateKey     string // the PEM itself, instead of a file
+
+	// Network of connections to the GitHub API, for corporate networks
+	HTTPProxy  string // proxy of http:// requests
+	HTTPSProxy string // proxy of https:// requests
+	NoProxy    string // comma-separated hosts and domains connected to directly
+	CACertPath string // PEM file of CA certificates trusted besides the system ones
 
 	// Storage
 	StorageType string // "sqlite" or "postgres"
 	SQLitePath  string
 	PostgresURL string
+	AutoMigrate bool // apply pending schema migrations whenever the database is opened
 
 	// API Server
 	APIPort string
 	APIHost string
 
+	// API server timeouts
+	APIReadTimeout     time.Duration // maximum duration for reading a request, including the body
+	APIWriteTimeout    time.Duration // maximum duration before timing out writes of a response
+	APIIdleTimeout     time.Duration // maximum time to wait for the next request on keep-alive connections
+	APIShutdownTimeout time.Duration // how long to wait for in-flight requests on shutdown
+
+	// gRPC server (MetricsService), served by the API process on its own port
+	GRPCPort string // empty disables the gRPC server
+
+	// Live updates (WebSocket /ws)
+	LivePollInterval time.Duration // how often subscribed owners are checked for new events; 0 disables /ws
+
+	// GitHub webhook ingestion (POST /api/v1/webhooks/github)
+	GitHubWebhookSecret string // secret used to verify delivery signatures; empty disables the endpoint
+
+	// Slack slash command (POST /api/v1/slack/commands)
+	SlackSigningSecret string   // signing secret of the Slack app, used to verify requests; empty disables the endpoint
+	SlackAllowedOwners []string // owners the command may show; "*" for all, empty for none
+	SlackInChannel     bool     // post results to the channel rather than only to the user
+
+	// API response compression
+	APIGzip bool // gzip JSON/text responses for clients that accept it
+
+	// CORS
+	CORSOrigins []string // origins allowed to make cross-origin requests; "*" for all
+
+	// Reverse proxies whose X-Forwarded-For / X-Real-IP headers are trusted for the client IP
+	TrustedProxies []string // IP addresses or CIDR ranges; empty uses the connection's remote address
+
+	// API authentication
+	AuthEnabled bool           // require an API key on /api routes
+	APIKeys     []APIKeyConfig // keys configured through the environment (in addition to stored keys)
+
+	// Viewer tokens: signed read-only tokens for a single owner, shared as dashboard links
+	ViewerTokenSecret string // signing secret; empty disables viewer tokens
+
+	// API access audit log
+	AuditLogEnabled bool // record every /api request (principal, owner, route, status) in storage
+
+	// Idempotency-Key handling of mutating requests and webhook deliveries (API server)
+	IdempotencyTTL time.Duration // how long responses are kept for retries; 0 disables deduplication
+
+	// OpenTelemetry tracing (API server); the OTLP endpoint is read from OTEL_EXPORTER_OTLP_ENDPOINT
+	TracingEnabled     bool    // export spans for requests, aggregations and database queries
+	TracingServiceName string  // service.name resource attribute
+	TracingProtocol    string  // OTLP protocol: "grpc" or "http/protobuf"
+	TracingSampleRatio float64 // fraction of traces sampled, 0 to 1
+
+	// Logging
+	LogLevel  string // "debug", "info", "warn" or "error"
+	LogFormat string // "json" or "text"
+
+	// API rate limiting (per API key, or per IP for unauthenticated requests)
+	RateLimitRPS   float64 // sustained requests per second; 0 disables rate limiting
+	RateLimitBurst int     // maximum burst size
+
+	// Aggregation cache (API server only)
+	CacheSize int           // maximum number of cached results; 0 disables the cache
+	CacheTTL  time.Duration // maximum age of a cached result
+
+	// Aggregation
+	DedupMergeCommits bool          // exclude PR merge/squash commits from commit counts
+	ExcludeBots       bool          // leave bot accounts out of member tables and rankings
+	BotPatterns       []string      // globs of logins treated as bots besides "*[bot]"
+	StreamChunk       time.Duration // time chunk length used when aggregating raw events
+
+	// Pseudonymization: member usernames are replaced with pseudonyms keyed with PseudonymSecret
+	// in API responses, exports and reports
+	PseudonymizeMembers bool
+	PseudonymSecret     string
+
 	// CLI
 	APIEndpoint string
+
+	// Prometheus exporter (cmd/exporter)
+	ExporterHost     string
+	ExporterPort     string
+	ExporterOwners   []string      // owners whose metrics are exported; empty for every owner in storage
+	ExporterWindow   time.Duration // time window the exported metrics are totaled over, ending now
+	ExporterInterval time.Duration // how often the exported metrics are recomputed
+
+	// Collection
+	CollectConcurrency  int           // repositories collected from GitHub at once; 0 uses the collector default
+	CollectRepos        []string      // globs of repositories collected when none are given on the command line
+	CollectExcludeRepos []string      // globs of repositories never collected unless overridden on the command line
+	CollectMetricsAddr  string        // address collect and schedule serve Prometheus metrics on, e.g. ":9102"; empty disables
+	CollectRepoCacheTTL time.Duration // how long collections reuse an owner's repository list; 0 lists them every time
+
+	// Defaults of the CLI's --start and --granularity (see Defaults)
+	DefaultRange       string // time ago the range starts at, such as "30d" or "LAST_30_DAYS"
+	DefaultGranularity string // "day", "week" or "month"
+
+	// Scheduled collection (github-metrics schedule)
+	Schedules []ScheduleConfig // owners collected on a cron schedule
+
+	// Components of monorepos, each owning the files under its path prefixes
+	RepoComponents []ComponentConfig
+
+	// Notifications
+	SlackWebhookURL string // incoming webhook that collect and report --notify-slack post to
+
+	// Outbound webhooks POSTing signed metric summaries to external systems
+	MetricsHookURLs       []string
+	MetricsHookSecret     string            // HMAC-SHA256 key signing each delivery; empty sends them unsigned
+	MetricsHookThresholds []ThresholdConfig // metric bounds whose breach after a collection is delivered
+	MetricsHookAnomaly    float64           // change from the previous window delivered as an anomaly; 0 disables
+	MetricsHookWindow     time.Duration     // window the metrics of a delivery are aggregated over
+
+	// Email delivery of reports (report --email-to, scheduled reports)
+	SMTPHost        string
+	SMTPPort        int // 465 for implicit TLS; otherwise STARTTLS is used when offered
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string           // sender address
+	ReportSchedules []ScheduleConfig // owners whose report the scheduler emails on a cron schedule
+	ReportPeriod    string           // period of scheduled reports: weekly, monthly or quarterly
+	ReportEmailTo   []string         // recipients of scheduled reports
 }
 
-// Load loads the configuration from environment variables
+// dotenvState tracks which variables were set from the .env and configuration files, so
+// that Reload can replace them without overriding the process environment
+var dotenvState struct {
+	mu      sync.Mutex
+	loaded  bool
+	process map[string]bool // variables set in the process environment before .env was read
+	keys    map[string]bool // variables currently set from .env or the configuration file
+}
+
+// Load loads the configuration from environment variables, which take precedence over the
+// .env file, which takes precedence over the configuration file (see FilePath). It may be
+// called again to pick up changes: variables set from the files are updated or unset to
+// match them, while variables set in the process environment keep precedence.
+//
+// Secrets such as GITHUB_TOKEN may instead be read from a file (GITHUB_TOKEN_FILE) or
+// fetched from Vault or AWS Secrets Manager (see resolveSecrets).
 func Load() (*Config, error) {
+	fileValues, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
 	// Load .env file if it exists (ignore error if not found)
-	_ = godotenv.Load()
+	loadDotenv(fileValues)
+
+	cfg := fromEnv()
+	if err := cfg.resolveSecrets(context.Background()); err != nil {
+		return nil, err
+	}
+	// Refuse to run rather than show pseudonyms anyone could recompute from the usernames
+	if cfg.PseudonymizeMembers && cfg.PseudonymSecret == "" {
+		return nil, &ConfigError{Field: "PSEUDONYM_SECRET", Message: "is required when PSEUDONYMIZE_MEMBERS is true"}
+	}
+	return cfg, nil
+}
+
+// loadDotenv applies the .env file, over defaults from the configuration file, to the
+// environment
+func loadDotenv(defaults map[string]string) {
+	dotenvState.mu.Lock()
+	defer dotenvState.mu.Unlock()
+
+	if !dotenvState.loaded {
+		dotenvState.process = make(map[string]bool)
+		for _, kv := range os.Environ() {
+			if k, _, ok := strings.Cut(kv, "="); ok {
+				dotenvState.process[k] = true
+			}
+		}
+		dotenvState.keys = make(map[string]bool)
+		dotenvState.loaded = true
+	}
+
+	values, err := godotenv.Read()
+	if err != nil {
+		values = map[string]string{}
+	}
+	for k, v := range defaults {
+		if _, ok := values[k]; !ok {
+			values[k] = v
+		}
+	}
+	for k := range dotenvState.keys {
+		if _, ok := values[k]; !ok {
+			_ = os.Unsetenv(k)
+			delete(dotenvState.keys, k)
+		}
+	}
+	for k, v := range values {
+		if dotenvState.process[k] {
+			continue
+		}
+		_ = os.Setenv(k, v)
+		dotenvState.keys[k] = true
+	}
+}
 
+// fromEnv builds the configuration from environment variables
+func fromEnv() *Config {
 	return &Config{
-		GitHubToken: getEnv("GITHUB_TOKEN", ""),
-		Mode:        getEnv("MODE", "organization"), // "organization" or "user"
-		StorageType: getEnv("STORAGE_TYPE", "sqlite"),
-		SQLitePath:  getEnv("SQLITE_PATH", "./metrics.db"),
-		PostgresURL: getEnv("POSTGRES_URL", ""),
-		APIPort:     getEnv("API_PORT", "8080"),
-		APIHost:     getEnv("API_HOST", "localhost"),
-		APIEndpoint: getEnv("API_ENDPOINT", "http://localhost:8080"),
-	}, nil
+		GitHubToken:             getEnv("GITHUB_TOKEN", ""),
+		GitHubTokens:            parseOwnerTokens(getEnv("GITHUB_TOKENS", "")),
+		GitHubAPIURL:            getEnv("GITHUB_API_URL", ""),
+		Mode:                    getEnv("MODE", "organization"), // "organization" or "user"
+		GitHubRateLimitBudget:   getEnvInt("GITHUB_RATE_LIMIT_BUDGET", 100),
+		GitHubAppID:             getEnvInt64("GITHUB_APP_ID", 0),
+		GitHubAppInstallationID: getEnvInt64("GITHUB_APP_INSTALLATION_ID", 0),
+		GitHubAppPrivateKeyPath: getEnv("GITHUB_APP_PRIVATE_KEY_PATH", ""),
+		GitHubAppPrivateKey:     getEnv("GITHUB_APP_PRIVATE_KEY", ""),
+		HTTPProxy:               getEnv("HTTP_PROXY", getEnv("http_proxy", "")),
+		HTTPSProxy:              getEnv("HTTPS_PROXY", getEnv("https_proxy", "")),
+		NoProxy:                 getEnv("NO_PROXY", getEnv("no_proxy", "")),
+		CACertPath:              getEnv("CA_CERT_PATH", ""),
+		StorageType:             getEnv("STORAGE_TYPE", "sqlite"),
+		SQLitePath:              getEnv("SQLITE_PATH", "./metrics.db"),
+		PostgresURL:             getEnv("POSTGRES_URL", ""),
+		AutoMigrate:             getEnvBool("AUTO_MIGRATE", true),
+		APIPort:                 getEnv("API_PORT", "8080"),
+		APIHost:                 getEnv("API_HOST", "localhost"),
+		APIReadTimeout:          getEnvDuration("API_READ_TIMEOUT", 15*time.Second),
+		APIWriteTimeout:         getEnvDuration("API_WRITE_TIMEOUT", 60*time.Second),
+		APIIdleTimeout:          getEnvDuration("API_IDLE_TIMEOUT", 120*time.Second),
+		APIShutdownTimeout:      getEnvDuration("API_SHUTDOWN_TIMEOUT", 30*time.Second),
+		APIGzip:                 getEnvBool("API_GZIP", true),
+		CORSOrigins:             parseList(getEnv("CORS_ORIGINS", "*")),
+		TrustedProxies:          parseList(getEnv("TRUSTED_PROXIES", "")),
+		GRPCPort:                getEnv("GRPC_PORT", ""),
+		LivePollInterval:        getEnvDuration("LIVE_POLL_INTERVAL", 10*time.Second),
+		GitHubWebhookSecret:     getEnv("GITHUB_WEBHOOK_SECRET", ""),
+		SlackSigningSecret:      getEnv("SLACK_SIGNING_SECRET", ""),
+		SlackAllowedOwners:      parseList(getEnv("SLACK_ALLOWED_OWNERS", "")),
+		SlackInChannel:          getEnvBool("SLACK_IN_CHANNEL", false),
+		AuthEnabled:             getEnvBool("API_AUTH_ENABLED", false),
+		APIKeys:                 parseAPIKeys(getEnv("API_KEYS", "")),
+		ViewerTokenSecret:       getEnv("VIEWER_TOKEN_SECRET", ""),
+		AuditLogEnabled:         getEnvBool("AUDIT_LOG_ENABLED", false),
+		IdempotencyTTL:          getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		TracingEnabled:          getEnvBool("TRACING_ENABLED", false),
+		TracingServiceName:      getEnv("OTEL_SERVICE_NAME", "github-activity-metrics"),
+		TracingProtocol:         getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc"),
+		TracingSampleRatio:      getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		LogFormat:               getEnv("LOG_FORMAT", "json"),
+		RateLimitRPS:            getEnvFloat("RATE_LIMIT_RPS", 0),
+		RateLimitBurst:          getEnvInt("RATE_LIMIT_BURST", 20),
+		CacheSize:               getEnvInt("AGGREGATOR_CACHE_SIZE", 256),
+		CacheTTL:                getEnvDuration("AGGREGATOR_CACHE_TTL", 5*time.Minute),
+		DedupMergeCommits:       getEnvBool("DEDUP_MERGE_COMMITS", false),
+		ExcludeBots:             getEnvBool("EXCLUDE_BOTS", false),
+		BotPatterns:             parseList(getEnv("BOT_PATTERNS", "")),
+		StreamChunk:             getEnvDuration("AGGREGATOR_STREAM_CHUNK", 720*time.Hour),
+		PseudonymizeMembers:     getEnvBool("PSEUDONYMIZE_MEMBERS", false),
+		PseudonymSecret:         getEnv("PSEUDONYM_SECRET", ""),
+		APIEndpoint:             getEnv("API_ENDPOINT", "http://localhost:8080"),
+		ExporterHost:            getEnv("EXPORTER_HOST", "localhost"),
+		ExporterPort:            getEnv("EXPORTER_PORT", "9101"),
+		ExporterOwners:          parseList(getEnv("EXPORTER_OWNERS", "")),
+		ExporterWindow:          getEnvDuration("EXPORTER_WINDOW", 720*time.Hour),
+		ExporterInterval:        getEnvDuration("EXPORTER_INTERVAL", 5*time.Minute),
+		CollectConcurrency:      getEnvInt("COLLECT_CONCURRENCY", 5),
+		CollectRepos:            parseList(getEnv("COLLECT_REPOS", "")),
+		CollectExcludeRepos:     parseList(getEnv("COLLECT_EXCLUDE_REPOS", "")),
+		CollectMetricsAddr:      getEnv("COLLECT_METRICS_ADDR", ""),
+		CollectRepoCacheTTL:     getEnvDuration("COLLECT_REPO_CACHE_TTL", 10*time.Minute),
+		DefaultRange:            getEnv("DEFAULT_RANGE", ""),
+		DefaultGranularity:      getEnv("DEFAULT_GRANULARITY", ""),
+		Schedules:               parseSchedules(getEnv("COLLECT_SCHEDULES", "")),
+		RepoComponents:          parseComponents(getEnv("REPO_COMPONENTS", "")),
+		SlackWebhookURL:         getEnv("SLACK_WEBHOOK_URL", ""),
+		MetricsHookURLs:         parseList(getEnv("METRICS_WEBHOOK_URLS", "")),
+		MetricsHookSecret:       getEnv("METRICS_WEBHOOK_SECRET", ""),
+		MetricsHookThresholds:   parseThresholds(getEnv("METRICS_WEBHOOK_THRESHOLDS", "")),
+		MetricsHookAnomaly:      getEnvFloat("METRICS_WEBHOOK_ANOMALY_PERCENT", 0),
+		MetricsHookWindow:       getEnvDuration("METRICS_WEBHOOK_WINDOW", 7*24*time.Hour),
+		SMTPHost:                getEnv("SMTP_HOST", ""),
+		SMTPPort:                getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:            getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:            getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                getEnv("SMTP_FROM", ""),
+		ReportSchedules:         parseSchedules(getEnv("REPORT_SCHEDULES", "")),
+		ReportPeriod:            getEnv("REPORT_PERIOD", "weekly"),
+		ReportEmailTo:           parseList(getEnv("REPORT_EMAIL_TO", "")),
+	}
+}
+
+// TokenFor returns the GitHub token to collect owner with: its own from GITHUB_TOKENS, or else
+// GITHUB_TOKEN
+func (c *Config) TokenFor(owner string) string {
+	if token, ok := c.GitHubTokens[strings.ToLower(owner)]; ok {
+		return token
+	}
+	return c.GitHubToken
+}
+
+// HasOwnToken reports whether owner has a token of its own in GITHUB_TOKENS
+func (c *Config) HasOwnToken(owner string) bool {
+	_, ok := c.GitHubTokens[strings.ToLower(owner)]
+	return ok
+}
+
+// parseOwnerTokens parses a semicolon-separated list of "owner=token" entries
+func parseOwnerTokens(value string) map[string]string {
+	tokens := make(map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		owner, token, _ := strings.Cut(entry, "=")
+		owner, token = strings.TrimSpace(owner), strings.TrimSpace(token)
+		if owner == "" && token == "" {
+			continue
+		}
+		tokens[strings.ToLower(owner)] = token
+	}
+	return tokens
+}
+
+// APIKeyConfig is an API key configured through the environment
+type APIKeyConfig struct {
+	Key    string
+	Scope  string   // "read" or "admin"
+	Owners []string // owners the key may view; "*" for all
+}
+
+// parseAPIKeys parses a comma-separated list of "key[:scope[:owner|owner...]]" entries.
+// The scope defaults to "read" and the owners to "*".
+func parseAPIKeys(value string) []APIKeyConfig {
+	var keys []APIKeyConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		k := APIKeyConfig{Key: parts[0], Scope: "read", Owners: []string{"*"}}
+		if len(parts) > 1 && parts[1] != "" {
+			k.Scope = parts[1]
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			k.Owners = strings.Split(parts[2], "|")
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ScheduleConfig is an owner collected on a schedule
+type ScheduleConfig struct {
+	Owner string
+	Spec  string // cron expression, e.g. "0 2 * * *" or "@every 6h"
+}
+
+// parseSchedules parses a semicolon-separated list of "owner=cron expression" entries
+func parseSchedules(value string) []ScheduleConfig {
+	var schedules []ScheduleConfig
+	for _, entry := range strings.Split(value, ";") {
+		owner, spec, _ := strings.Cut(entry, "=")
+		owner, spec = strings.TrimSpace(owner), strings.TrimSpace(spec)
+		if owner == "" && spec == "" {
+			continue
+		}
+		schedules = append(schedules, ScheduleConfig{Owner: owner, Spec: spec})
+	}
+	return schedules
+}
+
+// ComponentConfig is a logical component of a repository, such as a service of a monorepo
+type ComponentConfig struct {
+	Owner string
+	Repo  string
+	Name  string
+	Paths []string // path prefixes of the files the component owns, e.g. "services/api"
+}
+
+// parseComponents parses a semicolon-separated list of "owner/repo/component=path,path"
+// entries. Entries that can't be parsed are kept with what could be, for Problems to report.
+func parseComponents(value string) []ComponentConfig {
+	var components []ComponentConfig
+	for _, entry := range strings.Split(value, ";") {
+		key, paths, _ := strings.Cut(entry, "=")
+		if key = strings.TrimSpace(key); key == "" && strings.TrimSpace(paths) == "" {
+			continue
+		}
+		c := ComponentConfig{Paths: parseList(paths)}
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) == 3 {
+			c.Owner, c.Repo, c.Name = parts[0], parts[1], parts[2]
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+// ThresholdMetrics are the owner metrics a threshold may bound
+var ThresholdMetrics = []string{"commits", "prs", "additions", "deletions", "deploys", "members", "repos"}
+
+// ThresholdConfig bounds an owner metric, e.g. "deploys<1"
+type ThresholdConfig struct {
+	Entry  string // as configured
+	Metric string // one of ThresholdMetrics
+	Op     string // <, <=, > or >=
+	Value  float64
+}
+
+// Breached reports whether value is outside the bound
+func (t ThresholdConfig) Breached(value float64) bool {
+	switch t.Op {
+	case "<":
+		return value < t.Value
+	case "<=":
+		return value <= t.Value
+	case ">":
+		return value > t.Value
+	case ">=":
+		return value >= t.Value
+	}
+	return false
+}
+
+// parseThresholds parses a comma-separated list of "metric<value" entries (or <=, >, >=).
+// Entries that can't be parsed are kept without an operator, for Problems to report.
+func parseThresholds(value string) []ThresholdConfig {
+	var thresholds []ThresholdConfig
+	for _, entry := range parseList(value) {
+		t := ThresholdConfig{Entry: entry}
+		if i := strings.IndexAny(entry, "<>"); i > 0 {
+			op := entry[i : i+1]
+			if strings.HasPrefix(entry[i+1:], "=") {
+				op += "="
+			}
+			if v, err := strconv.ParseFloat(strings.TrimSpace(entry[i+len(op):]), 64); err == nil {
+				t.Metric, t.Op, t.Value = strings.ToLower(strings.TrimSpace(entry[:i])), op, v
+			}
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds
+}
+
+// parseList parses a comma-separated list, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // getEnv returns the value of an environment variable or a default value
@@ -50,21 +507,293 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// Validate validates the configuration
+// getEnvInt returns the integer value of an environment variable or a default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInt64 returns the 64-bit integer value of an environment variable or a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat returns the float value of an environment variable or a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool returns the boolean value ("true", "1", ...) of an environment variable or a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration returns the duration value (e.g. "30s", "5m") of an environment variable or a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// Validate validates the configuration, returning its first problem
 func (c *Config) Validate() error {
-	if c.GitHubToken == "" {
-		return &ConfigError{Field: "GITHUB_TOKEN", Message: "GitHub token is required"}
+	if problems := c.Problems(); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// Problems returns every problem of the configuration that can be found without contacting
+// GitHub or the database, in the order of the settings
+func (c *Config) Problems() []*ConfigError {
+	var problems []*ConfigError
+	add := func(field, message string) {
+		problems = append(problems, &ConfigError{Field: field, Message: message})
+	}
+
+	// GitHub
+	if c.UsesGitHubApp() {
+		problems = append(problems, c.appProblems()...)
+	} else if c.GitHubToken == "" && len(c.GitHubTokens) == 0 {
+		add("GITHUB_TOKEN", "GitHub token is required (or a GitHub App: GITHUB_APP_ID)")
+	} else if c.GitHubToken != "" && !ValidTokenFormat(c.GitHubToken) {
+		add("GITHUB_TOKEN", "does not look like a GitHub token (ghp_..., github_pat_..., or 40 hex characters)")
+	}
+	for _, owner := range sortedKeys(c.GitHubTokens) {
+		token := c.GitHubTokens[owner]
+		switch {
+		case owner == "" || token == "":
+			add("GITHUB_TOKENS", "entries must be owner=token")
+		case !ValidTokenFormat(token):
+			add("GITHUB_TOKENS", owner+": does not look like a GitHub token")
+		}
+	}
+	if c.GitHubRateLimitBudget < 1 || c.GitHubRateLimitBudget > 100 {
+		add("GITHUB_RATE_LIMIT_BUDGET", "must be a percentage between 1 and 100")
+	}
+	if c.GitHubAPIURL != "" && !validHTTPURL(c.GitHubAPIURL) {
+		add("GITHUB_API_URL", "must be an http(s) URL such as https://github.example.com/api/v3")
 	}
 	if c.Mode != "organization" && c.Mode != "user" {
-		return &ConfigError{Field: "MODE", Message: "must be 'organization' or 'user'"}
+		add("MODE", "must be 'organization' or 'user'")
 	}
-	if c.StorageType != "sqlite" && c.StorageType != "postgres" {
-		return &ConfigError{Field: "STORAGE_TYPE", Message: "must be 'sqlite' or 'postgres'"}
+	if err := validateProxy(c.HTTPProxy); err != nil {
+		add("HTTP_PROXY", err.Error())
 	}
-	if c.StorageType == "postgres" && c.PostgresURL == "" {
-		return &ConfigError{Field: "POSTGRES_URL", Message: "PostgreSQL URL is required when STORAGE_TYPE is 'postgres'"}
+	if err := validateProxy(c.HTTPSProxy); err != nil {
+		add("HTTPS_PROXY", err.Error())
 	}
-	return nil
+	if c.CACertPath != "" {
+		if _, err := LoadCACerts(c.CACertPath); err != nil {
+			add("CA_CERT_PATH", err.Error())
+		}
+	}
+
+	// Storage
+	switch c.StorageType {
+	case "sqlite":
+		if err := checkWritable(c.SQLitePath); err != nil {
+			add("SQLITE_PATH", err.Error())
+		}
+	case "postgres":
+		if c.PostgresURL == "" {
+			add("POSTGRES_URL", "PostgreSQL URL is required when STORAGE_TYPE is 'postgres'")
+		} else if !validPostgresURL(c.PostgresURL) {
+			add("POSTGRES_URL", "must be a postgres:// URL or a key=value connection string")
+		}
+	default:
+		add("STORAGE_TYPE", "must be 'sqlite' or 'postgres'")
+	}
+
+	// API server
+	if !validPort(c.APIPort) {
+		add("API_PORT", fmt.Sprintf("%q is not a port number (1-65535)", c.APIPort))
+	}
+	if c.GRPCPort != "" {
+		if !validPort(c.GRPCPort) {
+			add("GRPC_PORT", fmt.Sprintf("%q is not a port number (1-65535)", c.GRPCPort))
+		} else if c.GRPCPort == c.APIPort {
+			add("GRPC_PORT", "must differ from API_PORT")
+		}
+	}
+	if c.APIEndpoint != "" && !validHTTPURL(c.APIEndpoint) {
+		add("API_ENDPOINT", "must be an http(s) URL such as http://localhost:8080")
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		add("LOG_LEVEL", "must be 'debug', 'info', 'warn' or 'error'")
+	}
+	if !strings.EqualFold(c.LogFormat, "json") && !strings.EqualFold(c.LogFormat, "text") {
+		add("LOG_FORMAT", "must be 'json' or 'text'")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			add("TRUSTED_PROXIES", fmt.Sprintf("%q is not an IP address or CIDR range", proxy))
+		}
+	}
+	if c.RateLimitRPS < 0 {
+		add("RATE_LIMIT_RPS", "must not be negative (0 disables rate limiting)")
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+
+	// Prometheus exporter
+	if !validPort(c.ExporterPort) {
+		add("EXPORTER_PORT", fmt.Sprintf("%q is not a port number (1-65535)", c.ExporterPort))
+	}
+	if c.ExporterWindow <= 0 {
+		add("EXPORTER_WINDOW", "must be a positive duration such as 720h")
+	}
+	if c.ExporterInterval <= 0 {
+		add("EXPORTER_INTERVAL", "must be a positive duration such as 5m")
+	}
+
+	// Collection and notifications
+	if c.CollectConcurrency < 0 {
+		add("COLLECT_CONCURRENCY", "must be at least 1 (0 uses the default)")
+	}
+	if c.CollectMetricsAddr != "" {
+		if _, port, err := net.SplitHostPort(c.CollectMetricsAddr); err != nil || port == "" {
+			add("COLLECT_METRICS_ADDR", "must be host:port or :port, such as :9102")
+		}
+	}
+	if c.CollectRepoCacheTTL < 0 {
+		add("COLLECT_REPO_CACHE_TTL", "must not be negative (0 disables the cache)")
+	}
+	seen := make(map[string]bool)
+	for _, comp := range c.RepoComponents {
+		key := strings.ToLower(comp.Owner + "/" + comp.Repo + "/" + comp.Name)
+		switch {
+		case comp.Owner == "" || comp.Repo == "" || comp.Name == "":
+			add("REPO_COMPONENTS", "entries must be owner/repo/component=path,path")
+		case len(comp.Paths) == 0:
+			add("REPO_COMPONENTS", key+": needs at least one path prefix")
+		case seen[key]:
+			add("REPO_COMPONENTS", key+": listed more than once")
+		}
+		seen[key] = true
+	}
+	if _, err := parseDefaultRange(c.DefaultRange); err != nil {
+		add("DEFAULT_RANGE", err.Error())
+	}
+	switch strings.ToLower(c.DefaultGranularity) {
+	case "", "day", "week", "month":
+	default:
+		add("DEFAULT_GRANULARITY", "must be 'day', 'week' or 'month'")
+	}
+	if c.SlackWebhookURL != "" && !validHTTPURL(c.SlackWebhookURL) {
+		add("SLACK_WEBHOOK_URL", "must be an http(s) URL such as https://hooks.slack.com/services/...")
+	}
+	for _, u := range c.MetricsHookURLs {
+		if !validHTTPURL(u) {
+			add("METRICS_WEBHOOK_URLS", u+": must be an http(s) URL")
+		}
+	}
+	for _, t := range c.MetricsHookThresholds {
+		switch {
+		case t.Op == "":
+			add("METRICS_WEBHOOK_THRESHOLDS", t.Entry+": entries must be metric<value, metric<=value, metric>value or metric>=value")
+		case !slices.Contains(ThresholdMetrics, t.Metric):
+			add("METRICS_WEBHOOK_THRESHOLDS", t.Entry+": the metric must be one of "+strings.Join(ThresholdMetrics, ", "))
+		}
+	}
+	if c.MetricsHookAnomaly < 0 {
+		add("METRICS_WEBHOOK_ANOMALY_PERCENT", "must not be negative (0 disables anomalies)")
+	}
+	if c.MetricsHookWindow <= 0 {
+		add("METRICS_WEBHOOK_WINDOW", "must be a positive duration such as 168h")
+	}
+	if c.SMTPHost != "" && (c.SMTPPort < 1 || c.SMTPPort > 65535) {
+		add("SMTP_PORT", fmt.Sprintf("%d is not a port number (1-65535)", c.SMTPPort))
+	}
+	return problems
+}
+
+// tokenPattern matches the formats of GitHub tokens: prefixed personal access, OAuth, app
+// and refresh tokens, fine-grained tokens and legacy 40-character tokens
+var tokenPattern = regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|[0-9a-f]{40})$`)
+
+// ValidTokenFormat reports whether token has the format of a GitHub token
+func ValidTokenFormat(token string) bool {
+	return tokenPattern.MatchString(token)
+}
+
+func validHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validPostgresURL(value string) bool {
+	if u, err := url.Parse(value); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		return true
+	}
+	// lib/pq also takes "host=... dbname=..." connection strings
+	return strings.Contains(value, "=") && !strings.Contains(value, "://")
+}
+
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+// checkWritable checks that the SQLite database at path can be written, or created if it
+// doesn't exist yet
+func checkWritable(path string) error {
+	if path == "" {
+		return errors.New("SQLite database path is required")
+	}
+	if path == ":memory:" || strings.HasPrefix(path, "file::memory:") {
+		return nil
+	}
+	path = strings.TrimPrefix(path, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("cannot write %s: %w", path, err)
+		}
+		return f.Close()
+	}
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory %s does not exist", dir)
+	}
+	f, err := os.CreateTemp(dir, ".github-metrics-*")
+	if err != nil {
+		return fmt.Errorf("cannot create files in %s: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
 }
 
 // ConfigError represents a configuration error
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Defaults are the settings that replace the built-in defaults of the CLI's --start and
+// --granularity flags
+type Defaults struct {
+	Range       string // time ago the range starts at, such as "30d"; empty for one month
+	Granularity string // "day", "week" or "month"; empty for "day"
+}
+
+// LoadDefaults reads DEFAULT_RANGE and DEFAULT_GRANULARITY from the configuration file, .env
+// and the environment like Load, without resolving secrets, so that it can run before every
+// command
+func LoadDefaults() (Defaults, error) {
+	fileValues, err := readConfigFile()
+	if err != nil {
+		return Defaults{}, err
+	}
+	loadDotenv(fileValues)
+
+	cfg := fromEnv()
+	return cfg.Defaults()
+}
+
+// Defaults returns DefaultRange and DefaultGranularity, or an error if one is invalid
+func (c *Config) Defaults() (Defaults, error) {
+	rangeStart, err := parseDefaultRange(c.DefaultRange)
+	if err != nil {
+		return Defaults{}, fmt.Errorf("invalid DEFAULT_RANGE %q: %w", c.DefaultRange, err)
+	}
+	granularity := strings.ToLower(c.DefaultGranularity)
+	switch granularity {
+	case "", "day", "week", "month":
+	default:
+		return Defaults{}, fmt.Errorf("invalid DEFAULT_GRANULARITY %q: must be one of day, week, month", c.DefaultGranularity)
+	}
+	return Defaults{Range: rangeStart, Granularity: granularity}, nil
+}
+
+// defaultRangePattern matches the long form of DEFAULT_RANGE, such as LAST_30_DAYS
+var defaultRangePattern = regexp.MustCompile(`^last_([0-9]+)_(day|week|month|year)s?$`)
+
+// parseDefaultRange converts a DEFAULT_RANGE, a time ago such as 30d, 2w, 3m or 1y, or its
+// long form such as LAST_30_DAYS or last_3_months, to the short form
+func parseDefaultRange(value string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s == "" {
+		return "", nil
+	}
+	if m := defaultRangePattern.FindStringSubmatch(s); m != nil {
+		s = m[1] + m[2][:1]
+	}
+	n, unit := strings.TrimRight(s, "dwmy"), strings.TrimLeft(s, "0123456789")
+	if n == "" || len(unit) != 1 || strings.Trim(n, "0") == "" {
+		return "", errors.New("must be a time ago such as 30d, 2w, 3m, 1y or LAST_30_DAYS")
+	}
+	return s, nil
+}
@@ -0,0 +1,305 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// FileEnv is the environment variable naming the configuration file, for processes
+// without a --config flag such as the API server
+const FileEnv = "GITHUB_METRICS_CONFIG"
+
+// ProfileEnv is the environment variable naming the profile of the configuration file to use
+const ProfileEnv = "GITHUB_METRICS_PROFILE"
+
+// fileName is the configuration file looked for in the working directory and in the
+// github-metrics directory of the user configuration directory, as YAML or TOML
+const fileName = "github-metrics.yaml"
+
+// configFile is the file chosen with SetFile and the profile chosen with SetProfile
+var configFile struct {
+	mu      sync.Mutex
+	path    string
+	profile string
+}
+
+// SetFile makes Load read the configuration file at path instead of looking for one.
+// An empty path restores the lookup.
+func SetFile(path string) {
+	configFile.mu.Lock()
+	defer configFile.mu.Unlock()
+	configFile.path = path
+}
+
+// SetProfile makes Load apply the named profile of the configuration file. An empty name
+// restores the default: GITHUB_METRICS_PROFILE, or the file's profile setting.
+func SetProfile(name string) {
+	configFile.mu.Lock()
+	defer configFile.mu.Unlock()
+	configFile.profile = name
+}
+
+// FilePath returns the configuration file Load reads: the one given with SetFile or
+// GITHUB_METRICS_CONFIG, which must exist, or else the first of ./github-metrics.yaml,
+// ./github-metrics.yml, ./github-metrics.toml and $XDG_CONFIG_HOME/github-metrics/config.yaml
+// (or .yml, .toml) that exists. It returns "" when there is none.
+func FilePath() (string, error) {
+	configFile.mu.Lock()
+	path := configFile.path
+	configFile.mu.Unlock()
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("config file: %w", err)
+		}
+		return path, nil
+	}
+
+	base := strings.TrimSuffix(fileName, ".yaml")
+	candidates := []string{fileName, base + ".yml", base + ".toml"}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(dir, "github-metrics", "config.yaml"),
+			filepath.Join(dir, "github-metrics", "config.yml"),
+			filepath.Join(dir, "github-metrics", "config.toml"))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// requestedProfile returns the profile given with SetProfile or GITHUB_METRICS_PROFILE
+func requestedProfile() string {
+	configFile.mu.Lock()
+	defer configFile.mu.Unlock()
+	if configFile.profile != "" {
+		return configFile.profile
+	}
+	return os.Getenv(ProfileEnv)
+}
+
+// readConfigFile returns the settings of the configuration file as environment variables,
+// or nothing when there is no file. The settings of the selected profile replace the
+// file's top-level ones.
+func readConfigFile() (map[string]string, error) {
+	path, err := FilePath()
+	if err != nil {
+		return nil, err
+	}
+	profile := requestedProfile()
+	if path == "" {
+		if profile != "" {
+			return nil, fmt.Errorf("profile %q: no configuration file found", profile)
+		}
+		return nil, nil
+	}
+
+	if !structuredFile(path) {
+		// Anything else is read as a .env file, which has no profiles
+		if profile != "" {
+			return nil, fmt.Errorf("profile %q: %s is not a YAML or TOML file", profile, path)
+		}
+		values, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		return values, nil
+	}
+
+	doc, profiles, err := readStructuredFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		profile, _ = doc["profile"].(string)
+	}
+	delete(doc, "profile")
+
+	values := make(map[string]string)
+	if err := flattenSettings("", doc, values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	if profile != "" {
+		settings, ok := profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("config file %s: unknown profile %q (profiles: %s)", path, profile, strings.Join(sortedKeys(profiles), ", "))
+		}
+		if err := flattenSettings("", settings, values); err != nil {
+			return nil, fmt.Errorf("config file %s: profile %s: %w", path, profile, err)
+		}
+	}
+	return values, nil
+}
+
+// structuredFile reports whether path is a YAML or TOML file, rather than a .env file
+func structuredFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// readStructuredFile parses a YAML or TOML configuration file into its top-level settings
+// and its profiles, keyed by name. Both formats have the same structure: a TOML table is a
+// YAML map.
+func readStructuredFile(path string) (map[string]any, map[string]map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config file: %w", err)
+	}
+	var doc map[string]any
+	unmarshal := yaml.Unmarshal
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		unmarshal = toml.Unmarshal
+	}
+	if err := unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	profiles := make(map[string]map[string]any)
+	if raw, ok := doc["profiles"]; ok {
+		entries, ok := raw.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("config file %s: profiles must map names to settings", path)
+		}
+		for name, settings := range entries {
+			m, ok := settings.(map[string]any)
+			if !ok && settings != nil {
+				return nil, nil, fmt.Errorf("config file %s: profile %s must be a map of settings", path, name)
+			}
+			profiles[name] = m
+		}
+		delete(doc, "profiles")
+	}
+	return doc, profiles, nil
+}
+
+// Profiles returns the names of the profiles in the configuration file, sorted, and the one
+// Load applies ("" for none)
+func Profiles() (names []string, active string, err error) {
+	path, err := FilePath()
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+	if !structuredFile(path) {
+		return nil, "", nil
+	}
+	doc, profiles, err := readStructuredFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if active = requestedProfile(); active == "" {
+		active, _ = doc["profile"].(string)
+	}
+	return sortedKeys(profiles), active, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ownerSettings are the variables listing owners (or repository components) with a value
+// (a cron expression, a token or paths), separated by ";"
+var ownerSettings = map[string]bool{"COLLECT_SCHEDULES": true, "REPORT_SCHEDULES": true, "GITHUB_TOKENS": true, "REPO_COMPONENTS": true}
+
+// settingAliases maps keys of the file that aren't variable names to the variables they set,
+// so that settings may be grouped in the sections github, storage, api, collection and defaults
+var settingAliases = map[string]string{
+	"COLLECTION":           "COLLECT",
+	"DEFAULTS":             "DEFAULT",
+	"GITHUB_MODE":          "MODE",
+	"STORAGE_SQLITE_PATH":  "SQLITE_PATH",
+	"STORAGE_POSTGRES_URL": "POSTGRES_URL",
+	"STORAGE_AUTO_MIGRATE": "AUTO_MIGRATE",
+}
+
+// flattenSettings converts YAML or TOML settings to environment variables. Keys are the variable
+// names in any case, and nested keys are joined with "_", so both api_port: 8080 and
+// api: {port: 8080} set API_PORT. Lists become comma-separated values; schedules and tokens
+// may be given as a map of owner to cron expression or token.
+func flattenSettings(prefix string, value any, values map[string]string) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		if ownerSettings[prefix] {
+			entries := make([]string, 0, len(v))
+			for _, owner := range sortedKeys(v) {
+				value := v[owner]
+				// Component paths may be given as a list
+				if items, ok := value.([]any); ok {
+					paths := make([]string, len(items))
+					for i, item := range items {
+						paths[i] = fmt.Sprint(item)
+					}
+					value = strings.Join(paths, ",")
+				}
+				entries = append(entries, fmt.Sprintf("%s=%v", owner, value))
+			}
+			values[prefix] = strings.Join(entries, ";")
+			return nil
+		}
+		for k, child := range v {
+			key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			if alias, ok := settingAliases[key]; ok {
+				key = alias
+			}
+			if err := flattenSettings(key, child, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		if prefix == "" {
+			return errors.New("the top level must be a map of settings")
+		}
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("%s: list items must be plain values", prefix)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		sep := ","
+		if ownerSettings[prefix] {
+			sep = ";"
+		}
+		values[prefix] = strings.Join(items, sep)
+		return nil
+	default:
+		if prefix == "" {
+			return errors.New("the top level must be a map of settings")
+		}
+		values[prefix] = fmt.Sprint(v)
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// HTTPTransport returns the transport of connections to the GitHub API: through HTTP_PROXY
+// or HTTPS_PROXY except for the hosts of NO_PROXY, trusting the CA certificates of
+// CA_CERT_PATH besides the system ones. It's nil when none of them are set, to use the
+// default transport.
+func (c *Config) HTTPTransport() (*http.Transport, error) {
+	if c.HTTPProxy == "" && c.HTTPSProxy == "" && c.CACertPath == "" {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.HTTPProxy != "" || c.HTTPSProxy != "" {
+		proxy := (&httpproxy.Config{HTTPProxy: c.HTTPProxy, HTTPSProxy: c.HTTPSProxy, NoProxy: c.NoProxy}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+	if c.CACertPath != "" {
+		pool, err := LoadCACerts(c.CACertPath)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
+
+// LoadCACerts returns the system certificate pool with the PEM certificates of path added,
+// e.g. the CA of a TLS-inspecting corporate proxy or of GitHub Enterprise Server
+func LoadCACerts(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("CA certificates: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA certificates: no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// validateProxy checks a proxy URL of HTTP_PROXY or HTTPS_PROXY
+func validateProxy(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		// A bare host:port is taken as an http:// proxy, as by the Go standard library
+		if u, err = url.Parse("http://" + value); err != nil || u.Host == "" {
+			return errors.New("must be a proxy URL such as http://proxy.example.com:8080")
+		}
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return errors.New("must be an http://, https:// or socks5:// proxy URL")
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret references: a secret setting may name where its value is kept instead of holding it
+const (
+	vaultPrefix     = "vault:"  // vault:<path>#<key>, a key of a Vault KV secret
+	awsSecretPrefix = "aws-sm:" // aws-sm:<secret id>[#<key>], an AWS Secrets Manager secret
+)
+
+// secretCacheTTL is how long fetched secrets are reused, so that reloads don't query the
+// backends every time yet pick up rotated secrets
+const secretCacheTTL = 5 * time.Minute
+
+var secretCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// secretSettings returns the settings holding secrets, keyed by variable name
+func (c *Config) secretSettings() map[string]*string {
+	return map[string]*string{
+		"GITHUB_TOKEN":           &c.GitHubToken,
+		"POSTGRES_URL":           &c.PostgresURL,
+		"GITHUB_APP_PRIVATE_KEY": &c.GitHubAppPrivateKey,
+		"GITHUB_WEBHOOK_SECRET":  &c.GitHubWebhookSecret,
+		"VIEWER_TOKEN_SECRET":    &c.ViewerTokenSecret,
+		"SMTP_PASSWORD":          &c.SMTPPassword,
+		"SLACK_WEBHOOK_URL":      &c.SlackWebhookURL,
+		"METRICS_WEBHOOK_SECRET": &c.MetricsHookSecret,
+		"SLACK_SIGNING_SECRET":   &c.SlackSigningSecret,
+		"PSEUDONYM_SECRET":       &c.PseudonymSecret,
+	}
+}
+
+// resolveSecrets replaces the secret settings that aren't given directly: <NAME>_FILE names
+// a file holding the value (e.g. a Docker or Kubernetes secret), and a vault: or aws-sm:
+// reference is fetched from HashiCorp Vault or AWS Secrets Manager. Per-owner GitHub tokens
+// may be references too.
+func (c *Config) resolveSecrets(ctx context.Context) error {
+	for name, value := range c.secretSettings() {
+		if *value == "" {
+			if path := os.Getenv(name + "_FILE"); path != "" {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return &ConfigError{Field: name + "_FILE", Message: err.Error()}
+				}
+				*value = strings.TrimRight(string(data), "\r\n")
+			}
+		}
+		resolved, err := resolveSecret(ctx, *value)
+		if err != nil {
+			return &ConfigError{Field: name, Message: err.Error()}
+		}
+		*value = resolved
+	}
+	for owner, token := range c.GitHubTokens {
+		resolved, err := resolveSecret(ctx, token)
+		if err != nil {
+			return &ConfigError{Field: "GITHUB_TOKENS", Message: owner + ": " + err.Error()}
+		}
+		c.GitHubTokens[owner] = resolved
+	}
+	return nil
+}
+
+// resolveSecret returns the secret value references, or value itself if it isn't a reference
+func resolveSecret(ctx context.Context, value string) (string, error) {
+	var fetch func(context.Context, string) (string, error)
+	switch {
+	case strings.HasPrefix(value, vaultPrefix):
+		fetch = fetchVaultSecret
+	case strings.HasPrefix(value, awsSecretPrefix):
+		fetch = fetchAWSSecret
+	default:
+		return value, nil
+	}
+
+	secretCache.mu.Lock()
+	cached, ok := secretCache.entries[value]
+	secretCache.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < secretCacheTTL {
+		return cached.value, nil
+	}
+
+	secret, err := fetch(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	secretCache.mu.Lock()
+	defer secretCache.mu.Unlock()
+	if secretCache.entries == nil {
+		secretCache.entries = make(map[string]cachedSecret)
+	}
+	secretCache.entries[value] = cachedSecret{value: secret, fetchedAt: time.Now()}
+	return secret, nil
+}
+
+// secretsHTTPClient is the client of the secret backends
+var secretsHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// fetchVaultSecret reads the key of a KV secret (version 1 or 2) from the Vault server at
+// VAULT_ADDR with VAULT_TOKEN, e.g. vault:secret/data/github-metrics#github_token
+func fetchVaultSecret(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(strings.TrimPrefix(ref, vaultPrefix), "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("%s: must be vault:<path>#<key>", ref)
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("%s: VAULT_ADDR and VAULT_TOKEN must be set", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: vault: %w", ref, err)
+	}
+
+	var response struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("%s: vault: %w", ref, err)
+	}
+	data := response.Data
+	// KV version 2 nests the secret's data next to its metadata
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("%s: vault: the secret has no key %q", ref, key)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// fetchAWSSecret reads a secret string from AWS Secrets Manager in AWS_REGION with the
+// credentials of AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, e.g.
+// aws-sm:prod/github-metrics#github_token. With a key, the secret string is a JSON object
+// and the key's value is returned. Only these environment variables provide credentials; the
+// SDK credential chain (IRSA, instance and task roles, profiles) isn't supported.
+func fetchAWSSecret(ctx context.Context, ref string) (string, error) {
+	id, key, _ := strings.Cut(strings.TrimPrefix(ref, awsSecretPrefix), "#")
+	if id == "" {
+		return "", fmt.Errorf("%s: must be aws-sm:<secret id>[#<key>]", ref)
+	}
+	region := getEnv("AWS_REGION", os.Getenv("AWS_DEFAULT_REGION"))
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if region == "" || accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("%s: AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set", ref)
+	}
+	endpoint := getEnv("AWS_ENDPOINT_URL_SECRETS_MANAGER", getEnv("AWS_ENDPOINT_URL", "https://secretsmanager."+region+".amazonaws.com"))
+
+	payload, _ := json.Marshal(map[string]string{"SecretId": id})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ref, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, payload, region, "secretsmanager", accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"), time.Now())
+	body, err := doSecretRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: aws secrets manager: %w", ref, err)
+	}
+
+	var response struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("%s: aws secrets manager: %w", ref, err)
+	}
+	if response.SecretString == nil {
+		return "", fmt.Errorf("%s: aws secrets manager: the secret is binary, not a string", ref)
+	}
+	if key == "" {
+		return *response.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*response.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("%s: aws secrets manager: the secret isn't a JSON object: %w", ref, err)
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%s: aws secrets manager: the secret has no key %q", ref, key)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// doSecretRequest sends a request to a secret backend and returns the body of a successful
+// response
+func doSecretRequest(req *http.Request) ([]byte, error) {
+	resp, err := secretsHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// signAWSRequest signs req with AWS Signature Version 4
+func signAWSRequest(req *http.Request, payload []byte, region, service, accessKey, secretKey, sessionToken string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.Query().Encode(), canonicalHeaders.String(), signedHeaders, hashHex(payload),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package domain
+
+import "time"
+
+// RepoActivityStatus classifies how active a repository is compared with its own past
+type RepoActivityStatus string
+
+const (
+	// RepoActivityActive repositories are as active as in their baseline window, or more
+	RepoActivityActive RepoActivityStatus = "active"
+	// RepoActivitySlowing repositories are active at less than half their baseline rate
+	RepoActivitySlowing RepoActivityStatus = "slowing"
+	// RepoActivityDormant repositories had no activity in the recent window but some in the
+	// baseline window
+	RepoActivityDormant RepoActivityStatus = "dormant"
+	// RepoActivityDead repositories had no activity in either window
+	RepoActivityDead RepoActivityStatus = "dead"
+)
+
+// RepoActivityStatuses are the statuses, from the one most in need of attention
+var RepoActivityStatuses = []RepoActivityStatus{RepoActivityDead, RepoActivityDormant, RepoActivitySlowing, RepoActivityActive}
+
+const (
+	// DefaultRecentWindow is the default length of the window a repository's recent activity is measured in
+	DefaultRecentWindow = 30 * 24 * time.Hour
+	// DefaultBaselineWindow is the default length of the window before the recent one that
+	// a repository's recent activity is compared with
+	DefaultBaselineWindow = 180 * 24 * time.Hour
+	// SlowingRatio is the share of its baseline rate below which an active repository is slowing
+	SlowingRatio = 0.5
+)
+
+// RepoActivityQuery selects the windows repositories are classified over: the recent window
+// ends at End, and the baseline window ends where the recent one starts
+type RepoActivityQuery struct {
+	End      time.Time     `json:"end"`
+	Recent   time.Duration `json:"-"` // DefaultRecentWindow if zero
+	Baseline time.Duration `json:"-"` // DefaultBaselineWindow if zero
+}
+
+// Windows returns the recent and baseline windows of the query
+func (q RepoActivityQuery) Windows() (recent, baseline TimeRange) {
+	if q.Recent <= 0 {
+		q.Recent = DefaultRecentWindow
+	}
+	if q.Baseline <= 0 {
+		q.Baseline = DefaultBaselineWindow
+	}
+	recent = TimeRange{Start: q.End.Add(-q.Recent), End: q.End}
+	baseline = TimeRange{Start: recent.Start.Add(-q.Baseline), End: recent.Start.Add(-time.Nanosecond)}
+	return recent, baseline
+}
+
+// RepoActivity is how active a repository is. Events are commits, pull requests and deploys;
+// the ratio compares the daily event rates of the two windows and is 0 without baseline events.
+type RepoActivity struct {
+	Repo           string             `json:"repo"`
+	Status         RepoActivityStatus `json:"status"`
+	RecentEvents   int64              `json:"recent_events"`
+	BaselineEvents int64              `json:"baseline_events"`
+	Ratio          float64            `json:"ratio"`
+	LastActivity   *time.Time         `json:"last_activity,omitempty"` // nil without events in either window
+}
+
+// RepoActivityReport classifies the repositories of an owner, those needing attention first
+type RepoActivityReport struct {
+	Owner    string                     `json:"owner"`
+	Repos    []*RepoActivity            `json:"repos"`
+	Counts   map[RepoActivityStatus]int `json:"counts"`
+	Recent   TimeRange                  `json:"recent"`
+	Baseline TimeRange                  `json:"baseline"`
+}
+
+// ClassifyRepoActivity classifies a repository by its events in the recent and baseline
+// windows, returning the ratio of its recent daily event rate to its baseline one. A new
+// repository, without baseline events, is active as soon as it has recent events.
+func ClassifyRepoActivity(recentEvents, baselineEvents int64, recent, baseline TimeRange) (RepoActivityStatus, float64) {
+	switch {
+	case recentEvents == 0 && baselineEvents == 0:
+		return RepoActivityDead, 0
+	case recentEvents == 0:
+		return RepoActivityDormant, 0
+	case baselineEvents == 0:
+		return RepoActivityActive, 0
+	}
+	recentRate := float64(recentEvents) / recent.End.Sub(recent.Start).Hours()
+	baselineRate := float64(baselineEvents) / baseline.End.Sub(baseline.Start).Hours()
+	ratio := recentRate / baselineRate
+	if ratio < SlowingRatio {
+		return RepoActivitySlowing, ratio
+	}
+	return RepoActivityActive, ratio
+}
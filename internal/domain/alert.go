@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// MetricAlert is a threshold breach or an anomaly of an owner's metrics that is in effect.
+// Alerts are delivered when they are triggered and when they are resolved, rather than after
+// every collection while they last.
+type MetricAlert struct {
+	Owner       string    `json:"owner"`
+	Key         string    `json:"key"` // "threshold:<threshold as configured>" or "anomaly:<metric>"
+	TriggeredAt time.Time `json:"triggered_at"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MemberAlias maps another name an owner's events may be recorded under, such as a second
+// login, a commit author name or an email, to the canonical member it belongs to.
+// Aliases are matched case-insensitively.
+type MemberAlias struct {
+	Owner     string    `json:"owner"`
+	Alias     string    `json:"alias"`
+	Member    string    `json:"member"` // canonical member the alias's activity counts towards
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Identity is a member with the other names its activity is recorded under
+type Identity struct {
+	Owner   string   `json:"owner"`
+	Member  string   `json:"member"`  // canonical member
+	Aliases []string `json:"aliases"` // logins, commit author names and emails, as saved
+}
+
+// Names returns the names the activity of the identity is recorded under: the member and its
+// aliases
+func (i *Identity) Names() []string {
+	return append([]string{i.Member}, i.Aliases...)
+}
+
+// Identities resolves the names an owner's events are recorded under to the members they
+// belong to
+type Identities struct {
+	owner    string
+	aliases  map[string]*MemberAlias // by lowercase alias
+	byMember map[string]*Identity
+	list     []*Identity // ordered by member
+}
+
+// NewIdentities returns the identities of an owner's member aliases
+func NewIdentities(owner string, aliases []*MemberAlias) *Identities {
+	ids := &Identities{
+		owner:    owner,
+		aliases:  make(map[string]*MemberAlias, len(aliases)),
+		byMember: make(map[string]*Identity),
+	}
+	for _, a := range aliases {
+		ids.aliases[strings.ToLower(a.Alias)] = a
+		identity, ok := ids.byMember[a.Member]
+		if !ok {
+			identity = &Identity{Owner: owner, Member: a.Member}
+			ids.byMember[a.Member] = identity
+			ids.list = append(ids.list, identity)
+		}
+		identity.Aliases = append(identity.Aliases, a.Alias)
+	}
+	sort.Slice(ids.list, func(i, j int) bool { return ids.list[i].Member < ids.list[j].Member })
+	return ids
+}
+
+// Empty reports whether there are no aliases
+func (ids *Identities) Empty() bool {
+	return len(ids.aliases) == 0
+}
+
+// Alias returns the alias saved for name, matched case-insensitively, or nil if name isn't an
+// alias
+func (ids *Identities) Alias(name string) *MemberAlias {
+	return ids.aliases[strings.ToLower(name)]
+}
+
+// Resolve returns the member the activity of name counts towards: the member name is an
+// alias of, or name itself
+func (ids *Identities) Resolve(name string) string {
+	if a := ids.Alias(name); a != nil {
+		return a.Member
+	}
+	return name
+}
+
+// Identity returns the identity of member, a canonical member; one without aliases if it has
+// none
+func (ids *Identities) Identity(member string) *Identity {
+	if identity, ok := ids.byMember[member]; ok {
+		return identity
+	}
+	return &Identity{Owner: ids.owner, Member: member}
+}
+
+// List returns the identities of the members with aliases, ordered by member
+func (ids *Identities) List() []*Identity {
+	return ids.list
+}
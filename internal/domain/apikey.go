@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// APIKeyScope represents what an API key is allowed to do
+type APIKeyScope string
+
+const (
+	// APIKeyScopeRead allows read-only access to metrics
+	APIKeyScopeRead APIKeyScope = "read"
+	// APIKeyScopeAdmin allows everything, including administrative endpoints
+	APIKeyScopeAdmin APIKeyScope = "admin"
+)
+
+// Allows reports whether a key with this scope may access an endpoint requiring required
+func (s APIKeyScope) Allows(required APIKeyScope) bool {
+	switch s {
+	case APIKeyScopeAdmin:
+		return true
+	case APIKeyScopeRead:
+		return required == APIKeyScopeRead
+	default:
+		return false
+	}
+}
+
+// IsValid reports whether the scope is a known scope
+func (s APIKeyScope) IsValid() bool {
+	return s == APIKeyScopeRead || s == APIKeyScopeAdmin
+}
+
+// APIKey represents an API key accepted by the API server.
+// Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	Name      string       `json:"name"`
+	KeyHash   string       `json:"-"`
+	Scope     APIKeyScope  `json:"scope"`
+	Grants    []OwnerGrant `json:"grants"`              // owners the key may access; see RoleFor
+	Workspace string       `json:"workspace,omitempty"` // workspace the key is confined to; empty for deployment-wide keys
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash used to store and look up an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Role represents what a principal may do within a single owner (organization or user)
+type Role string
+
+const (
+	// RoleViewer may read the owner's metrics
+	RoleViewer Role = "viewer"
+	// RoleAdmin may also modify the owner's data (collections, settings, ...)
+	RoleAdmin Role = "admin"
+)
+
+// AllOwners is the grant owner matching every organization and user
+const AllOwners = "*"
+
+// Allows reports whether a principal with this role may perform an action requiring required
+func (r Role) Allows(required Role) bool {
+	switch r {
+	case RoleAdmin:
+		return true
+	case RoleViewer:
+		return required == RoleViewer
+	default:
+		return false
+	}
+}
+
+// IsValid reports whether the role is a known role
+func (r Role) IsValid() bool {
+	return r == RoleViewer || r == RoleAdmin
+}
+
+// OwnerGrant gives an API key a role within an owner (AllOwners for every owner)
+type OwnerGrant struct {
+	KeyName string `json:"key_name"`
+	Owner   string `json:"owner"`
+	Role    Role   `json:"role"`
+}
+
+// RoleFor returns the key's role for owner. Admin-scoped keys are admins everywhere;
+// otherwise a grant for the owner itself takes precedence over an AllOwners grant.
+func (k *APIKey) RoleFor(owner string) (Role, bool) {
+	if k.Scope == APIKeyScopeAdmin {
+		return RoleAdmin, true
+	}
+
+	var role Role
+	found := false
+	for _, g := range k.Grants {
+		if g.Owner == owner {
+			return g.Role, true
+		}
+		if g.Owner == AllOwners {
+			role, found = g.Role, true
+		}
+	}
+	return role, found
+}
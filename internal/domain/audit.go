@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// AuditEntry records one API request: who accessed which owner's data and with what result
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal"` // API key name, or "anonymous" when authentication is disabled
+	Owner     string    `json:"owner"`     // organization/user the request targeted; empty for other routes
+	Method    string    `json:"method"`
+	Route     string    `json:"route"` // matched route pattern, e.g. /api/v1/orgs/:org/metrics
+	Path      string    `json:"path"`
+	Params    string    `json:"params"` // query string, with credentials redacted
+	Status    int       `json:"status"`
+	ClientIP  string    `json:"client_ip"`
+	RequestID string    `json:"request_id"`
+}
+
+// AuditFilter selects audit entries when listing them
+type AuditFilter struct {
+	Owner     string    `json:"owner"`     // empty for all owners
+	Principal string    `json:"principal"` // empty for all principals
+	Since     time.Time `json:"since"`     // zero for no lower bound
+	Until     time.Time `json:"until"`     // zero for no upper bound (exclusive)
+	Limit     int       `json:"limit"`     // maximum number of entries, newest first; 0 for no limit
+}
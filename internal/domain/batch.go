@@ -4,13 +4,76 @@ import "time"
 
 // CollectionBatch represents a batch collection job
 type CollectionBatch struct {
-	ID        string
-	Mode      string // "organization" or "user"
-	Owner     string // organization name or user name
-	StartDate time.Time
-	EndDate   time.Time
-	Status    string // "in_progress", "completed", "failed"
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID        string    `json:"id"`
+	Mode      string    `json:"mode"`  // "organization" or "user"
+	Owner     string    `json:"owner"` // organization name or user name
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Status    string    `json:"status"` // "in_progress", "completed", "failed"
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
+
+// Per-repository statuses within a collection batch
+const (
+	BatchRepoStatusPending    = "pending"
+	BatchRepoStatusProcessing = "processing"
+	BatchRepoStatusCompleted  = "completed"
+	BatchRepoStatusFailed     = "failed"
+)
+
+// BatchRepoStatus represents the collection status of a single repository within a batch
+type BatchRepoStatus struct {
+	BatchID   string    `json:"batch_id"`
+	Repo      string    `json:"repo"`
+	Status    string    `json:"status"` // "pending", "processing", "completed", "failed"
+	Events    int       `json:"events"` // number of events saved for the repository
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BatchProgress summarizes the repository statuses of a batch
+type BatchProgress struct {
+	Total      int `json:"total"`
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+}
+
+// SummarizeBatchRepos counts repository statuses
+func SummarizeBatchRepos(repos []*BatchRepoStatus) BatchProgress {
+	progress := BatchProgress{Total: len(repos)}
+	for _, r := range repos {
+		switch r.Status {
+		case BatchRepoStatusPending:
+			progress.Pending++
+		case BatchRepoStatusProcessing:
+			progress.Processing++
+		case BatchRepoStatusCompleted:
+			progress.Completed++
+		case BatchRepoStatusFailed:
+			progress.Failed++
+		}
+	}
+	return progress
+}
+
+// BatchFilter selects collection batches when listing them
+type BatchFilter struct {
+	Owner  string `json:"owner"`  // empty for all owners
+	Status string `json:"status"` // empty for all statuses
+	Limit  int    `json:"limit"`  // maximum number of batches, newest first; 0 for no limit
+
+	// Owners restricts the batches to these owners unless nil, before the limit is applied;
+	// an empty list matches no batches
+	Owners []string `json:"owners,omitempty"`
+}
+
+// CollectionBatchDetail is a batch together with its per-repository progress
+type CollectionBatchDetail struct {
+	Batch    *CollectionBatch   `json:"batch"`
+	Progress BatchProgress      `json:"progress"`
+	Repos    []*BatchRepoStatus `json:"repos,omitempty"` // nil when listing batches
+}
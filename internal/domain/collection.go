@@ -0,0 +1,42 @@
+package domain
+
+// RepoCollectionResult is the outcome of collecting a single repository
+type RepoCollectionResult struct {
+	Repo   string `json:"repo"`
+	Status string `json:"status"` // BatchRepoStatusCompleted or BatchRepoStatusFailed
+	Events int    `json:"events"` // events collected from the repository
+	Error  string `json:"error,omitempty"`
+}
+
+// Failed reports whether the repository could not be collected
+func (r *RepoCollectionResult) Failed() bool {
+	return r.Status == BatchRepoStatusFailed
+}
+
+// CollectionResult is the outcome of collecting an owner's repositories. A collection that
+// returns a result went through every repository; repositories that failed are reported in
+// it rather than failing the whole collection.
+type CollectionResult struct {
+	Owner string                  `json:"owner"`
+	Repos []*RepoCollectionResult `json:"repos"` // in the order the repositories were listed
+}
+
+// Failed returns the repositories that could not be collected
+func (r *CollectionResult) Failed() []*RepoCollectionResult {
+	var failed []*RepoCollectionResult
+	for _, repo := range r.Repos {
+		if repo.Failed() {
+			failed = append(failed, repo)
+		}
+	}
+	return failed
+}
+
+// Events returns the number of events collected from every repository
+func (r *CollectionResult) Events() int {
+	events := 0
+	for _, repo := range r.Repos {
+		events += repo.Events
+	}
+	return events
+}
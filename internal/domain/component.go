@@ -0,0 +1,105 @@
+package domain
+
+import "strings"
+
+// OtherComponent collects the changes to files under no component's paths
+const OtherComponent = "(other)"
+
+// Component is a logical part of a repository, such as a service of a monorepo, that owns
+// the files under its path prefixes
+type Component struct {
+	Owner string   `json:"owner"`
+	Repo  string   `json:"repo"`
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"` // path prefixes relative to the repository root, e.g. "services/api"
+}
+
+// Contains reports whether path is under one of the component's path prefixes, returning
+// the length of the longest one that matches
+func (c *Component) Contains(path string) (int, bool) {
+	longest, ok := 0, false
+	for _, prefix := range c.Paths {
+		prefix = strings.Trim(prefix, "/")
+		if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			if !ok || len(prefix) > longest {
+				longest, ok = len(prefix), true
+			}
+		}
+	}
+	return longest, ok
+}
+
+// RepoComponents returns the components of a repository among components
+func RepoComponents(components []Component, owner, repo string) []Component {
+	var matched []Component
+	for _, c := range components {
+		if strings.EqualFold(c.Owner, owner) && strings.EqualFold(c.Repo, repo) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// ComponentOf returns the name of the component owning path, the one with the longest
+// matching path prefix, or OtherComponent if no component's paths match
+func ComponentOf(components []Component, path string) string {
+	owner, longest := OtherComponent, -1
+	for i := range components {
+		if n, ok := components[i].Contains(path); ok && n > longest {
+			owner, longest = components[i].Name, n
+		}
+	}
+	return owner
+}
+
+// FileChange is a file changed by a commit
+type FileChange struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// CommitFiles returns the changed files recorded in the data of a commit event, whether it
+// was just collected or read back from storage, or nil for commits collected without them
+func CommitFiles(data map[string]interface{}) []FileChange {
+	switch files := data["files"].(type) {
+	case []FileChange:
+		return files
+	case []interface{}:
+		changes := make([]FileChange, 0, len(files))
+		for _, f := range files {
+			m, ok := f.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path, _ := m["path"].(string)
+			additions, _ := m["additions"].(float64)
+			deletions, _ := m["deletions"].(float64)
+			changes = append(changes, FileChange{Path: path, Additions: int(additions), Deletions: int(deletions)})
+		}
+		return changes
+	}
+	return nil
+}
+
+// ComponentMetrics is the activity in the files of a component of a repository
+type ComponentMetrics struct {
+	Component    string   `json:"component"`
+	Paths        []string `json:"paths,omitempty"`
+	Commits      int64    `json:"commits"`       // commits changing the component's files
+	PRs          int64    `json:"prs"`           // merged pull requests whose merge commit changed its files
+	Additions    int64    `json:"additions"`     // lines added to its files
+	Deletions    int64    `json:"deletions"`     // lines deleted from its files
+	FilesChanged int64    `json:"files_changed"` // changes to its files, counted per commit
+	Contributors int64    `json:"contributors"`  // members committing to its files
+}
+
+// RepoComponentMetrics is the activity of each component of a repository. Commits collected
+// before changed files were recorded can't be attributed to a component and are only counted.
+type RepoComponentMetrics struct {
+	Owner               string              `json:"owner"`
+	Repo                string              `json:"repo"`
+	Components          []*ComponentMetrics `json:"components"` // in configuration order, OtherComponent last
+	UnattributedCommits int64               `json:"unattributed_commits"`
+	TimeRange           TimeRange           `json:"time_range"`
+}
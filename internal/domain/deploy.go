@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// DeploySource is what counts as a deploy of a repository
+type DeploySource string
+
+const (
+	// DeploySourceDeployments counts the repository's GitHub deployments (the Deployments API),
+	// optionally only those to environments matching the pattern. It's the default.
+	DeploySourceDeployments DeploySource = "deployments"
+	// DeploySourceWorkflow counts completed GitHub Actions runs of the workflows whose name or
+	// file name matches the pattern
+	DeploySourceWorkflow DeploySource = "workflow"
+	// DeploySourceTag counts tags matching the pattern, at the time of their commit
+	DeploySourceTag DeploySource = "tag"
+	// DeploySourceRelease counts published releases, optionally only those whose tag matches
+	// the pattern
+	DeploySourceRelease DeploySource = "release"
+)
+
+// DeploySources are the valid deploy sources
+var DeploySources = []DeploySource{DeploySourceDeployments, DeploySourceWorkflow, DeploySourceTag, DeploySourceRelease}
+
+// DeployDefinition chooses what counts as a deploy of a repository. Repositories without one
+// count their GitHub deployments (DefaultDeployDefinition).
+type DeployDefinition struct {
+	Owner     string       `json:"owner"`
+	Repo      string       `json:"repo"`
+	Source    DeploySource `json:"source"`
+	Pattern   string       `json:"pattern,omitempty"`   // path.Match glob, case insensitive; see the sources
+	UpdatedAt time.Time    `json:"updated_at,omitzero"` // zero for the default definition
+}
+
+// DefaultDeployDefinition returns the deploy definition of a repository without one
+func DefaultDeployDefinition(owner, repo string) *DeployDefinition {
+	return &DeployDefinition{Owner: owner, Repo: repo, Source: DeploySourceDeployments}
+}
+
+// Validate checks the source and pattern of a deploy definition
+func (d *DeployDefinition) Validate() error {
+	switch d.Source {
+	case DeploySourceDeployments, DeploySourceRelease:
+	case DeploySourceWorkflow, DeploySourceTag:
+		if d.Pattern == "" {
+			return fmt.Errorf("the %s deploy source needs a pattern", d.Source)
+		}
+	default:
+		return fmt.Errorf("unknown deploy source %q: must be one of deployments, workflow, tag, release", d.Source)
+	}
+	if _, err := path.Match(d.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid deploy pattern %q: %w", d.Pattern, err)
+	}
+	return nil
+}
+
+// Matches reports whether name, an environment, workflow or tag depending on the source,
+// matches the definition's pattern; every name matches an empty pattern
+func (d *DeployDefinition) Matches(name string) bool {
+	if d.Pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(strings.ToLower(d.Pattern), strings.ToLower(name))
+	return ok
+}
+
+// DeployEventSource returns the source a stored deploy event was collected from; deploys
+// collected before sources were recorded are GitHub deployments
+func DeployEventSource(data map[string]interface{}) DeploySource {
+	if source, _ := data["source"].(string); source != "" {
+		return DeploySource(source)
+	}
+	return DeploySourceDeployments
+}
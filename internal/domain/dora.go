@@ -0,0 +1,115 @@
+package domain
+
+// DORAPerformance is a DORA performance level
+type DORAPerformance string
+
+const (
+	DORAPerformanceElite  DORAPerformance = "elite"
+	DORAPerformanceHigh   DORAPerformance = "high"
+	DORAPerformanceMedium DORAPerformance = "medium"
+	DORAPerformanceLow    DORAPerformance = "low"
+)
+
+// DORAMetrics are the four DORA software delivery metrics of an owner or repository, derived
+// from collected deployments and pull requests:
+//   - deployment frequency: successful deployments per day
+//   - lead time for changes: median time from opening a pull request to merging it
+//   - change failure rate: share of deployments whose status is failure or error
+//   - time to restore: median time from a failed deployment to the next successful deployment
+//     of the same repository and environment
+//
+// A performance level is empty when there is no data to classify.
+type DORAMetrics struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo,omitempty"` // empty for the whole owner
+
+	Deployments         int64           `json:"deployments"`                  // deployments with any status
+	FailedDeployments   int64           `json:"failed_deployments"`           // deployments with a failure or error status
+	DeploymentFrequency float64         `json:"deployment_frequency_per_day"` // successful deployments per day
+	DeploymentLevel     DORAPerformance `json:"deployment_frequency_level"`
+
+	MergedPRs     int64           `json:"merged_prs"`      // pull requests opened in the range and merged
+	LeadTimeHours float64         `json:"lead_time_hours"` // median hours from opening to merging
+	LeadTimeLevel DORAPerformance `json:"lead_time_level"`
+
+	ChangeFailureRate  float64         `json:"change_failure_rate"` // 0 to 1
+	ChangeFailureLevel DORAPerformance `json:"change_failure_level"`
+
+	Restores           int64           `json:"restores"`              // failed deployments followed by a successful one
+	TimeToRestoreHours float64         `json:"time_to_restore_hours"` // median hours from failure to restore
+	TimeToRestoreLevel DORAPerformance `json:"time_to_restore_level"`
+	TimeRange          TimeRange       `json:"time_range"`
+}
+
+// IsFailedDeployStatus reports whether a deployment status counts as a failed change
+func IsFailedDeployStatus(status string) bool {
+	return status == "failure" || status == "error"
+}
+
+// ClassifyDeploymentFrequency rates successful deployments per day: daily or more is elite,
+// weekly high, monthly medium
+func ClassifyDeploymentFrequency(perDay float64, deployments int64) DORAPerformance {
+	switch {
+	case deployments == 0:
+		return ""
+	case perDay >= 1:
+		return DORAPerformanceElite
+	case perDay >= 1.0/7:
+		return DORAPerformanceHigh
+	case perDay >= 1.0/30:
+		return DORAPerformanceMedium
+	default:
+		return DORAPerformanceLow
+	}
+}
+
+// ClassifyLeadTime rates the lead time for changes: under a day is elite, a week high,
+// a month medium
+func ClassifyLeadTime(hours float64, merged int64) DORAPerformance {
+	switch {
+	case merged == 0:
+		return ""
+	case hours < 24:
+		return DORAPerformanceElite
+	case hours < 7*24:
+		return DORAPerformanceHigh
+	case hours < 30*24:
+		return DORAPerformanceMedium
+	default:
+		return DORAPerformanceLow
+	}
+}
+
+// ClassifyChangeFailureRate rates the change failure rate: up to 5% is elite, 10% high,
+// 15% medium
+func ClassifyChangeFailureRate(rate float64, deployments int64) DORAPerformance {
+	switch {
+	case deployments == 0:
+		return ""
+	case rate <= 0.05:
+		return DORAPerformanceElite
+	case rate <= 0.10:
+		return DORAPerformanceHigh
+	case rate <= 0.15:
+		return DORAPerformanceMedium
+	default:
+		return DORAPerformanceLow
+	}
+}
+
+// ClassifyTimeToRestore rates the time to restore service: under an hour is elite, a day
+// high, a week medium
+func ClassifyTimeToRestore(hours float64, restores int64) DORAPerformance {
+	switch {
+	case restores == 0:
+		return ""
+	case hours < 1:
+		return DORAPerformanceElite
+	case hours < 24:
+		return DORAPerformanceHigh
+	case hours < 7*24:
+		return DORAPerformanceMedium
+	default:
+		return DORAPerformanceLow
+	}
+}
@@ -9,39 +9,71 @@ const (
 	EventTypeCommit      EventType = "commit"
 	EventTypePullRequest EventType = "pull_request"
 	EventTypeDeploy      EventType = "deploy"
+	EventTypeReview      EventType = "review"
+	EventTypeRelease     EventType = "release"
 )
 
 // Event represents a raw GitHub event
 type Event struct {
-	ID        string
-	Type      EventType
-	Org       string
-	Repo      string
-	Member    string
-	OwnerType string // "organization" or "user"
-	Timestamp time.Time
-	Data      map[string]interface{}
-	CreatedAt time.Time
+	ID        string                 `json:"id"`
+	Type      EventType              `json:"type"`
+	Org       string                 `json:"org"`
+	Repo      string                 `json:"repo"`
+	Member    string                 `json:"member"`
+	OwnerType string                 `json:"owner_type"` // "organization" or "user"
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
 }
 
 // CommitEvent represents a commit event with additional details
 type CommitEvent struct {
-	ID           string
-	Org          string
-	Repo         string
-	Member       string
-	OwnerType    string // "organization" or "user"
-	Timestamp    time.Time
-	Sha          string
-	Message      string
-	Additions    int
-	Deletions    int
-	FilesChanged int
-	CreatedAt    time.Time
+	ID           string       `json:"id"`
+	Org          string       `json:"org"`
+	Repo         string       `json:"repo"`
+	Member       string       `json:"member"`
+	OwnerType    string       `json:"owner_type"` // "organization" or "user"
+	Timestamp    time.Time    `json:"timestamp"`
+	Sha          string       `json:"sha"`
+	Message      string       `json:"message"`
+	Additions    int          `json:"additions"`
+	Deletions    int          `json:"deletions"`
+	FilesChanged int          `json:"files_changed"`
+	ParentCount  int          `json:"parent_count"`
+	MergeKind    string       `json:"merge_kind"`      // "merge", "squash" or empty for regular commits
+	PRNumber     int          `json:"pr_number"`       // pull request referenced by a merge/squash commit, 0 if none
+	Files        []FileChange `json:"files,omitempty"` // changed files, for components of monorepos
+	CreatedAt    time.Time    `json:"created_at"`
 }
 
+const (
+	// MergeKindMerge marks a merge commit (more than one parent) created when merging a PR
+	MergeKindMerge = "merge"
+	// MergeKindSquash marks a single-parent commit whose header refers to a PR like squash and
+	// merge-queue commits do. Cherry-picks and reverts of such commits look the same, so it is
+	// a PR's merge commit only when the PR's merge_commit_sha is the commit.
+	MergeKindSquash = "squash"
+)
+
 // ToEvent converts CommitEvent to Event
 func (c *CommitEvent) ToEvent() *Event {
+	data := map[string]interface{}{
+		"sha":           c.Sha,
+		"message":       c.Message,
+		"additions":     c.Additions,
+		"deletions":     c.Deletions,
+		"files_changed": c.FilesChanged,
+		"parent_count":  c.ParentCount,
+	}
+	if c.MergeKind != "" {
+		data["merge_kind"] = c.MergeKind
+	}
+	if c.PRNumber != 0 {
+		data["pr_number"] = c.PRNumber
+	}
+	if len(c.Files) > 0 {
+		data["files"] = c.Files
+	}
 	return &Event{
 		ID:        c.ID,
 		Type:      EventTypeCommit,
@@ -50,30 +82,25 @@ func (c *CommitEvent) ToEvent() *Event {
 		Member:    c.Member,
 		OwnerType: c.OwnerType,
 		Timestamp: c.Timestamp,
-		Data: map[string]interface{}{
-			"sha":           c.Sha,
-			"message":       c.Message,
-			"additions":     c.Additions,
-			"deletions":     c.Deletions,
-			"files_changed": c.FilesChanged,
-		},
+		Data:      data,
 		CreatedAt: c.CreatedAt,
 	}
 }
 
 // PullRequestEvent represents a pull request event with additional details
 type PullRequestEvent struct {
-	ID        string
-	Org       string
-	Repo      string
-	Member    string
-	OwnerType string // "organization" or "user"
-	Timestamp time.Time
-	Number    int
-	State     string // open, closed, merged
-	Title     string
-	MergedAt  *time.Time
-	CreatedAt time.Time
+	ID             string     `json:"id"`
+	Org            string     `json:"org"`
+	Repo           string     `json:"repo"`
+	Member         string     `json:"member"`
+	OwnerType      string     `json:"owner_type"` // "organization" or "user"
+	Timestamp      time.Time  `json:"timestamp"`
+	Number         int        `json:"number"`
+	State          string     `json:"state"` // open, closed, merged
+	Title          string     `json:"title"`
+	MergedAt       *time.Time `json:"merged_at,omitempty"`
+	MergeCommitSHA string     `json:"merge_commit_sha"` // commit created on the base branch when the PR was merged
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // ToEvent converts PullRequestEvent to Event
@@ -85,6 +112,9 @@ func (p *PullRequestEvent) ToEvent() *Event {
 	}
 	if p.MergedAt != nil {
 		data["merged_at"] = p.MergedAt.Format(time.RFC3339)
+		if p.MergeCommitSHA != "" {
+			data["merge_commit_sha"] = p.MergeCommitSHA
+		}
 	}
 	return &Event{
 		ID:        p.ID,
@@ -101,16 +131,17 @@ func (p *PullRequestEvent) ToEvent() *Event {
 
 // DeployEvent represents a deployment event with additional details
 type DeployEvent struct {
-	ID            string
-	Org           string
-	Repo          string
-	Member        string
-	OwnerType     string // "organization" or "user"
-	Timestamp     time.Time
-	Environment   string
-	Status        string
-	WorkflowRunID string
-	CreatedAt     time.Time
+	ID            string       `json:"id"`
+	Org           string       `json:"org"`
+	Repo          string       `json:"repo"`
+	Member        string       `json:"member"`
+	OwnerType     string       `json:"owner_type"` // "organization" or "user"
+	Timestamp     time.Time    `json:"timestamp"`
+	Environment   string       `json:"environment"`
+	Status        string       `json:"status"`
+	WorkflowRunID string       `json:"workflow_run_id"`
+	Source        DeploySource `json:"source"` // what counted as the deploy (see DeployDefinition)
+	CreatedAt     time.Time    `json:"created_at"`
 }
 
 // ToEvent converts DeployEvent to Event
@@ -127,7 +158,82 @@ func (d *DeployEvent) ToEvent() *Event {
 			"environment":     d.Environment,
 			"status":          d.Status,
 			"workflow_run_id": d.WorkflowRunID,
+			"source":          string(d.Source),
 		},
 		CreatedAt: d.CreatedAt,
 	}
 }
+
+// Review states of a ReviewEvent, the lowercase review states of the GitHub API
+const (
+	ReviewStateApproved         = "approved"
+	ReviewStateChangesRequested = "changes_requested"
+	ReviewStateCommented        = "commented"
+	ReviewStateDismissed        = "dismissed"
+)
+
+// ReviewEvent represents a submitted pull request review
+type ReviewEvent struct {
+	ID          string    `json:"id"`
+	Org         string    `json:"org"`
+	Repo        string    `json:"repo"`
+	Reviewer    string    `json:"reviewer"`
+	OwnerType   string    `json:"owner_type"` // "organization" or "user"
+	SubmittedAt time.Time `json:"submitted_at"`
+	PRNumber    int       `json:"pr_number"`
+	State       string    `json:"state"`       // ReviewStateApproved, ReviewStateChangesRequested, ...
+	BodyLength  int       `json:"body_length"` // characters of the review's summary comment, 0 if it has none
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToEvent converts ReviewEvent to Event; the reviewer is the event's member
+func (r *ReviewEvent) ToEvent() *Event {
+	return &Event{
+		ID:        r.ID,
+		Type:      EventTypeReview,
+		Org:       r.Org,
+		Repo:      r.Repo,
+		Member:    r.Reviewer,
+		OwnerType: r.OwnerType,
+		Timestamp: r.SubmittedAt,
+		Data: map[string]interface{}{
+			"pr_number":   r.PRNumber,
+			"state":       r.State,
+			"body_length": r.BodyLength,
+		},
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// ReleaseEvent represents a published release of a repository
+type ReleaseEvent struct {
+	ID          string    `json:"id"`
+	Org         string    `json:"org"`
+	Repo        string    `json:"repo"`
+	Member      string    `json:"member"`     // author of the release
+	OwnerType   string    `json:"owner_type"` // "organization" or "user"
+	Tag         string    `json:"tag"`        // tag the release points at, e.g. "v1.2.0"
+	Name        string    `json:"name"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToEvent converts ReleaseEvent to Event
+func (r *ReleaseEvent) ToEvent() *Event {
+	return &Event{
+		ID:        r.ID,
+		Type:      EventTypeRelease,
+		Org:       r.Org,
+		Repo:      r.Repo,
+		Member:    r.Member,
+		OwnerType: r.OwnerType,
+		Timestamp: r.PublishedAt,
+		Data: map[string]interface{}{
+			"tag":        r.Tag,
+			"name":       r.Name,
+			"prerelease": r.Prerelease,
+		},
+		CreatedAt: r.CreatedAt,
+	}
+}
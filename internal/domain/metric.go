@@ -14,87 +14,100 @@ const (
 
 // TimeRange represents a time range for metrics
 type TimeRange struct {
-	Start       time.Time
-	End         time.Time
-	Granularity string // "day", "week", "month"
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Granularity string    `json:"granularity"` // "day", "week", "month"
 }
 
 // Metric represents an aggregated metric
 type Metric struct {
-	ID        string
-	Type      MetricType
-	Org       string
-	Repo      *string // nil means organization-wide
-	Member    *string // nil means repository-wide
-	Value     int64
-	TimeRange TimeRange
-	Metadata  map[string]interface{}
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID        string                 `json:"id"`
+	Type      MetricType             `json:"type"`
+	Org       string                 `json:"org"`
+	Repo      *string                `json:"repo"`   // nil means organization-wide
+	Member    *string                `json:"member"` // nil means repository-wide
+	Value     int64                  `json:"value"`
+	TimeRange TimeRange              `json:"time_range"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
 }
 
 // MemberMetrics represents aggregated metrics for a member
 type MemberMetrics struct {
-	Member    string
-	Commits   int64
-	PRs       int64
-	Additions int64
-	Deletions int64
-	Deploys   int64
-	TimeRange TimeRange
+	Member    string    `json:"member"`
+	Commits   int64     `json:"commits"`
+	PRs       int64     `json:"prs"`
+	Additions int64     `json:"additions"`
+	Deletions int64     `json:"deletions"`
+	Deploys   int64     `json:"deploys"`
+	TimeRange TimeRange `json:"time_range"`
 }
 
 // RepoMetrics represents aggregated metrics for a repository
 type RepoMetrics struct {
-	Repo      string
-	Commits   int64
-	PRs       int64
-	Additions int64
-	Deletions int64
-	Deploys   int64
-	TimeRange TimeRange
+	Repo      string    `json:"repo"`
+	Commits   int64     `json:"commits"`
+	PRs       int64     `json:"prs"`
+	Additions int64     `json:"additions"`
+	Deletions int64     `json:"deletions"`
+	Deploys   int64     `json:"deploys"`
+	TimeRange TimeRange `json:"time_range"`
 }
 
 // OrgMetrics represents aggregated metrics for an organization
 type OrgMetrics struct {
-	Org          string
-	TotalRepos   int
-	TotalMembers int
-	Commits      int64
-	PRs          int64
-	Additions    int64
-	Deletions    int64
-	Deploys      int64
-	TimeRange    TimeRange
+	Org          string    `json:"org"`
+	TotalRepos   int       `json:"total_repos"`
+	TotalMembers int       `json:"total_members"`
+	Commits      int64     `json:"commits"`
+	PRs          int64     `json:"prs"`
+	Additions    int64     `json:"additions"`
+	Deletions    int64     `json:"deletions"`
+	Deploys      int64     `json:"deploys"`
+	TimeRange    TimeRange `json:"time_range"`
+}
+
+// TeamMetrics represents aggregated metrics for a team (the sum over its members)
+type TeamMetrics struct {
+	Team      string    `json:"team"` // team slug
+	Name      string    `json:"name"`
+	Members   int       `json:"members"`
+	Commits   int64     `json:"commits"`
+	PRs       int64     `json:"prs"`
+	Additions int64     `json:"additions"`
+	Deletions int64     `json:"deletions"`
+	Deploys   int64     `json:"deploys"`
+	TimeRange TimeRange `json:"time_range"`
 }
 
 // TimeSeriesMetric represents a single data point in a time series
 type TimeSeriesMetric struct {
-	Timestamp time.Time
-	Value     int64
+	Timestamp time.Time `json:"timestamp"`
+	Value     int64     `json:"value"`
 }
 
 // TimeSeriesData represents time series data for a metric type
 type TimeSeriesData struct {
-	Type        MetricType
-	Granularity string
-	DataPoints  []TimeSeriesMetric
+	Type        MetricType         `json:"type"`
+	Granularity string             `json:"granularity"`
+	DataPoints  []TimeSeriesMetric `json:"data_points"`
 }
 
 // DetailedTimeSeriesMetric represents a detailed data point with all metrics
 type DetailedTimeSeriesMetric struct {
-	Timestamp time.Time
-	Commits   int64
-	PRs       int64
-	Additions int64
-	Deletions int64
-	Deploys   int64
+	Timestamp time.Time `json:"timestamp"`
+	Commits   int64     `json:"commits"`
+	PRs       int64     `json:"prs"`
+	Additions int64     `json:"additions"`
+	Deletions int64     `json:"deletions"`
+	Deploys   int64     `json:"deploys"`
 }
 
 // DetailedTimeSeriesData represents detailed time series data with all metrics
 type DetailedTimeSeriesData struct {
-	Granularity string
-	DataPoints  []DetailedTimeSeriesMetric
+	Granularity string                     `json:"granularity"`
+	DataPoints  []DetailedTimeSeriesMetric `json:"data_points"`
 }
 
 // RankingType represents the type of ranking
@@ -109,22 +122,128 @@ const (
 
 // MemberRanking represents a member ranking entry
 type MemberRanking struct {
-	Rank      int    // 1-based rank
-	Member    string
-	Value     int64
-	Commits   int64
-	PRs       int64
-	Additions int64
-	Deletions int64
-	Deploys   int64
+	Rank      int    `json:"rank"` // 1-based rank
+	Member    string `json:"member"`
+	Value     int64  `json:"value"`
+	Commits   int64  `json:"commits"`
+	PRs       int64  `json:"prs"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	Deploys   int64  `json:"deploys"`
 }
 
 // RepoRanking represents a repository ranking entry
 type RepoRanking struct {
-	Rank    int    // 1-based rank
-	Repo    string
-	Value   int64
-	Commits int64
-	PRs     int64
-	Deploys int64
+	Rank    int    `json:"rank"` // 1-based rank
+	Repo    string `json:"repo"`
+	Value   int64  `json:"value"`
+	Commits int64  `json:"commits"`
+	PRs     int64  `json:"prs"`
+	Deploys int64  `json:"deploys"`
+}
+
+// TeamRanking represents a team ranking entry
+type TeamRanking struct {
+	Rank      int    `json:"rank"` // 1-based rank
+	Team      string `json:"team"` // team slug
+	Name      string `json:"name"`
+	Value     int64  `json:"value"`
+	Commits   int64  `json:"commits"`
+	PRs       int64  `json:"prs"`
+	Additions int64  `json:"additions"`
+	Deletions int64  `json:"deletions"`
+	Deploys   int64  `json:"deploys"`
+}
+
+// CommitCategory represents a conventional-commit type (feat, fix, ...)
+type CommitCategory string
+
+const (
+	CommitCategoryFeat     CommitCategory = "feat"
+	CommitCategoryFix      CommitCategory = "fix"
+	CommitCategoryChore    CommitCategory = "chore"
+	CommitCategoryDocs     CommitCategory = "docs"
+	CommitCategoryRefactor CommitCategory = "refactor"
+	CommitCategoryTest     CommitCategory = "test"
+	CommitCategoryPerf     CommitCategory = "perf"
+	CommitCategoryCI       CommitCategory = "ci"
+	CommitCategoryBuild    CommitCategory = "build"
+	CommitCategoryStyle    CommitCategory = "style"
+	CommitCategoryRevert   CommitCategory = "revert"
+	CommitCategoryOther    CommitCategory = "other" // message does not follow the convention
+)
+
+// CommitClassification represents commit counts per conventional-commit type for a repository or member
+type CommitClassification struct {
+	Name      string                   `json:"name"` // repository or member name
+	Total     int64                    `json:"total"`
+	Counts    map[CommitCategory]int64 `json:"counts"`
+	TimeRange TimeRange                `json:"time_range"`
+}
+
+// MergeCommit is a commit created by merging a pull request, which the PR already accounts for
+type MergeCommit struct {
+	SHA       string    `json:"sha"`
+	Repo      string    `json:"repo"`
+	Member    string    `json:"member"`
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"`      // MergeKindMerge or MergeKindSquash
+	Additions int64     `json:"additions"` // lines added by the commit
+	Deletions int64     `json:"deletions"` // lines deleted by the commit
+}
+
+// Comparison represents side-by-side metrics and time series of several repositories and members
+type Comparison struct {
+	Repos     []*RepoComparison   `json:"repos"`
+	Members   []*MemberComparison `json:"members"`
+	TimeRange TimeRange           `json:"time_range"`
+}
+
+// RepoComparison represents a repository's entry in a comparison
+type RepoComparison struct {
+	Repo       string                  `json:"repo"`
+	Metrics    *RepoMetrics            `json:"metrics"`
+	TimeSeries *DetailedTimeSeriesData `json:"time_series"`
+}
+
+// MemberComparison represents a member's entry in a comparison
+type MemberComparison struct {
+	Member     string                  `json:"member"`
+	Metrics    *MemberMetrics          `json:"metrics"`
+	TimeSeries *DetailedTimeSeriesData `json:"time_series"`
+}
+
+// OrgOverview is a snapshot of an owner for dashboard home pages: its metrics, top members
+// and repositories, latest collection and recent daily activity
+type OrgOverview struct {
+	Metrics          *OrgMetrics             `json:"metrics"`
+	TopMembers       []*MemberRanking        `json:"top_members"`       // by commits
+	TopRepos         []*RepoRanking          `json:"top_repos"`         // by commits
+	LatestCollection *CollectionBatchDetail  `json:"latest_collection"` // nil if the owner was never collected
+	Sparkline        *DetailedTimeSeriesData `json:"sparkline"`         // daily activity of the 30 days up to the end of the range
+	TimeRange        TimeRange               `json:"time_range"`
+}
+
+// HeatmapQuery selects the events counted in an activity heatmap
+type HeatmapQuery struct {
+	Repo       string         `json:"repo"`        // empty for all repositories
+	Member     string         `json:"member"`      // empty for all members
+	MetricType MetricType     `json:"metric_type"` // commit, pull_request or deploy
+	Location   *time.Location `json:"-"`           // time zone the weekday and hour of each event are taken in
+}
+
+// Heatmap counts events per weekday and hour of day. Values[d][h] is the count for
+// Weekdays[d] at Hours[h], so the matrix can be handed to chart libraries as is.
+type Heatmap struct {
+	Owner     string     `json:"owner"`
+	Repo      string     `json:"repo,omitempty"`   // empty when not limited to a repository
+	Member    string     `json:"member,omitempty"` // empty when not limited to a member
+	Type      MetricType `json:"type"`
+	TimeZone  string     `json:"time_zone"`
+	Weekdays  []string   `json:"weekdays"` // Monday first
+	Hours     []int      `json:"hours"`    // 0 to 23
+	Values    [][]int64  `json:"values"`   // [weekday][hour]
+	Max       int64      `json:"max"`      // largest cell value, for color scales
+	Total     int64      `json:"total"`
+	TimeRange TimeRange  `json:"time_range"`
 }
@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+// MemberProfile is everything an individual contributor page shows about a member: lifetime
+// and range totals, activity per repository, recent daily activity, where the member ranks
+// and their activity streaks
+type MemberProfile struct {
+	Member     string                  `json:"member"`
+	Lifetime   *MemberMetrics          `json:"lifetime"` // all activity up to the end of the range
+	Metrics    *MemberMetrics          `json:"metrics"`
+	Repos      []*RepoMetrics          `json:"repos"`      // activity in each repository in the range
+	TimeSeries *DetailedTimeSeriesData `json:"timeseries"` // daily activity of the 30 days up to the end of the range
+	Rankings   []MemberRankPosition    `json:"rankings"`
+	Streak     MemberStreak            `json:"streak"`
+	TimeRange  TimeRange               `json:"time_range"`
+}
+
+// MemberRankPosition is where a member ranks among the members of an owner in the range
+type MemberRankPosition struct {
+	Type  RankingType `json:"type"`
+	Rank  int         `json:"rank"`  // 1-based; 0 if the member isn't ranked, e.g. without activity
+	Value int64       `json:"value"` // the member's value the ranking is by
+	Of    int         `json:"of"`    // number of ranked members
+}
+
+// MemberStreak describes a member's runs of consecutive active days (UTC days with a commit,
+// pull request or deploy)
+type MemberStreak struct {
+	Current      int        `json:"current"` // days in the run ending on the last day, or the day before if it had no activity yet
+	Longest      int        `json:"longest"`
+	LongestStart *time.Time `json:"longest_start,omitempty"`
+	LongestEnd   *time.Time `json:"longest_end,omitempty"`
+	ActiveDays   int        `json:"active_days"`
+	FirstActive  *time.Time `json:"first_active,omitempty"`
+	LastActive   *time.Time `json:"last_active,omitempty"`
+}
+
+// NewMemberStreak computes the streaks of daily activity up to the day of end. Days of the
+// series without activity, and days it leaves out, break a streak.
+func NewMemberStreak(daily *DetailedTimeSeriesData, end time.Time) MemberStreak {
+	var streak MemberStreak
+	if daily == nil {
+		return streak
+	}
+
+	var runStart, prev time.Time
+	run := 0
+	for _, point := range daily.DataPoints {
+		if point.Commits+point.PRs+point.Deploys == 0 {
+			continue
+		}
+		day := point.Timestamp.UTC().Truncate(24 * time.Hour)
+		if run > 0 && day.Equal(prev) {
+			continue
+		}
+		if run > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run, runStart = 1, day
+		}
+		if run > streak.Longest {
+			start, last := runStart, day
+			streak.Longest, streak.LongestStart, streak.LongestEnd = run, &start, &last
+		}
+		if streak.FirstActive == nil {
+			first := day
+			streak.FirstActive = &first
+		}
+		streak.ActiveDays++
+		prev = day
+	}
+	if streak.ActiveDays == 0 {
+		return streak
+	}
+
+	last := prev
+	streak.LastActive = &last
+	// A streak isn't broken until a whole day passes without activity
+	today := end.UTC().Truncate(24 * time.Hour)
+	if !last.Before(today.AddDate(0, 0, -1)) {
+		streak.Current = run
+	}
+	return streak
+}
@@ -4,23 +4,98 @@ import "time"
 
 // Repository represents a GitHub repository
 type Repository struct {
-	Org          string
-	Name         string
-	FullName     string
-	IsPrivate    bool
-	OwnerType    string // "organization" or "user"
-	LastSyncedAt *time.Time
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	Org          string     `json:"org"`
+	Name         string     `json:"name"`
+	FullName     string     `json:"full_name"`
+	IsPrivate    bool       `json:"is_private"`
+	OwnerType    string     `json:"owner_type"` // "organization" or "user"
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RepositoryList is an owner's list of repositories as fetched from GitHub at FetchedAt,
+// cached so that collections shortly after don't list them again
+type RepositoryList struct {
+	Owner     string        `json:"owner"`
+	OwnerType string        `json:"owner_type"` // "organization" or "user"
+	Repos     []*Repository `json:"repos"`
+	FetchedAt time.Time     `json:"fetched_at"`
 }
 
 // Member represents a GitHub organization member
 type Member struct {
-	Org          string
-	Username     string
-	DisplayName  string
-	OwnerType    string // "organization" or "user"
-	LastSyncedAt *time.Time
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	Org          string     `json:"org"`
+	Username     string     `json:"username"`
+	DisplayName  string     `json:"display_name"`
+	OwnerType    string     `json:"owner_type"` // "organization" or "user"
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Team represents a GitHub organization team
+type Team struct {
+	Org          string     `json:"org"`
+	Slug         string     `json:"slug"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Members      []string   `json:"members"`               // usernames of the team's direct and child-team members
+	Maintainers  []string   `json:"maintainers,omitempty"` // the members with the maintainer role
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// HasMember reports whether username belongs to the team
+func (t *Team) HasMember(username string) bool {
+	for _, m := range t.Members {
+		if m == username {
+			return true
+		}
+	}
+	return false
+}
+
+// Role returns the role of username in the team, or "" if username isn't a member
+func (t *Team) Role(username string) string {
+	if !t.HasMember(username) {
+		return ""
+	}
+	for _, m := range t.Maintainers {
+		if m == username {
+			return TeamRoleMaintainer
+		}
+	}
+	return TeamRoleMember
+}
+
+// Memberships returns the membership of each member of the team
+func (t *Team) Memberships() []*TeamMembership {
+	memberships := make([]*TeamMembership, 0, len(t.Members))
+	for _, m := range t.Members {
+		memberships = append(memberships, &TeamMembership{
+			Org:      t.Org,
+			Team:     t.Slug,
+			TeamName: t.Name,
+			Username: m,
+			Role:     t.Role(m),
+		})
+	}
+	return memberships
+}
+
+// Roles of a team member
+const (
+	TeamRoleMember     = "member"
+	TeamRoleMaintainer = "maintainer"
+)
+
+// TeamMembership represents a user's membership in an organization team
+type TeamMembership struct {
+	Org      string `json:"org"`
+	Team     string `json:"team"` // team slug
+	TeamName string `json:"team_name"`
+	Username string `json:"username"`
+	Role     string `json:"role"` // TeamRoleMember or TeamRoleMaintainer
 }
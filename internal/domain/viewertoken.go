@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ViewerTokenPrefix starts every viewer token, telling them apart from API keys
+const ViewerTokenPrefix = "gvt_"
+
+// ErrInvalidViewerToken is returned for viewer tokens that are malformed, not signed with
+// the configured secret or expired
+var ErrInvalidViewerToken = errors.New("invalid or expired viewer token")
+
+// ViewerToken is a signed, expiring token giving read-only access to a single organization
+// or user, meant for sharing dashboard links. Tokens are not stored: they stay valid until
+// they expire or the signing secret is changed.
+type ViewerToken struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// viewerTokenPayload is the signed part of a viewer token
+type viewerTokenPayload struct {
+	Owner     string `json:"owner"`
+	ExpiresAt int64  `json:"exp"` // Unix seconds
+}
+
+// Sign encodes the token and signs it with secret
+func (t ViewerToken) Sign(secret []byte) (string, error) {
+	payload, err := json.Marshal(viewerTokenPayload{Owner: t.Owner, ExpiresAt: t.ExpiresAt.Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return ViewerTokenPrefix + encoded + "." + viewerTokenSignature(secret, encoded), nil
+}
+
+// ParseViewerToken verifies a token signed with secret and returns it if it has not
+// expired at now
+func ParseViewerToken(secret []byte, token string, now time.Time) (*ViewerToken, error) {
+	encoded, signature, ok := strings.Cut(strings.TrimPrefix(token, ViewerTokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, ViewerTokenPrefix) {
+		return nil, ErrInvalidViewerToken
+	}
+	if !hmac.Equal([]byte(signature), []byte(viewerTokenSignature(secret, encoded))) {
+		return nil, ErrInvalidViewerToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidViewerToken
+	}
+	var p viewerTokenPayload
+	if err := json.Unmarshal(payload, &p); err != nil || p.Owner == "" {
+		return nil, ErrInvalidViewerToken
+	}
+	t := &ViewerToken{Owner: p.Owner, ExpiresAt: time.Unix(p.ExpiresAt, 0).UTC()}
+	if !now.Before(t.ExpiresAt) {
+		return nil, ErrInvalidViewerToken
+	}
+	return t, nil
+}
+
+// APIKey returns the read-only principal the token authenticates as
+func (t *ViewerToken) APIKey() *APIKey {
+	return &APIKey{
+		Name:   "viewer:" + t.Owner,
+		Scope:  APIKeyScopeRead,
+		Grants: []OwnerGrant{{Owner: t.Owner, Role: RoleViewer}},
+	}
+}
+
+// viewerTokenSignature returns the base64url HMAC-SHA256 of an encoded token payload
+func viewerTokenSignature(secret []byte, encoded string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// workspaceIDPattern restricts workspace IDs to URL-friendly slugs
+var workspaceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Workspace is an independent tenant of a hosted deployment. It owns a set of
+// organizations/users; its API keys and routes can only reach those owners.
+type Workspace struct {
+	ID        string    `json:"id"` // URL slug used in /api/v1/workspaces/:ws
+	Name      string    `json:"name"`
+	Owners    []string  `json:"owners"` // organizations/users belonging to the workspace; an owner belongs to at most one workspace
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HasOwner reports whether owner belongs to the workspace
+func (w *Workspace) HasOwner(owner string) bool {
+	for _, o := range w.Owners {
+		if o == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidWorkspaceID reports whether id can be used as a workspace ID
+func IsValidWorkspaceID(id string) bool {
+	return workspaceIDPattern.MatchString(id)
+}
@@ -12,6 +12,9 @@ const (
 	ErrCodeInternal     ErrCode = "INTERNAL_ERROR"
 	ErrCodeBadRequest   ErrCode = "BAD_REQUEST"
 	ErrCodeForbidden    ErrCode = "FORBIDDEN"
+	ErrCodeConflict     ErrCode = "CONFLICT"
+
+	ErrCodeInvalidRankingType ErrCode = "INVALID_RANKING_TYPE"
 )
 
 // AppError represents an application error
@@ -81,6 +84,22 @@ func NewForbiddenError(message string) *AppError {
 	}
 }
 
+// NewConflictError creates a new error for a request conflicting with one in progress
+func NewConflictError(message string) *AppError {
+	return &AppError{
+		Code:    ErrCodeConflict,
+		Message: message,
+	}
+}
+
+// NewInvalidRankingTypeError creates a new error for an unknown ranking type
+func NewInvalidRankingTypeError(message string) *AppError {
+	return &AppError{
+		Code:    ErrCodeInvalidRankingType,
+		Message: message,
+	}
+}
+
 // IsNotFound checks if the error is a not found error
 func IsNotFound(err error) bool {
 	if appErr, ok := err.(*AppError); ok {
@@ -0,0 +1,279 @@
+// Package exporter exposes aggregated activity metrics in the Prometheus exposition format, so
+// that Prometheus can scrape them for dashboards such as Grafana
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+const namespace = "github_metrics"
+
+// DefaultWindow is the length of the time window the metrics are totaled over unless
+// WithWindow is given
+const DefaultWindow = 30 * 24 * time.Hour
+
+func newDesc(subsystem, name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil)
+}
+
+var (
+	orgCommitsDesc  = newDesc("org", "commits_total", "Commits in the window, by organization or user.", "org")
+	orgPRsDesc      = newDesc("org", "prs_total", "Pull requests opened in the window, by organization or user.", "org")
+	orgDeploysDesc  = newDesc("org", "deploys_total", "Deployments in the window, by organization or user.", "org")
+	orgLeadTimeDesc = newDesc("org", "lead_time_seconds", "Median time from opening to merging of the pull requests opened in the window and merged, by organization or user.", "org")
+
+	repoCommitsDesc  = newDesc("repo", "commits_total", "Commits in the window, by repository.", "org", "repo")
+	repoPRsDesc      = newDesc("repo", "prs_total", "Pull requests opened in the window, by repository.", "org", "repo")
+	repoDeploysDesc  = newDesc("repo", "deploys_total", "Deployments in the window, by repository.", "org", "repo")
+	repoLeadTimeDesc = newDesc("repo", "lead_time_seconds", "Median time from opening to merging of the pull requests opened in the window and merged, by repository.", "org", "repo")
+
+	memberCommitsDesc = newDesc("member", "commits_total", "Commits in the window, by member.", "org", "member")
+	memberPRsDesc     = newDesc("member", "prs_total", "Pull requests opened in the window, by member.", "org", "member")
+	memberDeploysDesc = newDesc("member", "deploys_total", "Deployments in the window, by member.", "org", "member")
+
+	windowDesc      = newDesc("exporter", "window_seconds", "Length of the time window the activity metrics are totaled over.")
+	lastRefreshDesc = newDesc("exporter", "last_refresh_timestamp_seconds", "Unix time the activity metrics were last refreshed.")
+	durationDesc    = newDesc("exporter", "refresh_duration_seconds", "Time the last refresh of the activity metrics took.")
+)
+
+// OwnerLister lists the organizations and users with stored activity
+type OwnerLister interface {
+	ListOwners(ctx context.Context) ([]string, error)
+}
+
+// ownerMetrics are the metrics of an owner at the last refresh
+type ownerMetrics struct {
+	org      *domain.OrgMetrics
+	repos    []*domain.RepoMetrics
+	members  []*domain.MemberMetrics
+	leadTime *domain.DORAMetrics
+	repoLead map[string]*domain.DORAMetrics // by repository
+}
+
+// Exporter is a prometheus.Collector of the activity metrics of owners over a sliding window
+// ending at the last refresh. Scrapes serve the values of the last refresh, so they don't
+// query the database; Run refreshes them periodically.
+type Exporter struct {
+	agg    aggregator.Aggregator
+	lister OwnerLister
+	owners []string // empty for every owner lister returns
+	window time.Duration
+	logger *slog.Logger
+
+	refreshErrors prometheus.Counter
+
+	mu          sync.RWMutex
+	metrics     map[string]*ownerMetrics // by owner
+	lastRefresh time.Time
+	duration    time.Duration
+}
+
+// Option configures optional exporter behavior
+type Option func(*Exporter)
+
+// WithOwners limits the exported owners to owners instead of every owner with stored activity
+func WithOwners(owners ...string) Option {
+	return func(e *Exporter) {
+		e.owners = owners
+	}
+}
+
+// WithWindow sets the length of the time window the metrics are totaled over
+func WithWindow(window time.Duration) Option {
+	return func(e *Exporter) {
+		if window > 0 {
+			e.window = window
+		}
+	}
+}
+
+// WithLogger sets the logger refresh failures are reported to
+func WithLogger(logger *slog.Logger) Option {
+	return func(e *Exporter) {
+		e.logger = logger
+	}
+}
+
+// New returns an exporter of the metrics agg computes for the owners lister returns. It has no
+// metrics until the first Refresh.
+func New(agg aggregator.Aggregator, lister OwnerLister, opts ...Option) *Exporter {
+	e := &Exporter{
+		agg:    agg,
+		lister: lister,
+		window: DefaultWindow,
+		logger: slog.Default(),
+		refreshErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "exporter",
+			Name:      "refresh_errors_total",
+			Help:      "Number of owners whose activity metrics failed to refresh.",
+		}),
+		metrics: make(map[string]*ownerMetrics),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Describe implements prometheus.Collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		orgCommitsDesc, orgPRsDesc, orgDeploysDesc, orgLeadTimeDesc,
+		repoCommitsDesc, repoPRsDesc, repoDeploysDesc, repoLeadTimeDesc,
+		memberCommitsDesc, memberPRsDesc, memberDeploysDesc,
+		windowDesc, lastRefreshDesc, durationDesc,
+	} {
+		ch <- desc
+	}
+	e.refreshErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector with the values of the last refresh
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for owner, m := range e.metrics {
+		gauge(orgCommitsDesc, float64(m.org.Commits), owner)
+		gauge(orgPRsDesc, float64(m.org.PRs), owner)
+		gauge(orgDeploysDesc, float64(m.org.Deploys), owner)
+		// Without merged pull requests there is no lead time to report
+		if m.leadTime != nil && m.leadTime.MergedPRs > 0 {
+			gauge(orgLeadTimeDesc, m.leadTime.LeadTimeHours*3600, owner)
+		}
+		for _, r := range m.repos {
+			gauge(repoCommitsDesc, float64(r.Commits), owner, r.Repo)
+			gauge(repoPRsDesc, float64(r.PRs), owner, r.Repo)
+			gauge(repoDeploysDesc, float64(r.Deploys), owner, r.Repo)
+			if lead := m.repoLead[r.Repo]; lead != nil && lead.MergedPRs > 0 {
+				gauge(repoLeadTimeDesc, lead.LeadTimeHours*3600, owner, r.Repo)
+			}
+		}
+		for _, member := range m.members {
+			gauge(memberCommitsDesc, float64(member.Commits), owner, member.Member)
+			gauge(memberPRsDesc, float64(member.PRs), owner, member.Member)
+			gauge(memberDeploysDesc, float64(member.Deploys), owner, member.Member)
+		}
+	}
+	gauge(windowDesc, e.window.Seconds())
+	if !e.lastRefresh.IsZero() {
+		gauge(lastRefreshDesc, float64(e.lastRefresh.UnixNano())/1e9)
+		gauge(durationDesc, e.duration.Seconds())
+	}
+	e.refreshErrors.Collect(ch)
+}
+
+// Refresh recomputes the metrics of every owner over the window ending now. An owner that
+// fails keeps the values of its previous refresh; the failures are returned joined.
+func (e *Exporter) Refresh(ctx context.Context) error {
+	started := time.Now()
+	owners := e.owners
+	if len(owners) == 0 {
+		listed, err := e.lister.ListOwners(ctx)
+		if err != nil {
+			e.refreshErrors.Inc()
+			return fmt.Errorf("failed to list owners: %w", err)
+		}
+		owners = listed
+	}
+
+	timeRange := domain.TimeRange{Start: started.Add(-e.window), End: started, Granularity: "day"}
+	metrics := make(map[string]*ownerMetrics, len(owners))
+	var errs []error
+	for _, owner := range owners {
+		m, err := e.collectOwner(ctx, owner, timeRange)
+		if err != nil {
+			e.refreshErrors.Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", owner, err))
+			e.mu.RLock()
+			m = e.metrics[owner]
+			e.mu.RUnlock()
+			if m == nil {
+				continue
+			}
+		}
+		metrics[owner] = m
+	}
+
+	e.mu.Lock()
+	e.metrics = metrics
+	e.lastRefresh = time.Now()
+	e.duration = e.lastRefresh.Sub(started)
+	e.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// collectOwner queries the metrics of an owner over timeRange
+func (e *Exporter) collectOwner(ctx context.Context, owner string, timeRange domain.TimeRange) (*ownerMetrics, error) {
+	org, err := e.agg.AggregateOrgMetrics(ctx, owner, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := e.agg.GetReposMetrics(ctx, owner, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	members, err := e.agg.GetMembersMetrics(ctx, owner, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	leadTime, err := e.agg.GetDORAMetrics(ctx, owner, "", timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ownerMetrics{org: org, repos: repos, members: members, leadTime: leadTime, repoLead: make(map[string]*domain.DORAMetrics)}
+	// A repository can only have a lead time if the owner has one
+	if leadTime.MergedPRs > 0 {
+		for _, r := range repos {
+			if r.PRs == 0 {
+				continue
+			}
+			lead, err := e.agg.GetDORAMetrics(ctx, owner, r.Repo, timeRange)
+			if err != nil {
+				return nil, err
+			}
+			m.repoLead[r.Repo] = lead
+		}
+	}
+	return m, nil
+}
+
+// Run refreshes the metrics now and then every interval until ctx is canceled, logging
+// failures
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := e.Refresh(ctx); err != nil && ctx.Err() == nil {
+			e.logger.Warn("failed to refresh metrics", "error", err)
+		} else if err == nil {
+			e.logger.Debug("refreshed metrics", "owners", e.ownerCount())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Exporter) ownerCount() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.metrics)
+}
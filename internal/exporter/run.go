@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/logging"
+	"github.com/kurihiro0119/github-activity-metrics/internal/monitoring"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage/postgres"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage/sqlite"
+)
+
+// Run serves the metrics of cfg's storage on /metrics and blocks until it fails or is stopped
+// with SIGINT or SIGTERM
+func Run(cfg *config.Config) error {
+	logger := logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	slog.SetDefault(logger)
+
+	// Initialize storage; with AUTO_MIGRATE=false the schema is left to "migrate up"
+	var store storage.Storage
+	var err error
+	var storageOpts []storage.OpenOption
+	if !cfg.AutoMigrate {
+		storageOpts = append(storageOpts, storage.WithoutMigrate())
+	}
+	switch cfg.StorageType {
+	case "postgres":
+		store, err = postgres.NewPostgresStorage(cfg.PostgresURL, storageOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize PostgreSQL storage: %w", err)
+		}
+	default:
+		store, err = sqlite.NewSQLiteStorage(cfg.SQLitePath, storageOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize SQLite storage: %w", err)
+		}
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("failed to close storage", "error", err)
+		}
+	}()
+
+	// Every refresh covers a different window, so the aggregator's cache would never be hit
+	aggOpts := []aggregator.Option{aggregator.WithStreamChunk(cfg.StreamChunk)}
+	if cfg.DedupMergeCommits {
+		aggOpts = append(aggOpts, aggregator.WithMergeCommitDedup())
+	}
+	if cfg.ExcludeBots {
+		aggOpts = append(aggOpts, aggregator.WithBotExclusion(cfg.BotPatterns...))
+	}
+	if cfg.PseudonymizeMembers {
+		aggOpts = append(aggOpts, aggregator.WithPseudonyms(aggregator.NewPseudonyms(cfg.PseudonymSecret)))
+	}
+	agg := aggregator.NewAggregator(monitoring.InstrumentStorage(store), aggOpts...)
+
+	exp := New(agg, store,
+		WithOwners(cfg.ExporterOwners...),
+		WithWindow(cfg.ExporterWindow),
+		WithLogger(logger),
+	)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exp)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, monitoring.Registry}, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	addr := fmt.Sprintf("%s:%s", cfg.ExporterHost, cfg.ExporterPort)
+	logger.Info("starting exporter",
+		"addr", addr,
+		"storage", cfg.StorageType,
+		"owners", cfg.ExporterOwners,
+		"window", cfg.ExporterWindow.String(),
+		"interval", cfg.ExporterInterval.String(),
+	)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.APIReadTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go exp.Run(ctx, cfg.ExporterInterval)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to start exporter: %w", err)
+		}
+	case <-ctx.Done():
+		stop()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("graceful shutdown failed, closing remaining connections", "error", err)
+			_ = srv.Close()
+		}
+	}
+
+	logger.Info("exporter stopped")
+	return nil
+}
@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/api"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+const (
+	// auditMethod is recorded as the method of gRPC calls, which have no HTTP method of their own
+	auditMethod = "GRPC"
+
+	// anonymousPrincipal is recorded for calls without an authenticated API key, as in the
+	// HTTP audit log
+	anonymousPrincipal = "anonymous"
+)
+
+// principalContextKey holds a *string that authInterceptor sets to the API key name, so
+// that auditInterceptor, which runs first, can record who made the call
+type principalContextKey struct{}
+
+// auditInterceptor records every call in the audit log like the HTTP API's Audit middleware:
+// the principal, owner, full method as the route, request message as the parameters, the
+// HTTP status equivalent to the call's status code, and the peer address. It must run
+// before authInterceptor so that rejected calls are recorded as well. Failures to record
+// are logged, not returned to the client.
+func auditInterceptor(store api.AuditStore, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		principal := anonymousPrincipal
+		resp, err := handler(context.WithValue(ctx, principalContextKey{}, &principal), req)
+
+		entry := &domain.AuditEntry{
+			Time:      start.UTC(),
+			Principal: principal,
+			Method:    auditMethod,
+			Route:     info.FullMethod,
+			Path:      info.FullMethod,
+			Status:    httpStatus(status.Code(err)),
+			ClientIP:  peerAddress(ctx),
+			RequestID: requestID(ctx),
+		}
+		if r, ok := req.(ownerRequest); ok {
+			entry.Owner = r.GetOwner()
+		}
+		if m, ok := req.(proto.Message); ok {
+			if params, err := protojson.Marshal(m); err == nil {
+				entry.Params = string(params)
+			}
+		}
+
+		if err := store.SaveAuditEntry(context.WithoutCancel(ctx), entry); err != nil {
+			logger.Error("failed to record audit entry", "method", info.FullMethod, "error", err)
+		}
+		return resp, err
+	}
+}
+
+// setPrincipal records the API key name of the call for auditInterceptor, if it runs
+func setPrincipal(ctx context.Context, name string) {
+	if principal, ok := ctx.Value(principalContextKey{}).(*string); ok {
+		*principal = name
+	}
+}
+
+// peerAddress returns the IP address of the calling client, or "" when it is unknown
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// requestID returns the x-request-id metadata of the call, if the client sent one
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// httpStatus maps a gRPC status code to the HTTP status the HTTP API returns for the same
+// error, so that audit entries of both APIs can be filtered alike
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
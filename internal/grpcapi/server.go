@@ -0,0 +1,222 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/api"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	apperrors "github.com/kurihiro0119/github-activity-metrics/internal/errors"
+	"github.com/kurihiro0119/github-activity-metrics/pkg/grpc/metricsv1"
+)
+
+// Option configures optional gRPC server behavior
+type Option func(*serverConfig)
+
+// serverConfig holds the optional gRPC server settings
+type serverConfig struct {
+	auth    *api.Authenticator // nil when authentication is disabled
+	limiter *api.RateLimiter   // nil when rate limiting is disabled
+	audit   api.AuditStore     // nil disables the audit log
+	logger  *slog.Logger
+	tracing bool
+}
+
+// WithAuth requires a valid API key ("authorization: Bearer <key>" metadata) on every call
+func WithAuth(auth *api.Authenticator) Option {
+	return func(sc *serverConfig) {
+		sc.auth = auth
+	}
+}
+
+// WithRateLimit limits the call rate of each API key (or client IP without authentication)
+// with limiter. Sharing the HTTP API's limiter gives a key a single budget across both APIs.
+func WithRateLimit(limiter *api.RateLimiter) Option {
+	return func(sc *serverConfig) {
+		sc.limiter = limiter
+	}
+}
+
+// WithAuditLog records every call in store, alongside the HTTP API's requests
+func WithAuditLog(store api.AuditStore) Option {
+	return func(sc *serverConfig) {
+		sc.audit = store
+	}
+}
+
+// WithLogger sets the logger used for call logs (slog.Default() if not set)
+func WithLogger(logger *slog.Logger) Option {
+	return func(sc *serverConfig) {
+		sc.logger = logger
+	}
+}
+
+// WithTracing starts an OpenTelemetry span for every call
+func WithTracing() Option {
+	return func(sc *serverConfig) {
+		sc.tracing = true
+	}
+}
+
+// NewServer creates a gRPC server serving MetricsService backed by agg
+func NewServer(agg aggregator.Aggregator, opts ...Option) *grpc.Server {
+	sc := &serverConfig{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(sc)
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{logInterceptor(sc.logger)}
+	if sc.audit != nil {
+		// Before authInterceptor so that rejected calls are recorded as well
+		interceptors = append(interceptors, auditInterceptor(sc.audit, sc.logger))
+	}
+	if sc.auth != nil {
+		interceptors = append(interceptors, authInterceptor(sc.auth))
+	}
+	if sc.limiter != nil {
+		// After authInterceptor so that clients are limited per key rather than per IP
+		interceptors = append(interceptors, rateLimitInterceptor(sc.limiter))
+	}
+
+	serverOpts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if sc.tracing {
+		serverOpts = append(serverOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+	srv := grpc.NewServer(serverOpts...)
+	metricsv1.RegisterMetricsServiceServer(srv, &metricsService{aggregator: agg})
+	return srv
+}
+
+// ownerRequest is implemented by every MetricsService request message. authInterceptor
+// refuses calls whose request doesn't, as it can't check their owner.
+type ownerRequest interface {
+	GetOwner() string
+}
+
+// apiKeyContextKey holds the *domain.APIKey authInterceptor authenticated the call with
+type apiKeyContextKey struct{}
+
+// logInterceptor logs each call with its status code and latency
+func logInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code != codes.OK {
+			level = slog.LevelWarn
+		}
+		attrs := []any{
+			"method", info.FullMethod,
+			"code", code.String(),
+			"latency", time.Since(start),
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		logger.Log(ctx, level, "grpc call", attrs...)
+		return resp, err
+	}
+}
+
+// authInterceptor authenticates the API key in the call metadata and checks that it may
+// view the requested owner. All MetricsService calls are read-only.
+func authInterceptor(auth *api.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token = bearerToken(values[0])
+			}
+		}
+
+		key, err := auth.Authenticate(ctx, token)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		setPrincipal(ctx, key.Name)
+		if key.Workspace != "" {
+			// Workspaces are only served over the HTTP API's /workspaces/:ws routes
+			return nil, status.Error(codes.PermissionDenied, "workspace API keys cannot be used with the gRPC API")
+		}
+
+		r, ok := req.(ownerRequest)
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "%s has no owner to authorize", info.FullMethod)
+		}
+		role, ok := key.RoleFor(r.GetOwner())
+		if !ok || !role.Allows(domain.RoleViewer) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key is not allowed to access '%s'", r.GetOwner())
+		}
+
+		return handler(context.WithValue(ctx, apiKeyContextKey{}, key), req)
+	}
+}
+
+// rateLimitInterceptor limits calls per API key, or per client IP when authentication is
+// disabled, like the HTTP API's RateLimit middleware. Refused calls get ResourceExhausted
+// with the seconds to wait in the retry-after header.
+func rateLimitInterceptor(limiter *api.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, enabled := limiter.Limit(); !enabled {
+			return handler(ctx, req)
+		}
+
+		client := "ip:" + peerAddress(ctx)
+		if key, ok := ctx.Value(apiKeyContextKey{}).(*domain.APIKey); ok {
+			client = "key:" + key.Name
+		}
+		if allowed, _, retryAfter := limiter.Allow(client); !allowed {
+			seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", seconds))
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// bearerToken extracts the token from a "Bearer <token>" metadata value
+func bearerToken(value string) string {
+	const prefix = "bearer "
+	if len(value) > len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+		return strings.TrimSpace(value[len(prefix):])
+	}
+	return ""
+}
+
+// toStatus converts an application error to a gRPC status error
+func toStatus(err error) error {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch appErr.Code {
+	case apperrors.ErrCodeNotFound:
+		code = codes.NotFound
+	case apperrors.ErrCodeUnauthorized:
+		code = codes.Unauthenticated
+	case apperrors.ErrCodeForbidden:
+		code = codes.PermissionDenied
+	case apperrors.ErrCodeBadRequest, apperrors.ErrCodeInvalidRankingType:
+		code = codes.InvalidArgument
+	case apperrors.ErrCodeRateLimited:
+		code = codes.ResourceExhausted
+	}
+	return status.Error(code, appErr.Message)
+}
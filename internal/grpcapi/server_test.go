@@ -0,0 +1,32 @@
+package grpcapi
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/kurihiro0119/github-activity-metrics/pkg/grpc/metricsv1"
+)
+
+// TestRequestsHaveOwner guards authInterceptor, which refuses calls whose request message
+// has no owner to authorize: every MetricsService method must take one.
+func TestRequestsHaveOwner(t *testing.T) {
+	services := metricsv1.File_metrics_v1_metrics_proto.Services()
+	service := services.ByName("MetricsService")
+	if service == nil {
+		t.Fatal("MetricsService is not defined")
+	}
+
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		input := method.Input().FullName()
+		messageType, err := protoregistry.GlobalTypes.FindMessageByName(input)
+		if err != nil {
+			t.Fatalf("%s: %v", method.Name(), err)
+		}
+		if _, ok := messageType.New().Interface().(ownerRequest); !ok {
+			t.Errorf("%s takes %s, which has no GetOwner method", method.Name(), input)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/pkg/grpc/metricsv1"
+)
+
+// defaultRankingLimit is the number of ranking entries returned when the request has no limit
+const defaultRankingLimit = 10
+
+// metricsService implements metricsv1.MetricsServiceServer on top of the aggregator
+type metricsService struct {
+	metricsv1.UnimplementedMetricsServiceServer
+	aggregator aggregator.Aggregator
+}
+
+// GetOrgMetrics returns organization-level metrics
+func (s *metricsService) GetOrgMetrics(ctx context.Context, req *metricsv1.OwnerRequest) (*metricsv1.OrgMetrics, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	metrics, err := s.aggregator.AggregateOrgMetrics(ctx, req.GetOwner(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toOrgMetrics(metrics), nil
+}
+
+// GetMemberMetrics returns metrics for a single member
+func (s *metricsService) GetMemberMetrics(ctx context.Context, req *metricsv1.MemberRequest) (*metricsv1.MemberMetrics, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	metrics, err := s.aggregator.AggregateMemberMetrics(ctx, req.GetOwner(), req.GetMember(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toMemberMetrics(metrics), nil
+}
+
+// GetRepoMetrics returns metrics for a single repository
+func (s *metricsService) GetRepoMetrics(ctx context.Context, req *metricsv1.RepoRequest) (*metricsv1.RepoMetrics, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	metrics, err := s.aggregator.AggregateRepoMetrics(ctx, req.GetOwner(), req.GetRepo(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toRepoMetrics(metrics), nil
+}
+
+// ListMembersMetrics returns metrics for all members
+func (s *metricsService) ListMembersMetrics(ctx context.Context, req *metricsv1.OwnerRequest) (*metricsv1.ListMembersMetricsResponse, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	metrics, err := s.aggregator.GetMembersMetrics(ctx, req.GetOwner(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &metricsv1.ListMembersMetricsResponse{}
+	for _, m := range metrics {
+		resp.Members = append(resp.Members, toMemberMetrics(m))
+	}
+	return resp, nil
+}
+
+// ListRepoMembersMetrics returns metrics for all members of a repository
+func (s *metricsService) ListRepoMembersMetrics(ctx context.Context, req *metricsv1.RepoRequest) (*metricsv1.ListMembersMetricsResponse, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	metrics, err := s.aggregator.GetRepoMembersMetrics(ctx, req.GetOwner(), req.GetRepo(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &metricsv1.ListMembersMetricsResponse{}
+	for _, m := range metrics {
+		resp.Members = append(resp.Members, toMemberMetrics(m))
+	}
+	return resp, nil
+}
+
+// ListReposMetrics returns metrics for all repositories
+func (s *metricsService) ListReposMetrics(ctx context.Context, req *metricsv1.OwnerRequest) (*metricsv1.ListReposMetricsResponse, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	metrics, err := s.aggregator.GetReposMetrics(ctx, req.GetOwner(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &metricsv1.ListReposMetricsResponse{}
+	for _, m := range metrics {
+		resp.Repos = append(resp.Repos, toRepoMetrics(m))
+	}
+	return resp, nil
+}
+
+// GetTimeSeries returns a time series for a single metric type
+func (s *metricsService) GetTimeSeries(ctx context.Context, req *metricsv1.TimeSeriesRequest) (*metricsv1.TimeSeries, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+
+	var metricType domain.MetricType
+	switch req.GetMetricType() {
+	case "", "commit":
+		metricType = domain.MetricTypeCommit
+	case "pull_request":
+		metricType = domain.MetricTypePullRequest
+	case "deploy":
+		metricType = domain.MetricTypeDeploy
+	default:
+		return nil, status.Error(codes.InvalidArgument, "metric_type must be one of: commit, pull_request, deploy")
+	}
+
+	data, err := s.aggregator.GetTimeSeriesMetrics(ctx, req.GetOwner(), metricType, toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &metricsv1.TimeSeries{
+		Type:        string(data.Type),
+		Granularity: data.Granularity,
+	}
+	for _, p := range data.DataPoints {
+		resp.DataPoints = append(resp.DataPoints, &metricsv1.TimeSeriesPoint{
+			Timestamp: timestamppb.New(p.Timestamp),
+			Value:     p.Value,
+		})
+	}
+	return resp, nil
+}
+
+// GetOrgTimeSeries returns a detailed time series for an owner
+func (s *metricsService) GetOrgTimeSeries(ctx context.Context, req *metricsv1.OwnerRequest) (*metricsv1.DetailedTimeSeries, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	data, err := s.aggregator.GetOrgTimeSeries(ctx, req.GetOwner(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toDetailedTimeSeries(data), nil
+}
+
+// GetRepoTimeSeries returns a detailed time series for a repository
+func (s *metricsService) GetRepoTimeSeries(ctx context.Context, req *metricsv1.RepoRequest) (*metricsv1.DetailedTimeSeries, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	data, err := s.aggregator.GetRepoTimeSeries(ctx, req.GetOwner(), req.GetRepo(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toDetailedTimeSeries(data), nil
+}
+
+// GetMemberTimeSeries returns a detailed time series for a member
+func (s *metricsService) GetMemberTimeSeries(ctx context.Context, req *metricsv1.MemberRequest) (*metricsv1.DetailedTimeSeries, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	data, err := s.aggregator.GetMemberTimeSeries(ctx, req.GetOwner(), req.GetMember(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toDetailedTimeSeries(data), nil
+}
+
+// GetMemberRanking returns members ranked by a metric
+func (s *metricsService) GetMemberRanking(ctx context.Context, req *metricsv1.RankingRequest) (*metricsv1.MemberRankingResponse, error) {
+	rankingType, limit, err := parseRankingRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	rankings, err := s.aggregator.GetMemberRanking(ctx, req.GetOwner(), rankingType, toTimeRange(req.GetTimeRange()), limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &metricsv1.MemberRankingResponse{}
+	for _, r := range rankings {
+		resp.Rankings = append(resp.Rankings, &metricsv1.MemberRanking{
+			Rank:      int32(r.Rank),
+			Member:    r.Member,
+			Value:     r.Value,
+			Commits:   r.Commits,
+			Prs:       r.PRs,
+			Additions: r.Additions,
+			Deletions: r.Deletions,
+			Deploys:   r.Deploys,
+		})
+	}
+	return resp, nil
+}
+
+// GetRepoRanking returns repositories ranked by a metric
+func (s *metricsService) GetRepoRanking(ctx context.Context, req *metricsv1.RankingRequest) (*metricsv1.RepoRankingResponse, error) {
+	rankingType, limit, err := parseRankingRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	rankings, err := s.aggregator.GetRepoRanking(ctx, req.GetOwner(), rankingType, toTimeRange(req.GetTimeRange()), limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &metricsv1.RepoRankingResponse{}
+	for _, r := range rankings {
+		resp.Rankings = append(resp.Rankings, &metricsv1.RepoRanking{
+			Rank:    int32(r.Rank),
+			Repo:    r.Repo,
+			Value:   r.Value,
+			Commits: r.Commits,
+			Prs:     r.PRs,
+			Deploys: r.Deploys,
+		})
+	}
+	return resp, nil
+}
+
+// GetRepoCommitTypes returns conventional-commit type counts per repository
+func (s *metricsService) GetRepoCommitTypes(ctx context.Context, req *metricsv1.OwnerRequest) (*metricsv1.CommitTypesResponse, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	items, err := s.aggregator.GetRepoCommitClassification(ctx, req.GetOwner(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCommitTypes(items), nil
+}
+
+// GetMemberCommitTypes returns conventional-commit type counts per member
+func (s *metricsService) GetMemberCommitTypes(ctx context.Context, req *metricsv1.OwnerRequest) (*metricsv1.CommitTypesResponse, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return nil, err
+	}
+	items, err := s.aggregator.GetMemberCommitClassification(ctx, req.GetOwner(), toTimeRange(req.GetTimeRange()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCommitTypes(items), nil
+}
+
+// requireOwner rejects requests without an owner
+func requireOwner(owner string) error {
+	if owner == "" {
+		return status.Error(codes.InvalidArgument, "owner is required")
+	}
+	return nil
+}
+
+// parseRankingRequest validates a ranking request and applies the default limit
+func parseRankingRequest(req *metricsv1.RankingRequest) (domain.RankingType, int, error) {
+	if err := requireOwner(req.GetOwner()); err != nil {
+		return "", 0, err
+	}
+
+	var rankingType domain.RankingType
+	switch req.GetType() {
+	case "commits":
+		rankingType = domain.RankingTypeCommits
+	case "prs":
+		rankingType = domain.RankingTypePRs
+	case "code-changes":
+		rankingType = domain.RankingTypeCodeChanges
+	case "deploys":
+		rankingType = domain.RankingTypeDeploys
+	default:
+		return "", 0, status.Error(codes.InvalidArgument, "ranking type must be one of: commits, prs, code-changes, deploys")
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultRankingLimit
+	}
+	return rankingType, limit, nil
+}
+
+// toTimeRange converts a request time range, applying the same defaults as the HTTP API
+// (last 30 days, daily granularity)
+func toTimeRange(tr *metricsv1.TimeRange) domain.TimeRange {
+	now := time.Now()
+	timeRange := domain.TimeRange{
+		Start:       now.AddDate(0, -1, 0),
+		End:         now,
+		Granularity: "day",
+	}
+	if tr == nil {
+		return timeRange
+	}
+
+	if tr.GetStart() != nil {
+		timeRange.Start = tr.GetStart().AsTime()
+	}
+	if tr.GetEnd() != nil {
+		timeRange.End = tr.GetEnd().AsTime()
+	}
+	switch tr.GetGranularity() {
+	case "day", "week", "month":
+		timeRange.Granularity = tr.GetGranularity()
+	}
+	return timeRange
+}
+
+// fromTimeRange converts a domain time range to its protobuf form
+func fromTimeRange(tr domain.TimeRange) *metricsv1.TimeRange {
+	return &metricsv1.TimeRange{
+		Start:       timestamppb.New(tr.Start),
+		End:         timestamppb.New(tr.End),
+		Granularity: tr.Granularity,
+	}
+}
+
+func toOrgMetrics(m *domain.OrgMetrics) *metricsv1.OrgMetrics {
+	return &metricsv1.OrgMetrics{
+		Org:          m.Org,
+		TotalRepos:   int32(m.TotalRepos),
+		TotalMembers: int32(m.TotalMembers),
+		Commits:      m.Commits,
+		Prs:          m.PRs,
+		Additions:    m.Additions,
+		Deletions:    m.Deletions,
+		Deploys:      m.Deploys,
+		TimeRange:    fromTimeRange(m.TimeRange),
+	}
+}
+
+func toMemberMetrics(m *domain.MemberMetrics) *metricsv1.MemberMetrics {
+	return &metricsv1.MemberMetrics{
+		Member:    m.Member,
+		Commits:   m.Commits,
+		Prs:       m.PRs,
+		Additions: m.Additions,
+		Deletions: m.Deletions,
+		Deploys:   m.Deploys,
+		TimeRange: fromTimeRange(m.TimeRange),
+	}
+}
+
+func toRepoMetrics(m *domain.RepoMetrics) *metricsv1.RepoMetrics {
+	return &metricsv1.RepoMetrics{
+		Repo:      m.Repo,
+		Commits:   m.Commits,
+		Prs:       m.PRs,
+		Additions: m.Additions,
+		Deletions: m.Deletions,
+		Deploys:   m.Deploys,
+		TimeRange: fromTimeRange(m.TimeRange),
+	}
+}
+
+func toDetailedTimeSeries(data *domain.DetailedTimeSeriesData) *metricsv1.DetailedTimeSeries {
+	resp := &metricsv1.DetailedTimeSeries{Granularity: data.Granularity}
+	for _, p := range data.DataPoints {
+		resp.DataPoints = append(resp.DataPoints, &metricsv1.DetailedTimeSeriesPoint{
+			Timestamp: timestamppb.New(p.Timestamp),
+			Commits:   p.Commits,
+			Prs:       p.PRs,
+			Additions: p.Additions,
+			Deletions: p.Deletions,
+			Deploys:   p.Deploys,
+		})
+	}
+	return resp
+}
+
+func toCommitTypes(items []*domain.CommitClassification) *metricsv1.CommitTypesResponse {
+	resp := &metricsv1.CommitTypesResponse{}
+	for _, item := range items {
+		counts := make(map[string]int64, len(item.Counts))
+		for category, n := range item.Counts {
+			counts[string(category)] = n
+		}
+		resp.Items = append(resp.Items, &metricsv1.CommitClassification{
+			Name:   item.Name,
+			Total:  item.Total,
+			Counts: counts,
+		})
+	}
+	return resp
+}
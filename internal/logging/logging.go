@@ -0,0 +1,38 @@
+// Package logging builds the structured loggers used by the commands
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New creates a logger writing to w. format is "json" or "text"; level is one of
+// "debug", "info", "warn" or "error" (unknown values fall back to "info").
+func New(w io.Writer, format, level string) *slog.Logger {
+	return NewLeveled(w, format, ParseLevel(level))
+}
+
+// NewLeveled creates a logger like New with a level that may change afterwards, such as
+// a *slog.LevelVar
+func NewLeveled(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// ParseLevel converts a level name to a slog.Level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
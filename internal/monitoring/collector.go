@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// instrumentedTransport counts the requests made through it in GitHubRequests
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+// InstrumentTransport wraps the transport of a GitHub API client so that its requests are
+// counted in GitHubRequests. A nil transport wraps http.DefaultTransport.
+func InstrumentTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{next: next}
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	GitHubRequests.WithLabelValues(status).Inc()
+	return resp, err
+}
+
+// ObserveRepoCollected records a repository of owner collected with events
+func ObserveRepoCollected(owner string, events []*domain.Event) {
+	CollectorRepos.WithLabelValues(owner, "completed").Inc()
+	counts := make(map[domain.EventType]int)
+	for _, e := range events {
+		counts[e.Type]++
+	}
+	for eventType, n := range counts {
+		CollectorEvents.WithLabelValues(owner, string(eventType)).Add(float64(n))
+	}
+}
+
+// ObserveRepoFailed records a repository of owner that failed to collect
+func ObserveRepoFailed(owner, repo string) {
+	CollectorRepos.WithLabelValues(owner, "failed").Inc()
+	CollectorRepoErrors.WithLabelValues(owner, repo).Inc()
+}
@@ -0,0 +1,121 @@
+// Package monitoring exposes operational Prometheus metrics for the API server and collector
+package monitoring
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "github_metrics"
+
+// Registry holds all metrics exported by this package
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts API requests by method, route template and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes API request latencies by method and route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latencies in seconds, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// StorageQueryDuration observes storage query latencies by operation
+	StorageQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "query_duration_seconds",
+		Help:      "Storage query latencies in seconds, by operation.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"operation"})
+
+	// StorageQueryErrors counts failed storage queries by operation
+	StorageQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "query_errors_total",
+		Help:      "Number of failed storage queries, by operation.",
+	}, []string{"operation"})
+
+	// GitHubRateLimitRemaining is the remaining GitHub API quota last reported to the collector
+	GitHubRateLimitRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "github_rate_limit_remaining",
+		Help:      "Remaining GitHub API requests in the current rate limit window.",
+	})
+
+	// GitHubRateLimitReset is the time the GitHub API quota resets
+	GitHubRateLimitReset = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "github_rate_limit_reset_timestamp_seconds",
+		Help:      "Unix time at which the GitHub API rate limit window resets.",
+	})
+
+	// GitHubRequests counts the collector's GitHub API requests by status code
+	GitHubRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "github_requests_total",
+		Help:      "Number of GitHub API requests made, by status code (\"error\" when no response was received).",
+	}, []string{"status"})
+
+	// CollectorRepos counts the repositories collected by owner and result
+	CollectorRepos = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "repos_processed_total",
+		Help:      "Number of repositories processed, by owner and result (completed or failed).",
+	}, []string{"owner", "result"})
+
+	// CollectorEvents counts the events collected by owner and event type
+	CollectorEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "events_collected_total",
+		Help:      "Number of events collected and saved, by owner and event type.",
+	}, []string{"owner", "type"})
+
+	// CollectorRepoErrors counts the repositories that failed to collect by owner and repository
+	CollectorRepoErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "repo_errors_total",
+		Help:      "Number of failed repository collections, by owner and repository.",
+	}, []string{"owner", "repo"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		StorageQueryDuration,
+		StorageQueryErrors,
+		GitHubRateLimitRemaining,
+		GitHubRateLimitReset,
+		GitHubRequests,
+		CollectorRepos,
+		CollectorEvents,
+		CollectorRepoErrors,
+	)
+}
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,108 @@
+package monitoring
+
+import (
+	"context"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// instrumentedStorage records query durations for the read paths used by the API.
+// Methods that are not overridden are passed through to the wrapped storage untimed.
+type instrumentedStorage struct {
+	storage.Storage
+}
+
+// InstrumentStorage wraps a storage so that its metric queries are observed in StorageQueryDuration
+func InstrumentStorage(s storage.Storage) storage.Storage {
+	return &instrumentedStorage{Storage: s}
+}
+
+// observe records the duration and outcome of a storage operation started at start
+func observe(operation string, start time.Time, err error) {
+	StorageQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		StorageQueryErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (s *instrumentedStorage) GetMetricsByOrg(ctx context.Context, org string, timeRange domain.TimeRange) (m *domain.OrgMetrics, err error) {
+	defer func(start time.Time) { observe("GetMetricsByOrg", start, err) }(time.Now())
+	return s.Storage.GetMetricsByOrg(ctx, org, timeRange)
+}
+
+func (s *instrumentedStorage) GetMetricsByMember(ctx context.Context, org, member string, timeRange domain.TimeRange) (m *domain.MemberMetrics, err error) {
+	defer func(start time.Time) { observe("GetMetricsByMember", start, err) }(time.Now())
+	return s.Storage.GetMetricsByMember(ctx, org, member, timeRange)
+}
+
+func (s *instrumentedStorage) GetMetricsByRepo(ctx context.Context, org, repo string, timeRange domain.TimeRange) (m *domain.RepoMetrics, err error) {
+	defer func(start time.Time) { observe("GetMetricsByRepo", start, err) }(time.Now())
+	return s.Storage.GetMetricsByRepo(ctx, org, repo, timeRange)
+}
+
+func (s *instrumentedStorage) GetEvents(ctx context.Context, org string, eventType domain.EventType, timeRange domain.TimeRange) (e []*domain.Event, err error) {
+	defer func(start time.Time) { observe("GetEvents", start, err) }(time.Now())
+	return s.Storage.GetEvents(ctx, org, eventType, timeRange)
+}
+
+func (s *instrumentedStorage) StreamEvents(ctx context.Context, org string, eventType domain.EventType, timeRange domain.TimeRange, fn func(*domain.Event) error) (err error) {
+	defer func(start time.Time) { observe("StreamEvents", start, err) }(time.Now())
+	return s.Storage.StreamEvents(ctx, org, eventType, timeRange, fn)
+}
+
+func (s *instrumentedStorage) GetLatestEventTime(ctx context.Context, org string) (t time.Time, err error) {
+	defer func(start time.Time) { observe("GetLatestEventTime", start, err) }(time.Now())
+	return s.Storage.GetLatestEventTime(ctx, org)
+}
+
+func (s *instrumentedStorage) GetMergeCommits(ctx context.Context, org string, timeRange domain.TimeRange) (c []*domain.MergeCommit, err error) {
+	defer func(start time.Time) { observe("GetMergeCommits", start, err) }(time.Now())
+	return s.Storage.GetMergeCommits(ctx, org, timeRange)
+}
+
+func (s *instrumentedStorage) GetMembersWithMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (m []*domain.MemberMetrics, err error) {
+	defer func(start time.Time) { observe("GetMembersWithMetrics", start, err) }(time.Now())
+	return s.Storage.GetMembersWithMetrics(ctx, org, timeRange)
+}
+
+func (s *instrumentedStorage) GetRepoMembersWithMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (m []*domain.MemberMetrics, err error) {
+	defer func(start time.Time) { observe("GetRepoMembersWithMetrics", start, err) }(time.Now())
+	return s.Storage.GetRepoMembersWithMetrics(ctx, org, repo, timeRange)
+}
+
+func (s *instrumentedStorage) GetReposWithMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (m []*domain.RepoMetrics, err error) {
+	defer func(start time.Time) { observe("GetReposWithMetrics", start, err) }(time.Now())
+	return s.Storage.GetReposWithMetrics(ctx, org, timeRange)
+}
+
+func (s *instrumentedStorage) GetMemberReposWithMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) (m []*domain.RepoMetrics, err error) {
+	defer func(start time.Time) { observe("GetMemberReposWithMetrics", start, err) }(time.Now())
+	return s.Storage.GetMemberReposWithMetrics(ctx, org, member, timeRange)
+}
+
+func (s *instrumentedStorage) GetMemberRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) (r []*domain.MemberRanking, err error) {
+	defer func(start time.Time) { observe("GetMemberRanking", start, err) }(time.Now())
+	return s.Storage.GetMemberRanking(ctx, org, rankingType, timeRange, limit)
+}
+
+func (s *instrumentedStorage) GetRepoRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) (r []*domain.RepoRanking, err error) {
+	defer func(start time.Time) { observe("GetRepoRanking", start, err) }(time.Now())
+	return s.Storage.GetRepoRanking(ctx, org, rankingType, timeRange, limit)
+}
+
+func (s *instrumentedStorage) GetOrgTimeSeries(ctx context.Context, org string, timeRange domain.TimeRange) (d *domain.DetailedTimeSeriesData, err error) {
+	defer func(start time.Time) { observe("GetOrgTimeSeries", start, err) }(time.Now())
+	return s.Storage.GetOrgTimeSeries(ctx, org, timeRange)
+}
+
+func (s *instrumentedStorage) GetRepoTimeSeries(ctx context.Context, org, repo string, timeRange domain.TimeRange) (d *domain.DetailedTimeSeriesData, err error) {
+	defer func(start time.Time) { observe("GetRepoTimeSeries", start, err) }(time.Now())
+	return s.Storage.GetRepoTimeSeries(ctx, org, repo, timeRange)
+}
+
+func (s *instrumentedStorage) GetMemberTimeSeries(ctx context.Context, org, member string, timeRange domain.TimeRange) (d *domain.DetailedTimeSeriesData, err error) {
+	defer func(start time.Time) { observe("GetMemberTimeSeries", start, err) }(time.Now())
+	return s.Storage.GetMemberTimeSeries(ctx, org, member, timeRange)
+}
@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds an email delivery when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig is the mail server emails are sent through
+type SMTPConfig struct {
+	Host     string
+	Port     int // 465 uses implicit TLS; other ports upgrade with STARTTLS when offered
+	Username string
+	Password string // with Username, authenticates with PLAIN
+	From     string // sender address, optionally with a name: "Metrics <metrics@example.com>"
+}
+
+// Email sends emails through an SMTP server
+type Email struct {
+	cfg  SMTPConfig
+	from *mail.Address
+}
+
+// Mail is an email with a plain text body and an optional HTML alternative
+type Mail struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// NewEmail returns a sender using the server of cfg
+func NewEmail(cfg SMTPConfig) (*Email, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("no SMTP server: set SMTP_HOST")
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("invalid SMTP port %d", cfg.Port)
+	}
+	if cfg.From == "" {
+		return nil, errors.New("no sender address: set SMTP_FROM")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+	return &Email{cfg: cfg, from: from}, nil
+}
+
+// ParseRecipients parses a list of email addresses
+func ParseRecipients(addresses []string) ([]string, error) {
+	to := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", a, err)
+		}
+		to = append(to, addr.Address)
+	}
+	if len(to) == 0 {
+		return nil, errors.New("no email recipients")
+	}
+	return to, nil
+}
+
+// Send delivers m to its recipients
+func (e *Email) Send(ctx context.Context, m *Mail) error {
+	msg, err := e.message(m)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: e.cfg.Host}
+	var conn net.Conn
+	if e.cfg.Port == 465 {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to the SMTP server %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to the SMTP server %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if e.cfg.Port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("failed to start TLS with the SMTP server: %w", err)
+			}
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with the SMTP server: %w", err)
+		}
+	}
+	if err := c.Mail(e.from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected the sender: %w", err)
+	}
+	for _, to := range m.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected the recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return c.Quit()
+}
+
+// message formats m as a MIME message, multipart/alternative when it has an HTML body
+func (e *Email) message(m *Mail) ([]byte, error) {
+	if len(m.To) == 0 {
+		return nil, errors.New("no email recipients")
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if m.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&b, m.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	var random [12]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	boundary := "github-metrics-" + hex.EncodeToString(random[:])
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		fmt.Fprintf(&b, "\r\n--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", part.contentType)
+		if err := writeQuotedPrintable(&b, part.body); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(&b, "\r\n--%s--\r\n", boundary)
+	return b.Bytes(), nil
+}
+
+// writeQuotedPrintable writes body to b in the quoted-printable encoding
+func writeQuotedPrintable(b *bytes.Buffer, body string) error {
+	w := quotedprintable.NewWriter(b)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	return w.Close()
+}
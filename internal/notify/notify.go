@@ -0,0 +1,24 @@
+// Package notify delivers the outcome of finished runs, such as collections and reports, to
+// the channels where teams read them: Slack summaries and emailed reports
+package notify
+
+// Summary is the outcome of a run
+type Summary struct {
+	Title    string    // e.g. "Collection finished: my-org"
+	Failed   bool      // the run, or part of it, failed
+	Fields   []Field   // totals, shown side by side
+	Sections []Section // titled lists such as failures and top movers
+	Footer   string    // e.g. the time range covered
+}
+
+// Field is a labeled value of a summary
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Section is a titled list of lines of a summary
+type Section struct {
+	Title string
+	Lines []string
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// slackMaxFields is the most fields a Slack section block holds
+const slackMaxFields = 10
+
+// Slack posts summaries to a Slack channel through an incoming webhook
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlack returns a notifier posting to the incoming webhook at webhookURL
+func NewSlack(webhookURL string) (*Slack, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Slack webhook URL %q: must be an http(s) URL such as https://hooks.slack.com/services/...", webhookURL)
+	}
+	return &Slack{webhookURL: webhookURL, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+// SlackMessage is a Block Kit message: the payload of an incoming webhook or the response to
+// a slash command. Text is the fallback shown in notifications; the blocks are the message
+// itself.
+type SlackMessage struct {
+	ResponseType string       `json:"response_type,omitempty"` // of slash command responses: "in_channel" or "ephemeral"
+	Text         string       `json:"text"`
+	Blocks       []slackBlock `json:"blocks,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"` // "plain_text" or "mrkdwn"
+	Text string `json:"text"`
+}
+
+// Send posts summary to the channel
+func (s *Slack) Send(ctx context.Context, summary *Summary) error {
+	body, err := json.Marshal(SlackPayload(summary))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Slack explains rejected messages in a short plain text body, e.g. "invalid_blocks"
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to post to Slack: %s: %s", resp.Status, strings.TrimSpace(string(reason)))
+	}
+	return nil
+}
+
+// SlackPayload lays out a summary as Block Kit blocks
+func SlackPayload(summary *Summary) *SlackMessage {
+	title := summary.Title
+	if summary.Failed {
+		title = ":warning: " + title
+	}
+	msg := &SlackMessage{
+		Text:   title,
+		Blocks: []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: title}}},
+	}
+
+	for i := 0; i < len(summary.Fields); i += slackMaxFields {
+		block := slackBlock{Type: "section"}
+		for _, f := range summary.Fields[i:min(i+slackMaxFields, len(summary.Fields))] {
+			block.Fields = append(block.Fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", escapeSlack(f.Name), escapeSlack(f.Value))})
+		}
+		msg.Blocks = append(msg.Blocks, block)
+	}
+	for _, section := range summary.Sections {
+		if len(section.Lines) == 0 {
+			continue
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%s*", escapeSlack(section.Title))
+		for _, line := range section.Lines {
+			b.WriteString("\n• " + escapeSlack(line))
+		}
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: b.String()}})
+	}
+	if summary.Footer != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: escapeSlack(summary.Footer)}}})
+	}
+	return msg
+}
+
+// escapeSlack escapes the characters Slack's mrkdwn treats as control characters
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
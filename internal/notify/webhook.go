@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events delivered by outbound webhooks, in the X-Metrics-Event header and the event field
+const (
+	WebhookEventCollectionCompleted = "collection.completed"
+	WebhookEventThreshold           = "metric.threshold"
+	WebhookEventAnomaly             = "metric.anomaly"
+)
+
+// WebhookDelivery is the JSON body of an outbound webhook delivery
+type WebhookDelivery struct {
+	ID     string    `json:"id"` // also in the X-Metrics-Delivery header
+	Event  string    `json:"event"`
+	Owner  string    `json:"owner"`
+	SentAt time.Time `json:"sent_at"`
+	Data   any       `json:"data"`
+}
+
+// Webhook POSTs metric deliveries to the URLs of external systems, signed so that they can
+// verify where the deliveries come from
+type Webhook struct {
+	urls   []string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook returns a notifier posting to urls. With a secret each delivery carries an
+// X-Metrics-Signature-256 header (see Sign).
+func NewWebhook(urls []string, secret string) (*Webhook, error) {
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL %q: must be an http(s) URL", u)
+		}
+	}
+	return &Webhook{urls: urls, secret: []byte(secret), client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+// Sign returns the X-Metrics-Signature-256 of a delivery: "sha256=" and the hex HMAC-SHA256,
+// keyed with the secret, of "<X-Metrics-Timestamp>.<body>". Receivers compute the same and
+// reject deliveries whose timestamp is too old to be a retry.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send delivers an event about owner to every URL. Every URL is tried; the error joins those
+// of the URLs that didn't accept the delivery with a 2xx status.
+func (w *Webhook) Send(ctx context.Context, event, owner string, data any) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	delivery := &WebhookDelivery{
+		ID:     hex.EncodeToString(id),
+		Event:  event,
+		Owner:  owner,
+		SentAt: time.Now().UTC(),
+		Data:   data,
+	}
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(delivery.SentAt.Unix(), 10)
+
+	var errs []error
+	for _, u := range w.urls {
+		if err := w.post(ctx, u, delivery, timestamp, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *Webhook) post(ctx context.Context, u string, delivery *WebhookDelivery, timestamp string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "github-metrics-webhook")
+	req.Header.Set("X-Metrics-Event", delivery.Event)
+	req.Header.Set("X-Metrics-Delivery", delivery.ID)
+	req.Header.Set("X-Metrics-Timestamp", timestamp)
+	if len(w.secret) > 0 {
+		req.Header.Set("X-Metrics-Signature-256", Sign(w.secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %s to %s: %w", delivery.Event, req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to post %s to %s: %s: %s", delivery.Event, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(reason)))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/api"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/logging"
+)
+
+// reloader applies the settings that can change without restarting the server: log level,
+// CORS origins, rate limits, environment API keys and the viewer token secret. Other settings
+// need a restart.
+type reloader struct {
+	mu            sync.Mutex
+	cfg           *config.Config
+	logger        *slog.Logger
+	level         *slog.LevelVar
+	cors          *api.CORSOrigins
+	limiter       *api.RateLimiter
+	authenticator *api.Authenticator // nil when authentication is disabled
+}
+
+// Reload re-reads the configuration and applies it, returning the names of the settings
+// that changed. The current settings are kept if the new configuration is invalid.
+func (r *reloader) Reload(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	var keys []*domain.APIKey
+	if r.authenticator != nil {
+		if keys, err = buildAPIKeys(cfg.APIKeys); err != nil {
+			return nil, err
+		}
+	}
+
+	// details describes the changes in the log: old and new values, except for secrets
+	var changed []string
+	var details []any
+	if cfg.LogLevel != r.cfg.LogLevel {
+		r.level.Set(logging.ParseLevel(cfg.LogLevel))
+		changed = append(changed, "LOG_LEVEL")
+		details = append(details, slog.Group("log_level", "from", r.cfg.LogLevel, "to", cfg.LogLevel))
+	}
+	if !slices.Equal(cfg.CORSOrigins, r.cfg.CORSOrigins) {
+		r.cors.Set(cfg.CORSOrigins)
+		changed = append(changed, "CORS_ORIGINS")
+		details = append(details, slog.Group("cors_origins", "from", r.cfg.CORSOrigins, "to", cfg.CORSOrigins))
+	}
+	if cfg.RateLimitRPS != r.cfg.RateLimitRPS || cfg.RateLimitBurst != r.cfg.RateLimitBurst {
+		r.limiter.SetLimits(cfg.RateLimitRPS, cfg.RateLimitBurst)
+		changed = append(changed, "RATE_LIMIT_RPS/RATE_LIMIT_BURST")
+		details = append(details,
+			slog.Group("rate_limit_rps", "from", r.cfg.RateLimitRPS, "to", cfg.RateLimitRPS),
+			slog.Group("rate_limit_burst", "from", r.cfg.RateLimitBurst, "to", cfg.RateLimitBurst))
+	}
+	if r.authenticator != nil && !slices.EqualFunc(cfg.APIKeys, r.cfg.APIKeys, equalAPIKeyConfig) {
+		r.authenticator.SetStaticKeys(keys)
+		changed = append(changed, "API_KEYS")
+		details = append(details, slog.Group("api_keys", "from", len(r.cfg.APIKeys), "to", len(cfg.APIKeys)))
+	}
+	if r.authenticator != nil && cfg.ViewerTokenSecret != r.cfg.ViewerTokenSecret {
+		r.authenticator.SetViewerTokenSecret(cfg.ViewerTokenSecret)
+		changed = append(changed, "VIEWER_TOKEN_SECRET")
+	}
+
+	// Only the applied settings are recorded; the others take effect on restart
+	applied := *r.cfg
+	applied.LogLevel = cfg.LogLevel
+	applied.CORSOrigins = cfg.CORSOrigins
+	applied.RateLimitRPS, applied.RateLimitBurst = cfg.RateLimitRPS, cfg.RateLimitBurst
+	applied.APIKeys = cfg.APIKeys
+	applied.ViewerTokenSecret = cfg.ViewerTokenSecret
+	r.cfg = &applied
+
+	r.logger.InfoContext(ctx, "configuration reloaded", append([]any{"changed", changed}, details...)...)
+	return changed, nil
+}
+
+// buildAPIKeys converts the API keys configured through the environment to static keys
+func buildAPIKeys(configured []config.APIKeyConfig) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	for i, k := range configured {
+		scope := domain.APIKeyScope(k.Scope)
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("invalid scope %q for API_KEYS entry %d: must be 'read' or 'admin'", k.Scope, i+1)
+		}
+		key := &domain.APIKey{
+			Name:    fmt.Sprintf("env-%d", i+1),
+			KeyHash: domain.HashAPIKey(k.Key),
+			Scope:   scope,
+		}
+		for _, owner := range k.Owners {
+			key.Grants = append(key.Grants, domain.OwnerGrant{KeyName: key.Name, Owner: owner, Role: domain.RoleViewer})
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// equalAPIKeyConfig reports whether two configured API keys are the same
+func equalAPIKeyConfig(a, b config.APIKeyConfig) bool {
+	return a.Key == b.Key && a.Scope == b.Scope && slices.Equal(a.Owners, b.Owners)
+}
@@ -0,0 +1,299 @@
+// Package server runs the HTTP and gRPC API servers
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/api"
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/grpcapi"
+	"github.com/kurihiro0119/github-activity-metrics/internal/logging"
+	"github.com/kurihiro0119/github-activity-metrics/internal/monitoring"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage/postgres"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage/sqlite"
+	"github.com/kurihiro0119/github-activity-metrics/internal/tracing"
+)
+
+// Run starts the API server with cfg and blocks until it fails or is stopped with SIGINT or
+// SIGTERM, draining in-flight requests before returning. SIGHUP reloads the settings that can
+// change without a restart.
+func Run(cfg *config.Config) error {
+	// Structured logging; the standard log package is routed through it as well
+	level := new(slog.LevelVar)
+	level.Set(logging.ParseLevel(cfg.LogLevel))
+	logger := logging.NewLeveled(os.Stdout, cfg.LogFormat, level)
+	slog.SetDefault(logger)
+
+	// Initialize storage; with AUTO_MIGRATE=false the schema is left to "migrate up"
+	var store storage.Storage
+	var err error
+	var storageOpts []storage.OpenOption
+	if !cfg.AutoMigrate {
+		storageOpts = append(storageOpts, storage.WithoutMigrate())
+	}
+	switch cfg.StorageType {
+	case "postgres":
+		store, err = postgres.NewPostgresStorage(cfg.PostgresURL, storageOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize PostgreSQL storage: %w", err)
+		}
+	default:
+		store, err = sqlite.NewSQLiteStorage(cfg.SQLitePath, storageOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize SQLite storage: %w", err)
+		}
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("failed to close storage", "error", err)
+		}
+	}()
+	if !cfg.AutoMigrate {
+		if pending := pendingMigrations(context.Background(), store); pending > 0 {
+			logger.Warn("database schema is behind; run \"github-metrics migrate up\"", "pending_migrations", pending)
+		}
+	}
+
+	// Initialize aggregator
+	aggOpts := []aggregator.Option{
+		aggregator.WithCache(cfg.CacheSize, cfg.CacheTTL),
+		aggregator.WithStreamChunk(cfg.StreamChunk),
+	}
+	if cfg.DedupMergeCommits {
+		aggOpts = append(aggOpts, aggregator.WithMergeCommitDedup())
+	}
+	if cfg.ExcludeBots {
+		aggOpts = append(aggOpts, aggregator.WithBotExclusion(cfg.BotPatterns...))
+	}
+	var pseudonyms *aggregator.Pseudonyms
+	if cfg.PseudonymizeMembers {
+		pseudonyms = aggregator.NewPseudonyms(cfg.PseudonymSecret)
+		aggOpts = append(aggOpts, aggregator.WithPseudonyms(pseudonyms))
+	}
+	if len(cfg.RepoComponents) > 0 {
+		components := make([]domain.Component, 0, len(cfg.RepoComponents))
+		for _, c := range cfg.RepoComponents {
+			components = append(components, domain.Component{Owner: c.Owner, Repo: c.Repo, Name: c.Name, Paths: c.Paths})
+		}
+		aggOpts = append(aggOpts, aggregator.WithComponents(components))
+	}
+	agg := aggregator.NewAggregator(monitoring.InstrumentStorage(store), aggOpts...)
+
+	// OpenTelemetry tracing of requests, aggregations and database queries
+	if cfg.TracingEnabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+			ServiceName: cfg.TracingServiceName,
+			Protocol:    cfg.TracingProtocol,
+			SampleRatio: cfg.TracingSampleRatio,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Error("failed to flush traces", "error", err)
+			}
+		}()
+		agg = tracing.InstrumentAggregator(agg)
+	}
+
+	// Initialize handler
+	handler := api.NewHandler(agg, store)
+	handler.SetPseudonyms(pseudonyms)
+
+	// Setup routes
+	var routeOpts []api.RouteOption
+	var authenticator *api.Authenticator
+	if cfg.AuthEnabled {
+		keys, err := buildAPIKeys(cfg.APIKeys)
+		if err != nil {
+			return fmt.Errorf("invalid API_KEYS: %w", err)
+		}
+		authenticator = api.NewAuthenticator(keys, store)
+		authenticator.SetViewerTokenSecret(cfg.ViewerTokenSecret)
+		routeOpts = append(routeOpts, api.WithAuth(authenticator))
+	}
+	// The rate limiter is always installed so that a reload can enable it
+	limiter := api.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	corsOrigins := api.NewCORSOrigins(cfg.CORSOrigins)
+	rl := &reloader{
+		cfg:           cfg,
+		logger:        logger,
+		level:         level,
+		cors:          corsOrigins,
+		limiter:       limiter,
+		authenticator: authenticator,
+	}
+	routeOpts = append(routeOpts,
+		api.WithRateLimit(limiter),
+		api.WithCORSOrigins(corsOrigins),
+		api.WithTrustedProxies(cfg.TrustedProxies),
+		api.WithReload(rl.Reload),
+	)
+	if cfg.TracingEnabled {
+		routeOpts = append(routeOpts, api.WithTracing(cfg.TracingServiceName))
+	}
+	if cfg.AuditLogEnabled {
+		routeOpts = append(routeOpts, api.WithAuditLog(store))
+	}
+	if cfg.IdempotencyTTL > 0 {
+		routeOpts = append(routeOpts, api.WithIdempotency(api.NewIdempotencyStore(cfg.IdempotencyTTL)))
+	}
+	if cfg.APIGzip {
+		routeOpts = append(routeOpts, api.WithGzip())
+	}
+	liveCtx, stopLive := context.WithCancel(context.Background())
+	defer stopLive()
+	if cfg.LivePollInterval > 0 {
+		hub := api.NewLiveHub(agg, cfg.LivePollInterval, logger)
+		go hub.Run(liveCtx)
+		routeOpts = append(routeOpts, api.WithLiveUpdates(hub))
+	}
+	if cfg.GitHubWebhookSecret != "" {
+		routeOpts = append(routeOpts, api.WithGitHubWebhook(cfg.GitHubWebhookSecret))
+	}
+	if cfg.SlackSigningSecret != "" {
+		routeOpts = append(routeOpts, api.WithSlackCommands(api.SlackCommandConfig{
+			Secret:        cfg.SlackSigningSecret,
+			AllowedOwners: cfg.SlackAllowedOwners,
+			InChannel:     cfg.SlackInChannel,
+		}))
+	}
+	routeOpts = append(routeOpts, api.WithLogger(logger))
+	router := api.SetupRoutes(handler, routeOpts...)
+
+	// Start server
+	addr := fmt.Sprintf("%s:%s", cfg.APIHost, cfg.APIPort)
+	logger.Info("starting API server",
+		"addr", addr,
+		"storage", cfg.StorageType,
+		"auth", cfg.AuthEnabled,
+		"rate_limit_rps", cfg.RateLimitRPS,
+		"audit_log", cfg.AuditLogEnabled,
+		"tracing", cfg.TracingEnabled,
+	)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadTimeout:       cfg.APIReadTimeout,
+		ReadHeaderTimeout: cfg.APIReadTimeout,
+		WriteTimeout:      cfg.APIWriteTimeout,
+		IdleTimeout:       cfg.APIIdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	// Stop accepting connections on SIGINT/SIGTERM and drain in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Reload the configuration on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			if _, err := rl.Reload(context.Background()); err != nil {
+				logger.Error("failed to reload configuration", "error", err)
+			}
+		}
+	}()
+
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	// gRPC server on its own port, sharing the aggregator and API keys with the HTTP API
+	var grpcSrv *grpc.Server
+	if cfg.GRPCPort != "" {
+		grpcAddr := fmt.Sprintf("%s:%s", cfg.APIHost, cfg.GRPCPort)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC on %s: %w", grpcAddr, err)
+		}
+		grpcOpts := []grpcapi.Option{grpcapi.WithLogger(logger)}
+		if cfg.TracingEnabled {
+			grpcOpts = append(grpcOpts, grpcapi.WithTracing())
+		}
+		if authenticator != nil {
+			grpcOpts = append(grpcOpts, grpcapi.WithAuth(authenticator))
+		}
+		grpcOpts = append(grpcOpts, grpcapi.WithRateLimit(limiter))
+		if cfg.AuditLogEnabled {
+			grpcOpts = append(grpcOpts, grpcapi.WithAuditLog(store))
+		}
+		grpcSrv = grpcapi.NewServer(agg, grpcOpts...)
+		logger.Info("starting gRPC server", "addr", grpcAddr)
+		go func() {
+			serveErr <- grpcSrv.Serve(lis)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			if grpcSrv != nil {
+				grpcSrv.Stop()
+			}
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+	case <-ctx.Done():
+		stop()
+		logger.Info("shutting down API server", "timeout", cfg.APIShutdownTimeout.String())
+		// WebSocket connections are hijacked and not tracked by Shutdown; close them explicitly
+		stopLive()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.APIShutdownTimeout)
+		defer cancel()
+		if grpcSrv != nil {
+			go func() {
+				// GracefulStop has no deadline of its own; force it once the shutdown timeout expires
+				<-shutdownCtx.Done()
+				grpcSrv.Stop()
+			}()
+		}
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("graceful shutdown failed, closing remaining connections", "error", err)
+			_ = srv.Close()
+		}
+		if grpcSrv != nil {
+			grpcSrv.GracefulStop()
+		}
+	}
+
+	logger.Info("API server stopped")
+	return nil
+}
+
+// pendingMigrations returns the number of schema migrations not applied to store, or 0 when
+// it cannot be told
+func pendingMigrations(ctx context.Context, store storage.Storage) int {
+	status, err := store.MigrationStatus(ctx)
+	if err != nil {
+		return 0
+	}
+	pending := 0
+	for _, m := range status {
+		if !m.Applied() {
+			pending++
+		}
+	}
+	return pending
+}
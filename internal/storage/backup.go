@@ -0,0 +1,304 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupFormat identifies a backup written by Storage.Backup
+const BackupFormat = "github-metrics-backup"
+
+// BackupFormatVersion is the version of the backup format; readers reject newer ones
+const BackupFormatVersion = 1
+
+// BackupHeader describes a backup. It is the first line of the backup.
+type BackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int       `json:"schema_version"` // version of the newest migration applied to the source
+	Storage       string    `json:"storage"`        // "sqlite" or "postgres"
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// BackupSummary is what a backup holds, or what a restore loaded
+type BackupSummary struct {
+	BackupHeader
+	Rows map[string]int64 // rows by table
+}
+
+// TotalRows returns the number of rows of every table
+func (s *BackupSummary) TotalRows() int64 {
+	var total int64
+	for _, n := range s.Rows {
+		total += n
+	}
+	return total
+}
+
+// ColumnKind is how the values of a column are encoded in a backup
+type ColumnKind int
+
+const (
+	ColumnText ColumnKind = iota
+	ColumnInt
+	ColumnBool
+	ColumnTime // RFC 3339 in UTC
+)
+
+// BackupColumn is a column of a backed up table
+type BackupColumn struct {
+	Name    string
+	Kind    ColumnKind
+	Default any // value for backups made before the column was added; nil if it always existed
+}
+
+// BackupTable is a table copied by backups, with its columns in backup order
+type BackupTable struct {
+	Name    string
+	Columns []BackupColumn
+}
+
+// ColumnList returns the table's column names separated by commas, for SELECT and INSERT
+func (t *BackupTable) ColumnList() string {
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// text, integer, boolean and stamp declare the columns of BackupTables
+func text(name string) BackupColumn    { return BackupColumn{Name: name, Kind: ColumnText} }
+func integer(name string) BackupColumn { return BackupColumn{Name: name, Kind: ColumnInt} }
+func boolean(name string) BackupColumn { return BackupColumn{Name: name, Kind: ColumnBool} }
+func stamp(name string) BackupColumn   { return BackupColumn{Name: name, Kind: ColumnTime} }
+
+// added declares a column added to a table after backups of it were made, which restores
+// rows of older backups with value
+func added(c BackupColumn, value any) BackupColumn {
+	c.Default = value
+	return c
+}
+
+// BackupTables are the tables copied by backups, parents before the rows referring to them.
+// Both adapters have the same columns, so a backup of one can be restored into the other.
+var BackupTables = []*BackupTable{
+	{Name: "events", Columns: []BackupColumn{
+		text("id"), text("type"), text("owner"), text("owner_type"), text("repo"), text("member"),
+		stamp("timestamp"), text("data"), stamp("created_at"),
+	}},
+	{Name: "repositories", Columns: []BackupColumn{
+		text("owner"), text("owner_type"), text("name"), text("full_name"), boolean("is_private"),
+		stamp("last_synced_at"), stamp("created_at"), stamp("updated_at"),
+	}},
+	{Name: "members", Columns: []BackupColumn{
+		text("owner"), text("owner_type"), text("username"), text("display_name"),
+		stamp("last_synced_at"), stamp("created_at"), stamp("updated_at"),
+	}},
+	{Name: "teams", Columns: []BackupColumn{
+		text("owner"), text("slug"), text("name"), text("description"),
+		stamp("last_synced_at"), stamp("created_at"), stamp("updated_at"),
+	}},
+	{Name: "team_members", Columns: []BackupColumn{
+		text("owner"), text("team_slug"), text("username"), added(text("role"), "member"),
+	}},
+	{Name: "collection_batches", Columns: []BackupColumn{
+		text("id"), text("mode"), text("owner"), stamp("start_date"), stamp("end_date"), text("status"),
+		stamp("created_at"), stamp("updated_at"),
+	}},
+	{Name: "collection_batch_repos", Columns: []BackupColumn{
+		text("batch_id"), text("repo"), text("status"), integer("events"), text("error"), stamp("updated_at"),
+	}},
+	{Name: "workspaces", Columns: []BackupColumn{
+		text("id"), text("name"), stamp("created_at"),
+	}},
+	{Name: "workspace_owners", Columns: []BackupColumn{
+		text("owner"), text("workspace_id"),
+	}},
+	{Name: "api_keys", Columns: []BackupColumn{
+		text("name"), text("key_hash"), text("scope"), text("workspace"), stamp("created_at"),
+	}},
+	{Name: "api_key_grants", Columns: []BackupColumn{
+		text("key_name"), text("owner"), text("role"),
+	}},
+	{Name: "audit_log", Columns: []BackupColumn{
+		integer("id"), stamp("time"), text("principal"), text("owner"), text("method"), text("route"),
+		text("path"), text("params"), integer("status"), text("client_ip"), text("request_id"),
+	}},
+	{Name: "member_aliases", Columns: []BackupColumn{
+		text("owner"), text("alias"), text("member"), stamp("created_at"),
+	}},
+	{Name: "deploy_definitions", Columns: []BackupColumn{
+		text("owner"), text("repo"), text("source"), text("pattern"), stamp("updated_at"),
+	}},
+}
+
+// backupRow is a line of a backup after the header
+type backupRow struct {
+	Table string `json:"table"`
+	Row   []any  `json:"row"`
+}
+
+// BackupWriter writes a backup: the header, then one JSON line per row
+type BackupWriter struct {
+	enc     *json.Encoder
+	summary *BackupSummary
+}
+
+// NewBackupWriter starts a backup described by header on w
+func NewBackupWriter(w io.Writer, header BackupHeader) (*BackupWriter, error) {
+	header.Format = BackupFormat
+	header.Version = BackupFormatVersion
+	if header.CreatedAt.IsZero() {
+		header.CreatedAt = time.Now().UTC()
+	}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(header); err != nil {
+		return nil, err
+	}
+	return &BackupWriter{enc: enc, summary: &BackupSummary{BackupHeader: header, Rows: make(map[string]int64)}}, nil
+}
+
+// WriteRow writes a row of table. values are as scanned from the database, in the order of
+// the table's columns; time columns must be time.Time (or nil).
+func (w *BackupWriter) WriteRow(table *BackupTable, values []any) error {
+	row := make([]any, len(values))
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		if v == nil {
+			continue
+		}
+		col := table.Columns[i]
+		switch col.Kind {
+		case ColumnTime:
+			t, ok := v.(time.Time)
+			if !ok {
+				return fmt.Errorf("%s.%s: %v is not a time", table.Name, col.Name, v)
+			}
+			v = t.UTC().Format(time.RFC3339Nano)
+		case ColumnBool:
+			if n, ok := v.(int64); ok {
+				v = n != 0
+			}
+		}
+		row[i] = v
+	}
+	if err := w.enc.Encode(backupRow{Table: table.Name, Row: row}); err != nil {
+		return err
+	}
+	w.summary.Rows[table.Name]++
+	return nil
+}
+
+// Summary returns what has been written so far
+func (w *BackupWriter) Summary() *BackupSummary {
+	return w.summary
+}
+
+// ReadBackup reads a backup from r, checking its header with check before calling fn for
+// each row with values converted to the column kinds: string, int64, bool, time.Time or nil
+func ReadBackup(r io.Reader, check func(BackupHeader) error, fn func(table *BackupTable, values []any) error) (*BackupSummary, error) {
+	tables := make(map[string]*BackupTable, len(BackupTables))
+	for _, t := range BackupTables {
+		tables[t.Name] = t
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	var header BackupHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("not a github-metrics backup: %w", err)
+	}
+	if header.Format != BackupFormat {
+		return nil, errors.New("not a github-metrics backup")
+	}
+	if header.Version > BackupFormatVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than this version of github-metrics supports (%d)", header.Version, BackupFormatVersion)
+	}
+	if err := check(header); err != nil {
+		return nil, err
+	}
+
+	summary := &BackupSummary{BackupHeader: header, Rows: make(map[string]int64)}
+	for line := 2; ; line++ {
+		var row backupRow
+		if err := dec.Decode(&row); err == io.EOF {
+			return summary, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("backup line %d: %w", line, err)
+		}
+		table, ok := tables[row.Table]
+		if !ok {
+			return nil, fmt.Errorf("backup line %d: unknown table %q", line, row.Table)
+		}
+		for len(row.Row) < len(table.Columns) && table.Columns[len(row.Row)].Default != nil {
+			// A backup made before the column was added
+			row.Row = append(row.Row, table.Columns[len(row.Row)].Default)
+		}
+		if len(row.Row) != len(table.Columns) {
+			return nil, fmt.Errorf("backup line %d: %s has %d columns, not %d", line, table.Name, len(table.Columns), len(row.Row))
+		}
+		values, err := decodeBackupRow(table, row.Row)
+		if err != nil {
+			return nil, fmt.Errorf("backup line %d: %w", line, err)
+		}
+		if err := fn(table, values); err != nil {
+			return nil, err
+		}
+		summary.Rows[table.Name]++
+	}
+}
+
+// decodeBackupRow converts the JSON values of a row to the kinds of its columns
+func decodeBackupRow(table *BackupTable, row []any) ([]any, error) {
+	values := make([]any, len(row))
+	for i, v := range row {
+		if v == nil {
+			continue
+		}
+		col := table.Columns[i]
+		var err error
+		switch col.Kind {
+		case ColumnText:
+			s, ok := v.(string)
+			if !ok {
+				err = errors.New("not a string")
+			}
+			values[i] = s
+		case ColumnInt:
+			var n int64
+			if num, ok := v.(json.Number); ok {
+				n, err = strconv.ParseInt(num.String(), 10, 64)
+			} else {
+				err = errors.New("not an integer")
+			}
+			values[i] = n
+		case ColumnBool:
+			b, ok := v.(bool)
+			if !ok {
+				err = errors.New("not a boolean")
+			}
+			values[i] = b
+		case ColumnTime:
+			var t time.Time
+			if s, ok := v.(string); ok {
+				t, err = time.Parse(time.RFC3339Nano, s)
+			} else {
+				err = errors.New("not a time")
+			}
+			values[i] = t
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v: %w", table.Name, col.Name, v, err)
+		}
+	}
+	return values, nil
+}
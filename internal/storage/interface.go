@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
 )
@@ -20,14 +22,70 @@ type Storage interface {
 	// Event retrieval (for re-aggregation)
 	GetEvents(ctx context.Context, org string, eventType domain.EventType, timeRange domain.TimeRange) ([]*domain.Event, error)
 
+	// StreamEvents calls fn for each event in timestamp order while reading rows, so large
+	// ranges can be aggregated without holding every event in memory. An error returned by
+	// fn stops the iteration and is returned.
+	StreamEvents(ctx context.Context, org string, eventType domain.EventType, timeRange domain.TimeRange, fn func(*domain.Event) error) error
+
+	// GetLatestEventTime returns the most recent created_at among the owner's events
+	// (zero time if none). It acts as a cheap change watermark for caches.
+	GetLatestEventTime(ctx context.Context, org string) (time.Time, error)
+
+	// GetDataVersion returns a counter that moves whenever the owner's data changes without a
+	// newer created_at: deletes, rewritten events, and changes of the teams, member aliases and
+	// deploy definitions metrics depend on. Watermarks combine it with GetLatestEventTime.
+	GetDataVersion(ctx context.Context, owner string) (int64, error)
+	// BumpDataVersion moves the owner's data version, or every owner's if owner is empty. The
+	// adapters move it themselves where they delete or change metadata; callers rewriting
+	// stored events with SaveRawEvents move it after.
+	BumpDataVersion(ctx context.Context, owner string) error
+
+	// GetMergeCommits returns the PR merge commits of an owner in a time range, oldest first:
+	// the commits classified as merge commits, and the squash commits that are the merge
+	// commit (merge_commit_sha) of the collected pull request their header refers to
+	GetMergeCommits(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.MergeCommit, error)
+
+	// Data management (admin maintenance); each returns the number of events deleted.
+	// PurgeEvents deletes events older than before, for one owner or all owners if owner is empty.
+	PurgeEvents(ctx context.Context, owner string, before time.Time) (int64, error)
+	// PurgeBatches deletes finished collection batches whose range ended before before, with
+	// their per-repository progress, and returns the number of batches deleted
+	PurgeBatches(ctx context.Context, owner string, before time.Time) (int64, error)
+	// CountPurgeable returns the number of events and batches PurgeEvents and PurgeBatches
+	// would delete
+	CountPurgeable(ctx context.Context, owner string, before time.Time) (events, batches int64, err error)
+	// DeleteRepoData deletes a repository's events and metadata
+	DeleteRepoData(ctx context.Context, owner, repo string) (int64, error)
+	// DeleteMemberData deletes a member's events and metadata within an owner
+	DeleteMemberData(ctx context.Context, owner, member string) (int64, error)
+
 	// Repository operations
+	// SaveRepository saves a repository; a nil LastSyncedAt keeps the stored sync time
 	SaveRepository(ctx context.Context, repo *domain.Repository) error
 	GetRepositories(ctx context.Context, org string) ([]*domain.Repository, error)
+	// ListOwners returns the organizations and users with repositories or events, sorted by name
+	ListOwners(ctx context.Context) ([]string, error)
+
+	// Repository list cache (see collector.WithRepoListCache)
+	// SaveRepositoryList stores a repository list, replacing the owner's previous one
+	SaveRepositoryList(ctx context.Context, list *domain.RepositoryList) error
+	// GetRepositoryList returns the stored repository list of an owner, or nil if there is none
+	GetRepositoryList(ctx context.Context, owner, ownerType string) (*domain.RepositoryList, error)
 
 	// Member operations
 	SaveMember(ctx context.Context, member *domain.Member) error
 	GetMembers(ctx context.Context, org string) ([]*domain.Member, error)
 
+	// Team operations
+	// SaveTeam saves a team and replaces its member list
+	SaveTeam(ctx context.Context, team *domain.Team) error
+	// GetTeams retrieves all teams of an organization with their members
+	GetTeams(ctx context.Context, org string) ([]*domain.Team, error)
+	// GetTeam retrieves a team with its members, or nil if there is none
+	GetTeam(ctx context.Context, org, slug string) (*domain.Team, error)
+	// GetMemberTeams retrieves the teams of an organization a member belongs to
+	GetMemberTeams(ctx context.Context, org, username string) ([]*domain.TeamMembership, error)
+
 	// List all members with metrics
 	GetMembersWithMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error)
 
@@ -37,6 +95,9 @@ type Storage interface {
 	// List all repos with metrics
 	GetReposWithMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error)
 
+	// List all repos a member was active in, with the member's metrics in each
+	GetMemberReposWithMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error)
+
 	// Rankings
 	GetMemberRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.MemberRanking, error)
 	GetRepoRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) ([]*domain.RepoRanking, error)
@@ -50,10 +111,136 @@ type Storage interface {
 	CreateOrGetBatch(ctx context.Context, batch *domain.CollectionBatch) (*domain.CollectionBatch, error)
 	GetBatch(ctx context.Context, batchID string) (*domain.CollectionBatch, error)
 	UpdateBatchStatus(ctx context.Context, batchID string, status string) error
+	ListBatches(ctx context.Context, filter domain.BatchFilter) ([]*domain.CollectionBatch, error)
+
+	// Per-repository batch progress
+	SaveBatchRepoStatus(ctx context.Context, status *domain.BatchRepoStatus) error
+	GetBatchRepoStatuses(ctx context.Context, batchID string) ([]*domain.BatchRepoStatus, error)
+
+	// API key management
+	SaveAPIKey(ctx context.Context, key *domain.APIKey) error
+	// GetAPIKeyByHash returns the key with the given hash, or nil if there is none
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	DeleteAPIKey(ctx context.Context, name string) error
+
+	// Per-owner access grants for API keys
+	SaveAPIKeyGrant(ctx context.Context, grant *domain.OwnerGrant) error
+	GetAPIKeyGrants(ctx context.Context, keyName string) ([]*domain.OwnerGrant, error)
+	DeleteAPIKeyGrant(ctx context.Context, keyName, owner string) error
+
+	// Workspaces (independent tenants of a hosted deployment)
+	SaveWorkspace(ctx context.Context, ws *domain.Workspace) error
+	// GetWorkspace returns the workspace with its owners, or nil if there is none
+	GetWorkspace(ctx context.Context, id string) (*domain.Workspace, error)
+	ListWorkspaces(ctx context.Context) ([]*domain.Workspace, error)
+	DeleteWorkspace(ctx context.Context, id string) error
+	// AddWorkspaceOwner assigns an owner to a workspace; an owner belongs to at most one workspace
+	AddWorkspaceOwner(ctx context.Context, id, owner string) error
+	RemoveWorkspaceOwner(ctx context.Context, id, owner string) error
+
+	// API access audit log
+	SaveAuditEntry(ctx context.Context, entry *domain.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+
+	// Member aliases (other names an owner's events are recorded under, counted as a member)
+	SaveMemberAlias(ctx context.Context, alias *domain.MemberAlias) error
+	ListMemberAliases(ctx context.Context, owner string) ([]*domain.MemberAlias, error)
+	DeleteMemberAlias(ctx context.Context, owner, alias string) error
+
+	// Deploy definitions (what counts as a deploy of a repository). Owners and repositories
+	// are matched case-insensitively. Changing what counts as a repository's deploys deletes
+	// its deploy events, returning how many, so that metrics only count the deploys of the
+	// current definition once the repository is collected again.
+	// SaveDeployDefinition sets a repository's deploy definition
+	SaveDeployDefinition(ctx context.Context, def *domain.DeployDefinition) (int64, error)
+	// GetDeployDefinition returns a repository's deploy definition, or nil if it has none
+	GetDeployDefinition(ctx context.Context, owner, repo string) (*domain.DeployDefinition, error)
+	// ListDeployDefinitions returns the deploy definitions of an owner's repositories, ordered by repository
+	ListDeployDefinitions(ctx context.Context, owner string) ([]*domain.DeployDefinition, error)
+	// DeleteDeployDefinition removes a repository's deploy definition, counting its GitHub
+	// deployments again
+	DeleteDeployDefinition(ctx context.Context, owner, repo string) (int64, error)
+
+	// Metric alerts (threshold breaches and anomalies in effect, delivered by outbound
+	// webhooks when they are triggered and resolved)
+	ListMetricAlerts(ctx context.Context, owner string) ([]*domain.MetricAlert, error)
+	SaveMetricAlert(ctx context.Context, alert *domain.MetricAlert) error
+	DeleteMetricAlert(ctx context.Context, owner, key string) error
 
 	// Migration
+	// Migrate applies the schema migrations that haven't been applied yet, oldest first
 	Migrate(ctx context.Context) error
+	// MigrateDown reverts the last steps applied schema migrations, newest first
+	MigrateDown(ctx context.Context, steps int) error
+	// MigrationStatus lists every schema migration, applied or not, by version
+	MigrationStatus(ctx context.Context) ([]*Migration, error)
+
+	// Backup
+	// Backup writes every row of the tables in BackupTables to w, as of one point in time
+	Backup(ctx context.Context, w io.Writer) (*BackupSummary, error)
+	// Restore replaces the rows of the tables in BackupTables with those of the backup read
+	// from r, all or nothing. The backup may come from either adapter.
+	Restore(ctx context.Context, r io.Reader) (*BackupSummary, error)
+
+	// MissingTables returns the tables of the current schema that don't exist in the database
+	MissingTables(ctx context.Context) ([]string, error)
+
+	// Stats returns the events stored for each owner and type, the size of the database and
+	// the sizes of its tables and indexes
+	Stats(ctx context.Context) (*Stats, error)
 
 	// Connection management
+	// Ping checks that the database can be reached
+	Ping(ctx context.Context) error
 	Close() error
 }
+
+// Migration is a versioned step of the database schema
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time // zero while the migration is pending
+}
+
+// Applied reports whether the migration has been applied to the database
+func (m *Migration) Applied() bool {
+	return !m.AppliedAt.IsZero()
+}
+
+// SchemaVersion returns the version of the newest applied migration in status, or 0 for none
+func SchemaVersion(status []*Migration) int {
+	version := 0
+	for _, m := range status {
+		if m.Applied() && m.Version > version {
+			version = m.Version
+		}
+	}
+	return version
+}
+
+// OpenOptions are the options of opening a storage
+type OpenOptions struct {
+	// SkipMigrate opens the database as it is instead of applying pending migrations
+	SkipMigrate bool
+}
+
+// OpenOption sets an option of opening a storage
+type OpenOption func(*OpenOptions)
+
+// WithoutMigrate leaves pending schema migrations to be applied explicitly, with
+// "github-metrics migrate up"
+func WithoutMigrate() OpenOption {
+	return func(o *OpenOptions) {
+		o.SkipMigrate = true
+	}
+}
+
+// NewOpenOptions returns the options set by opts
+func NewOpenOptions(opts ...OpenOption) OpenOptions {
+	var o OpenOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultQueryWorkers is the default number of per-entity queries adapters run concurrently
+// when building list metrics (members, repositories)
+const DefaultQueryWorkers = 8
+
+// ForEachParallel calls fn for every index in [0, n) using at most workers goroutines.
+// The first error cancels the context passed to the remaining calls and is returned.
+func ForEachParallel(ctx context.Context, n, workers int, fn func(ctx context.Context, i int) error) error {
+	if n == 0 {
+		return nil
+	}
+	if workers <= 0 {
+		workers = DefaultQueryWorkers
+	}
+	if workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
 	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
@@ -18,9 +21,11 @@ type postgresStorage struct {
 	db *sql.DB
 }
 
-// NewPostgresStorage creates a new PostgreSQL storage instance
-func NewPostgresStorage(connStr string) (storage.Storage, error) {
-	db, err := sql.Open("postgres", connStr)
+// NewPostgresStorage creates a new PostgreSQL storage instance. Pending schema migrations
+// are applied unless storage.WithoutMigrate is given.
+func NewPostgresStorage(connStr string, opts ...storage.OpenOption) (storage.Storage, error) {
+	// Queries are traced when OpenTelemetry tracing is enabled
+	db, err := otelsql.Open("postgres", connStr, otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL))
 	if err != nil {
 		return nil, err
 	}
@@ -31,99 +36,13 @@ func NewPostgresStorage(connStr string) (storage.Storage, error) {
 	}
 
 	s := &postgresStorage{db: db}
-	if err := s.Migrate(context.Background()); err != nil {
-		return nil, err
-	}
-
-	return s, nil
-}
-
-// Migrate runs database migrations
-func (s *postgresStorage) Migrate(ctx context.Context) error {
-	// Check if migration is needed (check if old 'org' column exists)
-	var columnExists bool
-	err := s.db.QueryRowContext(ctx, `
-		SELECT EXISTS (
-			SELECT 1 FROM information_schema.columns 
-			WHERE table_name = 'events' AND column_name = 'org'
-		)
-	`).Scan(&columnExists)
-
-	if err == nil && columnExists {
-		// Old schema exists, need to migrate
-		if err := s.migrateFromOrgToOwner(ctx); err != nil {
-			return fmt.Errorf("failed to migrate from org to owner: %w", err)
+	if !storage.NewOpenOptions(opts...).SkipMigrate {
+		if err := s.Migrate(context.Background()); err != nil {
+			return nil, err
 		}
 	}
 
-	// Create new schema (or ensure it exists after migration)
-	schema := `
-	CREATE TABLE IF NOT EXISTS events (
-		id TEXT PRIMARY KEY,
-		type TEXT NOT NULL,
-		owner TEXT NOT NULL,
-		owner_type TEXT NOT NULL DEFAULT 'organization',
-		repo TEXT NOT NULL,
-		member TEXT NOT NULL,
-		timestamp TIMESTAMP NOT NULL,
-		data JSONB NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_events_owner_repo ON events(owner, repo);
-	CREATE INDEX IF NOT EXISTS idx_events_member ON events(member);
-	CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp);
-	CREATE INDEX IF NOT EXISTS idx_events_type ON events(type);
-	CREATE INDEX IF NOT EXISTS idx_events_owner_type_timestamp ON events(owner, type, timestamp);
-	CREATE INDEX IF NOT EXISTS idx_events_owner_type ON events(owner_type);
-
-	CREATE TABLE IF NOT EXISTS repositories (
-		owner TEXT NOT NULL,
-		owner_type TEXT NOT NULL DEFAULT 'organization',
-		name TEXT NOT NULL,
-		full_name TEXT NOT NULL,
-		is_private BOOLEAN NOT NULL,
-		last_synced_at TIMESTAMP,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (owner, name)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_repositories_owner ON repositories(owner);
-	CREATE INDEX IF NOT EXISTS idx_repositories_owner_type ON repositories(owner_type);
-
-	CREATE TABLE IF NOT EXISTS members (
-		owner TEXT NOT NULL,
-		owner_type TEXT NOT NULL DEFAULT 'organization',
-		username TEXT NOT NULL,
-		display_name TEXT,
-		last_synced_at TIMESTAMP,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (owner, username)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_members_owner ON members(owner);
-	CREATE INDEX IF NOT EXISTS idx_members_owner_type ON members(owner_type);
-
-	CREATE TABLE IF NOT EXISTS collection_batches (
-		id TEXT PRIMARY KEY,
-		mode TEXT NOT NULL,
-		owner TEXT NOT NULL,
-		start_date TIMESTAMP NOT NULL,
-		end_date TIMESTAMP NOT NULL,
-		status TEXT NOT NULL DEFAULT 'in_progress',
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_collection_batches_owner ON collection_batches(owner);
-	CREATE INDEX IF NOT EXISTS idx_collection_batches_status ON collection_batches(status);
-	CREATE INDEX IF NOT EXISTS idx_collection_batches_mode_owner_dates ON collection_batches(mode, owner, start_date, end_date);
-	`
-
-	_, err = s.db.ExecContext(ctx, schema)
-	return err
+	return s, nil
 }
 
 // migrateFromOrgToOwner migrates existing tables from 'org' to 'owner' with 'owner_type'
@@ -494,6 +413,19 @@ func (s *postgresStorage) GetMetricsByRepo(ctx context.Context, org, repo string
 
 // GetEvents retrieves events for re-aggregation
 func (s *postgresStorage) GetEvents(ctx context.Context, org string, eventType domain.EventType, timeRange domain.TimeRange) ([]*domain.Event, error) {
+	var events []*domain.Event
+	err := s.StreamEvents(ctx, org, eventType, timeRange, func(e *domain.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// StreamEvents calls fn for each event in timestamp order without loading the result set into memory
+func (s *postgresStorage) StreamEvents(ctx context.Context, org string, eventType domain.EventType, timeRange domain.TimeRange, fn func(*domain.Event) error) error {
 	query := `
 		SELECT id, type, owner, owner_type, repo, member, timestamp, data, created_at
 		FROM events
@@ -502,11 +434,10 @@ func (s *postgresStorage) GetEvents(ctx context.Context, org string, eventType d
 	`
 	rows, err := s.db.QueryContext(ctx, query, org, string(eventType), timeRange.Start, timeRange.End)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var events []*domain.Event
 	for rows.Next() {
 		var e domain.Event
 		var dataStr string
@@ -515,7 +446,7 @@ func (s *postgresStorage) GetEvents(ctx context.Context, org string, eventType d
 		err := rows.Scan(&e.ID, &e.Type, &e.Org, &ownerType, &e.Repo, &e.Member, &e.Timestamp, &dataStr, &e.CreatedAt)
 		e.OwnerType = ownerType
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if dataStr != "" {
@@ -525,10 +456,89 @@ func (s *postgresStorage) GetEvents(ctx context.Context, org string, eventType d
 			}
 		}
 
-		events = append(events, &e)
+		if err := fn(&e); err != nil {
+			return err
+		}
 	}
 
-	return events, nil
+	return rows.Err()
+}
+
+// GetLatestEventTime returns the most recent created_at among the owner's events
+func (s *postgresStorage) GetLatestEventTime(ctx context.Context, org string) (time.Time, error) {
+	var latest sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM events WHERE owner = $1`, org).Scan(&latest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+	return latest.Time, nil
+}
+
+// GetDataVersion returns the owner's data version, which also moves with changes to every owner
+func (s *postgresStorage) GetDataVersion(ctx context.Context, owner string) (int64, error) {
+	var version int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(version), 0)::BIGINT FROM data_versions WHERE owner IN ($1, '')
+	`, strings.ToLower(owner)).Scan(&version)
+	return version, err
+}
+
+// BumpDataVersion moves the owner's data version, or every owner's if owner is empty
+func (s *postgresStorage) BumpDataVersion(ctx context.Context, owner string) error {
+	_, err := s.db.ExecContext(ctx, bumpDataVersionQuery, strings.ToLower(owner))
+	return err
+}
+
+// bumpDataVersion moves the owner's data version within tx, so it moves with the change it marks
+func bumpDataVersion(ctx context.Context, tx *sql.Tx, owner string) error {
+	if _, err := tx.ExecContext(ctx, bumpDataVersionQuery, strings.ToLower(owner)); err != nil {
+		return fmt.Errorf("failed to update the data version: %w", err)
+	}
+	return nil
+}
+
+const bumpDataVersionQuery = `
+	INSERT INTO data_versions (owner, version) VALUES ($1, 1)
+	ON CONFLICT (owner) DO UPDATE SET version = data_versions.version + 1
+`
+
+// GetMergeCommits returns the PR merge commits of an owner in a time range, oldest first.
+// A squash commit counts only when the pull request its header refers to was collected with
+// the commit as its merge commit; the PR event ID is derived from the owner, repository and
+// number like the collector does.
+func (s *postgresStorage) GetMergeCommits(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.MergeCommit, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.data->>'sha', c.repo, c.member, c.timestamp, c.data->>'merge_kind',
+			COALESCE((c.data->>'additions')::BIGINT, 0),
+			COALESCE((c.data->>'deletions')::BIGINT, 0)
+		FROM events c
+		WHERE c.owner = $1 AND c.type = 'commit' AND c.timestamp >= $2 AND c.timestamp <= $3
+			AND (c.data->>'merge_kind' = 'merge'
+				OR (c.data->>'merge_kind' = 'squash' AND EXISTS (
+					SELECT 1 FROM events p
+					WHERE p.id = c.owner || '-' || c.repo || '-pr-' || (c.data->>'pr_number')
+						AND p.data->>'merge_commit_sha' = c.data->>'sha'
+				)))
+		ORDER BY c.timestamp
+	`, org, timeRange.Start, timeRange.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commits []*domain.MergeCommit
+	for rows.Next() {
+		var c domain.MergeCommit
+		if err := rows.Scan(&c.SHA, &c.Repo, &c.Member, &c.Timestamp, &c.Kind, &c.Additions, &c.Deletions); err != nil {
+			return nil, err
+		}
+		commits = append(commits, &c)
+	}
+
+	return commits, rows.Err()
 }
 
 // SaveRepository saves a repository
@@ -537,6 +547,7 @@ func (s *postgresStorage) SaveRepository(ctx context.Context, repo *domain.Repos
 	if ownerType == "" {
 		ownerType = "organization" // default
 	}
+	// A repository saved without a sync time keeps the one recorded by an earlier collection
 	query := `
 		INSERT INTO repositories (owner, owner_type, name, full_name, is_private, last_synced_at, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -544,7 +555,7 @@ func (s *postgresStorage) SaveRepository(ctx context.Context, repo *domain.Repos
 			full_name = EXCLUDED.full_name,
 			is_private = EXCLUDED.is_private,
 			owner_type = EXCLUDED.owner_type,
-			last_synced_at = EXCLUDED.last_synced_at,
+			last_synced_at = COALESCE(EXCLUDED.last_synced_at, repositories.last_synced_at),
 			updated_at = EXCLUDED.updated_at
 	`
 	_, err := s.db.ExecContext(ctx, query,
@@ -560,6 +571,30 @@ func (s *postgresStorage) SaveRepository(ctx context.Context, repo *domain.Repos
 	return err
 }
 
+// ListOwners returns the organizations and users with repositories or events, sorted by name
+func (s *postgresStorage) ListOwners(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner FROM repositories
+		UNION
+		SELECT DISTINCT owner FROM events
+		ORDER BY owner
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	return owners, rows.Err()
+}
+
 // GetRepositories retrieves all repositories for an organization
 func (s *postgresStorage) GetRepositories(ctx context.Context, org string) ([]*domain.Repository, error) {
 	query := `
@@ -659,6 +694,186 @@ func (s *postgresStorage) GetMembers(ctx context.Context, org string) ([]*domain
 	return members, nil
 }
 
+// SaveTeam saves a team and replaces its member list
+func (s *postgresStorage) SaveTeam(ctx context.Context, team *domain.Team) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO teams (owner, slug, name, description, last_synced_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (owner, slug) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			last_synced_at = EXCLUDED.last_synced_at,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		team.Org,
+		team.Slug,
+		team.Name,
+		team.Description,
+		team.LastSyncedAt,
+		team.CreatedAt,
+		team.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM team_members WHERE owner = $1 AND team_slug = $2`, team.Org, team.Slug); err != nil {
+		return err
+	}
+	for _, member := range team.Members {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO team_members (owner, team_slug, username, role) VALUES ($1, $2, $3, $4)`,
+			team.Org, team.Slug, member, team.Role(member),
+		); err != nil {
+			return err
+		}
+	}
+	if err := bumpDataVersion(ctx, tx, team.Org); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTeams retrieves all teams of an organization with their members
+func (s *postgresStorage) GetTeams(ctx context.Context, org string) ([]*domain.Team, error) {
+	query := `
+		SELECT owner, slug, name, description, last_synced_at, created_at, updated_at
+		FROM teams
+		WHERE owner = $1
+		ORDER BY slug
+	`
+	rows, err := s.db.QueryContext(ctx, query, org)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []*domain.Team
+	bySlug := make(map[string]*domain.Team)
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+		bySlug[team.Slug] = team
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	memberRows, err := s.db.QueryContext(ctx,
+		`SELECT team_slug, username, role FROM team_members WHERE owner = $1 ORDER BY team_slug, username`, org)
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var slug, username, role string
+		if err := memberRows.Scan(&slug, &username, &role); err != nil {
+			return nil, err
+		}
+		if team, ok := bySlug[slug]; ok {
+			addTeamMember(team, username, role)
+		}
+	}
+
+	return teams, memberRows.Err()
+}
+
+// GetTeam retrieves a team with its members, or nil if there is none
+func (s *postgresStorage) GetTeam(ctx context.Context, org, slug string) (*domain.Team, error) {
+	query := `
+		SELECT owner, slug, name, description, last_synced_at, created_at, updated_at
+		FROM teams
+		WHERE owner = $1 AND slug = $2
+	`
+	team, err := scanTeam(s.db.QueryRowContext(ctx, query, org, slug))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT username, role FROM team_members WHERE owner = $1 AND team_slug = $2 ORDER BY username`, org, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var username, role string
+		if err := rows.Scan(&username, &role); err != nil {
+			return nil, err
+		}
+		addTeamMember(team, username, role)
+	}
+
+	return team, rows.Err()
+}
+
+// GetMemberTeams retrieves the teams of an organization a member belongs to, by team slug
+func (s *postgresStorage) GetMemberTeams(ctx context.Context, org, username string) ([]*domain.TeamMembership, error) {
+	query := `
+		SELECT tm.owner, tm.team_slug, t.name, tm.username, tm.role
+		FROM team_members tm
+		JOIN teams t ON t.owner = tm.owner AND t.slug = tm.team_slug
+		WHERE tm.owner = $1 AND tm.username = $2
+		ORDER BY tm.team_slug
+	`
+	rows, err := s.db.QueryContext(ctx, query, org, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []*domain.TeamMembership
+	for rows.Next() {
+		var m domain.TeamMembership
+		if err := rows.Scan(&m.Org, &m.Team, &m.TeamName, &m.Username, &m.Role); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, &m)
+	}
+	return memberships, rows.Err()
+}
+
+// addTeamMember adds a member with its role, a team_members row, to a team
+func addTeamMember(team *domain.Team, username, role string) {
+	team.Members = append(team.Members, username)
+	if role == domain.TeamRoleMaintainer {
+		team.Maintainers = append(team.Maintainers, username)
+	}
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTeam scans a teams row
+func scanTeam(row rowScanner) (*domain.Team, error) {
+	var t domain.Team
+	var lastSyncedAt sql.NullTime
+	if err := row.Scan(&t.Org, &t.Slug, &t.Name, &t.Description, &lastSyncedAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if lastSyncedAt.Valid {
+		t.LastSyncedAt = &lastSyncedAt.Time
+	}
+	return &t, nil
+}
+
 // GetMembersWithMetrics retrieves all members with their metrics
 func (s *postgresStorage) GetMembersWithMetrics(ctx context.Context, org string, timeRange domain.TimeRange) ([]*domain.MemberMetrics, error) {
 	query := `
@@ -681,13 +896,23 @@ func (s *postgresStorage) GetMembersWithMetrics(ctx context.Context, org string,
 		memberNames = append(memberNames, member)
 	}
 
-	var metrics []*domain.MemberMetrics
-	for _, member := range memberNames {
-		m, err := s.GetMetricsByMember(ctx, org, member, timeRange)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Query members concurrently; results keep the member name order
+	metrics := make([]*domain.MemberMetrics, len(memberNames))
+	err = storage.ForEachParallel(ctx, len(memberNames), storage.DefaultQueryWorkers, func(ctx context.Context, i int) error {
+		m, err := s.GetMetricsByMember(ctx, org, memberNames[i], timeRange)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		metrics = append(metrics, m)
+		metrics[i] = m
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return metrics, nil
@@ -715,57 +940,75 @@ func (s *postgresStorage) GetRepoMembersWithMetrics(ctx context.Context, org, re
 		memberNames = append(memberNames, member)
 	}
 
-	var metrics []*domain.MemberMetrics
-	for _, member := range memberNames {
-		// Get metrics for this member in this specific repo
-		memberMetrics := &domain.MemberMetrics{
-			Member:    member,
-			TimeRange: timeRange,
-		}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-		// Get commits count for this repo
-		err := s.db.QueryRowContext(ctx, `
-			SELECT COUNT(*) FROM events 
-			WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'commit' AND timestamp >= $4 AND timestamp <= $5
-		`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.Commits)
+	// Query members concurrently; results keep the member name order
+	metrics := make([]*domain.MemberMetrics, len(memberNames))
+	err = storage.ForEachParallel(ctx, len(memberNames), storage.DefaultQueryWorkers, func(ctx context.Context, i int) error {
+		m, err := s.getRepoMemberMetrics(ctx, org, repo, memberNames[i], timeRange)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		metrics[i] = m
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Get PRs count for this repo
-		err = s.db.QueryRowContext(ctx, `
-			SELECT COUNT(*) FROM events 
-			WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'pull_request' AND timestamp >= $4 AND timestamp <= $5
-		`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.PRs)
-		if err != nil {
-			return nil, err
-		}
+	return metrics, nil
+}
 
-		// Get deploys count for this repo
-		err = s.db.QueryRowContext(ctx, `
-			SELECT COUNT(*) FROM events 
-			WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'deploy' AND timestamp >= $4 AND timestamp <= $5
-		`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.Deploys)
-		if err != nil {
-			return nil, err
-		}
+// getRepoMemberMetrics retrieves the metrics of a single member within a specific repository
+func (s *postgresStorage) getRepoMemberMetrics(ctx context.Context, org, repo, member string, timeRange domain.TimeRange) (*domain.MemberMetrics, error) {
+	memberMetrics := &domain.MemberMetrics{
+		Member:    member,
+		TimeRange: timeRange,
+	}
 
-		// Get additions and deletions from commit events using JSONB for this repo
-		err = s.db.QueryRowContext(ctx, `
-			SELECT 
-				COALESCE(SUM((data->>'additions')::int), 0),
-				COALESCE(SUM((data->>'deletions')::int), 0)
-			FROM events 
-			WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'commit' AND timestamp >= $4 AND timestamp <= $5
-		`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.Additions, &memberMetrics.Deletions)
-		if err != nil {
-			return nil, err
-		}
+	// Get commits count for this repo
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM events 
+		WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'commit' AND timestamp >= $4 AND timestamp <= $5
+	`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.Commits)
+	if err != nil {
+		return nil, err
+	}
 
-		metrics = append(metrics, memberMetrics)
+	// Get PRs count for this repo
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM events 
+		WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'pull_request' AND timestamp >= $4 AND timestamp <= $5
+	`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.PRs)
+	if err != nil {
+		return nil, err
 	}
 
-	return metrics, nil
+	// Get deploys count for this repo
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM events 
+		WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'deploy' AND timestamp >= $4 AND timestamp <= $5
+	`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.Deploys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get additions and deletions from commit events using JSONB for this repo
+	err = s.db.QueryRowContext(ctx, `
+		SELECT 
+			COALESCE(SUM((data->>'additions')::int), 0),
+			COALESCE(SUM((data->>'deletions')::int), 0)
+		FROM events 
+		WHERE owner = $1 AND repo = $2 AND member = $3 AND type = 'commit' AND timestamp >= $4 AND timestamp <= $5
+	`, org, repo, member, timeRange.Start, timeRange.End).Scan(&memberMetrics.Additions, &memberMetrics.Deletions)
+	if err != nil {
+		return nil, err
+	}
+
+	return memberMetrics, nil
 }
 
 // GetReposWithMetrics retrieves all repos with their metrics
@@ -790,16 +1033,58 @@ func (s *postgresStorage) GetReposWithMetrics(ctx context.Context, org string, t
 		repoNames = append(repoNames, repo)
 	}
 
-	var metrics []*domain.RepoMetrics
-	for _, repo := range repoNames {
-		m, err := s.GetMetricsByRepo(ctx, org, repo, timeRange)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Query repositories concurrently; results keep the repository name order
+	metrics := make([]*domain.RepoMetrics, len(repoNames))
+	err = storage.ForEachParallel(ctx, len(repoNames), storage.DefaultQueryWorkers, func(ctx context.Context, i int) error {
+		m, err := s.GetMetricsByRepo(ctx, org, repoNames[i], timeRange)
 		if err != nil {
+			return err
+		}
+		metrics[i] = m
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// GetMemberReposWithMetrics retrieves a member's metrics in each repository they were active in
+func (s *postgresStorage) GetMemberReposWithMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) ([]*domain.RepoMetrics, error) {
+	query := `
+		SELECT repo,
+			SUM(CASE WHEN type = 'commit' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN type = 'pull_request' THEN 1 ELSE 0 END),
+			COALESCE(SUM(CASE WHEN type = 'commit' THEN (data->>'additions')::int ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN type = 'commit' THEN (data->>'deletions')::int ELSE 0 END), 0),
+			SUM(CASE WHEN type = 'deploy' THEN 1 ELSE 0 END)
+		FROM events
+		WHERE owner = $1 AND member = $2 AND timestamp >= $3 AND timestamp <= $4
+		GROUP BY repo
+		ORDER BY repo
+	`
+	rows, err := s.db.QueryContext(ctx, query, org, member, timeRange.Start, timeRange.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []*domain.RepoMetrics
+	for rows.Next() {
+		m := &domain.RepoMetrics{TimeRange: timeRange}
+		if err := rows.Scan(&m.Repo, &m.Commits, &m.PRs, &m.Additions, &m.Deletions, &m.Deploys); err != nil {
 			return nil, err
 		}
 		metrics = append(metrics, m)
 	}
 
-	return metrics, nil
+	return metrics, rows.Err()
 }
 
 // GetMemberRanking retrieves member rankings
@@ -1080,19 +1365,602 @@ func (s *postgresStorage) GetBatch(ctx context.Context, batchID string) (*domain
 	return &batch, nil
 }
 
-// UpdateBatchStatus updates the status of a batch
-func (s *postgresStorage) UpdateBatchStatus(ctx context.Context, batchID string, status string) error {
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE collection_batches
-		SET status = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
-	`, status, batchID)
-	return err
-}
-
-// GetOrgTimeSeries retrieves time series data for an organization
-func (s *postgresStorage) GetOrgTimeSeries(ctx context.Context, org string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
-	return s.getTimeSeries(ctx, org, "", "", timeRange)
+// ListBatches retrieves collection batches, newest first
+func (s *postgresStorage) ListBatches(ctx context.Context, filter domain.BatchFilter) ([]*domain.CollectionBatch, error) {
+	query := `
+		SELECT id, mode, owner, start_date, end_date, status, created_at, updated_at
+		FROM collection_batches
+		WHERE 1=1`
+	var args []interface{}
+	if filter.Owner != "" {
+		args = append(args, filter.Owner)
+		query += " AND owner = " + fmt.Sprintf("$%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += " AND status = " + fmt.Sprintf("$%d", len(args))
+	}
+	if filter.Owners != nil {
+		if len(filter.Owners) == 0 {
+			return nil, nil
+		}
+		placeholders := make([]string, len(filter.Owners))
+		for i, owner := range filter.Owners {
+			args = append(args, owner)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		query += " AND owner IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += " LIMIT " + fmt.Sprintf("$%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*domain.CollectionBatch
+	for rows.Next() {
+		var batch domain.CollectionBatch
+		if err := rows.Scan(
+			&batch.ID, &batch.Mode, &batch.Owner, &batch.StartDate, &batch.EndDate,
+			&batch.Status, &batch.CreatedAt, &batch.UpdatedAt); err != nil {
+			return nil, err
+		}
+		batches = append(batches, &batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// UpdateBatchStatus updates the status of a batch
+func (s *postgresStorage) UpdateBatchStatus(ctx context.Context, batchID string, status string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE collection_batches
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, status, batchID)
+	return err
+}
+
+// SaveAPIKey stores a new API key
+func (s *postgresStorage) SaveAPIKey(ctx context.Context, key *domain.APIKey) error {
+	createdAt := key.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO api_keys (name, key_hash, scope, workspace, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		key.Name, key.KeyHash, string(key.Scope), key.Workspace, createdAt)
+	return err
+}
+
+// GetAPIKeyByHash retrieves an API key by its hash, returning nil if it does not exist
+func (s *postgresStorage) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var scope string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name, key_hash, scope, workspace, created_at
+		FROM api_keys
+		WHERE key_hash = $1
+	`, keyHash).Scan(&key.Name, &key.KeyHash, &scope, &key.Workspace, &key.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.Scope = domain.APIKeyScope(scope)
+	return &key, nil
+}
+
+// ListAPIKeys retrieves all stored API keys
+func (s *postgresStorage) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name, key_hash, scope, workspace, created_at
+		FROM api_keys
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		var key domain.APIKey
+		var scope string
+		if err := rows.Scan(&key.Name, &key.KeyHash, &scope, &key.Workspace, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		key.Scope = domain.APIKeyScope(scope)
+		keys = append(keys, &key)
+	}
+
+	return keys, rows.Err()
+}
+
+// DeleteAPIKey removes an API key and its grants by name
+func (s *postgresStorage) DeleteAPIKey(ctx context.Context, name string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM api_key_grants WHERE key_name = $1`, name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM api_keys WHERE name = $1`, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveAPIKeyGrant creates or updates an API key's role for an owner
+func (s *postgresStorage) SaveAPIKeyGrant(ctx context.Context, grant *domain.OwnerGrant) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_key_grants (key_name, owner, role) VALUES ($1, $2, $3)
+		ON CONFLICT (key_name, owner) DO UPDATE SET role = EXCLUDED.role
+	`, grant.KeyName, grant.Owner, string(grant.Role))
+	return err
+}
+
+// GetAPIKeyGrants retrieves the owner grants of an API key
+func (s *postgresStorage) GetAPIKeyGrants(ctx context.Context, keyName string) ([]*domain.OwnerGrant, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT key_name, owner, role
+		FROM api_key_grants
+		WHERE key_name = $1
+		ORDER BY owner
+	`, keyName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []*domain.OwnerGrant
+	for rows.Next() {
+		var g domain.OwnerGrant
+		var role string
+		if err := rows.Scan(&g.KeyName, &g.Owner, &role); err != nil {
+			return nil, err
+		}
+		g.Role = domain.Role(role)
+		grants = append(grants, &g)
+	}
+
+	return grants, rows.Err()
+}
+
+// DeleteAPIKeyGrant removes an API key's grant for an owner
+func (s *postgresStorage) DeleteAPIKeyGrant(ctx context.Context, keyName, owner string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM api_key_grants WHERE key_name = $1 AND owner = $2`, keyName, owner)
+	return err
+}
+
+// SaveWorkspace creates a workspace or updates its name
+func (s *postgresStorage) SaveWorkspace(ctx context.Context, ws *domain.Workspace) error {
+	createdAt := ws.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO workspaces (id, name, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name
+	`, ws.ID, ws.Name, createdAt)
+	return err
+}
+
+// GetWorkspace retrieves a workspace with its owners, returning nil if it does not exist
+func (s *postgresStorage) GetWorkspace(ctx context.Context, id string) (*domain.Workspace, error) {
+	var ws domain.Workspace
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, created_at FROM workspaces WHERE id = $1
+	`, id).Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ws.Owners, err = s.getWorkspaceOwners(ctx, id); err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// ListWorkspaces retrieves all workspaces with their owners
+func (s *postgresStorage) ListWorkspaces(ctx context.Context) ([]*domain.Workspace, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, created_at FROM workspaces ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []*domain.Workspace
+	for rows.Next() {
+		var ws domain.Workspace
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, &ws)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, ws := range workspaces {
+		if ws.Owners, err = s.getWorkspaceOwners(ctx, ws.ID); err != nil {
+			return nil, err
+		}
+	}
+	return workspaces, nil
+}
+
+// getWorkspaceOwners retrieves the owners assigned to a workspace
+func (s *postgresStorage) getWorkspaceOwners(ctx context.Context, id string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT owner FROM workspace_owners WHERE workspace_id = $1 ORDER BY owner`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	return owners, rows.Err()
+}
+
+// DeleteWorkspace removes a workspace and its owner assignments
+func (s *postgresStorage) DeleteWorkspace(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workspace_owners WHERE workspace_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workspaces WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddWorkspaceOwner assigns an owner to a workspace; it fails if the owner belongs to another workspace
+func (s *postgresStorage) AddWorkspaceOwner(ctx context.Context, id, owner string) error {
+	var current string
+	err := s.db.QueryRowContext(ctx, `SELECT workspace_id FROM workspace_owners WHERE owner = $1`, owner).Scan(&current)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	case current == id:
+		return nil
+	default:
+		return fmt.Errorf("%s already belongs to workspace %s", owner, current)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO workspace_owners (owner, workspace_id) VALUES ($1, $2)`, owner, id)
+	return err
+}
+
+// RemoveWorkspaceOwner removes an owner from a workspace
+func (s *postgresStorage) RemoveWorkspaceOwner(ctx context.Context, id, owner string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM workspace_owners WHERE workspace_id = $1 AND owner = $2`, id, owner)
+	return err
+}
+
+// SaveMemberAlias maps an alias to a member, replacing the alias's previous member
+func (s *postgresStorage) SaveMemberAlias(ctx context.Context, alias *domain.MemberAlias) error {
+	createdAt := alias.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO member_aliases (owner, alias, member, created_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (owner, alias) DO UPDATE SET member = EXCLUDED.member
+		`, alias.Owner, alias.Alias, alias.Member, createdAt)
+		if err != nil {
+			return err
+		}
+		return bumpDataVersion(ctx, tx, alias.Owner)
+	})
+}
+
+// ListMemberAliases retrieves the member aliases of an owner, ordered by member and alias
+func (s *postgresStorage) ListMemberAliases(ctx context.Context, owner string) ([]*domain.MemberAlias, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner, alias, member, created_at
+		FROM member_aliases
+		WHERE owner = $1
+		ORDER BY member, alias
+	`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []*domain.MemberAlias
+	for rows.Next() {
+		var a domain.MemberAlias
+		if err := rows.Scan(&a.Owner, &a.Alias, &a.Member, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, &a)
+	}
+
+	return aliases, rows.Err()
+}
+
+// DeleteMemberAlias removes a member alias of an owner
+func (s *postgresStorage) DeleteMemberAlias(ctx context.Context, owner, alias string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM member_aliases WHERE owner = $1 AND alias = $2`, owner, alias); err != nil {
+			return err
+		}
+		return bumpDataVersion(ctx, tx, owner)
+	})
+}
+
+// SaveRepositoryList stores a repository list, replacing the owner's previous one
+func (s *postgresStorage) SaveRepositoryList(ctx context.Context, list *domain.RepositoryList) error {
+	repos, err := json.Marshal(list.Repos)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repositories: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO repository_lists (owner, owner_type, repos, fetched_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (owner, owner_type) DO UPDATE SET repos = EXCLUDED.repos, fetched_at = EXCLUDED.fetched_at
+	`, list.Owner, list.OwnerType, string(repos), list.FetchedAt)
+	return err
+}
+
+// GetRepositoryList returns the stored repository list of an owner, or nil if there is none
+func (s *postgresStorage) GetRepositoryList(ctx context.Context, owner, ownerType string) (*domain.RepositoryList, error) {
+	list := &domain.RepositoryList{Owner: owner, OwnerType: ownerType}
+	var repos string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT repos, fetched_at FROM repository_lists WHERE owner = $1 AND owner_type = $2
+	`, owner, ownerType).Scan(&repos, &list.FetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(repos), &list.Repos); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal repositories: %w", err)
+	}
+	return list, nil
+}
+
+// SaveDeployDefinition sets a repository's deploy definition, deleting the repository's
+// deploy events if the definition changes
+func (s *postgresStorage) SaveDeployDefinition(ctx context.Context, def *domain.DeployDefinition) (int64, error) {
+	updatedAt := def.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	owner, repo := strings.ToLower(def.Owner), strings.ToLower(def.Repo)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	deleted, err := deleteChangedDeploys(ctx, tx, owner, repo, def)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO deploy_definitions (owner, repo, source, pattern, updated_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT(owner, repo) DO UPDATE SET source = excluded.source, pattern = excluded.pattern, updated_at = excluded.updated_at
+	`, owner, repo, string(def.Source), def.Pattern, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+	if err := bumpDataVersion(ctx, tx, owner); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// GetDeployDefinition returns a repository's deploy definition, or nil if it has none
+func (s *postgresStorage) GetDeployDefinition(ctx context.Context, owner, repo string) (*domain.DeployDefinition, error) {
+	var def domain.DeployDefinition
+	var source string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT owner, repo, source, pattern, updated_at FROM deploy_definitions WHERE owner = $1 AND repo = $2
+	`, strings.ToLower(owner), strings.ToLower(repo)).Scan(&def.Owner, &def.Repo, &source, &def.Pattern, &def.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	def.Source = domain.DeploySource(source)
+	return &def, nil
+}
+
+// ListDeployDefinitions returns the deploy definitions of an owner's repositories, ordered by repository
+func (s *postgresStorage) ListDeployDefinitions(ctx context.Context, owner string) ([]*domain.DeployDefinition, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner, repo, source, pattern, updated_at
+		FROM deploy_definitions
+		WHERE owner = $1
+		ORDER BY repo
+	`, strings.ToLower(owner))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defs []*domain.DeployDefinition
+	for rows.Next() {
+		var def domain.DeployDefinition
+		var source string
+		if err := rows.Scan(&def.Owner, &def.Repo, &source, &def.Pattern, &def.UpdatedAt); err != nil {
+			return nil, err
+		}
+		def.Source = domain.DeploySource(source)
+		defs = append(defs, &def)
+	}
+
+	return defs, rows.Err()
+}
+
+// DeleteDeployDefinition removes a repository's deploy definition, deleting the repository's
+// deploy events unless it counted all GitHub deployments
+func (s *postgresStorage) DeleteDeployDefinition(ctx context.Context, owner, repo string) (int64, error) {
+	owner, repo = strings.ToLower(owner), strings.ToLower(repo)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	deleted, err := deleteChangedDeploys(ctx, tx, owner, repo, domain.DefaultDeployDefinition(owner, repo))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM deploy_definitions WHERE owner = $1 AND repo = $2`, owner, repo); err != nil {
+		return 0, err
+	}
+	if err := bumpDataVersion(ctx, tx, owner); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// deleteChangedDeploys deletes a repository's deploy events if def changes what counts as its
+// deploys, as they may not be deploys of def; owner and repo are lowercase
+func deleteChangedDeploys(ctx context.Context, tx *sql.Tx, owner, repo string, def *domain.DeployDefinition) (int64, error) {
+	current := domain.DefaultDeployDefinition(owner, repo)
+	var source string
+	err := tx.QueryRowContext(ctx, `
+		SELECT source, pattern FROM deploy_definitions WHERE owner = $1 AND repo = $2
+	`, owner, repo).Scan(&source, &current.Pattern)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil {
+		current.Source = domain.DeploySource(source)
+	}
+	if current.Source == def.Source && current.Pattern == def.Pattern {
+		return 0, nil
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM events WHERE type = 'deploy' AND LOWER(owner) = $1 AND LOWER(repo) = $2
+	`, owner, repo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete deploys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListMetricAlerts returns the metric alerts in effect for an owner, ordered by key
+func (s *postgresStorage) ListMetricAlerts(ctx context.Context, owner string) ([]*domain.MetricAlert, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner, key, triggered_at FROM metric_alerts WHERE owner = $1 ORDER BY key
+	`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []*domain.MetricAlert
+	for rows.Next() {
+		var a domain.MetricAlert
+		if err := rows.Scan(&a.Owner, &a.Key, &a.TriggeredAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &a)
+	}
+
+	return alerts, rows.Err()
+}
+
+// SaveMetricAlert records that a metric alert is in effect, keeping the time it was first triggered
+func (s *postgresStorage) SaveMetricAlert(ctx context.Context, alert *domain.MetricAlert) error {
+	triggeredAt := alert.TriggeredAt
+	if triggeredAt.IsZero() {
+		triggeredAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO metric_alerts (owner, key, triggered_at) VALUES ($1, $2, $3)
+		ON CONFLICT (owner, key) DO NOTHING
+	`, alert.Owner, alert.Key, triggeredAt)
+	return err
+}
+
+// DeleteMetricAlert records that a metric alert of an owner is resolved
+func (s *postgresStorage) DeleteMetricAlert(ctx context.Context, owner, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM metric_alerts WHERE owner = $1 AND key = $2`, owner, key)
+	return err
+}
+
+// SaveBatchRepoStatus creates or updates the status of a repository within a batch
+func (s *postgresStorage) SaveBatchRepoStatus(ctx context.Context, status *domain.BatchRepoStatus) error {
+	updatedAt := status.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO collection_batch_repos (batch_id, repo, status, events, error, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (batch_id, repo) DO UPDATE SET
+			status = EXCLUDED.status,
+			events = EXCLUDED.events,
+			error = EXCLUDED.error,
+			updated_at = EXCLUDED.updated_at
+	`, status.BatchID, status.Repo, status.Status, status.Events, status.Error, updatedAt)
+	return err
+}
+
+// GetBatchRepoStatuses retrieves the repository statuses of a batch
+func (s *postgresStorage) GetBatchRepoStatuses(ctx context.Context, batchID string) ([]*domain.BatchRepoStatus, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT batch_id, repo, status, events, error, updated_at
+		FROM collection_batch_repos
+		WHERE batch_id = $1
+		ORDER BY repo
+	`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []*domain.BatchRepoStatus
+	for rows.Next() {
+		var st domain.BatchRepoStatus
+		if err := rows.Scan(&st.BatchID, &st.Repo, &st.Status, &st.Events, &st.Error, &st.UpdatedAt); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, &st)
+	}
+
+	return statuses, rows.Err()
+}
+
+// GetOrgTimeSeries retrieves time series data for an organization
+func (s *postgresStorage) GetOrgTimeSeries(ctx context.Context, org string, timeRange domain.TimeRange) (*domain.DetailedTimeSeriesData, error) {
+	return s.getTimeSeries(ctx, org, "", "", timeRange)
 }
 
 // GetRepoTimeSeries retrieves time series data for a repository
@@ -1175,6 +2043,8 @@ func getPostgresDateTrunc(granularity string) string {
 	switch granularity {
 	case "day":
 		return "day"
+	case "week":
+		return "week"
 	case "month":
 		return "month"
 	default:
@@ -1190,14 +2060,21 @@ func (s *postgresStorage) fillTimeSeriesGaps(dataPoints []domain.DetailedTimeSer
 
 	// Create a map of existing timestamps
 	existingMap := make(map[time.Time]domain.DetailedTimeSeriesMetric)
+	// Periods are bucketed in UTC; the range may be in another location, which as a map
+	// key would never match
 	for _, dp := range dataPoints {
-		existingMap[truncateTimeForGranularity(dp.Timestamp, timeRange.Granularity)] = dp
+		existingMap[truncateTimeForGranularity(dp.Timestamp.UTC(), timeRange.Granularity)] = dp
 	}
 
 	// Generate all periods in the range
 	var filled []domain.DetailedTimeSeriesMetric
-	current := truncateTimeForGranularity(timeRange.Start, timeRange.Granularity)
-	end := truncateTimeForGranularity(timeRange.End, timeRange.Granularity)
+	current := truncateTimeForGranularity(timeRange.Start.UTC(), timeRange.Granularity)
+	if timeRange.Start.IsZero() {
+		// A range without a start, such as a member's whole history, starts at the first
+		// period with activity rather than in year 1
+		current = truncateTimeForGranularity(dataPoints[0].Timestamp.UTC(), timeRange.Granularity)
+	}
+	end := truncateTimeForGranularity(timeRange.End.UTC(), timeRange.Granularity)
 
 	for !current.After(end) {
 		if dp, exists := existingMap[current]; exists {
@@ -1223,6 +2100,13 @@ func truncateTimeForGranularity(t time.Time, granularity string) time.Time {
 	switch granularity {
 	case "day":
 		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case "week":
+		// Weeks start on Monday
+		weekday := int(t.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		return time.Date(t.Year(), t.Month(), t.Day()-weekday+1, 0, 0, 0, 0, t.Location())
 	case "month":
 		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
 	default:
@@ -1235,6 +2119,8 @@ func getNextPeriodForGranularity(t time.Time, granularity string) time.Time {
 	switch granularity {
 	case "day":
 		return t.AddDate(0, 0, 1)
+	case "week":
+		return t.AddDate(0, 0, 7)
 	case "month":
 		return t.AddDate(0, 1, 0)
 	default:
@@ -1246,3 +2132,220 @@ func getNextPeriodForGranularity(t time.Time, granularity string) time.Time {
 func (s *postgresStorage) Close() error {
 	return s.db.Close()
 }
+
+// Ping checks that the database can be reached
+func (s *postgresStorage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// schemaTables are the tables created by Migrate
+var schemaTables = []string{
+	"events", "repositories", "members", "teams", "team_members", "collection_batches",
+	"collection_batch_repos", "api_keys", "api_key_grants", "workspaces", "workspace_owners", "audit_log",
+	"member_aliases", "repository_lists", "deploy_definitions", "data_versions",
+	"metric_alerts",
+}
+
+// MissingTables returns the tables of the current schema that don't exist in the database
+func (s *postgresStorage) MissingTables(ctx context.Context) ([]string, error) {
+	var missing []string
+	for _, table := range schemaTables {
+		var n int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`, table).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
+// PurgeEvents deletes events older than before, for one owner or all owners if owner is empty
+func (s *postgresStorage) PurgeEvents(ctx context.Context, owner string, before time.Time) (int64, error) {
+	var deleted int64
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var result sql.Result
+		var err error
+		if owner == "" {
+			result, err = tx.ExecContext(ctx, `DELETE FROM events WHERE timestamp < $1`, before)
+		} else {
+			result, err = tx.ExecContext(ctx, `DELETE FROM events WHERE owner = $1 AND timestamp < $2`, owner, before)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to purge events: %w", err)
+		}
+		if deleted, err = result.RowsAffected(); err != nil || deleted == 0 {
+			return err
+		}
+		return bumpDataVersion(ctx, tx, owner)
+	})
+	return deleted, err
+}
+
+// PurgeBatches deletes finished collection batches whose range ended before before, with
+// their per-repository progress, for one owner or all owners if owner is empty
+func (s *postgresStorage) PurgeBatches(ctx context.Context, owner string, before time.Time) (int64, error) {
+	where := `status != 'in_progress' AND end_date < $1`
+	args := []any{before}
+	if owner != "" {
+		where += ` AND owner = $2`
+		args = append(args, owner)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM collection_batch_repos WHERE batch_id IN (SELECT id FROM collection_batches WHERE `+where+`)`, args...); err != nil {
+		return 0, fmt.Errorf("failed to purge batch progress: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM collection_batches WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge batches: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountPurgeable returns the number of events PurgeEvents and batches PurgeBatches would
+// delete, for one owner or all owners if owner is empty
+func (s *postgresStorage) CountPurgeable(ctx context.Context, owner string, before time.Time) (events, batches int64, err error) {
+	eventsQuery := `SELECT COUNT(*) FROM events WHERE timestamp < $1`
+	batchesQuery := `SELECT COUNT(*) FROM collection_batches WHERE status != 'in_progress' AND end_date < $1`
+	args := []any{before}
+	if owner != "" {
+		eventsQuery += ` AND owner = $2`
+		batchesQuery += ` AND owner = $2`
+		args = append(args, owner)
+	}
+
+	if err := s.db.QueryRowContext(ctx, eventsQuery, args...).Scan(&events); err != nil {
+		return 0, 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, batchesQuery, args...).Scan(&batches); err != nil {
+		return 0, 0, fmt.Errorf("failed to count batches: %w", err)
+	}
+	return events, batches, nil
+}
+
+// DeleteRepoData deletes a repository's events and metadata
+func (s *postgresStorage) DeleteRepoData(ctx context.Context, owner, repo string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM events WHERE owner = $1 AND repo = $2`, owner, repo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete repository events: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM repositories WHERE owner = $1 AND name = $2`, owner, repo); err != nil {
+		return 0, fmt.Errorf("failed to delete repository: %w", err)
+	}
+	if err := bumpDataVersion(ctx, tx, owner); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteMemberData deletes a member's events and metadata within an owner
+func (s *postgresStorage) DeleteMemberData(ctx context.Context, owner, member string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM events WHERE owner = $1 AND member = $2`, owner, member)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete member events: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM members WHERE owner = $1 AND username = $2`, owner, member); err != nil {
+		return 0, fmt.Errorf("failed to delete member: %w", err)
+	}
+	if err := bumpDataVersion(ctx, tx, owner); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SaveAuditEntry records an API request in the audit log
+func (s *postgresStorage) SaveAuditEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (time, principal, owner, method, route, path, params, status, client_ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
+		entry.Time,
+		entry.Principal,
+		entry.Owner,
+		entry.Method,
+		entry.Route,
+		entry.Path,
+		entry.Params,
+		entry.Status,
+		entry.ClientIP,
+		entry.RequestID,
+	)
+	return err
+}
+
+// ListAuditEntries retrieves audit log entries, newest first
+func (s *postgresStorage) ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	query := `
+		SELECT id, time, principal, owner, method, route, path, params, status, client_ip, request_id
+		FROM audit_log
+		WHERE 1=1`
+	var args []interface{}
+	if filter.Owner != "" {
+		args = append(args, filter.Owner)
+		query += " AND owner = " + fmt.Sprintf("$%d", len(args))
+	}
+	if filter.Principal != "" {
+		args = append(args, filter.Principal)
+		query += " AND principal = " + fmt.Sprintf("$%d", len(args))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		query += " AND time >= " + fmt.Sprintf("$%d", len(args))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		query += " AND time < " + fmt.Sprintf("$%d", len(args))
+	}
+	query += " ORDER BY time DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += " LIMIT " + fmt.Sprintf("$%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		var e domain.AuditEntry
+		if err := rows.Scan(
+			&e.ID, &e.Time, &e.Principal, &e.Owner, &e.Method, &e.Route, &e.Path,
+			&e.Params, &e.Status, &e.ClientIP, &e.RequestID); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
@@ -17,6 +17,7 @@ CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp);
 CREATE INDEX IF NOT EXISTS idx_events_type ON events(type);
 CREATE INDEX IF NOT EXISTS idx_events_owner_type_timestamp ON events(owner, type, timestamp);
 CREATE INDEX IF NOT EXISTS idx_events_owner_type ON events(owner_type);
+CREATE INDEX IF NOT EXISTS idx_events_owner_created_at ON events(owner, created_at);
 
 -- Repositories table (repository metadata)
 CREATE TABLE IF NOT EXISTS repositories (
@@ -49,6 +50,27 @@ CREATE TABLE IF NOT EXISTS members (
 CREATE INDEX IF NOT EXISTS idx_members_owner ON members(owner);
 CREATE INDEX IF NOT EXISTS idx_members_owner_type ON members(owner_type);
 
+-- Teams table (organization team metadata)
+CREATE TABLE IF NOT EXISTS teams (
+    owner TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (owner, slug)
+);
+
+-- Team members table (membership of organization members in teams)
+CREATE TABLE IF NOT EXISTS team_members (
+    owner TEXT NOT NULL,
+    team_slug TEXT NOT NULL,
+    username TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member', -- 'member' or 'maintainer'
+    PRIMARY KEY (owner, team_slug, username)
+);
+
 -- Collection batches table (batch collection jobs)
 CREATE TABLE IF NOT EXISTS collection_batches (
     id TEXT PRIMARY KEY,
@@ -64,3 +86,111 @@ CREATE TABLE IF NOT EXISTS collection_batches (
 CREATE INDEX IF NOT EXISTS idx_collection_batches_owner ON collection_batches(owner);
 CREATE INDEX IF NOT EXISTS idx_collection_batches_status ON collection_batches(status);
 CREATE INDEX IF NOT EXISTS idx_collection_batches_mode_owner_dates ON collection_batches(mode, owner, start_date, end_date);
+
+-- Collection batch repositories table (per-repository progress of a batch)
+CREATE TABLE IF NOT EXISTS collection_batch_repos (
+    batch_id TEXT NOT NULL,
+    repo TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    events INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (batch_id, repo)
+);
+
+-- API keys table (hashed keys accepted by the API server)
+CREATE TABLE IF NOT EXISTS api_keys (
+    name TEXT PRIMARY KEY,
+    key_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL DEFAULT 'read',
+    workspace TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- API key grants table (per-owner roles for API keys; owner '*' matches every owner)
+CREATE TABLE IF NOT EXISTS api_key_grants (
+    key_name TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'viewer',
+    PRIMARY KEY (key_name, owner)
+);
+
+-- Workspaces table (independent tenants of a hosted deployment)
+CREATE TABLE IF NOT EXISTS workspaces (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Workspace owners table (an organization/user belongs to at most one workspace)
+CREATE TABLE IF NOT EXISTS workspace_owners (
+    owner TEXT PRIMARY KEY,
+    workspace_id TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_owners_workspace ON workspace_owners(workspace_id);
+
+-- API access audit log (who accessed which owner's data, when and with what result)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    time TIMESTAMP NOT NULL,
+    principal TEXT NOT NULL,
+    owner TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL,
+    route TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL,
+    params TEXT NOT NULL DEFAULT '',
+    status INTEGER NOT NULL,
+    client_ip TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log(time);
+CREATE INDEX IF NOT EXISTS idx_audit_log_owner_time ON audit_log(owner, time);
+
+-- Member aliases table (other logins, author names or emails counted as a canonical member;
+-- aliases are stored lowercase)
+CREATE TABLE IF NOT EXISTS member_aliases (
+    owner TEXT NOT NULL,
+    alias TEXT NOT NULL,
+    member TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (owner, alias)
+);
+
+-- Repository list cache (each owner's repositories as last listed from GitHub, as JSON; owners
+-- are stored lowercase)
+CREATE TABLE IF NOT EXISTS repository_lists (
+    owner TEXT NOT NULL,
+    owner_type TEXT NOT NULL,
+    repos TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (owner, owner_type)
+);
+
+-- Deploy definitions (what counts as a deploy of a repository: deployments, workflow, tag or
+-- release; owners and repositories are stored lowercase)
+CREATE TABLE IF NOT EXISTS deploy_definitions (
+    owner TEXT NOT NULL,
+    repo TEXT NOT NULL,
+    source TEXT NOT NULL,
+    pattern TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (owner, repo)
+);
+
+-- Data versions (a counter per lowercase owner moved by deletes, rewritten events and changes
+-- of the metadata metrics depend on; the '' row moves for every owner)
+CREATE TABLE IF NOT EXISTS data_versions (
+    owner TEXT PRIMARY KEY,
+    version BIGINT NOT NULL DEFAULT 0
+);
+
+-- Metric alerts (threshold breaches and anomalies in effect, so that outbound webhooks deliver
+-- them when they are triggered and resolved)
+CREATE TABLE IF NOT EXISTS metric_alerts (
+    owner TEXT NOT NULL,
+    key TEXT NOT NULL,
+    triggered_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (owner, key)
+);
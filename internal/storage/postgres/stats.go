@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// Stats returns the events stored for each owner and type, the size of the database and the
+// sizes of its tables and indexes with how often each index was used, from the statistics
+// PostgreSQL collects. Row counts of tables are the planner's estimates, as counting every
+// row of a large events table would take long.
+func (s *postgresStorage) Stats(ctx context.Context) (*storage.Stats, error) {
+	stats := &storage.Stats{Storage: "postgres", Owners: []*storage.OwnerStats{}}
+
+	if err := s.db.QueryRowContext(ctx, `SELECT pg_database_size(current_database())`).Scan(&stats.SizeBytes); err != nil {
+		return nil, fmt.Errorf("failed to get the database size: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner, type, COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM events
+		GROUP BY owner, type
+		ORDER BY owner, type
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var owner, eventType string
+		var count int64
+		var oldest, newest time.Time
+		if err := rows.Scan(&owner, &eventType, &count, &oldest, &newest); err != nil {
+			return nil, err
+		}
+		stats.AddEvents(owner, domain.EventType(eventType), count, oldest, newest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables, err := s.db.QueryContext(ctx, `
+		SELECT relname, GREATEST(n_live_tup, 0), pg_total_relation_size(relid)
+		FROM pg_stat_user_tables
+		WHERE schemaname = current_schema() AND relname = ANY($1)
+	`, pq.Array(schemaTables))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table statistics: %w", err)
+	}
+	defer tables.Close()
+	byName := make(map[string]*storage.TableStats, len(schemaTables))
+	for tables.Next() {
+		t := &storage.TableStats{}
+		if err := tables.Scan(&t.Name, &t.Rows, &t.SizeBytes); err != nil {
+			return nil, err
+		}
+		byName[t.Name] = t
+	}
+	if err := tables.Err(); err != nil {
+		return nil, err
+	}
+	for _, table := range schemaTables {
+		if t, ok := byName[table]; ok {
+			stats.Tables = append(stats.Tables, t)
+		}
+	}
+
+	indexes, err := s.db.QueryContext(ctx, `
+		SELECT indexrelname, relname, pg_relation_size(indexrelid), idx_scan
+		FROM pg_stat_user_indexes
+		WHERE schemaname = current_schema()
+		ORDER BY relname, indexrelname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index statistics: %w", err)
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		i := &storage.IndexStats{}
+		var scans int64
+		if err := indexes.Scan(&i.Name, &i.Table, &i.SizeBytes, &scans); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(schemaTables, i.Table) {
+			continue
+		}
+		i.Scans = &scans
+		stats.Indexes = append(stats.Indexes, i)
+	}
+	return stats, indexes.Err()
+}
@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// Backup writes every row of the tables in storage.BackupTables to w. The rows are read in
+// one transaction, so the backup is consistent even while collections are writing.
+func (s *sqliteStorage) Backup(ctx context.Context, w io.Writer) (*storage.BackupSummary, error) {
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bw, err := storage.NewBackupWriter(w, storage.BackupHeader{SchemaVersion: storage.SchemaVersion(status), Storage: "sqlite"})
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range storage.BackupTables {
+		if err := backupTable(ctx, tx, bw, table); err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", table.Name, err)
+		}
+	}
+	return bw.Summary(), nil
+}
+
+// backupTable writes the rows of table
+func backupTable(ctx context.Context, tx *sql.Tx, bw *storage.BackupWriter, table *storage.BackupTable) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", table.ColumnList(), table.Name))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]any, len(table.Columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		// Timestamps go-sqlite3 doesn't recognize are scanned as text
+		for i, col := range table.Columns {
+			if s, ok := values[i].(string); ok && col.Kind == storage.ColumnTime {
+				t, err := parseSQLiteTime(s)
+				if err != nil {
+					return fmt.Errorf("%s: %w", col.Name, err)
+				}
+				values[i] = t
+			}
+		}
+		if err := bw.WriteRow(table, values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Restore replaces the rows of the tables in storage.BackupTables with those of the backup
+// read from r in one transaction, so a backup that fails to load leaves the data as it was
+func (s *sqliteStorage) Restore(ctx context.Context, r io.Reader) (*storage.BackupSummary, error) {
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version := storage.SchemaVersion(status)
+
+	tx, err := s.beginWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmts := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, stmt := range stmts {
+			stmt.Close()
+		}
+	}()
+
+	// The tables are emptied once the header shows the backup can be restored
+	check := func(header storage.BackupHeader) error {
+		if header.SchemaVersion > version {
+			return fmt.Errorf("the backup has schema version %d but the database has %d: upgrade github-metrics or run \"github-metrics migrate up\"", header.SchemaVersion, version)
+		}
+		for _, table := range slices.Backward(storage.BackupTables) {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table.Name); err != nil {
+				return fmt.Errorf("failed to empty %s: %w", table.Name, err)
+			}
+		}
+		return nil
+	}
+	insert := func(table *storage.BackupTable, values []any) error {
+		stmt, ok := stmts[table.Name]
+		if !ok {
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(table.Columns)), ", ")
+			var err error
+			stmt, err = tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table.Name, table.ColumnList(), placeholders))
+			if err != nil {
+				return err
+			}
+			stmts[table.Name] = stmt
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return fmt.Errorf("failed to restore a row of %s: %w", table.Name, err)
+		}
+		return nil
+	}
+
+	summary, err := storage.ReadBackup(r, check, insert)
+	if err != nil {
+		return nil, err
+	}
+	if err := bumpDataVersion(ctx, tx, ""); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,505 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// migration is a versioned change of the schema, applied and reverted in a transaction.
+// The migrations released before the schema was versioned only create what is missing, so
+// databases created back then are brought under version control by applying them.
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, tx *sql.Tx) error
+	down    string
+}
+
+// migrations are the schema migrations in the order they are applied
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "events, repositories and members",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS events (
+			id TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			owner TEXT NOT NULL,
+			owner_type TEXT NOT NULL DEFAULT 'organization',
+			repo TEXT NOT NULL,
+			member TEXT NOT NULL,
+			timestamp TIMESTAMP NOT NULL,
+			data TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_events_owner_repo ON events(owner, repo);
+		CREATE INDEX IF NOT EXISTS idx_events_member ON events(member);
+		CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_events_type ON events(type);
+		CREATE INDEX IF NOT EXISTS idx_events_owner_type_timestamp ON events(owner, type, timestamp);
+		CREATE INDEX IF NOT EXISTS idx_events_owner_type ON events(owner_type);
+		CREATE INDEX IF NOT EXISTS idx_events_owner_created_at ON events(owner, created_at);
+
+		CREATE TABLE IF NOT EXISTS repositories (
+			owner TEXT NOT NULL,
+			owner_type TEXT NOT NULL DEFAULT 'organization',
+			name TEXT NOT NULL,
+			full_name TEXT NOT NULL,
+			is_private INTEGER NOT NULL,
+			last_synced_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner, name)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_repositories_owner ON repositories(owner);
+		CREATE INDEX IF NOT EXISTS idx_repositories_owner_type ON repositories(owner_type);
+
+		CREATE TABLE IF NOT EXISTS members (
+			owner TEXT NOT NULL,
+			owner_type TEXT NOT NULL DEFAULT 'organization',
+			username TEXT NOT NULL,
+			display_name TEXT,
+			last_synced_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner, username)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_members_owner ON members(owner);
+		CREATE INDEX IF NOT EXISTS idx_members_owner_type ON members(owner_type);
+		`),
+		down: `
+		DROP TABLE IF EXISTS members;
+		DROP TABLE IF EXISTS repositories;
+		DROP TABLE IF EXISTS events;
+		`,
+	},
+	{
+		version: 2,
+		name:    "teams",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS teams (
+			owner TEXT NOT NULL,
+			slug TEXT NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			last_synced_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner, slug)
+		);
+
+		CREATE TABLE IF NOT EXISTS team_members (
+			owner TEXT NOT NULL,
+			team_slug TEXT NOT NULL,
+			username TEXT NOT NULL,
+			PRIMARY KEY (owner, team_slug, username)
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS team_members;
+		DROP TABLE IF EXISTS teams;
+		`,
+	},
+	{
+		version: 3,
+		name:    "collection batches",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS collection_batches (
+			id TEXT PRIMARY KEY,
+			mode TEXT NOT NULL,
+			owner TEXT NOT NULL,
+			start_date TIMESTAMP NOT NULL,
+			end_date TIMESTAMP NOT NULL,
+			status TEXT NOT NULL DEFAULT 'in_progress',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_collection_batches_owner ON collection_batches(owner);
+		CREATE INDEX IF NOT EXISTS idx_collection_batches_status ON collection_batches(status);
+		CREATE INDEX IF NOT EXISTS idx_collection_batches_mode_owner_dates ON collection_batches(mode, owner, start_date, end_date);
+
+		CREATE TABLE IF NOT EXISTS collection_batch_repos (
+			batch_id TEXT NOT NULL,
+			repo TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			events INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (batch_id, repo)
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS collection_batch_repos;
+		DROP TABLE IF EXISTS collection_batches;
+		`,
+	},
+	{
+		version: 4,
+		name:    "API keys",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			var grants int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'api_key_grants'`).Scan(&grants); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS api_keys (
+				name TEXT PRIMARY KEY,
+				key_hash TEXT NOT NULL UNIQUE,
+				scope TEXT NOT NULL DEFAULT 'read',
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS api_key_grants (
+				key_name TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				role TEXT NOT NULL DEFAULT 'viewer',
+				PRIMARY KEY (key_name, owner)
+			);
+			`)
+			if err != nil || grants > 0 {
+				return err
+			}
+			// Read keys created before grants existed could view every owner; without a grant
+			// they would be refused everywhere
+			_, err = tx.ExecContext(ctx, `
+			INSERT INTO api_key_grants (key_name, owner, role)
+			SELECT name, '*', 'viewer' FROM api_keys WHERE scope != 'admin'
+			`)
+			return err
+		},
+		down: `
+		DROP TABLE IF EXISTS api_key_grants;
+		DROP TABLE IF EXISTS api_keys;
+		`,
+	},
+	{
+		version: 5,
+		name:    "workspaces",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS workspaces (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS workspace_owners (
+				owner TEXT PRIMARY KEY,
+				workspace_id TEXT NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_workspace_owners_workspace ON workspace_owners(workspace_id);
+			`)
+			if err != nil {
+				return err
+			}
+			return addColumnIfMissing(ctx, tx, "api_keys", "workspace", "TEXT NOT NULL DEFAULT ''")
+		},
+		down: `
+		ALTER TABLE api_keys DROP COLUMN workspace;
+		DROP TABLE IF EXISTS workspace_owners;
+		DROP TABLE IF EXISTS workspaces;
+		`,
+	},
+	{
+		version: 6,
+		name:    "audit log",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY,
+			time TIMESTAMP NOT NULL,
+			principal TEXT NOT NULL,
+			owner TEXT NOT NULL DEFAULT '',
+			method TEXT NOT NULL,
+			route TEXT NOT NULL DEFAULT '',
+			path TEXT NOT NULL,
+			params TEXT NOT NULL DEFAULT '',
+			status INTEGER NOT NULL,
+			client_ip TEXT NOT NULL DEFAULT '',
+			request_id TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log(time);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_owner_time ON audit_log(owner, time);
+		`),
+		down: `
+		DROP TABLE IF EXISTS audit_log;
+		`,
+	},
+	{
+		version: 7,
+		name:    "member aliases",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS member_aliases (
+			owner TEXT NOT NULL,
+			alias TEXT NOT NULL,
+			member TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner, alias)
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS member_aliases;
+		`,
+	},
+	{
+		version: 8,
+		name:    "team member roles",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			return addColumnIfMissing(ctx, tx, "team_members", "role", "TEXT NOT NULL DEFAULT 'member'")
+		},
+		down: `
+		ALTER TABLE team_members DROP COLUMN role;
+		`,
+	},
+	{
+		version: 9,
+		name:    "repository list cache",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS repository_lists (
+			owner TEXT NOT NULL,
+			owner_type TEXT NOT NULL,
+			repos TEXT NOT NULL,
+			fetched_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (owner, owner_type)
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS repository_lists;
+		`,
+	},
+	{
+		version: 10,
+		name:    "deploy definitions",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS deploy_definitions (
+			owner TEXT NOT NULL,
+			repo TEXT NOT NULL,
+			source TEXT NOT NULL,
+			pattern TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (owner, repo)
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS deploy_definitions;
+		`,
+	},
+	{
+		version: 11,
+		name:    "data versions",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS data_versions (
+			owner TEXT PRIMARY KEY,
+			version INTEGER NOT NULL DEFAULT 0
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS data_versions;
+		`,
+	},
+	{
+		version: 12,
+		name:    "metric alerts",
+		up: execMigration(`
+		CREATE TABLE IF NOT EXISTS metric_alerts (
+			owner TEXT NOT NULL,
+			key TEXT NOT NULL,
+			triggered_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (owner, key)
+		);
+		`),
+		down: `
+		DROP TABLE IF EXISTS metric_alerts;
+		`,
+	},
+}
+
+// execMigration returns a migration step running query
+func execMigration(query string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+}
+
+// addColumnIfMissing adds a column to an existing table created by an older version
+func addColumnIfMissing(ctx context.Context, tx *sql.Tx, table, column, definition string) error {
+	var count int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// Migrate applies the schema migrations that haven't been applied yet, oldest first
+func (s *sqliteStorage) Migrate(ctx context.Context) error {
+	// Check if migration is needed (check if old 'org' column exists)
+	var tableInfo string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT sql FROM sqlite_master
+		WHERE type='table' AND name='events' AND sql LIKE '%org TEXT%'
+	`).Scan(&tableInfo)
+	if err == nil {
+		// Old schema exists, need to migrate
+		if err := s.migrateFromOrgToOwner(ctx); err != nil {
+			return fmt.Errorf("failed to migrate from org to owner: %w", err)
+		}
+	}
+
+	if _, err := s.exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+		err := s.inTx(ctx, func(tx *sql.Tx) error {
+			// Another process sharing the file may have applied it while this one waited for
+			// the write lock
+			var done int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, m.version).Scan(&done); err != nil || done > 0 {
+				return err
+			}
+			if err := m.up(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts the last steps applied schema migrations, newest first
+func (s *sqliteStorage) MigrateDown(ctx context.Context, steps int) error {
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	for _, v := range versions[:min(steps, len(versions))] {
+		m, ok := findMigration(v)
+		if !ok {
+			return fmt.Errorf("migration %d is unknown to this version of github-metrics", v)
+		}
+		err := s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, m.down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("reverting migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// MigrationStatus lists every schema migration, applied or not, by version. Applied
+// migrations unknown to this version are listed with their recorded name.
+func (s *sqliteStorage) MigrationStatus(ctx context.Context) ([]*storage.Migration, error) {
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]*storage.Migration, 0, len(migrations))
+	for _, m := range migrations {
+		entry := &storage.Migration{Version: m.version, Name: m.name}
+		if a, ok := applied[m.version]; ok {
+			entry.AppliedAt = a.AppliedAt
+			delete(applied, m.version)
+		}
+		status = append(status, entry)
+	}
+	for _, m := range applied {
+		status = append(status, m)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+// appliedMigrations returns the migrations recorded in schema_migrations by version; none
+// when the table doesn't exist yet
+func (s *sqliteStorage) appliedMigrations(ctx context.Context) (map[int]*storage.Migration, error) {
+	applied := make(map[int]*storage.Migration)
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return applied, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m := &storage.Migration{}
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[m.Version] = m
+	}
+	return applied, rows.Err()
+}
+
+// inTx runs fn in a transaction, committed when fn succeeds
+func (s *sqliteStorage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.beginWrite(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findMigration returns the migration with version
+func findMigration(version int) (migration, bool) {
+	for _, m := range migrations {
+		if m.version == version {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/internal/storage"
+)
+
+// Stats returns the events stored for each owner and type, the size of the database file
+// and the sizes of its tables and indexes. The sizes of tables and indexes come from the
+// dbstat virtual table, which go-sqlite3 only has when built with
+// CGO_CFLAGS=-DSQLITE_ENABLE_DBSTAT_VTAB; without it they are left out.
+func (s *sqliteStorage) Stats(ctx context.Context) (*storage.Stats, error) {
+	stats := &storage.Stats{Storage: "sqlite", Owners: []*storage.OwnerStats{}}
+
+	var pageCount, pageSize int64
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return nil, fmt.Errorf("failed to get the database size: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return nil, fmt.Errorf("failed to get the database size: %w", err)
+	}
+	stats.SizeBytes = pageCount * pageSize
+
+	// SQLite returns MIN() and MAX() of a TIMESTAMP column as text, so scan them as strings
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner, type, COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM events
+		GROUP BY owner, type
+		ORDER BY owner, type
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var owner, eventType, oldest, newest string
+		var count int64
+		if err := rows.Scan(&owner, &eventType, &count, &oldest, &newest); err != nil {
+			return nil, err
+		}
+		first, err := parseSQLiteTime(oldest)
+		if err != nil {
+			return nil, err
+		}
+		last, err := parseSQLiteTime(newest)
+		if err != nil {
+			return nil, err
+		}
+		stats.AddEvents(owner, domain.EventType(eventType), count, first, last)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sizes := s.objectSizes(ctx)
+	for _, table := range schemaTables {
+		t := &storage.TableStats{Name: table, SizeBytes: sizes[table]}
+		if err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&t.Rows); err != nil {
+			return nil, fmt.Errorf("failed to count the rows of %s: %w", table, err)
+		}
+		stats.Tables = append(stats.Tables, t)
+	}
+
+	indexes, err := s.db.QueryContext(ctx, `SELECT name, tbl_name FROM sqlite_master WHERE type = 'index' ORDER BY tbl_name, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		i := &storage.IndexStats{}
+		if err := indexes.Scan(&i.Name, &i.Table); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(schemaTables, i.Table) {
+			continue
+		}
+		i.SizeBytes = sizes[i.Name]
+		stats.Indexes = append(stats.Indexes, i)
+		// A table's size includes its indexes, as on PostgreSQL
+		if t := stats.Tables[slices.Index(schemaTables, i.Table)]; t.SizeBytes > 0 {
+			t.SizeBytes += i.SizeBytes
+		}
+	}
+	return stats, indexes.Err()
+}
+
+// objectSizes returns the bytes each table and index takes in the database file, or nil
+// when go-sqlite3 was built without the dbstat virtual table
+func (s *sqliteStorage) objectSizes(ctx context.Context) map[string]int64 {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, SUM(pgsize) FROM dbstat GROUP BY name`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var size int64
+		if err := rows.Scan(&name, &size); err != nil {
+			return nil
+		}
+		sizes[name] = size
+	}
+	if rows.Err() != nil {
+		return nil
+	}
+	return sizes
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// Stats describes what a database holds and how much space it takes, for capacity planning
+type Stats struct {
+	Storage     string        `json:"storage"`    // "sqlite" or "postgres"
+	SizeBytes   int64         `json:"size_bytes"` // the whole database, indexes included
+	Events      int64         `json:"events"`
+	OldestEvent *time.Time    `json:"oldest_event,omitempty"` // nil without events
+	NewestEvent *time.Time    `json:"newest_event,omitempty"`
+	Owners      []*OwnerStats `json:"owners"`  // by owner name
+	Tables      []*TableStats `json:"tables"`  // the tables of the schema
+	Indexes     []*IndexStats `json:"indexes"` // by table, then index name
+}
+
+// OwnerStats are the events stored for an owner
+type OwnerStats struct {
+	Owner       string                     `json:"owner"`
+	Events      int64                      `json:"events"`
+	ByType      map[domain.EventType]int64 `json:"by_type"`
+	OldestEvent time.Time                  `json:"oldest_event"`
+	NewestEvent time.Time                  `json:"newest_event"`
+}
+
+// TableStats describes a table of the schema
+type TableStats struct {
+	Name string `json:"name"`
+	// Rows is exact on SQLite and the planner's estimate on PostgreSQL, which is kept up to
+	// date by autovacuum
+	Rows      int64 `json:"rows"`
+	SizeBytes int64 `json:"size_bytes,omitempty"` // with its indexes; 0 when SQLite can't tell (without the dbstat table)
+}
+
+// IndexStats describes an index of the schema
+type IndexStats struct {
+	Name      string `json:"name"`
+	Table     string `json:"table"`
+	SizeBytes int64  `json:"size_bytes,omitempty"` // 0 when SQLite can't tell (without the dbstat table)
+	Scans     *int64 `json:"scans,omitempty"`      // times the index was used since the statistics were reset; PostgreSQL only
+}
+
+// AddEvents records count events of an owner of one type, spanning oldest to newest. Owners
+// are added in the order they are first seen.
+func (s *Stats) AddEvents(owner string, eventType domain.EventType, count int64, oldest, newest time.Time) {
+	var o *OwnerStats
+	if n := len(s.Owners); n > 0 && s.Owners[n-1].Owner == owner {
+		o = s.Owners[n-1]
+	} else {
+		o = &OwnerStats{Owner: owner, ByType: make(map[domain.EventType]int64), OldestEvent: oldest, NewestEvent: newest}
+		s.Owners = append(s.Owners, o)
+	}
+	o.Events += count
+	o.ByType[eventType] += count
+	if oldest.Before(o.OldestEvent) {
+		o.OldestEvent = oldest
+	}
+	if newest.After(o.NewestEvent) {
+		o.NewestEvent = newest
+	}
+
+	s.Events += count
+	if s.OldestEvent == nil || oldest.Before(*s.OldestEvent) {
+		s.OldestEvent = &oldest
+	}
+	if s.NewestEvent == nil || newest.After(*s.NewestEvent) {
+		s.NewestEvent = &newest
+	}
+}
@@ -0,0 +1,193 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/aggregator"
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// tracedAggregator starts a span around each aggregation, so that request traces show
+// which aggregation the storage queries belong to. Methods without a context are passed
+// through untraced.
+type tracedAggregator struct {
+	aggregator.Aggregator
+}
+
+// InstrumentAggregator wraps an aggregator so that its methods are traced
+func InstrumentAggregator(agg aggregator.Aggregator) aggregator.Aggregator {
+	return &tracedAggregator{Aggregator: agg}
+}
+
+// start starts a span for an aggregator method on owner's data
+func start(ctx context.Context, method, owner string, timeRange domain.TimeRange, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String("metrics.owner", owner),
+		attribute.String("metrics.start", timeRange.Start.Format("2006-01-02")),
+		attribute.String("metrics.end", timeRange.End.Format("2006-01-02")),
+		attribute.String("metrics.granularity", timeRange.Granularity),
+	)
+	return Tracer().Start(ctx, "aggregator."+method, trace.WithAttributes(attrs...))
+}
+
+// end records err on the span, if any, and ends it
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (a *tracedAggregator) AggregateOrgMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (result *domain.OrgMetrics, err error) {
+	ctx, span := start(ctx, "AggregateOrgMetrics", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.AggregateOrgMetrics(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) AggregateMemberMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) (result *domain.MemberMetrics, err error) {
+	ctx, span := start(ctx, "AggregateMemberMetrics", org, timeRange, attribute.String("metrics.member", member))
+	defer func() { end(span, err) }()
+	return a.Aggregator.AggregateMemberMetrics(ctx, org, member, timeRange)
+}
+
+func (a *tracedAggregator) AggregateRepoMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (result *domain.RepoMetrics, err error) {
+	ctx, span := start(ctx, "AggregateRepoMetrics", org, timeRange, attribute.String("metrics.repo", repo))
+	defer func() { end(span, err) }()
+	return a.Aggregator.AggregateRepoMetrics(ctx, org, repo, timeRange)
+}
+
+func (a *tracedAggregator) GetMembersMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (result []*domain.MemberMetrics, err error) {
+	ctx, span := start(ctx, "GetMembersMetrics", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetMembersMetrics(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) GetRepoMembersMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (result []*domain.MemberMetrics, err error) {
+	ctx, span := start(ctx, "GetRepoMembersMetrics", org, timeRange, attribute.String("metrics.repo", repo))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetRepoMembersMetrics(ctx, org, repo, timeRange)
+}
+
+func (a *tracedAggregator) GetReposMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (result []*domain.RepoMetrics, err error) {
+	ctx, span := start(ctx, "GetReposMetrics", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetReposMetrics(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) GetMemberReposMetrics(ctx context.Context, org, member string, timeRange domain.TimeRange) (result []*domain.RepoMetrics, err error) {
+	ctx, span := start(ctx, "GetMemberReposMetrics", org, timeRange, attribute.String("metrics.member", member))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetMemberReposMetrics(ctx, org, member, timeRange)
+}
+
+func (a *tracedAggregator) GetTimeSeriesMetrics(ctx context.Context, org string, metricType domain.MetricType, timeRange domain.TimeRange) (result *domain.TimeSeriesData, err error) {
+	ctx, span := start(ctx, "GetTimeSeriesMetrics", org, timeRange, attribute.String("metrics.type", string(metricType)))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetTimeSeriesMetrics(ctx, org, metricType, timeRange)
+}
+
+func (a *tracedAggregator) GetHeatmap(ctx context.Context, org string, query domain.HeatmapQuery, timeRange domain.TimeRange) (result *domain.Heatmap, err error) {
+	ctx, span := start(ctx, "GetHeatmap", org, timeRange,
+		attribute.String("metrics.repo", query.Repo),
+		attribute.String("metrics.member", query.Member),
+		attribute.String("metrics.type", string(query.MetricType)))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetHeatmap(ctx, org, query, timeRange)
+}
+
+func (a *tracedAggregator) GetMemberRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) (result []*domain.MemberRanking, err error) {
+	ctx, span := start(ctx, "GetMemberRanking", org, timeRange, attribute.String("metrics.ranking_type", string(rankingType)), attribute.Int("metrics.limit", limit))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetMemberRanking(ctx, org, rankingType, timeRange, limit)
+}
+
+func (a *tracedAggregator) GetRepoRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) (result []*domain.RepoRanking, err error) {
+	ctx, span := start(ctx, "GetRepoRanking", org, timeRange, attribute.String("metrics.ranking_type", string(rankingType)), attribute.Int("metrics.limit", limit))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetRepoRanking(ctx, org, rankingType, timeRange, limit)
+}
+
+func (a *tracedAggregator) GetOrgTimeSeries(ctx context.Context, org string, timeRange domain.TimeRange) (result *domain.DetailedTimeSeriesData, err error) {
+	ctx, span := start(ctx, "GetOrgTimeSeries", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetOrgTimeSeries(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) GetRepoTimeSeries(ctx context.Context, org, repo string, timeRange domain.TimeRange) (result *domain.DetailedTimeSeriesData, err error) {
+	ctx, span := start(ctx, "GetRepoTimeSeries", org, timeRange, attribute.String("metrics.repo", repo))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetRepoTimeSeries(ctx, org, repo, timeRange)
+}
+
+func (a *tracedAggregator) GetMemberTimeSeries(ctx context.Context, org, member string, timeRange domain.TimeRange) (result *domain.DetailedTimeSeriesData, err error) {
+	ctx, span := start(ctx, "GetMemberTimeSeries", org, timeRange, attribute.String("metrics.member", member))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetMemberTimeSeries(ctx, org, member, timeRange)
+}
+
+func (a *tracedAggregator) GetTeamsMetrics(ctx context.Context, org string, timeRange domain.TimeRange) (result []*domain.TeamMetrics, err error) {
+	ctx, span := start(ctx, "GetTeamsMetrics", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetTeamsMetrics(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) GetTeamMetrics(ctx context.Context, org, team string, timeRange domain.TimeRange) (result *domain.TeamMetrics, err error) {
+	ctx, span := start(ctx, "GetTeamMetrics", org, timeRange, attribute.String("metrics.team", team))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetTeamMetrics(ctx, org, team, timeRange)
+}
+
+func (a *tracedAggregator) GetTeamTimeSeries(ctx context.Context, org, team string, timeRange domain.TimeRange) (result *domain.DetailedTimeSeriesData, err error) {
+	ctx, span := start(ctx, "GetTeamTimeSeries", org, timeRange, attribute.String("metrics.team", team))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetTeamTimeSeries(ctx, org, team, timeRange)
+}
+
+func (a *tracedAggregator) GetTeamRanking(ctx context.Context, org string, rankingType domain.RankingType, timeRange domain.TimeRange, limit int) (result []*domain.TeamRanking, err error) {
+	ctx, span := start(ctx, "GetTeamRanking", org, timeRange, attribute.String("metrics.ranking_type", string(rankingType)), attribute.Int("metrics.limit", limit))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetTeamRanking(ctx, org, rankingType, timeRange, limit)
+}
+
+func (a *tracedAggregator) Compare(ctx context.Context, org string, repos, members []string, timeRange domain.TimeRange) (result *domain.Comparison, err error) {
+	ctx, span := start(ctx, "Compare", org, timeRange, attribute.StringSlice("metrics.repos", repos), attribute.StringSlice("metrics.members", members))
+	defer func() { end(span, err) }()
+	return a.Aggregator.Compare(ctx, org, repos, members, timeRange)
+}
+
+func (a *tracedAggregator) GetRepoCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) (result []*domain.CommitClassification, err error) {
+	ctx, span := start(ctx, "GetRepoCommitClassification", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetRepoCommitClassification(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) GetMemberCommitClassification(ctx context.Context, org string, timeRange domain.TimeRange) (result []*domain.CommitClassification, err error) {
+	ctx, span := start(ctx, "GetMemberCommitClassification", org, timeRange)
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetMemberCommitClassification(ctx, org, timeRange)
+}
+
+func (a *tracedAggregator) GetDORAMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (result *domain.DORAMetrics, err error) {
+	ctx, span := start(ctx, "GetDORAMetrics", org, timeRange, attribute.String("metrics.repo", repo))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetDORAMetrics(ctx, org, repo, timeRange)
+}
+
+func (a *tracedAggregator) GetComponentMetrics(ctx context.Context, org, repo string, timeRange domain.TimeRange) (result *domain.RepoComponentMetrics, err error) {
+	ctx, span := start(ctx, "GetComponentMetrics", org, timeRange, attribute.String("metrics.repo", repo))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetComponentMetrics(ctx, org, repo, timeRange)
+}
+
+func (a *tracedAggregator) GetRepoActivity(ctx context.Context, org string, query domain.RepoActivityQuery) (result *domain.RepoActivityReport, err error) {
+	recent, baseline := query.Windows()
+	ctx, span := start(ctx, "GetRepoActivity", org, domain.TimeRange{Start: baseline.Start, End: recent.End},
+		attribute.String("metrics.recent_start", recent.Start.Format("2006-01-02")))
+	defer func() { end(span, err) }()
+	return a.Aggregator.GetRepoActivity(ctx, org, query)
+}
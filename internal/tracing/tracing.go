@@ -0,0 +1,70 @@
+// Package tracing configures OpenTelemetry tracing for the API server
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this module
+const instrumentationName = "github.com/kurihiro0119/github-activity-metrics"
+
+// Config configures the OTLP trace exporter. The endpoint, headers and TLS settings are
+// read by the exporter from the standard OTEL_EXPORTER_OTLP_* environment variables.
+type Config struct {
+	ServiceName string
+	Protocol    string  // "grpc" or "http/protobuf"
+	SampleRatio float64 // fraction of root spans sampled, 0 to 1
+}
+
+// Setup installs a global tracer provider exporting spans over OTLP and the W3C trace
+// context propagator. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var client otlptrace.Client
+	switch strings.ToLower(cfg.Protocol) {
+	case "", "grpc":
+		client = otlptracegrpc.NewClient()
+	case "http/protobuf", "http":
+		client = otlptracehttp.NewClient()
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q: must be 'grpc' or 'http/protobuf'", cfg.Protocol)
+	}
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the tracer used for the module's own spans. Until Setup is called it
+// is a no-op tracer.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
@@ -0,0 +1,175 @@
+// Package version identifies the running build and looks up newer releases
+package version
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// Build information, set at link time, e.g.
+//
+//	go build -ldflags "-X github.com/kurihiro0119/github-activity-metrics/internal/version.Version=v1.2.0"
+//
+// Builds without them fall back to the module version and VCS stamp recorded by the Go toolchain.
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// ReleaseOwner and ReleaseRepo are the GitHub repository releases are published in
+const (
+	ReleaseOwner = "kurihiro0119"
+	ReleaseRepo  = "github-activity-metrics"
+)
+
+// Info describes a build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a working tree with uncommitted changes
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the information of the running build
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		build = &debug.BuildInfo{}
+	}
+	if info.Version == "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	// The VCS stamp describes the working tree go build ran in, unless the commit was given
+	for _, s := range build.Settings {
+		switch {
+		case Commit != "":
+		case s.Key == "vcs.revision":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.Date == "":
+			info.Date = s.Value
+		case s.Key == "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// String formats the build information on one line
+func (i Info) String() string {
+	s := i.Version
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if i.Modified {
+			commit += "-dirty"
+		}
+		s += " (" + commit
+		if i.Date != "" {
+			s += ", " + i.Date
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s %s %s", s, i.GoVersion, i.Platform)
+}
+
+// Release is a published release
+type Release struct {
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// LatestRelease looks up the latest release on GitHub. client defaults to an unauthenticated
+// github.com client.
+func LatestRelease(ctx context.Context, client *github.Client) (*Release, error) {
+	if client == nil {
+		client = github.NewClient(&http.Client{Timeout: 10 * time.Second})
+	}
+	release, _, err := client.Repositories.GetLatestRelease(ctx, ReleaseOwner, ReleaseRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the latest release: %w", err)
+	}
+	return &Release{
+		Version:     release.GetTagName(),
+		URL:         release.GetHTMLURL(),
+		PublishedAt: release.GetPublishedAt().Time,
+	}, nil
+}
+
+// Newer reports whether version latest is newer than current. ok is false when either isn't a
+// semantic version such as v1.2.3, as with development builds.
+func Newer(latest, current string) (newer, ok bool) {
+	l, lok := parseSemver(latest)
+	c, cok := parseSemver(current)
+	if !lok || !cok {
+		return false, false
+	}
+	for i := 0; i < 3; i++ {
+		if l.core[i] != c.core[i] {
+			return l.core[i] > c.core[i], true
+		}
+	}
+	// A pre-release precedes its release; pre-releases of the same version compare as strings
+	switch {
+	case l.pre == c.pre:
+		return false, true
+	case l.pre == "":
+		return true, true
+	case c.pre == "":
+		return false, true
+	}
+	return l.pre > c.pre, true
+}
+
+// semver is a parsed semantic version; build metadata is ignored
+type semver struct {
+	core [3]int
+	pre  string
+}
+
+// parseSemver parses a version such as v1.2.3 or 1.2.3-rc.1; a missing minor or patch is 0
+func parseSemver(v string) (semver, bool) {
+	var s semver
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, s.pre = v[:i], v[i+1:]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return s, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return s, false
+		}
+		s.core[i] = n
+	}
+	return s, true
+}
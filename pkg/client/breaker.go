@@ -0,0 +1,86 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending a request while the circuit breaker of
+// WithCircuitBreaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open: the API is failing")
+
+// WithCircuitBreaker makes the client fail fast while the API is down: after threshold
+// requests in a row failed with a network error or a server error (5xx), requests return
+// ErrCircuitOpen at once for cooldown. After that, one request is let through; if it
+// succeeds the client goes back to normal, otherwise it fails fast for another cooldown.
+//
+// Retries of WithRetry count as requests, so a retried request stops early once the breaker
+// opens.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *clientOptions) {
+		o.breaker = &circuitBreaker{threshold: max(threshold, 1), cooldown: cooldown}
+	}
+}
+
+// circuitBreaker counts the consecutive failures of a client's requests; a nil breaker lets
+// every request through
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int       // in a row
+	openedAt time.Time // zero while closed
+	probing  bool      // the request let through after the cooldown hasn't finished
+}
+
+// allow returns ErrCircuitOpen if a request must not be sent
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record takes the result of a request that allow let through
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !serverFailure(err) {
+		// Any response but a server error, even a 404, shows the API is up
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold || !b.openedAt.IsZero() {
+		b.openedAt = time.Now()
+	}
+}
+
+// serverFailure reports whether a request failed because the API is unreachable or broken
+func serverFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return retryable(err)
+}
@@ -1,11 +1,13 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
@@ -15,15 +17,40 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	timeout    time.Duration // of each request; 0 for none
+	apiKey     string
+	userAgent  string
+	retries    int           // of a failed request
+	backoff    time.Duration // before the first retry
+	breaker    *circuitBreaker
+	onRequest  []func(req *http.Request, attempt int)
+	onResponse []func(info ResponseInfo)
+	err        error // of the options, returned by every request
 }
 
-// NewClient creates a new API client
-func NewClient(baseURL string) *Client {
+// NewClient creates a new API client for the server at baseURL, such as
+// http://localhost:8080, configured with opts; NewClient(baseURL) has the defaults
+func NewClient(baseURL string, opts ...Option) *Client {
+	o := &clientOptions{timeout: DefaultTimeout, userAgent: DefaultUserAgent, backoff: DefaultRetryBackoff}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.backoff <= 0 {
+		o.backoff = DefaultRetryBackoff
+	}
+	httpClient, err := newHTTPClient(o)
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		err:        err,
+		timeout:    o.timeout,
+		apiKey:     o.apiKey,
+		userAgent:  o.userAgent,
+		retries:    o.retries,
+		backoff:    o.backoff,
+		breaker:    o.breaker,
+		onRequest:  o.onRequest,
+		onResponse: o.onResponse,
 	}
 }
 
@@ -112,6 +139,76 @@ func (c *Client) GetTimeSeriesMetrics(org string, metricType string, start, end
 	return response.Data, nil
 }
 
+// GetOrgTimeSeriesDetailed retrieves every metric of an organization per granularity period
+// ("day", "week" or "month")
+func (c *Client) GetOrgTimeSeriesDetailed(org string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	path := fmt.Sprintf("/api/v1/orgs/%s/metrics/timeseries/detailed", org)
+	return c.getDetailedTimeSeries(path, start, end, granularity)
+}
+
+// GetRepoTimeSeriesDetailed retrieves every metric of a repository per granularity period
+func (c *Client) GetRepoTimeSeriesDetailed(org, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	path := fmt.Sprintf("/api/v1/orgs/%s/repos/%s/metrics/timeseries", org, repo)
+	return c.getDetailedTimeSeries(path, start, end, granularity)
+}
+
+// GetMemberTimeSeriesDetailed retrieves every metric of a member per granularity period
+func (c *Client) GetMemberTimeSeriesDetailed(org, member string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	path := fmt.Sprintf("/api/v1/orgs/%s/members/%s/metrics/timeseries", org, member)
+	return c.getDetailedTimeSeries(path, start, end, granularity)
+}
+
+func (c *Client) getDetailedTimeSeries(path string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	params := c.buildTimeParams(start, end, granularity)
+
+	var response struct {
+		Data *domain.DetailedTimeSeriesData `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// Ranking types of GetMemberRanking and GetRepoRanking, usable outside this module, which
+// can't import the domain package
+const (
+	RankingCommits     = domain.RankingTypeCommits
+	RankingPRs         = domain.RankingTypePRs
+	RankingCodeChanges = domain.RankingTypeCodeChanges
+	RankingDeploys     = domain.RankingTypeDeploys
+)
+
+// GetMemberRanking retrieves the top members by rankingType, at most limit of them (the
+// server's default, 10, if limit is 0)
+func (c *Client) GetMemberRanking(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error) {
+	path := fmt.Sprintf("/api/v1/orgs/%s/rankings/members/%s", org, rankingType)
+	params := c.buildRankingParams(limit, start, end)
+
+	var response struct {
+		Data []*domain.MemberRanking `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetRepoRanking retrieves the top repositories by rankingType, at most limit of them (the
+// server's default, 10, if limit is 0)
+func (c *Client) GetRepoRanking(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error) {
+	path := fmt.Sprintf("/api/v1/orgs/%s/rankings/repos/%s", org, rankingType)
+	params := c.buildRankingParams(limit, start, end)
+
+	var response struct {
+		Data []*domain.RepoRanking `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
 // HealthCheck checks if the API is healthy
 func (c *Client) HealthCheck() error {
 	var response struct {
@@ -140,7 +237,18 @@ func (c *Client) buildTimeParams(start, end time.Time, granularity string) url.V
 	return params
 }
 
+func (c *Client) buildRankingParams(limit int, start, end time.Time) url.Values {
+	params := c.buildTimeParams(start, end, "")
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	return params
+}
+
 func (c *Client) get(path string, params url.Values, result interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
 	u, err := url.Parse(c.baseURL + path)
 	if err != nil {
 		return err
@@ -149,16 +257,89 @@ func (c *Client) get(path string, params url.Values, result interface{}) error {
 		u.RawQuery = params.Encode()
 	}
 
-	resp, err := c.httpClient.Get(u.String())
+	for attempt := 0; ; attempt++ {
+		if err := c.breaker.allow(); err != nil {
+			return err
+		}
+		err := c.getOnce(u.String(), attempt, result)
+		c.breaker.record(err)
+		if err == nil || attempt >= c.retries || !retryable(err) {
+			return err
+		}
+		time.Sleep(c.retryWait(attempt, err))
+	}
+}
+
+func (c *Client) getOnce(u string, attempt int, result interface{}) (err error) {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := c.newRequest(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	c.beforeRequest(req, attempt)
+	started := time.Now()
+	statusCode := 0
+	defer func() {
+		c.afterResponse(ResponseInfo{Request: req, Attempt: attempt, StatusCode: statusCode, Duration: time.Since(started), Err: err})
+	}()
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	statusCode = resp.StatusCode
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+		return newAPIError(resp)
 	}
 
 	return json.NewDecoder(resp.Body).Decode(result)
 }
+
+// newRequest returns a GET request of u with the headers of the client's options
+func (c *Client) newRequest(ctx context.Context, u string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// retryable reports whether a request that failed with err may succeed if sent again: the
+// server was unreachable, failed or rate limited the client
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	// Errors of sending the request, unlike those of decoding the response
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryWait returns how long to wait before retrying a request that failed attempt+1 times,
+// the last time with err
+func (c *Client) retryWait(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	wait := c.backoff
+	for i := 0; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
+}
@@ -0,0 +1,342 @@
+// Package clienttest provides a stand-in for the API client, to unit-test code that uses a
+// client.MetricsClient without a running server.
+package clienttest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+	"github.com/kurihiro0119/github-activity-metrics/pkg/client"
+)
+
+// Call is a method call received by a Mock
+type Call struct {
+	Method string
+	Args   []interface{} // in the order of the method's parameters
+}
+
+// Mock is a client.MetricsClient whose methods call the function of the matching field, e.g.
+// GetOrgMetrics calls GetOrgMetricsFunc:
+//
+//	mock := &clienttest.Mock{
+//		GetOrgMetricsFunc: func(org string, start, end time.Time, granularity string) (*client.OrgMetrics, error) {
+//			return &client.OrgMetrics{Org: org, Commits: 42}, nil
+//		},
+//	}
+//
+// A method whose function isn't set returns an error. The pagers page over the result of
+// GetMembersMetricsFunc, GetReposMetricsFunc or GetUserReposMetricsFunc unless their own
+// function is set. Every call is recorded, for Calls. A Mock is safe for concurrent use once
+// its functions are set.
+type Mock struct {
+	HealthCheckFunc                   func() error
+	GetOrgMetricsFunc                 func(org string, start, end time.Time, granularity string) (*domain.OrgMetrics, error)
+	GetMemberMetricsFunc              func(org, member string, start, end time.Time, granularity string) (*domain.MemberMetrics, error)
+	GetRepoMetricsFunc                func(org, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error)
+	GetMembersMetricsFunc             func(org string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error)
+	GetReposMetricsFunc               func(org string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error)
+	GetTimeSeriesMetricsFunc          func(org string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error)
+	GetOrgTimeSeriesDetailedFunc      func(org string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetRepoTimeSeriesDetailedFunc     func(org, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetMemberTimeSeriesDetailedFunc   func(org, member string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetMemberRankingFunc              func(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error)
+	GetRepoRankingFunc                func(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error)
+	GetUserMetricsFunc                func(user string, start, end time.Time, granularity string) (*domain.OrgMetrics, error)
+	GetUserReposMetricsFunc           func(user string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error)
+	GetUserRepoMetricsFunc            func(user, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error)
+	GetUserRepoMembersMetricsFunc     func(user, repo string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error)
+	GetUserTimeSeriesMetricsFunc      func(user string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error)
+	GetUserTimeSeriesDetailedFunc     func(user string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetUserRepoTimeSeriesDetailedFunc func(user, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetUserMemberRankingFunc          func(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error)
+	GetUserRepoRankingFunc            func(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error)
+
+	MembersMetricsPagerFunc   func(org string, start, end time.Time, granularity string, pageSize int) *client.MembersMetricsPager
+	ReposMetricsPagerFunc     func(org string, start, end time.Time, granularity string, pageSize int) *client.ReposMetricsPager
+	UserReposMetricsPagerFunc func(user string, start, end time.Time, granularity string, pageSize int) *client.ReposMetricsPager
+
+	StreamCollectionFunc func(ctx context.Context, batchID string) (<-chan *client.CollectionProgress, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ client.MetricsClient = (*Mock)(nil)
+
+// Calls returns the calls received so far, in order
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the calls of method received so far, in order
+func (m *Mock) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the calls received so far
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *Mock) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func notMocked(method string) error {
+	return fmt.Errorf("clienttest: %s called but %sFunc is not set", method, method)
+}
+
+func notMockedPager(method, listMethod string) error {
+	return fmt.Errorf("clienttest: %s called but neither %sFunc nor %sFunc is set", method, method, listMethod)
+}
+
+func (m *Mock) HealthCheck() error {
+	m.record("HealthCheck")
+	if m.HealthCheckFunc == nil {
+		return notMocked("HealthCheck")
+	}
+	return m.HealthCheckFunc()
+}
+
+func (m *Mock) GetOrgMetrics(org string, start, end time.Time, granularity string) (*domain.OrgMetrics, error) {
+	m.record("GetOrgMetrics", org, start, end, granularity)
+	if m.GetOrgMetricsFunc == nil {
+		return nil, notMocked("GetOrgMetrics")
+	}
+	return m.GetOrgMetricsFunc(org, start, end, granularity)
+}
+
+func (m *Mock) GetMemberMetrics(org, member string, start, end time.Time, granularity string) (*domain.MemberMetrics, error) {
+	m.record("GetMemberMetrics", org, member, start, end, granularity)
+	if m.GetMemberMetricsFunc == nil {
+		return nil, notMocked("GetMemberMetrics")
+	}
+	return m.GetMemberMetricsFunc(org, member, start, end, granularity)
+}
+
+func (m *Mock) GetRepoMetrics(org, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error) {
+	m.record("GetRepoMetrics", org, repo, start, end, granularity)
+	if m.GetRepoMetricsFunc == nil {
+		return nil, notMocked("GetRepoMetrics")
+	}
+	return m.GetRepoMetricsFunc(org, repo, start, end, granularity)
+}
+
+func (m *Mock) GetMembersMetrics(org string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error) {
+	m.record("GetMembersMetrics", org, start, end, granularity)
+	if m.GetMembersMetricsFunc == nil {
+		return nil, notMocked("GetMembersMetrics")
+	}
+	return m.GetMembersMetricsFunc(org, start, end, granularity)
+}
+
+func (m *Mock) GetReposMetrics(org string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error) {
+	m.record("GetReposMetrics", org, start, end, granularity)
+	if m.GetReposMetricsFunc == nil {
+		return nil, notMocked("GetReposMetrics")
+	}
+	return m.GetReposMetricsFunc(org, start, end, granularity)
+}
+
+func (m *Mock) GetTimeSeriesMetrics(org string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error) {
+	m.record("GetTimeSeriesMetrics", org, metricType, start, end, granularity)
+	if m.GetTimeSeriesMetricsFunc == nil {
+		return nil, notMocked("GetTimeSeriesMetrics")
+	}
+	return m.GetTimeSeriesMetricsFunc(org, metricType, start, end, granularity)
+}
+
+func (m *Mock) GetOrgTimeSeriesDetailed(org string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	m.record("GetOrgTimeSeriesDetailed", org, start, end, granularity)
+	if m.GetOrgTimeSeriesDetailedFunc == nil {
+		return nil, notMocked("GetOrgTimeSeriesDetailed")
+	}
+	return m.GetOrgTimeSeriesDetailedFunc(org, start, end, granularity)
+}
+
+func (m *Mock) GetRepoTimeSeriesDetailed(org, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	m.record("GetRepoTimeSeriesDetailed", org, repo, start, end, granularity)
+	if m.GetRepoTimeSeriesDetailedFunc == nil {
+		return nil, notMocked("GetRepoTimeSeriesDetailed")
+	}
+	return m.GetRepoTimeSeriesDetailedFunc(org, repo, start, end, granularity)
+}
+
+func (m *Mock) GetMemberTimeSeriesDetailed(org, member string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	m.record("GetMemberTimeSeriesDetailed", org, member, start, end, granularity)
+	if m.GetMemberTimeSeriesDetailedFunc == nil {
+		return nil, notMocked("GetMemberTimeSeriesDetailed")
+	}
+	return m.GetMemberTimeSeriesDetailedFunc(org, member, start, end, granularity)
+}
+
+func (m *Mock) GetMemberRanking(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error) {
+	m.record("GetMemberRanking", org, rankingType, limit, start, end)
+	if m.GetMemberRankingFunc == nil {
+		return nil, notMocked("GetMemberRanking")
+	}
+	return m.GetMemberRankingFunc(org, rankingType, limit, start, end)
+}
+
+func (m *Mock) GetRepoRanking(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error) {
+	m.record("GetRepoRanking", org, rankingType, limit, start, end)
+	if m.GetRepoRankingFunc == nil {
+		return nil, notMocked("GetRepoRanking")
+	}
+	return m.GetRepoRankingFunc(org, rankingType, limit, start, end)
+}
+
+func (m *Mock) GetUserMetrics(user string, start, end time.Time, granularity string) (*domain.OrgMetrics, error) {
+	m.record("GetUserMetrics", user, start, end, granularity)
+	if m.GetUserMetricsFunc == nil {
+		return nil, notMocked("GetUserMetrics")
+	}
+	return m.GetUserMetricsFunc(user, start, end, granularity)
+}
+
+func (m *Mock) GetUserReposMetrics(user string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error) {
+	m.record("GetUserReposMetrics", user, start, end, granularity)
+	if m.GetUserReposMetricsFunc == nil {
+		return nil, notMocked("GetUserReposMetrics")
+	}
+	return m.GetUserReposMetricsFunc(user, start, end, granularity)
+}
+
+func (m *Mock) GetUserRepoMetrics(user, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error) {
+	m.record("GetUserRepoMetrics", user, repo, start, end, granularity)
+	if m.GetUserRepoMetricsFunc == nil {
+		return nil, notMocked("GetUserRepoMetrics")
+	}
+	return m.GetUserRepoMetricsFunc(user, repo, start, end, granularity)
+}
+
+func (m *Mock) GetUserRepoMembersMetrics(user, repo string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error) {
+	m.record("GetUserRepoMembersMetrics", user, repo, start, end, granularity)
+	if m.GetUserRepoMembersMetricsFunc == nil {
+		return nil, notMocked("GetUserRepoMembersMetrics")
+	}
+	return m.GetUserRepoMembersMetricsFunc(user, repo, start, end, granularity)
+}
+
+func (m *Mock) GetUserTimeSeriesMetrics(user string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error) {
+	m.record("GetUserTimeSeriesMetrics", user, metricType, start, end, granularity)
+	if m.GetUserTimeSeriesMetricsFunc == nil {
+		return nil, notMocked("GetUserTimeSeriesMetrics")
+	}
+	return m.GetUserTimeSeriesMetricsFunc(user, metricType, start, end, granularity)
+}
+
+func (m *Mock) GetUserTimeSeriesDetailed(user string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	m.record("GetUserTimeSeriesDetailed", user, start, end, granularity)
+	if m.GetUserTimeSeriesDetailedFunc == nil {
+		return nil, notMocked("GetUserTimeSeriesDetailed")
+	}
+	return m.GetUserTimeSeriesDetailedFunc(user, start, end, granularity)
+}
+
+func (m *Mock) GetUserRepoTimeSeriesDetailed(user, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	m.record("GetUserRepoTimeSeriesDetailed", user, repo, start, end, granularity)
+	if m.GetUserRepoTimeSeriesDetailedFunc == nil {
+		return nil, notMocked("GetUserRepoTimeSeriesDetailed")
+	}
+	return m.GetUserRepoTimeSeriesDetailedFunc(user, repo, start, end, granularity)
+}
+
+func (m *Mock) GetUserMemberRanking(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error) {
+	m.record("GetUserMemberRanking", user, rankingType, limit, start, end)
+	if m.GetUserMemberRankingFunc == nil {
+		return nil, notMocked("GetUserMemberRanking")
+	}
+	return m.GetUserMemberRankingFunc(user, rankingType, limit, start, end)
+}
+
+func (m *Mock) GetUserRepoRanking(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error) {
+	m.record("GetUserRepoRanking", user, rankingType, limit, start, end)
+	if m.GetUserRepoRankingFunc == nil {
+		return nil, notMocked("GetUserRepoRanking")
+	}
+	return m.GetUserRepoRankingFunc(user, rankingType, limit, start, end)
+}
+
+func (m *Mock) MembersMetricsPager(org string, start, end time.Time, granularity string, pageSize int) *client.MembersMetricsPager {
+	m.record("MembersMetricsPager", org, start, end, granularity, pageSize)
+	if m.MembersMetricsPagerFunc != nil {
+		return m.MembersMetricsPagerFunc(org, start, end, granularity, pageSize)
+	}
+	return pageOver(pageSize, func() ([]*domain.MemberMetrics, error) {
+		if m.GetMembersMetricsFunc == nil {
+			return nil, notMockedPager("MembersMetricsPager", "GetMembersMetrics")
+		}
+		return m.GetMembersMetricsFunc(org, start, end, granularity)
+	})
+}
+
+func (m *Mock) ReposMetricsPager(org string, start, end time.Time, granularity string, pageSize int) *client.ReposMetricsPager {
+	m.record("ReposMetricsPager", org, start, end, granularity, pageSize)
+	if m.ReposMetricsPagerFunc != nil {
+		return m.ReposMetricsPagerFunc(org, start, end, granularity, pageSize)
+	}
+	return pageOver(pageSize, func() ([]*domain.RepoMetrics, error) {
+		if m.GetReposMetricsFunc == nil {
+			return nil, notMockedPager("ReposMetricsPager", "GetReposMetrics")
+		}
+		return m.GetReposMetricsFunc(org, start, end, granularity)
+	})
+}
+
+func (m *Mock) UserReposMetricsPager(user string, start, end time.Time, granularity string, pageSize int) *client.ReposMetricsPager {
+	m.record("UserReposMetricsPager", user, start, end, granularity, pageSize)
+	if m.UserReposMetricsPagerFunc != nil {
+		return m.UserReposMetricsPagerFunc(user, start, end, granularity, pageSize)
+	}
+	return pageOver(pageSize, func() ([]*domain.RepoMetrics, error) {
+		if m.GetUserReposMetricsFunc == nil {
+			return nil, notMockedPager("UserReposMetricsPager", "GetUserReposMetrics")
+		}
+		return m.GetUserReposMetricsFunc(user, start, end, granularity)
+	})
+}
+
+func (m *Mock) StreamCollection(ctx context.Context, batchID string) (<-chan *client.CollectionProgress, error) {
+	m.record("StreamCollection", ctx, batchID)
+	if m.StreamCollectionFunc == nil {
+		return nil, notMocked("StreamCollection")
+	}
+	return m.StreamCollectionFunc(ctx, batchID)
+}
+
+// pageOver returns a pager over the items list returns, fetched once on the first page
+func pageOver[T any](pageSize int, list func() ([]T, error)) *client.Pager[T] {
+	var items []T
+	fetched := false
+	return client.NewPager(pageSize, func(limit, offset int) ([]T, error) {
+		if !fetched {
+			var err error
+			if items, err = list(); err != nil {
+				return nil, err
+			}
+			fetched = true
+		}
+		if offset >= len(items) {
+			return nil, nil
+		}
+		return items[offset:min(offset+limit, len(items))], nil
+	})
+}
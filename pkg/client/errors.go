@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error codes of the API, the Code of an APIError
+const (
+	CodeNotFound           = "NOT_FOUND"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeConflict           = "CONFLICT"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeInvalidRankingType = "INVALID_RANKING_TYPE"
+)
+
+// APIError is an error response of the API
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Code       string // error code, such as CodeNotFound; empty if the body wasn't an API error
+	Message    string
+	RequestID  string        // X-Request-ID of the failed request, to find it in the server logs
+	RetryAfter time.Duration // how long to wait before retrying, for CodeRateLimited; 0 if not given
+}
+
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("API error: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		s += " " + e.Code
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		s += " (request ID " + e.RequestID + ")"
+	}
+	return s
+}
+
+// IsNotFound reports whether err is an API error for a resource that doesn't exist
+func IsNotFound(err error) bool {
+	return HasCode(err, CodeNotFound)
+}
+
+// IsRateLimited reports whether err is an API error for a request over the rate limit
+func IsRateLimited(err error) bool {
+	return HasCode(err, CodeRateLimited)
+}
+
+// HasCode reports whether err is an API error with error code code
+func HasCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// newAPIError reads an error response, whose body is {"error": {"code": ..., "message": ...}}
+// on /api/v1 and /api/v2, or any text from a proxy in front of the API
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var response struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && response.Error.Code != "" {
+		apiErr.Code = response.Error.Code
+		apiErr.Message = response.Error.Message
+		if response.Error.RequestID != "" {
+			apiErr.RequestID = response.Error.RequestID
+		}
+		return apiErr
+	}
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResponseInfo describes a request of the client once its response was handled, for the
+// hooks of WithOnResponse
+type ResponseInfo struct {
+	Request    *http.Request
+	Attempt    int           // 0 for the first try, 1 for the first retry of WithRetry, ...
+	StatusCode int           // 0 if there was no response
+	Duration   time.Duration // from sending the request to reading the response
+	Err        error         // what the request failed with, such as an *APIError; nil on success
+}
+
+// WithOnRequest calls hook before each request is sent, including retries, e.g. to log it or
+// inject tracing headers. hook may set headers of req but must not read its body.
+//
+// WithOnRequest and WithOnResponse may be given several times; the hooks are called in
+// order, on the goroutine of the request, so they must be safe for concurrent use if the
+// client is used concurrently.
+func WithOnRequest(hook func(req *http.Request, attempt int)) Option {
+	return func(o *clientOptions) {
+		o.onRequest = append(o.onRequest, hook)
+	}
+}
+
+// WithOnResponse calls hook after each request, including retries and failed ones, e.g. to
+// log it or record its latency. For StreamCollection, it's called once the stream is accepted
+// or refused, not when it ends. Requests refused by the circuit breaker of WithCircuitBreaker
+// are never sent, so no hooks are called for them.
+func WithOnResponse(hook func(info ResponseInfo)) Option {
+	return func(o *clientOptions) {
+		o.onResponse = append(o.onResponse, hook)
+	}
+}
+
+func (c *Client) beforeRequest(req *http.Request, attempt int) {
+	for _, hook := range c.onRequest {
+		hook(req, attempt)
+	}
+}
+
+func (c *Client) afterResponse(info ResponseInfo) {
+	for _, hook := range c.onResponse {
+		hook(info)
+	}
+}
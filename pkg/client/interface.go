@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// MetricsClient is the API of a Client, for code that should also work with a stand-in, such
+// as the clienttest.Mock of unit tests
+type MetricsClient interface {
+	HealthCheck() error
+
+	GetOrgMetrics(org string, start, end time.Time, granularity string) (*domain.OrgMetrics, error)
+	GetMemberMetrics(org, member string, start, end time.Time, granularity string) (*domain.MemberMetrics, error)
+	GetRepoMetrics(org, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error)
+	GetMembersMetrics(org string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error)
+	GetReposMetrics(org string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error)
+	MembersMetricsPager(org string, start, end time.Time, granularity string, pageSize int) *MembersMetricsPager
+	ReposMetricsPager(org string, start, end time.Time, granularity string, pageSize int) *ReposMetricsPager
+
+	GetTimeSeriesMetrics(org string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error)
+	GetOrgTimeSeriesDetailed(org string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetRepoTimeSeriesDetailed(org, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetMemberTimeSeriesDetailed(org, member string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+
+	GetMemberRanking(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error)
+	GetRepoRanking(org string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error)
+
+	GetUserMetrics(user string, start, end time.Time, granularity string) (*domain.OrgMetrics, error)
+	GetUserReposMetrics(user string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error)
+	GetUserRepoMetrics(user, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error)
+	GetUserRepoMembersMetrics(user, repo string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error)
+	UserReposMetricsPager(user string, start, end time.Time, granularity string, pageSize int) *ReposMetricsPager
+	GetUserTimeSeriesMetrics(user string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error)
+	GetUserTimeSeriesDetailed(user string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetUserRepoTimeSeriesDetailed(user, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error)
+	GetUserMemberRanking(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error)
+	GetUserRepoRanking(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error)
+
+	StreamCollection(ctx context.Context, batchID string) (<-chan *CollectionProgress, error)
+}
+
+var _ MetricsClient = (*Client)(nil)
+
+// The types of the API's data, usable outside this module, which can't import the domain
+// package, e.g. to implement MetricsClient
+type (
+	OrgMetrics               = domain.OrgMetrics
+	MemberMetrics            = domain.MemberMetrics
+	RepoMetrics              = domain.RepoMetrics
+	TimeSeriesData           = domain.TimeSeriesData
+	TimeSeriesMetric         = domain.TimeSeriesMetric
+	DetailedTimeSeriesData   = domain.DetailedTimeSeriesData
+	DetailedTimeSeriesMetric = domain.DetailedTimeSeriesMetric
+	RankingType              = domain.RankingType
+	MemberRanking            = domain.MemberRanking
+	RepoRanking              = domain.RepoRanking
+)
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/config"
+)
+
+// DefaultTimeout is how long a request may take unless WithTimeout says otherwise
+const DefaultTimeout = 30 * time.Second
+
+// DefaultUserAgent is the User-Agent header of requests unless WithUserAgent says otherwise
+const DefaultUserAgent = "github-activity-metrics-client"
+
+// DefaultRetryBackoff is the wait before the first retry of WithRetry when it isn't given
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// maxRetryBackoff caps the doubling wait between retries
+const maxRetryBackoff = 30 * time.Second
+
+// Option configures how a client connects to the API
+type Option func(*clientOptions)
+
+// clientOptions are the settings of a client
+type clientOptions struct {
+	httpClient *http.Client
+	timeout    time.Duration
+	proxy      func(*http.Request) (*url.URL, error)
+	tlsConfig  *tls.Config
+	caCertFile string
+	apiKey     string
+	userAgent  string
+	retries    int
+	backoff    time.Duration
+	breaker    *circuitBreaker
+	onRequest  []func(req *http.Request, attempt int)
+	onResponse []func(info ResponseInfo)
+}
+
+// WithHTTPClient makes the client send requests with httpClient instead of one of its own,
+// e.g. to share a transport or add middleware. httpClient is not modified.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTimeout limits how long each request may take, including reading the response
+// (DefaultTimeout if not set). 0 disables the limit, leaving any timeout of the
+// WithHTTPClient client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithProxy sends requests through the HTTP(S) proxy at proxyURL instead of the one in the
+// HTTP_PROXY/HTTPS_PROXY environment variables
+func WithProxy(proxyURL *url.URL) Option {
+	return func(o *clientOptions) {
+		o.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of connections to the API, e.g. to trust a private
+// CA or present a client certificate
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *clientOptions) {
+		o.tlsConfig = config
+	}
+}
+
+// WithCACertFile trusts the PEM CA certificates of path besides the system ones, like
+// CA_CERT_PATH of the collector, e.g. for a server behind a TLS-inspecting proxy. It adds to
+// the WithTLSConfig configuration. If the file can't be loaded, every request of the client
+// fails with the error.
+func WithCACertFile(path string) Option {
+	return func(o *clientOptions) {
+		o.caCertFile = path
+	}
+}
+
+// WithAuth authenticates requests with an API key of the server, sent as
+// "Authorization: Bearer <apiKey>", for a server with API_AUTH_ENABLED
+func WithAuth(apiKey string) Option {
+	return func(o *clientOptions) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests (DefaultUserAgent if not set), e.g. to
+// tell apart the applications calling the API in its logs
+func WithUserAgent(userAgent string) Option {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithRetry retries a failed request up to retries times: after a network error, a server
+// error (5xx) or a rate limit response (429). The wait before a retry starts at backoff
+// (DefaultRetryBackoff if 0) and doubles each time, up to 30 seconds, unless a rate limit
+// response says how long to wait with Retry-After. Other errors, such as CodeNotFound, are
+// returned at once. WithTimeout applies to each attempt.
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// newHTTPClient returns the HTTP client requests are sent with. WithProxy, WithTLSConfig and
+// WithCACertFile apply to a copy of the WithHTTPClient client's transport; they are ignored
+// if it isn't an *http.Transport.
+func newHTTPClient(o *clientOptions) (*http.Client, error) {
+	httpClient := o.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	tlsConfig := o.tlsConfig
+	if o.caCertFile != "" {
+		pool, err := config.LoadCACerts(o.caCertFile)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		} else {
+			tlsConfig = tlsConfig.Clone()
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if o.proxy == nil && tlsConfig == nil {
+		return httpClient, nil
+	}
+
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return httpClient, nil
+	}
+	transport = transport.Clone()
+	if o.proxy != nil {
+		transport.Proxy = o.proxy
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	copied := *httpClient
+	copied.Transport = transport
+	return &copied, nil
+}
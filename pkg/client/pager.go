@@ -0,0 +1,130 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// DefaultPageSize is the number of items a pager fetches per request unless told otherwise
+const DefaultPageSize = 100
+
+// Pager iterates over a list endpoint page by page, fetching the next page when the items of
+// the previous one are used up:
+//
+//	pager := c.MembersMetricsPager("my-org", start, end, "", 0)
+//	for pager.Next() {
+//		m := pager.Value()
+//		...
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+type Pager[T any] struct {
+	fetch    func(limit, offset int) ([]T, error)
+	pageSize int
+	offset   int // of the next page
+	page     []T
+	index    int
+	done     bool // the last page was fetched
+	err      error
+}
+
+// MembersMetricsPager iterates over the metrics of an organization's members
+type MembersMetricsPager = Pager[*domain.MemberMetrics]
+
+// ReposMetricsPager iterates over the metrics of an organization's or user's repositories
+type ReposMetricsPager = Pager[*domain.RepoMetrics]
+
+// NewPager returns a pager that fetches pages of pageSize items (DefaultPageSize if 0) with
+// fetch, which returns the page of at most limit items after the first offset ones. A page
+// with fewer than limit items is the last one.
+func NewPager[T any](pageSize int, fetch func(limit, offset int) ([]T, error)) *Pager[T] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return &Pager[T]{fetch: fetch, pageSize: pageSize, index: -1}
+}
+
+// Next advances to the next item, fetching the next page if needed. It returns false when
+// there are no more items or a request failed, which Err tells apart.
+func (p *Pager[T]) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	p.index++
+	for p.index >= len(p.page) {
+		if p.done {
+			return false
+		}
+		page, err := p.fetch(p.pageSize, p.offset)
+		if err != nil {
+			p.err = err
+			return false
+		}
+		// A short page is the last one
+		p.page, p.index = page, 0
+		p.offset += len(page)
+		p.done = len(page) < p.pageSize
+	}
+	return true
+}
+
+// Value returns the current item; call it after Next returned true
+func (p *Pager[T]) Value() T {
+	return p.page[p.index]
+}
+
+// Err returns the error of the request that stopped the iteration, if any
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// MembersMetricsPager returns a pager over the metrics of every member of an organization,
+// in the order of the member names, fetching pageSize members per request (DefaultPageSize
+// if 0)
+func (c *Client) MembersMetricsPager(org string, start, end time.Time, granularity string, pageSize int) *MembersMetricsPager {
+	path := fmt.Sprintf("/api/v1/orgs/%s/members/metrics", org)
+	return NewPager(pageSize, func(limit, offset int) ([]*domain.MemberMetrics, error) {
+		var response struct {
+			Data []*domain.MemberMetrics `json:"data"`
+		}
+		err := c.get(path, c.buildPageParams(start, end, granularity, limit, offset), &response)
+		return response.Data, err
+	})
+}
+
+// ReposMetricsPager returns a pager over the metrics of every repository of an organization,
+// in the order of the repository names, fetching pageSize repositories per request
+// (DefaultPageSize if 0)
+func (c *Client) ReposMetricsPager(org string, start, end time.Time, granularity string, pageSize int) *ReposMetricsPager {
+	return c.reposMetricsPager(fmt.Sprintf("/api/v1/orgs/%s/repos/metrics", org), start, end, granularity, pageSize)
+}
+
+// UserReposMetricsPager returns a pager over the metrics of every repository of a user, like
+// ReposMetricsPager
+func (c *Client) UserReposMetricsPager(user string, start, end time.Time, granularity string, pageSize int) *ReposMetricsPager {
+	return c.reposMetricsPager(fmt.Sprintf("/api/v1/users/%s/repos/metrics", user), start, end, granularity, pageSize)
+}
+
+func (c *Client) reposMetricsPager(path string, start, end time.Time, granularity string, pageSize int) *ReposMetricsPager {
+	return NewPager(pageSize, func(limit, offset int) ([]*domain.RepoMetrics, error) {
+		var response struct {
+			Data []*domain.RepoMetrics `json:"data"`
+		}
+		err := c.get(path, c.buildPageParams(start, end, granularity, limit, offset), &response)
+		return response.Data, err
+	})
+}
+
+func (c *Client) buildPageParams(start, end time.Time, granularity string, limit, offset int) url.Values {
+	params := c.buildTimeParams(start, end, granularity)
+	params.Set("limit", strconv.Itoa(limit))
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	return params
+}
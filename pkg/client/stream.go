@@ -0,0 +1,140 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Collection progress event types
+const (
+	CollectionEventProgress = "progress" // repositories changed status
+	CollectionEventDone     = "done"     // the batch is no longer in progress; the last event
+	CollectionEventError    = "error"    // the stream failed; the last event
+)
+
+// CollectionProgress is an event of a collection batch's progress stream
+type CollectionProgress struct {
+	Event      string                   `json:"-"` // CollectionEventProgress, CollectionEventDone or CollectionEventError
+	BatchID    string                   `json:"batch_id"`
+	Status     string                   `json:"status"` // of the batch: in_progress, completed or failed
+	Total      int                      `json:"total"`
+	Pending    int                      `json:"pending"`
+	Processing int                      `json:"processing"`
+	Completed  int                      `json:"completed"`
+	Failed     int                      `json:"failed"`
+	Repos      []CollectionRepoProgress `json:"repos"`             // repositories whose status changed since the previous event
+	Error      string                   `json:"message,omitempty"` // what failed, for CollectionEventError
+}
+
+// CollectionRepoProgress is the status of a repository in a CollectionProgress event
+type CollectionRepoProgress struct {
+	Repo   string `json:"repo"`
+	Status string `json:"status"` // pending, processing, completed or failed
+	Events int    `json:"events"`
+	Error  string `json:"error,omitempty"`
+}
+
+// StreamCollection subscribes to the progress of a collection batch. The first event has the
+// status of every repository, later ones the repositories that changed. The channel is closed
+// after a CollectionEventDone or CollectionEventError event, or when ctx is canceled, which
+// is how to stop listening early.
+//
+// The stream isn't limited by WithTimeout; an error is returned if the server doesn't accept
+// it, such as an APIError for a batch that doesn't exist.
+func (c *Client) StreamCollection(ctx context.Context, batchID string) (<-chan *CollectionProgress, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	u := c.baseURL + "/api/v1/collections/" + url.PathEscape(batchID) + "/stream"
+	req, err := c.newRequest(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	c.beforeRequest(req, 0)
+	started := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.breaker.record(err)
+		c.afterResponse(ResponseInfo{Request: req, Duration: time.Since(started), Err: err})
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		apiErr := newAPIError(resp)
+		c.breaker.record(apiErr)
+		c.afterResponse(ResponseInfo{Request: req, StatusCode: resp.StatusCode, Duration: time.Since(started), Err: apiErr})
+		return nil, apiErr
+	}
+	c.breaker.record(nil)
+	c.afterResponse(ResponseInfo{Request: req, StatusCode: resp.StatusCode, Duration: time.Since(started)})
+
+	events := make(chan *CollectionProgress)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		send := func(event *CollectionProgress) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var eventType string
+		var data strings.Builder
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		for scanner.Scan() {
+			line := scanner.Text()
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch {
+			case line == "":
+				// A blank line ends an event
+				if data.Len() == 0 {
+					continue
+				}
+				event := &CollectionProgress{Event: eventType}
+				if err := json.Unmarshal([]byte(data.String()), event); err != nil {
+					send(&CollectionProgress{Event: CollectionEventError, BatchID: batchID, Error: fmt.Sprintf("invalid %s event: %v", eventType, err)})
+					return
+				}
+				if !send(event) || eventType == CollectionEventDone || eventType == CollectionEventError {
+					return
+				}
+				eventType = ""
+				data.Reset()
+			case field == "event":
+				eventType = value
+			case field == "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+			// Comments (keep-alives) and other fields are ignored
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		message := "stream ended before the batch finished"
+		if err := scanner.Err(); err != nil {
+			message = err.Error()
+		}
+		send(&CollectionProgress{Event: CollectionEventError, BatchID: batchID, Error: message})
+	}()
+	return events, nil
+}
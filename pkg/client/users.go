@@ -0,0 +1,126 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kurihiro0119/github-activity-metrics/internal/domain"
+)
+
+// The /api/v1/users/:user endpoints serve the metrics of a personal account collected with
+// MODE=user; they mirror the organization endpoints.
+
+// GetUserMetrics retrieves the metrics of a user's repositories combined
+func (c *Client) GetUserMetrics(user string, start, end time.Time, granularity string) (*domain.OrgMetrics, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/metrics", user)
+	params := c.buildTimeParams(start, end, granularity)
+
+	var response struct {
+		Data *domain.OrgMetrics `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetUserReposMetrics retrieves metrics for all repositories of a user
+func (c *Client) GetUserReposMetrics(user string, start, end time.Time, granularity string) ([]*domain.RepoMetrics, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/repos/metrics", user)
+	params := c.buildTimeParams(start, end, granularity)
+
+	var response struct {
+		Data []*domain.RepoMetrics `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetUserRepoMetrics retrieves the metrics of one repository of a user
+func (c *Client) GetUserRepoMetrics(user, repo string, start, end time.Time, granularity string) (*domain.RepoMetrics, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/repos/%s/metrics", user, repo)
+	params := c.buildTimeParams(start, end, granularity)
+
+	var response struct {
+		Data *domain.RepoMetrics `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetUserRepoMembersMetrics retrieves the metrics of everyone who contributed to a repository
+// of a user
+func (c *Client) GetUserRepoMembersMetrics(user, repo string, start, end time.Time, granularity string) ([]*domain.MemberMetrics, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/repos/%s/members/metrics", user, repo)
+	params := c.buildTimeParams(start, end, granularity)
+
+	var response struct {
+		Data []*domain.MemberMetrics `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetUserTimeSeriesMetrics retrieves one metric of a user per granularity period
+func (c *Client) GetUserTimeSeriesMetrics(user string, metricType string, start, end time.Time, granularity string) (*domain.TimeSeriesData, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/metrics/timeseries", user)
+	params := c.buildTimeParams(start, end, granularity)
+	params.Set("type", metricType)
+
+	var response struct {
+		Data *domain.TimeSeriesData `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetUserTimeSeriesDetailed retrieves every metric of a user per granularity period
+func (c *Client) GetUserTimeSeriesDetailed(user string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/metrics/timeseries/detailed", user)
+	return c.getDetailedTimeSeries(path, start, end, granularity)
+}
+
+// GetUserRepoTimeSeriesDetailed retrieves every metric of a repository of a user per
+// granularity period
+func (c *Client) GetUserRepoTimeSeriesDetailed(user, repo string, start, end time.Time, granularity string) (*domain.DetailedTimeSeriesData, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/repos/%s/metrics/timeseries", user, repo)
+	return c.getDetailedTimeSeries(path, start, end, granularity)
+}
+
+// GetUserMemberRanking retrieves the top contributors to a user's repositories by
+// rankingType, at most limit of them (the server's default, 10, if limit is 0)
+func (c *Client) GetUserMemberRanking(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.MemberRanking, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/rankings/members/%s", user, rankingType)
+	params := c.buildRankingParams(limit, start, end)
+
+	var response struct {
+		Data []*domain.MemberRanking `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetUserRepoRanking retrieves the top repositories of a user by rankingType, at most limit
+// of them (the server's default, 10, if limit is 0)
+func (c *Client) GetUserRepoRanking(user string, rankingType domain.RankingType, limit int, start, end time.Time) ([]*domain.RepoRanking, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/rankings/repos/%s", user, rankingType)
+	params := c.buildRankingParams(limit, start, end)
+
+	var response struct {
+		Data []*domain.RepoRanking `json:"data"`
+	}
+	if err := c.get(path, params, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -0,0 +1,649 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: metrics/v1/metrics.proto
+
+package metricsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MetricsService_GetOrgMetrics_FullMethodName          = "/metrics.v1.MetricsService/GetOrgMetrics"
+	MetricsService_GetMemberMetrics_FullMethodName       = "/metrics.v1.MetricsService/GetMemberMetrics"
+	MetricsService_GetRepoMetrics_FullMethodName         = "/metrics.v1.MetricsService/GetRepoMetrics"
+	MetricsService_ListMembersMetrics_FullMethodName     = "/metrics.v1.MetricsService/ListMembersMetrics"
+	MetricsService_ListRepoMembersMetrics_FullMethodName = "/metrics.v1.MetricsService/ListRepoMembersMetrics"
+	MetricsService_ListReposMetrics_FullMethodName       = "/metrics.v1.MetricsService/ListReposMetrics"
+	MetricsService_GetTimeSeries_FullMethodName          = "/metrics.v1.MetricsService/GetTimeSeries"
+	MetricsService_GetOrgTimeSeries_FullMethodName       = "/metrics.v1.MetricsService/GetOrgTimeSeries"
+	MetricsService_GetRepoTimeSeries_FullMethodName      = "/metrics.v1.MetricsService/GetRepoTimeSeries"
+	MetricsService_GetMemberTimeSeries_FullMethodName    = "/metrics.v1.MetricsService/GetMemberTimeSeries"
+	MetricsService_GetMemberRanking_FullMethodName       = "/metrics.v1.MetricsService/GetMemberRanking"
+	MetricsService_GetRepoRanking_FullMethodName         = "/metrics.v1.MetricsService/GetRepoRanking"
+	MetricsService_GetRepoCommitTypes_FullMethodName     = "/metrics.v1.MetricsService/GetRepoCommitTypes"
+	MetricsService_GetMemberCommitTypes_FullMethodName   = "/metrics.v1.MetricsService/GetMemberCommitTypes"
+)
+
+// MetricsServiceClient is the client API for MetricsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MetricsService mirrors the aggregator API served over HTTP under /api/v1.
+// Owners are organizations or user accounts (users are stored the same way as organizations).
+type MetricsServiceClient interface {
+	// GetOrgMetrics returns organization-level metrics
+	GetOrgMetrics(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*OrgMetrics, error)
+	// GetMemberMetrics returns metrics for a single member
+	GetMemberMetrics(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*MemberMetrics, error)
+	// GetRepoMetrics returns metrics for a single repository
+	GetRepoMetrics(ctx context.Context, in *RepoRequest, opts ...grpc.CallOption) (*RepoMetrics, error)
+	// ListMembersMetrics returns metrics for all members
+	ListMembersMetrics(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*ListMembersMetricsResponse, error)
+	// ListRepoMembersMetrics returns metrics for all members of a repository
+	ListRepoMembersMetrics(ctx context.Context, in *RepoRequest, opts ...grpc.CallOption) (*ListMembersMetricsResponse, error)
+	// ListReposMetrics returns metrics for all repositories
+	ListReposMetrics(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*ListReposMetricsResponse, error)
+	// GetTimeSeries returns a time series for a single metric type
+	GetTimeSeries(ctx context.Context, in *TimeSeriesRequest, opts ...grpc.CallOption) (*TimeSeries, error)
+	// GetOrgTimeSeries returns a detailed time series for an owner
+	GetOrgTimeSeries(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*DetailedTimeSeries, error)
+	// GetRepoTimeSeries returns a detailed time series for a repository
+	GetRepoTimeSeries(ctx context.Context, in *RepoRequest, opts ...grpc.CallOption) (*DetailedTimeSeries, error)
+	// GetMemberTimeSeries returns a detailed time series for a member
+	GetMemberTimeSeries(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*DetailedTimeSeries, error)
+	// GetMemberRanking returns members ranked by a metric
+	GetMemberRanking(ctx context.Context, in *RankingRequest, opts ...grpc.CallOption) (*MemberRankingResponse, error)
+	// GetRepoRanking returns repositories ranked by a metric
+	GetRepoRanking(ctx context.Context, in *RankingRequest, opts ...grpc.CallOption) (*RepoRankingResponse, error)
+	// GetRepoCommitTypes returns conventional-commit type counts per repository
+	GetRepoCommitTypes(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*CommitTypesResponse, error)
+	// GetMemberCommitTypes returns conventional-commit type counts per member
+	GetMemberCommitTypes(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*CommitTypesResponse, error)
+}
+
+type metricsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMetricsServiceClient(cc grpc.ClientConnInterface) MetricsServiceClient {
+	return &metricsServiceClient{cc}
+}
+
+func (c *metricsServiceClient) GetOrgMetrics(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*OrgMetrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrgMetrics)
+	err := c.cc.Invoke(ctx, MetricsService_GetOrgMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetMemberMetrics(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*MemberMetrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MemberMetrics)
+	err := c.cc.Invoke(ctx, MetricsService_GetMemberMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetRepoMetrics(ctx context.Context, in *RepoRequest, opts ...grpc.CallOption) (*RepoMetrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RepoMetrics)
+	err := c.cc.Invoke(ctx, MetricsService_GetRepoMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) ListMembersMetrics(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*ListMembersMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMembersMetricsResponse)
+	err := c.cc.Invoke(ctx, MetricsService_ListMembersMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) ListRepoMembersMetrics(ctx context.Context, in *RepoRequest, opts ...grpc.CallOption) (*ListMembersMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMembersMetricsResponse)
+	err := c.cc.Invoke(ctx, MetricsService_ListRepoMembersMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) ListReposMetrics(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*ListReposMetricsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReposMetricsResponse)
+	err := c.cc.Invoke(ctx, MetricsService_ListReposMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetTimeSeries(ctx context.Context, in *TimeSeriesRequest, opts ...grpc.CallOption) (*TimeSeries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TimeSeries)
+	err := c.cc.Invoke(ctx, MetricsService_GetTimeSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetOrgTimeSeries(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*DetailedTimeSeries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DetailedTimeSeries)
+	err := c.cc.Invoke(ctx, MetricsService_GetOrgTimeSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetRepoTimeSeries(ctx context.Context, in *RepoRequest, opts ...grpc.CallOption) (*DetailedTimeSeries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DetailedTimeSeries)
+	err := c.cc.Invoke(ctx, MetricsService_GetRepoTimeSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetMemberTimeSeries(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*DetailedTimeSeries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DetailedTimeSeries)
+	err := c.cc.Invoke(ctx, MetricsService_GetMemberTimeSeries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetMemberRanking(ctx context.Context, in *RankingRequest, opts ...grpc.CallOption) (*MemberRankingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MemberRankingResponse)
+	err := c.cc.Invoke(ctx, MetricsService_GetMemberRanking_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetRepoRanking(ctx context.Context, in *RankingRequest, opts ...grpc.CallOption) (*RepoRankingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RepoRankingResponse)
+	err := c.cc.Invoke(ctx, MetricsService_GetRepoRanking_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetRepoCommitTypes(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*CommitTypesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitTypesResponse)
+	err := c.cc.Invoke(ctx, MetricsService_GetRepoCommitTypes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsServiceClient) GetMemberCommitTypes(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*CommitTypesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitTypesResponse)
+	err := c.cc.Invoke(ctx, MetricsService_GetMemberCommitTypes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MetricsServiceServer is the server API for MetricsService service.
+// All implementations must embed UnimplementedMetricsServiceServer
+// for forward compatibility.
+//
+// MetricsService mirrors the aggregator API served over HTTP under /api/v1.
+// Owners are organizations or user accounts (users are stored the same way as organizations).
+type MetricsServiceServer interface {
+	// GetOrgMetrics returns organization-level metrics
+	GetOrgMetrics(context.Context, *OwnerRequest) (*OrgMetrics, error)
+	// GetMemberMetrics returns metrics for a single member
+	GetMemberMetrics(context.Context, *MemberRequest) (*MemberMetrics, error)
+	// GetRepoMetrics returns metrics for a single repository
+	GetRepoMetrics(context.Context, *RepoRequest) (*RepoMetrics, error)
+	// ListMembersMetrics returns metrics for all members
+	ListMembersMetrics(context.Context, *OwnerRequest) (*ListMembersMetricsResponse, error)
+	// ListRepoMembersMetrics returns metrics for all members of a repository
+	ListRepoMembersMetrics(context.Context, *RepoRequest) (*ListMembersMetricsResponse, error)
+	// ListReposMetrics returns metrics for all repositories
+	ListReposMetrics(context.Context, *OwnerRequest) (*ListReposMetricsResponse, error)
+	// GetTimeSeries returns a time series for a single metric type
+	GetTimeSeries(context.Context, *TimeSeriesRequest) (*TimeSeries, error)
+	// GetOrgTimeSeries returns a detailed time series for an owner
+	GetOrgTimeSeries(context.Context, *OwnerRequest) (*DetailedTimeSeries, error)
+	// GetRepoTimeSeries returns a detailed time series for a repository
+	GetRepoTimeSeries(context.Context, *RepoRequest) (*DetailedTimeSeries, error)
+	// GetMemberTimeSeries returns a detailed time series for a member
+	GetMemberTimeSeries(context.Context, *MemberRequest) (*DetailedTimeSeries, error)
+	// GetMemberRanking returns members ranked by a metric
+	GetMemberRanking(context.Context, *RankingRequest) (*MemberRankingResponse, error)
+	// GetRepoRanking returns repositories ranked by a metric
+	GetRepoRanking(context.Context, *RankingRequest) (*RepoRankingResponse, error)
+	// GetRepoCommitTypes returns conventional-commit type counts per repository
+	GetRepoCommitTypes(context.Context, *OwnerRequest) (*CommitTypesResponse, error)
+	// GetMemberCommitTypes returns conventional-commit type counts per member
+	GetMemberCommitTypes(context.Context, *OwnerRequest) (*CommitTypesResponse, error)
+	mustEmbedUnimplementedMetricsServiceServer()
+}
+
+// UnimplementedMetricsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMetricsServiceServer struct{}
+
+func (UnimplementedMetricsServiceServer) GetOrgMetrics(context.Context, *OwnerRequest) (*OrgMetrics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrgMetrics not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetMemberMetrics(context.Context, *MemberRequest) (*MemberMetrics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMemberMetrics not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetRepoMetrics(context.Context, *RepoRequest) (*RepoMetrics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepoMetrics not implemented")
+}
+func (UnimplementedMetricsServiceServer) ListMembersMetrics(context.Context, *OwnerRequest) (*ListMembersMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMembersMetrics not implemented")
+}
+func (UnimplementedMetricsServiceServer) ListRepoMembersMetrics(context.Context, *RepoRequest) (*ListMembersMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRepoMembersMetrics not implemented")
+}
+func (UnimplementedMetricsServiceServer) ListReposMetrics(context.Context, *OwnerRequest) (*ListReposMetricsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReposMetrics not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetTimeSeries(context.Context, *TimeSeriesRequest) (*TimeSeries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTimeSeries not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetOrgTimeSeries(context.Context, *OwnerRequest) (*DetailedTimeSeries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrgTimeSeries not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetRepoTimeSeries(context.Context, *RepoRequest) (*DetailedTimeSeries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepoTimeSeries not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetMemberTimeSeries(context.Context, *MemberRequest) (*DetailedTimeSeries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMemberTimeSeries not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetMemberRanking(context.Context, *RankingRequest) (*MemberRankingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMemberRanking not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetRepoRanking(context.Context, *RankingRequest) (*RepoRankingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepoRanking not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetRepoCommitTypes(context.Context, *OwnerRequest) (*CommitTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepoCommitTypes not implemented")
+}
+func (UnimplementedMetricsServiceServer) GetMemberCommitTypes(context.Context, *OwnerRequest) (*CommitTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMemberCommitTypes not implemented")
+}
+func (UnimplementedMetricsServiceServer) mustEmbedUnimplementedMetricsServiceServer() {}
+func (UnimplementedMetricsServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeMetricsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MetricsServiceServer will
+// result in compilation errors.
+type UnsafeMetricsServiceServer interface {
+	mustEmbedUnimplementedMetricsServiceServer()
+}
+
+func RegisterMetricsServiceServer(s grpc.ServiceRegistrar, srv MetricsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMetricsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MetricsService_ServiceDesc, srv)
+}
+
+func _MetricsService_GetOrgMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetOrgMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetOrgMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetOrgMetrics(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetMemberMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetMemberMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetMemberMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetMemberMetrics(ctx, req.(*MemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetRepoMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetRepoMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetRepoMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetRepoMetrics(ctx, req.(*RepoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_ListMembersMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).ListMembersMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_ListMembersMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).ListMembersMetrics(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_ListRepoMembersMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).ListRepoMembersMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_ListRepoMembersMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).ListRepoMembersMetrics(ctx, req.(*RepoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_ListReposMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).ListReposMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_ListReposMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).ListReposMetrics(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetTimeSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeSeriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetTimeSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetTimeSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetTimeSeries(ctx, req.(*TimeSeriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetOrgTimeSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetOrgTimeSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetOrgTimeSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetOrgTimeSeries(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetRepoTimeSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetRepoTimeSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetRepoTimeSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetRepoTimeSeries(ctx, req.(*RepoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetMemberTimeSeries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetMemberTimeSeries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetMemberTimeSeries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetMemberTimeSeries(ctx, req.(*MemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetMemberRanking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RankingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetMemberRanking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetMemberRanking_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetMemberRanking(ctx, req.(*RankingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetRepoRanking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RankingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetRepoRanking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetRepoRanking_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetRepoRanking(ctx, req.(*RankingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetRepoCommitTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetRepoCommitTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetRepoCommitTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetRepoCommitTypes(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsService_GetMemberCommitTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).GetMemberCommitTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsService_GetMemberCommitTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).GetMemberCommitTypes(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MetricsService_ServiceDesc is the grpc.ServiceDesc for MetricsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MetricsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "metrics.v1.MetricsService",
+	HandlerType: (*MetricsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOrgMetrics",
+			Handler:    _MetricsService_GetOrgMetrics_Handler,
+		},
+		{
+			MethodName: "GetMemberMetrics",
+			Handler:    _MetricsService_GetMemberMetrics_Handler,
+		},
+		{
+			MethodName: "GetRepoMetrics",
+			Handler:    _MetricsService_GetRepoMetrics_Handler,
+		},
+		{
+			MethodName: "ListMembersMetrics",
+			Handler:    _MetricsService_ListMembersMetrics_Handler,
+		},
+		{
+			MethodName: "ListRepoMembersMetrics",
+			Handler:    _MetricsService_ListRepoMembersMetrics_Handler,
+		},
+		{
+			MethodName: "ListReposMetrics",
+			Handler:    _MetricsService_ListReposMetrics_Handler,
+		},
+		{
+			MethodName: "GetTimeSeries",
+			Handler:    _MetricsService_GetTimeSeries_Handler,
+		},
+		{
+			MethodName: "GetOrgTimeSeries",
+			Handler:    _MetricsService_GetOrgTimeSeries_Handler,
+		},
+		{
+			MethodName: "GetRepoTimeSeries",
+			Handler:    _MetricsService_GetRepoTimeSeries_Handler,
+		},
+		{
+			MethodName: "GetMemberTimeSeries",
+			Handler:    _MetricsService_GetMemberTimeSeries_Handler,
+		},
+		{
+			MethodName: "GetMemberRanking",
+			Handler:    _MetricsService_GetMemberRanking_Handler,
+		},
+		{
+			MethodName: "GetRepoRanking",
+			Handler:    _MetricsService_GetRepoRanking_Handler,
+		},
+		{
+			MethodName: "GetRepoCommitTypes",
+			Handler:    _MetricsService_GetRepoCommitTypes_Handler,
+		},
+		{
+			MethodName: "GetMemberCommitTypes",
+			Handler:    _MetricsService_GetMemberCommitTypes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "metrics/v1/metrics.proto",
+}